@@ -62,8 +62,18 @@ func (act *Activity) Eval(ctx activity.Context) (done bool, err error) {
 		Topic: act.topic,
 		Value: sarama.StringEncoder(input.Message),
 	}
+	if input.Key != "" {
+		msg.Key = sarama.StringEncoder(input.Key)
+	}
+	for name, value := range input.Headers {
+		msg.Headers = append(msg.Headers, sarama.RecordHeader{Key: []byte(name), Value: []byte(value)})
+	}
+	if input.Partition >= 0 {
+		msg.Partition = input.Partition
+		msg.Metadata = partitionSelection{}
+	}
 
-	partition, offset, err := act.conn.Connection().SendMessage(msg)
+	partition, offset, err := act.conn.send(msg)
 	if err != nil {
 		return false, fmt.Errorf("failed to send Kakfa message for reason [%s]", err.Error())
 	}
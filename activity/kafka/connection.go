@@ -1,13 +1,13 @@
 package kafka
 
 import (
-	"crypto/tls"
 	"crypto/x509"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/Shopify/sarama"
 	"flogo/core/support/log"
@@ -20,12 +20,43 @@ type KafkaConnection struct {
 	kafkaConfig  *sarama.Config
 	brokers      []string
 	syncProducer sarama.SyncProducer
+
+	// a transactional producer runs one transaction at a time
+	txnMu sync.Mutex
 }
 
 func (c *KafkaConnection) Connection() sarama.SyncProducer {
 	return c.syncProducer
 }
 
+// send sends the message, within a transaction when the producer is transactional
+func (c *KafkaConnection) send(msg *sarama.ProducerMessage) (int32, int64, error) {
+
+	if !c.syncProducer.IsTransactional() {
+		return c.syncProducer.SendMessage(msg)
+	}
+
+	c.txnMu.Lock()
+	defer c.txnMu.Unlock()
+
+	if err := c.syncProducer.BeginTxn(); err != nil {
+		return 0, 0, err
+	}
+
+	partition, offset, err := c.syncProducer.SendMessage(msg)
+	if err == nil {
+		err = c.syncProducer.CommitTxn()
+	}
+	if err != nil {
+		if abortErr := c.syncProducer.AbortTxn(); abortErr != nil {
+			return 0, 0, fmt.Errorf("%s, and aborting the transaction failed for reason [%s]", err, abortErr)
+		}
+		return 0, 0, err
+	}
+
+	return partition, offset, nil
+}
+
 func (c *KafkaConnection) Stop() error {
 	return c.syncProducer.Close()
 }
@@ -42,6 +73,11 @@ func getConnectionKey(settings *Settings) string {
 		connKey += settings.User
 	}
 
+	// the producers with different security or delivery settings can't be shared
+	connKey += strings.Join([]string{settings.SASLMechanism, settings.CAFile, settings.CertFile, settings.ServerName,
+		strconv.FormatBool(settings.EnableTLS), strconv.FormatBool(settings.InsecureSkipVerify),
+		settings.Acks, strconv.FormatBool(settings.Idempotent), settings.TransactionalID}, "|")
+
 	return connKey
 }
 
@@ -58,7 +94,6 @@ func getKafkaConnection(logger log.Logger, settings *Settings) (*KafkaConnection
 
 	newConn.kafkaConfig = sarama.NewConfig()
 	newConn.kafkaConfig.Producer.Return.Errors = true
-	newConn.kafkaConfig.Producer.RequiredAcks = sarama.WaitForAll
 	newConn.kafkaConfig.Producer.Retry.Max = 5
 	newConn.kafkaConfig.Producer.Return.Successes = true
 
@@ -81,36 +116,20 @@ func getKafkaConnection(logger log.Logger, settings *Settings) (*KafkaConnection
 	newConn.brokers = brokers
 	logger.Debugf("Kafka brokers: [%v]", brokers)
 
-	//clientKeystore
-	/*
-		Its worth mentioning here that when the keystore for kafka is created it must support RSA keys via
-		the -keyalg RSA option.  If not then there will be ZERO overlap in supported cipher suites with java.
-		see: https://issues.apache.org/jira/browse/KAFKA-3647
-		for more info
-	*/
-	if settings.TrustStore != "" {
-		if trustPool, err := getCerts(logger, settings.TrustStore); err == nil {
-			config := tls.Config{
-				RootCAs:            trustPool,
-				InsecureSkipVerify: true}
-			newConn.kafkaConfig.Net.TLS.Enable = true
-			newConn.kafkaConfig.Net.TLS.Config = &config
-
-			logger.Debugf("Kafka initialized truststore from [%v]", settings.TrustStore)
-		} else {
-			return nil, err
-		}
+	if err := configureTLS(logger, newConn.kafkaConfig, settings); err != nil {
+		return nil, err
 	}
 
 	// SASL
-	if settings.User != "" {
-		if len(settings.Password) == 0 {
-			return nil, fmt.Errorf("password not provided for user: %s", settings.User)
-		}
-		newConn.kafkaConfig.Net.SASL.Enable = true
-		newConn.kafkaConfig.Net.SASL.User = settings.User
-		newConn.kafkaConfig.Net.SASL.Password = settings.Password
-		logger.Debugf("Kafka SASL params initialized; user [%v]", settings.User)
+	if err := configureSASL(newConn.kafkaConfig, settings); err != nil {
+		return nil, err
+	}
+	if newConn.kafkaConfig.Net.SASL.Enable {
+		logger.Debugf("Kafka SASL params initialized; mechanism [%v], user [%v]", newConn.kafkaConfig.Net.SASL.Mechanism, settings.User)
+	}
+
+	if err := configureProducer(newConn.kafkaConfig, settings); err != nil {
+		return nil, err
 	}
 
 	syncProducer, err := sarama.NewSyncProducer(newConn.brokers, newConn.kafkaConfig)
@@ -1,7 +1,8 @@
 module github.com/qingcloudhx/contrib/activity/kafka
 
 require (
-	github.com/Shopify/sarama v1.22.0
+	github.com/Shopify/sarama v1.38.1
 	flogo/core v0.9.0
 	github.com/stretchr/testify v1.3.0
+	github.com/xdg-go/scram v1.1.2
 )
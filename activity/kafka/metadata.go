@@ -6,18 +6,42 @@ import (
 
 type Settings struct {
 	BrokerUrls string `md:"brokerUrls,required"` // The Kafka cluster to connect to
-	User       string `md:"user"`       // If connecting to a SASL enabled port, the user id to use for authentication
-	Password   string `md:"password"`   // If connecting to a SASL enabled port, the password to use for authentication
-	TrustStore string `md:"trustStore"` // If connecting to a TLS secured port, the directory containing the certificates representing the trust chain for the connection. This is usually just the CACert used to sign the server's certificate
-	Topic      string `md:"topic,required"` // The Kafka topic on which to place the message
+	User       string `md:"user"`                // If connecting to a SASL enabled port, the user id to use for authentication
+	Password   string `md:"password"`            // If connecting to a SASL enabled port, the password to use for authentication
+	TrustStore string `md:"trustStore"`          // If connecting to a TLS secured port, the directory containing the certificates representing the trust chain for the connection. This is usually just the CACert used to sign the server's certificate
+	Topic      string `md:"topic,required"`      // The Kafka topic on which to place the message
+
+	SASLMechanism string `md:"saslMechanism"` // The SASL mechanism to use for authentication: PLAIN (default when a user is set), SCRAM-SHA-256, SCRAM-SHA-512 or OAUTHBEARER
+	Token         string `md:"token"`         // The static access token to use with the OAUTHBEARER mechanism
+	TokenURL      string `md:"tokenUrl"`      // The OAuth2 token endpoint used to obtain access tokens with the client credentials grant for the OAUTHBEARER mechanism
+	ClientID      string `md:"clientId"`      // The OAuth2 client id used to obtain access tokens
+	ClientSecret  string `md:"clientSecret"`  // The OAuth2 client secret used to obtain access tokens
+	Scopes        string `md:"scopes"`        // The comma separated OAuth2 scopes to request
+
+	EnableTLS          bool   `md:"enableTLS"`          // Connect to the brokers using TLS, implied by the other TLS settings
+	CAFile             string `md:"caFile"`             // The PEM file of the CA certificates used to verify the brokers
+	CertFile           string `md:"certFile"`           // The PEM file of the client certificate, for brokers requiring client authentication
+	KeyFile            string `md:"keyFile"`            // The PEM file of the client private key
+	ServerName         string `md:"serverName"`         // The server name used to verify the broker certificates, overrides the broker host
+	InsecureSkipVerify bool   `md:"insecureSkipVerify"` // Don't verify the broker certificates, for testing only
+
+	Acks            string `md:"acks"`            // The acknowledgements required for a message to be sent: none, leader or all (default)
+	Idempotent      bool   `md:"idempotent"`      // Enable the idempotent producer, the retried messages are written exactly once and in order
+	TransactionalID string `md:"transactionalId"` // The transactional id of the producer, each message is written in a transaction, implies idempotent
 }
 type Input struct {
-	Message string `md:"message,required"` // The message to send
+	Message   string            `md:"message,required"` // The message to send
+	Key       string            `md:"key"`              // The key of the message, the messages with the same key are sent to the same partition
+	Headers   map[string]string `md:"headers"`          // The headers of the message
+	Partition int32             `md:"partition"`        // The partition to send the message to, by default it is selected by the hash of the key
 }
 
 func (i *Input) ToMap() map[string]interface{} {
 	return map[string]interface{}{
-		"message": i.Message,
+		"message":   i.Message,
+		"key":       i.Key,
+		"headers":   i.Headers,
+		"partition": i.Partition,
 	}
 }
 
@@ -25,7 +49,28 @@ func (i *Input) FromMap(values map[string]interface{}) error {
 
 	var err error
 	i.Message, err = coerce.ToString(values["message"])
-	return err
+	if err != nil {
+		return err
+	}
+	i.Key, err = coerce.ToString(values["key"])
+	if err != nil {
+		return err
+	}
+	i.Headers, err = coerce.ToParams(values["headers"])
+	if err != nil {
+		return err
+	}
+
+	// the partition is selected by the hash of the key unless one is set
+	i.Partition = -1
+	if p, ok := values["partition"]; ok && p != nil && p != "" {
+		i.Partition, err = coerce.ToInt32(p)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 type Output struct {
@@ -0,0 +1,87 @@
+package kafka
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Shopify/sarama"
+)
+
+const (
+	AcksNone   = "none"
+	AcksLeader = "leader"
+	AcksAll    = "all"
+)
+
+// toRequiredAcks converts the acks setting to the sarama required acks
+func toRequiredAcks(acks string) (sarama.RequiredAcks, error) {
+	switch strings.ToLower(acks) {
+	case "", AcksAll:
+		return sarama.WaitForAll, nil
+	case AcksLeader:
+		return sarama.WaitForLocal, nil
+	case AcksNone:
+		return sarama.NoResponse, nil
+	}
+
+	return 0, fmt.Errorf("unsupported acks '%s', expected none, leader or all", acks)
+}
+
+// configureProducer configures the acknowledgements, idempotence and transactions of the producer according to the settings
+func configureProducer(config *sarama.Config, settings *Settings) error {
+
+	acks, err := toRequiredAcks(settings.Acks)
+	if err != nil {
+		return err
+	}
+	config.Producer.RequiredAcks = acks
+	config.Producer.Partitioner = newPartitioner
+
+	// transactions require the idempotent producer
+	idempotent := settings.Idempotent || settings.TransactionalID != ""
+	config.Producer.Transaction.ID = settings.TransactionalID
+
+	if idempotent {
+		if acks != sarama.WaitForAll {
+			return fmt.Errorf("the idempotent producer requires acks from all the replicas")
+		}
+		config.Producer.Idempotent = true
+		config.Net.MaxOpenRequests = 1
+	}
+
+	if idempotent && !config.Version.IsAtLeast(sarama.V0_11_0_0) {
+		// idempotence and transactions require the v3 produce request
+		config.Version = sarama.V0_11_0_0
+	}
+
+	return nil
+}
+
+// partitionSelection is the metadata of a message sent to a partition selected in the input
+type partitionSelection struct{}
+
+// partitioner sends the messages to the partition selected in the input, the other messages
+// are partitioned by the hash of their key
+type partitioner struct {
+	hash sarama.Partitioner
+}
+
+func newPartitioner(topic string) sarama.Partitioner {
+	return &partitioner{hash: sarama.NewHashPartitioner(topic)}
+}
+
+// Partition implements sarama.Partitioner.Partition
+func (p *partitioner) Partition(message *sarama.ProducerMessage, numPartitions int32) (int32, error) {
+	if _, ok := message.Metadata.(partitionSelection); ok {
+		if message.Partition < 0 || message.Partition >= numPartitions {
+			return -1, fmt.Errorf("partition %d doesn't exist, the topic has %d partitions", message.Partition, numPartitions)
+		}
+		return message.Partition, nil
+	}
+	return p.hash.Partition(message, numPartitions)
+}
+
+// RequiresConsistency implements sarama.Partitioner.RequiresConsistency
+func (p *partitioner) RequiresConsistency() bool {
+	return true
+}
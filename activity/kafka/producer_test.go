@@ -0,0 +1,63 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigureProducer(t *testing.T) {
+
+	config := sarama.NewConfig()
+	err := configureProducer(config, &Settings{Acks: "leader"})
+	assert.Nil(t, err)
+	assert.Equal(t, sarama.WaitForLocal, config.Producer.RequiredAcks)
+	assert.False(t, config.Producer.Idempotent)
+
+	config = sarama.NewConfig()
+	err = configureProducer(config, &Settings{TransactionalID: "orders"})
+	assert.Nil(t, err)
+	assert.True(t, config.Producer.Idempotent)
+	assert.Equal(t, "orders", config.Producer.Transaction.ID)
+	assert.Equal(t, 1, config.Net.MaxOpenRequests)
+	assert.True(t, config.Version.IsAtLeast(sarama.V0_11_0_0))
+
+	err = configureProducer(sarama.NewConfig(), &Settings{Acks: "none", Idempotent: true})
+	assert.NotNil(t, err)
+
+	err = configureProducer(sarama.NewConfig(), &Settings{Acks: "some"})
+	assert.NotNil(t, err)
+}
+
+func TestPartitioner(t *testing.T) {
+
+	p := newPartitioner("events")
+
+	partition, err := p.Partition(&sarama.ProducerMessage{Partition: 2, Metadata: partitionSelection{}}, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(2), partition)
+
+	_, err = p.Partition(&sarama.ProducerMessage{Partition: 3, Metadata: partitionSelection{}}, 3)
+	assert.NotNil(t, err)
+
+	msg := &sarama.ProducerMessage{Key: sarama.StringEncoder("tenant-1")}
+	first, err := p.Partition(msg, 3)
+	assert.Nil(t, err)
+	second, err := p.Partition(msg, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, first, second)
+}
+
+func TestInput_FromMap(t *testing.T) {
+
+	input := &Input{}
+	err := input.FromMap(map[string]interface{}{"message": "hello"})
+	assert.Nil(t, err)
+	assert.Equal(t, int32(-1), input.Partition)
+
+	err = input.FromMap(map[string]interface{}{"message": "hello", "partition": 0, "headers": map[string]interface{}{"trace-id": "abc"}})
+	assert.Nil(t, err)
+	assert.Equal(t, int32(0), input.Partition)
+	assert.Equal(t, map[string]string{"trace-id": "abc"}, input.Headers)
+}
@@ -0,0 +1,76 @@
+package kafka
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+
+	"flogo/core/support/log"
+	"github.com/Shopify/sarama"
+)
+
+// configureTLS configures the TLS connection to the brokers according to the settings
+func configureTLS(logger log.Logger, config *sarama.Config, settings *Settings) error {
+
+	if !settings.EnableTLS && settings.TrustStore == "" && settings.CAFile == "" && settings.CertFile == "" {
+		return nil
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName:         settings.ServerName,
+		InsecureSkipVerify: settings.InsecureSkipVerify,
+	}
+
+	//clientKeystore
+	/*
+		Its worth mentioning here that when the keystore for kafka is created it must support RSA keys via
+		the -keyalg RSA option.  If not then there will be ZERO overlap in supported cipher suites with java.
+		see: https://issues.apache.org/jira/browse/KAFKA-3647
+		for more info
+	*/
+	if settings.TrustStore != "" {
+		trustPool, err := getCerts(logger, settings.TrustStore)
+		if err != nil {
+			return err
+		}
+		tlsConfig.RootCAs = trustPool
+		// the trust store has always been used without verifying the host name
+		tlsConfig.InsecureSkipVerify = true
+
+		logger.Debugf("Kafka initialized truststore from [%v]", settings.TrustStore)
+	}
+
+	if settings.CAFile != "" {
+		pem, err := ioutil.ReadFile(settings.CAFile)
+		if err != nil {
+			return fmt.Errorf("unable to read CA file [%s]: %v", settings.CAFile, err)
+		}
+		if tlsConfig.RootCAs == nil {
+			tlsConfig.RootCAs = x509.NewCertPool()
+		}
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in CA file [%s]", settings.CAFile)
+		}
+	}
+
+	if settings.CertFile != "" || settings.KeyFile != "" {
+		if settings.CertFile == "" || settings.KeyFile == "" {
+			return fmt.Errorf("both cert file and key file must be specified for client certificate authentication")
+		}
+		cert, err := tls.LoadX509KeyPair(settings.CertFile, settings.KeyFile)
+		if err != nil {
+			return fmt.Errorf("unable to load client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	if tlsConfig.InsecureSkipVerify && settings.TrustStore == "" {
+		logger.Warnf("Kafka broker certificates are not verified")
+	}
+
+	config.Net.TLS.Enable = true
+	config.Net.TLS.Config = tlsConfig
+
+	return nil
+}
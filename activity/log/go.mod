@@ -1,3 +1,11 @@
 module github.com/qingcloudhx/contrib/activity/log
 
+go 1.27.1
+
 require github.com/stretchr/testify v1.3.0
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	github.com/stretchr/objx v0.1.0 // indirect
+)
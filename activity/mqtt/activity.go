@@ -0,0 +1,127 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"flogo/core/activity"
+	"flogo/core/data/coerce"
+	"flogo/core/data/metadata"
+)
+
+func init() {
+	_ = activity.Register(&Activity{}, New)
+}
+
+var activityMd = activity.ToMetadata(&Settings{}, &Input{}, &Output{})
+
+// Activity is an activity publishing messages to a MQTT broker
+type Activity struct {
+	conn   *Connection
+	topic  string
+	qos    byte
+	retain bool
+}
+
+// New creates a new MQTT activity
+func New(ctx activity.InitContext) (activity.Activity, error) {
+	settings := &Settings{}
+	err := metadata.MapToStruct(ctx.Settings(), settings, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if settings.QoS < 0 || settings.QoS > 2 {
+		return nil, fmt.Errorf("invalid QoS [%d], the QoS is 0, 1 or 2", settings.QoS)
+	}
+
+	conn, err := getConnection(ctx.Logger(), settings)
+	if err != nil {
+		return nil, err
+	}
+
+	act := &Activity{conn: conn, topic: settings.Topic, qos: byte(settings.QoS), retain: settings.Retain}
+	return act, nil
+}
+
+// Metadata returns the metadata of the MQTT activity
+func (*Activity) Metadata() *activity.Metadata {
+	return activityMd
+}
+
+// Eval publishes the message
+func (act *Activity) Eval(ctx activity.Context) (done bool, err error) {
+	input := &Input{}
+
+	err = ctx.GetInputObject(input)
+	if err != nil {
+		return false, err
+	}
+
+	topic := input.Topic
+	if topic == "" {
+		topic = act.topic
+	}
+	topic, err = buildTopic(topic, input.TopicParams)
+	if err != nil {
+		return false, err
+	}
+
+	payload, err := toPayload(input.Message)
+	if err != nil {
+		return false, err
+	}
+
+	ctx.Logger().Debugf("Publishing MQTT message on topic [%s]", topic)
+
+	id, err := act.conn.publish(topic, act.qos, act.retain, payload)
+	if err != nil {
+		return false, fmt.Errorf("failed to publish MQTT message on topic [%s] for reason [%s]", topic, err.Error())
+	}
+
+	err = ctx.SetOutputObject(&Output{Topic: topic, MessageID: id})
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// buildTopic replaces the {name} placeholders of a topic with the parameters
+func buildTopic(topic string, params map[string]string) (string, error) {
+	if topic == "" {
+		return "", fmt.Errorf("no topic to publish to")
+	}
+
+	for name, value := range params {
+		topic = strings.Replace(topic, "{"+name+"}", value, -1)
+	}
+
+	if i := strings.Index(topic, "{"); i >= 0 && strings.Contains(topic[i:], "}") {
+		return "", fmt.Errorf("topic [%s] has a placeholder without parameter", topic)
+	}
+	if strings.ContainsAny(topic, "+#") {
+		return "", fmt.Errorf("topic [%s] contains a wildcard", topic)
+	}
+
+	return topic, nil
+}
+
+// toPayload converts a message to the payload of a MQTT message, objects are encoded as JSON
+func toPayload(message interface{}) ([]byte, error) {
+	switch m := message.(type) {
+	case nil:
+		return nil, fmt.Errorf("no message to publish")
+	case []byte:
+		return m, nil
+	case map[string]interface{}, []interface{}:
+		return json.Marshal(m)
+	default:
+		s, err := coerce.ToString(m)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(s), nil
+	}
+}
@@ -0,0 +1,118 @@
+package mqtt
+
+import (
+	"strings"
+	"testing"
+
+	"flogo/core/activity"
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegister(t *testing.T) {
+
+	ref := activity.GetRef(&Activity{})
+	act := activity.Get(ref)
+
+	assert.NotNil(t, act)
+}
+
+func TestBuildTopic(t *testing.T) {
+
+	topic, err := buildTopic("devices/{deviceId}/commands/{command}", map[string]string{"deviceId": "sensor-1", "command": "reboot"})
+	assert.Nil(t, err)
+	assert.Equal(t, "devices/sensor-1/commands/reboot", topic)
+
+	topic, err = buildTopic("devices/all", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "devices/all", topic)
+
+	_, err = buildTopic("devices/{deviceId}/commands", nil)
+	assert.NotNil(t, err)
+
+	_, err = buildTopic("devices/+/commands", nil)
+	assert.NotNil(t, err)
+
+	_, err = buildTopic("", nil)
+	assert.NotNil(t, err)
+}
+
+func TestToPayload(t *testing.T) {
+
+	payload, err := toPayload("on")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("on"), payload)
+
+	payload, err = toPayload(map[string]interface{}{"temperature": 21.5})
+	assert.Nil(t, err)
+	assert.Equal(t, []byte(`{"temperature":21.5}`), payload)
+
+	payload, err = toPayload(42)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("42"), payload)
+
+	_, err = toPayload(nil)
+	assert.NotNil(t, err)
+}
+
+func TestGetTLSConfig(t *testing.T) {
+
+	config, err := getTLSConfig(&Settings{Broker: "tcp://localhost:1883"})
+	assert.Nil(t, err)
+	assert.Nil(t, config)
+
+	config, err = getTLSConfig(&Settings{Broker: "ssl://broker:8883", ServerName: "mqtt.internal"})
+	assert.Nil(t, err)
+	assert.Equal(t, "mqtt.internal", config.ServerName)
+
+	_, err = getTLSConfig(&Settings{Broker: "ssl://broker:8883", CertFile: "client.pem"})
+	assert.NotNil(t, err)
+}
+
+func TestRotateBrokers(t *testing.T) {
+
+	options := paho.NewClientOptions().AddBroker("tcp://broker-1:1883").AddBroker("tcp://broker-2:1883").AddBroker("tcp://broker-3:1883")
+	hosts := func() []string {
+		var hosts []string
+		for _, server := range options.Servers {
+			hosts = append(hosts, server.Host)
+		}
+		return hosts
+	}
+
+	rotateBrokers(nil, options)
+	assert.Equal(t, []string{"broker-2:1883", "broker-3:1883", "broker-1:1883"}, hosts())
+	rotateBrokers(nil, options)
+	assert.Equal(t, []string{"broker-3:1883", "broker-1:1883", "broker-2:1883"}, hosts())
+}
+
+func TestNewClientID(t *testing.T) {
+
+	id := newClientID()
+	assert.True(t, strings.HasPrefix(id, "flogo-"))
+	assert.True(t, len(id) <= 23)
+	assert.NotEqual(t, id, newClientID())
+}
+
+func TestGetBrokers(t *testing.T) {
+
+	settings := &Settings{Broker: "tcp://broker-1:1883, tcp://broker-2:1883,tcp://broker-3:1883"}
+	brokers, err := getBrokers(settings)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"tcp://broker-1:1883", "tcp://broker-2:1883", "tcp://broker-3:1883"}, brokers)
+
+	settings.BrokerPolicy = PolicyRoundRobin
+	nextBroker = 0
+	brokers, err = getBrokers(settings)
+	assert.Nil(t, err)
+	assert.Equal(t, "tcp://broker-1:1883", brokers[0])
+	brokers, err = getBrokers(settings)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"tcp://broker-2:1883", "tcp://broker-3:1883", "tcp://broker-1:1883"}, brokers)
+
+	_, err = getBrokers(&Settings{Broker: " , "})
+	assert.NotNil(t, err)
+
+	_, err = getBrokers(&Settings{Broker: "tcp://broker-1:1883", BrokerPolicy: "random"})
+	assert.NotNil(t, err)
+}
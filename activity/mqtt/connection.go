@@ -0,0 +1,230 @@
+package mqtt
+
+import (
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"flogo/core/support/log"
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+const defaultTimeout = 30 * time.Second
+
+const (
+	PolicyFailover   = "failover"
+	PolicyRoundRobin = "roundRobin"
+)
+
+// the activities publishing with the same connection settings share their connection
+var (
+	connectionsMu sync.Mutex
+	connections   = make(map[string]*Connection)
+
+	// the index of the first broker of the next round robin connection
+	nextBroker int
+)
+
+// Connection is a connection to a broker shared by the activities
+type Connection struct {
+	client  paho.Client
+	timeout time.Duration
+}
+
+// publish publishes a message and waits for its confirmation, it returns the id of the message
+func (c *Connection) publish(topic string, qos byte, retain bool, payload []byte) (int, error) {
+
+	token := c.client.Publish(topic, qos, retain, payload)
+	if !token.WaitTimeout(c.timeout) {
+		return 0, fmt.Errorf("the broker didn't confirm the message within %s", c.timeout)
+	}
+	if err := token.Error(); err != nil {
+		return 0, err
+	}
+
+	if t, ok := token.(*paho.PublishToken); ok {
+		return int(t.MessageID()), nil
+	}
+	return 0, nil
+}
+
+func (c *Connection) Stop() {
+	c.client.Disconnect(250)
+}
+
+func getConnectionKey(settings *Settings) string {
+	return strings.Join([]string{settings.Broker, settings.BrokerPolicy, settings.ClientID, settings.Username, strconv.FormatBool(settings.CleanSession),
+		settings.CAFile, settings.CertFile, settings.ServerName, strconv.FormatBool(settings.InsecureSkipVerify),
+		settings.Timeout, settings.WillTopic, settings.WillPayload, strconv.Itoa(settings.WillQoS),
+		strconv.FormatBool(settings.WillRetain)}, "|")
+}
+
+func getConnection(logger log.Logger, settings *Settings) (*Connection, error) {
+
+	connectionsMu.Lock()
+	defer connectionsMu.Unlock()
+
+	connKey := getConnectionKey(settings)
+
+	if conn, ok := connections[connKey]; ok {
+		logger.Debugf("Reusing cached MQTT connection [%s]", settings.Broker)
+		return conn, nil
+	}
+
+	conn := &Connection{timeout: defaultTimeout}
+	if settings.Timeout != "" {
+		timeout, err := time.ParseDuration(settings.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse timeout: %s", err.Error())
+		}
+		conn.timeout = timeout
+	}
+
+	brokers, err := getBrokers(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	options := paho.NewClientOptions()
+	for _, broker := range brokers {
+		// the client connects to the first available broker, in order
+		options.AddBroker(broker)
+	}
+	clientID := settings.ClientID
+	if clientID == "" {
+		// the brokers reject an empty client id with a persistent session, and may reject it with a clean one
+		clientID = newClientID()
+		logger.Debugf("Using generated MQTT client id [%s]", clientID)
+	}
+	options.SetClientID(clientID)
+	options.SetUsername(settings.Username)
+	options.SetPassword(settings.Password)
+	options.SetCleanSession(settings.CleanSession)
+	options.SetAutoReconnect(true)
+	options.SetConnectTimeout(conn.timeout)
+	if settings.BrokerPolicy == PolicyRoundRobin {
+		options.SetReconnectingHandler(rotateBrokers)
+	}
+
+	if settings.WillTopic != "" {
+		if settings.WillQoS < 0 || settings.WillQoS > 2 {
+			return nil, fmt.Errorf("invalid last will QoS [%d], the QoS is 0, 1 or 2", settings.WillQoS)
+		}
+		options.SetWill(settings.WillTopic, settings.WillPayload, byte(settings.WillQoS), settings.WillRetain)
+	}
+
+	tlsConfig, err := getTLSConfig(settings)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		options.SetTLSConfig(tlsConfig)
+		if settings.InsecureSkipVerify {
+			logger.Warnf("MQTT broker certificate is not verified")
+		}
+	}
+
+	conn.client = paho.NewClient(options)
+
+	token := conn.client.Connect()
+	if !token.WaitTimeout(conn.timeout) {
+		return nil, fmt.Errorf("unable to connect to MQTT brokers [%s] within %s", settings.Broker, conn.timeout)
+	}
+	if err := token.Error(); err != nil {
+		return nil, fmt.Errorf("unable to connect to MQTT brokers [%s]: %s", settings.Broker, err.Error())
+	}
+
+	connections[connKey] = conn
+	logger.Debugf("Caching MQTT connection [%s]", settings.Broker)
+
+	return conn, nil
+}
+
+// getBrokers returns the brokers in the order they are tried according to the broker policy, the lock must be held
+func getBrokers(settings *Settings) ([]string, error) {
+
+	var brokers []string
+	for _, broker := range strings.Split(settings.Broker, ",") {
+		if broker = strings.TrimSpace(broker); broker != "" {
+			brokers = append(brokers, broker)
+		}
+	}
+	if len(brokers) == 0 {
+		return nil, fmt.Errorf("no MQTT broker to connect to")
+	}
+
+	switch settings.BrokerPolicy {
+	case "", PolicyFailover:
+		return brokers, nil
+	case PolicyRoundRobin:
+		first := nextBroker % len(brokers)
+		nextBroker++
+		return append(brokers[first:], brokers[:first]...), nil
+	default:
+		return nil, fmt.Errorf("unsupported broker policy [%s], the policies are failover and roundRobin", settings.BrokerPolicy)
+	}
+}
+
+// newClientID returns a unique client id, within the 23 characters every broker accepts
+func newClientID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return "flogo-" + hex.EncodeToString(b)
+}
+
+// rotateBrokers moves the first broker to the end of the list before each reconnection attempt, so that the
+// round robin connections try the brokers in turn
+func rotateBrokers(_ paho.Client, options *paho.ClientOptions) {
+
+	if len(options.Servers) < 2 {
+		return
+	}
+	servers := make([]*url.URL, 0, len(options.Servers))
+	servers = append(servers, options.Servers[1:]...)
+	options.Servers = append(servers, options.Servers[0])
+}
+
+// getTLSConfig returns the TLS configuration of the connection to the broker, nil if there are no TLS settings
+func getTLSConfig(settings *Settings) (*tls.Config, error) {
+
+	if settings.CAFile == "" && settings.CertFile == "" && settings.ServerName == "" && !settings.InsecureSkipVerify {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName:         settings.ServerName,
+		InsecureSkipVerify: settings.InsecureSkipVerify,
+	}
+
+	if settings.CAFile != "" {
+		pem, err := ioutil.ReadFile(settings.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read CA file [%s]: %v", settings.CAFile, err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file [%s]", settings.CAFile)
+		}
+	}
+
+	if settings.CertFile != "" || settings.KeyFile != "" {
+		if settings.CertFile == "" || settings.KeyFile == "" {
+			return nil, fmt.Errorf("both cert file and key file must be specified for client certificate authentication")
+		}
+		cert, err := tls.LoadX509KeyPair(settings.CertFile, settings.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
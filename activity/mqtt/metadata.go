@@ -0,0 +1,87 @@
+package mqtt
+
+import (
+	"flogo/core/data/coerce"
+)
+
+type Settings struct {
+	Broker       string `md:"broker,required"` // The comma separated brokers to connect to (ex. tcp://localhost:1883), the schemes are tcp, ssl, ws and wss
+	BrokerPolicy string `md:"brokerPolicy"`    // The order the brokers are tried in: failover (default), in the order of the list, or roundRobin, starting with the next broker for each new connection and each reconnection attempt
+	ClientID     string `md:"clientId"`        // The client id of the connection, generated if not specified
+	Username     string `md:"username"`        // The user name used to authenticate
+	Password     string `md:"password"`        // The password used to authenticate
+	CleanSession bool   `md:"cleanSession"`    // Start a clean session, the broker discards the state of a previous session with the same client id
+
+	CAFile             string `md:"caFile"`             // The PEM file of the CA certificates used to verify the broker
+	CertFile           string `md:"certFile"`           // The PEM file of the client certificate, for brokers requiring client authentication
+	KeyFile            string `md:"keyFile"`            // The PEM file of the client private key
+	ServerName         string `md:"serverName"`         // The server name used to verify the broker certificate, overrides the broker host
+	InsecureSkipVerify bool   `md:"insecureSkipVerify"` // Don't verify the broker certificate, for testing only
+
+	WillTopic   string `md:"willTopic"`   // The topic of the last will message, published by the broker when the connection is lost unexpectedly
+	WillPayload string `md:"willPayload"` // The payload of the last will message
+	WillQoS     int    `md:"willQos"`     // The quality of service of the last will message: 0 (default), 1 or 2
+	WillRetain  bool   `md:"willRetain"`  // Retain the last will message, so that the future subscribers know the client is offline
+
+	Topic   string `md:"topic"`   // The topic to publish to, with {name} placeholders replaced by the topic parameters (ex. devices/{deviceId}/commands)
+	QoS     int    `md:"qos"`     // The quality of service of the messages: 0 (default), 1 or 2
+	Retain  bool   `md:"retain"`  // Publish retained messages, the broker keeps the last message of the topic for the future subscribers
+	Timeout string `md:"timeout"` // The time to wait for the broker to confirm a message (ex. 10s), 30s if not specified
+}
+
+type Input struct {
+	Message     interface{}       `md:"message,required"` // The message to publish, objects are published as JSON
+	Topic       string            `md:"topic"`            // The topic to publish to, overrides the topic setting
+	TopicParams map[string]string `md:"topicParams"`      // The values of the {name} placeholders of the topic
+}
+
+func (i *Input) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"message":     i.Message,
+		"topic":       i.Topic,
+		"topicParams": i.TopicParams,
+	}
+}
+
+func (i *Input) FromMap(values map[string]interface{}) error {
+
+	var err error
+	i.Message = values["message"]
+	i.Topic, err = coerce.ToString(values["topic"])
+	if err != nil {
+		return err
+	}
+	i.TopicParams, err = coerce.ToParams(values["topicParams"])
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type Output struct {
+	Topic     string `md:"topic"`     // The topic the message was published to
+	MessageID int    `md:"messageId"` // The id of the message confirmed by the broker, 0 for the messages with a QoS of 0
+}
+
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"topic":     o.Topic,
+		"messageId": o.MessageID,
+	}
+}
+
+func (o *Output) FromMap(values map[string]interface{}) error {
+
+	var err error
+	o.Topic, err = coerce.ToString(values["topic"])
+	if err != nil {
+		return err
+	}
+	o.MessageID, err = coerce.ToInt(values["messageId"])
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,62 @@
+package wspush
+
+import (
+	"fmt"
+
+	"flogo/core/activity"
+	"github.com/qingcloudhx/contrib/trigger/websocket"
+)
+
+func init() {
+	_ = activity.Register(&Activity{})
+}
+
+var activityMd = activity.ToMetadata(&Input{}, &Output{})
+
+// Activity sends a message to a connection or a broadcast group of the WebSocket trigger
+// inputs : {connectionId, group, message}
+// outputs: {sent}
+type Activity struct {
+}
+
+// Metadata returns the activity's metadata
+func (a *Activity) Metadata() *activity.Metadata {
+	return activityMd
+}
+
+// Eval sends the message to the connection, or broadcasts it to the group
+func (a *Activity) Eval(ctx activity.Context) (done bool, err error) {
+
+	input := &Input{}
+	err = ctx.GetInputObject(input)
+	if err != nil {
+		return false, err
+	}
+
+	if input.Message == nil {
+		return false, fmt.Errorf("message must be specified")
+	}
+
+	output := &Output{}
+
+	if input.ConnectionID != "" {
+		err = websocket.Send(input.ConnectionID, input.Message)
+		if err != nil {
+			return false, err
+		}
+		output.Sent = 1
+	} else {
+		output.Sent = websocket.Broadcast(input.Group, input.Message)
+	}
+
+	if logger := ctx.Logger(); logger.DebugEnabled() {
+		logger.Debugf("Sent message to %d WebSocket connections", output.Sent)
+	}
+
+	err = ctx.SetOutputObject(output)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
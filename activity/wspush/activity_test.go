@@ -0,0 +1,41 @@
+package wspush
+
+import (
+	"testing"
+
+	"flogo/core/activity"
+	"flogo/core/support/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegister(t *testing.T) {
+
+	ref := activity.GetRef(&Activity{})
+	act := activity.Get(ref)
+
+	assert.NotNil(t, act)
+}
+
+func TestEval(t *testing.T) {
+
+	act := &Activity{}
+	tc := test.NewActivityContext(act.Metadata())
+
+	// no open connection in the group
+	tc.SetInput("group", "lobby")
+	tc.SetInput("message", "hello")
+	done, err := act.Eval(tc)
+	assert.True(t, done)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, tc.GetOutput("sent"))
+
+	tc = test.NewActivityContext(act.Metadata())
+	tc.SetInput("connectionId", "unknown")
+	tc.SetInput("message", "hello")
+	_, err = act.Eval(tc)
+	assert.NotNil(t, err)
+
+	tc = test.NewActivityContext(act.Metadata())
+	_, err = act.Eval(tc)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,7 @@
+module github.com/qingcloudhx/contrib/activity/wspush
+
+require (
+	flogo/core v0.9.0
+	github.com/qingcloudhx/contrib/trigger/websocket v0.0.0-20261018011144-4bb4278145da
+	github.com/stretchr/testify v1.3.0
+)
@@ -0,0 +1,56 @@
+package wspush
+
+import (
+	"flogo/core/data/coerce"
+)
+
+type Input struct {
+	ConnectionID string      `md:"connectionId"`     // The id of the connection to send the message to
+	Group        string      `md:"group"`            // The broadcast group to send the message to, when no connection id is specified
+	Message      interface{} `md:"message,required"` // The message to send, objects are sent as JSON
+}
+
+type Output struct {
+	Sent int `md:"sent"` // The number of connections the message was sent to
+}
+
+func (i *Input) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"connectionId": i.ConnectionID,
+		"group":        i.Group,
+		"message":      i.Message,
+	}
+}
+
+func (i *Input) FromMap(values map[string]interface{}) error {
+
+	var err error
+	i.ConnectionID, err = coerce.ToString(values["connectionId"])
+	if err != nil {
+		return err
+	}
+	i.Group, err = coerce.ToString(values["group"])
+	if err != nil {
+		return err
+	}
+	i.Message = values["message"]
+
+	return nil
+}
+
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"sent": o.Sent,
+	}
+}
+
+func (o *Output) FromMap(values map[string]interface{}) error {
+
+	var err error
+	o.Sent, err = coerce.ToInt(values["sent"])
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
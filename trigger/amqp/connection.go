@@ -0,0 +1,71 @@
+package amqp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// dial connects to the broker of the settings
+func dial(settings *Settings) (*amqp.Connection, error) {
+
+	brokerURL, err := url.Parse(settings.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url '%s': %v", settings.URL, err)
+	}
+
+	if settings.Username != "" {
+		brokerURL.User = url.UserPassword(settings.Username, settings.Password)
+	}
+
+	tlsConfig, err := getTLSConfig(settings)
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil && brokerURL.Scheme == "amqp" {
+		brokerURL.Scheme = "amqps"
+	}
+
+	return amqp.DialConfig(brokerURL.String(), amqp.Config{TLSClientConfig: tlsConfig, Properties: amqp.Table{"connection_name": "flogo"}})
+}
+
+// getTLSConfig returns the TLS configuration of the connection, nil if the TLS settings aren't used
+func getTLSConfig(settings *Settings) (*tls.Config, error) {
+
+	if settings.CAFile == "" && settings.CertFile == "" && settings.ServerName == "" && !settings.InsecureSkipVerify {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName:         settings.ServerName,
+		InsecureSkipVerify: settings.InsecureSkipVerify,
+	}
+
+	if settings.CAFile != "" {
+		pem, err := ioutil.ReadFile(settings.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read CA file [%s]: %v", settings.CAFile, err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file [%s]", settings.CAFile)
+		}
+	}
+
+	if settings.CertFile != "" || settings.KeyFile != "" {
+		if settings.CertFile == "" || settings.KeyFile == "" {
+			return nil, fmt.Errorf("both cert file and key file must be specified for client certificate authentication")
+		}
+		cert, err := tls.LoadX509KeyPair(settings.CertFile, settings.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,189 @@
+package amqp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"strings"
+	"time"
+
+	"flogo/core/data/coerce"
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// channel is the part of the AMQP channel used by a consumer
+type channel interface {
+	Qos(prefetchCount, prefetchSize int, global bool) error
+	ExchangeDeclare(name, kind string, durable, autoDelete, internal, noWait bool, args amqp.Table) error
+	QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error)
+	QueueBind(name, key, exchange string, noWait bool, args amqp.Table) error
+	Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error)
+	PublishWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error
+}
+
+// consumer consumes the messages of the queue of a handler
+type consumer struct {
+	handler  trigger.Handler
+	settings *HandlerSettings
+	logger   log.Logger
+}
+
+// setup declares the exchange and the queue of the handler, binds them and starts consuming
+func (c *consumer) setup(ch channel) (<-chan amqp.Delivery, error) {
+
+	s := c.settings
+
+	if s.Prefetch > 0 {
+		if err := ch.Qos(s.Prefetch, 0, false); err != nil {
+			return nil, fmt.Errorf("unable to set prefetch: %v", err)
+		}
+	}
+
+	if s.Exchange != "" && !strings.HasPrefix(s.Exchange, "amq.") {
+		kind := s.ExchangeType
+		if kind == "" {
+			kind = amqp.ExchangeDirect
+		}
+		if err := ch.ExchangeDeclare(s.Exchange, kind, s.ExchangeDurable, false, false, false, nil); err != nil {
+			return nil, fmt.Errorf("unable to declare exchange '%s': %v", s.Exchange, err)
+		}
+	}
+
+	queue, err := ch.QueueDeclare(s.Queue, s.Durable, s.AutoDelete, s.Exclusive, false, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to declare queue '%s': %v", s.Queue, err)
+	}
+
+	if s.Exchange != "" {
+		keys := splitList(s.RoutingKeys)
+		if len(keys) == 0 {
+			keys = []string{""}
+		}
+		for _, key := range keys {
+			if err := ch.QueueBind(queue.Name, key, s.Exchange, false, nil); err != nil {
+				return nil, fmt.Errorf("unable to bind queue '%s' to exchange '%s' with key '%s': %v", queue.Name, s.Exchange, key, err)
+			}
+		}
+	}
+
+	deliveries, err := ch.Consume(queue.Name, "", s.AutoAck, false, false, false, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to consume from queue '%s': %v", queue.Name, err)
+	}
+
+	c.logger.Infof("Consuming messages from queue '%s'", queue.Name)
+
+	return deliveries, nil
+}
+
+// consume handles the deliveries, one at a time, until the channel is closed
+func (c *consumer) consume(ch channel, deliveries <-chan amqp.Delivery) {
+	for d := range deliveries {
+		c.handle(ch, d)
+	}
+}
+
+// handle invokes the action for a delivery and acknowledges it once the action succeeded,
+// the delivery is requeued or rejected when it fails
+func (c *consumer) handle(ch channel, d amqp.Delivery) {
+
+	results, err := c.handler.Handle(context.Background(), toOutput(d))
+	if err != nil {
+		c.logger.Errorf("Error handling message from queue '%s': %v", c.settings.Queue, err)
+		if !c.settings.AutoAck {
+			if err := d.Nack(false, c.settings.Requeue); err != nil {
+				c.logger.Errorf("Error rejecting message: %v", err)
+			}
+		}
+		return
+	}
+
+	if d.ReplyTo != "" {
+		reply := &Reply{}
+		if err := reply.FromMap(results); err == nil && reply.Data != nil {
+			if err := c.reply(ch, d, reply.Data); err != nil {
+				c.logger.Errorf("Error publishing reply to '%s': %v", d.ReplyTo, err)
+			}
+		}
+	}
+
+	if !c.settings.AutoAck {
+		if err := d.Ack(false); err != nil {
+			c.logger.Errorf("Error acknowledging message: %v", err)
+		}
+	}
+}
+
+// reply publishes the reply to the replyTo queue of the delivery, with its correlation id
+func (c *consumer) reply(ch channel, d amqp.Delivery, data interface{}) error {
+
+	msg := amqp.Publishing{CorrelationId: d.CorrelationId, Timestamp: time.Now()}
+
+	switch t := data.(type) {
+	case string:
+		msg.ContentType = "text/plain"
+		msg.Body = []byte(t)
+	case []byte:
+		msg.ContentType = "application/octet-stream"
+		msg.Body = t
+	default:
+		body, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		msg.ContentType = "application/json"
+		msg.Body = body
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return ch.PublishWithContext(ctx, "", d.ReplyTo, false, false, msg)
+}
+
+func toOutput(d amqp.Delivery) *Output {
+
+	out := &Output{
+		Message:       string(d.Body),
+		Exchange:      d.Exchange,
+		RoutingKey:    d.RoutingKey,
+		Headers:       make(map[string]string, len(d.Headers)),
+		ContentType:   d.ContentType,
+		CorrelationID: d.CorrelationId,
+		ReplyTo:       d.ReplyTo,
+		MessageID:     d.MessageId,
+		Redelivered:   d.Redelivered,
+	}
+
+	if !d.Timestamp.IsZero() {
+		out.Timestamp = d.Timestamp.UnixNano() / int64(time.Millisecond)
+	}
+
+	for name, value := range d.Headers {
+		out.Headers[name], _ = coerce.ToString(value)
+	}
+
+	if mediaType, _, err := mime.ParseMediaType(d.ContentType); err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")) {
+		var content interface{}
+		if err := json.Unmarshal(d.Body, &content); err == nil {
+			out.Content = content
+		}
+	}
+
+	return out
+}
+
+func splitList(list string) []string {
+
+	var values []string
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,7 @@
+module github.com/qingcloudhx/contrib/trigger/amqp
+
+require (
+	flogo/core v0.9.0
+	github.com/rabbitmq/amqp091-go v1.10.0
+	github.com/stretchr/testify v1.3.0
+)
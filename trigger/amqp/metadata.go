@@ -0,0 +1,127 @@
+package amqp
+
+import (
+	"flogo/core/data/coerce"
+)
+
+type Settings struct {
+	URL               string `md:"url,required"`      // The url of the broker (ex. amqp://localhost:5672/vhost), amqps urls connect with TLS
+	Username          string `md:"username"`          // The user name used to authenticate, overrides the user of the url
+	Password          string `md:"password"`          // The password used to authenticate, overrides the password of the url
+	ReconnectInterval string `md:"reconnectInterval"` // The delay between two attempts to reconnect to the broker when the connection is lost (ex. 10s), defaults to 5s
+
+	CAFile             string `md:"caFile"`             // The PEM file of the CA certificates used to verify the broker
+	CertFile           string `md:"certFile"`           // The PEM file of the client certificate, for brokers requiring client authentication
+	KeyFile            string `md:"keyFile"`            // The PEM file of the client private key
+	ServerName         string `md:"serverName"`         // The server name used to verify the broker certificate, overrides the broker host
+	InsecureSkipVerify bool   `md:"insecureSkipVerify"` // Don't verify the broker certificate, for testing only
+}
+
+type HandlerSettings struct {
+	Queue      string `md:"queue"`      // The queue to consume from, a server named queue is used if not specified
+	Durable    bool   `md:"durable"`    // Declare a durable queue, which survives a restart of the broker
+	AutoDelete bool   `md:"autoDelete"` // Declare a queue deleted once its last consumer is gone
+	Exclusive  bool   `md:"exclusive"`  // Declare a queue used only by this connection and deleted when it closes
+
+	Exchange        string `md:"exchange"`                                          // The exchange the queue is bound to
+	ExchangeType    string `md:"exchangeType,allowed(direct,fanout,topic,headers)"` // The type of the exchange, declared if it isn't a predefined amq.* exchange: direct (default), fanout, topic or headers
+	ExchangeDurable bool   `md:"exchangeDurable"`                                   // Declare a durable exchange
+	RoutingKeys     string `md:"routingKeys"`                                       // The comma separated routing keys the queue is bound to the exchange with, or patterns for a topic exchange
+
+	Prefetch int  `md:"prefetch"` // The max number of unacknowledged messages delivered to the handler, unlimited if not specified
+	AutoAck  bool `md:"autoAck"`  // Acknowledge the messages when they are delivered, instead of once the action succeeded
+	Requeue  bool `md:"requeue"`  // Requeue the messages when the action fails, instead of rejecting them to the dead letter exchange of the queue
+}
+
+type Output struct {
+	Message       string            `md:"message"`       // The body of the message
+	Content       interface{}       `md:"content"`       // The body of the message parsed, when its content type is JSON
+	Exchange      string            `md:"exchange"`      // The exchange the message was published to
+	RoutingKey    string            `md:"routingKey"`    // The routing key of the message
+	Headers       map[string]string `md:"headers"`       // The headers of the message
+	ContentType   string            `md:"contentType"`   // The content type of the message
+	CorrelationID string            `md:"correlationId"` // The correlation id of the message
+	ReplyTo       string            `md:"replyTo"`       // The queue the reply is published to
+	MessageID     string            `md:"messageId"`     // The id of the message
+	Timestamp     int64             `md:"timestamp"`     // The timestamp of the message, in milliseconds since the epoch
+	Redelivered   bool              `md:"redelivered"`   // Whether the message was delivered before
+}
+
+type Reply struct {
+	Data interface{} `md:"data"` // The reply published to the replyTo queue of the message, objects are published as JSON
+}
+
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"message":       o.Message,
+		"content":       o.Content,
+		"exchange":      o.Exchange,
+		"routingKey":    o.RoutingKey,
+		"headers":       o.Headers,
+		"contentType":   o.ContentType,
+		"correlationId": o.CorrelationID,
+		"replyTo":       o.ReplyTo,
+		"messageId":     o.MessageID,
+		"timestamp":     o.Timestamp,
+		"redelivered":   o.Redelivered,
+	}
+}
+
+func (o *Output) FromMap(values map[string]interface{}) error {
+
+	var err error
+	o.Message, err = coerce.ToString(values["message"])
+	if err != nil {
+		return err
+	}
+	o.Content = values["content"]
+	o.Exchange, err = coerce.ToString(values["exchange"])
+	if err != nil {
+		return err
+	}
+	o.RoutingKey, err = coerce.ToString(values["routingKey"])
+	if err != nil {
+		return err
+	}
+	o.Headers, err = coerce.ToParams(values["headers"])
+	if err != nil {
+		return err
+	}
+	o.ContentType, err = coerce.ToString(values["contentType"])
+	if err != nil {
+		return err
+	}
+	o.CorrelationID, err = coerce.ToString(values["correlationId"])
+	if err != nil {
+		return err
+	}
+	o.ReplyTo, err = coerce.ToString(values["replyTo"])
+	if err != nil {
+		return err
+	}
+	o.MessageID, err = coerce.ToString(values["messageId"])
+	if err != nil {
+		return err
+	}
+	o.Timestamp, err = coerce.ToInt64(values["timestamp"])
+	if err != nil {
+		return err
+	}
+	o.Redelivered, err = coerce.ToBool(values["redelivered"])
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *Reply) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"data": r.Data,
+	}
+}
+
+func (r *Reply) FromMap(values map[string]interface{}) error {
+	r.Data = values["data"]
+	return nil
+}
@@ -0,0 +1,194 @@
+package amqp
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"flogo/core/data/metadata"
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+const defaultReconnectInterval = 5 * time.Second
+
+var triggerMd = trigger.NewMetadata(&Settings{}, &HandlerSettings{}, &Output{}, &Reply{})
+
+func init() {
+	_ = trigger.Register(&Trigger{}, &Factory{})
+}
+
+type Factory struct {
+}
+
+// Metadata implements trigger.Factory.Metadata
+func (*Factory) Metadata() *trigger.Metadata {
+	return triggerMd
+}
+
+// New implements trigger.Factory.New
+func (*Factory) New(config *trigger.Config) (trigger.Trigger, error) {
+
+	s := &Settings{}
+	err := metadata.MapToStruct(config.Settings, s, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Trigger{settings: s}, nil
+}
+
+// Trigger consumes messages from an AMQP broker, such as RabbitMQ
+type Trigger struct {
+	settings          *Settings
+	logger            log.Logger
+	consumers         []*consumer
+	reconnectInterval time.Duration
+
+	mu      sync.Mutex
+	conn    *amqp.Connection
+	stop    chan struct{}
+	stopped sync.WaitGroup
+}
+
+// Initialize implements trigger.Init.Initialize
+func (t *Trigger) Initialize(ctx trigger.InitContext) error {
+
+	t.logger = ctx.Logger()
+
+	t.reconnectInterval = defaultReconnectInterval
+	if t.settings.ReconnectInterval != "" {
+		interval, err := time.ParseDuration(t.settings.ReconnectInterval)
+		if err != nil {
+			return fmt.Errorf("invalid reconnect interval '%s': %v", t.settings.ReconnectInterval, err)
+		}
+		t.reconnectInterval = interval
+	}
+
+	for _, handler := range ctx.GetHandlers() {
+
+		s := &HandlerSettings{}
+		err := metadata.MapToStruct(handler.Settings(), s, true)
+		if err != nil {
+			return err
+		}
+
+		if s.Queue == "" && s.Exchange == "" {
+			return fmt.Errorf("handler '%s' requires a queue or an exchange", handler.Name())
+		}
+
+		t.consumers = append(t.consumers, &consumer{handler: handler, settings: s, logger: t.logger})
+	}
+
+	return nil
+}
+
+// Start implements util.Managed.Start
+func (t *Trigger) Start() error {
+
+	t.stop = make(chan struct{})
+
+	conn, err := t.connect()
+	if err != nil {
+		t.stop = nil
+		return err
+	}
+
+	t.stopped.Add(1)
+	go t.supervise(conn)
+
+	return nil
+}
+
+// Stop implements util.Managed.Stop
+func (t *Trigger) Stop() error {
+
+	if t.stop == nil {
+		return nil
+	}
+
+	close(t.stop)
+
+	t.mu.Lock()
+	if t.conn != nil {
+		_ = t.conn.Close()
+	}
+	t.mu.Unlock()
+
+	t.stopped.Wait()
+	t.stop = nil
+
+	return nil
+}
+
+// connect connects to the broker and starts the consumers of the handlers, each on its own channel
+func (t *Trigger) connect() (*amqp.Connection, error) {
+
+	conn, err := dial(t.settings)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to '%s': %v", t.settings.URL, err)
+	}
+
+	for _, c := range t.consumers {
+		ch, err := conn.Channel()
+		if err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		deliveries, err := c.setup(ch)
+		if err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+		go c.consume(ch, deliveries)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	select {
+	case <-t.stop:
+		// stopped while connecting
+		_ = conn.Close()
+		return nil, errors.New("trigger stopped")
+	default:
+	}
+	t.conn = conn
+
+	return conn, nil
+}
+
+// supervise reconnects to the broker when the connection is lost, until the trigger stops
+func (t *Trigger) supervise(conn *amqp.Connection) {
+
+	defer t.stopped.Done()
+
+	for {
+		closed := conn.NotifyClose(make(chan *amqp.Error, 1))
+
+		select {
+		case <-t.stop:
+			return
+		case err := <-closed:
+			t.logger.Warnf("Connection to '%s' lost: %v", t.settings.URL, err)
+		}
+
+		for {
+			select {
+			case <-t.stop:
+				return
+			case <-time.After(t.reconnectInterval):
+			}
+
+			var err error
+			conn, err = t.connect()
+			if err == nil {
+				t.logger.Infof("Reconnected to '%s'", t.settings.URL)
+				break
+			}
+			t.logger.Warnf("Unable to reconnect: %v", err)
+		}
+	}
+}
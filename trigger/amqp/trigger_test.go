@@ -0,0 +1,231 @@
+package amqp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"flogo/core/action"
+	"flogo/core/api"
+	"flogo/core/support/log"
+	"flogo/core/support/test"
+	"flogo/core/trigger"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig string = `{
+	"id": "flogo-amqp",
+	"ref": "github.com/qingcloudhx/contrib/trigger/amqp",
+	"settings": {
+		"url": "amqp://localhost"
+	},
+	"handlers": [
+		{
+			"action": {
+				"id": "dummy"
+			},
+			"settings": {
+				"queue": "orders"
+			}
+		}
+	]
+}`
+
+// testChannel records the calls of a consumer
+type testChannel struct {
+	calls      []string
+	published  []amqp.Publishing
+	deliveries chan amqp.Delivery
+}
+
+func (ch *testChannel) Qos(prefetchCount, prefetchSize int, global bool) error {
+	ch.calls = append(ch.calls, "qos")
+	return nil
+}
+
+func (ch *testChannel) ExchangeDeclare(name, kind string, durable, autoDelete, internal, noWait bool, args amqp.Table) error {
+	ch.calls = append(ch.calls, "exchange "+name+" "+kind)
+	return nil
+}
+
+func (ch *testChannel) QueueDeclare(name string, durable, autoDelete, exclusive, noWait bool, args amqp.Table) (amqp.Queue, error) {
+	if name == "" {
+		name = "amq.gen-1"
+	}
+	ch.calls = append(ch.calls, "queue "+name)
+	return amqp.Queue{Name: name}, nil
+}
+
+func (ch *testChannel) QueueBind(name, key, exchange string, noWait bool, args amqp.Table) error {
+	ch.calls = append(ch.calls, "bind "+name+" "+exchange+" "+key)
+	return nil
+}
+
+func (ch *testChannel) Consume(queue, consumer string, autoAck, exclusive, noLocal, noWait bool, args amqp.Table) (<-chan amqp.Delivery, error) {
+	ch.calls = append(ch.calls, "consume "+queue)
+	return ch.deliveries, nil
+}
+
+func (ch *testChannel) PublishWithContext(ctx context.Context, exchange, key string, mandatory, immediate bool, msg amqp.Publishing) error {
+	ch.calls = append(ch.calls, "publish "+key)
+	ch.published = append(ch.published, msg)
+	return nil
+}
+
+// testAcknowledger records the acknowledgements of the deliveries
+type testAcknowledger struct {
+	acks []string
+}
+
+func (a *testAcknowledger) Ack(tag uint64, multiple bool) error {
+	a.acks = append(a.acks, "ack")
+	return nil
+}
+
+func (a *testAcknowledger) Nack(tag uint64, multiple bool, requeue bool) error {
+	if requeue {
+		a.acks = append(a.acks, "requeue")
+	} else {
+		a.acks = append(a.acks, "reject")
+	}
+	return nil
+}
+
+func (a *testAcknowledger) Reject(tag uint64, requeue bool) error {
+	return a.Nack(tag, false, requeue)
+}
+
+func TestConsumer_Setup(t *testing.T) {
+
+	ch := &testChannel{}
+	c := &consumer{settings: &HandlerSettings{Queue: "orders", Exchange: "events", ExchangeType: "topic", RoutingKeys: "order.*, invoice.created", Prefetch: 10}, logger: log.RootLogger()}
+	_, err := c.setup(ch)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"qos", "exchange events topic", "queue orders", "bind orders events order.*", "bind orders events invoice.created", "consume orders"}, ch.calls)
+
+	// predefined exchange and server named queue
+	ch = &testChannel{}
+	c = &consumer{settings: &HandlerSettings{Exchange: "amq.fanout"}, logger: log.RootLogger()}
+	_, err = c.setup(ch)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"queue amq.gen-1", "bind amq.gen-1 amq.fanout ", "consume amq.gen-1"}, ch.calls)
+}
+
+func TestConsumer_Handle(t *testing.T) {
+
+	var received *Output
+	f := func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+		received = &Output{}
+		if err := received.FromMap(inputs); err != nil {
+			return nil, err
+		}
+		if received.Message == "fail" {
+			return nil, errors.New("failed")
+		}
+		return map[string]interface{}{"data": map[string]interface{}{"status": "done"}}, nil
+	}
+
+	config := &trigger.Config{}
+	err := json.Unmarshal([]byte(testConfig), config)
+	assert.Nil(t, err)
+	config.Handlers[0].Settings["requeue"] = true
+
+	trg, err := test.InitTrigger(&Factory{}, config, map[string]action.Action{"dummy": api.NewProxyAction(f)})
+	assert.Nil(t, err)
+	c := trg.(*Trigger).consumers[0]
+
+	acks := &testAcknowledger{}
+	ch := &testChannel{deliveries: make(chan amqp.Delivery, 3)}
+
+	timestamp := time.Date(2019, 3, 1, 10, 0, 0, 0, time.UTC)
+	ch.deliveries <- amqp.Delivery{Acknowledger: acks, Body: []byte(`{"id":1}`), ContentType: "application/json",
+		RoutingKey: "order.created", Headers: amqp.Table{"retries": int32(2)}, Timestamp: timestamp, ReplyTo: "replies", CorrelationId: "42"}
+	ch.deliveries <- amqp.Delivery{Acknowledger: acks, Body: []byte("fail")}
+	close(ch.deliveries)
+
+	c.consume(ch, ch.deliveries)
+
+	assert.Equal(t, []string{"ack", "requeue"}, acks.acks)
+	assert.Equal(t, []string{"publish replies"}, ch.calls)
+	assert.Equal(t, `{"status":"done"}`, string(ch.published[0].Body))
+	assert.Equal(t, "42", ch.published[0].CorrelationId)
+
+	acks = &testAcknowledger{}
+	c.settings.Requeue = false
+	c.handle(ch, amqp.Delivery{Acknowledger: acks, Body: []byte("fail")})
+	assert.Equal(t, []string{"reject"}, acks.acks)
+	assert.Equal(t, "fail", received.Message)
+
+	acks = &testAcknowledger{}
+	c.settings.AutoAck = true
+	c.handle(ch, amqp.Delivery{Acknowledger: acks, Body: []byte("ok")})
+	assert.Empty(t, acks.acks)
+}
+
+func TestToOutput(t *testing.T) {
+
+	timestamp := time.Date(2019, 3, 1, 10, 0, 0, 0, time.UTC)
+	out := toOutput(amqp.Delivery{Body: []byte(`{"id":1}`), ContentType: "application/json; charset=utf-8", Exchange: "events",
+		RoutingKey: "order.created", Headers: amqp.Table{"retries": int32(2)}, Timestamp: timestamp, MessageId: "m1", Redelivered: true})
+
+	assert.Equal(t, `{"id":1}`, out.Message)
+	assert.Equal(t, map[string]interface{}{"id": 1.0}, out.Content)
+	assert.Equal(t, "events", out.Exchange)
+	assert.Equal(t, "order.created", out.RoutingKey)
+	assert.Equal(t, map[string]string{"retries": "2"}, out.Headers)
+	assert.Equal(t, timestamp.UnixNano()/int64(time.Millisecond), out.Timestamp)
+	assert.Equal(t, "m1", out.MessageID)
+	assert.True(t, out.Redelivered)
+
+	out = toOutput(amqp.Delivery{Body: []byte(`{"id":1}`), ContentType: "text/plain"})
+	assert.Nil(t, out.Content)
+	assert.Equal(t, int64(0), out.Timestamp)
+}
+
+func TestGetTLSConfig(t *testing.T) {
+
+	config, err := getTLSConfig(&Settings{URL: "amqp://localhost"})
+	assert.Nil(t, err)
+	assert.Nil(t, config)
+
+	config, err = getTLSConfig(&Settings{ServerName: "rabbit.internal"})
+	assert.Nil(t, err)
+	assert.Equal(t, "rabbit.internal", config.ServerName)
+
+	_, err = getTLSConfig(&Settings{CertFile: "client.pem"})
+	assert.NotNil(t, err)
+
+	_, err = getTLSConfig(&Settings{CAFile: "missing.pem"})
+	assert.NotNil(t, err)
+}
+
+func TestAMQPTrigger_Initialize(t *testing.T) {
+	f := &Factory{}
+
+	config := &trigger.Config{}
+	err := json.Unmarshal([]byte(testConfig), config)
+	assert.Nil(t, err)
+
+	actions := map[string]action.Action{"dummy": test.NewDummyAction(func() {
+		//do nothing
+	})}
+
+	trg, err := test.InitTrigger(f, config, actions)
+	assert.Nil(t, err)
+	tgr := trg.(*Trigger)
+	assert.Len(t, tgr.consumers, 1)
+	assert.Equal(t, defaultReconnectInterval, tgr.reconnectInterval)
+
+	// a queue or an exchange is required
+	config.Handlers[0].Settings = map[string]interface{}{}
+	_, err = test.InitTrigger(f, config, actions)
+	assert.NotNil(t, err)
+
+	config.Handlers[0].Settings = map[string]interface{}{"queue": "orders"}
+	config.Settings["reconnectInterval"] = "soon"
+	_, err = test.InitTrigger(f, config, actions)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,9 @@
+module github.com/qingcloudhx/contrib/trigger/azureservicebus
+
+require (
+	flogo/core v0.9.0
+	github.com/Azure/azure-sdk-for-go/sdk/azcore v1.14.0
+	github.com/Azure/azure-sdk-for-go/sdk/azidentity v1.8.0
+	github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus v1.7.3
+	github.com/stretchr/testify v1.3.0
+)
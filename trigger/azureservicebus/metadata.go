@@ -0,0 +1,106 @@
+package azureservicebus
+
+import (
+	"flogo/core/data/coerce"
+)
+
+type Settings struct {
+	ConnectionString string `md:"connectionString"` // The connection string of the namespace, with a shared access key
+	Namespace        string `md:"namespace"`        // The fully qualified namespace (ex. myns.servicebus.windows.net), authenticated with an Azure identity when there is no connection string
+	ClientID         string `md:"clientId"`         // The client id of the user-assigned managed identity, defaults to the default Azure credential chain
+}
+
+type HandlerSettings struct {
+	Queue              string `md:"queue"`              // The queue to receive from
+	Topic              string `md:"topic"`              // The topic of the subscription to receive from
+	Subscription       string `md:"subscription"`       // The subscription of the topic to receive from
+	Sessions           bool   `md:"sessions"`           // Receive from a session-enabled queue or subscription, the messages of a session being handled in order
+	SessionID          string `md:"sessionId"`          // The session to receive from, defaults to the next available sessions
+	MaxMessages        int    `md:"maxMessages"`        // The max number of messages received at once, defaults to 10
+	Concurrency        int    `md:"concurrency"`        // The number of messages, or of sessions, handled at the same time, defaults to 1
+	SessionIdleTimeout string `md:"sessionIdleTimeout"` // How long a session is kept without receiving messages before another session is accepted (ex. 30s), defaults to 1m
+}
+
+type Output struct {
+	MessageID      string                 `md:"messageId"`      // The id of the message
+	Body           string                 `md:"body"`           // The body of the message
+	Content        interface{}            `md:"content"`        // The body of the message parsed, when it is JSON
+	Properties     map[string]interface{} `md:"properties"`     // The application properties of the message
+	ContentType    string                 `md:"contentType"`    // The content type of the message
+	CorrelationID  string                 `md:"correlationId"`  // The correlation id of the message
+	SessionID      string                 `md:"sessionId"`      // The session of the message
+	Subject        string                 `md:"subject"`        // The subject of the message
+	ReplyTo        string                 `md:"replyTo"`        // The entity to reply to
+	DeliveryCount  int                    `md:"deliveryCount"`  // The number of deliveries of the message
+	SequenceNumber int64                  `md:"sequenceNumber"` // The sequence number assigned to the message by Service Bus
+	EnqueuedTime   int64                  `md:"enqueuedTime"`   // The time the message was enqueued, in milliseconds since epoch
+}
+
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"messageId":      o.MessageID,
+		"body":           o.Body,
+		"content":        o.Content,
+		"properties":     o.Properties,
+		"contentType":    o.ContentType,
+		"correlationId":  o.CorrelationID,
+		"sessionId":      o.SessionID,
+		"subject":        o.Subject,
+		"replyTo":        o.ReplyTo,
+		"deliveryCount":  o.DeliveryCount,
+		"sequenceNumber": o.SequenceNumber,
+		"enqueuedTime":   o.EnqueuedTime,
+	}
+}
+
+func (o *Output) FromMap(values map[string]interface{}) error {
+
+	var err error
+	o.MessageID, err = coerce.ToString(values["messageId"])
+	if err != nil {
+		return err
+	}
+	o.Body, err = coerce.ToString(values["body"])
+	if err != nil {
+		return err
+	}
+	o.Content = values["content"]
+	o.Properties, err = coerce.ToObject(values["properties"])
+	if err != nil {
+		return err
+	}
+	o.ContentType, err = coerce.ToString(values["contentType"])
+	if err != nil {
+		return err
+	}
+	o.CorrelationID, err = coerce.ToString(values["correlationId"])
+	if err != nil {
+		return err
+	}
+	o.SessionID, err = coerce.ToString(values["sessionId"])
+	if err != nil {
+		return err
+	}
+	o.Subject, err = coerce.ToString(values["subject"])
+	if err != nil {
+		return err
+	}
+	o.ReplyTo, err = coerce.ToString(values["replyTo"])
+	if err != nil {
+		return err
+	}
+	o.DeliveryCount, err = coerce.ToInt(values["deliveryCount"])
+	if err != nil {
+		return err
+	}
+	o.SequenceNumber, err = coerce.ToInt64(values["sequenceNumber"])
+	if err != nil {
+		return err
+	}
+	o.EnqueuedTime, err = coerce.ToInt64(values["enqueuedTime"])
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,392 @@
+package azureservicebus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+)
+
+const (
+	defaultMaxMessages        = 10
+	defaultSessionIdleTimeout = time.Minute
+	retryInterval             = 5 * time.Second
+	settleTimeout             = 30 * time.Second
+)
+
+// receiver is the part of a Service Bus receiver, or session receiver, used by a listener
+type receiver interface {
+	ReceiveMessages(ctx context.Context, maxMessages int, options *azservicebus.ReceiveMessagesOptions) ([]*azservicebus.ReceivedMessage, error)
+	CompleteMessage(ctx context.Context, message *azservicebus.ReceivedMessage, options *azservicebus.CompleteMessageOptions) error
+	AbandonMessage(ctx context.Context, message *azservicebus.ReceivedMessage, options *azservicebus.AbandonMessageOptions) error
+	Close(ctx context.Context) error
+}
+
+type messageReceiver interface {
+	receiver
+	RenewMessageLock(ctx context.Context, msg *azservicebus.ReceivedMessage, options *azservicebus.RenewMessageLockOptions) error
+}
+
+type sessionReceiver interface {
+	receiver
+	SessionID() string
+	LockedUntil() time.Time
+	RenewSessionLock(ctx context.Context, options *azservicebus.RenewSessionLockOptions) error
+}
+
+// connector opens the receivers of the queue or subscription of a handler
+type connector interface {
+	newReceiver() (messageReceiver, error)
+	// acceptSession accepts the session, or the next available session when the id is empty
+	acceptSession(ctx context.Context, sessionID string) (sessionReceiver, error)
+}
+
+type entityConnector struct {
+	client       *azservicebus.Client
+	queue        string
+	topic        string
+	subscription string
+}
+
+func (c *entityConnector) newReceiver() (messageReceiver, error) {
+
+	options := &azservicebus.ReceiverOptions{ReceiveMode: azservicebus.ReceiveModePeekLock}
+	if c.queue != "" {
+		return c.client.NewReceiverForQueue(c.queue, options)
+	}
+	return c.client.NewReceiverForSubscription(c.topic, c.subscription, options)
+}
+
+func (c *entityConnector) acceptSession(ctx context.Context, sessionID string) (sessionReceiver, error) {
+
+	options := &azservicebus.SessionReceiverOptions{ReceiveMode: azservicebus.ReceiveModePeekLock}
+	switch {
+	case c.queue != "" && sessionID != "":
+		return c.client.AcceptSessionForQueue(ctx, c.queue, sessionID, options)
+	case c.queue != "":
+		return c.client.AcceptNextSessionForQueue(ctx, c.queue, options)
+	case sessionID != "":
+		return c.client.AcceptSessionForSubscription(ctx, c.topic, c.subscription, sessionID, options)
+	default:
+		return c.client.AcceptNextSessionForSubscription(ctx, c.topic, c.subscription, options)
+	}
+}
+
+// listener receives the messages of the queue or subscription of a handler with peek-lock: a message
+// is completed once its action succeeded, otherwise it is abandoned and redelivered until the max
+// delivery count of the entity moves it to the dead letter queue. The lock of a message, or of its
+// session, is renewed while its action runs
+type listener struct {
+	connector connector
+	handler   trigger.Handler
+	settings  *HandlerSettings
+	logger    log.Logger
+	entity    string
+
+	sessionIdleTimeout time.Duration
+	done               chan struct{}
+}
+
+func newListener(connector connector, handler trigger.Handler, s *HandlerSettings, logger log.Logger) (*listener, error) {
+
+	if s.Queue != "" && (s.Topic != "" || s.Subscription != "") {
+		return nil, fmt.Errorf("either a queue or a topic subscription must be specified")
+	}
+	if s.Queue == "" && (s.Topic == "" || s.Subscription == "") {
+		return nil, fmt.Errorf("a queue, or a topic and a subscription, must be specified")
+	}
+	if s.SessionID != "" {
+		s.Sessions = true
+	}
+	if s.MaxMessages <= 0 {
+		s.MaxMessages = defaultMaxMessages
+	}
+	if s.Concurrency <= 0 {
+		s.Concurrency = 1
+	}
+
+	l := &listener{connector: connector, handler: handler, settings: s, logger: logger, sessionIdleTimeout: defaultSessionIdleTimeout}
+
+	l.entity = s.Queue
+	if s.Queue == "" {
+		l.entity = s.Topic + "/" + s.Subscription
+	}
+
+	if s.SessionIdleTimeout != "" {
+		timeout, err := time.ParseDuration(s.SessionIdleTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid session idle timeout '%s': %v", s.SessionIdleTimeout, err)
+		}
+		l.sessionIdleTimeout = timeout
+	}
+
+	return l, nil
+}
+
+// run receives the messages until the context is done, the messages being handled are completed
+func (l *listener) run(ctx context.Context) {
+
+	defer close(l.done)
+
+	l.logger.Infof("Receiving messages of '%s'", l.entity)
+
+	if !l.settings.Sessions {
+		l.receiveMessages(ctx)
+		return
+	}
+
+	// a single session is accepted at a time when its id is set
+	workers := l.settings.Concurrency
+	if l.settings.SessionID != "" {
+		workers = 1
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.receiveSessions(ctx)
+		}()
+	}
+	wg.Wait()
+}
+
+// wait waits for the listener to stop, at most for the timeout
+func (l *listener) wait(timeout time.Duration) {
+
+	if l.done == nil {
+		return
+	}
+
+	select {
+	case <-l.done:
+	case <-time.After(timeout):
+		l.logger.Warnf("Messages of '%s' still being handled", l.entity)
+	}
+}
+
+// receiveMessages handles the messages of each batch concurrently, before receiving the next batch
+func (l *listener) receiveMessages(ctx context.Context) {
+
+	var r messageReceiver
+	for r == nil {
+		var err error
+		r, err = l.connector.newReceiver()
+		if err != nil {
+			l.logger.Errorf("Error creating receiver of '%s': %v", l.entity, err)
+			if !sleep(ctx, retryInterval) {
+				return
+			}
+		}
+	}
+	defer r.Close(context.Background())
+
+	for ctx.Err() == nil {
+		msgs, err := r.ReceiveMessages(ctx, l.settings.MaxMessages, nil)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			l.logger.Errorf("Error receiving messages of '%s': %v", l.entity, err)
+			sleep(ctx, retryInterval)
+			continue
+		}
+
+		var wg sync.WaitGroup
+		slots := make(chan struct{}, l.settings.Concurrency)
+		for _, msg := range msgs {
+			slots <- struct{}{}
+			wg.Add(1)
+			go func(msg *azservicebus.ReceivedMessage) {
+				defer func() {
+					<-slots
+					wg.Done()
+				}()
+
+				stop := make(chan struct{})
+				go l.renew(stop, func() time.Time { return timeOf(msg.LockedUntil) }, func(ctx context.Context) error {
+					return r.RenewMessageLock(ctx, msg, nil)
+				})
+				l.handle(r, msg)
+				close(stop)
+			}(msg)
+		}
+		wg.Wait()
+	}
+}
+
+// receiveSessions accepts sessions one after the other, until the context is done
+func (l *listener) receiveSessions(ctx context.Context) {
+
+	for ctx.Err() == nil {
+		r, err := l.connector.acceptSession(ctx, l.settings.SessionID)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			// no session became available in time
+			var sbErr *azservicebus.Error
+			if errors.As(err, &sbErr) && sbErr.Code == azservicebus.CodeTimeout {
+				continue
+			}
+			l.logger.Errorf("Error accepting session of '%s': %v", l.entity, err)
+			sleep(ctx, retryInterval)
+			continue
+		}
+
+		l.receiveSession(ctx, r)
+		_ = r.Close(context.Background())
+	}
+}
+
+// receiveSession handles the messages of a session in order, until it has no message for the idle timeout
+func (l *listener) receiveSession(ctx context.Context, r sessionReceiver) {
+
+	l.logger.Debugf("Session '%s' of '%s' accepted", r.SessionID(), l.entity)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go l.renew(stop, r.LockedUntil, func(ctx context.Context) error {
+		return r.RenewSessionLock(ctx, nil)
+	})
+
+	for ctx.Err() == nil {
+		rctx, cancel := context.WithTimeout(ctx, l.sessionIdleTimeout)
+		msgs, err := r.ReceiveMessages(rctx, l.settings.MaxMessages, nil)
+		idle := rctx.Err() != nil && ctx.Err() == nil
+		cancel()
+
+		if err != nil || len(msgs) == 0 {
+			if err != nil && !idle && ctx.Err() == nil {
+				l.logger.Errorf("Error receiving messages of session '%s' of '%s': %v", r.SessionID(), l.entity, err)
+			}
+			return
+		}
+
+		for i, msg := range msgs {
+			if !l.handle(r, msg) {
+				// the next messages are abandoned too, to be redelivered after the failed message
+				for _, next := range msgs[i+1:] {
+					l.abandon(r, next)
+				}
+				break
+			}
+		}
+	}
+}
+
+// handle invokes the action for a message, and completes the message when it succeeded or abandons it otherwise
+func (l *listener) handle(r receiver, msg *azservicebus.ReceivedMessage) bool {
+
+	_, err := l.handler.Handle(context.Background(), toOutput(msg))
+	if err != nil {
+		l.logger.Errorf("Error handling message '%s' of '%s': %v", msg.MessageID, l.entity, err)
+		l.abandon(r, msg)
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), settleTimeout)
+	defer cancel()
+
+	err = r.CompleteMessage(ctx, msg, nil)
+	if err != nil {
+		l.logger.Errorf("Error completing message '%s' of '%s': %v", msg.MessageID, l.entity, err)
+	}
+
+	return true
+}
+
+func (l *listener) abandon(r receiver, msg *azservicebus.ReceivedMessage) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), settleTimeout)
+	defer cancel()
+
+	err := r.AbandonMessage(ctx, msg, nil)
+	if err != nil {
+		l.logger.Errorf("Error abandoning message '%s' of '%s': %v", msg.MessageID, l.entity, err)
+	}
+}
+
+// renew renews a lock halfway to its expiry, until stopped
+func (l *listener) renew(stop chan struct{}, lockedUntil func() time.Time, renew func(ctx context.Context) error) {
+
+	for {
+		until := lockedUntil()
+		if until.IsZero() {
+			return
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(time.Until(until) / 2):
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), settleTimeout)
+		err := renew(ctx)
+		cancel()
+		if err != nil {
+			l.logger.Warnf("Error renewing lock of '%s': %v", l.entity, err)
+			return
+		}
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+func timeOf(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
+
+func toOutput(msg *azservicebus.ReceivedMessage) *Output {
+
+	out := &Output{
+		MessageID:     msg.MessageID,
+		Body:          string(msg.Body),
+		Properties:    msg.ApplicationProperties,
+		ContentType:   stringOf(msg.ContentType),
+		CorrelationID: stringOf(msg.CorrelationID),
+		SessionID:     stringOf(msg.SessionID),
+		Subject:       stringOf(msg.Subject),
+		ReplyTo:       stringOf(msg.ReplyTo),
+		DeliveryCount: int(msg.DeliveryCount),
+	}
+
+	if msg.SequenceNumber != nil {
+		out.SequenceNumber = *msg.SequenceNumber
+	}
+	if msg.EnqueuedTime != nil {
+		out.EnqueuedTime = msg.EnqueuedTime.UnixNano() / int64(time.Millisecond)
+	}
+
+	var content interface{}
+	if json.Unmarshal(msg.Body, &content) == nil {
+		out.Content = content
+	}
+
+	return out
+}
+
+func stringOf(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
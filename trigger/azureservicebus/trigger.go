@@ -0,0 +1,151 @@
+package azureservicebus
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"flogo/core/data/metadata"
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+)
+
+var triggerMd = trigger.NewMetadata(&Settings{}, &HandlerSettings{}, &Output{})
+
+func init() {
+	_ = trigger.Register(&Trigger{}, &Factory{})
+}
+
+type Factory struct {
+}
+
+// Metadata implements trigger.Factory.Metadata
+func (*Factory) Metadata() *trigger.Metadata {
+	return triggerMd
+}
+
+// New implements trigger.Factory.New
+func (*Factory) New(config *trigger.Config) (trigger.Trigger, error) {
+
+	s := &Settings{}
+	err := metadata.MapToStruct(config.Settings, s, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.ConnectionString == "" && s.Namespace == "" {
+		return nil, fmt.Errorf("either a connection string or a namespace must be specified")
+	}
+
+	return &Trigger{settings: s}, nil
+}
+
+// Trigger receives the messages of Azure Service Bus queues and topic subscriptions
+type Trigger struct {
+	settings  *Settings
+	logger    log.Logger
+	client    *azservicebus.Client
+	listeners []*listener
+
+	cancel context.CancelFunc
+}
+
+// Initialize implements trigger.Init.Initialize
+func (t *Trigger) Initialize(ctx trigger.InitContext) error {
+
+	t.logger = ctx.Logger()
+
+	client, err := t.newClient()
+	if err != nil {
+		return err
+	}
+	t.client = client
+
+	for _, handler := range ctx.GetHandlers() {
+
+		s := &HandlerSettings{}
+		err := metadata.MapToStruct(handler.Settings(), s, true)
+		if err != nil {
+			return err
+		}
+
+		connector := &entityConnector{client: client, queue: s.Queue, topic: s.Topic, subscription: s.Subscription}
+		l, err := newListener(connector, handler, s, t.logger)
+		if err != nil {
+			return err
+		}
+		t.listeners = append(t.listeners, l)
+	}
+
+	return nil
+}
+
+// newClient creates the client of the namespace, authenticated with the shared access key of the connection
+// string or with an Azure identity
+func (t *Trigger) newClient() (*azservicebus.Client, error) {
+
+	s := t.settings
+
+	if s.ConnectionString != "" {
+		client, err := azservicebus.NewClientFromConnectionString(s.ConnectionString, nil)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create Service Bus client: %v", err)
+		}
+		return client, nil
+	}
+
+	var credential azcore.TokenCredential
+	var err error
+	if s.ClientID != "" {
+		credential, err = azidentity.NewManagedIdentityCredential(&azidentity.ManagedIdentityCredentialOptions{ID: azidentity.ClientID(s.ClientID)})
+	} else {
+		// environment, workload identity, managed identity and Azure CLI credentials
+		credential, err = azidentity.NewDefaultAzureCredential(nil)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to create Azure credential: %v", err)
+	}
+
+	client, err := azservicebus.NewClient(s.Namespace, credential, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create Service Bus client: %v", err)
+	}
+
+	return client, nil
+}
+
+// Start implements util.Managed.Start
+func (t *Trigger) Start() error {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+
+	for _, l := range t.listeners {
+		l.done = make(chan struct{})
+		go l.run(ctx)
+	}
+
+	return nil
+}
+
+// Stop implements util.Managed.Stop
+func (t *Trigger) Stop() error {
+
+	if t.cancel != nil {
+		t.cancel()
+		t.cancel = nil
+	}
+
+	for _, l := range t.listeners {
+		l.wait(30 * time.Second)
+	}
+
+	if t.client != nil {
+		return t.client.Close(context.Background())
+	}
+
+	return nil
+}
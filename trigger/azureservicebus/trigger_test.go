@@ -0,0 +1,335 @@
+package azureservicebus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"flogo/core/action"
+	"flogo/core/api"
+	"flogo/core/support/log"
+	"flogo/core/support/test"
+	"flogo/core/trigger"
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azservicebus"
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig string = `{
+	"id": "flogo-azureservicebus",
+	"ref": "github.com/qingcloudhx/contrib/trigger/azureservicebus",
+	"settings": {
+		"connectionString": "Endpoint=sb://test.servicebus.windows.net/;SharedAccessKeyName=RootManageSharedAccessKey;SharedAccessKey=a2V5"
+	},
+	"handlers": [
+		{
+			"action": {
+				"id": "dummy"
+			},
+			"settings": {
+				"topic": "orders",
+				"subscription": "billing"
+			}
+		}
+	]
+}`
+
+// collect returns a handler function appending the bodies of the messages to the slice, it fails for the bodies
+// of the failures
+func collect(bodies *[]string, failures map[string]bool) api.HandlerFunc {
+	var mu sync.Mutex
+	return func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+		out := &Output{}
+		if err := out.FromMap(inputs); err != nil {
+			return nil, err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		*bodies = append(*bodies, out.Body)
+		if failures[out.Body] {
+			return nil, errors.New("failed")
+		}
+		return nil, nil
+	}
+}
+
+// newHandler returns the handler of a trigger initialized with testConfig, running the function
+func newHandler(t *testing.T, f api.HandlerFunc) trigger.Handler {
+
+	config := &trigger.Config{}
+	err := json.Unmarshal([]byte(testConfig), config)
+	assert.Nil(t, err)
+
+	tgr, err := test.InitTrigger(&Factory{}, config, map[string]action.Action{"dummy": api.NewProxyAction(f)})
+	assert.Nil(t, err)
+
+	return tgr.(*Trigger).listeners[0].handler
+}
+
+// testReceiver returns its batches of messages, then blocks until the context is done, and records
+// the settlements of the messages
+type testReceiver struct {
+	mu        sync.Mutex
+	sessionID string
+	batches   [][]*azservicebus.ReceivedMessage
+	completed []string
+	abandoned []string
+	renewals  int
+	closed    bool
+}
+
+func (r *testReceiver) ReceiveMessages(ctx context.Context, maxMessages int, options *azservicebus.ReceiveMessagesOptions) ([]*azservicebus.ReceivedMessage, error) {
+	r.mu.Lock()
+	if len(r.batches) == 0 {
+		r.mu.Unlock()
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	defer r.mu.Unlock()
+
+	batch := r.batches[0]
+	r.batches = r.batches[1:]
+	return batch, nil
+}
+
+func (r *testReceiver) CompleteMessage(ctx context.Context, message *azservicebus.ReceivedMessage, options *azservicebus.CompleteMessageOptions) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.completed = append(r.completed, string(message.Body))
+	return nil
+}
+
+func (r *testReceiver) AbandonMessage(ctx context.Context, message *azservicebus.ReceivedMessage, options *azservicebus.AbandonMessageOptions) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.abandoned = append(r.abandoned, string(message.Body))
+	return nil
+}
+
+func (r *testReceiver) RenewMessageLock(ctx context.Context, msg *azservicebus.ReceivedMessage, options *azservicebus.RenewMessageLockOptions) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.renewals++
+	return nil
+}
+
+func (r *testReceiver) Close(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closed = true
+	return nil
+}
+
+func (r *testReceiver) SessionID() string {
+	return r.sessionID
+}
+
+func (r *testReceiver) LockedUntil() time.Time {
+	return time.Now().Add(time.Minute)
+}
+
+func (r *testReceiver) RenewSessionLock(ctx context.Context, options *azservicebus.RenewSessionLockOptions) error {
+	return nil
+}
+
+func (r *testReceiver) settled() ([]string, []string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]string(nil), r.completed...), append([]string(nil), r.abandoned...)
+}
+
+// testConnector returns its receiver and its sessions one after the other
+type testConnector struct {
+	receiver *testReceiver
+	mu       sync.Mutex
+	sessions []*testReceiver
+}
+
+func (c *testConnector) newReceiver() (messageReceiver, error) {
+	return c.receiver, nil
+}
+
+func (c *testConnector) acceptSession(ctx context.Context, sessionID string) (sessionReceiver, error) {
+	c.mu.Lock()
+	if len(c.sessions) == 0 {
+		c.mu.Unlock()
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	defer c.mu.Unlock()
+
+	session := c.sessions[0]
+	c.sessions = c.sessions[1:]
+	return session, nil
+}
+
+func messages(bodies ...string) []*azservicebus.ReceivedMessage {
+	var msgs []*azservicebus.ReceivedMessage
+	for _, body := range bodies {
+		msgs = append(msgs, &azservicebus.ReceivedMessage{MessageID: body, Body: []byte(body)})
+	}
+	return msgs
+}
+
+func waitFor(condition func() bool) {
+	for i := 0; i < 200 && !condition(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestNewListener(t *testing.T) {
+	var bodies []string
+	handler := newHandler(t, collect(&bodies, nil))
+
+	l, err := newListener(&testConnector{}, handler, &HandlerSettings{Queue: "orders"}, log.RootLogger())
+	assert.Nil(t, err)
+	assert.Equal(t, "orders", l.entity)
+	assert.Equal(t, defaultMaxMessages, l.settings.MaxMessages)
+	assert.Equal(t, 1, l.settings.Concurrency)
+
+	l, err = newListener(&testConnector{}, handler, &HandlerSettings{Topic: "orders", Subscription: "billing", SessionID: "customer-1"}, log.RootLogger())
+	assert.Nil(t, err)
+	assert.Equal(t, "orders/billing", l.entity)
+	assert.True(t, l.settings.Sessions)
+
+	_, err = newListener(&testConnector{}, handler, &HandlerSettings{Queue: "orders", Topic: "orders"}, log.RootLogger())
+	assert.NotNil(t, err)
+
+	_, err = newListener(&testConnector{}, handler, &HandlerSettings{Topic: "orders"}, log.RootLogger())
+	assert.NotNil(t, err)
+
+	_, err = newListener(&testConnector{}, handler, &HandlerSettings{Queue: "orders", SessionIdleTimeout: "idle"}, log.RootLogger())
+	assert.NotNil(t, err)
+}
+
+func TestListenerMessages(t *testing.T) {
+	receiver := &testReceiver{batches: [][]*azservicebus.ReceivedMessage{messages("a", "b"), messages("c")}}
+	var bodies []string
+
+	l, err := newListener(&testConnector{receiver: receiver}, newHandler(t, collect(&bodies, map[string]bool{"b": true})), &HandlerSettings{Queue: "orders", Concurrency: 2}, log.RootLogger())
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	l.done = make(chan struct{})
+	go l.run(ctx)
+
+	waitFor(func() bool { completed, abandoned := receiver.settled(); return len(completed)+len(abandoned) == 3 })
+	cancel()
+	l.wait(time.Second)
+
+	completed, abandoned := receiver.settled()
+	assert.ElementsMatch(t, []string{"a", "c"}, completed)
+	assert.Equal(t, []string{"b"}, abandoned)
+	assert.True(t, receiver.closed)
+}
+
+func TestListenerSessions(t *testing.T) {
+	first := &testReceiver{sessionID: "first", batches: [][]*azservicebus.ReceivedMessage{messages("a", "b", "c"), messages("b", "c")}}
+	second := &testReceiver{sessionID: "second", batches: [][]*azservicebus.ReceivedMessage{messages("d")}}
+	var bodies []string
+
+	l, err := newListener(&testConnector{sessions: []*testReceiver{first, second}}, newHandler(t, collect(&bodies, map[string]bool{"b": true})),
+		&HandlerSettings{Queue: "orders", Sessions: true, SessionIdleTimeout: "50ms"}, log.RootLogger())
+	assert.Nil(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	l.done = make(chan struct{})
+	go l.run(ctx)
+
+	waitFor(func() bool { completed, _ := second.settled(); return len(completed) == 1 })
+	cancel()
+	l.wait(time.Second)
+
+	// the messages after a failed message of a session aren't handled
+	completed, abandoned := first.settled()
+	assert.Equal(t, []string{"a"}, completed)
+	assert.Equal(t, []string{"b", "c", "b", "c"}, abandoned)
+	assert.True(t, first.closed)
+
+	completed, _ = second.settled()
+	assert.Equal(t, []string{"d"}, completed)
+	assert.Equal(t, []string{"a", "b", "b", "d"}, bodies)
+}
+
+func TestRenew(t *testing.T) {
+	receiver := &testReceiver{}
+	var bodies []string
+	l, err := newListener(&testConnector{receiver: receiver}, newHandler(t, collect(&bodies, nil)), &HandlerSettings{Queue: "orders"}, log.RootLogger())
+	assert.Nil(t, err)
+
+	until := time.Now().Add(40 * time.Millisecond)
+	msg := &azservicebus.ReceivedMessage{LockedUntil: &until}
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		l.renew(stop, func() time.Time { return timeOf(msg.LockedUntil) }, func(ctx context.Context) error {
+			until = time.Now().Add(40 * time.Millisecond)
+			return receiver.RenewMessageLock(ctx, msg, nil)
+		})
+		close(done)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	close(stop)
+	<-done
+
+	receiver.mu.Lock()
+	assert.True(t, receiver.renewals >= 2)
+	receiver.mu.Unlock()
+}
+
+func TestToOutput(t *testing.T) {
+	enqueued := time.Unix(1600000000, 0)
+	sequence := int64(42)
+	session := "customer-1"
+
+	out := toOutput(&azservicebus.ReceivedMessage{
+		MessageID:             "id",
+		Body:                  []byte(`{"id":1}`),
+		ApplicationProperties: map[string]interface{}{"source": "test"},
+		SessionID:             &session,
+		DeliveryCount:         2,
+		SequenceNumber:        &sequence,
+		EnqueuedTime:          &enqueued,
+	})
+
+	assert.Equal(t, "id", out.MessageID)
+	assert.Equal(t, `{"id":1}`, out.Body)
+	assert.Equal(t, map[string]interface{}{"id": 1.0}, out.Content)
+	assert.Equal(t, map[string]interface{}{"source": "test"}, out.Properties)
+	assert.Equal(t, "customer-1", out.SessionID)
+	assert.Equal(t, 2, out.DeliveryCount)
+	assert.Equal(t, int64(42), out.SequenceNumber)
+	assert.Equal(t, int64(1600000000000), out.EnqueuedTime)
+}
+
+func TestTrigger(t *testing.T) {
+	f := &Factory{}
+	_, err := f.New(&trigger.Config{Settings: map[string]interface{}{}})
+	assert.NotNil(t, err)
+
+	config := &trigger.Config{}
+	err = json.Unmarshal([]byte(testConfig), config)
+	assert.Nil(t, err)
+
+	actions := map[string]action.Action{"dummy": test.NewDummyAction(func() {
+		//do nothing
+	})}
+
+	tgr, err := test.InitTrigger(f, config, actions)
+	assert.Nil(t, err)
+
+	l := tgr.(*Trigger).listeners[0]
+	assert.Equal(t, "orders/billing", l.entity)
+	assert.Nil(t, tgr.Stop())
+}
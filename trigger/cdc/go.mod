@@ -0,0 +1,9 @@
+module github.com/qingcloudhx/contrib/trigger/cdc
+
+require (
+	flogo/core v0.9.0
+	github.com/go-mysql-org/go-mysql v1.7.0
+	github.com/jackc/pglogrepl v0.0.0-20231111135425-1627ab1b5780
+	github.com/jackc/pgx/v5 v5.5.3
+	github.com/stretchr/testify v1.3.0
+)
@@ -0,0 +1,233 @@
+package cdc
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"flogo/core/support/log"
+	"github.com/go-mysql-org/go-mysql/canal"
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/go-mysql-org/go-mysql/replication"
+	"github.com/go-mysql-org/go-mysql/schema"
+)
+
+// mysqlSource tails the binlog of a MySQL or MariaDB server, which requires the row format
+type mysqlSource struct {
+	canal.DummyEventHandler
+
+	dbURL    *url.URL
+	settings *Settings
+	tables   []string
+	dispatch func(out *Output)
+	logger   log.Logger
+
+	mu         sync.Mutex
+	position   mysql.Position
+	binlogFile string
+}
+
+func newMySQLSource(dbURL *url.URL, settings *Settings, tables []string, dispatch func(out *Output), logger log.Logger) *mysqlSource {
+	return &mysqlSource{dbURL: dbURL, settings: settings, tables: tables, dispatch: dispatch, logger: logger}
+}
+
+// config returns the configuration of the canal, which only tails the binlog
+func (s *mysqlSource) config() *canal.Config {
+
+	cfg := canal.NewDefaultConfig()
+
+	cfg.Addr = s.dbURL.Host
+	if s.dbURL.Port() == "" {
+		cfg.Addr = net.JoinHostPort(s.dbURL.Hostname(), "3306")
+	}
+	cfg.User = s.dbURL.User.Username()
+	cfg.Password, _ = s.dbURL.User.Password()
+	if s.dbURL.Scheme == "mariadb" {
+		cfg.Flavor = mysql.MariaDBFlavor
+	}
+	if s.settings.ServerID > 0 {
+		cfg.ServerID = uint32(s.settings.ServerID)
+	}
+	cfg.Dump.ExecutionPath = ""
+
+	for _, table := range s.tables {
+		cfg.IncludeTableRegex = append(cfg.IncludeTableRegex, "^"+regexp.QuoteMeta(table)+"$")
+	}
+
+	return cfg
+}
+
+func (s *mysqlSource) run(ctx context.Context) error {
+
+	c, err := canal.NewCanal(s.config())
+	if err != nil {
+		return fmt.Errorf("unable to connect to '%s': %v", s.dbURL.Host, err)
+	}
+	c.SetEventHandler(s)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+		c.Close()
+	}()
+
+	// the trigger resumes from the last position synced, saved in the position file across restarts
+	s.mu.Lock()
+	position := s.position
+	s.mu.Unlock()
+
+	if position.Name == "" && s.settings.PositionFile != "" {
+		position, err = loadPosition(s.settings.PositionFile)
+		if err != nil {
+			return err
+		}
+	}
+	if position.Name == "" {
+		position, err = c.GetMasterPos()
+		if err != nil {
+			return fmt.Errorf("unable to get the binlog position: %v", err)
+		}
+	}
+
+	s.binlogFile = position.Name
+	s.logger.Infof("Tailing binlog of '%s' from %s", s.dbURL.Host, position)
+
+	err = c.RunFrom(position)
+	if err == nil && ctx.Err() == nil {
+		err = fmt.Errorf("binlog tailing stopped")
+	}
+
+	return err
+}
+
+// OnRotate implements canal.EventHandler.OnRotate
+func (s *mysqlSource) OnRotate(header *replication.EventHeader, rotate *replication.RotateEvent) error {
+	s.binlogFile = string(rotate.NextLogName)
+	return nil
+}
+
+// OnRow implements canal.EventHandler.OnRow
+func (s *mysqlSource) OnRow(e *canal.RowsEvent) error {
+
+	position := fmt.Sprintf("%s:%d", s.binlogFile, e.Header.LogPos)
+
+	// the rows of an update are pairs of images, before and after
+	step := 1
+	if e.Action == canal.UpdateAction {
+		step = 2
+	}
+
+	for i := 0; i+step <= len(e.Rows); i += step {
+		out := &Output{
+			Operation: e.Action,
+			Schema:    e.Table.Schema,
+			Table:     e.Table.Name,
+			Timestamp: int64(e.Header.Timestamp) * 1000,
+			Position:  position,
+		}
+
+		switch e.Action {
+		case canal.InsertAction:
+			out.After = toColumns(e.Table.Columns, e.Rows[i])
+		case canal.UpdateAction:
+			out.Before = toColumns(e.Table.Columns, e.Rows[i])
+			out.After = toColumns(e.Table.Columns, e.Rows[i+1])
+		case canal.DeleteAction:
+			out.Before = toColumns(e.Table.Columns, e.Rows[i])
+		default:
+			return nil
+		}
+
+		s.dispatch(out)
+	}
+
+	return nil
+}
+
+// OnPosSynced implements canal.EventHandler.OnPosSynced, called once the changes of a transaction are handled
+func (s *mysqlSource) OnPosSynced(header *replication.EventHeader, pos mysql.Position, set mysql.GTIDSet, force bool) error {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if pos.Name == "" || pos == s.position {
+		return nil
+	}
+	s.position = pos
+
+	if s.settings.PositionFile != "" {
+		if err := savePosition(s.settings.PositionFile, pos); err != nil {
+			s.logger.Errorf("Error saving binlog position: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// String implements canal.EventHandler.String
+func (s *mysqlSource) String() string {
+	return "flogo"
+}
+
+func toColumns(columns []schema.TableColumn, row []interface{}) map[string]interface{} {
+
+	values := make(map[string]interface{}, len(columns))
+	for i, column := range columns {
+		if i >= len(row) {
+			break
+		}
+		if value, ok := row[i].([]byte); ok {
+			values[column.Name] = string(value)
+		} else {
+			values[column.Name] = row[i]
+		}
+	}
+
+	return values
+}
+
+// loadPosition reads the position of the file, the zero position if the file doesn't exist
+func loadPosition(file string) (mysql.Position, error) {
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return mysql.Position{}, nil
+		}
+		return mysql.Position{}, fmt.Errorf("unable to read position file [%s]: %v", file, err)
+	}
+
+	text := strings.TrimSpace(string(data))
+	sep := strings.LastIndex(text, ":")
+	if sep <= 0 {
+		return mysql.Position{}, fmt.Errorf("invalid position '%s' in file [%s]", text, file)
+	}
+	pos, err := strconv.ParseUint(text[sep+1:], 10, 32)
+	if err != nil {
+		return mysql.Position{}, fmt.Errorf("invalid position '%s' in file [%s]", text, file)
+	}
+
+	return mysql.Position{Name: text[:sep], Pos: uint32(pos)}, nil
+}
+
+// savePosition writes the position to the file, replacing it so that it is never partially written
+func savePosition(file string, pos mysql.Position) error {
+
+	tmp := file + ".tmp"
+	if err := ioutil.WriteFile(tmp, []byte(fmt.Sprintf("%s:%d\n", pos.Name, pos.Pos)), 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, file)
+}
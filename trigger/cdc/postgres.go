@@ -0,0 +1,235 @@
+package cdc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"flogo/core/support/log"
+	"github.com/jackc/pglogrepl"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgproto3"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const (
+	statusInterval   = 10 * time.Second
+	duplicateObject  = "42710"
+	outputPluginName = "pgoutput"
+)
+
+// postgresSource consumes a logical replication slot of a PostgreSQL server with the pgoutput plugin,
+// the position of the slot is confirmed once the changes of a transaction are handled
+type postgresSource struct {
+	connString string
+	host       string
+	settings   *Settings
+	dispatch   func(out *Output)
+	logger     log.Logger
+
+	relations map[uint32]*pglogrepl.RelationMessage
+	begin     *pglogrepl.BeginMessage
+	confirmed pglogrepl.LSN
+}
+
+func newPostgresSource(dbURL *url.URL, settings *Settings, dispatch func(out *Output), logger log.Logger) (*postgresSource, error) {
+
+	if settings.Slot == "" || settings.Publication == "" {
+		return nil, fmt.Errorf("a slot and a publication must be specified for PostgreSQL")
+	}
+
+	// the connection is a replication connection to the database
+	connURL := *dbURL
+	query := connURL.Query()
+	query.Set("replication", "database")
+	connURL.RawQuery = query.Encode()
+
+	return &postgresSource{connString: connURL.String(), host: dbURL.Host, settings: settings, dispatch: dispatch, logger: logger}, nil
+}
+
+func (s *postgresSource) run(ctx context.Context) error {
+
+	conn, err := pgconn.Connect(ctx, s.connString)
+	if err != nil {
+		return fmt.Errorf("unable to connect to '%s': %v", s.host, err)
+	}
+	defer conn.Close(context.Background())
+
+	if s.settings.CreateSlot {
+		_, err = pglogrepl.CreateReplicationSlot(ctx, conn, s.settings.Slot, outputPluginName, pglogrepl.CreateReplicationSlotOptions{Mode: pglogrepl.LogicalReplication})
+		var pgErr *pgconn.PgError
+		if err != nil && !(errors.As(err, &pgErr) && pgErr.Code == duplicateObject) {
+			return fmt.Errorf("unable to create replication slot '%s': %v", s.settings.Slot, err)
+		}
+	}
+
+	// the replication starts from the position confirmed to the slot
+	err = pglogrepl.StartReplication(ctx, conn, s.settings.Slot, 0, pglogrepl.StartReplicationOptions{
+		PluginArgs: []string{"proto_version '1'", fmt.Sprintf("publication_names '%s'", s.settings.Publication)},
+	})
+	if err != nil {
+		return fmt.Errorf("unable to start replication of slot '%s': %v", s.settings.Slot, err)
+	}
+
+	s.logger.Infof("Consuming replication slot '%s' of '%s'", s.settings.Slot, s.host)
+
+	// the relations are sent again by the server for each connection
+	s.relations = make(map[uint32]*pglogrepl.RelationMessage)
+	s.begin = nil
+
+	nextStatus := time.Now()
+	for {
+		if !time.Now().Before(nextStatus) {
+			if s.confirmed > 0 {
+				err = pglogrepl.SendStandbyStatusUpdate(ctx, conn, pglogrepl.StandbyStatusUpdate{WALWritePosition: s.confirmed})
+				if err != nil {
+					return fmt.Errorf("unable to confirm position %s: %v", s.confirmed, err)
+				}
+			}
+			nextStatus = time.Now().Add(statusInterval)
+		}
+
+		receiveCtx, cancel := context.WithDeadline(ctx, nextStatus)
+		msg, err := conn.ReceiveMessage(receiveCtx)
+		cancel()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if pgconn.Timeout(err) {
+				continue
+			}
+			return err
+		}
+
+		switch msg := msg.(type) {
+		case *pgproto3.ErrorResponse:
+			return pgconn.ErrorResponseToPgError(msg)
+		case *pgproto3.CopyData:
+			if len(msg.Data) == 0 {
+				continue
+			}
+			switch msg.Data[0] {
+			case pglogrepl.PrimaryKeepaliveMessageByteID:
+				keepalive, err := pglogrepl.ParsePrimaryKeepaliveMessage(msg.Data[1:])
+				if err != nil {
+					return err
+				}
+				if keepalive.ReplyRequested {
+					nextStatus = time.Time{}
+				}
+			case pglogrepl.XLogDataByteID:
+				data, err := pglogrepl.ParseXLogData(msg.Data[1:])
+				if err != nil {
+					return err
+				}
+				logical, err := pglogrepl.Parse(data.WALData)
+				if err != nil {
+					return fmt.Errorf("unable to parse logical replication message: %v", err)
+				}
+				s.process(logical)
+			}
+		}
+	}
+}
+
+// process dispatches the changes of the messages of the transactions
+func (s *postgresSource) process(msg pglogrepl.Message) {
+
+	switch msg := msg.(type) {
+	case *pglogrepl.RelationMessage:
+		s.relations[msg.RelationID] = msg
+	case *pglogrepl.BeginMessage:
+		s.begin = msg
+	case *pglogrepl.CommitMessage:
+		s.begin = nil
+		s.confirmed = msg.TransactionEndLSN
+	case *pglogrepl.InsertMessage:
+		if out := s.output(OperationInsert, msg.RelationID); out != nil {
+			out.After = s.toColumns(msg.RelationID, msg.Tuple)
+			s.dispatch(out)
+		}
+	case *pglogrepl.UpdateMessage:
+		if out := s.output(OperationUpdate, msg.RelationID); out != nil {
+			out.Before = s.toColumns(msg.RelationID, msg.OldTuple)
+			out.After = s.toColumns(msg.RelationID, msg.NewTuple)
+			s.dispatch(out)
+		}
+	case *pglogrepl.DeleteMessage:
+		if out := s.output(OperationDelete, msg.RelationID); out != nil {
+			out.Before = s.toColumns(msg.RelationID, msg.OldTuple)
+			s.dispatch(out)
+		}
+	}
+}
+
+func (s *postgresSource) output(operation string, relationID uint32) *Output {
+
+	relation, ok := s.relations[relationID]
+	if !ok {
+		s.logger.Errorf("Unknown relation %d of %s", relationID, operation)
+		return nil
+	}
+
+	out := &Output{Operation: operation, Schema: relation.Namespace, Table: relation.RelationName}
+	if s.begin != nil {
+		out.Timestamp = s.begin.CommitTime.UnixNano() / int64(time.Millisecond)
+		out.Position = s.begin.FinalLSN.String()
+	}
+
+	return out
+}
+
+// toColumns decodes the columns of the tuple, the unchanged TOAST values aren't sent by the server
+func (s *postgresSource) toColumns(relationID uint32, tuple *pglogrepl.TupleData) map[string]interface{} {
+
+	if tuple == nil {
+		return nil
+	}
+
+	relation := s.relations[relationID]
+	values := make(map[string]interface{}, len(tuple.Columns))
+	for i, column := range tuple.Columns {
+		if i >= len(relation.Columns) {
+			break
+		}
+		name := relation.Columns[i].Name
+		switch column.DataType {
+		case pglogrepl.TupleDataTypeNull:
+			values[name] = nil
+		case pglogrepl.TupleDataTypeText:
+			values[name] = decodeText(relation.Columns[i].DataType, string(column.Data))
+		}
+	}
+
+	return values
+}
+
+// decodeText decodes the text representation of the booleans, the numbers and the JSON values,
+// the other types are kept as text
+func decodeText(oid uint32, text string) interface{} {
+
+	switch oid {
+	case pgtype.BoolOID:
+		return text == "t"
+	case pgtype.Int2OID, pgtype.Int4OID, pgtype.Int8OID:
+		if value, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return value
+		}
+	case pgtype.Float4OID, pgtype.Float8OID:
+		if value, err := strconv.ParseFloat(text, 64); err == nil {
+			return value
+		}
+	case pgtype.JSONOID, pgtype.JSONBOID:
+		var value interface{}
+		if err := json.Unmarshal([]byte(text), &value); err == nil {
+			return value
+		}
+	}
+
+	return text
+}
@@ -0,0 +1,209 @@
+package cdc
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"flogo/core/data/metadata"
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+)
+
+const retryInterval = 5 * time.Second
+
+var triggerMd = trigger.NewMetadata(&Settings{}, &HandlerSettings{}, &Output{})
+
+func init() {
+	_ = trigger.Register(&Trigger{}, &Factory{})
+}
+
+type Factory struct {
+}
+
+// Metadata implements trigger.Factory.Metadata
+func (*Factory) Metadata() *trigger.Metadata {
+	return triggerMd
+}
+
+// New implements trigger.Factory.New
+func (*Factory) New(config *trigger.Config) (trigger.Trigger, error) {
+
+	s := &Settings{}
+	err := metadata.MapToStruct(config.Settings, s, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Trigger{settings: s}, nil
+}
+
+// source streams the changes of the database until the context is done or the connection fails
+type source interface {
+	run(ctx context.Context) error
+}
+
+// Trigger captures the changes of MySQL and PostgreSQL databases
+type Trigger struct {
+	settings *Settings
+	logger   log.Logger
+	handlers []*handler
+
+	source source
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// handler handles the changes of the tables and the operations of a trigger handler
+type handler struct {
+	handler    trigger.Handler
+	tables     map[string]bool
+	operations map[string]bool
+}
+
+// Initialize implements trigger.Init.Initialize
+func (t *Trigger) Initialize(ctx trigger.InitContext) error {
+
+	t.logger = ctx.Logger()
+
+	// the tables of all the handlers, all the tables if a handler doesn't specify them
+	var tables []string
+	allTables := false
+
+	for _, h := range ctx.GetHandlers() {
+
+		s := &HandlerSettings{}
+		err := metadata.MapToStruct(h.Settings(), s, true)
+		if err != nil {
+			return err
+		}
+
+		th := &handler{handler: h, tables: make(map[string]bool), operations: make(map[string]bool)}
+
+		for _, table := range splitList(s.Tables) {
+			if !strings.Contains(table, ".") {
+				return fmt.Errorf("handler '%s': table '%s' must be qualified with its database or schema", h.Name(), table)
+			}
+			th.tables[table] = true
+			tables = append(tables, table)
+		}
+		if len(th.tables) == 0 {
+			allTables = true
+		}
+
+		for _, operation := range splitList(s.Operations) {
+			switch operation {
+			case OperationInsert, OperationUpdate, OperationDelete:
+				th.operations[operation] = true
+			default:
+				return fmt.Errorf("handler '%s': unsupported operation '%s'", h.Name(), operation)
+			}
+		}
+
+		t.handlers = append(t.handlers, th)
+	}
+	if allTables {
+		tables = nil
+	}
+
+	dbURL, err := url.Parse(t.settings.URL)
+	if err != nil {
+		return fmt.Errorf("invalid url: %v", err)
+	}
+
+	switch dbURL.Scheme {
+	case "mysql", "mariadb":
+		t.source = newMySQLSource(dbURL, t.settings, tables, t.dispatch, t.logger)
+	case "postgres", "postgresql":
+		t.source, err = newPostgresSource(dbURL, t.settings, t.dispatch, t.logger)
+	default:
+		err = fmt.Errorf("unsupported url scheme '%s', mysql, mariadb or postgres expected", dbURL.Scheme)
+	}
+
+	return err
+}
+
+// Start implements util.Managed.Start
+func (t *Trigger) Start() error {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+	t.done = make(chan struct{})
+
+	go t.run(ctx)
+
+	return nil
+}
+
+// Stop implements util.Managed.Stop
+func (t *Trigger) Stop() error {
+
+	if t.cancel == nil {
+		return nil
+	}
+
+	t.cancel()
+	t.cancel = nil
+
+	select {
+	case <-t.done:
+	case <-time.After(30 * time.Second):
+		t.logger.Warnf("Change still being handled")
+	}
+
+	return nil
+}
+
+// run streams the changes until the context is done, reconnecting when the connection fails
+func (t *Trigger) run(ctx context.Context) {
+
+	defer close(t.done)
+
+	for {
+		err := t.source.run(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		t.logger.Errorf("Error capturing changes: %v", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+// dispatch invokes the actions of the handlers of the change, one after the other
+func (t *Trigger) dispatch(out *Output) {
+
+	table := out.Schema + "." + out.Table
+
+	for _, h := range t.handlers {
+		if len(h.tables) > 0 && !h.tables[table] {
+			continue
+		}
+		if len(h.operations) > 0 && !h.operations[out.Operation] {
+			continue
+		}
+
+		_, err := h.handler.Handle(context.Background(), out)
+		if err != nil {
+			t.logger.Errorf("Error handling %s of table '%s' at %s: %v", out.Operation, table, out.Position, err)
+		}
+	}
+}
+
+func splitList(list string) []string {
+
+	var values []string
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
@@ -4,50 +4,65 @@ import "flogo/core/data/coerce"
 
 const ovArgs = "args"
 const ovFlags = "flags"
+const ovStdin = "stdin"
 
 type Settings struct {
-	SingleCmd bool   `md:"singleCmd"`  // Indicates that this CLI runs only one command/handler
-	Usage     string `md:"usage"`      // The usage details of the CLI
-	Long      string `md:"long"`       // The description of the CLI
+	SingleCmd bool   `md:"singleCmd"` // Indicates that this CLI runs only one command/handler
+	Usage     string `md:"usage"`     // The usage details of the CLI
+	Long      string `md:"long"`      // The description of the CLI
 }
 
 type HandlerSettings struct {
-	FlagDesc []interface{} `md:"flags"`
-	Usage    string        `md:"usage"` // The usage details of the command
-	Short    string        `md:"short"` // A short description of the command
-	Long     string        `md:"long"`  // The description of the command
+	FlagDesc  []interface{} `md:"flags"`     // The flags of the command, each flag is defined as 'name||default||description'
+	Usage     string        `md:"usage"`     // The usage details of the command
+	Short     string        `md:"short"`     // A short description of the command
+	Long      string        `md:"long"`      // The description of the command
+	ReadStdin bool          `md:"readStdin"` // Read the standard input until its end before invoking the action
 }
 
 type Output struct {
-	Args  []interface{}          `md:"args"`   // An array of the command line arguments
-	Flags map[string]interface{} `md:"flags"`  // A map of the command line flags
+	Args  []interface{}          `md:"args"`  // An array of the command line arguments
+	Flags map[string]interface{} `md:"flags"` // A map of the command line flags
+	Stdin string                 `md:"stdin"` // The content of the standard input, when read by the command
 }
 
 func (o *Output) ToMap() map[string]interface{} {
 	return map[string]interface{}{
 		ovArgs:  o.Args,
 		ovFlags: o.Flags,
+		ovStdin: o.Stdin,
 	}
 }
 
 func (o *Output) FromMap(values map[string]interface{}) error {
 	var err error
 	o.Args, err = coerce.ToArray(values[ovArgs])
+	if err != nil {
+		return err
+	}
 	o.Flags, err = coerce.ToObject(values[ovFlags])
+	if err != nil {
+		return err
+	}
+	o.Stdin, err = coerce.ToString(values[ovStdin])
 	return err
 }
 
 type Reply struct {
 	Data interface{} `md:"data"` // The data that the command outputs
+	Code int         `md:"code"` // The exit code of the process, 0 if not set
 }
 
 func (r *Reply) ToMap() map[string]interface{} {
 	return map[string]interface{}{
 		"data": r.Data,
+		"code": r.Code,
 	}
 }
 
 func (r *Reply) FromMap(values map[string]interface{}) error {
+	var err error
 	r.Data, _ = values["data"]
-	return nil
+	r.Code, err = coerce.ToInt(values["code"])
+	return err
 }
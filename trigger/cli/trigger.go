@@ -2,8 +2,11 @@ package cli
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -17,7 +20,10 @@ import (
 	"flogo/core/trigger"
 )
 
-var triggerMd = trigger.NewMetadata(&HandlerSettings{}, &Output{}, &Reply{})
+var triggerMd = trigger.NewMetadata(&Settings{}, &HandlerSettings{}, &Output{}, &Reply{})
+
+// errUsage is returned when the command or its flags are invalid, its usage being written to stderr
+var errUsage = errors.New("invalid usage")
 
 func init() {
 	trigger.Register(&Trigger{}, &Factory{})
@@ -141,8 +147,56 @@ func (t *Trigger) Stop() error {
 	return nil
 }
 
+// Invoke runs the command of the arguments of the process and returns the data of its reply, the
+// process exits after the help and version commands and the invalid commands
 func Invoke() (string, error) {
 
+	setLogLevel()
+
+	reply, err := singleton.execute(os.Args, os.Stdin, os.Stdout, os.Stderr)
+	if err == errUsage {
+		os.Exit(1)
+	}
+	if err != nil {
+		return "", err
+	}
+	if reply == nil {
+		os.Exit(0)
+	}
+
+	return coerce.ToString(reply.Data)
+}
+
+// Run runs the command of the arguments of the process, writes the data of its reply to stdout and
+// returns the exit code of the reply
+func Run() int {
+
+	setLogLevel()
+
+	reply, err := singleton.execute(os.Args, os.Stdin, os.Stdout, os.Stderr)
+	if err == errUsage {
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		return 1
+	}
+	if reply == nil {
+		return 0
+	}
+
+	data, err := coerce.ToString(reply.Data)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		return 1
+	}
+	fmt.Fprintf(os.Stdout, "%s", data)
+
+	return reply.Code
+}
+
+func setLogLevel() {
+
 	logger := trigger.GetLogger(support.GetRef(singleton))
 
 	lvl := os.Getenv("FLOGO_LOG_LEVEL")
@@ -152,113 +206,131 @@ func Invoke() (string, error) {
 	} else {
 		log.SetLogLevel(logger, log.ToLogLevel(lvl))
 	}
+}
 
-	cliPath, _ := os.Executable()
-	cliName := filepath.Base(cliPath)
-	
-	//Todo?
-	if singleton.settings.SingleCmd {
+// execute runs the command of the arguments, the help and the version being written to stdout, and
+// returns the reply of the handler of the command, nil if no handler was invoked
+func (t *Trigger) execute(osArgs []string, stdin io.Reader, stdout, stderr io.Writer) (*Reply, error) {
 
-	}
+	cliName := filepath.Base(osArgs[0])
 
 	var cmdName string
 
-	if len(os.Args) == 1 {
-
-		if singleton.settings.SingleCmd {
-			//cli is a single command, assumes one handler
-			var hCmd *handlerCmd
-			for _, cmd := range singleton.commands {
-				hCmd = cmd
-				break
-			}
-
-			if hCmd == nil {
-				fmt.Fprintf(os.Stderr, "Error: cli improperly configured, needs at least one handler\n")
-				os.Exit(1)
-			}
-
-			flags, args := getFlagsAndArgs(hCmd, cliName, false)
-			return singleton.Invoke(hCmd.handler, flags, args)
-		}
-
-		help(cliName, singleton, false)
-		os.Exit(0)
-	} else {
-		cmdName = os.Args[1]
+	if len(osArgs) > 1 {
+		cmdName = osArgs[1]
 	}
 
 	if strings.EqualFold(cmdName, "help") {
-		if len(os.Args) == 2 {
-			help(cliName, singleton, false)
-			os.Exit(0)
+		if len(osArgs) == 2 {
+			help(stdout, cliName, t, false)
+			return nil, nil
 		}
 
-		subCmd := os.Args[2]
+		subCmd := osArgs[2]
 
-		handlerCmd, exists := singleton.commands[subCmd]
+		handlerCmd, exists := t.commands[subCmd]
 		if !exists {
-			fmt.Fprintf(os.Stderr, "Error: unknown command %#q\n", cmdName)
-			help(cliName, singleton, true)
-			os.Exit(1)
+			fmt.Fprintf(stderr, "Error: unknown command %#q\n", subCmd)
+			help(stderr, cliName, t, true)
+			return nil, errUsage
 		}
 
-		helpCmd(cliName, handlerCmd, false)
-		os.Exit(0)
+		helpCmd(stdout, cliName, handlerCmd, false)
+		return nil, nil
 	}
 
 	if strings.EqualFold(cmdName, "version") {
 
-		fmt.Fprintf(os.Stdout, "%s version %s\n", cliName, engine.GetAppVersion())
-		os.Exit(0)
+		fmt.Fprintf(stdout, "%s version %s\n", cliName, engine.GetAppVersion())
+		return nil, nil
 	}
 
-	hCmd, exists := singleton.commands[cmdName]
-	if !exists {
-		fmt.Fprintf(os.Stderr, "Error: unknown command %#q\n", cmdName)
-		help(cliName, singleton, true)
-		os.Exit(1)
+	var hCmd *handlerCmd
+	args := osArgs[1:]
+
+	if t.settings.SingleCmd {
+		//cli is a single command, assumes one handler, whose name is optional
+		for _, cmd := range t.commands {
+			hCmd = cmd
+			break
+		}
+
+		if hCmd == nil {
+			fmt.Fprintf(stderr, "Error: cli improperly configured, needs at least one handler\n")
+			return nil, errUsage
+		}
+
+		if len(args) > 0 && args[0] == hCmd.handler.Name() {
+			args = args[1:]
+		}
+	} else {
+		if cmdName == "" {
+			help(stdout, cliName, t, false)
+			return nil, nil
+		}
+
+		var exists bool
+		hCmd, exists = t.commands[cmdName]
+		if !exists {
+			fmt.Fprintf(stderr, "Error: unknown command %#q\n", cmdName)
+			help(stderr, cliName, t, true)
+			return nil, errUsage
+		}
+		args = args[1:]
 	}
 
-	flags, args := getFlagsAndArgs(hCmd, cliName, true)
+	flags, cmdArgs, err := getFlagsAndArgs(hCmd, args)
+	if err == flag.ErrHelp {
+		helpCmd(stdout, cliName, hCmd, false)
+		return nil, nil
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "Error: %s.\n", err.Error())
+		helpCmd(stderr, cliName, hCmd, true)
+		return nil, errUsage
+	}
 
-	return singleton.Invoke(hCmd.handler, flags, args)
+	var input string
+	if hCmd.settings.ReadStdin {
+		content, err := ioutil.ReadAll(stdin)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read stdin: %v", err)
+		}
+		input = string(content)
+	}
+
+	return t.Invoke(hCmd.handler, flags, cmdArgs, input)
 }
 
-func (t *Trigger) Invoke(handler trigger.Handler, flags map[string]interface{}, args []string) (string, error) {
+// Invoke invokes the handler with the flags, the arguments and the standard input of its command
+func (t *Trigger) Invoke(handler trigger.Handler, flags map[string]interface{}, args []string, stdin string) (*Reply, error) {
 
 	t.logger.Debugf("invoking handler '%s'", handler)
 
-	data := map[string]interface{}{
-		"args":  args,
-		"flags": flags,
+	out := &Output{Args: make([]interface{}, len(args)), Flags: flags, Stdin: stdin}
+	for i, arg := range args {
+		out.Args[i] = arg
 	}
 
-	results, err := handler.Handle(context.Background(), data)
+	results, err := handler.Handle(context.Background(), out.ToMap())
 
 	if err != nil {
 		t.logger.Debugf("error: %s", err.Error())
-		return "", err
+		return nil, err
 	}
 
-	replyData := results["data"]
-	stringData, _ := coerce.ToString(replyData)
-
-	return stringData, nil
+	reply := &Reply{}
+	if err := reply.FromMap(results); err != nil {
+		return nil, fmt.Errorf("invalid reply: %v", err)
+	}
 
-	//if replyData != nil {
-	//	data, err := json.Marshal(replyData)
-	//	if err != nil {
-	//		return "", err
-	//	}
-	//	return string(data), nil
-	//}
+	return reply, nil
 }
 
-func help(cliName string, t *Trigger, isErr bool) {
-	printMainUsage(cliName, t, isErr)
+func help(w io.Writer, cliName string, t *Trigger, isErr bool) {
+	printMainUsage(w, cliName, t, isErr)
 }
 
-func helpCmd(cliName string, hc *handlerCmd, isErr bool) {
-	printCmdUsage(cliName, hc, isErr)
+func helpCmd(w io.Writer, cliName string, hc *handlerCmd, isErr bool) {
+	printCmdUsage(w, cliName, hc, isErr)
 }
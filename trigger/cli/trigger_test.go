@@ -1,10 +1,16 @@
 package cli
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"strings"
 	"testing"
 
 	"flogo/core/action"
+	"flogo/core/api"
 	"flogo/core/support/test"
 	"flogo/core/trigger"
 	"github.com/stretchr/testify/assert"
@@ -65,8 +71,116 @@ func TestCliTrigger_Initialize(t *testing.T) {
 	trg, err := test.InitTrigger(f, config, actions)
 	assert.Nil(t, err)
 	assert.NotNil(t, trg)
-	_, err = Invoke()
+	reply, err := singleton.execute([]string{"cli"}, strings.NewReader(""), ioutil.Discard, ioutil.Discard)
 	assert.Nil(t, err)
+	assert.NotNil(t, reply)
+}
+
+// initTrigger returns a trigger initialized with the settings and a handler of the command running the function
+func initTrigger(t *testing.T, settings map[string]interface{}, command string, handlerSettings map[string]interface{}, f api.HandlerFunc) *Trigger {
+
+	config := &trigger.Config{}
+	err := json.Unmarshal([]byte(testConfig), config)
+	assert.Nil(t, err)
+	config.Settings = settings
+	config.Handlers[0].Name = command
+	config.Handlers[0].Settings = handlerSettings
+
+	trg, err := test.InitTrigger(&Factory{}, config, map[string]action.Action{"dummy": api.NewProxyAction(f)})
+	assert.Nil(t, err)
+
+	return trg.(*Trigger)
+}
+
+func TestExecute(t *testing.T) {
+
+	var output map[string]interface{}
+	tgr := initTrigger(t, map[string]interface{}{"long": "text utilities"},
+		"upper", map[string]interface{}{"flags": []interface{}{"prefix||||the prefix", "trim||false||trims the input"}, "readStdin": true},
+		func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+			output = inputs
+			return map[string]interface{}{"data": "HELLO", "code": 3}, nil
+		})
+
+	reply, err := tgr.execute([]string{"text", "upper", "-prefix", ">", "-trim", "a", "b"}, strings.NewReader("hello\n"), ioutil.Discard, ioutil.Discard)
+	assert.Nil(t, err)
+	assert.Equal(t, "HELLO", reply.Data)
+	assert.Equal(t, 3, reply.Code)
+	assert.Equal(t, []interface{}{"a", "b"}, output["args"])
+	assert.Equal(t, map[string]interface{}{"prefix": ">", "trim": true}, output["flags"])
+	assert.Equal(t, "hello\n", output["stdin"])
+
+	// the standard input is only read when configured
+	var actionErr error
+	tgr = initTrigger(t, map[string]interface{}{"long": "text utilities"}, "count", nil,
+		func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+			output = inputs
+			return map[string]interface{}{"data": 2}, actionErr
+		})
+
+	reply, err = tgr.execute([]string{"text", "count", "a"}, strings.NewReader("ignored"), ioutil.Discard, ioutil.Discard)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, reply.Data)
+	assert.Equal(t, 0, reply.Code)
+	assert.Equal(t, "", output["stdin"])
+
+	actionErr = errors.New("failed")
+	_, err = tgr.execute([]string{"text", "count"}, strings.NewReader(""), ioutil.Discard, ioutil.Discard)
+	assert.Equal(t, actionErr, err)
+}
+
+func TestExecute_Usage(t *testing.T) {
+
+	var output map[string]interface{}
+	tgr := initTrigger(t, map[string]interface{}{"long": "text utilities"},
+		"upper", map[string]interface{}{"short": "converts to upper case", "flags": []interface{}{"prefix||||the prefix"}},
+		func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+			output = inputs
+			return nil, nil
+		})
+
+	var stdout, stderr bytes.Buffer
+	reply, err := tgr.execute([]string{"text"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Nil(t, err)
+	assert.Nil(t, reply)
+	assert.Contains(t, stdout.String(), "text utilities")
+	assert.Contains(t, stdout.String(), "converts to upper case")
+
+	stdout.Reset()
+	reply, err = tgr.execute([]string{"text", "upper", "-h"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Nil(t, err)
+	assert.Nil(t, reply)
+	assert.Contains(t, stdout.String(), "-prefix")
+
+	_, err = tgr.execute([]string{"text", "lower"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, errUsage, err)
+	assert.Contains(t, stderr.String(), "unknown command `lower`")
+
+	stderr.Reset()
+	_, err = tgr.execute([]string{"text", "upper", "-suffix", "!"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, errUsage, err)
+	assert.Contains(t, stderr.String(), "flag provided but not defined")
+	assert.Nil(t, output)
+}
+
+func TestExecute_SingleCmd(t *testing.T) {
+
+	var output map[string]interface{}
+	tgr := initTrigger(t, map[string]interface{}{"singleCmd": true}, "upper", map[string]interface{}{"flags": []interface{}{"prefix||||the prefix"}},
+		func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+			output = inputs
+			return nil, nil
+		})
+
+	// the arguments are the arguments of the command, with or without its name
+	_, err := tgr.execute([]string{"upper", "-prefix", ">", "a"}, strings.NewReader(""), ioutil.Discard, ioutil.Discard)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"a"}, output["args"])
+
+	_, err = tgr.execute([]string{"text", "upper", "b"}, strings.NewReader(""), ioutil.Discard, ioutil.Discard)
+	assert.Nil(t, err)
+	assert.Equal(t, []interface{}{"b"}, output["args"])
+	assert.Equal(t, map[string]interface{}{"prefix": ""}, output["flags"])
 }
 
 /*
@@ -6,42 +6,45 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
-	"os"
 	"strings"
 	"text/template"
 )
 
-func getFlagsAndArgs(hCmd *handlerCmd, cliName string, subCmd bool) (map[string]interface{}, []string) {
+// getFlagsAndArgs parses the arguments of the command, the values of its flags are returned with
+// the remaining arguments
+func getFlagsAndArgs(hCmd *handlerCmd, cmdArgs []string) (map[string]interface{}, []string, error) {
 	hCmd.flagSet.SetOutput(ioutil.Discard)
 
-	idx := 1
-	if subCmd {
-		idx = 2
-	}
+	// the flags of a previous invocation are reset
+	hCmd.flagSet.VisitAll(func(f *flag.Flag) {
+		_ = f.Value.Set(f.DefValue)
+	})
 
-	err := hCmd.flagSet.Parse(os.Args[idx:])
+	err := hCmd.flagSet.Parse(cmdArgs)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %s.\n", err.Error())
-		printCmdUsage(cliName, hCmd, true)
-		os.Exit(1)
+		return nil, nil, err
 	}
 
 	flags := make(map[string]interface{})
 
 	for key, value := range hCmd.cmdFlags {
-		flags[key] = value
+		switch v := value.(type) {
+		case *bool:
+			flags[key] = *v
+		case *string:
+			flags[key] = *v
+		default:
+			flags[key] = value
+		}
 	}
 	args := hCmd.flagSet.Args()
 
-	return flags, args
+	return flags, args, nil
 }
 
-func printMainUsage(cliName string, trg *Trigger, isErr bool) {
-
-	w := os.Stderr
+func printMainUsage(w io.Writer, cliName string, trg *Trigger, isErr bool) {
 
 	if !isErr {
-		w = os.Stdout
 		fmt.Fprintf(w, "%s\n", trg.settings.Long)
 	}
 
@@ -59,12 +62,9 @@ func printMainUsage(cliName string, trg *Trigger, isErr bool) {
 	bw.Flush()
 }
 
-func printCmdUsage(cliName string, cmd *handlerCmd, isErr bool) {
-
-	w := os.Stderr
+func printCmdUsage(w io.Writer, cliName string, cmd *handlerCmd, isErr bool) {
 
 	if !isErr {
-		w = os.Stdout
 		fmt.Fprintf(w, "%s\n", cmd.settings.Long)
 	}
 
@@ -0,0 +1,159 @@
+package edgex
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// messageEnvelope is the message of the EdgeX message bus, its payload is base64 encoded by EdgeX 2 and
+// is the JSON object of the event request by EdgeX 3, the names of their fields only differ in case
+type messageEnvelope struct {
+	CorrelationID string          `json:"correlationID"`
+	ContentType   string          `json:"contentType"`
+	Payload       json.RawMessage `json:"payload"`
+}
+
+// event is the event of a device with its readings
+type event struct {
+	ID          string                 `json:"id"`
+	DeviceName  string                 `json:"deviceName"`
+	ProfileName string                 `json:"profileName"`
+	SourceName  string                 `json:"sourceName"`
+	Origin      int64                  `json:"origin"`
+	Tags        map[string]interface{} `json:"tags"`
+	Readings    []reading              `json:"readings"`
+}
+
+type reading struct {
+	ID           string      `json:"id"`
+	Origin       int64       `json:"origin"`
+	ResourceName string      `json:"resourceName"`
+	ValueType    string      `json:"valueType"`
+	Units        string      `json:"units"`
+	Value        string      `json:"value"`
+	BinaryValue  string      `json:"binaryValue"`
+	MediaType    string      `json:"mediaType"`
+	ObjectValue  interface{} `json:"objectValue"`
+}
+
+// parseEvent parses the event of a message of the message bus
+func parseEvent(topic string, message []byte) (*Output, error) {
+
+	envelope := &messageEnvelope{}
+	err := json.Unmarshal(message, envelope)
+	if err != nil {
+		return nil, err
+	}
+	if len(envelope.Payload) == 0 || string(envelope.Payload) == "null" {
+		return nil, errors.New("no payload")
+	}
+	if strings.Contains(envelope.ContentType, "cbor") {
+		return nil, fmt.Errorf("unsupported content type '%s'", envelope.ContentType)
+	}
+
+	payload := []byte(envelope.Payload)
+	if payload[0] == '"' {
+		err = json.Unmarshal(envelope.Payload, &payload)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// the events are sent in add event requests by the device services
+	request := &struct {
+		Event json.RawMessage `json:"event"`
+	}{}
+	err = json.Unmarshal(payload, request)
+	if err != nil {
+		return nil, err
+	}
+	if len(request.Event) > 0 {
+		payload = request.Event
+	}
+
+	e := &event{}
+	err = json.Unmarshal(payload, e)
+	if err != nil {
+		return nil, err
+	}
+	if e.DeviceName == "" {
+		return nil, errors.New("not an EdgeX event")
+	}
+
+	out := &Output{
+		Topic:         topic,
+		CorrelationID: envelope.CorrelationID,
+		ID:            e.ID,
+		DeviceName:    e.DeviceName,
+		ProfileName:   e.ProfileName,
+		SourceName:    e.SourceName,
+		Origin:        e.Origin,
+		Tags:          e.Tags,
+		Readings:      make([]interface{}, 0, len(e.Readings)),
+		Values:        make(map[string]interface{}, len(e.Readings)),
+	}
+	if out.Tags == nil {
+		out.Tags = make(map[string]interface{})
+	}
+
+	for _, r := range e.Readings {
+		value := r.toValue()
+		out.Readings = append(out.Readings, map[string]interface{}{
+			"id":           r.ID,
+			"origin":       r.Origin,
+			"resourceName": r.ResourceName,
+			"valueType":    r.ValueType,
+			"units":        r.Units,
+			"mediaType":    r.MediaType,
+			"value":        value,
+		})
+		out.Values[r.ResourceName] = value
+	}
+
+	err = json.Unmarshal(payload, &out.Event)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// toValue returns the value of the reading converted to its value type, the binary values being base64
+// encoded, or its value as sent when it can't be converted
+func (r *reading) toValue() interface{} {
+
+	var value interface{}
+	var err error
+
+	switch r.ValueType {
+	case "String":
+		return r.Value
+	case "Binary":
+		return r.BinaryValue
+	case "Object", "ObjectArray":
+		return r.ObjectValue
+	case "Bool":
+		value, err = strconv.ParseBool(r.Value)
+	case "Int8", "Int16", "Int32", "Int64":
+		value, err = strconv.ParseInt(r.Value, 10, 64)
+	case "Uint8", "Uint16", "Uint32", "Uint64":
+		value, err = strconv.ParseUint(r.Value, 10, 64)
+	case "Float32", "Float64":
+		value, err = strconv.ParseFloat(r.Value, 64)
+	default:
+		if !strings.HasSuffix(r.ValueType, "Array") {
+			return r.Value
+		}
+		var values []interface{}
+		err = json.Unmarshal([]byte(r.Value), &values)
+		value = values
+	}
+
+	if err != nil {
+		return r.Value
+	}
+	return value
+}
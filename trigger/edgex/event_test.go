@@ -0,0 +1,82 @@
+package edgex
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const addEventRequest = `{
+	"apiVersion": "v3",
+	"requestId": "81c2ef3c-5eb2-4adb-9f5b-32fbe6b4c3c1",
+	"event": {
+		"apiVersion": "v3",
+		"id": "6a3e4b3c-7a5b-4f0e-a1c6-6b6a4a8b5c0d",
+		"deviceName": "Random-Integer-Device",
+		"profileName": "Random-Integer-Device",
+		"sourceName": "Int16",
+		"origin": 1684741649467000000,
+		"tags": {"site": "lyon"},
+		"readings": [
+			{"id": "9f3e", "origin": 1684741649467000000, "deviceName": "Random-Integer-Device", "resourceName": "Int16", "profileName": "Random-Integer-Device", "valueType": "Int16", "value": "-8146"},
+			{"id": "9f3f", "origin": 1684741649467000000, "deviceName": "Random-Integer-Device", "resourceName": "Temperature", "profileName": "Random-Integer-Device", "valueType": "Float32", "units": "C", "value": "2.150000e+01"}
+		]
+	}
+}`
+
+func TestParseEvent_V3(t *testing.T) {
+
+	out, err := parseEvent("edgex/events/device/device-virtual/Random-Integer-Device/Random-Integer-Device/Int16",
+		[]byte(`{"apiVersion": "v3", "receivedTopic": "", "correlationID": "c1", "errorCode": 0, "contentType": "application/json", "payload": `+addEventRequest+`}`))
+	assert.Nil(t, err)
+
+	assert.Equal(t, "edgex/events/device/device-virtual/Random-Integer-Device/Random-Integer-Device/Int16", out.Topic)
+	assert.Equal(t, "c1", out.CorrelationID)
+	assert.Equal(t, "6a3e4b3c-7a5b-4f0e-a1c6-6b6a4a8b5c0d", out.ID)
+	assert.Equal(t, "Random-Integer-Device", out.DeviceName)
+	assert.Equal(t, "Random-Integer-Device", out.ProfileName)
+	assert.Equal(t, "Int16", out.SourceName)
+	assert.Equal(t, int64(1684741649467000000), out.Origin)
+	assert.Equal(t, map[string]interface{}{"site": "lyon"}, out.Tags)
+	assert.Equal(t, map[string]interface{}{"Int16": int64(-8146), "Temperature": 21.5}, out.Values)
+	assert.Equal(t, map[string]interface{}{
+		"id": "9f3f", "origin": int64(1684741649467000000), "resourceName": "Temperature", "valueType": "Float32", "units": "C", "mediaType": "", "value": 21.5,
+	}, out.Readings[1])
+	assert.Equal(t, "Int16", out.Event["sourceName"])
+}
+
+func TestParseEvent_V2(t *testing.T) {
+
+	payload := base64.StdEncoding.EncodeToString([]byte(addEventRequest))
+	out, err := parseEvent("edgex/events/device/device-virtual", []byte(`{"ReceivedTopic": "", "CorrelationID": "c2", "Payload": "`+payload+`", "ContentType": "application/json"}`))
+	assert.Nil(t, err)
+	assert.Equal(t, "c2", out.CorrelationID)
+	assert.Equal(t, "Random-Integer-Device", out.DeviceName)
+	assert.Len(t, out.Readings, 2)
+
+	// the events republished by core data aren't in requests
+	out, err = parseEvent("edgex/events/core", []byte(`{"payload": {"deviceName": "d1", "readings": []}}`))
+	assert.Nil(t, err)
+	assert.Equal(t, "d1", out.DeviceName)
+	assert.Equal(t, map[string]interface{}{}, out.Tags)
+
+	_, err = parseEvent("edgex/events/core", []byte(`{"payload": "oWVldmVudKA=", "contentType": "application/cbor"}`))
+	assert.NotNil(t, err)
+	_, err = parseEvent("edgex/events/core", []byte(`{"correlationID": "c3"}`))
+	assert.NotNil(t, err)
+	_, err = parseEvent("edgex/events/core", []byte(`{"payload": {"status": "up"}}`))
+	assert.NotNil(t, err)
+}
+
+func TestReading_ToValue(t *testing.T) {
+
+	assert.Equal(t, true, (&reading{ValueType: "Bool", Value: "true"}).toValue())
+	assert.Equal(t, uint64(200), (&reading{ValueType: "Uint8", Value: "200"}).toValue())
+	assert.Equal(t, 1.5, (&reading{ValueType: "Float64", Value: "1.500000e+00"}).toValue())
+	assert.Equal(t, "on", (&reading{ValueType: "String", Value: "on"}).toValue())
+	assert.Equal(t, "AQI=", (&reading{ValueType: "Binary", BinaryValue: "AQI=", MediaType: "image/jpeg"}).toValue())
+	assert.Equal(t, map[string]interface{}{"x": 1.0}, (&reading{ValueType: "Object", ObjectValue: map[string]interface{}{"x": 1.0}}).toValue())
+	assert.Equal(t, []interface{}{1.0, 2.0}, (&reading{ValueType: "Int32Array", Value: "[1, 2]"}).toValue())
+	assert.Equal(t, "n/a", (&reading{ValueType: "Int32", Value: "n/a"}).toValue())
+}
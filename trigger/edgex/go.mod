@@ -0,0 +1,9 @@
+module github.com/qingcloudhx/contrib/trigger/edgex
+
+require (
+	flogo/core v0.9.0
+	github.com/alicebob/miniredis/v2 v2.39.0
+	github.com/eclipse/paho.mqtt.golang v1.4.3
+	github.com/go-redis/redis/v8 v8.11.4
+	github.com/stretchr/testify v1.3.0
+)
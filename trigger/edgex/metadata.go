@@ -0,0 +1,112 @@
+package edgex
+
+import (
+	"flogo/core/data/coerce"
+)
+
+const (
+	ProtocolRedis = "redis"
+	ProtocolMQTT  = "mqtt"
+)
+
+type Settings struct {
+	Protocol string `md:"protocol,required,allowed(redis,mqtt)"` // The protocol of the message bus: redis (Pub/Sub) or mqtt
+	URL      string `md:"url,required"`                          // The url of the message bus (ex. redis://localhost:6379 or tcp://localhost:1883), rediss and ssl urls connect with TLS
+	Username string `md:"username"`                              // The user name used to authenticate
+	Password string `md:"password"`                              // The password used to authenticate
+	ClientID string `md:"clientId"`                              // The client id of the MQTT connection, generated if not specified
+	QoS      int    `md:"qos"`                                   // The quality of service of the MQTT subscriptions: 0 (default), 1 or 2
+
+	CAFile             string `md:"caFile"`             // The PEM file of the CA certificates used to verify the message bus, enables TLS
+	CertFile           string `md:"certFile"`           // The PEM file of the client certificate, for message buses requiring client authentication
+	KeyFile            string `md:"keyFile"`            // The PEM file of the client private key
+	InsecureSkipVerify bool   `md:"insecureSkipVerify"` // Don't verify the message bus certificate, for testing only
+}
+
+type HandlerSettings struct {
+	Topic    string `md:"topic"`    // The topic of the events, with the # and + wildcards, defaults to edgex/events/device/# which receives the events of all the devices
+	Devices  string `md:"devices"`  // The comma separated names of the devices of the events to handle, defaults to all the devices
+	Profiles string `md:"profiles"` // The comma separated names of the device profiles of the events to handle, defaults to all the profiles
+	Sources  string `md:"sources"`  // The comma separated names of the sources (resources or commands) of the events to handle, defaults to all the sources
+}
+
+type Output struct {
+	Topic         string                 `md:"topic"`         // The topic the event was published to
+	CorrelationID string                 `md:"correlationId"` // The correlation id of the message
+	ID            string                 `md:"id"`            // The id of the event
+	DeviceName    string                 `md:"deviceName"`    // The name of the device of the event
+	ProfileName   string                 `md:"profileName"`   // The name of the profile of the device
+	SourceName    string                 `md:"sourceName"`    // The name of the resource or command read
+	Origin        int64                  `md:"origin"`        // The time of the event, in nanoseconds since epoch
+	Tags          map[string]interface{} `md:"tags"`          // The tags of the event
+	Readings      []interface{}          `md:"readings"`      // The readings of the event, with their id, origin, resourceName, valueType, units, mediaType and value
+	Values        map[string]interface{} `md:"values"`        // The values of the readings by resource name, converted to their value type
+	Event         map[string]interface{} `md:"event"`         // The event sent by EdgeX
+}
+
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"topic":         o.Topic,
+		"correlationId": o.CorrelationID,
+		"id":            o.ID,
+		"deviceName":    o.DeviceName,
+		"profileName":   o.ProfileName,
+		"sourceName":    o.SourceName,
+		"origin":        o.Origin,
+		"tags":          o.Tags,
+		"readings":      o.Readings,
+		"values":        o.Values,
+		"event":         o.Event,
+	}
+}
+
+func (o *Output) FromMap(values map[string]interface{}) error {
+
+	var err error
+	o.Topic, err = coerce.ToString(values["topic"])
+	if err != nil {
+		return err
+	}
+	o.CorrelationID, err = coerce.ToString(values["correlationId"])
+	if err != nil {
+		return err
+	}
+	o.ID, err = coerce.ToString(values["id"])
+	if err != nil {
+		return err
+	}
+	o.DeviceName, err = coerce.ToString(values["deviceName"])
+	if err != nil {
+		return err
+	}
+	o.ProfileName, err = coerce.ToString(values["profileName"])
+	if err != nil {
+		return err
+	}
+	o.SourceName, err = coerce.ToString(values["sourceName"])
+	if err != nil {
+		return err
+	}
+	o.Origin, err = coerce.ToInt64(values["origin"])
+	if err != nil {
+		return err
+	}
+	o.Tags, err = coerce.ToObject(values["tags"])
+	if err != nil {
+		return err
+	}
+	o.Readings, err = coerce.ToArray(values["readings"])
+	if err != nil {
+		return err
+	}
+	o.Values, err = coerce.ToObject(values["values"])
+	if err != nil {
+		return err
+	}
+	o.Event, err = coerce.ToObject(values["event"])
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
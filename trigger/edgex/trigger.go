@@ -0,0 +1,316 @@
+package edgex
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+
+	"flogo/core/data/metadata"
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	defaultTopic   = "edgex/events/device/#"
+	connectTimeout = 30 * time.Second
+)
+
+var triggerMd = trigger.NewMetadata(&Settings{}, &HandlerSettings{}, &Output{})
+
+func init() {
+	_ = trigger.Register(&Trigger{}, &Factory{})
+}
+
+type Factory struct {
+}
+
+// Metadata implements trigger.Factory.Metadata
+func (*Factory) Metadata() *trigger.Metadata {
+	return triggerMd
+}
+
+// New implements trigger.Factory.New
+func (*Factory) New(config *trigger.Config) (trigger.Trigger, error) {
+
+	s := &Settings{}
+	err := metadata.MapToStruct(config.Settings, s, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.QoS < 0 || s.QoS > 2 {
+		return nil, fmt.Errorf("invalid QoS %d, the QoS is 0, 1 or 2", s.QoS)
+	}
+
+	return &Trigger{settings: s}, nil
+}
+
+// Trigger receives the events of the EdgeX devices from the message bus, like an application service
+type Trigger struct {
+	settings *Settings
+	logger   log.Logger
+	handlers []*eventHandler
+
+	redis   *redis.Client
+	mqtt    paho.Client
+	cancel  context.CancelFunc
+	running sync.WaitGroup
+}
+
+// eventHandler is a handler with the topic, devices, profiles and sources of the events it handles
+type eventHandler struct {
+	handler trigger.Handler
+	logger  log.Logger
+	topic   string
+
+	devices  map[string]bool
+	profiles map[string]bool
+	sources  map[string]bool
+}
+
+// Initialize implements trigger.Init.Initialize
+func (t *Trigger) Initialize(ctx trigger.InitContext) error {
+
+	t.logger = ctx.Logger()
+
+	for _, handler := range ctx.GetHandlers() {
+
+		s := &HandlerSettings{}
+		err := metadata.MapToStruct(handler.Settings(), s, true)
+		if err != nil {
+			return err
+		}
+
+		h := &eventHandler{
+			handler:  handler,
+			logger:   t.logger,
+			topic:    s.Topic,
+			devices:  toSet(s.Devices),
+			profiles: toSet(s.Profiles),
+			sources:  toSet(s.Sources),
+		}
+		if h.topic == "" {
+			h.topic = defaultTopic
+		}
+		t.handlers = append(t.handlers, h)
+	}
+
+	return nil
+}
+
+// Start implements util.Managed.Start
+func (t *Trigger) Start() error {
+
+	tlsConfig, err := getTLSConfig(t.settings)
+	if err != nil {
+		return err
+	}
+
+	if t.settings.Protocol == ProtocolMQTT {
+		t.mqtt = paho.NewClient(t.mqttOptions(tlsConfig))
+		// the client keeps trying to connect when the broker is not available
+		t.mqtt.Connect()
+		return nil
+	}
+
+	options, err := redis.ParseURL(t.settings.URL)
+	if err != nil {
+		return fmt.Errorf("invalid url '%s': %v", t.settings.URL, err)
+	}
+	if t.settings.Username != "" {
+		options.Username = t.settings.Username
+	}
+	if t.settings.Password != "" {
+		options.Password = t.settings.Password
+	}
+	if tlsConfig != nil {
+		options.TLSConfig = tlsConfig
+	}
+	t.redis = redis.NewClient(options)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+
+	for _, h := range t.handlers {
+		pubsub, err := subscribe(ctx, t.redis, h.topic)
+		if err != nil {
+			_ = t.Stop()
+			return err
+		}
+
+		t.running.Add(1)
+		go func(h *eventHandler) {
+			defer t.running.Done()
+			go func() {
+				<-ctx.Done()
+				_ = pubsub.Close()
+			}()
+			for msg := range pubsub.Channel() {
+				h.handleMessage(fromRedisTopic(msg.Channel), []byte(msg.Payload))
+			}
+		}(h)
+	}
+
+	return nil
+}
+
+// Stop implements util.Managed.Stop
+func (t *Trigger) Stop() error {
+
+	if t.mqtt != nil {
+		t.mqtt.Disconnect(250)
+		t.mqtt = nil
+	}
+
+	if t.cancel == nil {
+		return nil
+	}
+
+	t.cancel()
+	t.running.Wait()
+	t.cancel = nil
+
+	return t.redis.Close()
+}
+
+// mqttOptions returns the options of the MQTT client, which subscribes to the topics of the handlers
+// each time it connects
+func (t *Trigger) mqttOptions(tlsConfig *tls.Config) *paho.ClientOptions {
+
+	s := t.settings
+
+	options := paho.NewClientOptions()
+	options.AddBroker(s.URL)
+	options.SetClientID(s.ClientID)
+	options.SetUsername(s.Username)
+	options.SetPassword(s.Password)
+	options.SetConnectTimeout(connectTimeout)
+	options.SetAutoReconnect(true)
+	options.SetConnectRetry(true)
+	if tlsConfig != nil {
+		options.SetTLSConfig(tlsConfig)
+	}
+
+	options.SetOnConnectHandler(func(client paho.Client) {
+		for _, h := range t.handlers {
+			h := h
+			token := client.Subscribe(h.topic, byte(s.QoS), func(client paho.Client, msg paho.Message) {
+				h.handleMessage(msg.Topic(), msg.Payload())
+			})
+			if token.WaitTimeout(connectTimeout) && token.Error() == nil {
+				t.logger.Infof("Subscribed to events of topic '%s'", h.topic)
+				continue
+			}
+			t.logger.Errorf("Unable to subscribe to topic '%s': %v", h.topic, token.Error())
+		}
+	})
+	options.SetConnectionLostHandler(func(client paho.Client, err error) {
+		t.logger.Warnf("Connection to MQTT broker lost: %v", err)
+	})
+
+	return options
+}
+
+// subscribe subscribes to the Redis channels of the topic
+func subscribe(ctx context.Context, client *redis.Client, topic string) (*redis.PubSub, error) {
+
+	pubsub := client.PSubscribe(ctx, toRedisTopic(topic))
+
+	// wait for the confirmation, so that no event published after the start is missed
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return nil, fmt.Errorf("unable to subscribe to topic '%s': %v", topic, err)
+	}
+
+	return pubsub, nil
+}
+
+// toRedisTopic converts a topic to the channels of the Redis message bus of EdgeX, which uses dots as
+// separators and * as wildcard
+func toRedisTopic(topic string) string {
+	topic = strings.Replace(topic, "/", ".", -1)
+	topic = strings.Replace(topic, "#", "*", -1)
+	return strings.Replace(topic, "+", "*", -1)
+}
+
+func fromRedisTopic(channel string) string {
+	return strings.Replace(channel, ".", "/", -1)
+}
+
+// handleMessage invokes the action for the event of a message, the event of a failed action is lost
+func (h *eventHandler) handleMessage(topic string, message []byte) {
+
+	out, err := parseEvent(topic, message)
+	if err != nil {
+		h.logger.Errorf("Invalid event of topic '%s': %v", topic, err)
+		return
+	}
+	if !h.matches(out) {
+		return
+	}
+
+	_, err = h.handler.Handle(context.Background(), out)
+	if err != nil {
+		h.logger.Errorf("Error handling event of device '%s': %v", out.DeviceName, err)
+	}
+}
+
+func (h *eventHandler) matches(out *Output) bool {
+	return (h.devices == nil || h.devices[out.DeviceName]) &&
+		(h.profiles == nil || h.profiles[out.ProfileName]) &&
+		(h.sources == nil || h.sources[out.SourceName])
+}
+
+// getTLSConfig returns the TLS configuration of the connection to the message bus, nil if there are no TLS settings
+func getTLSConfig(settings *Settings) (*tls.Config, error) {
+
+	if settings.CAFile == "" && settings.CertFile == "" && !settings.InsecureSkipVerify {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: settings.InsecureSkipVerify}
+
+	if settings.CAFile != "" {
+		pem, err := ioutil.ReadFile(settings.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read CA file '%s': %v", settings.CAFile, err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file '%s'", settings.CAFile)
+		}
+	}
+
+	if settings.CertFile != "" || settings.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(settings.CertFile, settings.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// toSet returns the values of a comma separated list, nil if it is empty
+func toSet(list string) map[string]bool {
+
+	var set map[string]bool
+	for _, v := range strings.Split(list, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			if set == nil {
+				set = make(map[string]bool)
+			}
+			set[v] = true
+		}
+	}
+
+	return set
+}
@@ -0,0 +1,99 @@
+package edgex
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"flogo/core/api"
+	"flogo/core/trigger"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFactory_New(t *testing.T) {
+
+	f := &Factory{}
+
+	_, err := f.New(&trigger.Config{Settings: map[string]interface{}{"protocol": "redis"}})
+	assert.NotNil(t, err)
+
+	_, err = f.New(&trigger.Config{Settings: map[string]interface{}{"protocol": "nats", "url": "nats://localhost:4222"}})
+	assert.NotNil(t, err)
+
+	_, err = f.New(&trigger.Config{Settings: map[string]interface{}{"protocol": "mqtt", "url": "tcp://localhost:1883", "qos": 3}})
+	assert.NotNil(t, err)
+}
+
+func TestTopics(t *testing.T) {
+	assert.Equal(t, "edgex.events.device.*", toRedisTopic("edgex/events/device/#"))
+	assert.Equal(t, "edgex.events.device.*.Sensor.*", toRedisTopic("edgex/events/device/+/Sensor/#"))
+	assert.Equal(t, "edgex/events/device/svc/Sensor/d1/Temp", fromRedisTopic("edgex.events.device.svc.Sensor.d1.Temp"))
+}
+
+func TestTrigger_Redis(t *testing.T) {
+
+	s, err := miniredis.Run()
+	assert.Nil(t, err)
+	defer s.Close()
+
+	var mu sync.Mutex
+	outputs := map[string][]*Output{}
+
+	app := api.NewApp()
+	trg := app.NewTrigger(&Trigger{}, map[string]interface{}{"protocol": "redis", "url": "redis://" + s.Addr()})
+	for name, settings := range map[string]map[string]interface{}{
+		"all":     {},
+		"sensors": {"topic": "edgex/events/device/+/Sensor/#", "sources": "Temperature"},
+		"d2":      {"devices": "d2"},
+	} {
+		name := name
+		handler, err := trg.NewHandler(settings)
+		assert.Nil(t, err)
+		_, err = handler.NewAction(func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+			out := &Output{}
+			err := out.FromMap(inputs)
+
+			mu.Lock()
+			defer mu.Unlock()
+			outputs[name] = append(outputs[name], out)
+			return nil, err
+		})
+		assert.Nil(t, err)
+	}
+
+	e, err := api.NewEngine(app)
+	assert.Nil(t, err)
+	err = e.Start()
+	assert.Nil(t, err)
+	defer e.Stop()
+
+	publish := func(topic, event string) {
+		s.Publish(toRedisTopic(topic), `{"apiVersion": "v3", "contentType": "application/json", "payload": {"event": `+event+`}}`)
+	}
+	publish("edgex/events/device/svc/Sensor/d1/Temperature", `{"deviceName": "d1", "profileName": "Sensor", "sourceName": "Temperature", "readings": [{"resourceName": "Temperature", "valueType": "Float64", "value": "2.1e+01"}]}`)
+	publish("edgex/events/device/svc/Sensor/d1/Humidity", `{"deviceName": "d1", "profileName": "Sensor", "sourceName": "Humidity", "readings": []}`)
+	publish("edgex/events/device/svc/Camera/d2/Image", `{"deviceName": "d2", "profileName": "Camera", "sourceName": "Image", "readings": []}`)
+	s.Publish("edgex.events.device.svc.Camera.d2.Image", "not json")
+	s.Publish("edgex.system-events.core-metadata.device.add", `{"payload": {"deviceName": "d3"}}`)
+
+	devices := func(name string) []string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		var devices []string
+		for _, out := range outputs[name] {
+			devices = append(devices, out.DeviceName)
+		}
+		return devices
+	}
+	for i := 0; i < 100 && len(devices("all")) < 3; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, []string{"d1", "d1", "d2"}, devices("all"))
+	assert.Equal(t, []string{"d1"}, devices("sensors"))
+	assert.Equal(t, []string{"d2"}, devices("d2"))
+	assert.Equal(t, "edgex/events/device/svc/Sensor/d1/Temperature", outputs["sensors"][0].Topic)
+	assert.Equal(t, map[string]interface{}{"Temperature": 21.0}, outputs["sensors"][0].Values)
+}
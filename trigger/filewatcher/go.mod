@@ -0,0 +1,7 @@
+module github.com/qingcloudhx/contrib/trigger/filewatcher
+
+require (
+	flogo/core v0.9.0
+	github.com/fsnotify/fsnotify v1.7.0
+	github.com/stretchr/testify v1.3.0
+)
@@ -0,0 +1,72 @@
+package filewatcher
+
+import (
+	"flogo/core/data/coerce"
+)
+
+const (
+	EventCreate = "create"
+	EventModify = "modify"
+	EventDelete = "delete"
+)
+
+type HandlerSettings struct {
+	Path           string `md:"path,required"`  // The directory to watch
+	Patterns       string `md:"patterns"`       // The comma separated glob patterns of the names of the files to watch (ex. *.csv,report-??.json), defaults to all the files
+	Recursive      bool   `md:"recursive"`      // Watch the subdirectories of the directory too
+	Events         string `md:"events"`         // The comma separated events to handle: create, modify and delete, defaults to all of them
+	Debounce       string `md:"debounce"`       // How long a file has to be left unchanged before its event is handled, so that partial writes are ignored (ex. 2s), defaults to 500ms
+	ReadContent    bool   `md:"readContent"`    // Deliver the content of the created and modified files
+	MaxContentSize int64  `md:"maxContentSize"` // The max size in bytes of a content delivered, larger files are delivered without content, defaults to 10MB
+}
+
+type Output struct {
+	Event   string `md:"event"`   // The event of the file: create, modify or delete
+	Path    string `md:"path"`    // The path of the file
+	Name    string `md:"name"`    // The name of the file
+	Size    int64  `md:"size"`    // The size of the file in bytes, unless deleted
+	ModTime int64  `md:"modTime"` // The last modification time of the file in milliseconds since epoch, unless deleted
+	Content string `md:"content"` // The content of the file, when read
+}
+
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"event":   o.Event,
+		"path":    o.Path,
+		"name":    o.Name,
+		"size":    o.Size,
+		"modTime": o.ModTime,
+		"content": o.Content,
+	}
+}
+
+func (o *Output) FromMap(values map[string]interface{}) error {
+
+	var err error
+	o.Event, err = coerce.ToString(values["event"])
+	if err != nil {
+		return err
+	}
+	o.Path, err = coerce.ToString(values["path"])
+	if err != nil {
+		return err
+	}
+	o.Name, err = coerce.ToString(values["name"])
+	if err != nil {
+		return err
+	}
+	o.Size, err = coerce.ToInt64(values["size"])
+	if err != nil {
+		return err
+	}
+	o.ModTime, err = coerce.ToInt64(values["modTime"])
+	if err != nil {
+		return err
+	}
+	o.Content, err = coerce.ToString(values["content"])
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
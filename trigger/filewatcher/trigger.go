@@ -0,0 +1,79 @@
+package filewatcher
+
+import (
+	"flogo/core/data/metadata"
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+)
+
+var triggerMd = trigger.NewMetadata(&HandlerSettings{}, &Output{})
+
+func init() {
+	_ = trigger.Register(&Trigger{}, &Factory{})
+}
+
+type Factory struct {
+}
+
+// Metadata implements trigger.Factory.Metadata
+func (*Factory) Metadata() *trigger.Metadata {
+	return triggerMd
+}
+
+// New implements trigger.Factory.New
+func (*Factory) New(config *trigger.Config) (trigger.Trigger, error) {
+	return &Trigger{}, nil
+}
+
+// Trigger watches directories for file events
+type Trigger struct {
+	logger   log.Logger
+	watchers []*watcher
+}
+
+// Initialize implements trigger.Init.Initialize
+func (t *Trigger) Initialize(ctx trigger.InitContext) error {
+
+	t.logger = ctx.Logger()
+
+	for _, handler := range ctx.GetHandlers() {
+
+		s := &HandlerSettings{}
+		err := metadata.MapToStruct(handler.Settings(), s, true)
+		if err != nil {
+			return err
+		}
+
+		w, err := newWatcher(handler, s, t.logger)
+		if err != nil {
+			return err
+		}
+		t.watchers = append(t.watchers, w)
+	}
+
+	return nil
+}
+
+// Start implements util.Managed.Start
+func (t *Trigger) Start() error {
+
+	for _, w := range t.watchers {
+		err := w.start()
+		if err != nil {
+			t.Stop()
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Stop implements util.Managed.Stop
+func (t *Trigger) Stop() error {
+
+	for _, w := range t.watchers {
+		w.stop()
+	}
+
+	return nil
+}
@@ -0,0 +1,190 @@
+package filewatcher
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"flogo/core/action"
+	"flogo/core/api"
+	"flogo/core/support/log"
+	"flogo/core/support/test"
+	"flogo/core/trigger"
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig string = `{
+	"id": "trigger-filewatcher",
+	"ref": "github.com/qingcloudhx/contrib/trigger/filewatcher",
+	"handlers": [
+	  {
+		"settings": {
+		  "path": "."
+		},
+		"action": {
+		  "id": "test"
+		}
+	  }
+	]
+}`
+
+func next(t *testing.T, outputs <-chan *Output) *Output {
+	select {
+	case out := <-outputs:
+		return out
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event handled")
+		return nil
+	}
+}
+
+func none(t *testing.T, outputs <-chan *Output) {
+	select {
+	case out := <-outputs:
+		t.Fatalf("unexpected %s event of '%s'", out.Event, out.Path)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+// startTrigger starts a trigger whose handler has the settings, it returns the outputs of the events handled
+// and the function stopping the trigger
+func startTrigger(t *testing.T, settings map[string]interface{}) (<-chan *Output, func()) {
+
+	outputs := make(chan *Output, 10)
+	actions := map[string]action.Action{"test": api.NewProxyAction(func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+		out := &Output{}
+		if err := out.FromMap(inputs); err != nil {
+			return nil, err
+		}
+		outputs <- out
+		return nil, nil
+	})}
+
+	config := &trigger.Config{}
+	err := json.Unmarshal([]byte(testConfig), config)
+	assert.Nil(t, err)
+	config.Handlers[0].Settings = settings
+	config.Handlers[0].Settings["debounce"] = "50ms"
+
+	tgr, err := test.InitTrigger(&Factory{}, config, actions)
+	assert.Nil(t, err)
+	assert.Nil(t, tgr.Start())
+
+	return outputs, func() {
+		assert.Nil(t, tgr.Stop())
+	}
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "filewatcher")
+	assert.Nil(t, err)
+	return dir
+}
+
+func TestNewWatcher(t *testing.T) {
+	w, err := newWatcher(nil, &HandlerSettings{Path: ".", Patterns: "*.csv, *.json", Events: "create"}, log.RootLogger())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"*.csv", "*.json"}, w.patterns)
+	assert.Equal(t, map[string]bool{EventCreate: true}, w.events)
+	assert.Equal(t, defaultDebounce, w.debounce)
+	assert.True(t, w.matches("orders.csv"))
+	assert.False(t, w.matches("orders.txt"))
+
+	_, err = newWatcher(nil, &HandlerSettings{Path: ".", Events: "rename"}, log.RootLogger())
+	assert.NotNil(t, err)
+
+	_, err = newWatcher(nil, &HandlerSettings{Path: ".", Patterns: "[a"}, log.RootLogger())
+	assert.NotNil(t, err)
+
+	_, err = newWatcher(nil, &HandlerSettings{Path: ".", Debounce: "soon"}, log.RootLogger())
+	assert.NotNil(t, err)
+}
+
+func TestTrigger(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	outputs, stop := startTrigger(t, map[string]interface{}{"path": dir, "patterns": "*.csv", "readContent": true})
+	defer stop()
+
+	// the partial writes of a file are handled as a single event
+	path := filepath.Join(dir, "orders.csv")
+	f, err := os.Create(path)
+	assert.Nil(t, err)
+	for _, line := range []string{"id,amount\n", "1,10\n", "2,20\n"} {
+		_, _ = f.WriteString(line)
+		time.Sleep(10 * time.Millisecond)
+	}
+	f.Close()
+
+	out := next(t, outputs)
+	assert.Equal(t, EventCreate, out.Event)
+	assert.Equal(t, path, out.Path)
+	assert.Equal(t, "orders.csv", out.Name)
+	assert.Equal(t, int64(20), out.Size)
+	assert.True(t, out.ModTime > 0)
+	assert.Equal(t, "id,amount\n1,10\n2,20\n", out.Content)
+
+	assert.Nil(t, ioutil.WriteFile(path, []byte("id,amount\n"), 0644))
+	out = next(t, outputs)
+	assert.Equal(t, EventModify, out.Event)
+	assert.Equal(t, "id,amount\n", out.Content)
+
+	// the files which don't match are ignored
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "orders.txt"), []byte("text"), 0644))
+	none(t, outputs)
+
+	assert.Nil(t, os.Remove(path))
+	out = next(t, outputs)
+	assert.Equal(t, EventDelete, out.Event)
+	assert.Equal(t, path, out.Path)
+	assert.Equal(t, "", out.Content)
+
+	// a file created then deleted is ignored
+	assert.Nil(t, ioutil.WriteFile(path, []byte("temp"), 0644))
+	assert.Nil(t, os.Remove(path))
+	none(t, outputs)
+}
+
+func TestTriggerRecursive(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	assert.Nil(t, os.Mkdir(filepath.Join(dir, "existing"), 0755))
+
+	outputs, stop := startTrigger(t, map[string]interface{}{"path": dir, "recursive": true, "events": "create"})
+	defer stop()
+
+	path := filepath.Join(dir, "existing", "a.json")
+	assert.Nil(t, ioutil.WriteFile(path, []byte("{}"), 0644))
+	out := next(t, outputs)
+	assert.Equal(t, path, out.Path)
+	assert.Equal(t, "", out.Content)
+
+	// the files of a new directory are watched
+	assert.Nil(t, os.MkdirAll(filepath.Join(dir, "new", "nested"), 0755))
+	time.Sleep(100 * time.Millisecond)
+	path = filepath.Join(dir, "new", "nested", "b.json")
+	assert.Nil(t, ioutil.WriteFile(path, []byte("{}"), 0644))
+	assert.Equal(t, path, next(t, outputs).Path)
+
+	// the other events are ignored
+	assert.Nil(t, os.Remove(path))
+	none(t, outputs)
+}
+
+func TestTriggerMissingPath(t *testing.T) {
+	config := &trigger.Config{}
+	err := json.Unmarshal([]byte(testConfig), config)
+	assert.Nil(t, err)
+	config.Handlers[0].Settings["path"] = "/missing/directory"
+
+	tgr, err := test.InitTrigger(&Factory{}, config, map[string]action.Action{"test": test.NewDummyAction(func() {
+		//do nothing
+	})})
+	assert.Nil(t, err)
+	assert.NotNil(t, tgr.Start())
+}
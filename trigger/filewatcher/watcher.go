@@ -0,0 +1,308 @@
+package filewatcher
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	defaultDebounce       = 500 * time.Millisecond
+	defaultMaxContentSize = 10 * 1024 * 1024
+)
+
+// watcher watches the directory of a handler, the events of a file are coalesced until the file is
+// left unchanged for the debounce delay, then its event is handled
+type watcher struct {
+	handler  trigger.Handler
+	settings *HandlerSettings
+	logger   log.Logger
+
+	patterns []string
+	events   map[string]bool
+	debounce time.Duration
+
+	fs       *fsnotify.Watcher
+	mu       sync.Mutex
+	pending  map[string]*pendingEvent
+	handling sync.WaitGroup
+	done     chan struct{}
+}
+
+type pendingEvent struct {
+	event string
+	timer *time.Timer
+}
+
+func newWatcher(handler trigger.Handler, s *HandlerSettings, logger log.Logger) (*watcher, error) {
+
+	w := &watcher{handler: handler, settings: s, logger: logger, debounce: defaultDebounce, pending: make(map[string]*pendingEvent)}
+
+	w.patterns = splitList(s.Patterns)
+	for _, pattern := range w.patterns {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s': %v", pattern, err)
+		}
+	}
+
+	w.events = make(map[string]bool)
+	events := splitList(s.Events)
+	if len(events) == 0 {
+		events = []string{EventCreate, EventModify, EventDelete}
+	}
+	for _, event := range events {
+		if event != EventCreate && event != EventModify && event != EventDelete {
+			return nil, fmt.Errorf("unsupported event '%s'", event)
+		}
+		w.events[event] = true
+	}
+
+	if s.Debounce != "" {
+		debounce, err := time.ParseDuration(s.Debounce)
+		if err != nil {
+			return nil, fmt.Errorf("invalid debounce '%s': %v", s.Debounce, err)
+		}
+		w.debounce = debounce
+	}
+	if s.MaxContentSize <= 0 {
+		s.MaxContentSize = defaultMaxContentSize
+	}
+
+	return w, nil
+}
+
+// start watches the directory, and its subdirectories when recursive
+func (w *watcher) start() error {
+
+	info, err := os.Stat(w.settings.Path)
+	if err != nil {
+		return fmt.Errorf("unable to watch '%s': %v", w.settings.Path, err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("unable to watch '%s': not a directory", w.settings.Path)
+	}
+
+	w.fs, err = fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	err = w.add(w.settings.Path, false)
+	if err != nil {
+		w.fs.Close()
+		return err
+	}
+
+	w.done = make(chan struct{})
+	go w.run()
+
+	w.logger.Infof("Watching '%s'", w.settings.Path)
+
+	return nil
+}
+
+// stop stops watching, the events being handled are completed
+func (w *watcher) stop() {
+
+	if w.fs == nil {
+		return
+	}
+
+	w.fs.Close()
+	<-w.done
+	w.fs = nil
+
+	w.mu.Lock()
+	for path, p := range w.pending {
+		p.timer.Stop()
+		delete(w.pending, path)
+	}
+	w.mu.Unlock()
+
+	w.handling.Wait()
+}
+
+// add watches a directory and, when recursive, its subdirectories. The files of a directory created
+// after the watch started are notified as created, since they may have been created before the
+// directory is watched
+func (w *watcher) add(dir string, created bool) error {
+
+	if !w.settings.Recursive {
+		return w.fs.Add(dir)
+	}
+
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// removed in the meantime
+			return nil
+		}
+		if info.IsDir() {
+			return w.fs.Add(path)
+		}
+		if created {
+			w.notify(path, EventCreate)
+		}
+		return nil
+	})
+}
+
+func (w *watcher) run() {
+
+	defer close(w.done)
+
+	for {
+		select {
+		case event, ok := <-w.fs.Events:
+			if !ok {
+				return
+			}
+			w.process(event)
+		case err, ok := <-w.fs.Errors:
+			if !ok {
+				return
+			}
+			w.logger.Errorf("Error watching '%s': %v", w.settings.Path, err)
+		}
+	}
+}
+
+func (w *watcher) process(event fsnotify.Event) {
+
+	switch {
+	case event.Has(fsnotify.Create):
+		// a file already deleted is notified too, so that its deletion is coalesced with its creation
+		info, err := os.Stat(event.Name)
+		if err == nil && info.IsDir() {
+			if w.settings.Recursive {
+				err = w.add(event.Name, true)
+				if err != nil {
+					w.logger.Errorf("Error watching '%s': %v", event.Name, err)
+				}
+			}
+			return
+		}
+		w.notify(event.Name, EventCreate)
+	case event.Has(fsnotify.Write):
+		w.notify(event.Name, EventModify)
+	case event.Has(fsnotify.Remove), event.Has(fsnotify.Rename):
+		// a renamed file is notified as deleted, and as created under its new name
+		w.notify(event.Name, EventDelete)
+	}
+}
+
+// notify coalesces the event with the pending event of the file, and delays it for the debounce
+func (w *watcher) notify(path, event string) {
+
+	if !w.matches(filepath.Base(path)) {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	p, ok := w.pending[path]
+	if !ok {
+		p = &pendingEvent{event: event}
+		w.pending[path] = p
+		p.timer = time.AfterFunc(w.debounce, func() { w.fire(path, p) })
+		return
+	}
+
+	switch {
+	case p.event == EventCreate && event == EventDelete:
+		// a file created then deleted is ignored
+		p.timer.Stop()
+		delete(w.pending, path)
+		return
+	case p.event == EventDelete && event != EventDelete:
+		// a file deleted then created again is replaced
+		p.event = EventModify
+	case p.event == EventModify:
+		p.event = event
+	}
+
+	p.timer.Reset(w.debounce)
+}
+
+func (w *watcher) fire(path string, p *pendingEvent) {
+
+	w.mu.Lock()
+	if w.pending[path] != p {
+		w.mu.Unlock()
+		return
+	}
+	delete(w.pending, path)
+	event := p.event
+	w.handling.Add(1)
+	w.mu.Unlock()
+
+	defer w.handling.Done()
+
+	if !w.events[event] {
+		return
+	}
+
+	out := &Output{Event: event, Path: path, Name: filepath.Base(path)}
+
+	if event != EventDelete {
+		info, err := os.Stat(path)
+		if err != nil {
+			// deleted in the meantime
+			return
+		}
+		out.Size = info.Size()
+		out.ModTime = info.ModTime().UnixNano() / int64(time.Millisecond)
+
+		if w.settings.ReadContent {
+			if info.Size() > w.settings.MaxContentSize {
+				w.logger.Warnf("File '%s' too large to be read: %d bytes", path, info.Size())
+			} else {
+				content, err := ioutil.ReadFile(path)
+				if err != nil {
+					w.logger.Errorf("Error reading file '%s': %v", path, err)
+					return
+				}
+				out.Content = string(content)
+			}
+		}
+	}
+
+	_, err := w.handler.Handle(context.Background(), out)
+	if err != nil {
+		w.logger.Errorf("Error handling %s event of file '%s': %v", event, path, err)
+	}
+}
+
+func (w *watcher) matches(name string) bool {
+
+	if len(w.patterns) == 0 {
+		return true
+	}
+
+	for _, pattern := range w.patterns {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func splitList(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
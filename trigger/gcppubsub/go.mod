@@ -0,0 +1,9 @@
+module github.com/qingcloudhx/contrib/trigger/gcppubsub
+
+require (
+	cloud.google.com/go/pubsub v1.45.1
+	flogo/core v0.9.0
+	github.com/stretchr/testify v1.3.0
+	google.golang.org/api v0.203.0
+	google.golang.org/grpc v1.67.1
+)
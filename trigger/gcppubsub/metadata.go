@@ -0,0 +1,73 @@
+package gcppubsub
+
+import (
+	"flogo/core/data/coerce"
+)
+
+type Settings struct {
+	ProjectID       string `md:"projectId,required"` // The id of the Google Cloud project of the subscriptions
+	CredentialsFile string `md:"credentialsFile"`    // The service account key file, defaults to the application default credentials
+	Endpoint        string `md:"endpoint"`           // The address of the Pub/Sub endpoint, to use a regional endpoint (ex. europe-west1-pubsub.googleapis.com:443)
+}
+
+type HandlerSettings struct {
+	Subscription           string `md:"subscription,required"`  // The id of the subscription to pull
+	MaxOutstandingMessages int    `md:"maxOutstandingMessages"` // The max number of messages received and not yet acked, defaults to 1000
+	MaxOutstandingBytes    int    `md:"maxOutstandingBytes"`    // The max size of the messages received and not yet acked, defaults to 1e9
+	NumGoroutines          int    `md:"numGoroutines"`          // The number of streams pulling the messages, defaults to 10
+	MaxExtension           string `md:"maxExtension"`           // How long the ack deadline of a message is extended while its action runs (ex. 10m), defaults to 60m
+}
+
+type Output struct {
+	MessageID       string            `md:"messageId"`       // The id of the message
+	Data            string            `md:"data"`            // The data of the message
+	Content         interface{}       `md:"content"`         // The data of the message parsed, when it is JSON
+	Attributes      map[string]string `md:"attributes"`      // The attributes of the message
+	OrderingKey     string            `md:"orderingKey"`     // The ordering key of the message
+	PublishTime     int64             `md:"publishTime"`     // The time the message was published, in milliseconds since epoch
+	DeliveryAttempt int               `md:"deliveryAttempt"` // The number of delivery attempts of the message, when the subscription has a dead letter policy
+}
+
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"messageId":       o.MessageID,
+		"data":            o.Data,
+		"content":         o.Content,
+		"attributes":      o.Attributes,
+		"orderingKey":     o.OrderingKey,
+		"publishTime":     o.PublishTime,
+		"deliveryAttempt": o.DeliveryAttempt,
+	}
+}
+
+func (o *Output) FromMap(values map[string]interface{}) error {
+
+	var err error
+	o.MessageID, err = coerce.ToString(values["messageId"])
+	if err != nil {
+		return err
+	}
+	o.Data, err = coerce.ToString(values["data"])
+	if err != nil {
+		return err
+	}
+	o.Content = values["content"]
+	o.Attributes, err = coerce.ToParams(values["attributes"])
+	if err != nil {
+		return err
+	}
+	o.OrderingKey, err = coerce.ToString(values["orderingKey"])
+	if err != nil {
+		return err
+	}
+	o.PublishTime, err = coerce.ToInt64(values["publishTime"])
+	if err != nil {
+		return err
+	}
+	o.DeliveryAttempt, err = coerce.ToInt(values["deliveryAttempt"])
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,121 @@
+package gcppubsub
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+)
+
+const retryInterval = 5 * time.Second
+
+// subscriber receives the messages of the subscription of a handler, a message is acked once its
+// action succeeded and nacked otherwise, so that it is redelivered. The messages are flow controlled
+// by the max number and size of the messages received and not yet acked, and the messages of an
+// ordering key are handled one after the other when the subscription enables message ordering
+type subscriber struct {
+	sub      *pubsub.Subscription
+	handler  trigger.Handler
+	settings *HandlerSettings
+	logger   log.Logger
+
+	done chan struct{}
+}
+
+func newSubscriber(client *pubsub.Client, handler trigger.Handler, s *HandlerSettings, logger log.Logger) (*subscriber, error) {
+
+	sub := client.Subscription(s.Subscription)
+
+	if s.MaxOutstandingMessages != 0 {
+		sub.ReceiveSettings.MaxOutstandingMessages = s.MaxOutstandingMessages
+	}
+	if s.MaxOutstandingBytes != 0 {
+		sub.ReceiveSettings.MaxOutstandingBytes = s.MaxOutstandingBytes
+	}
+	if s.NumGoroutines != 0 {
+		sub.ReceiveSettings.NumGoroutines = s.NumGoroutines
+	}
+	if s.MaxExtension != "" {
+		extension, err := time.ParseDuration(s.MaxExtension)
+		if err != nil {
+			return nil, fmt.Errorf("invalid max extension '%s': %v", s.MaxExtension, err)
+		}
+		sub.ReceiveSettings.MaxExtension = extension
+	}
+
+	return &subscriber{sub: sub, handler: handler, settings: s, logger: logger}, nil
+}
+
+// run receives the messages until the context is done, the messages being handled are completed
+func (r *subscriber) run(ctx context.Context) {
+
+	defer close(r.done)
+
+	s := r.settings
+	r.logger.Infof("Receiving messages of subscription '%s'", s.Subscription)
+
+	for ctx.Err() == nil {
+		err := r.sub.Receive(ctx, r.handle)
+		if err != nil && ctx.Err() == nil {
+			r.logger.Errorf("Error receiving messages of subscription '%s': %v", s.Subscription, err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(retryInterval):
+			}
+		}
+	}
+}
+
+// wait waits for the subscriber to stop, at most for the timeout
+func (r *subscriber) wait(timeout time.Duration) {
+
+	if r.done == nil {
+		return
+	}
+
+	select {
+	case <-r.done:
+	case <-time.After(timeout):
+		r.logger.Warnf("Messages of subscription '%s' still being handled", r.settings.Subscription)
+	}
+}
+
+// handle invokes the action for a message, it is called concurrently except for the messages of an ordering key
+func (r *subscriber) handle(ctx context.Context, msg *pubsub.Message) {
+
+	_, err := r.handler.Handle(context.Background(), toOutput(msg))
+	if err != nil {
+		r.logger.Errorf("Error handling message '%s' of subscription '%s': %v", msg.ID, r.settings.Subscription, err)
+		// the next messages of its ordering key are redelivered after it
+		msg.Nack()
+		return
+	}
+
+	msg.Ack()
+}
+
+func toOutput(msg *pubsub.Message) *Output {
+
+	out := &Output{
+		MessageID:   msg.ID,
+		Data:        string(msg.Data),
+		Attributes:  msg.Attributes,
+		OrderingKey: msg.OrderingKey,
+		PublishTime: msg.PublishTime.UnixNano() / int64(time.Millisecond),
+	}
+
+	if msg.DeliveryAttempt != nil {
+		out.DeliveryAttempt = *msg.DeliveryAttempt
+	}
+
+	var content interface{}
+	if json.Unmarshal(msg.Data, &content) == nil {
+		out.Content = content
+	}
+
+	return out
+}
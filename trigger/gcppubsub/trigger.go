@@ -0,0 +1,120 @@
+package gcppubsub
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"flogo/core/data/metadata"
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+	"google.golang.org/api/option"
+)
+
+var triggerMd = trigger.NewMetadata(&Settings{}, &HandlerSettings{}, &Output{})
+
+func init() {
+	_ = trigger.Register(&Trigger{}, &Factory{})
+}
+
+type Factory struct {
+}
+
+// Metadata implements trigger.Factory.Metadata
+func (*Factory) Metadata() *trigger.Metadata {
+	return triggerMd
+}
+
+// New implements trigger.Factory.New
+func (*Factory) New(config *trigger.Config) (trigger.Trigger, error) {
+
+	s := &Settings{}
+	err := metadata.MapToStruct(config.Settings, s, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Trigger{settings: s}, nil
+}
+
+// Trigger pulls Google Cloud Pub/Sub subscriptions
+type Trigger struct {
+	settings    *Settings
+	logger      log.Logger
+	options     []option.ClientOption
+	client      *pubsub.Client
+	subscribers []*subscriber
+
+	cancel context.CancelFunc
+}
+
+// Initialize implements trigger.Init.Initialize
+func (t *Trigger) Initialize(ctx trigger.InitContext) error {
+
+	t.logger = ctx.Logger()
+
+	// the PUBSUB_EMULATOR_HOST environment variable selects the emulator
+	if t.settings.CredentialsFile != "" {
+		t.options = append(t.options, option.WithCredentialsFile(t.settings.CredentialsFile))
+	}
+	if t.settings.Endpoint != "" {
+		t.options = append(t.options, option.WithEndpoint(t.settings.Endpoint))
+	}
+
+	client, err := pubsub.NewClient(context.Background(), t.settings.ProjectID, t.options...)
+	if err != nil {
+		return fmt.Errorf("unable to create Pub/Sub client: %v", err)
+	}
+	t.client = client
+
+	for _, handler := range ctx.GetHandlers() {
+
+		s := &HandlerSettings{}
+		err := metadata.MapToStruct(handler.Settings(), s, true)
+		if err != nil {
+			return err
+		}
+
+		r, err := newSubscriber(client, handler, s, t.logger)
+		if err != nil {
+			return err
+		}
+		t.subscribers = append(t.subscribers, r)
+	}
+
+	return nil
+}
+
+// Start implements util.Managed.Start
+func (t *Trigger) Start() error {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+
+	for _, r := range t.subscribers {
+		r.done = make(chan struct{})
+		go r.run(ctx)
+	}
+
+	return nil
+}
+
+// Stop implements util.Managed.Stop
+func (t *Trigger) Stop() error {
+
+	if t.cancel != nil {
+		t.cancel()
+		t.cancel = nil
+	}
+
+	for _, r := range t.subscribers {
+		r.wait(30 * time.Second)
+	}
+
+	if t.client != nil {
+		return t.client.Close()
+	}
+
+	return nil
+}
@@ -0,0 +1,191 @@
+package gcppubsub
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"cloud.google.com/go/pubsub"
+	"cloud.google.com/go/pubsub/pstest"
+	"flogo/core/action"
+	"flogo/core/api"
+	"flogo/core/support/log"
+	"flogo/core/support/test"
+	"flogo/core/trigger"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/api/option"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+const testConfig string = `{
+	"id": "trigger-gcppubsub",
+	"ref": "github.com/qingcloudhx/contrib/trigger/gcppubsub",
+	"settings": {
+	  "projectId": "project"
+	},
+	"handlers": [
+	  {
+		"settings": {
+		  "subscription": "orders-sub"
+		},
+		"action": {
+		  "id": "test"
+		}
+	  }
+	]
+}`
+
+func next(t *testing.T, outputs <-chan *Output) *Output {
+	select {
+	case out := <-outputs:
+		return out
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message handled")
+		return nil
+	}
+}
+
+// startServer starts a fake Pub/Sub server with a topic and its subscription
+func startServer(t *testing.T, ordering bool) (*pstest.Server, []option.ClientOption, *pubsub.Topic, func()) {
+
+	srv := pstest.NewServer()
+	conn, err := grpc.Dial(srv.Addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.Nil(t, err)
+
+	options := []option.ClientOption{option.WithGRPCConn(conn), option.WithoutAuthentication()}
+
+	ctx := context.Background()
+	client, err := pubsub.NewClient(ctx, "project", options...)
+	assert.Nil(t, err)
+
+	topic, err := client.CreateTopic(ctx, "orders")
+	assert.Nil(t, err)
+	topic.EnableMessageOrdering = ordering
+
+	_, err = client.CreateSubscription(ctx, "orders-sub", pubsub.SubscriptionConfig{
+		Topic:                 topic,
+		AckDeadline:           10 * time.Second,
+		EnableMessageOrdering: ordering,
+	})
+	assert.Nil(t, err)
+
+	return srv, options, topic, func() {
+		topic.Stop()
+		client.Close()
+		srv.Close()
+	}
+}
+
+// startTrigger starts a trigger connected to the fake server, whose handler has the settings and runs the function
+func startTrigger(t *testing.T, srv *pstest.Server, settings map[string]interface{}, f api.HandlerFunc) *Trigger {
+
+	// the clients of the trigger connect to the emulator without credentials
+	t.Setenv("PUBSUB_EMULATOR_HOST", srv.Addr)
+
+	config := &trigger.Config{}
+	err := json.Unmarshal([]byte(testConfig), config)
+	assert.Nil(t, err)
+	config.Handlers[0].Settings = settings
+
+	tgr, err := test.InitTrigger(&Factory{}, config, map[string]action.Action{"test": api.NewProxyAction(f)})
+	assert.Nil(t, err)
+	assert.Nil(t, tgr.Start())
+
+	return tgr.(*Trigger)
+}
+
+// send returns a handler function sending the outputs to the channel, it fails for the data "fail"
+func send(outputs chan<- *Output) api.HandlerFunc {
+	return func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+		out := &Output{}
+		if err := out.FromMap(inputs); err != nil {
+			return nil, err
+		}
+
+		outputs <- out
+		if out.Data == "fail" {
+			return nil, errors.New("failed")
+		}
+		return nil, nil
+	}
+}
+
+func publish(t *testing.T, topic *pubsub.Topic, msg *pubsub.Message) string {
+	id, err := topic.Publish(context.Background(), msg).Get(context.Background())
+	assert.Nil(t, err)
+	return id
+}
+
+func TestTrigger(t *testing.T) {
+	srv, _, topic, stop := startServer(t, false)
+	defer stop()
+
+	outputs := make(chan *Output, 10)
+	tgr := startTrigger(t, srv, map[string]interface{}{"subscription": "orders-sub", "maxOutstandingMessages": 1}, send(outputs))
+	defer tgr.Stop()
+
+	id := publish(t, topic, &pubsub.Message{Data: []byte(`{"id":1}`), Attributes: map[string]string{"source": "test"}})
+
+	out := next(t, outputs)
+	assert.Equal(t, id, out.MessageID)
+	assert.Equal(t, `{"id":1}`, out.Data)
+	assert.Equal(t, map[string]interface{}{"id": 1.0}, out.Content)
+	assert.Equal(t, map[string]string{"source": "test"}, out.Attributes)
+	assert.True(t, out.PublishTime > 0)
+	waitFor(func() bool { return srv.Message(id).Acks == 1 })
+	assert.Equal(t, 1, srv.Message(id).Acks)
+
+	// the failed message is nacked
+	id = publish(t, topic, &pubsub.Message{Data: []byte("fail")})
+	out = next(t, outputs)
+	assert.Equal(t, id, out.MessageID)
+	assert.Nil(t, out.Content)
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, 0, srv.Message(id).Acks)
+}
+
+func waitFor(condition func() bool) {
+	for i := 0; i < 200 && !condition(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestTriggerOrdering(t *testing.T) {
+	srv, _, topic, stop := startServer(t, true)
+	defer stop()
+
+	outputs := make(chan *Output, 10)
+	tgr := startTrigger(t, srv, map[string]interface{}{"subscription": "orders-sub"}, send(outputs))
+	defer tgr.Stop()
+
+	for _, data := range []string{"1", "2", "3"} {
+		publish(t, topic, &pubsub.Message{Data: []byte(data), OrderingKey: "key"})
+	}
+
+	for _, data := range []string{"1", "2", "3"} {
+		out := next(t, outputs)
+		assert.Equal(t, data, out.Data)
+		assert.Equal(t, "key", out.OrderingKey)
+	}
+}
+
+func TestNewSubscriber(t *testing.T) {
+	_, options, _, stop := startServer(t, false)
+	defer stop()
+
+	client, err := pubsub.NewClient(context.Background(), "project", options...)
+	assert.Nil(t, err)
+	defer client.Close()
+
+	r, err := newSubscriber(client, nil, &HandlerSettings{Subscription: "orders-sub", MaxOutstandingMessages: 5, NumGoroutines: 2, MaxExtension: "10m"}, log.RootLogger())
+	assert.Nil(t, err)
+	assert.Equal(t, 5, r.sub.ReceiveSettings.MaxOutstandingMessages)
+	assert.Equal(t, 2, r.sub.ReceiveSettings.NumGoroutines)
+	assert.Equal(t, 10*time.Minute, r.sub.ReceiveSettings.MaxExtension)
+
+	_, err = newSubscriber(client, nil, &HandlerSettings{Subscription: "orders-sub", MaxExtension: "later"}, log.RootLogger())
+	assert.NotNil(t, err)
+}
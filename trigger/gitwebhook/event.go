@@ -0,0 +1,267 @@
+package gitwebhook
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// user is a user of GitHub or GitLab, GitHub names its login and GitLab its username
+type user struct {
+	Login    string `json:"login"`
+	Username string `json:"username"`
+}
+
+func (u *user) name() string {
+	if u.Login != "" {
+		return u.Login
+	}
+	return u.Username
+}
+
+type commit struct {
+	ID        string `json:"id"`
+	Message   string `json:"message"`
+	Timestamp string `json:"timestamp"`
+	URL       string `json:"url"`
+	Author    struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	} `json:"author"`
+	Added    []string `json:"added"`
+	Modified []string `json:"modified"`
+	Removed  []string `json:"removed"`
+}
+
+type githubEvent struct {
+	Ref        string   `json:"ref"`
+	Before     string   `json:"before"`
+	After      string   `json:"after"`
+	Commits    []commit `json:"commits"`
+	Action     string   `json:"action"`
+	Sender     user     `json:"sender"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+	PullRequest *struct {
+		Number  int    `json:"number"`
+		Title   string `json:"title"`
+		Body    string `json:"body"`
+		State   string `json:"state"`
+		HTMLURL string `json:"html_url"`
+		Merged  bool   `json:"merged"`
+		User    user   `json:"user"`
+		Head    struct {
+			Ref string `json:"ref"`
+			SHA string `json:"sha"`
+		} `json:"head"`
+		Base struct {
+			Ref string `json:"ref"`
+		} `json:"base"`
+	} `json:"pull_request"`
+	Issue *struct {
+		Number  int    `json:"number"`
+		Title   string `json:"title"`
+		Body    string `json:"body"`
+		State   string `json:"state"`
+		HTMLURL string `json:"html_url"`
+		User    user   `json:"user"`
+		Labels  []struct {
+			Name string `json:"name"`
+		} `json:"labels"`
+	} `json:"issue"`
+}
+
+type gitlabEvent struct {
+	ObjectKind   string   `json:"object_kind"`
+	Ref          string   `json:"ref"`
+	Before       string   `json:"before"`
+	After        string   `json:"after"`
+	Commits      []commit `json:"commits"`
+	UserUsername string   `json:"user_username"`
+	User         user     `json:"user"`
+	Project      struct {
+		PathWithNamespace string `json:"path_with_namespace"`
+	} `json:"project"`
+	ObjectAttributes struct {
+		IID          int    `json:"iid"`
+		Title        string `json:"title"`
+		Description  string `json:"description"`
+		State        string `json:"state"`
+		URL          string `json:"url"`
+		Action       string `json:"action"`
+		SourceBranch string `json:"source_branch"`
+		TargetBranch string `json:"target_branch"`
+		LastCommit   struct {
+			ID string `json:"id"`
+		} `json:"last_commit"`
+	} `json:"object_attributes"`
+	Labels []struct {
+		Title string `json:"title"`
+	} `json:"labels"`
+}
+
+// gitlabActions maps the actions of GitLab to the ones of GitHub
+var gitlabActions = map[string]string{
+	"open":   "opened",
+	"close":  "closed",
+	"reopen": "reopened",
+	"update": "edited",
+	"merge":  "merged",
+}
+
+// parseGitHub parses the payload of a GitHub event, named by the X-GitHub-Event header
+func parseGitHub(event string, body []byte) (*Output, error) {
+
+	e := &githubEvent{}
+	err := json.Unmarshal(body, e)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &Output{Provider: ProviderGitHub, Event: event, Action: e.Action, Repository: e.Repository.FullName, Sender: e.Sender.name()}
+
+	switch event {
+	case "push":
+		setPush(out, e.Ref, e.Before, e.After, e.Commits)
+	case "pull_request":
+		out.Event = EventPullRequest
+		if pr := e.PullRequest; pr != nil {
+			out.Branch = pr.Base.Ref
+			// a merged pull request is closed
+			if out.Action == "closed" && pr.Merged {
+				out.Action = "merged"
+			}
+			out.PullRequest = map[string]interface{}{
+				"number":       pr.Number,
+				"title":        pr.Title,
+				"body":         pr.Body,
+				"state":        pr.State,
+				"url":          pr.HTMLURL,
+				"author":       pr.User.name(),
+				"sourceBranch": pr.Head.Ref,
+				"targetBranch": pr.Base.Ref,
+				"headCommit":   pr.Head.SHA,
+				"merged":       pr.Merged,
+			}
+		}
+	case "issues":
+		out.Event = EventIssue
+		if issue := e.Issue; issue != nil {
+			labels := make([]interface{}, 0, len(issue.Labels))
+			for _, label := range issue.Labels {
+				labels = append(labels, label.Name)
+			}
+			out.Issue = map[string]interface{}{
+				"number": issue.Number,
+				"title":  issue.Title,
+				"body":   issue.Body,
+				"state":  issue.State,
+				"url":    issue.HTMLURL,
+				"author": issue.User.name(),
+				"labels": labels,
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// parseGitLab parses the payload of a GitLab event, named by its object kind
+func parseGitLab(body []byte) (*Output, error) {
+
+	e := &gitlabEvent{}
+	err := json.Unmarshal(body, e)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &Output{Provider: ProviderGitLab, Event: e.ObjectKind, Repository: e.Project.PathWithNamespace, Sender: e.User.name()}
+	if out.Sender == "" {
+		out.Sender = e.UserUsername
+	}
+
+	attrs := e.ObjectAttributes
+	switch e.ObjectKind {
+	case "push", "tag_push":
+		out.Event = EventPush
+		setPush(out, e.Ref, e.Before, e.After, e.Commits)
+	case "merge_request":
+		out.Event = EventPullRequest
+		out.Action = gitlabAction(attrs.Action)
+		out.Branch = attrs.TargetBranch
+		out.PullRequest = map[string]interface{}{
+			"number":       attrs.IID,
+			"title":        attrs.Title,
+			"body":         attrs.Description,
+			"state":        attrs.State,
+			"url":          attrs.URL,
+			"author":       out.Sender,
+			"sourceBranch": attrs.SourceBranch,
+			"targetBranch": attrs.TargetBranch,
+			"headCommit":   attrs.LastCommit.ID,
+			"merged":       attrs.State == "merged",
+		}
+	case "issue":
+		out.Event = EventIssue
+		out.Action = gitlabAction(attrs.Action)
+		labels := make([]interface{}, 0, len(e.Labels))
+		for _, label := range e.Labels {
+			labels = append(labels, label.Title)
+		}
+		out.Issue = map[string]interface{}{
+			"number": attrs.IID,
+			"title":  attrs.Title,
+			"body":   attrs.Description,
+			"state":  attrs.State,
+			"url":    attrs.URL,
+			"author": out.Sender,
+			"labels": labels,
+		}
+	}
+
+	return out, nil
+}
+
+func gitlabAction(action string) string {
+	if a, ok := gitlabActions[action]; ok {
+		return a
+	}
+	return action
+}
+
+// setPush sets the ref, branch or tag, and commits of a push
+func setPush(out *Output, ref, before, after string, commits []commit) {
+
+	out.Ref = ref
+	out.Before = before
+	out.After = after
+
+	if strings.HasPrefix(ref, "refs/tags/") {
+		out.Tag = strings.TrimPrefix(ref, "refs/tags/")
+	} else {
+		out.Branch = strings.TrimPrefix(ref, "refs/heads/")
+	}
+
+	out.Commits = make([]interface{}, 0, len(commits))
+	for _, c := range commits {
+		out.Commits = append(out.Commits, map[string]interface{}{
+			"id":        c.ID,
+			"message":   c.Message,
+			"url":       c.URL,
+			"author":    c.Author.Name,
+			"email":     c.Author.Email,
+			"timestamp": c.Timestamp,
+			"added":     toArray(c.Added),
+			"modified":  toArray(c.Modified),
+			"removed":   toArray(c.Removed),
+		})
+	}
+}
+
+func toArray(values []string) []interface{} {
+	array := make([]interface{}, 0, len(values))
+	for _, v := range values {
+		array = append(array, v)
+	}
+	return array
+}
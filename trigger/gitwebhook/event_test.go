@@ -0,0 +1,95 @@
+package gitwebhook
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseGitHub(t *testing.T) {
+
+	out, err := parseGitHub("push", []byte(`{"ref": "refs/tags/v1.0.0", "repository": {"full_name": "acme/api"}}`))
+	assert.Nil(t, err)
+	assert.Equal(t, EventPush, out.Event)
+	assert.Equal(t, "v1.0.0", out.Tag)
+	assert.Equal(t, "", out.Branch)
+	assert.Len(t, out.Commits, 0)
+
+	out, err = parseGitHub("pull_request", []byte(`{"action": "closed", "number": 7, "repository": {"full_name": "acme/api"}, "sender": {"login": "bob"},
+  "pull_request": {"number": 7, "title": "Add login", "body": "Closes #6", "state": "closed", "html_url": "https://github.com/acme/api/pull/7",
+    "merged": true, "user": {"login": "alice"}, "head": {"ref": "feature/login", "sha": "f00ba4"}, "base": {"ref": "main"}}}`))
+	assert.Nil(t, err)
+	assert.Equal(t, EventPullRequest, out.Event)
+	assert.Equal(t, "merged", out.Action)
+	assert.Equal(t, "main", out.Branch)
+	assert.Equal(t, "bob", out.Sender)
+	assert.Equal(t, map[string]interface{}{"number": 7, "title": "Add login", "body": "Closes #6", "state": "closed",
+		"url": "https://github.com/acme/api/pull/7", "author": "alice", "sourceBranch": "feature/login", "targetBranch": "main",
+		"headCommit": "f00ba4", "merged": true}, out.PullRequest)
+
+	out, err = parseGitHub("issues", []byte(`{"action": "opened", "repository": {"full_name": "acme/api"},
+  "issue": {"number": 6, "title": "Login fails", "state": "open", "user": {"login": "carol"}, "labels": [{"name": "bug"}]}}`))
+	assert.Nil(t, err)
+	assert.Equal(t, EventIssue, out.Event)
+	assert.Equal(t, "opened", out.Action)
+	assert.Equal(t, "", out.Branch)
+	assert.Equal(t, 6, out.Issue["number"])
+	assert.Equal(t, "carol", out.Issue["author"])
+	assert.Equal(t, []interface{}{"bug"}, out.Issue["labels"])
+
+	out, err = parseGitHub("release", []byte(`{"action": "published", "repository": {"full_name": "acme/api"}}`))
+	assert.Nil(t, err)
+	assert.Equal(t, "release", out.Event)
+	assert.Equal(t, "published", out.Action)
+
+	_, err = parseGitHub("push", []byte(`[]`))
+	assert.NotNil(t, err)
+}
+
+func TestParseGitLab(t *testing.T) {
+
+	out, err := parseGitLab([]byte(`{"object_kind": "push", "ref": "refs/heads/main", "before": "95790bf", "after": "da15608", "user_username": "bob",
+  "project": {"path_with_namespace": "acme/api"},
+  "commits": [{"id": "da15608", "message": "Update README", "timestamp": "2019-05-15T15:20:41+02:00", "url": "https://gitlab.com/acme/api/-/commit/da15608",
+    "author": {"name": "Bob", "email": "bob@acme.com"}, "added": [], "modified": ["README.md"], "removed": []}]}`))
+	assert.Nil(t, err)
+	assert.Equal(t, ProviderGitLab, out.Provider)
+	assert.Equal(t, EventPush, out.Event)
+	assert.Equal(t, "acme/api", out.Repository)
+	assert.Equal(t, "main", out.Branch)
+	assert.Equal(t, "bob", out.Sender)
+	assert.Equal(t, "95790bf", out.Before)
+	assert.Equal(t, []interface{}{map[string]interface{}{"id": "da15608", "message": "Update README", "url": "https://gitlab.com/acme/api/-/commit/da15608",
+		"author": "Bob", "email": "bob@acme.com", "timestamp": "2019-05-15T15:20:41+02:00",
+		"added": []interface{}{}, "modified": []interface{}{"README.md"}, "removed": []interface{}{}}}, out.Commits)
+
+	out, err = parseGitLab([]byte(`{"object_kind": "tag_push", "ref": "refs/tags/v1.0.0", "project": {"path_with_namespace": "acme/api"}}`))
+	assert.Nil(t, err)
+	assert.Equal(t, EventPush, out.Event)
+	assert.Equal(t, "v1.0.0", out.Tag)
+
+	out, err = parseGitLab([]byte(`{"object_kind": "merge_request", "user": {"username": "alice"}, "project": {"path_with_namespace": "acme/api"},
+  "object_attributes": {"iid": 3, "title": "Add login", "state": "merged", "action": "merge", "source_branch": "feature/login",
+    "target_branch": "main", "last_commit": {"id": "f00ba4"}}}`))
+	assert.Nil(t, err)
+	assert.Equal(t, EventPullRequest, out.Event)
+	assert.Equal(t, "merged", out.Action)
+	assert.Equal(t, "main", out.Branch)
+	assert.Equal(t, "alice", out.Sender)
+	assert.Equal(t, 3, out.PullRequest["number"])
+	assert.Equal(t, "feature/login", out.PullRequest["sourceBranch"])
+	assert.Equal(t, "f00ba4", out.PullRequest["headCommit"])
+	assert.Equal(t, true, out.PullRequest["merged"])
+
+	out, err = parseGitLab([]byte(`{"object_kind": "issue", "user": {"username": "carol"}, "project": {"path_with_namespace": "acme/api"},
+  "object_attributes": {"iid": 6, "title": "Login fails", "state": "opened", "action": "open"}, "labels": [{"title": "bug"}]}`))
+	assert.Nil(t, err)
+	assert.Equal(t, EventIssue, out.Event)
+	assert.Equal(t, "opened", out.Action)
+	assert.Equal(t, []interface{}{"bug"}, out.Issue["labels"])
+
+	out, err = parseGitLab([]byte(`{"object_kind": "pipeline", "project": {"path_with_namespace": "acme/api"}}`))
+	assert.Nil(t, err)
+	assert.Equal(t, "pipeline", out.Event)
+	assert.Nil(t, out.PullRequest)
+}
@@ -0,0 +1,141 @@
+package gitwebhook
+
+import (
+	"flogo/core/data/coerce"
+)
+
+const (
+	ProviderGitHub = "github"
+	ProviderGitLab = "gitlab"
+
+	EventPush        = "push"
+	EventPullRequest = "pull_request"
+	EventIssue       = "issue"
+)
+
+type Settings struct {
+	Port     int    `md:"port,required"` // The port to listen on
+	Path     string `md:"path"`          // The path the webhooks are received on, defaults to /webhook
+	CertFile string `md:"certFile"`      // The PEM file of the server certificate, enables TLS
+	KeyFile  string `md:"keyFile"`       // The PEM file of the server private key
+}
+
+type HandlerSettings struct {
+	Provider      string `md:"provider,allowed(github,gitlab)"` // The provider of the webhooks to handle: github or gitlab, defaults to both
+	Secret        string `md:"secret"`                          // The secret of the webhook, which signs the GitHub requests or is the GitLab token, required unless allowUnsigned is set
+	AllowUnsigned bool   `md:"allowUnsigned"`                   // Handle the requests without verifying them when there is no secret, for testing only
+	Events        string `md:"events"`                          // The comma separated events to handle (ex. push,pull_request,issue), defaults to all the events
+	Repositories  string `md:"repositories"`                    // The comma separated repositories to handle, with their owner or group, patterns are supported (ex. acme/api,acme/web-*), defaults to all the repositories
+	Branches      string `md:"branches"`                        // The comma separated branches to handle, patterns are supported (ex. main,release/*), defaults to all the branches
+}
+
+type Output struct {
+	Provider    string                 `md:"provider"`    // The provider of the webhook: github or gitlab
+	Event       string                 `md:"event"`       // The event: push, pull_request, issue or the name of the other events given by the provider
+	Action      string                 `md:"action"`      // The action of a pull request or issue event (ex. opened, closed, reopened, merged)
+	DeliveryID  string                 `md:"deliveryId"`  // The unique id of the delivery given by the provider
+	Repository  string                 `md:"repository"`  // The repository, with its owner or group (ex. acme/api)
+	Branch      string                 `md:"branch"`      // The branch that is pushed, or the target branch of a pull request
+	Tag         string                 `md:"tag"`         // The tag that is pushed
+	Sender      string                 `md:"sender"`      // The login of the user who triggered the event
+	Ref         string                 `md:"ref"`         // The full ref that is pushed (ex. refs/heads/main)
+	Before      string                 `md:"before"`      // The commit the ref pointed to before a push
+	After       string                 `md:"after"`       // The commit the ref points to after a push
+	Commits     []interface{}          `md:"commits"`     // The commits of a push, with their id, message, url, author, email, timestamp and added, modified and removed files
+	PullRequest map[string]interface{} `md:"pullRequest"` // The pull request, with its number, title, body, state, url, author, sourceBranch, targetBranch, headCommit and merged flag
+	Issue       map[string]interface{} `md:"issue"`       // The issue, with its number, title, body, state, url, author and labels
+	Headers     map[string]string      `md:"headers"`     // The headers of the HTTP request
+	Payload     map[string]interface{} `md:"payload"`     // The JSON payload of the webhook, as sent by the provider
+}
+
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"provider":    o.Provider,
+		"event":       o.Event,
+		"action":      o.Action,
+		"deliveryId":  o.DeliveryID,
+		"repository":  o.Repository,
+		"branch":      o.Branch,
+		"tag":         o.Tag,
+		"sender":      o.Sender,
+		"ref":         o.Ref,
+		"before":      o.Before,
+		"after":       o.After,
+		"commits":     o.Commits,
+		"pullRequest": o.PullRequest,
+		"issue":       o.Issue,
+		"headers":     o.Headers,
+		"payload":     o.Payload,
+	}
+}
+
+func (o *Output) FromMap(values map[string]interface{}) error {
+
+	var err error
+	o.Provider, err = coerce.ToString(values["provider"])
+	if err != nil {
+		return err
+	}
+	o.Event, err = coerce.ToString(values["event"])
+	if err != nil {
+		return err
+	}
+	o.Action, err = coerce.ToString(values["action"])
+	if err != nil {
+		return err
+	}
+	o.DeliveryID, err = coerce.ToString(values["deliveryId"])
+	if err != nil {
+		return err
+	}
+	o.Repository, err = coerce.ToString(values["repository"])
+	if err != nil {
+		return err
+	}
+	o.Branch, err = coerce.ToString(values["branch"])
+	if err != nil {
+		return err
+	}
+	o.Tag, err = coerce.ToString(values["tag"])
+	if err != nil {
+		return err
+	}
+	o.Sender, err = coerce.ToString(values["sender"])
+	if err != nil {
+		return err
+	}
+	o.Ref, err = coerce.ToString(values["ref"])
+	if err != nil {
+		return err
+	}
+	o.Before, err = coerce.ToString(values["before"])
+	if err != nil {
+		return err
+	}
+	o.After, err = coerce.ToString(values["after"])
+	if err != nil {
+		return err
+	}
+	o.Commits, err = coerce.ToArray(values["commits"])
+	if err != nil {
+		return err
+	}
+	o.PullRequest, err = coerce.ToObject(values["pullRequest"])
+	if err != nil {
+		return err
+	}
+	o.Issue, err = coerce.ToObject(values["issue"])
+	if err != nil {
+		return err
+	}
+	o.Headers, err = coerce.ToParams(values["headers"])
+	if err != nil {
+		return err
+	}
+	o.Payload, err = coerce.ToObject(values["payload"])
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,345 @@
+package gitwebhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"flogo/core/data/metadata"
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+)
+
+const (
+	defaultPath = "/webhook"
+
+	// the largest payload sent by GitHub
+	maxBodySize = 25 * 1024 * 1024
+)
+
+var triggerMd = trigger.NewMetadata(&Settings{}, &HandlerSettings{}, &Output{})
+
+func init() {
+	_ = trigger.Register(&Trigger{}, &Factory{})
+}
+
+type Factory struct {
+}
+
+// Metadata implements trigger.Factory.Metadata
+func (*Factory) Metadata() *trigger.Metadata {
+	return triggerMd
+}
+
+// New implements trigger.Factory.New
+func (*Factory) New(config *trigger.Config) (trigger.Trigger, error) {
+
+	s := &Settings{}
+	err := metadata.MapToStruct(config.Settings, s, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Trigger{settings: s}, nil
+}
+
+// Trigger receives the webhooks of GitHub and GitLab, each request is handled by the handlers
+// whose secret verifies it and whose filters match its event
+type Trigger struct {
+	settings *Settings
+	logger   log.Logger
+	handlers []*webhookHandler
+
+	mux      *http.ServeMux
+	server   *http.Server
+	listener net.Listener
+}
+
+type webhookHandler struct {
+	handler      trigger.Handler
+	settings     *HandlerSettings
+	events       map[string]bool
+	repositories []string
+	branches     []string
+}
+
+// Initialize implements trigger.Init.Initialize
+func (t *Trigger) Initialize(ctx trigger.InitContext) error {
+
+	t.logger = ctx.Logger()
+
+	for _, handler := range ctx.GetHandlers() {
+
+		s := &HandlerSettings{}
+		err := metadata.MapToStruct(handler.Settings(), s, true)
+		if err != nil {
+			return err
+		}
+
+		if s.Secret == "" {
+			if !s.AllowUnsigned {
+				return fmt.Errorf("handler '%s' requires a secret, unless allowUnsigned is set", handler.Name())
+			}
+			t.logger.Warnf("Handler '%s' has no secret, its requests aren't verified", handler.Name())
+		}
+
+		h, err := newWebhookHandler(handler, s)
+		if err != nil {
+			return err
+		}
+		t.handlers = append(t.handlers, h)
+	}
+
+	webhookPath := t.settings.Path
+	if webhookPath == "" {
+		webhookPath = defaultPath
+	}
+
+	t.mux = http.NewServeMux()
+	t.mux.HandleFunc(webhookPath, t.serveHTTP)
+
+	return nil
+}
+
+func newWebhookHandler(handler trigger.Handler, s *HandlerSettings) (*webhookHandler, error) {
+
+	h := &webhookHandler{handler: handler, settings: s, events: make(map[string]bool)}
+
+	for _, event := range splitList(s.Events) {
+		h.events[event] = true
+	}
+	for _, repository := range splitList(s.Repositories) {
+		if _, err := path.Match(repository, ""); err != nil {
+			return nil, fmt.Errorf("invalid repository pattern '%s': %v", repository, err)
+		}
+		h.repositories = append(h.repositories, strings.ToLower(repository))
+	}
+	for _, branch := range splitList(s.Branches) {
+		if _, err := path.Match(branch, ""); err != nil {
+			return nil, fmt.Errorf("invalid branch pattern '%s': %v", branch, err)
+		}
+		h.branches = append(h.branches, branch)
+	}
+
+	return h, nil
+}
+
+// Start implements util.Managed.Start
+func (t *Trigger) Start() error {
+
+	ln, err := net.Listen("tcp", ":"+strconv.Itoa(t.settings.Port))
+	if err != nil {
+		return err
+	}
+	t.listener = ln
+
+	// a shut down server can't serve again, each start uses a new one
+	server := &http.Server{Handler: t.mux, ReadHeaderTimeout: 30 * time.Second}
+	t.server = server
+
+	t.logger.Infof("Listening on port %d", t.settings.Port)
+
+	go func() {
+		var err error
+		if t.settings.CertFile != "" {
+			err = server.ServeTLS(ln, t.settings.CertFile, t.settings.KeyFile)
+		} else {
+			err = server.Serve(ln)
+		}
+		if err != nil && err != http.ErrServerClosed {
+			t.logger.Errorf("Webhook server stopped: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+// Stop implements util.Managed.Stop
+func (t *Trigger) Stop() error {
+
+	if t.listener == nil {
+		return nil
+	}
+	t.listener = nil
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return t.server.Shutdown(ctx)
+}
+
+func (t *Trigger) serveHTTP(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	var provider, deliveryID string
+	switch {
+	case r.Header.Get("X-GitHub-Event") != "":
+		provider = ProviderGitHub
+		deliveryID = r.Header.Get("X-GitHub-Delivery")
+	case r.Header.Get("X-Gitlab-Event") != "":
+		provider = ProviderGitLab
+		deliveryID = r.Header.Get("X-Gitlab-Event-UUID")
+	default:
+		http.Error(w, "the X-GitHub-Event or X-Gitlab-Event header is required", http.StatusBadRequest)
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	// the handlers of the provider whose secret verifies the request
+	var handlers []*webhookHandler
+	found := false
+	for _, h := range t.handlers {
+		if h.settings.Provider != "" && h.settings.Provider != provider {
+			continue
+		}
+		found = true
+		if h.verifies(provider, r.Header, body) {
+			handlers = append(handlers, h)
+		}
+	}
+	if !found {
+		http.Error(w, fmt.Sprintf("no handler for %s webhooks", provider), http.StatusNotFound)
+		return
+	}
+	if len(handlers) == 0 {
+		t.logger.Warnf("Unable to verify %s webhook '%s' from %s", provider, deliveryID, r.RemoteAddr)
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	var out *Output
+	if provider == ProviderGitHub {
+		out, err = parseGitHub(r.Header.Get("X-GitHub-Event"), body)
+	} else {
+		out, err = parseGitLab(body)
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid payload: %v", err), http.StatusBadRequest)
+		return
+	}
+	out.DeliveryID = deliveryID
+
+	// the whole payload is given too, for the fields that aren't parsed
+	out.Payload = make(map[string]interface{})
+	_ = json.Unmarshal(body, &out.Payload)
+
+	out.Headers = make(map[string]string)
+	for name, values := range r.Header {
+		out.Headers[name] = strings.Join(values, ",")
+	}
+
+	failed := false
+	for _, h := range handlers {
+		if !h.matches(out) {
+			continue
+		}
+		_, err := h.handler.Handle(r.Context(), out)
+		if err != nil {
+			t.logger.Errorf("Error handling %s event '%s' of %s: %v", out.Event, deliveryID, out.Repository, err)
+			failed = true
+		}
+	}
+
+	if failed {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// verifies returns true when the secret of the handler verifies the request, or when the handler
+// has no secret and allows the unsigned requests
+func (h *webhookHandler) verifies(provider string, header http.Header, body []byte) bool {
+
+	if h.settings.Secret == "" {
+		return h.settings.AllowUnsigned
+	}
+
+	return verify(provider, h.settings.Secret, header, body)
+}
+
+// verify verifies the X-Hub-Signature-256 header of a GitHub request, the HMAC-SHA256 of the body
+// with the secret, or the X-Gitlab-Token header of a GitLab request, which is the secret
+func verify(provider, secret string, header http.Header, body []byte) bool {
+
+	if secret == "" {
+		return false
+	}
+
+	if provider == ProviderGitLab {
+		token := header.Get("X-Gitlab-Token")
+		return subtle.ConstantTimeCompare([]byte(token), []byte(secret)) == 1
+	}
+
+	signature := header.Get("X-Hub-Signature-256")
+	if !strings.HasPrefix(signature, "sha256=") {
+		return false
+	}
+	sum, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	return hmac.Equal(sum, mac.Sum(nil))
+}
+
+// matches returns true when the event, repository and branch of the output match the filters of
+// the handler, the events without a branch, like issues or tags, aren't filtered by branch
+func (h *webhookHandler) matches(out *Output) bool {
+
+	if len(h.events) > 0 && !h.events[out.Event] {
+		return false
+	}
+	if len(h.repositories) > 0 && !matchAny(h.repositories, strings.ToLower(out.Repository)) {
+		return false
+	}
+	if len(h.branches) > 0 && out.Branch != "" && !matchAny(h.branches, out.Branch) {
+		return false
+	}
+
+	return true
+}
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func splitList(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
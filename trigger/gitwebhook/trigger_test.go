@@ -0,0 +1,308 @@
+package gitwebhook
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"flogo/core/action"
+	"flogo/core/api"
+	"flogo/core/support/test"
+	"flogo/core/trigger"
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig string = `{
+	"id": "trigger-gitwebhook",
+	"ref": "github.com/qingcloudhx/contrib/trigger/gitwebhook",
+	"settings": {
+	  "port": 8888
+	},
+	"handlers": [
+	  {
+		"settings": {
+		  "provider": "github",
+		  "secret": "s3cr3t"
+		},
+		"action": {
+		  "id": "test"
+		}
+	  }
+	]
+}`
+
+const githubPush = `{
+  "ref": "refs/heads/main",
+  "before": "a10867b",
+  "after": "e1b2c3d",
+  "repository": {"full_name": "Acme/api"},
+  "sender": {"login": "alice"},
+  "commits": [{"id": "e1b2c3d", "message": "Fix login", "timestamp": "2019-05-15T15:20:41Z", "url": "https://github.com/Acme/api/commit/e1b2c3d",
+    "author": {"name": "Alice", "email": "alice@acme.com"}, "added": ["login.go"], "modified": [], "removed": []}]
+}`
+
+func sign(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func post(tgr *Trigger, header map[string]string, body string) *httptest.ResponseRecorder {
+
+	r := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body))
+	for name, value := range header {
+		r.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	tgr.mux.ServeHTTP(w, r)
+
+	return w
+}
+
+func TestTrigger_GitHub(t *testing.T) {
+
+	var outputs []*Output
+	var actionErr error
+	actions := map[string]action.Action{"test": api.NewProxyAction(func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+		out := &Output{}
+		if err := out.FromMap(inputs); err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, out)
+		return nil, actionErr
+	})}
+
+	config := &trigger.Config{}
+	err := json.Unmarshal([]byte(testConfig), config)
+	assert.Nil(t, err)
+	trg, err := test.InitTrigger(&Factory{}, config, actions)
+	assert.Nil(t, err)
+	tgr := trg.(*Trigger)
+
+	header := map[string]string{"X-GitHub-Event": "push", "X-GitHub-Delivery": "72d3162e", "X-Hub-Signature-256": sign("s3cr3t", githubPush)}
+	w := post(tgr, header, githubPush)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Len(t, outputs, 1)
+
+	out := outputs[0]
+	assert.Equal(t, ProviderGitHub, out.Provider)
+	assert.Equal(t, EventPush, out.Event)
+	assert.Equal(t, "72d3162e", out.DeliveryID)
+	assert.Equal(t, "Acme/api", out.Repository)
+	assert.Equal(t, "main", out.Branch)
+	assert.Equal(t, "alice", out.Sender)
+	assert.Equal(t, "e1b2c3d", out.After)
+	assert.Equal(t, "push", out.Headers["X-Github-Event"])
+	assert.Equal(t, "refs/heads/main", out.Payload["ref"])
+	assert.Len(t, out.Commits, 1)
+	assert.Equal(t, "Fix login", out.Commits[0].(map[string]interface{})["message"])
+
+	// the signature of another body or secret isn't valid
+	header["X-Hub-Signature-256"] = sign("s3cr3t", githubPush+" ")
+	w = post(tgr, header, githubPush)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	header["X-Hub-Signature-256"] = sign("secret", githubPush)
+	w = post(tgr, header, githubPush)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	delete(header, "X-Hub-Signature-256")
+	w = post(tgr, header, githubPush)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Len(t, outputs, 1)
+
+	// no handler for GitLab
+	w = post(tgr, map[string]string{"X-Gitlab-Event": "Push Hook", "X-Gitlab-Token": "s3cr3t"}, `{"object_kind": "push"}`)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = post(tgr, map[string]string{}, githubPush)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	r := httptest.NewRequest(http.MethodGet, "/webhook", nil)
+	w = httptest.NewRecorder()
+	tgr.mux.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+
+	actionErr = errors.New("failed")
+	header["X-Hub-Signature-256"] = sign("s3cr3t", githubPush)
+	w = post(tgr, header, githubPush)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestTrigger_GitLab(t *testing.T) {
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	var outputs []*Output
+	app := api.NewApp()
+	trg := app.NewTrigger(&Trigger{}, map[string]interface{}{"port": port, "path": "/gitlab"})
+	for _, settings := range []map[string]interface{}{
+		{"provider": "gitlab", "secret": "token1", "repositories": "acme/api"},
+		{"provider": "gitlab", "secret": "token2", "repositories": "acme/web-*"},
+	} {
+		handler, err := trg.NewHandler(settings)
+		assert.Nil(t, err)
+		_, err = handler.NewAction(func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+			out := &Output{}
+			if err := out.FromMap(inputs); err != nil {
+				return nil, err
+			}
+			outputs = append(outputs, out)
+			return nil, nil
+		})
+		assert.Nil(t, err)
+	}
+
+	e, err := api.NewEngine(app)
+	assert.Nil(t, err)
+	err = e.Start()
+	assert.Nil(t, err)
+	defer e.Stop()
+
+	post := func(token, body string) int {
+		r, err := http.NewRequest(http.MethodPost, fmt.Sprintf("http://127.0.0.1:%d/gitlab", port), strings.NewReader(body))
+		assert.Nil(t, err)
+		r.Header.Set("X-Gitlab-Event", "Push Hook")
+		r.Header.Set("X-Gitlab-Event-UUID", "13792a34")
+		r.Header.Set("X-Gitlab-Token", token)
+		resp, err := http.DefaultClient.Do(r)
+		assert.Nil(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	body := `{"object_kind": "push", "ref": "refs/heads/main", "user_username": "bob", "project": {"path_with_namespace": "acme/web-shop"}}`
+	assert.Equal(t, http.StatusNoContent, post("token2", body))
+	assert.Len(t, outputs, 1)
+	assert.Equal(t, "13792a34", outputs[0].DeliveryID)
+	assert.Equal(t, "acme/web-shop", outputs[0].Repository)
+	assert.Equal(t, "bob", outputs[0].Sender)
+
+	// the token of the handler of another repository
+	assert.Equal(t, http.StatusNoContent, post("token1", body))
+	assert.Len(t, outputs, 1)
+
+	assert.Equal(t, http.StatusUnauthorized, post("token3", body))
+	assert.Equal(t, http.StatusBadRequest, post("token1", "{"))
+}
+
+func TestTrigger_Unsigned(t *testing.T) {
+
+	var outputs []*Output
+	actions := map[string]action.Action{"test": api.NewProxyAction(func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+		out := &Output{}
+		if err := out.FromMap(inputs); err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, out)
+		return nil, nil
+	})}
+
+	// a handler requires a secret unless it allows the unsigned requests
+	config := &trigger.Config{}
+	err := json.Unmarshal([]byte(testConfig), config)
+	assert.Nil(t, err)
+	config.Handlers[0].Settings = map[string]interface{}{"provider": "github"}
+	_, err = test.InitTrigger(&Factory{}, config, actions)
+	assert.NotNil(t, err)
+
+	config.Handlers[0].Settings["allowUnsigned"] = true
+	trg, err := test.InitTrigger(&Factory{}, config, actions)
+	assert.Nil(t, err)
+
+	w := post(trg.(*Trigger), map[string]string{"X-GitHub-Event": "push", "X-GitHub-Delivery": "72d3162e"}, githubPush)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Len(t, outputs, 1)
+}
+
+func TestTrigger_Restart(t *testing.T) {
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	handled := 0
+	actions := map[string]action.Action{"test": api.NewProxyAction(func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+		handled++
+		return nil, nil
+	})}
+
+	config := &trigger.Config{}
+	err = json.Unmarshal([]byte(testConfig), config)
+	assert.Nil(t, err)
+	config.Settings["port"] = port
+	trg, err := test.InitTrigger(&Factory{}, config, actions)
+	assert.Nil(t, err)
+
+	post := func() int {
+		r, err := http.NewRequest(http.MethodPost, fmt.Sprintf("http://127.0.0.1:%d/webhook", port), strings.NewReader(githubPush))
+		assert.Nil(t, err)
+		r.Header.Set("X-GitHub-Event", "push")
+		r.Header.Set("X-Hub-Signature-256", sign("s3cr3t", githubPush))
+		// the connections of a stopped trigger are closed
+		r.Close = true
+		resp, err := http.DefaultClient.Do(r)
+		if err != nil {
+			return 0
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// the trigger serves the requests again once restarted
+	for i := 1; i <= 2; i++ {
+		assert.Nil(t, trg.Start())
+		assert.Equal(t, http.StatusNoContent, post())
+		assert.Equal(t, i, handled)
+		assert.Nil(t, trg.Stop())
+	}
+	assert.Equal(t, 0, post())
+}
+
+func TestWebhookHandler_Matches(t *testing.T) {
+
+	h, err := newWebhookHandler(nil, &HandlerSettings{Events: "push, pull_request", Repositories: "Acme/*", Branches: "main,release/*"})
+	assert.Nil(t, err)
+
+	assert.True(t, h.matches(&Output{Event: EventPush, Repository: "acme/api", Branch: "main"}))
+	assert.True(t, h.matches(&Output{Event: EventPullRequest, Repository: "ACME/web", Branch: "release/1.2"}))
+	assert.True(t, h.matches(&Output{Event: EventPush, Repository: "acme/api", Tag: "v1.0.0"}))
+	assert.False(t, h.matches(&Output{Event: EventPush, Repository: "acme/api", Branch: "feature/login"}))
+	assert.False(t, h.matches(&Output{Event: EventPush, Repository: "other/api", Branch: "main"}))
+	assert.False(t, h.matches(&Output{Event: EventIssue, Repository: "acme/api"}))
+
+	h, err = newWebhookHandler(nil, &HandlerSettings{})
+	assert.Nil(t, err)
+	assert.True(t, h.matches(&Output{Event: "release", Repository: "acme/api"}))
+
+	_, err = newWebhookHandler(nil, &HandlerSettings{Branches: "release/["})
+	assert.NotNil(t, err)
+}
+
+func TestVerify(t *testing.T) {
+
+	header := http.Header{}
+	assert.False(t, verify(ProviderGitHub, "", header, []byte("{}")))
+
+	header.Set("X-Hub-Signature-256", sign("secret", "{}"))
+	assert.True(t, verify(ProviderGitHub, "secret", header, []byte("{}")))
+	header.Set("X-Hub-Signature-256", "sha256=zz")
+	assert.False(t, verify(ProviderGitHub, "secret", header, []byte("{}")))
+	header.Set("X-Hub-Signature-256", strings.TrimPrefix(sign("secret", "{}"), "sha256="))
+	assert.False(t, verify(ProviderGitHub, "secret", header, []byte("{}")))
+
+	header.Set("X-Gitlab-Token", "secret")
+	assert.True(t, verify(ProviderGitLab, "secret", header, []byte("{}")))
+	assert.False(t, verify(ProviderGitLab, "secrets", header, []byte("{}")))
+}
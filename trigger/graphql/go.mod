@@ -0,0 +1,7 @@
+module github.com/qingcloudhx/contrib/trigger/graphql
+
+require (
+	flogo/core v0.9.0
+	github.com/graphql-go/graphql v0.8.1
+	github.com/stretchr/testify v1.3.0
+)
@@ -0,0 +1,89 @@
+package graphql
+
+import (
+	"flogo/core/data/coerce"
+)
+
+const (
+	OperationQuery    = "query"
+	OperationMutation = "mutation"
+)
+
+type Settings struct {
+	Port          int    `md:"port,required"` // The port to listen on
+	Path          string `md:"path"`          // The path the GraphQL requests are served on, defaults to /graphql
+	Schema        string `md:"schema"`        // The schema of the API, in the GraphQL schema definition language
+	SchemaFile    string `md:"schemaFile"`    // The file of the schema of the API, instead of the schema setting
+	GraphiQL      bool   `md:"graphiql"`      // Serve the GraphiQL IDE to the browsers requesting the path, implies introspection
+	Introspection bool   `md:"introspection"` // Allow the introspection queries, used by the tools to discover the schema
+	CertFile      string `md:"certFile"`      // The PEM file of the server certificate, enables TLS
+	KeyFile       string `md:"keyFile"`       // The PEM file of the server private key
+}
+
+type HandlerSettings struct {
+	Operation string `md:"operation,allowed(query,mutation)"` // The operation of the field: query (default) or mutation
+	Field     string `md:"field,required"`                    // The field of the query or mutation type resolved by the handler
+}
+
+type Output struct {
+	Operation string                 `md:"operation"` // The operation of the field: query or mutation
+	Field     string                 `md:"field"`     // The field that is resolved
+	Arguments map[string]interface{} `md:"arguments"` // The arguments of the field, with their default values
+	Headers   map[string]string      `md:"headers"`   // The headers of the HTTP request
+}
+
+type Reply struct {
+	Data  interface{} `md:"data"`  // The value of the field, serialized according to its type in the schema
+	Error string      `md:"error"` // The error of the field, the field is null when specified
+}
+
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"operation": o.Operation,
+		"field":     o.Field,
+		"arguments": o.Arguments,
+		"headers":   o.Headers,
+	}
+}
+
+func (o *Output) FromMap(values map[string]interface{}) error {
+
+	var err error
+	o.Operation, err = coerce.ToString(values["operation"])
+	if err != nil {
+		return err
+	}
+	o.Field, err = coerce.ToString(values["field"])
+	if err != nil {
+		return err
+	}
+	o.Arguments, err = coerce.ToObject(values["arguments"])
+	if err != nil {
+		return err
+	}
+	o.Headers, err = coerce.ToParams(values["headers"])
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *Reply) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"data":  r.Data,
+		"error": r.Error,
+	}
+}
+
+func (r *Reply) FromMap(values map[string]interface{}) error {
+
+	var err error
+	r.Data = values["data"]
+	r.Error, err = coerce.ToString(values["error"])
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,435 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/kinds"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+const typeNameField = "__typename"
+
+// resolvers are the resolve functions of the fields, by type and field name
+type resolvers map[string]map[string]graphql.FieldResolveFn
+
+// schemaBuilder builds an executable schema from its SDL definition, the types are
+// created first and their fields resolved lazily so that they can reference each other
+type schemaBuilder struct {
+	definitions map[string]ast.Node
+	types       map[string]graphql.Type
+	resolvers   resolvers
+
+	// the first error of the lazily resolved fields
+	err error
+}
+
+// rootTypes returns the names of the query and mutation types of the SDL
+func rootTypes(doc *ast.Document) (query string, mutation string, err error) {
+
+	query, mutation = "Query", "Mutation"
+
+	for _, def := range doc.Definitions {
+		schema, ok := def.(*ast.SchemaDefinition)
+		if !ok {
+			continue
+		}
+		for _, op := range schema.OperationTypes {
+			switch op.Operation {
+			case ast.OperationTypeQuery:
+				query = op.Type.Name.Value
+			case ast.OperationTypeMutation:
+				mutation = op.Type.Name.Value
+			default:
+				return "", "", fmt.Errorf("unsupported %s operation", op.Operation)
+			}
+		}
+	}
+
+	return query, mutation, nil
+}
+
+// parseSchema parses the SDL of a schema
+func parseSchema(sdl string) (*ast.Document, error) {
+
+	doc, err := parser.Parse(parser.ParseParams{Source: sdl})
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema: %v", err)
+	}
+
+	return doc, nil
+}
+
+// buildSchema builds the executable schema of the SDL, the fields without a resolve
+// function resolve to the field of the same name of their parent object
+func buildSchema(doc *ast.Document, resolvers resolvers) (graphql.Schema, error) {
+
+	b := &schemaBuilder{definitions: make(map[string]ast.Node), types: make(map[string]graphql.Type), resolvers: resolvers}
+
+	for _, def := range doc.Definitions {
+		var name string
+		switch t := def.(type) {
+		case *ast.ObjectDefinition:
+			name = t.Name.Value
+		case *ast.InterfaceDefinition:
+			name = t.Name.Value
+		case *ast.UnionDefinition:
+			name = t.Name.Value
+		case *ast.EnumDefinition:
+			name = t.Name.Value
+		case *ast.InputObjectDefinition:
+			name = t.Name.Value
+		case *ast.ScalarDefinition:
+			name = t.Name.Value
+		case *ast.SchemaDefinition, *ast.DirectiveDefinition:
+			continue
+		default:
+			return graphql.Schema{}, fmt.Errorf("unsupported definition %s", def.GetKind())
+		}
+		if _, exists := b.definitions[name]; exists {
+			return graphql.Schema{}, fmt.Errorf("type '%s' is defined more than once", name)
+		}
+		b.definitions[name] = def
+	}
+
+	var types []graphql.Type
+	for name := range b.definitions {
+		t, err := b.namedType(name)
+		if err != nil {
+			return graphql.Schema{}, err
+		}
+		types = append(types, t)
+	}
+
+	queryName, mutationName, err := rootTypes(doc)
+	if err != nil {
+		return graphql.Schema{}, err
+	}
+
+	config := graphql.SchemaConfig{Types: types}
+
+	query, ok := b.types[queryName].(*graphql.Object)
+	if !ok {
+		return graphql.Schema{}, fmt.Errorf("query type '%s' is not defined", queryName)
+	}
+	config.Query = query
+
+	if t, ok := b.types[mutationName]; ok {
+		mutation, ok := t.(*graphql.Object)
+		if !ok {
+			return graphql.Schema{}, fmt.Errorf("mutation type '%s' is not an object", mutationName)
+		}
+		config.Mutation = mutation
+	}
+
+	schema, err := graphql.NewSchema(config)
+	if b.err != nil {
+		return graphql.Schema{}, b.err
+	}
+
+	return schema, err
+}
+
+func (b *schemaBuilder) namedType(name string) (graphql.Type, error) {
+
+	switch name {
+	case "Int":
+		return graphql.Int, nil
+	case "Float":
+		return graphql.Float, nil
+	case "String":
+		return graphql.String, nil
+	case "Boolean":
+		return graphql.Boolean, nil
+	case "ID":
+		return graphql.ID, nil
+	}
+
+	if t, ok := b.types[name]; ok {
+		return t, nil
+	}
+
+	def, ok := b.definitions[name]
+	if !ok {
+		return nil, fmt.Errorf("type '%s' is not defined", name)
+	}
+
+	var t graphql.Type
+
+	switch def := def.(type) {
+	case *ast.ObjectDefinition:
+		obj := graphql.NewObject(graphql.ObjectConfig{
+			Name:        name,
+			Description: description(def.Description),
+			Fields:      graphql.FieldsThunk(func() graphql.Fields { return b.fields(name, def.Fields) }),
+			Interfaces: graphql.InterfacesThunk(func() []*graphql.Interface {
+				var interfaces []*graphql.Interface
+				for _, named := range def.Interfaces {
+					it, err := b.namedType(named.Name.Value)
+					if err != nil {
+						b.fail(err)
+						continue
+					}
+					if it, ok := it.(*graphql.Interface); ok {
+						interfaces = append(interfaces, it)
+					} else {
+						b.fail(fmt.Errorf("type '%s' is not an interface", it.Name()))
+					}
+				}
+				return interfaces
+			}),
+		})
+		t = obj
+	case *ast.InterfaceDefinition:
+		t = graphql.NewInterface(graphql.InterfaceConfig{
+			Name:        name,
+			Description: description(def.Description),
+			Fields:      graphql.FieldsThunk(func() graphql.Fields { return b.fields(name, def.Fields) }),
+			ResolveType: b.resolveType,
+		})
+	case *ast.UnionDefinition:
+		t = graphql.NewUnion(graphql.UnionConfig{
+			Name:        name,
+			Description: description(def.Description),
+			Types: graphql.UnionTypesThunk(func() []*graphql.Object {
+				var objects []*graphql.Object
+				for _, named := range def.Types {
+					obj, err := b.namedType(named.Name.Value)
+					if err != nil {
+						b.fail(err)
+						continue
+					}
+					if obj, ok := obj.(*graphql.Object); ok {
+						objects = append(objects, obj)
+					} else {
+						b.fail(fmt.Errorf("type '%s' of union '%s' is not an object", obj.Name(), name))
+					}
+				}
+				return objects
+			}),
+			ResolveType: b.resolveType,
+		})
+	case *ast.EnumDefinition:
+		values := graphql.EnumValueConfigMap{}
+		for _, value := range def.Values {
+			values[value.Name.Value] = &graphql.EnumValueConfig{Value: value.Name.Value, Description: description(value.Description)}
+		}
+		t = graphql.NewEnum(graphql.EnumConfig{Name: name, Description: description(def.Description), Values: values})
+	case *ast.InputObjectDefinition:
+		t = graphql.NewInputObject(graphql.InputObjectConfig{
+			Name:        name,
+			Description: description(def.Description),
+			Fields: graphql.InputObjectConfigFieldMapThunk(func() graphql.InputObjectConfigFieldMap {
+				fields := graphql.InputObjectConfigFieldMap{}
+				for _, field := range def.Fields {
+					fieldType, err := b.inputType(field.Type)
+					if err != nil {
+						b.fail(err)
+						continue
+					}
+					fields[field.Name.Value] = &graphql.InputObjectFieldConfig{
+						Type:         fieldType,
+						DefaultValue: valueOf(field.DefaultValue),
+						Description:  description(field.Description),
+					}
+				}
+				return fields
+			}),
+		})
+	case *ast.ScalarDefinition:
+		// the values of the custom scalars are passed as is
+		t = graphql.NewScalar(graphql.ScalarConfig{
+			Name:         name,
+			Description:  description(def.Description),
+			Serialize:    func(value interface{}) interface{} { return value },
+			ParseValue:   func(value interface{}) interface{} { return value },
+			ParseLiteral: func(value ast.Value) interface{} { return valueOf(value) },
+		})
+	}
+
+	if err := t.Error(); err != nil {
+		return nil, err
+	}
+	b.types[name] = t
+
+	return t, nil
+}
+
+func (b *schemaBuilder) fields(typeName string, defs []*ast.FieldDefinition) graphql.Fields {
+
+	fields := graphql.Fields{}
+
+	for _, def := range defs {
+		fieldType, err := b.typeOf(def.Type)
+		if err != nil {
+			b.fail(err)
+			continue
+		}
+
+		args := graphql.FieldConfigArgument{}
+		for _, arg := range def.Arguments {
+			argType, err := b.inputType(arg.Type)
+			if err != nil {
+				b.fail(err)
+				continue
+			}
+			args[arg.Name.Value] = &graphql.ArgumentConfig{
+				Type:         argType,
+				DefaultValue: valueOf(arg.DefaultValue),
+				Description:  description(arg.Description),
+			}
+		}
+
+		fields[def.Name.Value] = &graphql.Field{
+			Name:        def.Name.Value,
+			Type:        fieldType,
+			Args:        args,
+			Resolve:     b.resolvers[typeName][def.Name.Value],
+			Description: description(def.Description),
+		}
+	}
+
+	return fields
+}
+
+func (b *schemaBuilder) fail(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
+
+func (b *schemaBuilder) typeOf(t ast.Type) (graphql.Type, error) {
+
+	switch t := t.(type) {
+	case *ast.NonNull:
+		inner, err := b.typeOf(t.Type)
+		if err != nil {
+			return nil, err
+		}
+		return graphql.NewNonNull(inner), nil
+	case *ast.List:
+		inner, err := b.typeOf(t.Type)
+		if err != nil {
+			return nil, err
+		}
+		return graphql.NewList(inner), nil
+	case *ast.Named:
+		return b.namedType(t.Name.Value)
+	}
+
+	return nil, fmt.Errorf("unsupported type %s", t.GetKind())
+}
+
+func (b *schemaBuilder) inputType(t ast.Type) (graphql.Input, error) {
+
+	gt, err := b.typeOf(t)
+	if err != nil {
+		return nil, err
+	}
+
+	input, ok := gt.(graphql.Input)
+	if !ok {
+		return nil, fmt.Errorf("type '%s' is not an input type", gt.Name())
+	}
+
+	return input, nil
+}
+
+// resolveType resolves the object type of an interface or union value with its __typename
+func (b *schemaBuilder) resolveType(p graphql.ResolveTypeParams) *graphql.Object {
+
+	if value, ok := p.Value.(map[string]interface{}); ok {
+		if name, ok := value[typeNameField].(string); ok {
+			if obj, ok := b.types[name].(*graphql.Object); ok {
+				return obj
+			}
+		}
+	}
+
+	return nil
+}
+
+// valueOf converts a literal value of the SDL or of a query
+func valueOf(value ast.Value) interface{} {
+
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case *ast.IntValue:
+		i, err := strconv.Atoi(v.Value)
+		if err != nil {
+			return nil
+		}
+		return i
+	case *ast.FloatValue:
+		f, err := strconv.ParseFloat(v.Value, 64)
+		if err != nil {
+			return nil
+		}
+		return f
+	case *ast.StringValue:
+		return v.Value
+	case *ast.BooleanValue:
+		return v.Value
+	case *ast.EnumValue:
+		return v.Value
+	case *ast.ListValue:
+		values := make([]interface{}, 0, len(v.Values))
+		for _, item := range v.Values {
+			values = append(values, valueOf(item))
+		}
+		return values
+	case *ast.ObjectValue:
+		values := make(map[string]interface{}, len(v.Fields))
+		for _, field := range v.Fields {
+			values[field.Name.Value] = valueOf(field.Value)
+		}
+		return values
+	}
+
+	return nil
+}
+
+func description(value *ast.StringValue) string {
+	if value == nil {
+		return ""
+	}
+	return value.Value
+}
+
+// isIntrospection returns true when the document queries the introspection fields
+func isIntrospection(doc *ast.Document) bool {
+
+	var found bool
+	var visit func(set *ast.SelectionSet)
+	visit = func(set *ast.SelectionSet) {
+		if set == nil || found {
+			return
+		}
+		for _, selection := range set.Selections {
+			switch s := selection.(type) {
+			case *ast.Field:
+				if name := s.Name.Value; name == "__schema" || name == "__type" {
+					found = true
+					return
+				}
+				visit(s.SelectionSet)
+			case *ast.InlineFragment:
+				visit(s.SelectionSet)
+			}
+		}
+	}
+
+	for _, def := range doc.Definitions {
+		switch def.GetKind() {
+		case kinds.OperationDefinition:
+			visit(def.(*ast.OperationDefinition).SelectionSet)
+		case kinds.FragmentDefinition:
+			visit(def.(*ast.FragmentDefinition).SelectionSet)
+		}
+	}
+
+	return found
+}
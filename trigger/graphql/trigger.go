@@ -0,0 +1,349 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"flogo/core/data/metadata"
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+const defaultPath = "/graphql"
+
+var triggerMd = trigger.NewMetadata(&Settings{}, &HandlerSettings{}, &Output{}, &Reply{})
+
+func init() {
+	_ = trigger.Register(&Trigger{}, &Factory{})
+}
+
+type Factory struct {
+}
+
+// Metadata implements trigger.Factory.Metadata
+func (*Factory) Metadata() *trigger.Metadata {
+	return triggerMd
+}
+
+// New implements trigger.Factory.New
+func (*Factory) New(config *trigger.Config) (trigger.Trigger, error) {
+
+	s := &Settings{}
+	err := metadata.MapToStruct(config.Settings, s, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Trigger{settings: s}, nil
+}
+
+// Trigger is a GraphQL server, each handler resolves a field of the query or mutation type
+type Trigger struct {
+	settings *Settings
+	logger   log.Logger
+	schema   graphql.Schema
+
+	mux      *http.ServeMux
+	server   *http.Server
+	listener net.Listener
+}
+
+type requestKey struct{}
+
+// Initialize implements trigger.Init.Initialize
+func (t *Trigger) Initialize(ctx trigger.InitContext) error {
+
+	t.logger = ctx.Logger()
+
+	sdl := t.settings.Schema
+	if t.settings.SchemaFile != "" {
+		data, err := ioutil.ReadFile(t.settings.SchemaFile)
+		if err != nil {
+			return fmt.Errorf("unable to read schema file '%s': %v", t.settings.SchemaFile, err)
+		}
+		sdl = string(data)
+	}
+	if sdl == "" {
+		return errors.New("a schema or a schema file is required")
+	}
+
+	doc, err := parseSchema(sdl)
+	if err != nil {
+		return err
+	}
+	queryType, mutationType, err := rootTypes(doc)
+	if err != nil {
+		return err
+	}
+
+	fields := make(resolvers)
+	for _, handler := range ctx.GetHandlers() {
+
+		s := &HandlerSettings{}
+		err := metadata.MapToStruct(handler.Settings(), s, true)
+		if err != nil {
+			return err
+		}
+
+		typeName := queryType
+		if s.Operation == OperationMutation {
+			typeName = mutationType
+		} else {
+			s.Operation = OperationQuery
+		}
+
+		if fields[typeName] == nil {
+			fields[typeName] = make(map[string]graphql.FieldResolveFn)
+		}
+		if _, exists := fields[typeName][s.Field]; exists {
+			return fmt.Errorf("more than one handler for %s field '%s'", s.Operation, s.Field)
+		}
+		fields[typeName][s.Field] = t.newResolver(handler, s)
+	}
+
+	t.schema, err = buildSchema(doc, fields)
+	if err != nil {
+		return fmt.Errorf("invalid schema: %v", err)
+	}
+
+	// the handlers must resolve fields of the schema
+	for typeName, resolvers := range fields {
+		var fieldMap graphql.FieldDefinitionMap
+		if obj, ok := t.schema.Type(typeName).(*graphql.Object); ok {
+			fieldMap = obj.Fields()
+		}
+		for field := range resolvers {
+			if _, ok := fieldMap[field]; !ok {
+				return fmt.Errorf("field '%s' is not defined in type '%s' of the schema", field, typeName)
+			}
+		}
+	}
+
+	path := t.settings.Path
+	if path == "" {
+		path = defaultPath
+	}
+
+	t.mux = http.NewServeMux()
+	t.mux.HandleFunc(path, t.serveHTTP)
+
+	return nil
+}
+
+// Start implements util.Managed.Start
+func (t *Trigger) Start() error {
+
+	ln, err := net.Listen("tcp", ":"+strconv.Itoa(t.settings.Port))
+	if err != nil {
+		return err
+	}
+	t.listener = ln
+
+	// a shut down server can't serve again, each start uses a new one
+	server := &http.Server{Handler: t.mux, ReadHeaderTimeout: 30 * time.Second}
+	t.server = server
+
+	t.logger.Infof("Listening on port %d", t.settings.Port)
+
+	go func() {
+		var err error
+		if t.settings.CertFile != "" {
+			err = server.ServeTLS(ln, t.settings.CertFile, t.settings.KeyFile)
+		} else {
+			err = server.Serve(ln)
+		}
+		if err != nil && err != http.ErrServerClosed {
+			t.logger.Errorf("GraphQL server stopped: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+// Stop implements util.Managed.Stop
+func (t *Trigger) Stop() error {
+
+	if t.listener == nil {
+		return nil
+	}
+	t.listener = nil
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return t.server.Shutdown(ctx)
+}
+
+// newResolver returns the resolve function of the field of a handler, which invokes
+// its action with the arguments of the field
+func (t *Trigger) newResolver(handler trigger.Handler, s *HandlerSettings) graphql.FieldResolveFn {
+
+	return func(p graphql.ResolveParams) (interface{}, error) {
+
+		out := &Output{Operation: s.Operation, Field: s.Field, Arguments: p.Args, Headers: make(map[string]string)}
+		if r, ok := p.Context.Value(requestKey{}).(*http.Request); ok {
+			for name, values := range r.Header {
+				out.Headers[name] = strings.Join(values, ",")
+			}
+		}
+
+		results, err := handler.Handle(p.Context, out)
+		if err != nil {
+			t.logger.Errorf("Error resolving %s field '%s': %v", s.Operation, s.Field, err)
+			return nil, err
+		}
+
+		reply := &Reply{}
+		err = reply.FromMap(results)
+		if err != nil {
+			return nil, err
+		}
+		if reply.Error != "" {
+			return nil, errors.New(reply.Error)
+		}
+
+		return reply.Data, nil
+	}
+}
+
+// request is a GraphQL request, sent as JSON or as query parameters
+type request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+func (t *Trigger) serveHTTP(w http.ResponseWriter, r *http.Request) {
+
+	req := &request{}
+
+	switch r.Method {
+	case http.MethodGet:
+		query := r.URL.Query()
+		if query.Get("query") == "" && t.settings.GraphiQL && strings.Contains(r.Header.Get("Accept"), "text/html") {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = w.Write([]byte(graphiQLPage))
+			return
+		}
+		req.Query = query.Get("query")
+		req.OperationName = query.Get("operationName")
+		if variables := query.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				writeErrors(w, http.StatusBadRequest, fmt.Errorf("invalid variables: %v", err))
+				return
+			}
+		}
+	case http.MethodPost:
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			writeErrors(w, http.StatusBadRequest, err)
+			return
+		}
+		if contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); contentType == "application/graphql" {
+			req.Query = string(body)
+		} else if err := json.Unmarshal(body, req); err != nil {
+			writeErrors(w, http.StatusBadRequest, fmt.Errorf("invalid request: %v", err))
+			return
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		writeErrors(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s is not allowed", r.Method))
+		return
+	}
+
+	if req.Query == "" {
+		writeErrors(w, http.StatusBadRequest, errors.New("a query is required"))
+		return
+	}
+
+	doc, err := parser.Parse(parser.ParseParams{Source: req.Query})
+	if err != nil {
+		writeErrors(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if result := graphql.ValidateDocument(&t.schema, doc, nil); !result.IsValid {
+		writeResult(w, http.StatusBadRequest, &graphql.Result{Errors: result.Errors})
+		return
+	}
+
+	if !t.settings.Introspection && !t.settings.GraphiQL && isIntrospection(doc) {
+		writeErrors(w, http.StatusBadRequest, errors.New("introspection is disabled"))
+		return
+	}
+
+	if r.Method == http.MethodGet && isMutation(doc, req.OperationName) {
+		w.Header().Set("Allow", "POST")
+		writeErrors(w, http.StatusMethodNotAllowed, errors.New("mutations must be sent with POST"))
+		return
+	}
+
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        t.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       context.WithValue(r.Context(), requestKey{}, r),
+	})
+
+	writeResult(w, http.StatusOK, result)
+}
+
+// isMutation returns true when the operation to execute is a mutation
+func isMutation(doc *ast.Document, operationName string) bool {
+
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if operationName == "" || (op.Name != nil && op.Name.Value == operationName) {
+			return op.Operation == ast.OperationTypeMutation
+		}
+	}
+
+	return false
+}
+
+func writeErrors(w http.ResponseWriter, status int, err error) {
+	writeResult(w, status, &graphql.Result{Errors: []gqlerrors.FormattedError{gqlerrors.NewFormattedError(err.Error())}})
+}
+
+func writeResult(w http.ResponseWriter, status int, result *graphql.Result) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(result)
+}
+
+const graphiQLPage = `<!DOCTYPE html>
+<html>
+<head>
+  <title>GraphiQL</title>
+  <link rel="stylesheet" href="https://unpkg.com/graphiql@1.4.7/graphiql.min.css"/>
+  <style>body { margin: 0; height: 100vh; } #graphiql { height: 100vh; }</style>
+</head>
+<body>
+  <div id="graphiql">Loading...</div>
+  <script src="https://unpkg.com/react@17/umd/react.production.min.js"></script>
+  <script src="https://unpkg.com/react-dom@17/umd/react-dom.production.min.js"></script>
+  <script src="https://unpkg.com/graphiql@1.4.7/graphiql.min.js"></script>
+  <script>
+    var fetcher = GraphiQL.createFetcher({url: window.location.pathname});
+    ReactDOM.render(React.createElement(GraphiQL, {fetcher: fetcher}), document.getElementById('graphiql'));
+  </script>
+</body>
+</html>
+`
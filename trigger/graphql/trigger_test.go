@@ -0,0 +1,285 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"flogo/core/action"
+	"flogo/core/api"
+	"flogo/core/support/test"
+	"flogo/core/trigger"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSchema = `
+enum Status { ACTIVE SUSPENDED }
+
+interface Node { id: ID! }
+
+type User implements Node {
+  id: ID!
+  name: String
+  status: Status
+  age: Int
+}
+
+type Group implements Node {
+  id: ID!
+  members: [User]
+}
+
+input UserInput {
+  name: String!
+  status: Status = ACTIVE
+}
+
+type Query {
+  user(id: ID!): User
+  users(status: Status, limit: Int = 10): [User]
+  node(id: ID!): Node
+  version: String
+}
+
+type Mutation {
+  createUser(input: UserInput!): User
+}
+`
+
+// newApp returns an app with a GraphQL trigger listening on a free port, whose handlers resolve the fields
+// of the test schema
+func newApp(t *testing.T, settings map[string]interface{}) (*api.App, int) {
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	settings["port"] = port
+	settings["schema"] = testSchema
+
+	app := api.NewApp()
+	trg := app.NewTrigger(&Trigger{}, settings)
+	resolve := func(settings map[string]interface{}, reply func(out *Output) map[string]interface{}) {
+		handler, err := trg.NewHandler(settings)
+		assert.Nil(t, err)
+		_, err = handler.NewAction(func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+			out := &Output{}
+			if err := out.FromMap(inputs); err != nil {
+				return nil, err
+			}
+			return reply(out), nil
+		})
+		assert.Nil(t, err)
+	}
+
+	resolve(map[string]interface{}{"field": "user"}, func(out *Output) map[string]interface{} {
+		if out.Arguments["id"] != "1" {
+			return map[string]interface{}{"error": "user not found"}
+		}
+		return map[string]interface{}{"data": map[string]interface{}{
+			"id": 1, "name": "Bob", "status": "ACTIVE", "age": 42.0, "password": "secret"}}
+	})
+	resolve(map[string]interface{}{"field": "users"}, func(out *Output) map[string]interface{} {
+		return map[string]interface{}{"data": []interface{}{
+			map[string]interface{}{"id": "1", "name": out.Headers["X-Tenant"]},
+			map[string]interface{}{"id": "2", "name": out.Arguments["limit"]}}}
+	})
+	resolve(map[string]interface{}{"field": "node"}, func(out *Output) map[string]interface{} {
+		return map[string]interface{}{"data": map[string]interface{}{"__typename": "Group", "id": out.Arguments["id"],
+			"members": []interface{}{map[string]interface{}{"id": "1", "name": "Bob"}}}}
+	})
+	resolve(map[string]interface{}{"operation": "mutation", "field": "createUser"}, func(out *Output) map[string]interface{} {
+		input := out.Arguments["input"].(map[string]interface{})
+		return map[string]interface{}{"data": map[string]interface{}{"id": "3", "name": input["name"], "status": input["status"]}}
+	})
+
+	return app, port
+}
+
+// startApp starts the engine of the app, it returns the function stopping it
+func startApp(t *testing.T, app *api.App) func() {
+
+	e, err := api.NewEngine(app)
+	assert.Nil(t, err)
+	err = e.Start()
+	assert.Nil(t, err)
+
+	return func() {
+		_ = e.Stop()
+	}
+}
+
+func post(port int, query string, variables map[string]interface{}) (int, map[string]interface{}) {
+
+	body, _ := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	r, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("http://127.0.0.1:%d/graphql", port), strings.NewReader(string(body)))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("X-Tenant", "acme")
+
+	return serve(r)
+}
+
+func get(port int, query string, header map[string]string) (int, map[string]interface{}) {
+
+	r, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("http://127.0.0.1:%d/graphql", port), nil)
+	if query != "" {
+		r.URL.RawQuery = "query=" + url.QueryEscape(query)
+	}
+	for name, value := range header {
+		r.Header.Set(name, value)
+	}
+
+	return serve(r)
+}
+
+func serve(r *http.Request) (int, map[string]interface{}) {
+
+	// the connections of a stopped trigger are closed
+	r.Close = true
+	resp, err := http.DefaultClient.Do(r)
+	if err != nil {
+		return 0, nil
+	}
+	defer resp.Body.Close()
+
+	var result map[string]interface{}
+	_ = json.NewDecoder(resp.Body).Decode(&result)
+
+	return resp.StatusCode, result
+}
+
+func TestTrigger_Query(t *testing.T) {
+
+	app, port := newApp(t, map[string]interface{}{})
+	stop := startApp(t, app)
+	defer stop()
+
+	status, result := post(port, `{ user(id: "1") { id name status age } }`, nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Nil(t, result["errors"])
+	// the data is serialized according to the schema
+	assert.Equal(t, map[string]interface{}{"user": map[string]interface{}{"id": "1", "name": "Bob", "status": "ACTIVE", "age": 42.0}}, result["data"])
+
+	status, result = post(port, `query($status: Status) { users(status: $status) { id name } }`, map[string]interface{}{"status": "ACTIVE"})
+	assert.Equal(t, http.StatusOK, status)
+	users := result["data"].(map[string]interface{})["users"].([]interface{})
+	assert.Equal(t, "acme", users[0].(map[string]interface{})["name"])
+	assert.Equal(t, "10", users[1].(map[string]interface{})["name"])
+
+	status, result = post(port, `{ node(id: "g1") { id ... on Group { members { name } } } }`, nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, map[string]interface{}{"node": map[string]interface{}{"id": "g1",
+		"members": []interface{}{map[string]interface{}{"name": "Bob"}}}}, result["data"])
+
+	// the error of the reply
+	status, result = post(port, `{ user(id: "2") { id } }`, nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "user not found", result["errors"].([]interface{})[0].(map[string]interface{})["message"])
+
+	// no handler for the field
+	status, result = post(port, `{ version }`, nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, map[string]interface{}{"version": nil}, result["data"])
+
+	// invalid query
+	status, result = post(port, `{ user(id: "1") { password } }`, nil)
+	assert.Equal(t, http.StatusBadRequest, status)
+	assert.NotNil(t, result["errors"])
+}
+
+func TestTrigger_Mutation(t *testing.T) {
+
+	app, port := newApp(t, map[string]interface{}{})
+	stop := startApp(t, app)
+	defer stop()
+
+	status, result := post(port, `mutation { createUser(input: {name: "Alice"}) { id name status } }`, nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, map[string]interface{}{"createUser": map[string]interface{}{"id": "3", "name": "Alice", "status": "ACTIVE"}}, result["data"])
+
+	status, _ = get(port, `mutation { createUser(input: {name: "Alice"}) { id } }`, nil)
+	assert.Equal(t, http.StatusMethodNotAllowed, status)
+
+	status, result = get(port, `{ user(id: "1") { name } }`, nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, map[string]interface{}{"user": map[string]interface{}{"name": "Bob"}}, result["data"])
+}
+
+func TestTrigger_Introspection(t *testing.T) {
+
+	app, port := newApp(t, map[string]interface{}{})
+	stop := startApp(t, app)
+	status, _ := post(port, `{ __schema { types { name } } }`, nil)
+	assert.Equal(t, http.StatusBadRequest, status)
+	status, _ = get(port, "", map[string]string{"Accept": "text/html"})
+	assert.Equal(t, http.StatusBadRequest, status)
+	stop()
+
+	app, port = newApp(t, map[string]interface{}{"introspection": true})
+	stop = startApp(t, app)
+	status, result := post(port, `{ __type(name: "User") { name } }`, nil)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, map[string]interface{}{"__type": map[string]interface{}{"name": "User"}}, result["data"])
+	stop()
+
+	app, port = newApp(t, map[string]interface{}{"graphiql": true})
+	stop = startApp(t, app)
+	defer stop()
+	r, _ := http.NewRequest(http.MethodGet, fmt.Sprintf("http://127.0.0.1:%d/graphql", port), nil)
+	r.Header.Set("Accept", "text/html")
+	resp, err := http.DefaultClient.Do(r)
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.True(t, strings.Contains(string(body), "GraphiQL"))
+}
+
+func TestTrigger_Restart(t *testing.T) {
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	config := &trigger.Config{Settings: map[string]interface{}{"port": port, "schema": testSchema}}
+	trg, err := test.InitTrigger(&Factory{}, config, nil)
+	assert.Nil(t, err)
+
+	// the trigger serves the requests again once restarted
+	for i := 0; i < 2; i++ {
+		assert.Nil(t, trg.Start())
+		status, result := post(port, `{ version }`, nil)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, map[string]interface{}{"version": nil}, result["data"])
+		assert.Nil(t, trg.Stop())
+	}
+	status, _ := post(port, `{ version }`, nil)
+	assert.Equal(t, 0, status)
+}
+
+func TestInitialize_Errors(t *testing.T) {
+
+	f := &Factory{}
+	actions := map[string]action.Action{"dummy": test.NewDummyAction(func() {
+		//do nothing
+	})}
+
+	config := &trigger.Config{Settings: map[string]interface{}{"port": 8888, "schema": testSchema}, Handlers: []*trigger.HandlerConfig{
+		{Settings: map[string]interface{}{"field": "unknown"}, Actions: []*trigger.ActionConfig{{Config: &action.Config{Id: "dummy"}}}},
+	}}
+	_, err := test.InitTrigger(f, config, actions)
+	assert.NotNil(t, err)
+
+	for _, schema := range []string{"type Query { user: Unknown }", "type User { id: ID }", ""} {
+		_, err = test.InitTrigger(f, &trigger.Config{Settings: map[string]interface{}{"port": 8888, "schema": schema}}, actions)
+		assert.NotNil(t, err)
+	}
+}
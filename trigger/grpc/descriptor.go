@@ -0,0 +1,149 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+var (
+	protoJSON   = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+	protoUnJSON = protojson.UnmarshalOptions{}
+)
+
+// loadFiles loads the descriptors of the services, from the descriptor set or by compiling the .proto files
+func loadFiles(settings *Settings) (*protoregistry.Files, error) {
+
+	if settings.DescriptorSet != "" {
+		data, err := ioutil.ReadFile(settings.DescriptorSet)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read descriptor set [%s]: %v", settings.DescriptorSet, err)
+		}
+
+		set := &descriptorpb.FileDescriptorSet{}
+		if err := proto.Unmarshal(data, set); err != nil {
+			return nil, fmt.Errorf("invalid descriptor set [%s]: %v", settings.DescriptorSet, err)
+		}
+
+		files, err := protodesc.NewFiles(set)
+		if err != nil {
+			return nil, fmt.Errorf("invalid descriptor set [%s]: %v", settings.DescriptorSet, err)
+		}
+		return files, nil
+	}
+
+	if settings.ProtoFiles == "" {
+		return nil, fmt.Errorf("a descriptor set or proto files are required")
+	}
+
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			ImportPaths: splitList(settings.ImportPaths),
+		}),
+	}
+	compiled, err := compiler.Compile(context.Background(), splitList(settings.ProtoFiles)...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to compile proto files: %v", err)
+	}
+
+	files := &protoregistry.Files{}
+	for _, file := range compiled {
+		if err := registerFile(files, file); err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+// registerFile registers a file and its imports
+func registerFile(files *protoregistry.Files, file protoreflect.FileDescriptor) error {
+	if _, err := files.FindFileByPath(file.Path()); err == nil {
+		return nil
+	}
+
+	imports := file.Imports()
+	for i := 0; i < imports.Len(); i++ {
+		if err := registerFile(files, imports.Get(i).FileDescriptor); err != nil {
+			return err
+		}
+	}
+
+	return files.RegisterFile(file)
+}
+
+// findMethod returns the descriptor of the method of a service
+func findMethod(files *protoregistry.Files, service, method string) (protoreflect.MethodDescriptor, error) {
+	desc, err := files.FindDescriptorByName(protoreflect.FullName(service))
+	if err != nil {
+		return nil, fmt.Errorf("service [%s] not found", service)
+	}
+	svc, ok := desc.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("[%s] is not a service", service)
+	}
+
+	m := svc.Methods().ByName(protoreflect.Name(method))
+	if m == nil {
+		return nil, fmt.Errorf("method [%s] not found in service [%s]", method, service)
+	}
+	if m.IsStreamingClient() {
+		return nil, fmt.Errorf("method [%s] of service [%s] is client streaming, only the unary and server streaming methods are supported", method, service)
+	}
+
+	return m, nil
+}
+
+// toContent converts a message to a map, with the field names of the proto definition
+func toContent(msg proto.Message) (map[string]interface{}, error) {
+	data, err := protoJSON.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	var content map[string]interface{}
+	if err := json.Unmarshal(data, &content); err != nil {
+		return nil, err
+	}
+
+	return content, nil
+}
+
+// toMessage converts the data of a reply to a message
+func toMessage(desc protoreflect.MessageDescriptor, data interface{}) (proto.Message, error) {
+	msg := dynamicpb.NewMessage(desc)
+	if data == nil {
+		return msg, nil
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := protoUnJSON.Unmarshal(encoded, msg); err != nil {
+		return nil, fmt.Errorf("the reply doesn't match message [%s]: %v", desc.FullName(), err)
+	}
+
+	return msg, nil
+}
+
+func splitList(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
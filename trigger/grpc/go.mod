@@ -0,0 +1,9 @@
+module github.com/qingcloudhx/contrib/trigger/grpc
+
+require (
+	github.com/bufbuild/protocompile v0.14.1
+	flogo/core v0.9.0
+	github.com/stretchr/testify v1.3.0
+	google.golang.org/grpc v1.70.0
+	google.golang.org/protobuf v1.36.12
+)
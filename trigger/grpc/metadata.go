@@ -0,0 +1,84 @@
+package grpc
+
+import (
+	"flogo/core/data/coerce"
+)
+
+type Settings struct {
+	Port          int    `md:"port,required"` // The port to listen on
+	DescriptorSet string `md:"descriptorSet"` // The compiled descriptor set of the services (ex. generated with protoc --include_imports --descriptor_set_out)
+	ProtoFiles    string `md:"protoFiles"`    // The comma separated .proto files of the services, instead of a descriptor set
+	ImportPaths   string `md:"importPaths"`   // The comma separated directories the imports of the .proto files are resolved from
+	Reflection    bool   `md:"reflection"`    // Serve the gRPC server reflection service, so that clients such as grpcurl can list and call the methods
+	CertFile      string `md:"certFile"`      // The PEM file of the server certificate, enables TLS
+	KeyFile       string `md:"keyFile"`       // The PEM file of the server private key
+	ClientCAFile  string `md:"clientCAFile"`  // The PEM file of the CA certificates the client certificates are verified with, requires the clients to authenticate
+}
+
+type HandlerSettings struct {
+	Service string `md:"service,required"` // The full name of the service (ex. helloworld.Greeter)
+	Method  string `md:"method,required"`  // The name of the method (ex. SayHello)
+}
+
+type Output struct {
+	Method   string                 `md:"method"`   // The full name of the method that was called (ex. /helloworld.Greeter/SayHello)
+	Metadata map[string]string      `md:"metadata"` // The metadata of the call
+	Content  map[string]interface{} `md:"content"`  // The request message, with the field names of the proto definition
+}
+
+type Reply struct {
+	Code    int         `md:"code"`    // The gRPC status code of the response, 0 (OK) if not specified
+	Message string      `md:"message"` // The error message, when the code is not OK
+	Data    interface{} `md:"data"`    // The response message, an array of messages for the server streaming methods
+}
+
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"method":   o.Method,
+		"metadata": o.Metadata,
+		"content":  o.Content,
+	}
+}
+
+func (o *Output) FromMap(values map[string]interface{}) error {
+
+	var err error
+	o.Method, err = coerce.ToString(values["method"])
+	if err != nil {
+		return err
+	}
+	o.Metadata, err = coerce.ToParams(values["metadata"])
+	if err != nil {
+		return err
+	}
+	o.Content, err = coerce.ToObject(values["content"])
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *Reply) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"code":    r.Code,
+		"message": r.Message,
+		"data":    r.Data,
+	}
+}
+
+func (r *Reply) FromMap(values map[string]interface{}) error {
+
+	var err error
+	r.Code, err = coerce.ToInt(values["code"])
+	if err != nil {
+		return err
+	}
+	r.Message, err = coerce.ToString(values["message"])
+	if err != nil {
+		return err
+	}
+	r.Data = values["data"]
+
+	return nil
+}
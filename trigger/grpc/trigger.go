@@ -0,0 +1,251 @@
+package grpc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strings"
+
+	"flogo/core/data/coerce"
+	"flogo/core/data/metadata"
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	md "google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	reflectionv1 "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	reflectionv1alpha "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+var triggerMd = trigger.NewMetadata(&Settings{}, &HandlerSettings{}, &Output{}, &Reply{})
+
+func init() {
+	_ = trigger.Register(&Trigger{}, &Factory{})
+}
+
+// Factory is a gRPC trigger factory
+type Factory struct {
+}
+
+// Metadata implements trigger.Factory.Metadata
+func (*Factory) Metadata() *trigger.Metadata {
+	return triggerMd
+}
+
+// New implements trigger.Factory.New
+func (*Factory) New(config *trigger.Config) (trigger.Trigger, error) {
+	s := &Settings{}
+	err := metadata.MapToStruct(config.Settings, s, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Trigger{settings: s}, nil
+}
+
+// Trigger is a gRPC trigger, serving the methods of the services of its handlers
+type Trigger struct {
+	settings *Settings
+	logger   log.Logger
+	files    *protoregistry.Files
+	methods  map[string]*methodHandler
+	server   *grpc.Server
+	listener net.Listener
+}
+
+// methodHandler is the handler of a method
+type methodHandler struct {
+	method  protoreflect.MethodDescriptor
+	handler trigger.Handler
+}
+
+// Initialize initializes the trigger
+func (t *Trigger) Initialize(ctx trigger.InitContext) error {
+	t.logger = ctx.Logger()
+
+	files, err := loadFiles(t.settings)
+	if err != nil {
+		return err
+	}
+	t.files = files
+
+	t.methods = make(map[string]*methodHandler)
+	for _, handler := range ctx.GetHandlers() {
+		s := &HandlerSettings{}
+		err := metadata.MapToStruct(handler.Settings(), s, true)
+		if err != nil {
+			return err
+		}
+
+		method, err := findMethod(files, s.Service, s.Method)
+		if err != nil {
+			return err
+		}
+
+		fullMethod := fmt.Sprintf("/%s/%s", s.Service, s.Method)
+		if _, exists := t.methods[fullMethod]; exists {
+			return fmt.Errorf("method [%s] has several handlers", fullMethod)
+		}
+		t.methods[fullMethod] = &methodHandler{method: method, handler: handler}
+	}
+
+	var options []grpc.ServerOption
+	if t.settings.CertFile != "" {
+		tlsConfig, err := getTLSConfig(t.settings)
+		if err != nil {
+			return err
+		}
+		options = append(options, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	// the methods are served dynamically, according to their descriptors
+	options = append(options, grpc.UnknownServiceHandler(t.handleStream))
+
+	t.server = grpc.NewServer(options...)
+
+	if t.settings.Reflection {
+		opts := reflection.ServerOptions{Services: t, DescriptorResolver: files}
+		reflectionv1.RegisterServerReflectionServer(t.server, reflection.NewServerV1(opts))
+		reflectionv1alpha.RegisterServerReflectionServer(t.server, reflection.NewServer(opts))
+	}
+
+	return nil
+}
+
+// Start starts the server
+func (t *Trigger) Start() error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", t.settings.Port))
+	if err != nil {
+		return err
+	}
+	t.listener = listener
+
+	t.logger.Infof("Listening on port %d", t.settings.Port)
+
+	go func() {
+		if err := t.server.Serve(listener); err != nil {
+			t.logger.Errorf("gRPC server stopped: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+// Stop stops the server, once the pending calls completed
+func (t *Trigger) Stop() error {
+	t.server.GracefulStop()
+	return nil
+}
+
+// GetServiceInfo returns the services with handlers, for the reflection service
+func (t *Trigger) GetServiceInfo() map[string]grpc.ServiceInfo {
+	services := make(map[string]grpc.ServiceInfo)
+	for _, h := range t.methods {
+		name := string(h.method.Parent().FullName())
+		info := services[name]
+		info.Methods = append(info.Methods, grpc.MethodInfo{
+			Name:           string(h.method.Name()),
+			IsServerStream: h.method.IsStreamingServer(),
+		})
+		services[name] = info
+	}
+	return services
+}
+
+// handleStream handles a call of a method
+func (t *Trigger) handleStream(srv interface{}, stream grpc.ServerStream) error {
+
+	fullMethod, _ := grpc.MethodFromServerStream(stream)
+	h, ok := t.methods[fullMethod]
+	if !ok {
+		return status.Errorf(codes.Unimplemented, "method %s is not implemented", fullMethod)
+	}
+
+	req := dynamicpb.NewMessage(h.method.Input())
+	if err := stream.RecvMsg(req); err != nil {
+		return err
+	}
+
+	content, err := toContent(req)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	out := &Output{Method: fullMethod, Content: content, Metadata: make(map[string]string)}
+	if incoming, ok := md.FromIncomingContext(stream.Context()); ok {
+		for name, values := range incoming {
+			out.Metadata[name] = strings.Join(values, ",")
+		}
+	}
+
+	results, err := h.handler.Handle(stream.Context(), out)
+	if err != nil {
+		t.logger.Errorf("Error handling method [%s]: %v", fullMethod, err)
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	reply := &Reply{}
+	if err := reply.FromMap(results); err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	if reply.Code != int(codes.OK) {
+		return status.Error(codes.Code(reply.Code), reply.Message)
+	}
+
+	if !h.method.IsStreamingServer() {
+		return t.send(stream, h.method, reply.Data)
+	}
+
+	// each item of the data is a message of the stream
+	items, err := coerce.ToArray(reply.Data)
+	if err != nil {
+		return status.Errorf(codes.Internal, "the reply of server streaming method %s must be an array", fullMethod)
+	}
+	for _, item := range items {
+		if err := t.send(stream, h.method, item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *Trigger) send(stream grpc.ServerStream, method protoreflect.MethodDescriptor, data interface{}) error {
+	msg, err := toMessage(method.Output(), data)
+	if err != nil {
+		t.logger.Errorf("Invalid reply of method [%s]: %v", method.FullName(), err)
+		return status.Error(codes.Internal, err.Error())
+	}
+	return stream.SendMsg(msg)
+}
+
+// getTLSConfig returns the TLS configuration of the server
+func getTLSConfig(settings *Settings) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(settings.CertFile, settings.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load server certificate: %v", err)
+	}
+
+	tlsConfig := &tls.Config{Certificates: []tls.Certificate{cert}}
+
+	if settings.ClientCAFile != "" {
+		pem, err := ioutil.ReadFile(settings.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read client CA file [%s]: %v", settings.ClientCAFile, err)
+		}
+		tlsConfig.ClientCAs = x509.NewCertPool()
+		if !tlsConfig.ClientCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in client CA file [%s]", settings.ClientCAFile)
+		}
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, nil
+}
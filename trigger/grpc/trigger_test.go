@@ -0,0 +1,203 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"flogo/core/action"
+	"flogo/core/api"
+	"flogo/core/support/test"
+	"flogo/core/trigger"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	md "google.golang.org/grpc/metadata"
+	reflectionv1 "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const testProto = `syntax = "proto3";
+package test;
+
+message HelloRequest {
+  string name = 1;
+  int32 times = 2;
+}
+
+message HelloReply {
+  string message = 1;
+}
+
+service Greeter {
+  rpc SayHello (HelloRequest) returns (HelloReply);
+  rpc SayHellos (HelloRequest) returns (stream HelloReply);
+  rpc Fail (HelloRequest) returns (HelloReply);
+}
+`
+
+const testConfig string = `{
+	"id": "trigger-grpc",
+	"ref": "github.com/qingcloudhx/contrib/trigger/grpc",
+	"settings": {
+	  "port": 50051,
+	  "protoFiles": "greeter.proto"
+	},
+	"handlers": [
+	  {
+		"settings": {
+		  "service": "test.Greeter",
+		  "method": "SayHello"
+		},
+		"action": {
+		  "id": "test"
+		}
+	  }
+	]
+}`
+
+// greeter returns a handler function replying with the result of the function
+func greeter(reply func(out *Output) map[string]interface{}) api.HandlerFunc {
+	return func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+		out := &Output{}
+		if err := out.FromMap(inputs); err != nil {
+			return nil, err
+		}
+		return reply(out), nil
+	}
+}
+
+// writeProto returns a directory containing the proto file of the Greeter service
+func writeProto(t *testing.T) string {
+	dir := t.TempDir()
+	err := ioutil.WriteFile(filepath.Join(dir, "greeter.proto"), []byte(testProto), 0644)
+	assert.Nil(t, err)
+	return dir
+}
+
+func TestTrigger(t *testing.T) {
+
+	dir := writeProto(t)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	app := api.NewApp()
+	trg := app.NewTrigger(&Trigger{}, map[string]interface{}{"port": port, "protoFiles": "greeter.proto", "importPaths": dir, "reflection": true})
+	for method, reply := range map[string]func(out *Output) map[string]interface{}{
+		"SayHello": func(out *Output) map[string]interface{} {
+			return map[string]interface{}{"data": map[string]interface{}{
+				"message": "Hello " + out.Content["name"].(string) + " from " + out.Metadata["client"]}}
+		},
+		"SayHellos": func(out *Output) map[string]interface{} {
+			return map[string]interface{}{"data": []interface{}{
+				map[string]interface{}{"message": "Hello"}, map[string]interface{}{"message": "Hello again"}}}
+		},
+		"Fail": func(out *Output) map[string]interface{} {
+			return map[string]interface{}{"code": int(codes.NotFound), "message": "no such greeter"}
+		},
+	} {
+		handler, err := trg.NewHandler(map[string]interface{}{"service": "test.Greeter", "method": method})
+		assert.Nil(t, err)
+		_, err = handler.NewAction(greeter(reply))
+		assert.Nil(t, err)
+	}
+
+	e, err := api.NewEngine(app)
+	assert.Nil(t, err)
+	assert.Nil(t, e.Start())
+	defer e.Stop()
+
+	conn, err := grpc.Dial(fmt.Sprintf("127.0.0.1:%d", port), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	files, err := loadFiles(&Settings{ProtoFiles: "greeter.proto", ImportPaths: dir})
+	assert.Nil(t, err)
+	service, err := files.FindDescriptorByName("test.Greeter")
+	assert.Nil(t, err)
+	methods := service.(protoreflect.ServiceDescriptor).Methods()
+	request := dynamicpb.NewMessage(methods.ByName("SayHello").Input())
+	request.Set(request.Descriptor().Fields().ByName("name"), protoreflect.ValueOfString("flogo"))
+	replyDesc := methods.ByName("SayHello").Output()
+
+	ctx := md.AppendToOutgoingContext(context.Background(), "client", "test")
+
+	// unary
+	reply := dynamicpb.NewMessage(replyDesc)
+	err = conn.Invoke(ctx, "/test.Greeter/SayHello", request, reply)
+	assert.Nil(t, err)
+	assert.Equal(t, "Hello flogo from test", reply.Get(replyDesc.Fields().ByName("message")).String())
+
+	// server streaming
+	stream, err := conn.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true}, "/test.Greeter/SayHellos")
+	assert.Nil(t, err)
+	assert.Nil(t, stream.SendMsg(request))
+	assert.Nil(t, stream.CloseSend())
+	var messages []string
+	for {
+		reply := dynamicpb.NewMessage(replyDesc)
+		err := stream.RecvMsg(reply)
+		if err == io.EOF {
+			break
+		}
+		assert.Nil(t, err)
+		messages = append(messages, reply.Get(replyDesc.Fields().ByName("message")).String())
+	}
+	assert.Equal(t, []string{"Hello", "Hello again"}, messages)
+
+	// error code
+	err = conn.Invoke(ctx, "/test.Greeter/Fail", request, dynamicpb.NewMessage(replyDesc))
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	assert.True(t, strings.Contains(err.Error(), "no such greeter"))
+
+	// no handler
+	err = conn.Invoke(ctx, "/test.Greeter/Unknown", request, dynamicpb.NewMessage(replyDesc))
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+
+	// the services with handlers are listed by the reflection service
+	client := reflectionv1.NewServerReflectionClient(conn)
+	info, err := client.ServerReflectionInfo(ctx)
+	assert.Nil(t, err)
+	err = info.Send(&reflectionv1.ServerReflectionRequest{MessageRequest: &reflectionv1.ServerReflectionRequest_ListServices{}})
+	assert.Nil(t, err)
+	resp, err := info.Recv()
+	assert.Nil(t, err)
+	var names []string
+	for _, s := range resp.GetListServicesResponse().GetService() {
+		names = append(names, s.Name)
+	}
+	assert.Contains(t, names, "test.Greeter")
+	assert.Nil(t, info.CloseSend())
+}
+
+func TestInitialize_InvalidMethod(t *testing.T) {
+
+	config := &trigger.Config{}
+	err := json.Unmarshal([]byte(testConfig), config)
+	assert.Nil(t, err)
+	config.Settings["importPaths"] = writeProto(t)
+	config.Handlers[0].Settings["method"] = "SayGoodbye"
+	_, err = test.InitTrigger(&Factory{}, config, map[string]action.Action{"test": api.NewProxyAction(greeter(nil))})
+	assert.NotNil(t, err)
+
+	config = &trigger.Config{}
+	err = json.Unmarshal([]byte(testConfig), config)
+	assert.Nil(t, err)
+	// neither descriptor set nor proto files
+	delete(config.Settings, "protoFiles")
+	config.Handlers = nil
+	_, err = test.InitTrigger(&Factory{}, config, nil)
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,110 @@
+package imap
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/emersion/go-imap/client"
+)
+
+const dialTimeout = 30 * time.Second
+
+// dial connects to the server of the settings and logs in
+func dial(settings *Settings) (*client.Client, error) {
+
+	serverURL, err := url.Parse(settings.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url '%s': %v", settings.URL, err)
+	}
+
+	tlsConfig, err := getTLSConfig(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{Timeout: dialTimeout}
+
+	var c *client.Client
+	switch serverURL.Scheme {
+	case "imaps":
+		c, err = client.DialWithDialerTLS(dialer, hostPort(serverURL, "993"), tlsConfig)
+	case "imap":
+		c, err = client.DialWithDialer(dialer, hostPort(serverURL, "143"))
+		if err == nil {
+			err = startTLS(c, tlsConfig)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported url scheme '%s', imap or imaps expected", serverURL.Scheme)
+	}
+	if err != nil {
+		if c != nil {
+			_ = c.Logout()
+		}
+		return nil, fmt.Errorf("unable to connect to '%s': %v", serverURL.Host, err)
+	}
+
+	if err := c.Login(settings.Username, settings.Password); err != nil {
+		_ = c.Logout()
+		return nil, fmt.Errorf("unable to log in to '%s' as '%s': %v", serverURL.Host, settings.Username, err)
+	}
+
+	return c, nil
+}
+
+// startTLS switches the connection to TLS when the server supports it
+func startTLS(c *client.Client, tlsConfig *tls.Config) error {
+
+	ok, err := c.SupportStartTLS()
+	if err != nil || !ok {
+		return err
+	}
+
+	return c.StartTLS(tlsConfig)
+}
+
+func hostPort(serverURL *url.URL, defaultPort string) string {
+
+	if serverURL.Port() != "" {
+		return serverURL.Host
+	}
+
+	return net.JoinHostPort(serverURL.Hostname(), defaultPort)
+}
+
+// getTLSConfig returns the TLS configuration of the connection
+func getTLSConfig(settings *Settings) (*tls.Config, error) {
+
+	tlsConfig := &tls.Config{
+		ServerName:         settings.ServerName,
+		InsecureSkipVerify: settings.InsecureSkipVerify,
+	}
+
+	if settings.CAFile != "" {
+		pem, err := ioutil.ReadFile(settings.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read CA file [%s]: %v", settings.CAFile, err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file [%s]", settings.CAFile)
+		}
+	}
+
+	if settings.CertFile != "" || settings.KeyFile != "" {
+		if settings.CertFile == "" || settings.KeyFile == "" {
+			return nil, fmt.Errorf("both cert file and key file must be specified for client certificate authentication")
+		}
+		cert, err := tls.LoadX509KeyPair(settings.CertFile, settings.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
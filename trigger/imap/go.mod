@@ -0,0 +1,8 @@
+module github.com/qingcloudhx/contrib/trigger/imap
+
+require (
+	flogo/core v0.9.0
+	github.com/emersion/go-imap v1.2.1
+	github.com/emersion/go-message v0.18.2
+	github.com/stretchr/testify v1.3.0
+)
@@ -0,0 +1,115 @@
+package imap
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/emersion/go-message"
+	_ "github.com/emersion/go-message/charset"
+	"github.com/emersion/go-message/mail"
+)
+
+// parseMessage parses the headers, the bodies and the attachments of a message
+func parseMessage(r io.Reader) (*Output, error) {
+
+	mr, err := mail.CreateReader(r)
+	if err != nil && !message.IsUnknownCharset(err) {
+		return nil, fmt.Errorf("unable to parse message: %v", err)
+	}
+	defer mr.Close()
+
+	out := &Output{Headers: make(map[string]string)}
+
+	h := mr.Header
+	out.MessageID, _ = h.MessageID()
+	out.Subject, _ = h.Subject()
+	if from, _ := h.AddressList("From"); len(from) > 0 {
+		out.From = from[0].Address
+	}
+	out.To = addresses(h, "To")
+	out.Cc = addresses(h, "Cc")
+	if date, err := h.Date(); err == nil && !date.IsZero() {
+		out.Date = date.UnixNano() / int64(time.Millisecond)
+	}
+
+	fields := h.Fields()
+	for fields.Next() {
+		key := textproto.CanonicalMIMEHeaderKey(fields.Key())
+		if _, ok := out.Headers[key]; !ok {
+			out.Headers[key], _ = fields.Text()
+		}
+	}
+
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil && !message.IsUnknownCharset(err) {
+			return nil, fmt.Errorf("unable to parse message part: %v", err)
+		}
+
+		switch ph := p.Header.(type) {
+		case *mail.InlineHeader:
+			contentType, params, _ := ph.ContentType()
+			switch {
+			case contentType == "text/html" && out.HTML == "":
+				body, err := ioutil.ReadAll(p.Body)
+				if err != nil {
+					return nil, fmt.Errorf("unable to read message body: %v", err)
+				}
+				out.HTML = string(body)
+			case (contentType == "text/plain" || contentType == "") && out.Text == "":
+				body, err := ioutil.ReadAll(p.Body)
+				if err != nil {
+					return nil, fmt.Errorf("unable to read message body: %v", err)
+				}
+				out.Text = string(body)
+			case !strings.HasPrefix(contentType, "text/"):
+				// inline parts other than the bodies, such as images, are attachments for the flows
+				attachment, err := toAttachment(params["name"], contentType, p.Body)
+				if err != nil {
+					return nil, err
+				}
+				out.Attachments = append(out.Attachments, attachment)
+			}
+		case *mail.AttachmentHeader:
+			contentType, _, _ := ph.ContentType()
+			filename, _ := ph.Filename()
+			attachment, err := toAttachment(filename, contentType, p.Body)
+			if err != nil {
+				return nil, err
+			}
+			out.Attachments = append(out.Attachments, attachment)
+		}
+	}
+
+	return out, nil
+}
+
+// toAttachment returns the metadata of an attachment, its content is skipped
+func toAttachment(filename, contentType string, body io.Reader) (map[string]interface{}, error) {
+
+	size, err := io.Copy(ioutil.Discard, body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read attachment '%s': %v", filename, err)
+	}
+
+	return map[string]interface{}{"filename": filename, "contentType": contentType, "size": size}, nil
+}
+
+func addresses(h mail.Header, key string) []string {
+
+	list, _ := h.AddressList(key)
+
+	var addresses []string
+	for _, address := range list {
+		addresses = append(addresses, address.Address)
+	}
+
+	return addresses
+}
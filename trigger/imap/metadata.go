@@ -0,0 +1,130 @@
+package imap
+
+import (
+	"flogo/core/data/coerce"
+)
+
+type Settings struct {
+	URL      string `md:"url,required"`      // The url of the server (ex. imaps://imap.example.com), imaps urls connect with TLS, imap urls switch to TLS with STARTTLS when the server supports it
+	Username string `md:"username,required"` // The user name used to log in
+	Password string `md:"password"`          // The password used to log in
+
+	CAFile             string `md:"caFile"`             // The PEM file of the CA certificates used to verify the server
+	CertFile           string `md:"certFile"`           // The PEM file of the client certificate, for servers requiring client authentication
+	KeyFile            string `md:"keyFile"`            // The PEM file of the client private key
+	ServerName         string `md:"serverName"`         // The server name used to verify the server certificate, overrides the server host
+	InsecureSkipVerify bool   `md:"insecureSkipVerify"` // Don't verify the server certificate, for testing only
+}
+
+type HandlerSettings struct {
+	Mailbox      string `md:"mailbox"`      // The mailbox to watch, defaults to INBOX
+	PollInterval string `md:"pollInterval"` // Poll the mailbox at this interval (ex. 5m) instead of waiting for new messages with IDLE
+	MarkAsRead   bool   `md:"markAsRead"`   // Mark a message as read once its action succeeded
+	MoveTo       string `md:"moveTo"`       // The mailbox a message is moved to once its action succeeded
+}
+
+type Output struct {
+	Mailbox     string            `md:"mailbox"`     // The mailbox of the message
+	UID         int               `md:"uid"`         // The unique identifier of the message in the mailbox
+	MessageID   string            `md:"messageId"`   // The Message-ID header of the message
+	Subject     string            `md:"subject"`     // The subject of the message
+	From        string            `md:"from"`        // The address of the sender
+	To          []string          `md:"to"`          // The addresses of the recipients
+	Cc          []string          `md:"cc"`          // The addresses of the carbon copy recipients
+	Date        int64             `md:"date"`        // The date of the message, in milliseconds since epoch
+	Headers     map[string]string `md:"headers"`     // The headers of the message, the first value of the headers present several times
+	Text        string            `md:"text"`        // The plain text body of the message
+	HTML        string            `md:"html"`        // The HTML body of the message
+	Attachments []interface{}     `md:"attachments"` // The attachments of the message, each attachment is an object with a filename, a contentType and a size
+}
+
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"mailbox":     o.Mailbox,
+		"uid":         o.UID,
+		"messageId":   o.MessageID,
+		"subject":     o.Subject,
+		"from":        o.From,
+		"to":          o.To,
+		"cc":          o.Cc,
+		"date":        o.Date,
+		"headers":     o.Headers,
+		"text":        o.Text,
+		"html":        o.HTML,
+		"attachments": o.Attachments,
+	}
+}
+
+func (o *Output) FromMap(values map[string]interface{}) error {
+
+	var err error
+	o.Mailbox, err = coerce.ToString(values["mailbox"])
+	if err != nil {
+		return err
+	}
+	o.UID, err = coerce.ToInt(values["uid"])
+	if err != nil {
+		return err
+	}
+	o.MessageID, err = coerce.ToString(values["messageId"])
+	if err != nil {
+		return err
+	}
+	o.Subject, err = coerce.ToString(values["subject"])
+	if err != nil {
+		return err
+	}
+	o.From, err = coerce.ToString(values["from"])
+	if err != nil {
+		return err
+	}
+	o.To, err = toStrings(values["to"])
+	if err != nil {
+		return err
+	}
+	o.Cc, err = toStrings(values["cc"])
+	if err != nil {
+		return err
+	}
+	o.Date, err = coerce.ToInt64(values["date"])
+	if err != nil {
+		return err
+	}
+	o.Headers, err = coerce.ToParams(values["headers"])
+	if err != nil {
+		return err
+	}
+	o.Text, err = coerce.ToString(values["text"])
+	if err != nil {
+		return err
+	}
+	o.HTML, err = coerce.ToString(values["html"])
+	if err != nil {
+		return err
+	}
+	o.Attachments, err = coerce.ToArray(values["attachments"])
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func toStrings(val interface{}) ([]string, error) {
+
+	values, err := coerce.ToArray(val)
+	if err != nil {
+		return nil, err
+	}
+
+	var strs []string
+	for _, value := range values {
+		str, err := coerce.ToString(value)
+		if err != nil {
+			return nil, err
+		}
+		strs = append(strs, str)
+	}
+
+	return strs, nil
+}
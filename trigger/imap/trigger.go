@@ -0,0 +1,103 @@
+package imap
+
+import (
+	"context"
+	"time"
+
+	"flogo/core/data/metadata"
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+)
+
+var triggerMd = trigger.NewMetadata(&Settings{}, &HandlerSettings{}, &Output{})
+
+func init() {
+	_ = trigger.Register(&Trigger{}, &Factory{})
+}
+
+type Factory struct {
+}
+
+// Metadata implements trigger.Factory.Metadata
+func (*Factory) Metadata() *trigger.Metadata {
+	return triggerMd
+}
+
+// New implements trigger.Factory.New
+func (*Factory) New(config *trigger.Config) (trigger.Trigger, error) {
+
+	s := &Settings{}
+	err := metadata.MapToStruct(config.Settings, s, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Trigger{settings: s}, nil
+}
+
+// Trigger watches IMAP mailboxes
+type Trigger struct {
+	settings *Settings
+	logger   log.Logger
+	watchers []*watcher
+
+	cancel context.CancelFunc
+}
+
+// Initialize implements trigger.Init.Initialize
+func (t *Trigger) Initialize(ctx trigger.InitContext) error {
+
+	t.logger = ctx.Logger()
+
+	// the TLS settings are checked before the first connection
+	if _, err := getTLSConfig(t.settings); err != nil {
+		return err
+	}
+
+	for _, handler := range ctx.GetHandlers() {
+
+		s := &HandlerSettings{}
+		err := metadata.MapToStruct(handler.Settings(), s, true)
+		if err != nil {
+			return err
+		}
+
+		w, err := newWatcher(handler, t.settings, s, t.logger)
+		if err != nil {
+			return err
+		}
+		t.watchers = append(t.watchers, w)
+	}
+
+	return nil
+}
+
+// Start implements util.Managed.Start
+func (t *Trigger) Start() error {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+
+	// each watcher has its own connection, the mailbox being selected per connection
+	for _, w := range t.watchers {
+		w.done = make(chan struct{})
+		go w.run(ctx)
+	}
+
+	return nil
+}
+
+// Stop implements util.Managed.Stop
+func (t *Trigger) Stop() error {
+
+	if t.cancel != nil {
+		t.cancel()
+		t.cancel = nil
+	}
+
+	for _, w := range t.watchers {
+		w.wait(30 * time.Second)
+	}
+
+	return nil
+}
@@ -0,0 +1,262 @@
+package imap
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"flogo/core/action"
+	"flogo/core/api"
+	"flogo/core/support/test"
+	"flogo/core/trigger"
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/backend"
+	"github.com/emersion/go-imap/backend/memory"
+	"github.com/emersion/go-imap/client"
+	"github.com/emersion/go-imap/server"
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig string = `{
+	"id": "trigger-imap",
+	"ref": "github.com/qingcloudhx/contrib/trigger/imap",
+	"settings": {
+	  "url": "imap://127.0.0.1:143",
+	  "username": "username",
+	  "password": "password"
+	},
+	"handlers": [
+	  {
+		"settings": {
+		  "mailbox": "INBOX"
+		},
+		"action": {
+		  "id": "test"
+		}
+	  }
+	]
+}`
+
+// initTrigger returns a trigger initialized with the settings and a handler of the settings running the function
+func initTrigger(settings, handlerSettings map[string]interface{}, f api.HandlerFunc) (*Trigger, error) {
+
+	config := &trigger.Config{}
+	if err := json.Unmarshal([]byte(testConfig), config); err != nil {
+		return nil, err
+	}
+	for name, value := range settings {
+		config.Settings[name] = value
+	}
+	config.Handlers[0].Settings = handlerSettings
+
+	trg, err := test.InitTrigger(&Factory{}, config, map[string]action.Action{"test": api.NewProxyAction(f)})
+	if err != nil {
+		return nil, err
+	}
+	return trg.(*Trigger), nil
+}
+
+// send returns a handler function sending its outputs, it fails for the subjects of the failures
+func send(outputs chan *Output, failures map[string]bool) api.HandlerFunc {
+	return func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+		out := &Output{}
+		if err := out.FromMap(inputs); err != nil {
+			return nil, err
+		}
+
+		outputs <- out
+		if failures[out.Subject] {
+			return nil, errors.New("failed")
+		}
+		return nil, nil
+	}
+}
+
+func next(t *testing.T, outputs chan *Output) *Output {
+	select {
+	case out := <-outputs:
+		return out
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message handled")
+		return nil
+	}
+}
+
+// moveBackend adds MOVE to the memory backend
+type moveBackend struct {
+	*memory.Backend
+}
+
+func (b *moveBackend) Login(info *imap.ConnInfo, username, password string) (backend.User, error) {
+	user, err := b.Backend.Login(info, username, password)
+	if err != nil {
+		return nil, err
+	}
+	return &moveUser{user}, nil
+}
+
+type moveUser struct {
+	backend.User
+}
+
+func (u *moveUser) GetMailbox(name string) (backend.Mailbox, error) {
+	mbox, err := u.User.GetMailbox(name)
+	if err != nil {
+		return nil, err
+	}
+	return &moveMailbox{mbox}, nil
+}
+
+type moveMailbox struct {
+	backend.Mailbox
+}
+
+func (m *moveMailbox) MoveMessages(uid bool, seqSet *imap.SeqSet, dest string) error {
+	if err := m.CopyMessages(uid, seqSet, dest); err != nil {
+		return err
+	}
+	if err := m.UpdateMessagesFlags(uid, seqSet, imap.AddFlags, []string{imap.DeletedFlag}); err != nil {
+		return err
+	}
+	return m.Expunge()
+}
+
+func newMessage(subject, body string) *bytes.Buffer {
+	return bytes.NewBufferString("From: Alice <alice@example.com>\r\n" +
+		"To: orders@example.com\r\n" +
+		"Subject: " + subject + "\r\n" +
+		"Date: Fri, 01 Mar 2019 10:00:00 +0000\r\n" +
+		"Message-ID: <" + subject + "@example.com>\r\n" +
+		"Content-Type: text/plain\r\n" +
+		"\r\n" + body)
+}
+
+func subjects(t *testing.T, c *client.Client, mailbox string) map[string][]string {
+
+	status, err := c.Select(mailbox, true)
+	assert.Nil(t, err)
+
+	seqSet := new(imap.SeqSet)
+	seqSet.AddRange(1, status.Messages)
+	messages := make(chan *imap.Message, 10)
+	assert.Nil(t, c.Fetch(seqSet, []imap.FetchItem{imap.FetchEnvelope, imap.FetchFlags}, messages))
+
+	subjects := make(map[string][]string)
+	for msg := range messages {
+		subjects[msg.Envelope.Subject] = msg.Flags
+	}
+	return subjects
+}
+
+func TestWatcher(t *testing.T) {
+
+	s := server.New(&moveBackend{memory.New()})
+	s.AllowInsecureAuth = true
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	go func() {
+		_ = s.Serve(l)
+	}()
+	defer s.Close()
+
+	c, err := client.Dial(l.Addr().String())
+	assert.Nil(t, err)
+	defer c.Logout()
+	assert.Nil(t, c.Login("username", "password"))
+	assert.Nil(t, c.Create("Processed"))
+	assert.Nil(t, c.Append("INBOX", nil, time.Now(), newMessage("first", "ok")))
+	assert.Nil(t, c.Append("INBOX", nil, time.Now(), newMessage("second", "failing")))
+
+	outputs := make(chan *Output, 10)
+	trg, err := initTrigger(map[string]interface{}{"url": "imap://" + l.Addr().String()},
+		map[string]interface{}{"markAsRead": true, "moveTo": "Processed"}, send(outputs, map[string]bool{"second": true}))
+	assert.Nil(t, err)
+	assert.Nil(t, trg.Start())
+
+	// the message of the memory backend is already read
+	out := next(t, outputs)
+	assert.Equal(t, "INBOX", out.Mailbox)
+	assert.Equal(t, "first", out.Subject)
+	assert.Equal(t, "alice@example.com", out.From)
+	assert.Equal(t, []string{"orders@example.com"}, out.To)
+	assert.Equal(t, "ok", out.Text)
+	assert.Equal(t, "second", next(t, outputs).Subject)
+
+	// the watcher is idle once the messages are handled
+	time.Sleep(100 * time.Millisecond)
+	assert.Nil(t, trg.Stop())
+
+	// the failed message is left unchanged
+	inbox := subjects(t, c, "INBOX")
+	assert.Len(t, inbox, 2)
+	assert.NotContains(t, inbox, "first")
+	assert.NotContains(t, inbox["second"], imap.SeenFlag)
+	assert.Contains(t, subjects(t, c, "Processed")["first"], imap.SeenFlag)
+}
+
+func TestNewWatcher(t *testing.T) {
+
+	s := map[string]interface{}{"pollInterval": "1m"}
+	trg, err := initTrigger(nil, s, send(nil, nil))
+	assert.Nil(t, err)
+	w := trg.watchers[0]
+	assert.Equal(t, "INBOX", w.settings.Mailbox)
+	assert.Equal(t, time.Minute, w.pollInterval)
+
+	_, err = initTrigger(nil, map[string]interface{}{"pollInterval": "soon"}, send(nil, nil))
+	assert.NotNil(t, err)
+
+	_, err = initTrigger(nil, map[string]interface{}{"moveTo": "INBOX"}, send(nil, nil))
+	assert.NotNil(t, err)
+}
+
+func TestParseMessage(t *testing.T) {
+
+	msg := "From: =?ISO-8859-1?Q?Andr=E9?= <andre@example.com>\r\n" +
+		"To: orders@example.com, sales@example.com\r\n" +
+		"Cc: support@example.com\r\n" +
+		"Subject: =?ISO-8859-1?Q?Caf=E9?= order\r\n" +
+		"Date: Fri, 01 Mar 2019 10:00:00 +0000\r\n" +
+		"Message-ID: <order-1@example.com>\r\n" +
+		"X-Order: 1\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: multipart/mixed; boundary=mixed\r\n" +
+		"\r\n" +
+		"--mixed\r\n" +
+		"Content-Type: multipart/alternative; boundary=alt\r\n" +
+		"\r\n" +
+		"--alt\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"\r\n" +
+		"One coffee\r\n" +
+		"--alt\r\n" +
+		"Content-Type: text/html; charset=utf-8\r\n" +
+		"\r\n" +
+		"<p>One coffee</p>\r\n" +
+		"--alt--\r\n" +
+		"--mixed\r\n" +
+		"Content-Type: application/pdf\r\n" +
+		"Content-Disposition: attachment; filename=order.pdf\r\n" +
+		"Content-Transfer-Encoding: base64\r\n" +
+		"\r\n" +
+		"JVBERi0xLjQ=\r\n" +
+		"--mixed--\r\n"
+
+	out, err := parseMessage(strings.NewReader(msg))
+	assert.Nil(t, err)
+	assert.Equal(t, "order-1@example.com", out.MessageID)
+	assert.Equal(t, "Café order", out.Subject)
+	assert.Equal(t, "andre@example.com", out.From)
+	assert.Equal(t, []string{"orders@example.com", "sales@example.com"}, out.To)
+	assert.Equal(t, []string{"support@example.com"}, out.Cc)
+	assert.Equal(t, int64(1551434400000), out.Date)
+	assert.Equal(t, "1", out.Headers["X-Order"])
+	assert.Equal(t, "One coffee", out.Text)
+	assert.Equal(t, "<p>One coffee</p>", out.HTML)
+	assert.Equal(t, []interface{}{map[string]interface{}{"filename": "order.pdf", "contentType": "application/pdf", "size": int64(8)}}, out.Attachments)
+}
@@ -0,0 +1,264 @@
+package imap
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+	"github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/client"
+)
+
+const (
+	defaultMailbox = "INBOX"
+	retryInterval  = 5 * time.Second
+)
+
+// watcher handles the unread messages of the mailbox of a handler, each message is handled once
+// while the trigger runs, it is marked as read or moved once its action succeeded, otherwise it is
+// left unchanged
+type watcher struct {
+	handler  trigger.Handler
+	settings *HandlerSettings
+	account  *Settings
+	logger   log.Logger
+
+	pollInterval time.Duration
+	uidValidity  uint32
+	lastUID      uint32
+	done         chan struct{}
+}
+
+func newWatcher(handler trigger.Handler, account *Settings, s *HandlerSettings, logger log.Logger) (*watcher, error) {
+
+	if s.Mailbox == "" {
+		s.Mailbox = defaultMailbox
+	}
+	if s.MoveTo == s.Mailbox {
+		return nil, fmt.Errorf("handler '%s' can't move the messages to the mailbox it watches", handler.Name())
+	}
+
+	w := &watcher{handler: handler, settings: s, account: account, logger: logger}
+
+	if s.PollInterval != "" {
+		interval, err := time.ParseDuration(s.PollInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid poll interval '%s': %v", s.PollInterval, err)
+		}
+		if interval <= 0 {
+			return nil, fmt.Errorf("poll interval must be positive")
+		}
+		w.pollInterval = interval
+	}
+
+	return w, nil
+}
+
+// run watches the mailbox until the context is done, reconnecting when the connection fails
+func (w *watcher) run(ctx context.Context) {
+
+	defer close(w.done)
+
+	for ctx.Err() == nil {
+		err := w.watch(ctx)
+		if err != nil && ctx.Err() == nil {
+			w.logger.Errorf("Error watching mailbox '%s': %v", w.settings.Mailbox, err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(retryInterval):
+			}
+		}
+	}
+}
+
+// wait waits for the watcher to stop, at most for the timeout
+func (w *watcher) wait(timeout time.Duration) {
+
+	if w.done == nil {
+		return
+	}
+
+	select {
+	case <-w.done:
+	case <-time.After(timeout):
+		w.logger.Warnf("Message of mailbox '%s' still being handled", w.settings.Mailbox)
+	}
+}
+
+// watch connects to the server and handles the new messages of the mailbox until the context is
+// done or the connection fails
+func (w *watcher) watch(ctx context.Context) error {
+
+	c, err := dial(w.account)
+	if err != nil {
+		return err
+	}
+
+	// the updates must be consumed, the client blocks otherwise
+	updates := make(chan client.Update, 16)
+	notify := make(chan struct{}, 1)
+	c.Updates = updates
+	go func() {
+		for update := range updates {
+			if _, ok := update.(*client.MailboxUpdate); ok {
+				select {
+				case notify <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+	defer func() {
+		_ = c.Logout()
+		<-c.LoggedOut()
+		close(updates)
+	}()
+
+	status, err := c.Select(w.settings.Mailbox, false)
+	if err != nil {
+		return fmt.Errorf("unable to select mailbox '%s': %v", w.settings.Mailbox, err)
+	}
+
+	// the uids of the messages only identify them while the uid validity doesn't change
+	if status.UidValidity != w.uidValidity {
+		w.uidValidity = status.UidValidity
+		w.lastUID = 0
+	}
+
+	w.logger.Infof("Watching mailbox '%s'", w.settings.Mailbox)
+
+	for {
+		if err := w.fetch(c); err != nil {
+			return err
+		}
+
+		if w.pollInterval > 0 {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(w.pollInterval):
+			}
+			continue
+		}
+
+		if err := idle(ctx, c, notify); err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// idle waits for an update of the mailbox or for the context to be done, the client polls the
+// mailbox when the server doesn't support IDLE
+func idle(ctx context.Context, c *client.Client, notify chan struct{}) error {
+
+	stop := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- c.Idle(stop, nil)
+	}()
+
+	select {
+	case <-ctx.Done():
+	case <-notify:
+	case err := <-done:
+		if err == nil {
+			err = fmt.Errorf("idle stopped")
+		}
+		return err
+	}
+
+	close(stop)
+	return <-done
+}
+
+// fetch handles the unread messages received since the last one handled
+func (w *watcher) fetch(c *client.Client) error {
+
+	criteria := imap.NewSearchCriteria()
+	criteria.WithoutFlags = []string{imap.SeenFlag}
+	criteria.Uid = new(imap.SeqSet)
+	criteria.Uid.AddRange(w.lastUID+1, 0)
+
+	uids, err := c.UidSearch(criteria)
+	if err != nil {
+		return fmt.Errorf("unable to search mailbox '%s': %v", w.settings.Mailbox, err)
+	}
+	sort.Slice(uids, func(i, j int) bool { return uids[i] < uids[j] })
+
+	for _, uid := range uids {
+		// the range n:* matches the last message even when its uid is lower than n
+		if uid <= w.lastUID {
+			continue
+		}
+		if err := w.handle(c, uid); err != nil {
+			return err
+		}
+		w.lastUID = uid
+	}
+
+	return nil
+}
+
+// handle invokes the action for a message, and marks the message as read or moves it once the
+// action succeeded
+func (w *watcher) handle(c *client.Client, uid uint32) error {
+
+	s := w.settings
+
+	seqSet := new(imap.SeqSet)
+	seqSet.AddNum(uid)
+
+	// the message is fetched with peek, so that it isn't marked as read before its action succeeded
+	section := &imap.BodySectionName{Peek: true}
+	messages := make(chan *imap.Message, 1)
+	if err := c.UidFetch(seqSet, []imap.FetchItem{imap.FetchUid, section.FetchItem()}, messages); err != nil {
+		return fmt.Errorf("unable to fetch message %d of mailbox '%s': %v", uid, s.Mailbox, err)
+	}
+
+	msg := <-messages
+	if msg == nil {
+		// the message was deleted in the meantime
+		return nil
+	}
+
+	body := msg.GetBody(section)
+	if body == nil {
+		w.logger.Errorf("Message %d of mailbox '%s' has no body", uid, s.Mailbox)
+		return nil
+	}
+
+	out, err := parseMessage(body)
+	if err != nil {
+		w.logger.Errorf("Error parsing message %d of mailbox '%s': %v", uid, s.Mailbox, err)
+		return nil
+	}
+	out.Mailbox = s.Mailbox
+	out.UID = int(uid)
+
+	_, err = w.handler.Handle(context.Background(), out)
+	if err != nil {
+		w.logger.Errorf("Error handling message %d of mailbox '%s': %v", uid, s.Mailbox, err)
+		return nil
+	}
+
+	if s.MarkAsRead {
+		err = c.UidStore(seqSet, imap.FormatFlagsOp(imap.AddFlags, true), []interface{}{imap.SeenFlag}, nil)
+		if err != nil {
+			w.logger.Errorf("Error marking message %d of mailbox '%s' as read: %v", uid, s.Mailbox, err)
+		}
+	}
+	if s.MoveTo != "" {
+		err = c.UidMove(seqSet, s.MoveTo)
+		if err != nil {
+			w.logger.Errorf("Error moving message %d of mailbox '%s' to '%s': %v", uid, s.Mailbox, s.MoveTo, err)
+		}
+	}
+
+	return nil
+}
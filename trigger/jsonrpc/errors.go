@@ -0,0 +1,65 @@
+package jsonrpc
+
+import (
+	"strconv"
+)
+
+// the codes of the errors defined by JSON-RPC 2.0
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+	CodeServerError    = -32000
+)
+
+// Error is an error an action can return to choose the code, message and data of the error of the call
+type Error struct {
+	Code    int
+	Message string
+	Data    interface{}
+}
+
+// NewError creates an error of the call with the specified code
+func NewError(code int, message string, data interface{}) *Error {
+	return &Error{Code: code, Message: message, Data: data}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// errorObject is the error of a response
+type errorObject struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// toErrorObject returns the error object of an error returned by the action. The code is the one of an Error
+// or the code of an activity error when it is an integer, otherwise the error is an internal error
+func toErrorObject(err error) *errorObject {
+
+	if rpcErr, ok := err.(*Error); ok {
+		code := rpcErr.Code
+		if code == 0 {
+			code = CodeInternalError
+		}
+		return &errorObject{Code: code, Message: rpcErr.Message, Data: rpcErr.Data}
+	}
+
+	e := &errorObject{Code: CodeInternalError, Message: err.Error()}
+
+	// activity errors have a code and data
+	if coded, ok := err.(interface{ Code() string }); ok {
+		if code, convErr := strconv.Atoi(coded.Code()); convErr == nil && code != 0 {
+			e.Code = code
+		}
+	}
+	if withData, ok := err.(interface{ Data() interface{} }); ok {
+		e.Data = withData.Data()
+	}
+
+	return e
+}
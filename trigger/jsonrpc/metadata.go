@@ -0,0 +1,107 @@
+package jsonrpc
+
+import (
+	"flogo/core/data/coerce"
+)
+
+const (
+	TransportHTTP = "http"
+	TransportTCP  = "tcp"
+)
+
+type Settings struct {
+	Port         int    `md:"port"`         // The port of the HTTP endpoint, the requests are received with HTTP if specified
+	Path         string `md:"path"`         // The path of the HTTP endpoint, defaults to /rpc
+	CertFile     string `md:"certFile"`     // The PEM file of the server certificate, enables HTTPS
+	KeyFile      string `md:"keyFile"`      // The PEM file of the server private key
+	TCPPort      int    `md:"tcpPort"`      // The port of the raw TCP endpoint, the requests are received as a stream of JSON values if specified
+	MaxBatchSize int    `md:"maxBatchSize"` // The max number of requests of a batch, defaults to 100
+}
+
+type HandlerSettings struct {
+	Method string `md:"method,required"` // The name of the method handled
+}
+
+type Output struct {
+	Method        string            `md:"method"`        // The name of the method called
+	Params        interface{}       `md:"params"`        // The parameters of the call, an array or an object
+	ID            interface{}       `md:"id"`            // The id of the request, a string or a number, null for a notification
+	Notification  bool              `md:"notification"`  // Whether the request is a notification, whose reply is not sent
+	Transport     string            `md:"transport"`     // The transport of the request: http or tcp
+	RemoteAddress string            `md:"remoteAddress"` // The address of the client (ex. 10.0.0.5:49152)
+	Headers       map[string]string `md:"headers"`       // The HTTP headers of the request
+}
+
+type Reply struct {
+	Result       interface{} `md:"result"`       // The result of the call
+	ErrorCode    int         `md:"errorCode"`    // The code of the error of the call, the reply is an error if a code or a message is specified
+	ErrorMessage string      `md:"errorMessage"` // The message of the error of the call
+	ErrorData    interface{} `md:"errorData"`    // The data of the error of the call
+}
+
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"method":        o.Method,
+		"params":        o.Params,
+		"id":            o.ID,
+		"notification":  o.Notification,
+		"transport":     o.Transport,
+		"remoteAddress": o.RemoteAddress,
+		"headers":       o.Headers,
+	}
+}
+
+func (o *Output) FromMap(values map[string]interface{}) error {
+
+	var err error
+	o.Method, err = coerce.ToString(values["method"])
+	if err != nil {
+		return err
+	}
+	o.Params = values["params"]
+	o.ID = values["id"]
+	o.Notification, err = coerce.ToBool(values["notification"])
+	if err != nil {
+		return err
+	}
+	o.Transport, err = coerce.ToString(values["transport"])
+	if err != nil {
+		return err
+	}
+	o.RemoteAddress, err = coerce.ToString(values["remoteAddress"])
+	if err != nil {
+		return err
+	}
+	o.Headers, err = coerce.ToParams(values["headers"])
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *Reply) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"result":       r.Result,
+		"errorCode":    r.ErrorCode,
+		"errorMessage": r.ErrorMessage,
+		"errorData":    r.ErrorData,
+	}
+}
+
+func (r *Reply) FromMap(values map[string]interface{}) error {
+
+	var err error
+	r.Result = values["result"]
+	r.ErrorCode, err = coerce.ToInt(values["errorCode"])
+	if err != nil {
+		return err
+	}
+	r.ErrorMessage, err = coerce.ToString(values["errorMessage"])
+	if err != nil {
+		return err
+	}
+	r.ErrorData = values["errorData"]
+
+	return nil
+}
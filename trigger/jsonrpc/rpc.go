@@ -0,0 +1,186 @@
+package jsonrpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+)
+
+var nullID = json.RawMessage("null")
+
+// call is the context of the requests received from a client
+type call struct {
+	transport     string
+	remoteAddress string
+	headers       map[string]string
+}
+
+// dispatcher dispatches the requests to the handlers of their methods
+type dispatcher struct {
+	handlers     map[string]trigger.Handler
+	maxBatchSize int
+	logger       log.Logger
+}
+
+// process processes a single request or a batch, it returns the response to send or nil when there is
+// none, for the notifications
+func (d *dispatcher) process(ctx context.Context, c *call, body []byte) []byte {
+
+	body = bytes.TrimSpace(body)
+	if !json.Valid(body) {
+		return marshal(failure(nullID, &errorObject{Code: CodeParseError, Message: "Parse error"}))
+	}
+
+	if body[0] != '[' {
+		response := d.invoke(ctx, c, body)
+		if response == nil {
+			return nil
+		}
+		return marshal(response)
+	}
+
+	var batch []json.RawMessage
+	_ = json.Unmarshal(body, &batch)
+	if len(batch) == 0 {
+		return marshal(failure(nullID, &errorObject{Code: CodeInvalidRequest, Message: "Invalid Request", Data: "empty batch"}))
+	}
+	if len(batch) > d.maxBatchSize {
+		return marshal(failure(nullID, &errorObject{Code: CodeInvalidRequest, Message: "Invalid Request",
+			Data: fmt.Sprintf("batch of more than %d requests", d.maxBatchSize)}))
+	}
+
+	responses := make([]interface{}, 0, len(batch))
+	for _, request := range batch {
+		if response := d.invoke(ctx, c, request); response != nil {
+			responses = append(responses, response)
+		}
+	}
+	if len(responses) == 0 {
+		return nil
+	}
+
+	return marshal(responses)
+}
+
+// invoke invokes the handler of the method of a request, it returns its response or nil for a notification.
+// A notification is a request without id, a request with an invalid envelope is answered with a null id.
+func (d *dispatcher) invoke(ctx context.Context, c *call, request json.RawMessage) map[string]interface{} {
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(request, &fields); err != nil {
+		return invalidRequest("not an object")
+	}
+
+	id, hasID := fields["id"]
+	if hasID && !isValidID(id) {
+		return invalidRequest("invalid id")
+	}
+
+	var version, method string
+	if err := json.Unmarshal(fields["jsonrpc"], &version); err != nil || version != "2.0" {
+		return invalidRequest("jsonrpc must be 2.0")
+	}
+	if err := json.Unmarshal(fields["method"], &method); err != nil || method == "" {
+		return invalidRequest("invalid method")
+	}
+
+	var params interface{}
+	if raw, ok := fields["params"]; ok {
+		raw = bytes.TrimSpace(raw)
+		if len(raw) == 0 || (raw[0] != '[' && raw[0] != '{') {
+			return invalidRequest("params must be an array or an object")
+		}
+		_ = json.Unmarshal(raw, &params)
+	}
+
+	handler, ok := d.handlers[method]
+	if !ok {
+		if !hasID {
+			return nil
+		}
+		return failure(id, &errorObject{Code: CodeMethodNotFound, Message: "Method not found"})
+	}
+
+	out := &Output{
+		Method:        method,
+		Params:        params,
+		Notification:  !hasID,
+		Transport:     c.transport,
+		RemoteAddress: c.remoteAddress,
+		Headers:       c.headers,
+	}
+	if hasID {
+		_ = json.Unmarshal(id, &out.ID)
+	}
+	if out.Headers == nil {
+		out.Headers = make(map[string]string)
+	}
+
+	results, err := handler.Handle(ctx, out)
+	if err != nil {
+		d.logger.Errorf("Error handling method '%s': %v", method, err)
+		if !hasID {
+			return nil
+		}
+		return failure(id, toErrorObject(err))
+	}
+	if !hasID {
+		return nil
+	}
+
+	reply := &Reply{}
+	err = reply.FromMap(results)
+	if err != nil {
+		return failure(id, &errorObject{Code: CodeInternalError, Message: err.Error()})
+	}
+	if reply.ErrorCode != 0 || reply.ErrorMessage != "" {
+		e := &errorObject{Code: reply.ErrorCode, Message: reply.ErrorMessage, Data: reply.ErrorData}
+		if e.Code == 0 {
+			e.Code = CodeServerError
+		}
+		if e.Message == "" {
+			e.Message = "Server error"
+		}
+		return failure(id, e)
+	}
+
+	return map[string]interface{}{"jsonrpc": "2.0", "result": reply.Result, "id": id}
+}
+
+func failure(id json.RawMessage, e *errorObject) map[string]interface{} {
+	return map[string]interface{}{"jsonrpc": "2.0", "error": e, "id": id}
+}
+
+func invalidRequest(reason string) map[string]interface{} {
+	return failure(nullID, &errorObject{Code: CodeInvalidRequest, Message: "Invalid Request", Data: reason})
+}
+
+// isValidID returns true when the id is a string, a number or null
+func isValidID(id json.RawMessage) bool {
+
+	var value interface{}
+	if err := json.Unmarshal(id, &value); err != nil {
+		return false
+	}
+	switch value.(type) {
+	case string, float64, nil:
+		return true
+	}
+
+	return false
+}
+
+// marshal returns the JSON of a response, an internal error when the result can't be encoded
+func marshal(response interface{}) []byte {
+
+	b, err := json.Marshal(response)
+	if err != nil {
+		b, _ = json.Marshal(failure(nullID, &errorObject{Code: CodeInternalError, Message: err.Error()}))
+	}
+
+	return b
+}
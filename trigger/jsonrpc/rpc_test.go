@@ -0,0 +1,185 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"flogo/core/action"
+	"flogo/core/api"
+	"flogo/core/support/test"
+	"flogo/core/trigger"
+	"github.com/stretchr/testify/assert"
+)
+
+// activityError is an error with a code and data, like the errors of the activities
+type activityError struct {
+	code string
+	data interface{}
+}
+
+func (e *activityError) Error() string     { return "activity failed" }
+func (e *activityError) Code() string      { return e.code }
+func (e *activityError) Data() interface{} { return e.data }
+
+// newDispatcher returns the dispatcher of a trigger whose handler of the method runs the action
+func newDispatcher(t *testing.T, method string, act action.Action) *dispatcher {
+
+	config := &trigger.Config{Settings: map[string]interface{}{"port": 8080, "maxBatchSize": 2}, Handlers: []*trigger.HandlerConfig{
+		{Settings: map[string]interface{}{"method": method}, Actions: []*trigger.ActionConfig{{Config: &action.Config{Id: "test"}}}},
+	}}
+	trg, err := test.InitTrigger(&Factory{}, config, map[string]action.Action{"test": act})
+	assert.Nil(t, err)
+
+	return trg.(*Trigger).dispatcher
+}
+
+func process(t *testing.T, d *dispatcher, body string) interface{} {
+
+	response := d.process(context.Background(), &call{transport: TransportHTTP}, []byte(body))
+	if response == nil {
+		return nil
+	}
+
+	var value interface{}
+	assert.Nil(t, json.Unmarshal(response, &value))
+	return value
+}
+
+func errorCode(response interface{}) interface{} {
+	return response.(map[string]interface{})["error"].(map[string]interface{})["code"]
+}
+
+func TestProcessCall(t *testing.T) {
+
+	outputs := make(chan *Output, 10)
+	d := newDispatcher(t, "sum", api.NewProxyAction(func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+		out := &Output{}
+		if err := out.FromMap(inputs); err != nil {
+			return nil, err
+		}
+		outputs <- out
+
+		params := out.Params.([]interface{})
+		return map[string]interface{}{"result": params[0].(float64) + params[1].(float64)}, nil
+	}))
+
+	response := process(t, d, `{"jsonrpc": "2.0", "method": "sum", "params": [1, 2], "id": "a"}`)
+	assert.Equal(t, map[string]interface{}{"jsonrpc": "2.0", "result": 3.0, "id": "a"}, response)
+
+	out := <-outputs
+	assert.Equal(t, "sum", out.Method)
+	assert.Equal(t, "a", out.ID)
+	assert.False(t, out.Notification)
+	assert.Equal(t, TransportHTTP, out.Transport)
+
+	response = process(t, d, `{"jsonrpc": "2.0", "method": "sum", "params": [1, 2]}`)
+	assert.Nil(t, response)
+	out = <-outputs
+	assert.True(t, out.Notification)
+	assert.Nil(t, out.ID)
+
+	response = process(t, d, `{"jsonrpc": "2.0", "method": "missing", "id": 7}`)
+	assert.Equal(t, float64(CodeMethodNotFound), errorCode(response))
+	assert.Equal(t, 7.0, response.(map[string]interface{})["id"])
+
+	response = process(t, d, `{"jsonrpc": "2.0", "method": "missing"}`)
+	assert.Nil(t, response)
+}
+
+func TestProcessInvalid(t *testing.T) {
+
+	d := newDispatcher(t, "echo", echo(make(chan *Output, 10)))
+
+	tests := []struct {
+		body string
+		code int
+	}{
+		{`{"jsonrpc": "2.0", "method": "echo"`, CodeParseError},
+		{`{"method": "echo", "id": 1}`, CodeInvalidRequest},
+		{`{"jsonrpc": "1.0", "method": "echo", "id": 1}`, CodeInvalidRequest},
+		{`{"jsonrpc": "2.0", "method": 1, "id": 1}`, CodeInvalidRequest},
+		{`{"jsonrpc": "2.0", "method": "echo", "params": 1, "id": 1}`, CodeInvalidRequest},
+		{`{"jsonrpc": "2.0", "method": "echo", "id": {}}`, CodeInvalidRequest},
+		{`"echo"`, CodeInvalidRequest},
+		{`[]`, CodeInvalidRequest},
+		{`[1, 2, 3]`, CodeInvalidRequest},
+	}
+
+	for _, test := range tests {
+		response := process(t, d, test.body)
+		assert.Equal(t, float64(test.code), errorCode(response), test.body)
+		assert.Nil(t, response.(map[string]interface{})["id"], test.body)
+	}
+}
+
+func TestProcessBatch(t *testing.T) {
+
+	d := newDispatcher(t, "echo", echo(make(chan *Output, 10)))
+
+	response := process(t, d, `[{"jsonrpc": "2.0", "method": "echo", "params": {"a": 1}, "id": 1}, 1]`)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"jsonrpc": "2.0", "result": map[string]interface{}{"a": 1.0}, "id": 1.0},
+		map[string]interface{}{"jsonrpc": "2.0", "id": nil,
+			"error": map[string]interface{}{"code": float64(CodeInvalidRequest), "message": "Invalid Request", "data": "not an object"}},
+	}, response)
+
+	response = process(t, d, `[{"jsonrpc": "2.0", "method": "echo"}, {"jsonrpc": "2.0", "method": "echo"}]`)
+	assert.Nil(t, response)
+}
+
+func TestProcessErrors(t *testing.T) {
+
+	tests := []struct {
+		reply    func() (map[string]interface{}, error)
+		expected map[string]interface{}
+	}{
+		{
+			func() (map[string]interface{}, error) {
+				return nil, errors.New("boom")
+			},
+			map[string]interface{}{"code": float64(CodeInternalError), "message": "boom"},
+		},
+		{
+			func() (map[string]interface{}, error) {
+				return nil, NewError(CodeInvalidParams, "Invalid params", "a is required")
+			},
+			map[string]interface{}{"code": float64(CodeInvalidParams), "message": "Invalid params", "data": "a is required"},
+		},
+		{
+			func() (map[string]interface{}, error) {
+				return nil, &activityError{code: "-32001", data: map[string]interface{}{"retry": true}}
+			},
+			map[string]interface{}{"code": -32001.0, "message": "activity failed", "data": map[string]interface{}{"retry": true}},
+		},
+		{
+			func() (map[string]interface{}, error) {
+				return map[string]interface{}{"errorCode": 42, "errorMessage": "Not allowed"}, nil
+			},
+			map[string]interface{}{"code": 42.0, "message": "Not allowed"},
+		},
+		{
+			func() (map[string]interface{}, error) {
+				return map[string]interface{}{"errorCode": -32001}, nil
+			},
+			map[string]interface{}{"code": -32001.0, "message": "Server error"},
+		},
+		{
+			func() (map[string]interface{}, error) {
+				return map[string]interface{}{"errorMessage": "Unavailable", "errorData": "details"}, nil
+			},
+			map[string]interface{}{"code": float64(CodeServerError), "message": "Unavailable", "data": "details"},
+		},
+	}
+
+	for _, test := range tests {
+		reply := test.reply
+		d := newDispatcher(t, "fail", api.NewProxyAction(func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+			return reply()
+		}))
+
+		response := process(t, d, `{"jsonrpc": "2.0", "method": "fail", "id": 1}`)
+		assert.Equal(t, map[string]interface{}{"jsonrpc": "2.0", "error": test.expected, "id": 1.0}, response)
+	}
+}
@@ -0,0 +1,341 @@
+package jsonrpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"flogo/core/data/metadata"
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+)
+
+const (
+	defaultPath         = "/rpc"
+	defaultMaxBatchSize = 100
+	maxBodySize         = 1024 * 1024
+	stopTimeout         = 5 * time.Second
+)
+
+var triggerMd = trigger.NewMetadata(&Settings{}, &HandlerSettings{}, &Output{}, &Reply{})
+
+func init() {
+	_ = trigger.Register(&Trigger{}, &Factory{})
+}
+
+type Factory struct {
+}
+
+// Metadata implements trigger.Factory.Metadata
+func (*Factory) Metadata() *trigger.Metadata {
+	return triggerMd
+}
+
+// New implements trigger.Factory.New
+func (*Factory) New(config *trigger.Config) (trigger.Trigger, error) {
+
+	s := &Settings{}
+	err := metadata.MapToStruct(config.Settings, s, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Port == 0 && s.TCPPort == 0 {
+		return nil, errors.New("a port or a tcp port is required")
+	}
+	if (s.CertFile == "") != (s.KeyFile == "") {
+		return nil, errors.New("both cert file and key file must be specified for HTTPS")
+	}
+	if s.Path == "" {
+		s.Path = defaultPath
+	}
+	if s.MaxBatchSize <= 0 {
+		s.MaxBatchSize = defaultMaxBatchSize
+	}
+
+	return &Trigger{settings: s}, nil
+}
+
+// Trigger is a JSON-RPC 2.0 server invoking the action of the handler of the method of each request
+// received over HTTP or raw TCP
+type Trigger struct {
+	settings   *Settings
+	logger     log.Logger
+	dispatcher *dispatcher
+
+	mux          *http.ServeMux
+	server       *http.Server
+	httpListener net.Listener
+
+	listener    net.Listener
+	cancel      context.CancelFunc
+	mu          sync.Mutex
+	connections map[net.Conn]bool
+	wg          *sync.WaitGroup
+}
+
+// Initialize implements trigger.Init.Initialize
+func (t *Trigger) Initialize(ctx trigger.InitContext) error {
+
+	t.logger = ctx.Logger()
+	t.dispatcher = &dispatcher{
+		handlers:     make(map[string]trigger.Handler),
+		maxBatchSize: t.settings.MaxBatchSize,
+		logger:       t.logger,
+	}
+
+	for _, handler := range ctx.GetHandlers() {
+
+		s := &HandlerSettings{}
+		err := metadata.MapToStruct(handler.Settings(), s, true)
+		if err != nil {
+			return err
+		}
+
+		if _, exists := t.dispatcher.handlers[s.Method]; exists {
+			return fmt.Errorf("method '%s' is handled by more than one handler", s.Method)
+		}
+		t.dispatcher.handlers[s.Method] = handler
+	}
+
+	if t.settings.Port != 0 {
+		t.mux = http.NewServeMux()
+		t.mux.HandleFunc(t.settings.Path, t.serveHTTP)
+	}
+
+	return nil
+}
+
+// Start implements util.Managed.Start
+func (t *Trigger) Start() error {
+
+	if t.mux != nil {
+		ln, err := net.Listen("tcp", ":"+strconv.Itoa(t.settings.Port))
+		if err != nil {
+			return err
+		}
+		t.httpListener = ln
+
+		// a shut down server can't serve again, each start uses a new one
+		server := &http.Server{Handler: t.mux, ReadHeaderTimeout: 30 * time.Second}
+		t.server = server
+
+		t.logger.Infof("Listening on port %d", t.settings.Port)
+
+		go func() {
+			var err error
+			if t.settings.CertFile != "" {
+				err = server.ServeTLS(ln, t.settings.CertFile, t.settings.KeyFile)
+			} else {
+				err = server.Serve(ln)
+			}
+			if err != nil && err != http.ErrServerClosed {
+				t.logger.Errorf("HTTP server stopped: %v", err)
+			}
+		}()
+	}
+
+	if t.settings.TCPPort != 0 {
+		listener, err := net.Listen("tcp", ":"+strconv.Itoa(t.settings.TCPPort))
+		if err != nil {
+			_ = t.Stop()
+			return err
+		}
+
+		// the context of the TCP calls is cancelled when the trigger stops
+		ctx, cancel := context.WithCancel(context.Background())
+
+		// the calls of a previous start may still be running, each start waits for its own
+		wg := &sync.WaitGroup{}
+
+		t.mu.Lock()
+		t.listener = listener
+		t.cancel = cancel
+		t.wg = wg
+		t.connections = make(map[net.Conn]bool)
+		t.mu.Unlock()
+
+		t.logger.Infof("Listening on %s", listener.Addr())
+
+		wg.Add(1)
+		go t.accept(ctx, wg, listener)
+	}
+
+	return nil
+}
+
+// Stop implements util.Managed.Stop
+func (t *Trigger) Stop() error {
+
+	t.mu.Lock()
+	if t.listener != nil {
+		_ = t.listener.Close()
+		t.listener = nil
+	}
+	if t.cancel != nil {
+		t.cancel()
+		t.cancel = nil
+	}
+	for conn := range t.connections {
+		_ = conn.Close()
+	}
+	wg := t.wg
+	t.wg = nil
+	t.mu.Unlock()
+
+	// the actions may ignore the cancellation of their context, they are given some time to return
+	if wg != nil {
+		done := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(stopTimeout):
+			t.logger.Warnf("TCP calls still running after %s, stopping anyway", stopTimeout)
+		}
+	}
+
+	if t.httpListener == nil {
+		return nil
+	}
+	t.httpListener = nil
+
+	ctx, cancel := context.WithTimeout(context.Background(), stopTimeout)
+	defer cancel()
+
+	return t.server.Shutdown(ctx)
+}
+
+// serveHTTP handles the request or the batch of a POST request, the response is empty when there are
+// only notifications
+func (t *Trigger) serveHTTP(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	headers := make(map[string]string, len(r.Header))
+	for name, values := range r.Header {
+		if len(values) > 0 {
+			headers[name] = values[0]
+		}
+	}
+
+	c := &call{transport: TransportHTTP, remoteAddress: r.RemoteAddr, headers: headers}
+	response := t.dispatcher.process(r.Context(), c, body)
+	if response == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(response)
+}
+
+func (t *Trigger) accept(ctx context.Context, wg *sync.WaitGroup, listener net.Listener) {
+
+	defer wg.Done()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			t.mu.Lock()
+			stopped := t.listener == nil
+			t.mu.Unlock()
+			if !stopped {
+				t.logger.Errorf("Error accepting connection: %v", err)
+			}
+			return
+		}
+
+		t.mu.Lock()
+		if t.listener == nil {
+			t.mu.Unlock()
+			_ = conn.Close()
+			return
+		}
+		t.connections[conn] = true
+		wg.Add(1)
+		t.mu.Unlock()
+
+		go t.serve(ctx, wg, conn)
+	}
+}
+
+// serve reads the JSON values of a connection one after the other, each response is written on its own
+// line in the order of the requests. The connection is closed after a parse error or a value larger than
+// maxBodySize, as the stream can't be resynchronized.
+func (t *Trigger) serve(ctx context.Context, wg *sync.WaitGroup, conn net.Conn) {
+
+	defer wg.Done()
+
+	c := &call{transport: TransportTCP, remoteAddress: conn.RemoteAddr().String()}
+	t.logger.Debugf("Connection opened from %s", c.remoteAddress)
+
+	// the bytes the decoder has already read past a value count for the next one
+	limited := &io.LimitedReader{R: conn, N: maxBodySize}
+	decoder := json.NewDecoder(limited)
+	for {
+		var request json.RawMessage
+		err := decoder.Decode(&request)
+		tooLarge := err != nil && limited.N <= 0
+		if buffered, ok := decoder.Buffered().(interface{ Len() int }); ok {
+			limited.N = maxBodySize - int64(buffered.Len())
+		}
+
+		var response []byte
+		if tooLarge {
+			t.logger.Warnf("Request from %s larger than %d bytes, closing the connection", c.remoteAddress, maxBodySize)
+			response = marshal(failure(nullID, &errorObject{Code: CodeInvalidRequest, Message: "Invalid Request",
+				Data: fmt.Sprintf("larger than %d bytes", maxBodySize)}))
+		} else if err != nil {
+			if _, ok := err.(*json.SyntaxError); !ok {
+				if err != io.EOF && !isClosed(err) {
+					t.logger.Debugf("Connection from %s closed: %v", c.remoteAddress, err)
+				}
+				break
+			}
+			response = marshal(failure(nullID, &errorObject{Code: CodeParseError, Message: "Parse error"}))
+		} else {
+			response = t.dispatcher.process(ctx, c, request)
+		}
+
+		if response != nil {
+			if _, werr := conn.Write(append(response, '\n')); werr != nil {
+				t.logger.Errorf("Error writing response to %s: %v", c.remoteAddress, werr)
+				break
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	t.mu.Lock()
+	delete(t.connections, conn)
+	t.mu.Unlock()
+	_ = conn.Close()
+}
+
+func isClosed(err error) bool {
+	return strings.Contains(err.Error(), "use of closed network connection")
+}
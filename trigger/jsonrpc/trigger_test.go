@@ -0,0 +1,260 @@
+package jsonrpc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"flogo/core/action"
+	"flogo/core/api"
+	"flogo/core/support/test"
+	"flogo/core/trigger"
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig string = `{
+	"id": "trigger-jsonrpc",
+	"ref": "github.com/qingcloudhx/contrib/trigger/jsonrpc",
+	"settings": {
+	  "port": 8080
+	},
+	"handlers": [
+	  {
+		"settings": {
+		  "method": "echo"
+		},
+		"action": {
+		  "id": "echo"
+		}
+	  }
+	]
+}`
+
+// echo returns an action replying with the params of the calls, it sends their outputs to the channel
+func echo(outputs chan<- *Output) action.Action {
+	return api.NewProxyAction(func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+		out := &Output{}
+		if err := out.FromMap(inputs); err != nil {
+			return nil, err
+		}
+		outputs <- out
+		return map[string]interface{}{"result": out.Params}, nil
+	})
+}
+
+func TestFactory(t *testing.T) {
+
+	f := &Factory{}
+	_, err := f.New(&trigger.Config{Settings: map[string]interface{}{}})
+	assert.NotNil(t, err)
+
+	tgr, err := f.New(&trigger.Config{Settings: map[string]interface{}{"port": 8080}})
+	assert.Nil(t, err)
+	assert.Equal(t, defaultPath, tgr.(*Trigger).settings.Path)
+	assert.Equal(t, defaultMaxBatchSize, tgr.(*Trigger).settings.MaxBatchSize)
+
+	// the methods have a single handler
+	app := api.NewApp()
+	trg := app.NewTrigger(&Trigger{}, map[string]interface{}{"port": 8080})
+	for i := 0; i < 2; i++ {
+		handler, err := trg.NewHandler(map[string]interface{}{"method": "a"})
+		assert.Nil(t, err)
+		_, err = handler.NewAction(func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+			return nil, nil
+		})
+		assert.Nil(t, err)
+	}
+	_, err = api.NewEngine(app)
+	assert.NotNil(t, err)
+}
+
+func TestServeHTTP(t *testing.T) {
+
+	outputs := make(chan *Output, 10)
+	config := &trigger.Config{}
+	err := json.Unmarshal([]byte(testConfig), config)
+	assert.Nil(t, err)
+	trg, err := test.InitTrigger(&Factory{}, config, map[string]action.Action{"echo": echo(outputs)})
+	assert.Nil(t, err)
+	tt := trg.(*Trigger)
+
+	request := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`{"jsonrpc": "2.0", "method": "echo", "params": ["hi"], "id": 1}`))
+	request.Header.Set("X-Request-Id", "abc")
+	w := httptest.NewRecorder()
+	tt.serveHTTP(w, request)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"jsonrpc": "2.0", "result": ["hi"], "id": 1}`, w.Body.String())
+
+	out := <-outputs
+	assert.Equal(t, TransportHTTP, out.Transport)
+	assert.Equal(t, "abc", out.Headers["X-Request-Id"])
+
+	request = httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(`{"jsonrpc": "2.0", "method": "echo"}`))
+	w = httptest.NewRecorder()
+	tt.serveHTTP(w, request)
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	request = httptest.NewRequest(http.MethodGet, "/rpc", nil)
+	w = httptest.NewRecorder()
+	tt.serveHTTP(w, request)
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, "POST", w.Header().Get("Allow"))
+}
+
+func TestTCP(t *testing.T) {
+
+	// find a free port for the TCP endpoint
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	port := ln.Addr().(*net.TCPAddr).Port
+	_ = ln.Close()
+
+	outputs := make(chan *Output, 10)
+	config := &trigger.Config{}
+	err = json.Unmarshal([]byte(testConfig), config)
+	assert.Nil(t, err)
+	config.Settings = map[string]interface{}{"tcpPort": port}
+	trg, err := test.InitTrigger(&Factory{}, config, map[string]action.Action{"echo": echo(outputs)})
+	assert.Nil(t, err)
+	tt := trg.(*Trigger)
+	assert.Nil(t, tt.Start())
+	defer func() {
+		assert.Nil(t, tt.Stop())
+	}()
+
+	conn, err := net.Dial("tcp", tt.listener.Addr().String())
+	assert.Nil(t, err)
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	_, err = conn.Write([]byte(`{"jsonrpc": "2.0", "method": "echo", "params": {"n": 1}}` +
+		`{"jsonrpc": "2.0", "method": "echo", "params": {"n": 2}, "id": "x"}` + "\n" +
+		`[{"jsonrpc": "2.0", "method": "missing", "id": 3}]` + "\n" + `{"jsonrpc" x}`))
+	assert.Nil(t, err)
+	assert.Nil(t, conn.(*net.TCPConn).CloseWrite())
+
+	r := bufio.NewReader(conn)
+	var responses []interface{}
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil {
+			break
+		}
+		var response interface{}
+		assert.Nil(t, json.Unmarshal(line, &response))
+		responses = append(responses, response)
+	}
+
+	// the connection is closed after the parse error
+	assert.Len(t, responses, 3)
+	if len(responses) == 3 {
+		assert.Equal(t, map[string]interface{}{"jsonrpc": "2.0", "result": map[string]interface{}{"n": 2.0}, "id": "x"}, responses[0])
+		assert.Equal(t, float64(CodeMethodNotFound), errorCode(responses[1].([]interface{})[0]))
+		assert.Equal(t, float64(CodeParseError), errorCode(responses[2]))
+	}
+	assert.Len(t, outputs, 2)
+	assert.Equal(t, TransportTCP, (<-outputs).Transport)
+}
+
+func TestTCP_Limits(t *testing.T) {
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	port := ln.Addr().(*net.TCPAddr).Port
+	_ = ln.Close()
+
+	// the action blocks until its call is cancelled
+	cancelled := make(chan bool, 1)
+	config := &trigger.Config{}
+	err = json.Unmarshal([]byte(testConfig), config)
+	assert.Nil(t, err)
+	config.Settings = map[string]interface{}{"tcpPort": port}
+	trg, err := test.InitTrigger(&Factory{}, config, map[string]action.Action{"echo": api.NewProxyAction(
+		func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+			<-ctx.Done()
+			cancelled <- true
+			return nil, ctx.Err()
+		})})
+	assert.Nil(t, err)
+	assert.Nil(t, trg.Start())
+
+	dial := func() net.Conn {
+		conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+		assert.Nil(t, err)
+		_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+		return conn
+	}
+
+	// a value larger than maxBodySize gets an error, and its connection is closed
+	conn := dial()
+	defer conn.Close()
+	_, err = conn.Write([]byte(`"` + strings.Repeat("a", maxBodySize-1)))
+	assert.Nil(t, err)
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	assert.Nil(t, err)
+	var response interface{}
+	assert.Nil(t, json.Unmarshal(line, &response))
+	assert.Equal(t, float64(CodeInvalidRequest), errorCode(response))
+	_, err = conn.Read(make([]byte, 1))
+	assert.NotNil(t, err)
+
+	// stopping the trigger cancels the running calls
+	conn = dial()
+	defer conn.Close()
+	_, err = conn.Write([]byte(`{"jsonrpc": "2.0", "method": "echo", "id": 1}`))
+	assert.Nil(t, err)
+	time.Sleep(50 * time.Millisecond)
+
+	start := time.Now()
+	assert.Nil(t, trg.Stop())
+	assert.True(t, time.Since(start) < stopTimeout)
+	assert.True(t, <-cancelled)
+}
+
+func TestTrigger_Restart(t *testing.T) {
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	port := ln.Addr().(*net.TCPAddr).Port
+	_ = ln.Close()
+
+	outputs := make(chan *Output, 10)
+	config := &trigger.Config{}
+	err = json.Unmarshal([]byte(testConfig), config)
+	assert.Nil(t, err)
+	config.Settings["port"] = port
+	trg, err := test.InitTrigger(&Factory{}, config, map[string]action.Action{"echo": echo(outputs)})
+	assert.Nil(t, err)
+
+	call := func() string {
+		request, _ := http.NewRequest(http.MethodPost, fmt.Sprintf("http://127.0.0.1:%d/rpc", port), strings.NewReader(`{"jsonrpc": "2.0", "method": "echo", "params": ["hi"], "id": 1}`))
+		// the connections of a stopped trigger are closed
+		request.Close = true
+		resp, err := http.DefaultClient.Do(request)
+		if err != nil {
+			return ""
+		}
+		defer resp.Body.Close()
+		body, _ := ioutil.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	// the trigger serves the requests again once restarted
+	for i := 0; i < 2; i++ {
+		assert.Nil(t, trg.Start())
+		assert.JSONEq(t, `{"jsonrpc": "2.0", "result": ["hi"], "id": 1}`, call())
+		assert.Nil(t, trg.Stop())
+	}
+	assert.Equal(t, "", call())
+	assert.Len(t, outputs, 2)
+}
@@ -1,13 +1,13 @@
 package kafka
 
 import (
-	"crypto/tls"
 	"crypto/x509"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/Shopify/sarama"
 	"flogo/core/support/log"
@@ -17,13 +17,50 @@ type KafkaConnection struct {
 	kafkaConfig  *sarama.Config
 	brokers      []string
 	consumer sarama.Consumer
+	registry *schemaRegistry
+
+	producerMu sync.Mutex
+	producer   sarama.SyncProducer
 }
 
 func (c *KafkaConnection) Connection() sarama.Consumer {
 	return c.consumer
 }
 
+// Producer returns the producer sharing the configuration of the connection, it is created on first use
+func (c *KafkaConnection) Producer() (sarama.SyncProducer, error) {
+	c.producerMu.Lock()
+	defer c.producerMu.Unlock()
+
+	if c.producer != nil {
+		return c.producer, nil
+	}
+
+	config := *c.kafkaConfig
+	config.Producer.Return.Successes = true
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	if !config.Version.IsAtLeast(sarama.V0_11_0_0) {
+		// required for the record headers
+		config.Version = sarama.V0_11_0_0
+	}
+
+	producer, err := sarama.NewSyncProducer(c.brokers, &config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kafka producer for reason [%s]", err)
+	}
+	c.producer = producer
+
+	return producer, nil
+}
+
 func (c *KafkaConnection) Stop() error {
+	c.producerMu.Lock()
+	if c.producer != nil {
+		_ = c.producer.Close()
+		c.producer = nil
+	}
+	c.producerMu.Unlock()
+
 	return c.consumer.Close()
 }
 
@@ -51,36 +88,21 @@ func getKafkaConnection(logger log.Logger, settings *Settings) (*KafkaConnection
 	newConn.brokers = brokers
 	logger.Debugf("Kafka brokers: [%v]", brokers)
 
-	//clientKeystore
-	/*
-		Its worth mentioning here that when the keystore for kafka is created it must support RSA keys via
-		the -keyalg RSA option.  If not then there will be ZERO overlap in supported cipher suites with java.
-		see: https://issues.apache.org/jira/browse/KAFKA-3647
-		for more info
-	*/
-	if settings.TrustStore != "" {
-		if trustPool, err := getCerts(logger, settings.TrustStore); err == nil {
-			config := tls.Config{
-				RootCAs:            trustPool,
-				InsecureSkipVerify: true}
-			newConn.kafkaConfig.Net.TLS.Enable = true
-			newConn.kafkaConfig.Net.TLS.Config = &config
-
-			logger.Debugf("Kafka initialized truststore from [%v]", settings.TrustStore)
-		} else {
-			return nil, err
-		}
+	if err := configureTLS(logger, newConn.kafkaConfig, settings); err != nil {
+		return nil, err
 	}
 
 	// SASL
-	if settings.User != "" {
-		if len(settings.Password) == 0 {
-			return nil, fmt.Errorf("password not provided for user: %s", settings.User)
-		}
-		newConn.kafkaConfig.Net.SASL.Enable = true
-		newConn.kafkaConfig.Net.SASL.User = settings.User
-		newConn.kafkaConfig.Net.SASL.Password = settings.Password
-		logger.Debugf("Kafka SASL params initialized; user [%v]", settings.User)
+	if err := configureSASL(newConn.kafkaConfig, settings); err != nil {
+		return nil, err
+	}
+	if newConn.kafkaConfig.Net.SASL.Enable {
+		logger.Debugf("Kafka SASL params initialized; mechanism [%v], user [%v]", newConn.kafkaConfig.Net.SASL.Mechanism, settings.User)
+	}
+
+	if !newConn.kafkaConfig.Version.IsAtLeast(sarama.V0_11_0_0) {
+		// required to receive the record headers
+		newConn.kafkaConfig.Version = sarama.V0_11_0_0
 	}
 
 	kafkaConsumer, err := sarama.NewConsumer(brokers, newConn.kafkaConfig)
@@ -89,6 +111,7 @@ func getKafkaConnection(logger log.Logger, settings *Settings) (*KafkaConnection
 	}
 
 	newConn.consumer = kafkaConsumer
+	newConn.registry = newSchemaRegistry(settings)
 
 	return newConn, nil
 }
@@ -0,0 +1,136 @@
+package kafka
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+const (
+	headerError             = "x-error"
+	headerHandler           = "x-handler"
+	headerOriginalTopic     = "x-original-topic"
+	headerOriginalPartition = "x-original-partition"
+	headerOriginalOffset    = "x-original-offset"
+	headerFailedAt          = "x-failed-at"
+)
+
+// process runs the action for a message, retrying it up to the max retries. If the action still fails, the message
+// is published to the next retry topic or to the dead letter topic of the handler and considered processed. A message
+// consumed from a retry topic is only processed once its retry delay elapsed, done stops the wait
+func (h *Handler) process(msg *sarama.ConsumerMessage, done <-chan struct{}) error {
+
+	if !h.awaitRetry(msg, done) {
+		return errHandlerStopped
+	}
+
+	if h.flow != nil {
+		if !h.flow.acquire(h.shutdown) {
+			return errHandlerStopped
+		}
+		defer h.flow.release()
+	}
+
+	results, err := h.run(msg)
+	if err == nil {
+		return h.publish(results)
+	}
+
+	record := h.failureRecord(msg, err)
+	if record == nil {
+		return err
+	}
+
+	producer, pErr := h.conn.Producer()
+	if pErr == nil {
+		_, _, pErr = producer.SendMessage(record)
+	}
+	if pErr != nil {
+		return fmt.Errorf("%s, and publishing to topic [%s] failed for reason [%s]", err, record.Topic, pErr)
+	}
+
+	h.logger.Warnf("Run action for handler [%s] failed for reason [%s], message published to topic [%s]", h.handler.Name(), err, record.Topic)
+
+	return nil
+}
+
+// run runs the action for a message, retrying it up to the max retries with an exponential backoff
+func (h *Handler) run(msg *sarama.ConsumerMessage) (map[string]interface{}, error) {
+
+	for attempt := 0; ; attempt++ {
+		results, err := h.handleMessage(msg)
+		if err == nil || attempt >= h.maxRetries {
+			return results, err
+		}
+
+		delay := h.backoff(attempt)
+		h.logger.Warnf("Run action for handler [%s] failed for reason [%s], retrying in %s (%d/%d)", h.handler.Name(), err, delay, attempt+1, h.maxRetries)
+		select {
+		case <-h.shutdown:
+			return nil, err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// failureRecord creates the record a message is published to when the action failed, to the next
+// retry topic or else to the dead letter topic, it returns nil when the handler has neither
+func (h *Handler) failureRecord(msg *sarama.ConsumerMessage, handlerErr error) *sarama.ProducerMessage {
+
+	if topic := h.nextRetryTopic(msg.Topic); topic != "" {
+		return h.failedMessage(msg, handlerErr, topic)
+	}
+	if h.deadLetterTopic != "" {
+		return h.failedMessage(msg, handlerErr, h.deadLetterTopic)
+	}
+	return nil
+}
+
+// failedMessage creates the record of a failed message, with the error and the origin of the message in headers.
+// The origin of a message consumed from a retry topic is the one of the message first consumed
+func (h *Handler) failedMessage(msg *sarama.ConsumerMessage, handlerErr error, topic string) *sarama.ProducerMessage {
+
+	origin := map[string]string{
+		headerOriginalTopic:     msg.Topic,
+		headerOriginalPartition: strconv.Itoa(int(msg.Partition)),
+		headerOriginalOffset:    strconv.FormatInt(msg.Offset, 10),
+	}
+	retried, _ := h.retryStage(msg.Topic)
+
+	headers := make([]sarama.RecordHeader, 0, len(msg.Headers)+6)
+	for _, header := range msg.Headers {
+		if header == nil {
+			continue
+		}
+		switch key := string(header.Key); key {
+		case headerOriginalTopic, headerOriginalPartition, headerOriginalOffset:
+			if retried >= 0 {
+				origin[key] = string(header.Value)
+			}
+		case headerError, headerHandler, headerFailedAt:
+		default:
+			headers = append(headers, *header)
+		}
+	}
+	headers = append(headers,
+		sarama.RecordHeader{Key: []byte(headerError), Value: []byte(handlerErr.Error())},
+		sarama.RecordHeader{Key: []byte(headerHandler), Value: []byte(h.handler.Name())},
+		sarama.RecordHeader{Key: []byte(headerOriginalTopic), Value: []byte(origin[headerOriginalTopic])},
+		sarama.RecordHeader{Key: []byte(headerOriginalPartition), Value: []byte(origin[headerOriginalPartition])},
+		sarama.RecordHeader{Key: []byte(headerOriginalOffset), Value: []byte(origin[headerOriginalOffset])},
+		sarama.RecordHeader{Key: []byte(headerFailedAt), Value: []byte(time.Now().UTC().Format(time.RFC3339))},
+	)
+
+	record := &sarama.ProducerMessage{
+		Topic:   topic,
+		Value:   sarama.ByteEncoder(msg.Value),
+		Headers: headers,
+	}
+	if msg.Key != nil {
+		record.Key = sarama.ByteEncoder(msg.Key)
+	}
+
+	return record
+}
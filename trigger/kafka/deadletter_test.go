@@ -0,0 +1,86 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"flogo/core/support/log"
+	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+type capturingHandler struct {
+	out *Output
+}
+
+func (h *capturingHandler) Name() string {
+	return "capturing"
+}
+
+func (h *capturingHandler) Settings() map[string]interface{} {
+	return nil
+}
+
+func (h *capturingHandler) Handle(ctx context.Context, triggerData interface{}) (map[string]interface{}, error) {
+	h.out = triggerData.(*Output)
+	return nil, nil
+}
+
+type failingHandler struct {
+	calls int
+}
+
+func (h *failingHandler) Name() string {
+	return "failing"
+}
+
+func (h *failingHandler) Settings() map[string]interface{} {
+	return nil
+}
+
+func (h *failingHandler) Handle(ctx context.Context, triggerData interface{}) (map[string]interface{}, error) {
+	h.calls++
+	return nil, errors.New("downstream unavailable")
+}
+
+func TestHandler_ProcessDeadLetter(t *testing.T) {
+
+	producer := mocks.NewSyncProducer(t, nil)
+	producer.ExpectSendMessageWithCheckerFunctionAndSucceed(func(value []byte) error {
+		if string(value) != "hello" {
+			return errors.New("unexpected value")
+		}
+		return nil
+	})
+
+	handler := &failingHandler{}
+	h := &Handler{logger: log.RootLogger(), shutdown: make(chan struct{}), handler: handler,
+		conn: &KafkaConnection{producer: producer}, deadLetterTopic: "events-dlq"}
+
+	err := h.process(&sarama.ConsumerMessage{Topic: "events", Value: []byte("hello")}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, handler.calls)
+
+	assert.Nil(t, producer.Close())
+}
+
+func TestHandler_HandleMessage(t *testing.T) {
+
+	handler := &capturingHandler{}
+	h := &Handler{logger: log.RootLogger(), handler: handler}
+
+	msg := &sarama.ConsumerMessage{Topic: "events", Partition: 2, Offset: 42, Key: []byte("tenant-1"), Value: []byte("hello"),
+		Timestamp: time.Unix(1500000000, 0), Headers: []*sarama.RecordHeader{{Key: []byte("trace-id"), Value: []byte("abc")}}}
+
+	_, err := h.handleMessage(msg)
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", handler.out.Message)
+	assert.Equal(t, "tenant-1", handler.out.Key)
+	assert.Equal(t, 2, handler.out.Partition)
+	assert.Equal(t, int64(42), handler.out.Offset)
+	assert.Equal(t, int64(1500000000000), handler.out.Timestamp)
+	assert.Equal(t, map[string]string{"trace-id": "abc"}, handler.out.Headers)
+}
@@ -0,0 +1,86 @@
+package kafka
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/linkedin/goavro/v2"
+)
+
+const (
+	FormatString   = "string"
+	FormatAvro     = "avro"
+	FormatProtobuf = "protobuf"
+)
+
+// valueDecoder decodes the value of the consumed messages into the content output
+type valueDecoder interface {
+	decode(value []byte) (interface{}, error)
+}
+
+func newValueDecoder(settings *HandlerSettings, registry *schemaRegistry) (valueDecoder, error) {
+
+	switch settings.ValueFormat {
+	case "", FormatString:
+		return nil, nil
+	case FormatAvro:
+		if registry == nil {
+			return nil, fmt.Errorf("a schema registry url is required to decode avro messages")
+		}
+		return &avroDecoder{registry: registry, codecs: make(map[int32]*goavro.Codec)}, nil
+	case FormatProtobuf:
+		return newProtobufDecoder(settings.ProtoDescriptorSet, settings.ProtoMessage, registry)
+	}
+
+	return nil, fmt.Errorf("unsupported value format '%s'", settings.ValueFormat)
+}
+
+// avroDecoder decodes Confluent framed Avro messages using the writer schema from the registry
+type avroDecoder struct {
+	mutex    sync.Mutex
+	registry *schemaRegistry
+	codecs   map[int32]*goavro.Codec
+}
+
+func (d *avroDecoder) decode(value []byte) (interface{}, error) {
+
+	id, payload, err := schemaID(value)
+	if err != nil {
+		return nil, err
+	}
+
+	codec, err := d.codec(id)
+	if err != nil {
+		return nil, err
+	}
+
+	native, _, err := codec.NativeFromBinary(payload)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode avro message with schema [%d]: %v", id, err)
+	}
+
+	return native, nil
+}
+
+func (d *avroDecoder) codec(id int32) (*goavro.Codec, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if codec, ok := d.codecs[id]; ok {
+		return codec, nil
+	}
+
+	schema, err := d.registry.schema(id)
+	if err != nil {
+		return nil, err
+	}
+
+	codec, err := goavro.NewCodec(schema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid avro schema [%d]: %v", id, err)
+	}
+
+	d.codecs[id] = codec
+
+	return codec, nil
+}
@@ -0,0 +1,40 @@
+package kafka
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSchema = `{"type":"record","name":"Pet","fields":[{"name":"id","type":"long"},{"name":"name","type":"string"}]}`
+
+func TestAvroDecoder(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/schemas/ids/7", r.URL.Path)
+		_, _ = w.Write([]byte(`{"schema":"{\"type\":\"record\",\"name\":\"Pet\",\"fields\":[{\"name\":\"id\",\"type\":\"long\"},{\"name\":\"name\",\"type\":\"string\"}]}"}`))
+	}))
+	defer server.Close()
+
+	decoder, err := newValueDecoder(&HandlerSettings{ValueFormat: FormatAvro}, newSchemaRegistry(&Settings{SchemaRegistryURL: server.URL}))
+	assert.Nil(t, err)
+
+	codec, err := goavro.NewCodec(testSchema)
+	assert.Nil(t, err)
+
+	msg, err := codec.BinaryFromNative([]byte{0, 0, 0, 0, 7}, map[string]interface{}{"id": int64(12), "name": "rex"})
+	assert.Nil(t, err)
+
+	content, err := decoder.decode(msg)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"id": int64(12), "name": "rex"}, content)
+
+	_, err = decoder.decode([]byte("plain"))
+	assert.NotNil(t, err)
+
+	_, err = newValueDecoder(&HandlerSettings{ValueFormat: FormatAvro}, nil)
+	assert.NotNil(t, err)
+}
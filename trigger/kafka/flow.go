@@ -0,0 +1,81 @@
+package kafka
+
+import (
+	"errors"
+	"sync"
+)
+
+var errHandlerStopped = errors.New("handler was stopped")
+
+// flowControl bounds the number of in-flight action executions of a handler, the fetching from the
+// brokers is paused while all the slots are taken so the consumed messages don't pile up in memory
+type flowControl struct {
+	slots  chan struct{}
+	pause  func()
+	resume func()
+
+	mu     sync.Mutex
+	paused bool
+}
+
+func newFlowControl(maxInFlight int, pause, resume func()) *flowControl {
+	return &flowControl{slots: make(chan struct{}, maxInFlight), pause: pause, resume: resume}
+}
+
+// acquire takes a slot, waiting for one to be released when all are taken, it returns false
+// when the handler is stopped while waiting
+func (f *flowControl) acquire(shutdown <-chan struct{}) bool {
+	select {
+	case f.slots <- struct{}{}:
+		return true
+	default:
+	}
+
+	f.mu.Lock()
+	if !f.paused {
+		f.paused = true
+		f.pause()
+	}
+	f.mu.Unlock()
+
+	select {
+	case f.slots <- struct{}{}:
+		return true
+	case <-shutdown:
+		return false
+	}
+}
+
+// release frees a slot, resuming the fetching if it was paused
+func (f *flowControl) release() {
+	<-f.slots
+
+	f.mu.Lock()
+	if f.paused {
+		f.paused = false
+		f.resume()
+	}
+	f.mu.Unlock()
+}
+
+// pauseConsumers pauses the fetching of the partitions consumed by the handler
+func (h *Handler) pauseConsumers() {
+	h.logger.Debugf("Handler [%s] reached its max in-flight executions, pausing the consumption", h.handler.Name())
+	if h.group != nil {
+		h.group.PauseAll()
+	}
+	for _, consumer := range h.consumers {
+		consumer.Pause()
+	}
+}
+
+// resumeConsumers resumes the fetching of the partitions consumed by the handler
+func (h *Handler) resumeConsumers() {
+	h.logger.Debugf("Handler [%s] resuming the consumption", h.handler.Name())
+	if h.group != nil {
+		h.group.ResumeAll()
+	}
+	for _, consumer := range h.consumers {
+		consumer.Resume()
+	}
+}
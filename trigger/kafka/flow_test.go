@@ -0,0 +1,39 @@
+package kafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlowControl(t *testing.T) {
+
+	paused, resumed := 0, 0
+	flow := newFlowControl(1, func() { paused++ }, func() { resumed++ })
+	shutdown := make(chan struct{})
+
+	assert.True(t, flow.acquire(shutdown))
+
+	acquired := make(chan bool)
+	go func() {
+		acquired <- flow.acquire(shutdown)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("slot acquired while all the slots are taken")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	flow.release()
+	assert.True(t, <-acquired)
+	assert.Equal(t, 1, paused)
+	assert.Equal(t, 1, resumed)
+
+	go func() {
+		acquired <- flow.acquire(shutdown)
+	}()
+	close(shutdown)
+	assert.False(t, <-acquired)
+}
@@ -1,7 +1,11 @@
 module github.com/qingcloudhx/contrib/trigger/kafka
 
 require (
-	github.com/Shopify/sarama v1.22.0
+	github.com/Shopify/sarama v1.38.1
 	flogo/core v0.9.0
 	github.com/stretchr/testify v1.3.0
+	github.com/xdg-go/scram v1.1.2
+	github.com/linkedin/goavro/v2 v2.9.8
+	github.com/bufbuild/protocompile v0.14.1
+	google.golang.org/protobuf v1.36.12
 )
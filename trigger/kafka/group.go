@@ -0,0 +1,244 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+const (
+	OffsetEarliest = "earliest"
+	OffsetLatest   = "latest"
+
+	CommitAuto   = "auto"
+	CommitManual = "manual"
+
+	StrategyRange             = "range"
+	StrategyRoundRobin        = "roundrobin"
+	StrategySticky            = "sticky"
+	StrategyCooperativeSticky = "cooperative-sticky"
+)
+
+// toInitialOffset converts the initial offset setting to the sarama offset
+func toInitialOffset(initialOffset string) (int64, error) {
+	switch strings.ToLower(initialOffset) {
+	case "", OffsetLatest:
+		return sarama.OffsetNewest, nil
+	case OffsetEarliest:
+		return sarama.OffsetOldest, nil
+	}
+
+	return 0, fmt.Errorf("unsupported initial offset '%s', expected earliest or latest", initialOffset)
+}
+
+// toBalanceStrategies converts the comma separated assignment strategies setting, in order of preference,
+// to the sarama balance strategies
+func toBalanceStrategies(strategies string) ([]sarama.BalanceStrategy, error) {
+	var balanceStrategies []sarama.BalanceStrategy
+	for _, strategy := range strings.Split(strategies, ",") {
+		switch strings.ToLower(strings.TrimSpace(strategy)) {
+		case "":
+		case StrategyRange:
+			balanceStrategies = append(balanceStrategies, sarama.BalanceStrategyRange)
+		case StrategyRoundRobin:
+			balanceStrategies = append(balanceStrategies, sarama.BalanceStrategyRoundRobin)
+		case StrategySticky:
+			balanceStrategies = append(balanceStrategies, sarama.BalanceStrategySticky)
+		case StrategyCooperativeSticky:
+			return nil, fmt.Errorf("assignment strategy '%s' is not supported, the incremental cooperative rebalance protocol isn't implemented by the Kafka client", strategy)
+		default:
+			return nil, fmt.Errorf("unsupported assignment strategy '%s', expected range, roundrobin or sticky", strategy)
+		}
+	}
+
+	return balanceStrategies, nil
+}
+
+// joinGroup creates the consumer group of the handler, the partitions of the topic are balanced
+// between the members of the group and the consumed offsets are committed to the group
+func (h *Handler) joinGroup(conn *KafkaConnection, settings *HandlerSettings, initialOffset int64) error {
+
+	switch settings.CommitMode {
+	case "", CommitAuto:
+	case CommitManual:
+		h.manualCommit = true
+	default:
+		return fmt.Errorf("unsupported commit mode '%s', expected auto or manual", settings.CommitMode)
+	}
+
+	// each handler has its own group, so it gets its own copy of the configuration
+	config := *conn.kafkaConfig
+	config.Consumer.Offsets.Initial = initialOffset
+
+	if settings.CommitInterval != "" {
+		interval, err := time.ParseDuration(settings.CommitInterval)
+		if err != nil {
+			return fmt.Errorf("invalid commit interval '%s': %v", settings.CommitInterval, err)
+		}
+		config.Consumer.Offsets.CommitInterval = interval
+	}
+
+	strategies, err := toBalanceStrategies(settings.AssignmentStrategy)
+	if err != nil {
+		return err
+	}
+	if len(strategies) > 0 {
+		config.Consumer.Group.Rebalance.GroupStrategies = strategies
+	}
+
+	if settings.SessionTimeout != "" {
+		timeout, err := time.ParseDuration(settings.SessionTimeout)
+		if err != nil {
+			return fmt.Errorf("invalid session timeout '%s': %v", settings.SessionTimeout, err)
+		}
+		config.Consumer.Group.Session.Timeout = timeout
+	}
+
+	if !config.Version.IsAtLeast(sarama.V0_10_2_0) {
+		// consumer groups require the v1 join group request
+		config.Version = sarama.V0_10_2_0
+	}
+
+	if settings.GroupInstanceID != "" {
+		config.Consumer.Group.InstanceId = settings.GroupInstanceID
+		if !config.Version.IsAtLeast(sarama.V2_3_0_0) {
+			// static membership requires the v5 join group request
+			config.Version = sarama.V2_3_0_0
+		}
+	}
+
+	if settings.TransactionalID != "" {
+		if err := h.newTxnProducer(conn, &config, settings.TransactionalID); err != nil {
+			return err
+		}
+	}
+
+	group, err := sarama.NewConsumerGroup(conn.brokers, settings.Group, &config)
+	if err != nil {
+		return fmt.Errorf("failed to join consumer group [%s] for reason [%s]", settings.Group, err)
+	}
+
+	h.group = group
+	h.groupID = settings.Group
+	h.topicsChanged = make(chan struct{}, 1)
+
+	return nil
+}
+
+// consumeGroup consumes the claims of the group until the handler is stopped, a new session
+// is started after every rebalance
+func (h *Handler) consumeGroup() {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-h.shutdown
+		cancel()
+	}()
+
+	if h.pattern != nil {
+		go h.watchTopics(ctx, h.topics)
+	}
+
+	for {
+		if h.pattern != nil {
+			if topics, err := h.resolveTopics(); err == nil {
+				h.topics = topics
+			}
+			if len(h.topics) == 0 {
+				// wait for a topic matching the pattern to be created
+				select {
+				case <-h.shutdown:
+					return
+				case <-h.topicsChanged:
+					continue
+				}
+			}
+		}
+
+		// the session context is cancelled to restart the session when a message must be redelivered
+		// or when the topics matching the pattern changed
+		var sessionCtx context.Context
+		sessionCtx, h.cancelSession = context.WithCancel(ctx)
+		go func(sessionCtx context.Context, cancelSession context.CancelFunc) {
+			select {
+			case <-h.topicsChanged:
+				cancelSession()
+			case <-sessionCtx.Done():
+			}
+		}(sessionCtx, h.cancelSession)
+
+		err := h.group.Consume(sessionCtx, h.topics, h)
+		h.cancelSession()
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			h.logger.Errorf("Consumer group session for handler [%s] failed for reason [%s]", h.handler.Name(), err)
+		}
+		if err != nil || atomic.CompareAndSwapInt32(&h.redeliver, 1, 0) {
+			// don't retry a failing message in a tight loop
+			select {
+			case <-h.shutdown:
+				return
+			case <-time.After(time.Second):
+			}
+		}
+	}
+}
+
+// Setup implements sarama.ConsumerGroupHandler.Setup
+func (h *Handler) Setup(session sarama.ConsumerGroupSession) error {
+	h.logger.Debugf("Consumer group session started, claims: %v", session.Claims())
+	return nil
+}
+
+// Cleanup implements sarama.ConsumerGroupHandler.Cleanup
+func (h *Handler) Cleanup(session sarama.ConsumerGroupSession) error {
+	return nil
+}
+
+// ConsumeClaim implements sarama.ConsumerGroupHandler.ConsumeClaim, with the manual commit mode the offset
+// of a message is only committed once it was handled successfully, a failure restarts the session so the
+// message is consumed again from the last committed offset
+func (h *Handler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+
+	for msg := range claim.Messages() {
+		h.stats.consumed(msg, claim.HighWaterMarkOffset())
+
+		if h.txnProducer != nil {
+			if err := h.processTxn(msg, session.Context().Done()); err != nil {
+				if err == errHandlerStopped {
+					return nil
+				}
+				h.logger.Errorf("Transaction for handler [%s] failed for reason [%s] message will be redelivered", h.handler.Name(), err)
+				atomic.StoreInt32(&h.redeliver, 1)
+				h.cancelSession()
+				return nil
+			}
+			// the offset was committed by the transaction
+			continue
+		}
+
+		err := h.process(msg, session.Context().Done())
+		if err != nil {
+			if err == errHandlerStopped {
+				// the message wasn't processed, it is consumed again by the next session
+				return nil
+			}
+			if h.manualCommit {
+				h.logger.Errorf("Run action for handler [%s] failed for reason [%s] message will be redelivered", h.handler.Name(), err)
+				atomic.StoreInt32(&h.redeliver, 1)
+				h.cancelSession()
+				return nil
+			}
+			h.logger.Errorf("Run action for handler [%s] failed for reason [%s] message lost", h.handler.Name(), err)
+		}
+		session.MarkMessage(msg, "")
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToInitialOffset(t *testing.T) {
+
+	offset, err := toInitialOffset("")
+	assert.Nil(t, err)
+	assert.Equal(t, sarama.OffsetNewest, offset)
+
+	offset, err = toInitialOffset("Earliest")
+	assert.Nil(t, err)
+	assert.Equal(t, sarama.OffsetOldest, offset)
+
+	_, err = toInitialOffset("first")
+	assert.NotNil(t, err)
+}
+
+func TestToBalanceStrategies(t *testing.T) {
+
+	strategies, err := toBalanceStrategies("")
+	assert.Nil(t, err)
+	assert.Empty(t, strategies)
+
+	strategies, err = toBalanceStrategies("sticky, RoundRobin")
+	assert.Nil(t, err)
+	assert.Equal(t, []sarama.BalanceStrategy{sarama.BalanceStrategySticky, sarama.BalanceStrategyRoundRobin}, strategies)
+
+	_, err = toBalanceStrategies("cooperative-sticky")
+	assert.NotNil(t, err)
+
+	_, err = toBalanceStrategies("random")
+	assert.NotNil(t, err)
+}
@@ -9,20 +9,84 @@ type Settings struct {
 	User       string `md:"user"`                // If connecting to a SASL enabled port, the user id to use for authentication
 	Password   string `md:"password"`            // If connecting to a SASL enabled port, the password to use for authentication
 	TrustStore string `md:"trustStore"`          // If connecting to a TLS secured port, the directory containing the certificates representing the trust chain for the connection. This is usually just the CACert used to sign the server's certificate
+
+	SASLMechanism string `md:"saslMechanism"` // The SASL mechanism to use for authentication: PLAIN (default when a user is set), SCRAM-SHA-256, SCRAM-SHA-512 or OAUTHBEARER
+	Token         string `md:"token"`         // The static access token to use with the OAUTHBEARER mechanism
+	TokenURL      string `md:"tokenUrl"`      // The OAuth2 token endpoint used to obtain access tokens with the client credentials grant for the OAUTHBEARER mechanism
+	ClientID      string `md:"clientId"`      // The OAuth2 client id used to obtain access tokens
+	ClientSecret  string `md:"clientSecret"`  // The OAuth2 client secret used to obtain access tokens
+	Scopes        string `md:"scopes"`        // The comma separated OAuth2 scopes to request
+
+	EnableTLS          bool   `md:"enableTLS"`          // Connect to the brokers using TLS, implied by the other TLS settings
+	CAFile             string `md:"caFile"`             // The PEM file of the CA certificates used to verify the brokers
+	CertFile           string `md:"certFile"`           // The PEM file of the client certificate, for brokers requiring client authentication
+	KeyFile            string `md:"keyFile"`            // The PEM file of the client private key
+	ServerName         string `md:"serverName"`         // The server name used to verify the broker certificates, overrides the broker host
+	InsecureSkipVerify bool   `md:"insecureSkipVerify"` // Don't verify the broker certificates, for testing only
+
+	SchemaRegistryURL      string `md:"schemaRegistryUrl"`      // The url of the Schema Registry used to decode avro messages
+	SchemaRegistryUser     string `md:"schemaRegistryUser"`     // The user used to authenticate with the Schema Registry
+	SchemaRegistryPassword string `md:"schemaRegistryPassword"` // The password used to authenticate with the Schema Registry
+
+	StatsInterval string `md:"statsInterval"` // How often the lag, consumption rate and errors of the handlers are reported (ex. 1m), not reported if not specified
 }
 type HandlerSettings struct {
-	Topic      string `md:"topic,required"` // The Kafka topic on which to listen for messageS
-	Partitions string `md:"partitions"`     // The specific partitions to consume messages from
-	Offset     int64  `md:"offset"`         // The offset to use when starting to consume messages, default is set to Newest
+	Topic        string `md:"topic"`        // The Kafka topic on which to listen for messageS, or a comma separated list of topics
+	TopicPattern string `md:"topicPattern"` // A regular expression, the handler listens on all the topics it matches
+	Partitions   string `md:"partitions"`   // The specific partitions to consume messages from
+	Offset       int64  `md:"offset"`       // The offset to use when starting to consume messages, default is set to Newest
+
+	Group          string `md:"group"`          // The consumer group to join, the partitions of the topic are balanced between its members and the offsets are committed
+	InitialOffset  string `md:"initialOffset"`  // The offset to start from when no offset was committed, earliest or latest (default)
+	CommitInterval string `md:"commitInterval"` // How often the consumed offsets are committed to the group (ex. 5s), defaults to 1s
+	CommitMode     string `md:"commitMode"`     // auto (default) commits all consumed messages, manual only commits messages once the action succeeded
+
+	AssignmentStrategy string `md:"assignmentStrategy"` // The comma separated partition assignment strategies of the group, in order of preference: range (default), roundrobin or sticky
+	GroupInstanceID    string `md:"groupInstanceId"`    // The static member id of this instance in the group, a restarted member gets its partitions back without a rebalance
+	SessionTimeout     string `md:"sessionTimeout"`     // How long the group waits for a missing member before rebalancing its partitions (ex. 30s), defaults to 10s
+
+	ValueFormat        string `md:"valueFormat"`        // The format of the message values, string (default), avro (Confluent framed, decoded with the Schema Registry) or protobuf
+	ProtoDescriptorSet string `md:"protoDescriptorSet"` // The file descriptor set (protoc --include_imports --descriptor_set_out) defining the protobuf message, Confluent framed messages are decoded with the Schema Registry when not specified
+	ProtoMessage       string `md:"protoMessage"`       // The fully qualified name of the protobuf message of the descriptor set
+
+	MaxRetries      int    `md:"maxRetries"`      // The number of times the action is retried when it fails
+	RetryBackoff    string `md:"retryBackoff"`    // The delay before the first retry (ex. 500ms), doubled with every retry, defaults to 1s
+	RetryMaxBackoff string `md:"retryMaxBackoff"` // The max delay between two retries, defaults to 1m
+	RetryTopics     string `md:"retryTopics"`     // The comma separated delays of the retry topics (ex. 5s,1m,10m), a message still failing is published to the next retry topic, ex. events-retry-5s
+	DeadLetterTopic string `md:"deadLetterTopic"` // The topic the messages are published to, with the error in headers, when the action still fails after the retries
+
+	MaxInFlight int `md:"maxInFlight"` // The max number of concurrent action executions of the handler, the consumption is paused while it is reached
+
+	TransactionalID string `md:"transactionalId"` // Enables the exactly-once mode with a group, the reply records and the offset of each message are committed in a transaction with this id
 }
 
 type Output struct {
-	Message string `md:"message"` // The message that was consumed
+	Message string      `md:"message"` // The message that was consumed
+	Topic   string      `md:"topic"`   // The topic the message was consumed from
+	Content interface{} `md:"content"` // The decoded message, when the handler has a value format
+
+	Key       string            `md:"key"`       // The key of the message
+	Headers   map[string]string `md:"headers"`   // The headers of the message
+	Partition int               `md:"partition"` // The partition the message was consumed from
+	Offset    int64             `md:"offset"`    // The offset of the message in its partition
+	Timestamp int64             `md:"timestamp"` // The timestamp of the message, in milliseconds since the epoch
+}
+
+type Reply struct {
+	Records []interface{} `md:"records"` // The records to publish, each record is an object with a topic, a value and optionally a key and headers
 }
 
 func (o *Output) ToMap() map[string]interface{} {
 	return map[string]interface{}{
 		"message": o.Message,
+		"topic":   o.Topic,
+		"content": o.Content,
+
+		"key":       o.Key,
+		"headers":   o.Headers,
+		"partition": o.Partition,
+		"offset":    o.Offset,
+		"timestamp": o.Timestamp,
 	}
 }
 
@@ -33,6 +97,45 @@ func (o *Output) FromMap(values map[string]interface{}) error {
 	if err != nil {
 		return err
 	}
+	o.Topic, err = coerce.ToString(values["topic"])
+	if err != nil {
+		return err
+	}
+	o.Content = values["content"]
+
+	o.Key, err = coerce.ToString(values["key"])
+	if err != nil {
+		return err
+	}
+	o.Headers, err = coerce.ToParams(values["headers"])
+	if err != nil {
+		return err
+	}
+	o.Partition, err = coerce.ToInt(values["partition"])
+	if err != nil {
+		return err
+	}
+	o.Offset, err = coerce.ToInt64(values["offset"])
+	if err != nil {
+		return err
+	}
+	o.Timestamp, err = coerce.ToInt64(values["timestamp"])
+	if err != nil {
+		return err
+	}
 
 	return nil
 }
+
+func (r *Reply) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"records": r.Records,
+	}
+}
+
+func (r *Reply) FromMap(values map[string]interface{}) error {
+
+	var err error
+	r.Records, err = coerce.ToArray(values["records"])
+	return err
+}
@@ -0,0 +1,199 @@
+package kafka
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sync"
+
+	"github.com/bufbuild/protocompile"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// the name of the registry schema compiled by the proto compiler
+const registrySchemaFile = "schema.proto"
+
+var protoJSON = protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}
+
+// newProtobufDecoder creates the protobuf decoder, using the message of the descriptor set when specified,
+// otherwise the messages are expected to be Confluent framed and their schema is fetched from the registry
+func newProtobufDecoder(descriptorSet, message string, registry *schemaRegistry) (valueDecoder, error) {
+
+	if descriptorSet == "" {
+		if registry == nil {
+			return nil, fmt.Errorf("a descriptor set or a schema registry url is required to decode protobuf messages")
+		}
+		return &protobufDecoder{registry: registry, files: make(map[int32]protoreflect.FileDescriptor)}, nil
+	}
+
+	if message == "" {
+		return nil, fmt.Errorf("the protobuf message of the descriptor set [%s] is required", descriptorSet)
+	}
+
+	data, err := ioutil.ReadFile(descriptorSet)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read descriptor set [%s]: %v", descriptorSet, err)
+	}
+
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(data, set); err != nil {
+		return nil, fmt.Errorf("invalid descriptor set [%s]: %v", descriptorSet, err)
+	}
+
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("invalid descriptor set [%s]: %v", descriptorSet, err)
+	}
+
+	desc, err := files.FindDescriptorByName(protoreflect.FullName(message))
+	if err != nil {
+		return nil, fmt.Errorf("message [%s] not found in descriptor set [%s]", message, descriptorSet)
+	}
+	msgDesc, ok := desc.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("[%s] of descriptor set [%s] is not a message", message, descriptorSet)
+	}
+
+	return &protobufDecoder{message: msgDesc}, nil
+}
+
+// protobufDecoder decodes protobuf messages into maps, with the field names of the proto definition
+type protobufDecoder struct {
+	message protoreflect.MessageDescriptor
+
+	mutex    sync.Mutex
+	registry *schemaRegistry
+	files    map[int32]protoreflect.FileDescriptor
+}
+
+func (d *protobufDecoder) decode(value []byte) (interface{}, error) {
+
+	desc := d.message
+	if desc == nil {
+		id, payload, err := schemaID(value)
+		if err != nil {
+			return nil, err
+		}
+
+		file, err := d.file(id)
+		if err != nil {
+			return nil, err
+		}
+
+		desc, value, err = messageIndexes(file, payload)
+		if err != nil {
+			return nil, fmt.Errorf("invalid protobuf message with schema [%d]: %v", id, err)
+		}
+	}
+
+	msg := dynamicpb.NewMessage(desc)
+	if err := proto.Unmarshal(value, msg); err != nil {
+		return nil, fmt.Errorf("unable to decode protobuf message [%s]: %v", desc.FullName(), err)
+	}
+
+	data, err := protoJSON.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	var content map[string]interface{}
+	if err := json.Unmarshal(data, &content); err != nil {
+		return nil, err
+	}
+
+	return content, nil
+}
+
+// file returns the compiled registry schema with the specified id
+func (d *protobufDecoder) file(id int32) (protoreflect.FileDescriptor, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if file, ok := d.files[id]; ok {
+		return file, nil
+	}
+
+	schema, err := d.registry.lookup(id)
+	if err != nil {
+		return nil, err
+	}
+
+	sources := map[string]string{registrySchemaFile: schema.Schema}
+	if err := d.addReferences(sources, schema); err != nil {
+		return nil, err
+	}
+
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{
+			Accessor: protocompile.SourceAccessorFromMap(sources),
+		}),
+	}
+	files, err := compiler.Compile(context.Background(), registrySchemaFile)
+	if err != nil {
+		return nil, fmt.Errorf("invalid protobuf schema [%d]: %v", id, err)
+	}
+
+	d.files[id] = files[0]
+
+	return files[0], nil
+}
+
+// addReferences adds the sources of the schemas imported by a schema, recursively
+func (d *protobufDecoder) addReferences(sources map[string]string, schema *registrySchema) error {
+	for _, ref := range schema.References {
+		if _, ok := sources[ref.Name]; ok {
+			continue
+		}
+		imported, err := d.registry.subjectVersion(ref.Subject, ref.Version)
+		if err != nil {
+			return err
+		}
+		sources[ref.Name] = imported.Schema
+		if err := d.addReferences(sources, imported); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// messageIndexes reads the message indexes following the schema id of a Confluent framed protobuf message,
+// they locate the message type in the schema, and returns its descriptor and the payload
+func messageIndexes(file protoreflect.FileDescriptor, payload []byte) (protoreflect.MessageDescriptor, []byte, error) {
+
+	count, n := binary.Varint(payload)
+	if n <= 0 || count < 0 {
+		return nil, nil, fmt.Errorf("invalid message indexes")
+	}
+	payload = payload[n:]
+
+	indexes := []int64{0}
+	if count > 0 {
+		indexes = make([]int64, count)
+		for i := range indexes {
+			indexes[i], n = binary.Varint(payload)
+			if n <= 0 {
+				return nil, nil, fmt.Errorf("invalid message indexes")
+			}
+			payload = payload[n:]
+		}
+	}
+
+	messages := file.Messages()
+	var desc protoreflect.MessageDescriptor
+	for _, index := range indexes {
+		if index < 0 || int(index) >= messages.Len() {
+			return nil, nil, fmt.Errorf("message index %d out of range", index)
+		}
+		desc = messages.Get(int(index))
+		messages = desc.Messages()
+	}
+
+	return desc, payload, nil
+}
@@ -0,0 +1,92 @@
+package kafka
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func testPetFile() *descriptorpb.FileDescriptorProto {
+	return &descriptorpb.FileDescriptorProto{
+		Name:    proto.String("pet.proto"),
+		Package: proto.String("pets"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Pet"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				{Name: proto.String("id"), JsonName: proto.String("id"), Number: proto.Int32(1),
+					Type: descriptorpb.FieldDescriptorProto_TYPE_INT32.Enum(), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()},
+				{Name: proto.String("name"), JsonName: proto.String("name"), Number: proto.Int32(2),
+					Type: descriptorpb.FieldDescriptorProto_TYPE_STRING.Enum(), Label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()},
+			},
+		}},
+	}
+}
+
+func testPet(t *testing.T) []byte {
+	file, err := protodesc.NewFile(testPetFile(), nil)
+	assert.Nil(t, err)
+
+	desc := file.Messages().Get(0)
+	msg := dynamicpb.NewMessage(desc)
+	msg.Set(desc.Fields().ByName("id"), protoreflect.ValueOfInt32(12))
+	msg.Set(desc.Fields().ByName("name"), protoreflect.ValueOfString("rex"))
+
+	data, err := proto.Marshal(msg)
+	assert.Nil(t, err)
+	return data
+}
+
+func TestProtobufDecoder(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "proto")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	set, err := proto.Marshal(&descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{testPetFile()}})
+	assert.Nil(t, err)
+	descriptorSet := filepath.Join(dir, "pets.pb")
+	assert.Nil(t, ioutil.WriteFile(descriptorSet, set, 0644))
+
+	decoder, err := newValueDecoder(&HandlerSettings{ValueFormat: FormatProtobuf, ProtoDescriptorSet: descriptorSet, ProtoMessage: "pets.Pet"}, nil)
+	assert.Nil(t, err)
+
+	content, err := decoder.decode(testPet(t))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"id": float64(12), "name": "rex"}, content)
+
+	_, err = newValueDecoder(&HandlerSettings{ValueFormat: FormatProtobuf, ProtoDescriptorSet: descriptorSet, ProtoMessage: "pets.Cat"}, nil)
+	assert.NotNil(t, err)
+
+	_, err = newValueDecoder(&HandlerSettings{ValueFormat: FormatProtobuf}, nil)
+	assert.NotNil(t, err)
+}
+
+func TestProtobufDecoder_Registry(t *testing.T) {
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/schemas/ids/9", r.URL.Path)
+		_, _ = w.Write([]byte(`{"schemaType":"PROTOBUF","schema":"syntax = \"proto3\";\npackage pets;\nmessage Pet {\n  int32 id = 1;\n  string name = 2;\n}"}`))
+	}))
+	defer server.Close()
+
+	decoder, err := newValueDecoder(&HandlerSettings{ValueFormat: FormatProtobuf}, newSchemaRegistry(&Settings{SchemaRegistryURL: server.URL}))
+	assert.Nil(t, err)
+
+	// magic byte, schema id and the message indexes of the first message
+	msg := append([]byte{0, 0, 0, 0, 9, 0}, testPet(t)...)
+
+	content, err := decoder.decode(msg)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"id": float64(12), "name": "rex"}, content)
+}
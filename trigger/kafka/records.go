@@ -0,0 +1,83 @@
+package kafka
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"flogo/core/data/coerce"
+	"github.com/Shopify/sarama"
+)
+
+// toRecords converts the records of the reply of the action to producer messages, the values
+// that aren't a string are encoded in JSON
+func toRecords(results map[string]interface{}) ([]*sarama.ProducerMessage, error) {
+
+	if results == nil || results["records"] == nil {
+		return nil, nil
+	}
+
+	reply := &Reply{}
+	if err := reply.FromMap(results); err != nil {
+		return nil, err
+	}
+
+	records := make([]*sarama.ProducerMessage, 0, len(reply.Records))
+	for i, r := range reply.Records {
+		values, err := coerce.ToObject(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid record %d: %v", i, err)
+		}
+
+		record := &sarama.ProducerMessage{}
+		record.Topic, _ = coerce.ToString(values["topic"])
+		if record.Topic == "" {
+			return nil, fmt.Errorf("invalid record %d: no topic", i)
+		}
+
+		if key, _ := coerce.ToString(values["key"]); key != "" {
+			record.Key = sarama.StringEncoder(key)
+		}
+
+		switch value := values["value"].(type) {
+		case nil:
+		case string:
+			record.Value = sarama.StringEncoder(value)
+		case []byte:
+			record.Value = sarama.ByteEncoder(value)
+		default:
+			data, err := json.Marshal(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid record %d: %v", i, err)
+			}
+			record.Value = sarama.ByteEncoder(data)
+		}
+
+		headers, err := coerce.ToParams(values["headers"])
+		if err != nil {
+			return nil, fmt.Errorf("invalid record %d headers: %v", i, err)
+		}
+		for name, value := range headers {
+			record.Headers = append(record.Headers, sarama.RecordHeader{Key: []byte(name), Value: []byte(value)})
+		}
+
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// publish publishes the records of the reply of the action
+func (h *Handler) publish(results map[string]interface{}) error {
+
+	records, err := toRecords(results)
+	if err != nil || len(records) == 0 {
+		return err
+	}
+
+	producer, err := h.conn.Producer()
+	if err != nil {
+		return err
+	}
+
+	return producer.SendMessages(records)
+}
@@ -0,0 +1,122 @@
+package kafka
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// the Confluent wire format prefixes the payload with a magic byte and the 4 bytes schema id
+const confluentHeaderSize = 5
+
+// schemaRegistry is a Schema Registry client caching the schemas by id
+type schemaRegistry struct {
+	mutex    sync.Mutex
+	url      string
+	user     string
+	password string
+	client   *http.Client
+	schemas  map[int32]*registrySchema
+}
+
+func newSchemaRegistry(settings *Settings) *schemaRegistry {
+	if settings.SchemaRegistryURL == "" {
+		return nil
+	}
+
+	return &schemaRegistry{
+		url:      strings.TrimSuffix(settings.SchemaRegistryURL, "/"),
+		user:     settings.SchemaRegistryUser,
+		password: settings.SchemaRegistryPassword,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		schemas:  make(map[int32]*registrySchema),
+	}
+}
+
+// schemaID splits a Confluent framed message into the schema id and the payload
+func schemaID(msg []byte) (int32, []byte, error) {
+	if len(msg) < confluentHeaderSize || msg[0] != 0 {
+		return 0, nil, fmt.Errorf("message is not in the Confluent wire format")
+	}
+	return int32(binary.BigEndian.Uint32(msg[1:confluentHeaderSize])), msg[confluentHeaderSize:], nil
+}
+
+// schema returns the schema with the specified id
+func (r *schemaRegistry) schema(id int32) (string, error) {
+	s, err := r.lookup(id)
+	if err != nil {
+		return "", err
+	}
+	return s.Schema, nil
+}
+
+// registrySchema is a schema of the registry, with the references to the schemas it imports
+type registrySchema struct {
+	Schema     string `json:"schema"`
+	References []struct {
+		Name    string `json:"name"`
+		Subject string `json:"subject"`
+		Version int    `json:"version"`
+	} `json:"references"`
+}
+
+// lookup returns the schema with the specified id
+func (r *schemaRegistry) lookup(id int32) (*registrySchema, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if s, ok := r.schemas[id]; ok {
+		return s, nil
+	}
+
+	s, err := r.fetch(fmt.Sprintf("/schemas/ids/%d", id))
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch schema [%d] from registry: %v", id, err)
+	}
+	r.schemas[id] = s
+
+	return s, nil
+}
+
+// subjectVersion returns the schema registered under the subject with the specified version
+func (r *schemaRegistry) subjectVersion(subject string, version int) (*registrySchema, error) {
+	s, err := r.fetch(fmt.Sprintf("/subjects/%s/versions/%d", url.PathEscape(subject), version))
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch schema [%s] version [%d] from registry: %v", subject, version, err)
+	}
+	return s, nil
+}
+
+func (r *schemaRegistry) fetch(path string) (*registrySchema, error) {
+
+	req, err := http.NewRequest(http.MethodGet, r.url+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.schemaregistry.v1+json")
+	if r.user != "" {
+		req.SetBasicAuth(r.user, r.password)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status: %d", resp.StatusCode)
+	}
+
+	s := &registrySchema{}
+	if err := json.NewDecoder(resp.Body).Decode(s); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
@@ -0,0 +1,115 @@
+package kafka
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Shopify/sarama"
+)
+
+const (
+	defaultRetryBackoff    = time.Second
+	defaultRetryMaxBackoff = time.Minute
+)
+
+// parseRetryTopics parses the comma separated delays of the retry topics, a retry topic is
+// named after the consumed topic and its delay, ex. events-retry-5s
+func parseRetryTopics(retryTopics string) ([]string, []time.Duration, error) {
+	var suffixes []string
+	var delays []time.Duration
+	for _, d := range strings.Split(retryTopics, ",") {
+		if d = strings.TrimSpace(d); d == "" {
+			continue
+		}
+		delay, err := time.ParseDuration(d)
+		if err != nil || delay <= 0 {
+			return nil, nil, fmt.Errorf("invalid retry topic delay '%s'", d)
+		}
+		suffixes = append(suffixes, "-retry-"+d)
+		delays = append(delays, delay)
+	}
+	return suffixes, delays, nil
+}
+
+// parseDuration parses a duration setting, returning the default when it isn't set
+func parseDuration(name, value string, defaultValue time.Duration) (time.Duration, error) {
+	if value == "" {
+		return defaultValue, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid %s '%s'", name, value)
+	}
+	return d, nil
+}
+
+// backoff returns the delay before the retry following the attempt, doubling with every attempt up to the max backoff
+func (h *Handler) backoff(attempt int) time.Duration {
+	delay := h.retryBackoff
+	for i := 0; i < attempt && delay < h.retryMaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > h.retryMaxBackoff {
+		delay = h.retryMaxBackoff
+	}
+	return delay
+}
+
+// retryStage returns the index of the retry topic a topic is, -1 if it isn't a retry topic, and the consumed topic it retries
+func (h *Handler) retryStage(topic string) (int, string) {
+	for i, suffix := range h.retrySuffixes {
+		if strings.HasSuffix(topic, suffix) {
+			return i, strings.TrimSuffix(topic, suffix)
+		}
+	}
+	return -1, topic
+}
+
+// nextRetryTopic returns the retry topic the messages failing on a topic are published to, empty after the last retry topic
+func (h *Handler) nextRetryTopic(topic string) string {
+	stage, original := h.retryStage(topic)
+	if stage+1 >= len(h.retrySuffixes) {
+		return ""
+	}
+	return original + h.retrySuffixes[stage+1]
+}
+
+// withRetryTopics adds the retry topics of the topics
+func (h *Handler) withRetryTopics(topics []string) []string {
+	all := append([]string(nil), topics...)
+	for _, topic := range topics {
+		if stage, _ := h.retryStage(topic); stage < 0 {
+			for _, suffix := range h.retrySuffixes {
+				all = append(all, topic+suffix)
+			}
+		}
+	}
+	return matchTopics(all, nil, nil)
+}
+
+// awaitRetry waits until the retry delay of a message consumed from a retry topic elapsed, it returns false
+// when the handler is stopped or done is closed while waiting
+func (h *Handler) awaitRetry(msg *sarama.ConsumerMessage, done <-chan struct{}) bool {
+	stage, _ := h.retryStage(msg.Topic)
+	if stage < 0 {
+		return true
+	}
+
+	wait := time.Until(msg.Timestamp.Add(h.retryDelays[stage]))
+	if wait <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-h.shutdown:
+		return false
+	case <-done:
+		return false
+	}
+}
@@ -0,0 +1,58 @@
+package kafka
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryTopics(t *testing.T) {
+
+	suffixes, delays, err := parseRetryTopics("5s, 1m")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"-retry-5s", "-retry-1m"}, suffixes)
+	assert.Equal(t, []time.Duration{5 * time.Second, time.Minute}, delays)
+
+	_, _, err = parseRetryTopics("5 seconds")
+	assert.NotNil(t, err)
+
+	h := &Handler{handler: &failingHandler{}, retrySuffixes: suffixes, retryDelays: delays, deadLetterTopic: "events-dlq"}
+
+	assert.Equal(t, []string{"events", "events-retry-1m", "events-retry-5s"}, h.withRetryTopics([]string{"events"}))
+	assert.Equal(t, "events-retry-5s", h.nextRetryTopic("events"))
+	assert.Equal(t, "events-retry-1m", h.nextRetryTopic("events-retry-5s"))
+	assert.Equal(t, "", h.nextRetryTopic("events-retry-1m"))
+
+	msg := &sarama.ConsumerMessage{Topic: "events", Partition: 1, Offset: 7, Value: []byte("hello")}
+	record := h.failureRecord(msg, errors.New("timeout"))
+	assert.Equal(t, "events-retry-5s", record.Topic)
+
+	// the origin of the message is kept through the retry topics
+	retried := &sarama.ConsumerMessage{Topic: "events-retry-1m", Partition: 0, Offset: 3, Value: []byte("hello")}
+	for i := range record.Headers {
+		retried.Headers = append(retried.Headers, &record.Headers[i])
+	}
+	record = h.failureRecord(retried, errors.New("timeout"))
+	assert.Equal(t, "events-dlq", record.Topic)
+
+	headers := make(map[string]string)
+	for _, header := range record.Headers {
+		headers[string(header.Key)] = string(header.Value)
+	}
+	assert.Len(t, record.Headers, 6)
+	assert.Equal(t, "events", headers[headerOriginalTopic])
+	assert.Equal(t, "1", headers[headerOriginalPartition])
+	assert.Equal(t, "7", headers[headerOriginalOffset])
+}
+
+func TestBackoff(t *testing.T) {
+
+	h := &Handler{retryBackoff: time.Second, retryMaxBackoff: 5 * time.Second}
+	assert.Equal(t, time.Second, h.backoff(0))
+	assert.Equal(t, 4*time.Second, h.backoff(2))
+	assert.Equal(t, 5*time.Second, h.backoff(3))
+	assert.Equal(t, 5*time.Second, h.backoff(100))
+}
@@ -0,0 +1,175 @@
+package kafka
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Shopify/sarama"
+	"github.com/xdg-go/scram"
+)
+
+const (
+	SASLPlain       = "PLAIN"
+	SASLScramSHA256 = "SCRAM-SHA-256"
+	SASLScramSHA512 = "SCRAM-SHA-512"
+	SASLOAuthBearer = "OAUTHBEARER"
+
+	// tokens are refreshed this long before they expire
+	tokenExpiryMargin = 30 * time.Second
+)
+
+// configureSASL configures the SASL authentication of the connection according to the settings
+func configureSASL(config *sarama.Config, settings *Settings) error {
+
+	mechanism := strings.ToUpper(settings.SASLMechanism)
+	if mechanism == "" {
+		if settings.User == "" {
+			return nil
+		}
+		mechanism = SASLPlain
+	}
+
+	config.Net.SASL.Enable = true
+
+	switch mechanism {
+	case SASLPlain, SASLScramSHA256, SASLScramSHA512:
+		if settings.User == "" || settings.Password == "" {
+			return fmt.Errorf("user and password are required for SASL mechanism %s", mechanism)
+		}
+		config.Net.SASL.User = settings.User
+		config.Net.SASL.Password = settings.Password
+
+		if mechanism == SASLScramSHA256 {
+			config.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return &scramClient{hashGenerator: sha256.New} }
+		} else if mechanism == SASLScramSHA512 {
+			config.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient { return &scramClient{hashGenerator: sha512.New} }
+		}
+	case SASLOAuthBearer:
+		provider, err := newTokenProvider(settings)
+		if err != nil {
+			return err
+		}
+		config.Net.SASL.TokenProvider = provider
+	default:
+		return fmt.Errorf("unsupported SASL mechanism '%s'", settings.SASLMechanism)
+	}
+
+	config.Net.SASL.Mechanism = sarama.SASLMechanism(mechanism)
+
+	if mechanism != SASLPlain && !config.Version.IsAtLeast(sarama.V1_0_0_0) {
+		// SCRAM and OAUTHBEARER require the v1 SASL handshake
+		config.Version = sarama.V1_0_0_0
+	}
+
+	return nil
+}
+
+// scramClient implements the SCRAM exchange of sarama
+type scramClient struct {
+	hashGenerator scram.HashGeneratorFcn
+	conversation  *scram.ClientConversation
+}
+
+func (c *scramClient) Begin(userName, password, authzID string) error {
+	client, err := c.hashGenerator.NewClient(userName, password, authzID)
+	if err != nil {
+		return err
+	}
+	c.conversation = client.NewConversation()
+	return nil
+}
+
+func (c *scramClient) Step(challenge string) (string, error) {
+	return c.conversation.Step(challenge)
+}
+
+func (c *scramClient) Done() bool {
+	return c.conversation.Done()
+}
+
+// tokenProvider provides the OAUTHBEARER access tokens, either a static token or tokens
+// obtained from an OAuth2 token endpoint with the client credentials grant
+type tokenProvider struct {
+	mutex        sync.Mutex
+	token        string
+	expires      time.Time
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	scopes       string
+	client       *http.Client
+}
+
+func newTokenProvider(settings *Settings) (*tokenProvider, error) {
+
+	if settings.Token != "" {
+		return &tokenProvider{token: settings.Token}, nil
+	}
+
+	if settings.TokenURL == "" || settings.ClientID == "" {
+		return nil, fmt.Errorf("either a token or a token url and client id are required for SASL mechanism %s", SASLOAuthBearer)
+	}
+
+	return &tokenProvider{
+		tokenURL:     settings.TokenURL,
+		clientID:     settings.ClientID,
+		clientSecret: settings.ClientSecret,
+		scopes:       settings.Scopes,
+		client:       &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Token implements sarama.AccessTokenProvider.Token
+func (p *tokenProvider) Token() (*sarama.AccessToken, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.tokenURL == "" || (p.token != "" && time.Now().Before(p.expires)) {
+		return &sarama.AccessToken{Token: p.token}, nil
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if p.scopes != "" {
+		form.Set("scope", strings.Join(strings.FieldsFunc(p.scopes, func(r rune) bool { return r == ',' || r == ' ' }), " "))
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(p.clientID), url.QueryEscape(p.clientSecret))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to obtain token from [%s], status: %d", p.tokenURL, resp.StatusCode)
+	}
+
+	var tokenResp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
+		return nil, err
+	}
+	if tokenResp.AccessToken == "" {
+		return nil, fmt.Errorf("no access token in response from [%s]", p.tokenURL)
+	}
+
+	p.token = tokenResp.AccessToken
+	p.expires = time.Now().Add(time.Duration(tokenResp.ExpiresIn)*time.Second - tokenExpiryMargin)
+
+	return &sarama.AccessToken{Token: p.token}, nil
+}
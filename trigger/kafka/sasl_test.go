@@ -0,0 +1,53 @@
+package kafka
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigureSASL(t *testing.T) {
+
+	config := sarama.NewConfig()
+	err := configureSASL(config, &Settings{User: "user", Password: "secret", SASLMechanism: "scram-sha-512"})
+	assert.Nil(t, err)
+	assert.True(t, config.Net.SASL.Enable)
+	assert.Equal(t, sarama.SASLMechanism(SASLScramSHA512), config.Net.SASL.Mechanism)
+	assert.NotNil(t, config.Net.SASL.SCRAMClientGeneratorFunc)
+	assert.Nil(t, config.Validate())
+
+	config = sarama.NewConfig()
+	err = configureSASL(config, &Settings{})
+	assert.Nil(t, err)
+	assert.False(t, config.Net.SASL.Enable)
+
+	err = configureSASL(sarama.NewConfig(), &Settings{SASLMechanism: SASLScramSHA256})
+	assert.NotNil(t, err)
+}
+
+func TestTokenProvider(t *testing.T) {
+
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		id, secret, _ := r.BasicAuth()
+		assert.Equal(t, "client", id)
+		assert.Equal(t, "secret", secret)
+		assert.Equal(t, "client_credentials", r.FormValue("grant_type"))
+		_, _ = w.Write([]byte(`{"access_token":"abc","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	p, err := newTokenProvider(&Settings{TokenURL: server.URL, ClientID: "client", ClientSecret: "secret"})
+	assert.Nil(t, err)
+
+	token, err := p.Token()
+	assert.Nil(t, err)
+	assert.Equal(t, "abc", token.Token)
+
+	_, _ = p.Token()
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,143 @@
+package kafka
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"flogo/core/engine/event"
+	"github.com/Shopify/sarama"
+)
+
+// StatsEventType is the type of the engine events reporting the consumer stats of the handlers
+const StatsEventType = "kafkaConsumerStats"
+
+// ConsumerStats are the consumer stats of a handler since the previous report
+type ConsumerStats struct {
+	Handler    string           `json:"handler"`
+	Consumed   int64            `json:"consumed"`
+	Errors     int64            `json:"errors"`
+	Rate       float64          `json:"rate"`
+	Lag        int64            `json:"lag"`
+	Partitions []PartitionStats `json:"partitions"`
+}
+
+// PartitionStats are the consumer stats of a partition, the lag is the number of messages
+// between the last consumed message and the end of the partition
+type PartitionStats struct {
+	Topic         string `json:"topic"`
+	Partition     int32  `json:"partition"`
+	Offset        int64  `json:"offset"`
+	HighWaterMark int64  `json:"highWaterMark"`
+	Lag           int64  `json:"lag"`
+}
+
+type topicPartition struct {
+	topic     string
+	partition int32
+}
+
+// handlerStats collects the consumer stats of a handler
+type handlerStats struct {
+	mutex      sync.Mutex
+	messages   int64
+	errors     int64
+	since      time.Time
+	partitions map[topicPartition]*PartitionStats
+}
+
+func newHandlerStats() *handlerStats {
+	return &handlerStats{since: time.Now(), partitions: make(map[topicPartition]*PartitionStats)}
+}
+
+// consumed records a consumed message and the high water mark of its partition
+func (s *handlerStats) consumed(msg *sarama.ConsumerMessage, highWaterMark int64) {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.messages++
+
+	key := topicPartition{topic: msg.Topic, partition: msg.Partition}
+	p, ok := s.partitions[key]
+	if !ok {
+		p = &PartitionStats{Topic: msg.Topic, Partition: msg.Partition}
+		s.partitions[key] = p
+	}
+	p.Offset = msg.Offset
+	p.HighWaterMark = highWaterMark
+}
+
+// failed records a failed action execution
+func (s *handlerStats) failed() {
+	if s == nil {
+		return
+	}
+	s.mutex.Lock()
+	s.errors++
+	s.mutex.Unlock()
+}
+
+// report returns the stats since the previous report and resets the counters
+func (s *handlerStats) report(handler string) *ConsumerStats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	stats := &ConsumerStats{Handler: handler, Consumed: s.messages, Errors: s.errors}
+	if elapsed := now.Sub(s.since).Seconds(); elapsed > 0 {
+		stats.Rate = float64(s.messages) / elapsed
+	}
+
+	for _, p := range s.partitions {
+		partition := *p
+		partition.Lag = partition.HighWaterMark - partition.Offset - 1
+		if partition.Lag < 0 {
+			partition.Lag = 0
+		}
+		stats.Lag += partition.Lag
+		stats.Partitions = append(stats.Partitions, partition)
+	}
+	sort.Slice(stats.Partitions, func(i, j int) bool {
+		a, b := stats.Partitions[i], stats.Partitions[j]
+		return a.Topic < b.Topic || (a.Topic == b.Topic && a.Partition < b.Partition)
+	})
+
+	s.messages, s.errors, s.since = 0, 0, now
+
+	return stats
+}
+
+// String formats the stats for the log
+func (s *ConsumerStats) String() string {
+	lags := make([]string, len(s.Partitions))
+	for i, p := range s.Partitions {
+		lags[i] = fmt.Sprintf("%s/%d=%d", p.Topic, p.Partition, p.Lag)
+	}
+	return fmt.Sprintf("Handler [%s] consumed %d messages (%.1f/s) with %d errors, lag %d [%s]",
+		s.Handler, s.Consumed, s.Rate, s.Errors, s.Lag, strings.Join(lags, " "))
+}
+
+// reportStats periodically logs the consumer stats of the handlers and posts them as engine events
+func (t *Trigger) reportStats(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.shutdown:
+			return
+		case <-ticker.C:
+		}
+
+		for _, h := range t.kafkaHandlers {
+			stats := h.stats.report(h.handler.Name())
+			t.logger.Info(stats.String())
+			event.Post(StatsEventType, stats)
+		}
+	}
+}
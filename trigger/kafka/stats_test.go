@@ -0,0 +1,35 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandlerStats(t *testing.T) {
+
+	var none *handlerStats
+	none.consumed(&sarama.ConsumerMessage{}, 0)
+	none.failed()
+
+	s := newHandlerStats()
+	s.consumed(&sarama.ConsumerMessage{Topic: "events", Partition: 1, Offset: 10}, 15)
+	s.consumed(&sarama.ConsumerMessage{Topic: "events", Partition: 0, Offset: 41}, 42)
+	s.consumed(&sarama.ConsumerMessage{Topic: "events", Partition: 1, Offset: 11}, 20)
+	s.failed()
+
+	stats := s.report("orders")
+	assert.Equal(t, int64(3), stats.Consumed)
+	assert.Equal(t, int64(1), stats.Errors)
+	assert.Equal(t, int64(8), stats.Lag)
+	assert.Equal(t, []PartitionStats{
+		{Topic: "events", Partition: 0, Offset: 41, HighWaterMark: 42, Lag: 0},
+		{Topic: "events", Partition: 1, Offset: 11, HighWaterMark: 20, Lag: 8},
+	}, stats.Partitions)
+	assert.Contains(t, stats.String(), "events/1=8")
+
+	stats = s.report("orders")
+	assert.Equal(t, int64(0), stats.Consumed)
+	assert.Equal(t, int64(8), stats.Lag)
+}
@@ -0,0 +1,30 @@
+package kafka
+
+import (
+	"testing"
+
+	"flogo/core/support/log"
+	"github.com/Shopify/sarama"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigureTLS(t *testing.T) {
+
+	config := sarama.NewConfig()
+	err := configureTLS(log.RootLogger(), config, &Settings{})
+	assert.Nil(t, err)
+	assert.False(t, config.Net.TLS.Enable)
+
+	config = sarama.NewConfig()
+	err = configureTLS(log.RootLogger(), config, &Settings{EnableTLS: true, ServerName: "kafka.internal"})
+	assert.Nil(t, err)
+	assert.True(t, config.Net.TLS.Enable)
+	assert.Equal(t, "kafka.internal", config.Net.TLS.Config.ServerName)
+	assert.False(t, config.Net.TLS.Config.InsecureSkipVerify)
+
+	err = configureTLS(log.RootLogger(), sarama.NewConfig(), &Settings{CertFile: "client.pem"})
+	assert.NotNil(t, err)
+
+	err = configureTLS(log.RootLogger(), sarama.NewConfig(), &Settings{CAFile: "missing.pem"})
+	assert.NotNil(t, err)
+}
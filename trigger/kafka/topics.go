@@ -0,0 +1,101 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// topicRefreshInterval is how often the topics matching the pattern of a group handler are checked
+var topicRefreshInterval = time.Minute
+
+// splitTopics splits a comma separated list of topics
+func splitTopics(topic string) []string {
+	var topics []string
+	for _, t := range strings.Split(topic, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			topics = append(topics, t)
+		}
+	}
+	return topics
+}
+
+// compileTopicPattern compiles the topic pattern, it has to match the whole topic name
+func compileTopicPattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid topic pattern '%s': %v", pattern, err)
+	}
+	return re, nil
+}
+
+// matchTopics returns the sorted list of the topics and the available topics matching the pattern,
+// the internal topics are never matched
+func matchTopics(topics []string, pattern *regexp.Regexp, available []string) []string {
+	seen := make(map[string]bool)
+	var matched []string
+	for _, t := range topics {
+		if !seen[t] {
+			seen[t] = true
+			matched = append(matched, t)
+		}
+	}
+	if pattern != nil {
+		for _, t := range available {
+			if !seen[t] && !strings.HasPrefix(t, "__") && pattern.MatchString(t) {
+				seen[t] = true
+				matched = append(matched, t)
+			}
+		}
+	}
+	sort.Strings(matched)
+	return matched
+}
+
+// resolveTopics returns the topics the handler subscribes to, including their retry topics
+func (h *Handler) resolveTopics() ([]string, error) {
+	var available []string
+	if h.pattern != nil {
+		var err error
+		available, err = h.conn.Connection().Topics()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list Kafka topics for reason [%s]", err)
+		}
+	}
+	return h.withRetryTopics(matchTopics(h.staticTopics, h.pattern, available)), nil
+}
+
+// watchTopics signals the group session to restart when the topics matching the pattern change
+func (h *Handler) watchTopics(ctx context.Context, current []string) {
+	ticker := time.NewTicker(topicRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		topics, err := h.resolveTopics()
+		if err != nil {
+			h.logger.Warnf("Refreshing the topics of handler [%s] failed: %v", h.handler.Name(), err)
+			continue
+		}
+		if !reflect.DeepEqual(topics, current) {
+			h.logger.Infof("Topics of handler [%s] changed to %v", h.handler.Name(), topics)
+			current = topics
+			select {
+			case h.topicsChanged <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchTopics(t *testing.T) {
+
+	assert.Equal(t, []string{"events", "audit"}, splitTopics("events, audit,"))
+
+	pattern, err := compileTopicPattern(`events\..*`)
+	assert.Nil(t, err)
+
+	available := []string{"events.orders", "events.users", "my.events.orders", "__consumer_offsets", "audit"}
+	topics := matchTopics([]string{"audit"}, pattern, available)
+	assert.Equal(t, []string{"audit", "events.orders", "events.users"}, topics)
+
+	topics = matchTopics(splitTopics("events,audit"), nil, available)
+	assert.Equal(t, []string{"audit", "events"}, topics)
+
+	_, err = compileTopicPattern("events(")
+	assert.NotNil(t, err)
+}
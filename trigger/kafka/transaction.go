@@ -0,0 +1,97 @@
+package kafka
+
+import (
+	"fmt"
+
+	"github.com/Shopify/sarama"
+)
+
+// newTxnProducer creates the transactional producer of a handler in the exactly-once mode, the consumer
+// of the group only reads the committed messages and its offsets are committed by the transactions
+func (h *Handler) newTxnProducer(conn *KafkaConnection, config *sarama.Config, transactionalID string) error {
+
+	config.Consumer.IsolationLevel = sarama.ReadCommitted
+	config.Consumer.Offsets.AutoCommit.Enable = false
+
+	producerConfig := *config
+	producerConfig.Producer.Return.Successes = true
+	producerConfig.Producer.RequiredAcks = sarama.WaitForAll
+	producerConfig.Producer.Idempotent = true
+	producerConfig.Producer.Transaction.ID = transactionalID
+	producerConfig.Net.MaxOpenRequests = 1
+
+	producer, err := sarama.NewSyncProducer(conn.brokers, &producerConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create Kafka transactional producer [%s] for reason [%s]", transactionalID, err)
+	}
+	h.txnProducer = producer
+
+	return nil
+}
+
+// processTxn runs the action for a message and commits the records of its reply and the offset of the
+// message atomically in a transaction. If the action still fails after the retries and the handler has
+// a retry or dead letter topic, the message is published to it in the transaction instead
+func (h *Handler) processTxn(msg *sarama.ConsumerMessage, done <-chan struct{}) error {
+
+	if !h.awaitRetry(msg, done) {
+		return errHandlerStopped
+	}
+
+	if h.flow != nil {
+		if !h.flow.acquire(h.shutdown) {
+			return errHandlerStopped
+		}
+		defer h.flow.release()
+	}
+
+	results, err := h.run(msg)
+
+	var records []*sarama.ProducerMessage
+	if err != nil {
+		record := h.failureRecord(msg, err)
+		if record == nil {
+			return err
+		}
+		h.logger.Warnf("Run action for handler [%s] failed for reason [%s], message published to topic [%s]", h.handler.Name(), err, record.Topic)
+		records = append(records, record)
+	} else {
+		records, err = toRecords(results)
+		if err != nil {
+			return err
+		}
+	}
+
+	// the partitions of the group are consumed concurrently but the producer runs one transaction at a time,
+	// the actions run outside of it and only their records and offsets are committed in turn
+	h.txnMu.Lock()
+	defer h.txnMu.Unlock()
+
+	if err := h.txnProducer.BeginTxn(); err != nil {
+		return err
+	}
+
+	if len(records) > 0 {
+		if err := h.txnProducer.SendMessages(records); err != nil {
+			return h.abortTxn(err)
+		}
+	}
+
+	if err := h.txnProducer.AddMessageToTxn(msg, h.groupID, nil); err != nil {
+		return h.abortTxn(err)
+	}
+
+	if err := h.txnProducer.CommitTxn(); err != nil {
+		return h.abortTxn(err)
+	}
+
+	return nil
+}
+
+// abortTxn aborts the current transaction after a failure
+func (h *Handler) abortTxn(err error) error {
+	if abortErr := h.txnProducer.AbortTxn(); abortErr != nil {
+		return fmt.Errorf("%s, and aborting the transaction failed for reason [%s]", err, abortErr)
+	}
+	return err
+}
@@ -0,0 +1,133 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"flogo/core/support/log"
+	"github.com/Shopify/sarama"
+	"github.com/Shopify/sarama/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+type replyingHandler struct {
+	results map[string]interface{}
+}
+
+func (h *replyingHandler) Name() string {
+	return "replying"
+}
+
+func (h *replyingHandler) Settings() map[string]interface{} {
+	return nil
+}
+
+// blockingHandler replies once all the messages it waits for are handled concurrently
+type blockingHandler struct {
+	replyingHandler
+	arrived chan struct{}
+	count   int
+}
+
+func (h *blockingHandler) Handle(ctx context.Context, triggerData interface{}) (map[string]interface{}, error) {
+	h.arrived <- struct{}{}
+	for len(h.arrived) < h.count {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Millisecond):
+		}
+	}
+	return h.results, nil
+}
+
+func (h *replyingHandler) Handle(ctx context.Context, triggerData interface{}) (map[string]interface{}, error) {
+	return h.results, nil
+}
+
+func TestToRecords(t *testing.T) {
+
+	records, err := toRecords(nil)
+	assert.Nil(t, err)
+	assert.Empty(t, records)
+
+	records, err = toRecords(map[string]interface{}{"records": []interface{}{
+		map[string]interface{}{"topic": "orders", "key": "42", "value": "created", "headers": map[string]interface{}{"trace-id": "abc"}},
+		map[string]interface{}{"topic": "audit", "value": map[string]interface{}{"id": 42}},
+	}})
+	assert.Nil(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, sarama.StringEncoder("42"), records[0].Key)
+	assert.Equal(t, sarama.StringEncoder("created"), records[0].Value)
+	assert.Equal(t, []sarama.RecordHeader{{Key: []byte("trace-id"), Value: []byte("abc")}}, records[0].Headers)
+	assert.Equal(t, sarama.ByteEncoder(`{"id":42}`), records[1].Value)
+
+	_, err = toRecords(map[string]interface{}{"records": []interface{}{map[string]interface{}{"value": "no topic"}}})
+	assert.NotNil(t, err)
+}
+
+func TestHandler_ProcessTxn(t *testing.T) {
+
+	config := mocks.NewTestConfig()
+	config.Producer.Transaction.ID = "orders"
+	config.Producer.Idempotent = true
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Net.MaxOpenRequests = 1
+	config.Version = sarama.V0_11_0_0
+	producer := mocks.NewSyncProducer(t, config)
+	producer.ExpectSendMessageAndSucceed()
+
+	handler := &replyingHandler{results: map[string]interface{}{"records": []interface{}{
+		map[string]interface{}{"topic": "orders", "value": "created"},
+	}}}
+	h := &Handler{logger: log.RootLogger(), shutdown: make(chan struct{}), handler: handler, txnProducer: producer, groupID: "flows"}
+
+	err := h.processTxn(&sarama.ConsumerMessage{Topic: "events", Value: []byte("hello")}, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, sarama.ProducerTxnFlagReady, producer.TxnStatus())
+
+	// without a dead letter topic a failure isn't committed
+	h.handler = &failingHandler{}
+	err = h.processTxn(&sarama.ConsumerMessage{Topic: "events", Value: []byte("hello")}, nil)
+	assert.Equal(t, errors.New("downstream unavailable"), err)
+
+	assert.Nil(t, producer.Close())
+}
+
+func TestHandler_ProcessTxn_Concurrent(t *testing.T) {
+
+	config := mocks.NewTestConfig()
+	config.Producer.Transaction.ID = "orders"
+	config.Producer.Idempotent = true
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Net.MaxOpenRequests = 1
+	config.Version = sarama.V0_11_0_0
+	producer := mocks.NewSyncProducer(t, config)
+	producer.ExpectSendMessageAndSucceed()
+	producer.ExpectSendMessageAndSucceed()
+
+	// the actions of the messages of different partitions run concurrently, only their transactions are serialized
+	handler := &blockingHandler{replyingHandler: replyingHandler{results: map[string]interface{}{"records": []interface{}{
+		map[string]interface{}{"topic": "orders", "value": "created"},
+	}}}, arrived: make(chan struct{}, 2), count: 2}
+	h := &Handler{logger: log.RootLogger(), shutdown: make(chan struct{}), handler: handler, txnProducer: producer, groupID: "flows"}
+
+	errs := make(chan error, 2)
+	for partition := int32(0); partition < 2; partition++ {
+		go func(partition int32) {
+			errs <- h.processTxn(&sarama.ConsumerMessage{Topic: "events", Partition: partition, Value: []byte("hello")}, nil)
+		}(partition)
+	}
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-errs:
+			assert.Nil(t, err)
+		case <-time.After(time.Second):
+			t.Fatal("the actions didn't run concurrently")
+		}
+	}
+
+	assert.Nil(t, producer.Close())
+}
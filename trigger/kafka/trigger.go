@@ -3,8 +3,10 @@ package kafka
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Shopify/sarama"
@@ -13,7 +15,7 @@ import (
 	"flogo/core/trigger"
 )
 
-var triggerMd = trigger.NewMetadata(&Settings{}, &HandlerSettings{}, &Output{})
+var triggerMd = trigger.NewMetadata(&Settings{}, &HandlerSettings{}, &Output{}, &Reply{})
 
 func init() {
 	_ = trigger.Register(&Trigger{}, &Factory{})
@@ -44,19 +46,35 @@ type Trigger struct {
 	settings      *Settings
 	conn          *KafkaConnection
 	kafkaHandlers []*Handler
+	logger        log.Logger
+	statsInterval time.Duration
+	shutdown      chan struct{}
 }
 
 // Initialize initializes the trigger
 func (t *Trigger) Initialize(ctx trigger.InitContext) error {
 
 	var err error
+	t.logger = ctx.Logger()
+	t.statsInterval, err = parseDuration("stats interval", t.settings.StatsInterval, 0)
+	if err != nil {
+		return err
+	}
+
 	t.conn, err = getKafkaConnection(ctx.Logger(), t.settings)
 
+	if err != nil {
+		return err
+	}
+
 	for _, handler := range ctx.GetHandlers() {
-		kafkaHandler, err := NewKafkaHandler(ctx.Logger(), handler, t.conn.Connection())
+		kafkaHandler, err := NewKafkaHandler(ctx.Logger(), handler, t.conn)
 		if err != nil {
 			return err
 		}
+		if t.statsInterval > 0 {
+			kafkaHandler.stats = newHandlerStats()
+		}
 		t.kafkaHandlers = append(t.kafkaHandlers, kafkaHandler)
 	}
 
@@ -70,12 +88,21 @@ func (t *Trigger) Start() error {
 		_ = handler.Start()
 	}
 
+	t.shutdown = make(chan struct{})
+	if t.statsInterval > 0 {
+		go t.reportStats(t.statsInterval)
+	}
+
 	return nil
 }
 
 // Stop implements ext.Trigger.Stop
 func (t *Trigger) Stop() error {
 
+	if t.shutdown != nil {
+		close(t.shutdown)
+	}
+
 	for _, handler := range t.kafkaHandlers {
 		_ = handler.Stop()
 	}
@@ -85,9 +112,9 @@ func (t *Trigger) Stop() error {
 }
 
 // NewKafkaHandler creates a new kafka handler to handle a topic
-func NewKafkaHandler(logger log.Logger, handler trigger.Handler, consumer sarama.Consumer) (*Handler, error) {
+func NewKafkaHandler(logger log.Logger, handler trigger.Handler, conn *KafkaConnection) (*Handler, error) {
 
-	kafkaHandler := &Handler{logger: logger, shutdown: make(chan struct{}), handler: handler}
+	kafkaHandler := &Handler{logger: logger, shutdown: make(chan struct{}), handler: handler, conn: conn}
 
 	handlerSetting := &HandlerSettings{}
 	err := metadata.MapToStruct(handler.Settings(), handlerSetting, true)
@@ -95,55 +122,113 @@ func NewKafkaHandler(logger log.Logger, handler trigger.Handler, consumer sarama
 		return nil, err
 	}
 
-	if handlerSetting.Topic == "" {
+	kafkaHandler.staticTopics = splitTopics(handlerSetting.Topic)
+	kafkaHandler.pattern, err = compileTopicPattern(handlerSetting.TopicPattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(kafkaHandler.staticTopics) == 0 && kafkaHandler.pattern == nil {
 		return nil, fmt.Errorf("topic string was not provided for handler: [%s]", handler)
 	}
 
-	logger.Debugf("Subscribing to topic [%s]", handlerSetting.Topic)
+	kafkaHandler.retrySuffixes, kafkaHandler.retryDelays, err = parseRetryTopics(handlerSetting.RetryTopics)
+	if err != nil {
+		return nil, err
+	}
+	kafkaHandler.retryBackoff, err = parseDuration("retry backoff", handlerSetting.RetryBackoff, defaultRetryBackoff)
+	if err != nil {
+		return nil, err
+	}
+	kafkaHandler.retryMaxBackoff, err = parseDuration("retry max backoff", handlerSetting.RetryMaxBackoff, defaultRetryMaxBackoff)
+	if err != nil {
+		return nil, err
+	}
+
+	kafkaHandler.topics, err = kafkaHandler.resolveTopics()
+	if err != nil {
+		return nil, err
+	}
+	if len(kafkaHandler.topics) == 0 {
+		logger.Warnf("No topic matches the pattern [%s] of handler [%s]", handlerSetting.TopicPattern, handler)
+	}
 
-	offset := sarama.OffsetNewest
+	logger.Debugf("Subscribing to topics %v", kafkaHandler.topics)
 
-	//offset
-	if handlerSetting.Offset != 0 {
-		offset = handlerSetting.Offset
+	kafkaHandler.maxRetries = handlerSetting.MaxRetries
+	kafkaHandler.deadLetterTopic = handlerSetting.DeadLetterTopic
+
+	if handlerSetting.MaxInFlight < 0 {
+		return nil, fmt.Errorf("invalid max in-flight '%d' for handler: [%s]", handlerSetting.MaxInFlight, handler)
+	}
+	if handlerSetting.MaxInFlight > 0 {
+		kafkaHandler.flow = newFlowControl(handlerSetting.MaxInFlight, kafkaHandler.pauseConsumers, kafkaHandler.resumeConsumers)
 	}
 
-	var partitions []int32
+	kafkaHandler.decoder, err = newValueDecoder(handlerSetting, conn.registry)
+	if err != nil {
+		return nil, err
+	}
 
-	validPartitions, err := consumer.Partitions(handlerSetting.Topic)
+	initialOffset, err := toInitialOffset(handlerSetting.InitialOffset)
 	if err != nil {
 		return nil, err
 	}
-	logger.Debugf("Valid partitions for topic [%s] detected as: [%v]", handlerSetting.Topic, validPartitions)
 
-	if handlerSetting.Partitions != "" {
-		parts := strings.Split(handlerSetting.Partitions, ",")
-		for _, p := range parts {
-			n, err := strconv.Atoi(p)
-			if err == nil {
-				for _, validPartition := range validPartitions {
-					if int32(n) == validPartition {
-						partitions = append(partitions, int32(n))
-						break
+	if handlerSetting.TransactionalID != "" && handlerSetting.Group == "" {
+		return nil, fmt.Errorf("the exactly-once mode requires a group for handler: [%s]", handler)
+	}
+
+	if handlerSetting.Group != "" {
+		return kafkaHandler, kafkaHandler.joinGroup(conn, handlerSetting, initialOffset)
+	}
+
+	consumer := conn.Connection()
+
+	offset := initialOffset
+
+	//offset
+	if handlerSetting.Offset != 0 {
+		offset = handlerSetting.Offset
+	}
+
+	for _, topic := range kafkaHandler.topics {
+		var partitions []int32
+
+		validPartitions, err := consumer.Partitions(topic)
+		if err != nil {
+			return nil, err
+		}
+		logger.Debugf("Valid partitions for topic [%s] detected as: [%v]", topic, validPartitions)
+
+		if handlerSetting.Partitions != "" {
+			parts := strings.Split(handlerSetting.Partitions, ",")
+			for _, p := range parts {
+				n, err := strconv.Atoi(p)
+				if err == nil {
+					for _, validPartition := range validPartitions {
+						if int32(n) == validPartition {
+							partitions = append(partitions, int32(n))
+							break
+						}
+						logger.Errorf("Configured partition [%d] on topic [%s] does not exist and will not be subscribed", n, topic)
 					}
-					logger.Errorf("Configured partition [%d] on topic [%s] does not exist and will not be subscribed", n, handlerSetting.Topic)
+				} else {
+					logger.Warnf("Partition [%s] specified for handler [%s] is not a valid number and was discarded", p, handler)
 				}
-			} else {
-				logger.Warnf("Partition [%s] specified for handler [%s] is not a valid number and was discarded", p, handler)
 			}
+		} else {
+			partitions = validPartitions
 		}
-	} else {
-		partitions = validPartitions
-	}
 
-	for _, partition := range partitions {
-		logger.Debugf("Creating PartitionConsumer for partition: [%s:%d]", handlerSetting.Topic, partition)
-		partitionConsumer, err := consumer.ConsumePartition(handlerSetting.Topic, partition, offset)
-		if err != nil {
-			logger.Errorf("Creating PartitionConsumer for valid partition: [%s:%d] failed for reason: %s", handlerSetting.Topic, partition, err)
-			return nil, err
+		for _, partition := range partitions {
+			logger.Debugf("Creating PartitionConsumer for partition: [%s:%d]", topic, partition)
+			partitionConsumer, err := consumer.ConsumePartition(topic, partition, offset)
+			if err != nil {
+				logger.Errorf("Creating PartitionConsumer for valid partition: [%s:%d] failed for reason: %s", topic, partition, err)
+				return nil, err
+			}
+			kafkaHandler.consumers = append(kafkaHandler.consumers, partitionConsumer)
 		}
-		kafkaHandler.consumers = append(kafkaHandler.consumers, partitionConsumer)
 	}
 
 	return kafkaHandler, nil
@@ -155,6 +240,32 @@ type Handler struct {
 	logger    log.Logger
 	handler   trigger.Handler
 	consumers []sarama.PartitionConsumer
+	decoder   valueDecoder
+	conn      *KafkaConnection
+
+	maxRetries      int
+	retryBackoff    time.Duration
+	retryMaxBackoff time.Duration
+	retrySuffixes   []string
+	retryDelays     []time.Duration
+	deadLetterTopic string
+	flow            *flowControl
+
+	topics       []string
+	staticTopics []string
+	pattern      *regexp.Regexp
+
+	group         sarama.ConsumerGroup
+	groupID       string
+	topicsChanged chan struct{}
+	manualCommit  bool
+	cancelSession context.CancelFunc
+	redeliver     int32
+
+	txnProducer sarama.SyncProducer
+	txnMu       sync.Mutex
+
+	stats *handlerStats
 }
 
 func (h *Handler) consumePartition(consumer sarama.PartitionConsumer) {
@@ -170,17 +281,8 @@ func (h *Handler) consumePartition(consumer sarama.PartitionConsumer) {
 			return
 		case msg := <-consumer.Messages():
 
-			if h.logger.DebugEnabled() {
-				h.logger.Debugf("Kafka subscriber triggering action from topic [%s] on partition [%d] with key [%s] at offset [%d]",
-					msg.Topic, msg.Partition, msg.Key, msg.Offset)
-
-				h.logger.Debugf("Kafka message: '%s'", string(msg.Value))
-			}
-
-			out := &Output{}
-			out.Message = string(msg.Value)
-
-			_, err := h.handler.Handle(context.Background(), out)
+			h.stats.consumed(msg, consumer.HighWaterMarkOffset())
+			err := h.process(msg, nil)
 			if err != nil {
 				h.logger.Errorf("Run action for handler [%s] failed for reason [%s] message lost", h.handler.Name(), err)
 			}
@@ -188,6 +290,48 @@ func (h *Handler) consumePartition(consumer sarama.PartitionConsumer) {
 	}
 }
 
+// handleMessage runs the action for a consumed message
+func (h *Handler) handleMessage(msg *sarama.ConsumerMessage) (map[string]interface{}, error) {
+
+	if h.logger.DebugEnabled() {
+		h.logger.Debugf("Kafka subscriber triggering action from topic [%s] on partition [%d] with key [%s] at offset [%d]",
+			msg.Topic, msg.Partition, msg.Key, msg.Offset)
+
+		h.logger.Debugf("Kafka message: '%s'", string(msg.Value))
+	}
+
+	out := &Output{}
+	out.Message = string(msg.Value)
+	_, out.Topic = h.retryStage(msg.Topic)
+	out.Key = string(msg.Key)
+	out.Partition = int(msg.Partition)
+	out.Offset = msg.Offset
+	if !msg.Timestamp.IsZero() {
+		out.Timestamp = msg.Timestamp.UnixNano() / int64(time.Millisecond)
+	}
+	if len(msg.Headers) > 0 {
+		out.Headers = make(map[string]string, len(msg.Headers))
+		for _, header := range msg.Headers {
+			out.Headers[string(header.Key)] = string(header.Value)
+		}
+	}
+
+	if h.decoder != nil {
+		content, err := h.decoder.decode(msg.Value)
+		if err != nil {
+			h.stats.failed()
+			return nil, err
+		}
+		out.Content = content
+	}
+
+	results, err := h.handler.Handle(context.Background(), out)
+	if err != nil {
+		h.stats.failed()
+	}
+	return results, err
+}
+
 // Start starts the handler
 func (h *Handler) Start() error {
 
@@ -195,6 +339,10 @@ func (h *Handler) Start() error {
 		go h.consumePartition(consumer)
 	}
 
+	if h.group != nil {
+		go h.consumeGroup()
+	}
+
 	return nil
 }
 
@@ -206,5 +354,13 @@ func (h *Handler) Stop() error {
 	for _, consumer := range h.consumers {
 		_ = consumer.Close()
 	}
+
+	if h.group != nil {
+		_ = h.group.Close()
+	}
+
+	if h.txnProducer != nil {
+		_ = h.txnProducer.Close()
+	}
 	return nil
 }
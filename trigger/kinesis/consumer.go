@@ -0,0 +1,441 @@
+package kinesis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+)
+
+const (
+	defaultConsumerName = "flogo"
+	defaultBatchSize    = 10000
+	defaultPollInterval = time.Second
+	defaultRetryDelay   = 5 * time.Second
+	syncInterval        = 30 * time.Second
+)
+
+// client is the part of the Kinesis client used by a consumer
+type client interface {
+	ListShards(ctx context.Context, params *kinesis.ListShardsInput, optFns ...func(*kinesis.Options)) (*kinesis.ListShardsOutput, error)
+	GetShardIterator(ctx context.Context, params *kinesis.GetShardIteratorInput, optFns ...func(*kinesis.Options)) (*kinesis.GetShardIteratorOutput, error)
+	GetRecords(ctx context.Context, params *kinesis.GetRecordsInput, optFns ...func(*kinesis.Options)) (*kinesis.GetRecordsOutput, error)
+	DescribeStreamSummary(ctx context.Context, params *kinesis.DescribeStreamSummaryInput, optFns ...func(*kinesis.Options)) (*kinesis.DescribeStreamSummaryOutput, error)
+	RegisterStreamConsumer(ctx context.Context, params *kinesis.RegisterStreamConsumerInput, optFns ...func(*kinesis.Options)) (*kinesis.RegisterStreamConsumerOutput, error)
+	DescribeStreamConsumer(ctx context.Context, params *kinesis.DescribeStreamConsumerInput, optFns ...func(*kinesis.Options)) (*kinesis.DescribeStreamConsumerOutput, error)
+	SubscribeToShard(ctx context.Context, params *kinesis.SubscribeToShardInput, optFns ...func(*kinesis.Options)) (*kinesis.SubscribeToShardOutput, error)
+}
+
+// consumer reads the shards of the stream of a handler: it leases the shards which aren't leased by
+// another instance, reads a shard once its parents were read to their end, and checkpoints the
+// sequence number of the records whose action succeeded
+type consumer struct {
+	client   client
+	store    CheckpointStore
+	handler  trigger.Handler
+	settings *HandlerSettings
+	logger   log.Logger
+
+	owner         string
+	leaseDuration time.Duration
+	pollInterval  time.Duration
+	retryDelay    time.Duration
+	consumerARN   string
+
+	mu      sync.Mutex
+	running map[string]bool
+	shards  sync.WaitGroup
+	done    chan struct{}
+}
+
+func newConsumer(client client, store CheckpointStore, handler trigger.Handler, s *HandlerSettings, owner string, leaseDuration time.Duration, logger log.Logger) (*consumer, error) {
+
+	if s.ConsumerName == "" {
+		s.ConsumerName = defaultConsumerName
+	}
+	if s.StartPosition == "" {
+		s.StartPosition = StartLatest
+	}
+	if s.StartPosition != StartLatest && s.StartPosition != StartTrimHorizon {
+		return nil, fmt.Errorf("invalid start position '%s'", s.StartPosition)
+	}
+	if s.BatchSize == 0 {
+		s.BatchSize = defaultBatchSize
+	}
+	if s.BatchSize < 1 || s.BatchSize > 10000 {
+		return nil, fmt.Errorf("batch size must be between 1 and 10000")
+	}
+
+	c := &consumer{client: client, store: store, handler: handler, settings: s, logger: logger, owner: owner,
+		leaseDuration: leaseDuration, pollInterval: defaultPollInterval, retryDelay: defaultRetryDelay, running: make(map[string]bool)}
+
+	var err error
+	if s.PollInterval != "" {
+		c.pollInterval, err = time.ParseDuration(s.PollInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid poll interval '%s': %v", s.PollInterval, err)
+		}
+	}
+	if s.RetryDelay != "" {
+		c.retryDelay, err = time.ParseDuration(s.RetryDelay)
+		if err != nil {
+			return nil, fmt.Errorf("invalid retry delay '%s': %v", s.RetryDelay, err)
+		}
+	}
+
+	return c, nil
+}
+
+// run synchronizes the shards of the stream until the context is done, the records being handled are completed
+func (c *consumer) run(ctx context.Context) {
+
+	defer close(c.done)
+	defer c.shards.Wait()
+
+	s := c.settings
+	c.logger.Infof("Consuming stream '%s' as '%s'", s.StreamName, s.ConsumerName)
+
+	for {
+		err := c.sync(ctx)
+		if err != nil && ctx.Err() == nil {
+			c.logger.Errorf("Error synchronizing shards of stream '%s': %v", s.StreamName, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(syncInterval):
+		}
+	}
+}
+
+// wait waits for the consumer to stop, at most for the timeout
+func (c *consumer) wait(timeout time.Duration) {
+
+	if c.done == nil {
+		return
+	}
+
+	select {
+	case <-c.done:
+	case <-time.After(timeout):
+		c.logger.Warnf("Records of stream '%s' still being handled", c.settings.StreamName)
+	}
+}
+
+// sync starts reading the shards which can be leased and whose parents were read to their end
+func (c *consumer) sync(ctx context.Context) error {
+
+	s := c.settings
+
+	if s.EnhancedFanOut && c.consumerARN == "" {
+		arn, err := c.register(ctx)
+		if err != nil {
+			return err
+		}
+		c.consumerARN = arn
+	}
+
+	shards, err := c.listShards(ctx)
+	if err != nil {
+		return err
+	}
+
+	ids := make(map[string]bool, len(shards))
+	for _, shard := range shards {
+		ids[aws.ToString(shard.ShardId)] = true
+	}
+
+	for _, shard := range shards {
+		id := aws.ToString(shard.ShardId)
+
+		c.mu.Lock()
+		running := c.running[id]
+		c.mu.Unlock()
+		if running {
+			continue
+		}
+
+		// the records of a key are read in order when the parents of a shard are read first
+		ready, err := c.parentsEnded(ids, shard.ParentShardId, shard.AdjacentParentShardId)
+		if err != nil {
+			return err
+		}
+		if !ready {
+			continue
+		}
+
+		key := c.key(id)
+		checkpoint, acquired, err := c.store.Acquire(key, c.owner, c.leaseDuration)
+		if err != nil {
+			return fmt.Errorf("unable to lease shard '%s': %v", id, err)
+		}
+		if !acquired {
+			continue
+		}
+		if checkpoint == ShardEnd {
+			_ = c.store.Release(key, c.owner)
+			continue
+		}
+
+		c.mu.Lock()
+		c.running[id] = true
+		c.mu.Unlock()
+
+		c.shards.Add(1)
+		go func(id, checkpoint string) {
+			defer c.shards.Done()
+			c.read(ctx, id, checkpoint)
+
+			c.mu.Lock()
+			delete(c.running, id)
+			c.mu.Unlock()
+		}(id, checkpoint)
+	}
+
+	return nil
+}
+
+func (c *consumer) parentsEnded(ids map[string]bool, parents ...*string) (bool, error) {
+
+	for _, parent := range parents {
+		id := aws.ToString(parent)
+		// a parent which isn't listed anymore expired with its records
+		if id == "" || !ids[id] {
+			continue
+		}
+
+		checkpoint, err := c.store.Get(c.key(id))
+		if err != nil {
+			return false, err
+		}
+		if checkpoint != ShardEnd {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func (c *consumer) listShards(ctx context.Context) ([]types.Shard, error) {
+
+	var shards []types.Shard
+
+	input := &kinesis.ListShardsInput{StreamName: aws.String(c.settings.StreamName)}
+	for {
+		output, err := c.client.ListShards(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		shards = append(shards, output.Shards...)
+
+		if output.NextToken == nil {
+			break
+		}
+		input = &kinesis.ListShardsInput{NextToken: output.NextToken}
+	}
+
+	sort.Slice(shards, func(i, j int) bool {
+		return aws.ToString(shards[i].ShardId) < aws.ToString(shards[j].ShardId)
+	})
+
+	return shards, nil
+}
+
+// register registers the enhanced fan-out consumer of the stream, when it isn't yet, and waits for it to be active
+func (c *consumer) register(ctx context.Context) (string, error) {
+
+	s := c.settings
+
+	summary, err := c.client.DescribeStreamSummary(ctx, &kinesis.DescribeStreamSummaryInput{StreamName: aws.String(s.StreamName)})
+	if err != nil {
+		return "", err
+	}
+	streamARN := summary.StreamDescriptionSummary.StreamARN
+
+	_, err = c.client.RegisterStreamConsumer(ctx, &kinesis.RegisterStreamConsumerInput{StreamARN: streamARN, ConsumerName: aws.String(s.ConsumerName)})
+	if err != nil {
+		var inUse *types.ResourceInUseException
+		if !errors.As(err, &inUse) {
+			return "", fmt.Errorf("unable to register consumer '%s': %v", s.ConsumerName, err)
+		}
+	}
+
+	for {
+		output, err := c.client.DescribeStreamConsumer(ctx, &kinesis.DescribeStreamConsumerInput{StreamARN: streamARN, ConsumerName: aws.String(s.ConsumerName)})
+		if err != nil {
+			return "", err
+		}
+		if output.ConsumerDescription.ConsumerStatus == types.ConsumerStatusActive {
+			return aws.ToString(output.ConsumerDescription.ConsumerARN), nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// read handles the records of a shard until the shard ends, the context is done or its lease is lost
+func (c *consumer) read(ctx context.Context, id, checkpoint string) {
+
+	s := c.settings
+	key := c.key(id)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	c.logger.Debugf("Reading shard '%s' of stream '%s' after '%s'", id, s.StreamName, checkpoint)
+
+	var mu sync.Mutex
+	current := checkpoint
+
+	// the lease is renewed while the records are handled, it is lost when another instance took it over
+	renewal := make(chan struct{})
+	go func() {
+		defer close(renewal)
+		ticker := time.NewTicker(c.leaseDuration / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				mu.Lock()
+				err := c.store.Checkpoint(key, c.owner, current, c.leaseDuration)
+				mu.Unlock()
+				if err != nil {
+					c.logger.Warnf("Lease of shard '%s' of stream '%s' lost: %v", id, s.StreamName, err)
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	defer func() {
+		cancel()
+		<-renewal
+		err := c.store.Release(key, c.owner)
+		if err != nil {
+			c.logger.Errorf("Error releasing lease of shard '%s': %v", id, err)
+		}
+	}()
+
+	f := c.newFetcher(id)
+	defer f.close()
+	f.reset(checkpoint, false)
+
+	saved := checkpoint
+	for ctx.Err() == nil {
+		records, end, err := f.fetch(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			c.logger.Errorf("Error reading shard '%s' of stream '%s': %v", id, s.StreamName, err)
+			c.sleep(ctx, c.retryDelay)
+			continue
+		}
+
+		failed := ""
+		for _, record := range records {
+			_, err := c.handler.Handle(context.Background(), toOutput(s.StreamName, id, record))
+			if err != nil {
+				failed = aws.ToString(record.SequenceNumber)
+				c.logger.Errorf("Error handling record '%s' of shard '%s': %v", failed, id, err)
+				break
+			}
+			mu.Lock()
+			current = aws.ToString(record.SequenceNumber)
+			mu.Unlock()
+		}
+
+		mu.Lock()
+		if failed == "" && end {
+			current = ShardEnd
+		}
+		if current != saved {
+			err = c.store.Checkpoint(key, c.owner, current, c.leaseDuration)
+			saved = current
+		}
+		mu.Unlock()
+		if err != nil {
+			c.logger.Errorf("Error checkpointing shard '%s' of stream '%s': %v", id, s.StreamName, err)
+			return
+		}
+
+		if failed != "" {
+			// the failed record is read again after the delay
+			f.reset(failed, true)
+			c.sleep(ctx, c.retryDelay)
+			continue
+		}
+		if end {
+			c.logger.Debugf("Shard '%s' of stream '%s' ended", id, s.StreamName)
+			return
+		}
+	}
+}
+
+func (c *consumer) newFetcher(id string) fetcher {
+
+	s := c.settings
+
+	start := types.ShardIteratorTypeLatest
+	if s.StartPosition == StartTrimHorizon {
+		start = types.ShardIteratorTypeTrimHorizon
+	}
+
+	if s.EnhancedFanOut {
+		return &fanOutFetcher{client: c.client, consumerARN: c.consumerARN, shardID: id, start: start}
+	}
+
+	return &pollingFetcher{client: c.client, stream: s.StreamName, shardID: id, start: start, limit: int32(s.BatchSize), interval: c.pollInterval}
+}
+
+// key is the key of the checkpoint and lease of a shard in the store
+func (c *consumer) key(shardID string) string {
+	return c.settings.ConsumerName + "/" + c.settings.StreamName + "/" + shardID
+}
+
+func (c *consumer) sleep(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
+
+func toOutput(stream, shardID string, record types.Record) *Output {
+
+	out := &Output{
+		StreamName:     stream,
+		ShardID:        shardID,
+		PartitionKey:   aws.ToString(record.PartitionKey),
+		SequenceNumber: aws.ToString(record.SequenceNumber),
+		Data:           string(record.Data),
+	}
+
+	if record.ApproximateArrivalTimestamp != nil {
+		out.ArrivalTime = record.ApproximateArrivalTimestamp.UnixNano() / int64(time.Millisecond)
+	}
+
+	var content interface{}
+	if json.Unmarshal(record.Data, &content) == nil {
+		out.Content = content
+	}
+
+	return out
+}
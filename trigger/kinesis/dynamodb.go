@@ -0,0 +1,223 @@
+package kinesis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+const (
+	leaseKeyAttribute    = "leaseKey"
+	ownerAttribute       = "leaseOwner"
+	expiresAttribute     = "leaseExpires"
+	checkpointAttribute  = "checkpoint"
+	dynamoDBTimeout      = 10 * time.Second
+	tableCreationTimeout = 2 * time.Minute
+)
+
+// dynamoDBClient is the part of the DynamoDB client used by a dynamoDBStore
+type dynamoDBClient interface {
+	dynamodb.DescribeTableAPIClient
+	CreateTable(ctx context.Context, params *dynamodb.CreateTableInput, optFns ...func(*dynamodb.Options)) (*dynamodb.CreateTableOutput, error)
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	UpdateItem(ctx context.Context, params *dynamodb.UpdateItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.UpdateItemOutput, error)
+}
+
+// dynamoDBStore stores the checkpoints and leases in a DynamoDB table, which is shared by the instances
+// of the app, a lease is taken over with a conditional write once it expired
+type dynamoDBStore struct {
+	client dynamoDBClient
+	table  string
+}
+
+// newDynamoDBStore creates the store of dynamodb://table?endpoint=url, the table is created when it doesn't exist
+func newDynamoDBStore(u *url.URL, awsConfig aws.Config) (CheckpointStore, error) {
+
+	table := u.Host
+	if table == "" {
+		return nil, fmt.Errorf("missing table of checkpoint store '%s'", u)
+	}
+
+	endpoint := u.Query().Get("endpoint")
+	client := dynamodb.NewFromConfig(awsConfig, func(o *dynamodb.Options) {
+		if endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+		}
+	})
+
+	s := &dynamoDBStore{client: client, table: table}
+
+	err := s.createTable()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+func (s *dynamoDBStore) createTable() error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), tableCreationTimeout)
+	defer cancel()
+
+	_, err := s.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(s.table)})
+	if err == nil {
+		return nil
+	}
+	var notFound *types.ResourceNotFoundException
+	if !errors.As(err, &notFound) {
+		return fmt.Errorf("unable to describe checkpoint table '%s': %v", s.table, err)
+	}
+
+	_, err = s.client.CreateTable(ctx, &dynamodb.CreateTableInput{
+		TableName:            aws.String(s.table),
+		AttributeDefinitions: []types.AttributeDefinition{{AttributeName: aws.String(leaseKeyAttribute), AttributeType: types.ScalarAttributeTypeS}},
+		KeySchema:            []types.KeySchemaElement{{AttributeName: aws.String(leaseKeyAttribute), KeyType: types.KeyTypeHash}},
+		BillingMode:          types.BillingModePayPerRequest,
+	})
+	if err != nil {
+		var inUse *types.ResourceInUseException
+		if !errors.As(err, &inUse) {
+			return fmt.Errorf("unable to create checkpoint table '%s': %v", s.table, err)
+		}
+	}
+
+	err = dynamodb.NewTableExistsWaiter(s.client).Wait(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(s.table)}, tableCreationTimeout)
+	if err != nil {
+		return fmt.Errorf("checkpoint table '%s' not created: %v", s.table, err)
+	}
+
+	return nil
+}
+
+func (s *dynamoDBStore) Acquire(key, owner string, duration time.Duration) (string, bool, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), dynamoDBTimeout)
+	defer cancel()
+
+	now := time.Now()
+	output, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(s.table),
+		Key:                 s.key(key),
+		UpdateExpression:    aws.String("SET #owner = :owner, #expires = :expires"),
+		ConditionExpression: aws.String("attribute_not_exists(#owner) OR #owner = :owner OR #expires < :now"),
+		ExpressionAttributeNames: map[string]string{
+			"#owner":   ownerAttribute,
+			"#expires": expiresAttribute,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":owner":   &types.AttributeValueMemberS{Value: owner},
+			":expires": millis(now.Add(duration)),
+			":now":     millis(now),
+		},
+		ReturnValues: types.ReturnValueAllNew,
+	})
+	if err != nil {
+		var failed *types.ConditionalCheckFailedException
+		if errors.As(err, &failed) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+
+	return checkpointOf(output.Attributes), true, nil
+}
+
+func (s *dynamoDBStore) Checkpoint(key, owner, checkpoint string, duration time.Duration) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), dynamoDBTimeout)
+	defer cancel()
+
+	update := "SET #expires = :expires"
+	names := map[string]string{"#owner": ownerAttribute, "#expires": expiresAttribute}
+	values := map[string]types.AttributeValue{
+		":owner":   &types.AttributeValueMemberS{Value: owner},
+		":expires": millis(time.Now().Add(duration)),
+	}
+	if checkpoint != "" {
+		update += ", #checkpoint = :checkpoint"
+		names["#checkpoint"] = checkpointAttribute
+		values[":checkpoint"] = &types.AttributeValueMemberS{Value: checkpoint}
+	}
+
+	_, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(s.table),
+		Key:                       s.key(key),
+		UpdateExpression:          aws.String(update),
+		ConditionExpression:       aws.String("#owner = :owner"),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	})
+	if err != nil {
+		var failed *types.ConditionalCheckFailedException
+		if errors.As(err, &failed) {
+			return fmt.Errorf("shard '%s' isn't leased to '%s'", key, owner)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s *dynamoDBStore) Release(key, owner string) error {
+
+	ctx, cancel := context.WithTimeout(context.Background(), dynamoDBTimeout)
+	defer cancel()
+
+	_, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 aws.String(s.table),
+		Key:                       s.key(key),
+		UpdateExpression:          aws.String("REMOVE #owner, #expires"),
+		ConditionExpression:       aws.String("#owner = :owner"),
+		ExpressionAttributeNames:  map[string]string{"#owner": ownerAttribute, "#expires": expiresAttribute},
+		ExpressionAttributeValues: map[string]types.AttributeValue{":owner": &types.AttributeValueMemberS{Value: owner}},
+	})
+	if err != nil {
+		var failed *types.ConditionalCheckFailedException
+		if errors.As(err, &failed) {
+			return nil
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (s *dynamoDBStore) Get(key string) (string, error) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), dynamoDBTimeout)
+	defer cancel()
+
+	output, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.table),
+		Key:            s.key(key),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return checkpointOf(output.Item), nil
+}
+
+func (s *dynamoDBStore) key(key string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{leaseKeyAttribute: &types.AttributeValueMemberS{Value: key}}
+}
+
+func millis(t time.Time) types.AttributeValue {
+	return &types.AttributeValueMemberN{Value: strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10)}
+}
+
+func checkpointOf(item map[string]types.AttributeValue) string {
+	if value, ok := item[checkpointAttribute].(*types.AttributeValueMemberS); ok {
+		return value.Value
+	}
+	return ""
+}
@@ -0,0 +1,165 @@
+package kinesis
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+)
+
+// fetcher reads the records of a shard
+type fetcher interface {
+	// fetch returns the next records of the shard, and whether the shard is closed and has no more records
+	fetch(ctx context.Context) (records []types.Record, end bool, err error)
+	// reset makes the next fetch read the records after the sequence number, or from it when at is set,
+	// or from the start position when the sequence number is empty
+	reset(sequenceNumber string, at bool)
+	// close releases the resources of the fetcher
+	close()
+}
+
+// pollingFetcher reads the records of a shard with GetRecords, which is limited to 5 reads per second
+// per shard shared by all the consumers of the stream
+type pollingFetcher struct {
+	client    client
+	stream    string
+	shardID   string
+	start     types.ShardIteratorType
+	limit     int32
+	interval  time.Duration
+	after     string
+	at        bool
+	iterator  *string
+	lastFetch time.Time
+}
+
+func (f *pollingFetcher) fetch(ctx context.Context) ([]types.Record, bool, error) {
+
+	if f.iterator == nil {
+		input := &kinesis.GetShardIteratorInput{
+			StreamName:        aws.String(f.stream),
+			ShardId:           aws.String(f.shardID),
+			ShardIteratorType: f.start,
+		}
+		if f.after != "" {
+			input.ShardIteratorType = iteratorType(f.at)
+			input.StartingSequenceNumber = aws.String(f.after)
+		}
+
+		output, err := f.client.GetShardIterator(ctx, input)
+		if err != nil {
+			return nil, false, err
+		}
+		f.iterator = output.ShardIterator
+	}
+
+	if wait := f.interval - time.Since(f.lastFetch); wait > 0 {
+		select {
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+	f.lastFetch = time.Now()
+
+	output, err := f.client.GetRecords(ctx, &kinesis.GetRecordsInput{ShardIterator: f.iterator, Limit: aws.Int32(f.limit)})
+	if err != nil {
+		// the iterator may have expired, a new one is requested by the next fetch
+		f.iterator = nil
+		return nil, false, err
+	}
+
+	f.iterator = output.NextShardIterator
+	if n := len(output.Records); n > 0 {
+		f.after = aws.ToString(output.Records[n-1].SequenceNumber)
+		f.at = false
+	}
+
+	return output.Records, output.NextShardIterator == nil, nil
+}
+
+func (f *pollingFetcher) reset(sequenceNumber string, at bool) {
+	f.after = sequenceNumber
+	f.at = at
+	f.iterator = nil
+}
+
+func (f *pollingFetcher) close() {
+}
+
+// fanOutFetcher receives the records of a shard pushed through an enhanced fan-out subscription, which
+// has a dedicated throughput and expires after 5 minutes
+type fanOutFetcher struct {
+	client      client
+	consumerARN string
+	shardID     string
+	start       types.ShardIteratorType
+	after       string
+	at          bool
+	stream      *kinesis.SubscribeToShardEventStream
+}
+
+func (f *fanOutFetcher) fetch(ctx context.Context) ([]types.Record, bool, error) {
+
+	if f.stream == nil {
+		position := &types.StartingPosition{Type: f.start}
+		if f.after != "" {
+			position = &types.StartingPosition{Type: iteratorType(f.at), SequenceNumber: aws.String(f.after)}
+		}
+
+		output, err := f.client.SubscribeToShard(ctx, &kinesis.SubscribeToShardInput{
+			ConsumerARN:      aws.String(f.consumerARN),
+			ShardId:          aws.String(f.shardID),
+			StartingPosition: position,
+		})
+		if err != nil {
+			return nil, false, err
+		}
+		f.stream = output.GetStream()
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	case event, ok := <-f.stream.Events():
+		if !ok {
+			// the subscription expired or failed, the next fetch subscribes again
+			err := f.stream.Err()
+			f.close()
+			return nil, false, err
+		}
+
+		e, ok := event.(*types.SubscribeToShardEventStreamMemberSubscribeToShardEvent)
+		if !ok {
+			return nil, false, nil
+		}
+		if e.Value.ContinuationSequenceNumber != nil {
+			f.after = *e.Value.ContinuationSequenceNumber
+			f.at = false
+		}
+
+		return e.Value.Records, e.Value.ContinuationSequenceNumber == nil, nil
+	}
+}
+
+func (f *fanOutFetcher) reset(sequenceNumber string, at bool) {
+	f.close()
+	f.after = sequenceNumber
+	f.at = at
+}
+
+func (f *fanOutFetcher) close() {
+	if f.stream != nil {
+		_ = f.stream.Close()
+		f.stream = nil
+	}
+}
+
+func iteratorType(at bool) types.ShardIteratorType {
+	if at {
+		return types.ShardIteratorTypeAtSequenceNumber
+	}
+	return types.ShardIteratorTypeAfterSequenceNumber
+}
@@ -0,0 +1,10 @@
+module github.com/qingcloudhx/contrib/trigger/kinesis
+
+require (
+	flogo/core v0.9.0
+	github.com/aws/aws-sdk-go-v2 v1.32.2
+	github.com/aws/aws-sdk-go-v2/config v1.27.43
+	github.com/aws/aws-sdk-go-v2/service/dynamodb v1.36.2
+	github.com/aws/aws-sdk-go-v2/service/kinesis v1.32.2
+	github.com/stretchr/testify v1.3.0
+)
@@ -0,0 +1,82 @@
+package kinesis
+
+import (
+	"flogo/core/data/coerce"
+)
+
+const (
+	StartLatest      = "latest"
+	StartTrimHorizon = "trimHorizon"
+)
+
+type Settings struct {
+	Region          string `md:"region"`          // The AWS region of the streams, defaults to the region of the environment or the shared configuration
+	Profile         string `md:"profile"`         // The profile of the shared configuration and credentials files to use
+	Endpoint        string `md:"endpoint"`        // The url of the Kinesis endpoint, to use a compatible service (ex. http://localhost:4566)
+	CheckpointStore string `md:"checkpointStore"` // Where the checkpoints and leases of the shards are stored (ex. dynamodb://table, file:///var/lib/app/checkpoints.json), defaults to memory
+	LeaseDuration   string `md:"leaseDuration"`   // How long a shard stays leased to an instance without being renewed (ex. 30s), defaults to 30s
+}
+
+type HandlerSettings struct {
+	StreamName     string `md:"streamName,required"`                       // The name of the stream to consume
+	ConsumerName   string `md:"consumerName"`                              // The name of the consumer, which identifies its checkpoints and its enhanced fan-out registration, defaults to 'flogo'
+	StartPosition  string `md:"startPosition,allowed(latest,trimHorizon)"` // Where a shard without checkpoint is read from: 'latest' (default) or 'trimHorizon'
+	BatchSize      int    `md:"batchSize"`                                 // The max number of records read from a shard at once when polling, from 1 to 10000 (default)
+	PollInterval   string `md:"pollInterval"`                              // The min interval between two reads of a shard when polling (ex. 500ms), defaults to 1s
+	EnhancedFanOut bool   `md:"enhancedFanOut"`                            // Receive the records through an enhanced fan-out subscription instead of polling
+	RetryDelay     string `md:"retryDelay"`                                // How long a shard waits before a failed record is handled again (ex. 10s), defaults to 5s
+}
+
+type Output struct {
+	StreamName     string      `md:"streamName"`     // The name of the stream
+	ShardID        string      `md:"shardId"`        // The id of the shard of the record
+	PartitionKey   string      `md:"partitionKey"`   // The partition key of the record
+	SequenceNumber string      `md:"sequenceNumber"` // The sequence number of the record in its shard
+	Data           string      `md:"data"`           // The data of the record
+	Content        interface{} `md:"content"`        // The data of the record parsed, when it is JSON
+	ArrivalTime    int64       `md:"arrivalTime"`    // The approximate time the record was added to the stream, in milliseconds since epoch
+}
+
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"streamName":     o.StreamName,
+		"shardId":        o.ShardID,
+		"partitionKey":   o.PartitionKey,
+		"sequenceNumber": o.SequenceNumber,
+		"data":           o.Data,
+		"content":        o.Content,
+		"arrivalTime":    o.ArrivalTime,
+	}
+}
+
+func (o *Output) FromMap(values map[string]interface{}) error {
+
+	var err error
+	o.StreamName, err = coerce.ToString(values["streamName"])
+	if err != nil {
+		return err
+	}
+	o.ShardID, err = coerce.ToString(values["shardId"])
+	if err != nil {
+		return err
+	}
+	o.PartitionKey, err = coerce.ToString(values["partitionKey"])
+	if err != nil {
+		return err
+	}
+	o.SequenceNumber, err = coerce.ToString(values["sequenceNumber"])
+	if err != nil {
+		return err
+	}
+	o.Data, err = coerce.ToString(values["data"])
+	if err != nil {
+		return err
+	}
+	o.Content = values["content"]
+	o.ArrivalTime, err = coerce.ToInt64(values["arrivalTime"])
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,178 @@
+package kinesis
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+)
+
+// ShardEnd is the checkpoint of a shard whose records were all handled
+const ShardEnd = "SHARD_END"
+
+// CheckpointStore stores the checkpoints of the shards and leases the shards to the consumers,
+// so that a shard is read by a single instance at a time
+type CheckpointStore interface {
+	// Acquire leases the shard to the owner for the duration when it isn't leased to another owner
+	// or its lease expired, and returns the checkpoint of the shard
+	Acquire(key, owner string, duration time.Duration) (checkpoint string, acquired bool, err error)
+	// Checkpoint renews the lease of the shard and stores its checkpoint when not empty, it fails when
+	// the shard isn't leased to the owner anymore
+	Checkpoint(key, owner, checkpoint string, duration time.Duration) error
+	// Release releases the lease of the shard
+	Release(key, owner string) error
+	// Get returns the checkpoint of the shard
+	Get(key string) (string, error)
+}
+
+// StoreFactory creates a CheckpointStore from its url and the AWS configuration of the trigger
+type StoreFactory func(u *url.URL, awsConfig aws.Config) (CheckpointStore, error)
+
+var (
+	storesMu sync.RWMutex
+	stores   = map[string]StoreFactory{
+		"file":     newFileStore,
+		"dynamodb": newDynamoDBStore,
+	}
+)
+
+// RegisterStore registers the factory of the checkpoint stores whose url has the scheme
+func RegisterStore(scheme string, factory StoreFactory) {
+	storesMu.Lock()
+	defer storesMu.Unlock()
+
+	stores[scheme] = factory
+}
+
+// newStore creates the checkpoint store of the url, a memory store when empty
+func newStore(rawURL string, awsConfig aws.Config) (CheckpointStore, error) {
+
+	if rawURL == "" {
+		return &fileStore{checkpoints: make(map[string]string), owners: make(map[string]string)}, nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid checkpoint store '%s': %v", rawURL, err)
+	}
+
+	storesMu.RLock()
+	factory, ok := stores[u.Scheme]
+	storesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unsupported checkpoint store '%s'", rawURL)
+	}
+
+	return factory(u, awsConfig)
+}
+
+// fileStore keeps the checkpoints in memory and saves them to its file when it has one, its leases
+// are only shared by the consumers of the process
+type fileStore struct {
+	mu          sync.Mutex
+	path        string
+	checkpoints map[string]string
+	owners      map[string]string
+}
+
+func newFileStore(u *url.URL, awsConfig aws.Config) (CheckpointStore, error) {
+
+	path := u.Path
+	if u.Host != "" {
+		// relative path (ex. file://checkpoints.json)
+		path = filepath.Join(u.Host, u.Path)
+	}
+	if path == "" {
+		return nil, fmt.Errorf("missing path of checkpoint store '%s'", u)
+	}
+
+	s := &fileStore{path: path, checkpoints: make(map[string]string), owners: make(map[string]string)}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("unable to read checkpoint file [%s]: %v", path, err)
+	}
+	if len(data) > 0 {
+		err = json.Unmarshal(data, &s.checkpoints)
+		if err != nil {
+			return nil, fmt.Errorf("invalid checkpoint file [%s]: %v", path, err)
+		}
+	}
+
+	return s, nil
+}
+
+func (s *fileStore) Acquire(key, owner string, duration time.Duration) (string, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if current, ok := s.owners[key]; ok && current != owner {
+		return "", false, nil
+	}
+	s.owners[key] = owner
+
+	return s.checkpoints[key], true, nil
+}
+
+func (s *fileStore) Checkpoint(key, owner, checkpoint string, duration time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.owners[key] != owner {
+		return fmt.Errorf("shard '%s' isn't leased to '%s'", key, owner)
+	}
+	if checkpoint == "" || s.checkpoints[key] == checkpoint {
+		return nil
+	}
+	s.checkpoints[key] = checkpoint
+
+	return s.save()
+}
+
+func (s *fileStore) Release(key, owner string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.owners[key] == owner {
+		delete(s.owners, key)
+	}
+
+	return nil
+}
+
+func (s *fileStore) Get(key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.checkpoints[key], nil
+}
+
+// save writes the checkpoints to a temporary file renamed to the file, so that it is never partially written
+func (s *fileStore) save() error {
+
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s.checkpoints, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	err = ioutil.WriteFile(tmp, data, 0644)
+	if err != nil {
+		return fmt.Errorf("unable to write checkpoint file [%s]: %v", s.path, err)
+	}
+
+	return os.Rename(tmp, s.path)
+}
@@ -0,0 +1,146 @@
+package kinesis
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"flogo/core/data/metadata"
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+)
+
+const defaultLeaseDuration = 30 * time.Second
+
+var triggerMd = trigger.NewMetadata(&Settings{}, &HandlerSettings{}, &Output{})
+
+func init() {
+	_ = trigger.Register(&Trigger{}, &Factory{})
+}
+
+type Factory struct {
+}
+
+// Metadata implements trigger.Factory.Metadata
+func (*Factory) Metadata() *trigger.Metadata {
+	return triggerMd
+}
+
+// New implements trigger.Factory.New
+func (*Factory) New(config *trigger.Config) (trigger.Trigger, error) {
+
+	s := &Settings{}
+	err := metadata.MapToStruct(config.Settings, s, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Trigger{settings: s}, nil
+}
+
+// Trigger consumes Kinesis streams
+type Trigger struct {
+	settings  *Settings
+	logger    log.Logger
+	consumers []*consumer
+
+	cancel context.CancelFunc
+}
+
+// Initialize implements trigger.Init.Initialize
+func (t *Trigger) Initialize(ctx trigger.InitContext) error {
+
+	t.logger = ctx.Logger()
+
+	leaseDuration := defaultLeaseDuration
+	if t.settings.LeaseDuration != "" {
+		var err error
+		leaseDuration, err = time.ParseDuration(t.settings.LeaseDuration)
+		if err != nil {
+			return fmt.Errorf("invalid lease duration '%s': %v", t.settings.LeaseDuration, err)
+		}
+		if leaseDuration <= 0 {
+			return fmt.Errorf("lease duration must be positive")
+		}
+	}
+
+	var options []func(*config.LoadOptions) error
+	if t.settings.Region != "" {
+		options = append(options, config.WithRegion(t.settings.Region))
+	}
+	if t.settings.Profile != "" {
+		options = append(options, config.WithSharedConfigProfile(t.settings.Profile))
+	}
+
+	// the credentials are resolved with the default chain: environment, shared files, web identity and instance roles
+	awsConfig, err := config.LoadDefaultConfig(context.Background(), options...)
+	if err != nil {
+		return fmt.Errorf("unable to load AWS configuration: %v", err)
+	}
+
+	client := kinesis.NewFromConfig(awsConfig, func(o *kinesis.Options) {
+		if t.settings.Endpoint != "" {
+			o.BaseEndpoint = aws.String(t.settings.Endpoint)
+		}
+	})
+
+	store, err := newStore(t.settings.CheckpointStore, awsConfig)
+	if err != nil {
+		return err
+	}
+
+	// the owner of the leases identifies this instance of the app
+	host, _ := os.Hostname()
+	owner := host + "-" + strconv.Itoa(os.Getpid()) + "-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+
+	for _, handler := range ctx.GetHandlers() {
+
+		s := &HandlerSettings{}
+		err := metadata.MapToStruct(handler.Settings(), s, true)
+		if err != nil {
+			return err
+		}
+
+		c, err := newConsumer(client, store, handler, s, owner, leaseDuration, t.logger)
+		if err != nil {
+			return err
+		}
+		t.consumers = append(t.consumers, c)
+	}
+
+	return nil
+}
+
+// Start implements util.Managed.Start
+func (t *Trigger) Start() error {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+
+	for _, c := range t.consumers {
+		c.done = make(chan struct{})
+		go c.run(ctx)
+	}
+
+	return nil
+}
+
+// Stop implements util.Managed.Stop
+func (t *Trigger) Stop() error {
+
+	if t.cancel != nil {
+		t.cancel()
+		t.cancel = nil
+	}
+
+	for _, c := range t.consumers {
+		c.wait(30 * time.Second)
+	}
+
+	return nil
+}
@@ -0,0 +1,411 @@
+package kinesis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"flogo/core/action"
+	"flogo/core/api"
+	"flogo/core/support/test"
+	"flogo/core/trigger"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis/types"
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig string = `{
+	"id": "trigger-kinesis",
+	"ref": "github.com/qingcloudhx/contrib/trigger/kinesis",
+	"settings": {
+	  "region": "eu-west-1"
+	},
+	"handlers": [
+	  {
+		"settings": {
+		  "streamName": "stream"
+		},
+		"action": {
+		  "id": "test"
+		}
+	  }
+	]
+}`
+
+// collect returns a handler function recording the data of the records, it fails once for the data of the
+// failures, and a function returning the recorded data
+func collect(failures map[string]bool) (api.HandlerFunc, func() []string) {
+	var mu sync.Mutex
+	var data []string
+
+	f := func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+		out := &Output{}
+		if err := out.FromMap(inputs); err != nil {
+			return nil, err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if failures[out.Data] {
+			delete(failures, out.Data)
+			return nil, errors.New("failed")
+		}
+		data = append(data, out.Data)
+		return nil, nil
+	}
+	handled := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return append([]string(nil), data...)
+	}
+
+	return f, handled
+}
+
+// initConsumer returns the consumer of a trigger initialized with a handler of the settings running the function
+func initConsumer(settings map[string]interface{}, f api.HandlerFunc) (*consumer, error) {
+
+	config := &trigger.Config{}
+	if err := json.Unmarshal([]byte(testConfig), config); err != nil {
+		return nil, err
+	}
+	for name, value := range settings {
+		config.Handlers[0].Settings[name] = value
+	}
+
+	trg, err := test.InitTrigger(&Factory{}, config, map[string]action.Action{"test": api.NewProxyAction(f)})
+	if err != nil {
+		return nil, err
+	}
+	return trg.(*Trigger).consumers[0], nil
+}
+
+type testShard struct {
+	parent  string
+	records []string
+	closed  bool
+}
+
+// testClient serves the records of its shards, whose sequence numbers are '<shard>-<index>', and
+// its iterators are '<shard>/<index>'
+type testClient struct {
+	client
+	shards    map[string]*testShard
+	mu        sync.Mutex
+	iterators []string
+}
+
+func (c *testClient) ListShards(ctx context.Context, params *kinesis.ListShardsInput, optFns ...func(*kinesis.Options)) (*kinesis.ListShardsOutput, error) {
+	output := &kinesis.ListShardsOutput{}
+	for id, shard := range c.shards {
+		s := types.Shard{ShardId: aws.String(id)}
+		if shard.parent != "" {
+			s.ParentShardId = aws.String(shard.parent)
+		}
+		output.Shards = append(output.Shards, s)
+	}
+	return output, nil
+}
+
+func (c *testClient) GetShardIterator(ctx context.Context, params *kinesis.GetShardIteratorInput, optFns ...func(*kinesis.Options)) (*kinesis.GetShardIteratorOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id := aws.ToString(params.ShardId)
+	c.iterators = append(c.iterators, string(params.ShardIteratorType)+" "+aws.ToString(params.StartingSequenceNumber))
+
+	var index int
+	switch params.ShardIteratorType {
+	case types.ShardIteratorTypeLatest:
+		index = len(c.shards[id].records)
+	case types.ShardIteratorTypeAtSequenceNumber, types.ShardIteratorTypeAfterSequenceNumber:
+		index, _ = strconv.Atoi(strings.TrimPrefix(aws.ToString(params.StartingSequenceNumber), id+"-"))
+		if params.ShardIteratorType == types.ShardIteratorTypeAfterSequenceNumber {
+			index++
+		}
+	}
+
+	return &kinesis.GetShardIteratorOutput{ShardIterator: aws.String(id + "/" + strconv.Itoa(index))}, nil
+}
+
+func (c *testClient) GetRecords(ctx context.Context, params *kinesis.GetRecordsInput, optFns ...func(*kinesis.Options)) (*kinesis.GetRecordsOutput, error) {
+	parts := strings.Split(aws.ToString(params.ShardIterator), "/")
+	id := parts[0]
+	index, _ := strconv.Atoi(parts[1])
+	shard := c.shards[id]
+
+	output := &kinesis.GetRecordsOutput{}
+	for i := index; i < len(shard.records) && len(output.Records) < int(aws.ToInt32(params.Limit)); i++ {
+		output.Records = append(output.Records, types.Record{
+			Data:           []byte(shard.records[i]),
+			PartitionKey:   aws.String("key"),
+			SequenceNumber: aws.String(id + "-" + strconv.Itoa(i)),
+		})
+	}
+	index += len(output.Records)
+
+	if !shard.closed || index < len(shard.records) {
+		output.NextShardIterator = aws.String(id + "/" + strconv.Itoa(index))
+	}
+
+	return output, nil
+}
+
+func (c *testClient) requestedIterators() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]string(nil), c.iterators...)
+}
+
+func newTestConsumer(t *testing.T, client client, store CheckpointStore, f api.HandlerFunc, settings map[string]interface{}) *consumer {
+	settings["pollInterval"] = "1ms"
+	settings["retryDelay"] = "10ms"
+
+	c, err := initConsumer(settings, f)
+	assert.Nil(t, err)
+	c.client = client
+	c.store = store
+	return c
+}
+
+func newMemoryStore(t *testing.T) CheckpointStore {
+	store, err := newStore("", aws.Config{})
+	assert.Nil(t, err)
+	return store
+}
+
+func waitFor(condition func() bool) {
+	for i := 0; i < 200 && !condition(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestNewConsumer(t *testing.T) {
+	f, _ := collect(nil)
+	c, err := initConsumer(nil, f)
+	assert.Nil(t, err)
+	assert.Equal(t, defaultConsumerName, c.settings.ConsumerName)
+	assert.Equal(t, StartLatest, c.settings.StartPosition)
+	assert.Equal(t, defaultBatchSize, c.settings.BatchSize)
+	assert.Equal(t, defaultPollInterval, c.pollInterval)
+	assert.Equal(t, defaultRetryDelay, c.retryDelay)
+
+	_, err = initConsumer(map[string]interface{}{"batchSize": 20000}, f)
+	assert.NotNil(t, err)
+
+	_, err = initConsumer(map[string]interface{}{"startPosition": "oldest"}, f)
+	assert.NotNil(t, err)
+
+	_, err = initConsumer(map[string]interface{}{"pollInterval": "soon"}, f)
+	assert.NotNil(t, err)
+}
+
+func TestConsumerShards(t *testing.T) {
+	client := &testClient{shards: map[string]*testShard{
+		"parent": {records: []string{"a", "b", "c"}, closed: true},
+		"child":  {parent: "parent", records: []string{"d", "e"}},
+	}}
+	store := newMemoryStore(t)
+	f, handled := collect(map[string]bool{"b": true})
+	c := newTestConsumer(t, client, store, f, map[string]interface{}{"startPosition": StartTrimHorizon, "batchSize": 2})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the child is read once the parent ended
+	assert.Nil(t, c.sync(ctx))
+	waitFor(func() bool { checkpoint, _ := store.Get("flogo/stream/parent"); return checkpoint == ShardEnd })
+	assert.Equal(t, []string{"a", "b", "c"}, handled())
+
+	assert.Nil(t, c.sync(ctx))
+	waitFor(func() bool { return len(handled()) == 5 })
+	assert.Equal(t, []string{"a", "b", "c", "d", "e"}, handled())
+
+	waitFor(func() bool { checkpoint, _ := store.Get("flogo/stream/child"); return checkpoint == "child-1" })
+	checkpoint, _ := store.Get("flogo/stream/child")
+	assert.Equal(t, "child-1", checkpoint)
+
+	// the failed record was read again
+	assert.Contains(t, client.requestedIterators(), "AT_SEQUENCE_NUMBER parent-1")
+
+	cancel()
+	c.shards.Wait()
+
+	// the leases are released once stopped
+	_, acquired, err := store.Acquire("flogo/stream/child", "other", time.Minute)
+	assert.Nil(t, err)
+	assert.True(t, acquired)
+}
+
+func TestConsumerCheckpoint(t *testing.T) {
+	client := &testClient{shards: map[string]*testShard{
+		"shard": {records: []string{"a", "b", "c"}},
+	}}
+	store := newMemoryStore(t)
+	_, _, _ = store.Acquire("app/stream/shard", "owner", time.Minute)
+	assert.Nil(t, store.Checkpoint("app/stream/shard", "owner", "shard-0", time.Minute))
+	assert.Nil(t, store.Release("app/stream/shard", "owner"))
+
+	f, handled := collect(nil)
+	c := newTestConsumer(t, client, store, f, map[string]interface{}{"consumerName": "app"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	assert.Nil(t, c.sync(ctx))
+	waitFor(func() bool { return len(handled()) == 2 })
+	cancel()
+	c.shards.Wait()
+
+	assert.Equal(t, []string{"b", "c"}, handled())
+	assert.Equal(t, "AFTER_SEQUENCE_NUMBER shard-0", client.requestedIterators()[0])
+}
+
+func TestConsumerLeased(t *testing.T) {
+	client := &testClient{shards: map[string]*testShard{
+		"shard": {records: []string{"a"}},
+	}}
+	store := newMemoryStore(t)
+	_, acquired, _ := store.Acquire("flogo/stream/shard", "other", time.Minute)
+	assert.True(t, acquired)
+
+	f, handled := collect(nil)
+	c := newTestConsumer(t, client, store, f, map[string]interface{}{"startPosition": StartTrimHorizon})
+
+	assert.Nil(t, c.sync(context.Background()))
+	c.shards.Wait()
+
+	assert.Empty(t, handled())
+	assert.Empty(t, client.requestedIterators())
+}
+
+// testReader is an enhanced fan-out subscription serving its events
+type testReader struct {
+	events chan types.SubscribeToShardEventStream
+}
+
+func (r *testReader) Events() <-chan types.SubscribeToShardEventStream {
+	return r.events
+}
+
+func (r *testReader) Close() error {
+	return nil
+}
+
+func (r *testReader) Err() error {
+	return nil
+}
+
+func TestFanOutFetcher(t *testing.T) {
+	reader := &testReader{events: make(chan types.SubscribeToShardEventStream, 2)}
+	reader.events <- &types.SubscribeToShardEventStreamMemberSubscribeToShardEvent{Value: types.SubscribeToShardEvent{
+		ContinuationSequenceNumber: aws.String("2"),
+		Records:                    []types.Record{{Data: []byte("a"), SequenceNumber: aws.String("1")}},
+	}}
+	reader.events <- &types.SubscribeToShardEventStreamMemberSubscribeToShardEvent{Value: types.SubscribeToShardEvent{
+		Records: []types.Record{{Data: []byte("b"), SequenceNumber: aws.String("3")}},
+	}}
+	close(reader.events)
+
+	f := &fanOutFetcher{shardID: "shard", stream: kinesis.NewSubscribeToShardEventStream(func(s *kinesis.SubscribeToShardEventStream) {
+		s.Reader = reader
+	})}
+
+	records, end, err := f.fetch(context.Background())
+	assert.Nil(t, err)
+	assert.False(t, end)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "2", f.after)
+
+	records, end, err = f.fetch(context.Background())
+	assert.Nil(t, err)
+	assert.True(t, end)
+	assert.Equal(t, "b", string(records[0].Data))
+
+	// the expired subscription is subscribed again by the next fetch
+	_, _, err = f.fetch(context.Background())
+	assert.Nil(t, err)
+	assert.Nil(t, f.stream)
+}
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kinesis")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "checkpoints.json")
+	store, err := newStore("file://"+path, aws.Config{})
+	assert.Nil(t, err)
+
+	checkpoint, acquired, err := store.Acquire("key", "owner", time.Minute)
+	assert.Nil(t, err)
+	assert.True(t, acquired)
+	assert.Equal(t, "", checkpoint)
+
+	_, acquired, _ = store.Acquire("key", "other", time.Minute)
+	assert.False(t, acquired)
+	assert.NotNil(t, store.Checkpoint("key", "other", "1", time.Minute))
+
+	assert.Nil(t, store.Checkpoint("key", "owner", "1", time.Minute))
+	assert.Nil(t, store.Release("key", "owner"))
+
+	data, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	var checkpoints map[string]string
+	assert.Nil(t, json.Unmarshal(data, &checkpoints))
+	assert.Equal(t, map[string]string{"key": "1"}, checkpoints)
+
+	store, err = newStore("file://"+path, aws.Config{})
+	assert.Nil(t, err)
+	checkpoint, acquired, err = store.Acquire("key", "other", time.Minute)
+	assert.Nil(t, err)
+	assert.True(t, acquired)
+	assert.Equal(t, "1", checkpoint)
+}
+
+func TestRegisterStore(t *testing.T) {
+	memory := newMemoryStore(t)
+	RegisterStore("test", func(u *url.URL, awsConfig aws.Config) (CheckpointStore, error) {
+		assert.Equal(t, "name", u.Host)
+		return memory, nil
+	})
+
+	store, err := newStore("test://name", aws.Config{})
+	assert.Nil(t, err)
+	assert.Equal(t, memory, store)
+
+	_, err = newStore("unknown://name", aws.Config{})
+	assert.NotNil(t, err)
+}
+
+func TestToOutput(t *testing.T) {
+	arrival := time.Unix(1600000000, 0)
+	out := toOutput("stream", "shard", types.Record{
+		Data:                        []byte(`{"id":1}`),
+		PartitionKey:                aws.String("key"),
+		SequenceNumber:              aws.String("42"),
+		ApproximateArrivalTimestamp: &arrival,
+	})
+
+	assert.Equal(t, "stream", out.StreamName)
+	assert.Equal(t, "shard", out.ShardID)
+	assert.Equal(t, "key", out.PartitionKey)
+	assert.Equal(t, "42", out.SequenceNumber)
+	assert.Equal(t, `{"id":1}`, out.Data)
+	assert.Equal(t, map[string]interface{}{"id": 1.0}, out.Content)
+	assert.Equal(t, int64(1600000000000), out.ArrivalTime)
+
+	out = toOutput("stream", "shard", types.Record{Data: []byte("text")})
+	assert.Nil(t, out.Content)
+}
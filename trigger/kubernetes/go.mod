@@ -0,0 +1,8 @@
+module github.com/qingcloudhx/contrib/trigger/kubernetes
+
+require (
+	flogo/core v0.9.0
+	github.com/stretchr/testify v1.3.0
+	k8s.io/apimachinery v0.32.3
+	k8s.io/client-go v0.32.3
+)
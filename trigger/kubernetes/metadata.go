@@ -0,0 +1,102 @@
+package kubernetes
+
+import (
+	"flogo/core/data/coerce"
+)
+
+const (
+	EventAdded   = "added"
+	EventUpdated = "updated"
+	EventDeleted = "deleted"
+)
+
+type Settings struct {
+	Kubeconfig   string `md:"kubeconfig"`   // The kubeconfig file of the cluster, defaults to the KUBECONFIG files, ~/.kube/config or the service account of the pod when running in the cluster
+	Context      string `md:"context"`      // The context of the kubeconfig file, defaults to its current context
+	ResyncPeriod string `md:"resyncPeriod"` // The period the watched objects are handled again as updated (ex. 10m), to reconcile them periodically, never if not specified
+}
+
+type HandlerSettings struct {
+	APIVersion     string `md:"apiVersion"`     // The API version of the kind (ex. apps/v1), defaults to v1
+	Kind           string `md:"kind,required"`  // The kind of the objects to watch (ex. Pod, Deployment or the kind of a custom resource)
+	Namespace      string `md:"namespace"`      // The namespace of the objects to watch, defaults to all the namespaces
+	LabelSelector  string `md:"labelSelector"`  // The label selector of the objects to watch (ex. app=web,tier!=cache)
+	FieldSelector  string `md:"fieldSelector"`  // The field selector of the objects to watch (ex. status.phase=Running)
+	Events         string `md:"events"`         // The comma separated events to handle: added, updated and deleted, defaults to all of them
+	IgnoreExisting bool   `md:"ignoreExisting"` // Ignore the objects existing when the trigger starts, instead of handling them as added
+}
+
+type Output struct {
+	Event           string                 `md:"event"`           // The event of the object: added, updated or deleted
+	APIVersion      string                 `md:"apiVersion"`      // The API version of the object
+	Kind            string                 `md:"kind"`            // The kind of the object
+	Namespace       string                 `md:"namespace"`       // The namespace of the object, empty for the cluster objects
+	Name            string                 `md:"name"`            // The name of the object
+	UID             string                 `md:"uid"`             // The unique id of the object
+	ResourceVersion string                 `md:"resourceVersion"` // The version of the object
+	Labels          map[string]string      `md:"labels"`          // The labels of the object
+	Object          map[string]interface{} `md:"object"`          // The object, its last known state when deleted
+	OldObject       map[string]interface{} `md:"oldObject"`       // The previous state of an updated object
+}
+
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"event":           o.Event,
+		"apiVersion":      o.APIVersion,
+		"kind":            o.Kind,
+		"namespace":       o.Namespace,
+		"name":            o.Name,
+		"uid":             o.UID,
+		"resourceVersion": o.ResourceVersion,
+		"labels":          o.Labels,
+		"object":          o.Object,
+		"oldObject":       o.OldObject,
+	}
+}
+
+func (o *Output) FromMap(values map[string]interface{}) error {
+
+	var err error
+	o.Event, err = coerce.ToString(values["event"])
+	if err != nil {
+		return err
+	}
+	o.APIVersion, err = coerce.ToString(values["apiVersion"])
+	if err != nil {
+		return err
+	}
+	o.Kind, err = coerce.ToString(values["kind"])
+	if err != nil {
+		return err
+	}
+	o.Namespace, err = coerce.ToString(values["namespace"])
+	if err != nil {
+		return err
+	}
+	o.Name, err = coerce.ToString(values["name"])
+	if err != nil {
+		return err
+	}
+	o.UID, err = coerce.ToString(values["uid"])
+	if err != nil {
+		return err
+	}
+	o.ResourceVersion, err = coerce.ToString(values["resourceVersion"])
+	if err != nil {
+		return err
+	}
+	o.Labels, err = coerce.ToParams(values["labels"])
+	if err != nil {
+		return err
+	}
+	o.Object, err = coerce.ToObject(values["object"])
+	if err != nil {
+		return err
+	}
+	o.OldObject, err = coerce.ToObject(values["oldObject"])
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
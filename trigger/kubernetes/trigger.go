@@ -0,0 +1,171 @@
+package kubernetes
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"flogo/core/data/metadata"
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+var triggerMd = trigger.NewMetadata(&Settings{}, &HandlerSettings{}, &Output{})
+
+func init() {
+	_ = trigger.Register(&Trigger{}, &Factory{})
+}
+
+type Factory struct {
+}
+
+// Metadata implements trigger.Factory.Metadata
+func (*Factory) Metadata() *trigger.Metadata {
+	return triggerMd
+}
+
+// New implements trigger.Factory.New
+func (*Factory) New(config *trigger.Config) (trigger.Trigger, error) {
+
+	s := &Settings{}
+	err := metadata.MapToStruct(config.Settings, s, true)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Trigger{settings: s}
+	if s.ResyncPeriod != "" {
+		t.resync, err = time.ParseDuration(s.ResyncPeriod)
+		if err != nil {
+			return nil, fmt.Errorf("invalid resync period '%s': %v", s.ResyncPeriod, err)
+		}
+	}
+	t.connect = t.connectCluster
+
+	return t, nil
+}
+
+// Trigger watches the objects of the Kubernetes cluster, and invokes the handlers of their kinds when they
+// are added, updated or deleted
+type Trigger struct {
+	settings *Settings
+	logger   log.Logger
+	watchers []*watcher
+	resync   time.Duration
+	connect  func() (dynamic.Interface, meta.RESTMapper, error)
+
+	stop    chan struct{}
+	running sync.WaitGroup
+}
+
+// Initialize implements trigger.Init.Initialize
+func (t *Trigger) Initialize(ctx trigger.InitContext) error {
+
+	t.logger = ctx.Logger()
+
+	for _, handler := range ctx.GetHandlers() {
+
+		s := &HandlerSettings{}
+		err := metadata.MapToStruct(handler.Settings(), s, true)
+		if err != nil {
+			return err
+		}
+
+		w, err := newWatcher(handler, s, t.logger)
+		if err != nil {
+			return err
+		}
+		t.watchers = append(t.watchers, w)
+	}
+
+	return nil
+}
+
+// connectCluster returns the client of the cluster, and the mapper of the kinds to their resources
+// discovered on the cluster
+func (t *Trigger) connectCluster() (dynamic.Interface, meta.RESTMapper, error) {
+
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = t.settings.Kubeconfig
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: t.settings.Context}
+
+	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to load Kubernetes configuration: %v", err)
+	}
+
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return client, restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient)), nil
+}
+
+// Start implements util.Managed.Start
+func (t *Trigger) Start() error {
+
+	client, mapper, err := t.connect()
+	if err != nil {
+		return err
+	}
+
+	var informers []cache.SharedIndexInformer
+	for _, w := range t.watchers {
+		informer, err := w.informer(client, mapper, t.resync)
+		if err != nil {
+			return err
+		}
+		informers = append(informers, informer)
+	}
+
+	stop := make(chan struct{})
+	t.stop = stop
+	for i, informer := range informers {
+		t.logger.Infof("Watching %s objects of API version '%s'", t.watchers[i].settings.Kind, t.watchers[i].settings.APIVersion)
+
+		t.running.Add(1)
+		go func(informer cache.SharedIndexInformer) {
+			defer t.running.Done()
+			informer.Run(stop)
+		}(informer)
+	}
+
+	return nil
+}
+
+// Stop implements util.Managed.Stop
+func (t *Trigger) Stop() error {
+
+	if t.stop == nil {
+		return nil
+	}
+
+	close(t.stop)
+	t.running.Wait()
+	t.stop = nil
+
+	return nil
+}
+
+func splitList(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,236 @@
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"flogo/core/action"
+	"flogo/core/api"
+	"flogo/core/support/test"
+	"flogo/core/trigger"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+var (
+	podsResource       = schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+	namespacesResource = schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+)
+
+const testConfig string = `{
+	"id": "trigger-kubernetes",
+	"ref": "github.com/qingcloudhx/contrib/trigger/kubernetes",
+	"settings": {
+	},
+	"handlers": [
+	  {
+		"settings": {
+		  "kind": "Pod"
+		},
+		"action": {
+		  "id": "test"
+		}
+	  }
+	]
+}`
+
+// collect returns a handler function recording the outputs it handles, and a function returning the outputs
+func collect() (api.HandlerFunc, func() []*Output) {
+	var mu sync.Mutex
+	var outputs []*Output
+
+	f := func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+		out := &Output{}
+		if err := out.FromMap(inputs); err != nil {
+			return nil, err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		outputs = append(outputs, out)
+		return nil, nil
+	}
+	handled := func() []*Output {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return append([]*Output(nil), outputs...)
+	}
+
+	return f, handled
+}
+
+// events returns the events of the outputs, with the names of their objects
+func events(outputs []*Output) []string {
+	var events []string
+	for _, out := range outputs {
+		events = append(events, out.Event+" "+out.Name)
+	}
+	return events
+}
+
+// waitFor waits until the number of events are handled
+func waitFor(handled func() []*Output, count int) {
+	for i := 0; i < 200 && len(handled()) < count; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// initTrigger returns a trigger initialized with a handler of the settings running the function
+func initTrigger(settings map[string]interface{}, f api.HandlerFunc) (*Trigger, error) {
+
+	config := &trigger.Config{}
+	if err := json.Unmarshal([]byte(testConfig), config); err != nil {
+		return nil, err
+	}
+	config.Handlers[0].Settings = settings
+
+	trg, err := test.InitTrigger(&Factory{}, config, map[string]action.Action{"test": api.NewProxyAction(f)})
+	if err != nil {
+		return nil, err
+	}
+	return trg.(*Trigger), nil
+}
+
+func newObject(kind, namespace, name string, labels map[string]string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion("v1")
+	u.SetKind(kind)
+	u.SetNamespace(namespace)
+	u.SetName(name)
+	u.SetLabels(labels)
+	return u
+}
+
+// fakeCluster returns the fake client and mapper of a cluster with pods and namespaces
+func fakeCluster(objects ...runtime.Object) (dynamic.Interface, meta.RESTMapper) {
+
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{
+		podsResource:       "PodList",
+		namespacesResource: "NamespaceList",
+	}, objects...)
+
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{{Version: "v1"}})
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Pod"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, meta.RESTScopeRoot)
+
+	return client, mapper
+}
+
+func TestFactory_New(t *testing.T) {
+
+	f := &Factory{}
+
+	_, err := f.New(&trigger.Config{Settings: map[string]interface{}{"resyncPeriod": "often"}})
+	assert.NotNil(t, err)
+
+	trg, err := f.New(&trigger.Config{Settings: map[string]interface{}{"resyncPeriod": "10m"}})
+	assert.Nil(t, err)
+	assert.Equal(t, 10*time.Minute, trg.(*Trigger).resync)
+}
+
+func TestTrigger_Initialize(t *testing.T) {
+
+	for _, settings := range []map[string]interface{}{
+		{},
+		{"kind": "Pod", "apiVersion": "apps/v1/beta"},
+		{"kind": "Pod", "labelSelector": "app in (web"},
+		{"kind": "Pod", "fieldSelector": "status.phase"},
+		{"kind": "Pod", "events": "added,modified"},
+	} {
+		f, _ := collect()
+		_, err := initTrigger(settings, f)
+		assert.NotNil(t, err, "%v", settings)
+	}
+}
+
+func TestTrigger_Start(t *testing.T) {
+
+	f, _ := collect()
+	trg, err := initTrigger(map[string]interface{}{"kind": "Deployment", "apiVersion": "apps/v1"}, f)
+	assert.Nil(t, err)
+
+	trg.connect = func() (dynamic.Interface, meta.RESTMapper, error) {
+		client, mapper := fakeCluster()
+		return client, mapper, nil
+	}
+	assert.NotNil(t, trg.Start())
+
+	trg.connect = func() (dynamic.Interface, meta.RESTMapper, error) {
+		return nil, nil, errors.New("unreachable")
+	}
+	assert.NotNil(t, trg.Start())
+}
+
+func TestTrigger_Watch(t *testing.T) {
+
+	client, mapper := fakeCluster(newObject("Pod", "default", "web-0", nil), newObject("Namespace", "", "default", nil))
+
+	// the triggers of the handlers watch the same cluster
+	watch := func(settings map[string]interface{}) func() []*Output {
+		f, handled := collect()
+		trg, err := initTrigger(settings, f)
+		assert.Nil(t, err)
+		trg.connect = func() (dynamic.Interface, meta.RESTMapper, error) {
+			return client, mapper, nil
+		}
+
+		assert.Nil(t, trg.Start())
+		t.Cleanup(func() { trg.Stop() })
+		return handled
+	}
+	all := watch(map[string]interface{}{"kind": "Pod"})
+	created := watch(map[string]interface{}{"kind": "Pod", "namespace": "default", "events": "added,deleted", "ignoreExisting": true})
+	namespaces := watch(map[string]interface{}{"kind": "Namespace", "namespace": "default"})
+
+	// the existing objects are listed before they are watched
+	waitFor(all, 1)
+	waitFor(namespaces, 1)
+	time.Sleep(100 * time.Millisecond)
+
+	ctx := context.Background()
+	pods := client.Resource(podsResource)
+
+	pod, err := pods.Namespace("default").Create(ctx, newObject("Pod", "default", "web-1", map[string]string{"app": "web"}), metav1.CreateOptions{})
+	assert.Nil(t, err)
+	pod.SetLabels(map[string]string{"app": "web", "version": "2"})
+	pod.SetResourceVersion("2")
+	_, err = pods.Namespace("default").Update(ctx, pod, metav1.UpdateOptions{})
+	assert.Nil(t, err)
+	_, err = pods.Namespace("other").Create(ctx, newObject("Pod", "other", "db-0", nil), metav1.CreateOptions{})
+	assert.Nil(t, err)
+	err = pods.Namespace("default").Delete(ctx, "web-1", metav1.DeleteOptions{})
+	assert.Nil(t, err)
+
+	// the events of different namespaces are not ordered
+	waitFor(all, 5)
+	assert.ElementsMatch(t, []string{"added web-0", "added web-1", "updated web-1", "added db-0", "deleted web-1"}, events(all()))
+	waitFor(created, 2)
+	assert.Equal(t, []string{"added web-1", "deleted web-1"}, events(created()))
+	assert.Equal(t, []string{"added default"}, events(namespaces()))
+
+	var updated *Output
+	for _, out := range all() {
+		if out.Event == EventUpdated {
+			updated = out
+		}
+	}
+	assert.Equal(t, "v1", updated.APIVersion)
+	assert.Equal(t, "Pod", updated.Kind)
+	assert.Equal(t, "default", updated.Namespace)
+	assert.Equal(t, map[string]string{"app": "web", "version": "2"}, updated.Labels)
+	assert.Equal(t, "web-1", updated.Object["metadata"].(map[string]interface{})["name"])
+	assert.Equal(t, map[string]interface{}{"app": "web"}, updated.OldObject["metadata"].(map[string]interface{})["labels"])
+	assert.Equal(t, map[string]string{}, all()[0].Labels)
+}
@@ -0,0 +1,154 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+)
+
+// watcher watches the objects of a kind with an informer, and invokes the action of a handler for their events
+type watcher struct {
+	handler  trigger.Handler
+	settings *HandlerSettings
+	logger   log.Logger
+
+	gvk    schema.GroupVersionKind
+	events map[string]bool
+}
+
+func newWatcher(handler trigger.Handler, s *HandlerSettings, logger log.Logger) (*watcher, error) {
+
+	if s.APIVersion == "" {
+		s.APIVersion = "v1"
+	}
+	gv, err := schema.ParseGroupVersion(s.APIVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid API version '%s': %v", s.APIVersion, err)
+	}
+
+	if _, err := labels.Parse(s.LabelSelector); err != nil {
+		return nil, fmt.Errorf("invalid label selector '%s': %v", s.LabelSelector, err)
+	}
+	if _, err := fields.ParseSelector(s.FieldSelector); err != nil {
+		return nil, fmt.Errorf("invalid field selector '%s': %v", s.FieldSelector, err)
+	}
+
+	w := &watcher{handler: handler, settings: s, logger: logger, gvk: gv.WithKind(s.Kind)}
+
+	for _, event := range splitList(s.Events) {
+		switch event {
+		case EventAdded, EventUpdated, EventDeleted:
+		default:
+			return nil, fmt.Errorf("unsupported event '%s', the events are added, updated and deleted", event)
+		}
+		if w.events == nil {
+			w.events = make(map[string]bool)
+		}
+		w.events[event] = true
+	}
+
+	return w, nil
+}
+
+// informer returns the informer of the objects of the watcher, with the namespace and selectors of the
+// handler, the namespace being ignored for the cluster objects
+func (w *watcher) informer(client dynamic.Interface, mapper meta.RESTMapper, resync time.Duration) (cache.SharedIndexInformer, error) {
+
+	s := w.settings
+
+	mapping, err := mapper.RESTMapping(w.gvk.GroupKind(), w.gvk.Version)
+	if err != nil {
+		return nil, fmt.Errorf("unknown kind '%s' of API version '%s': %v", s.Kind, s.APIVersion, err)
+	}
+
+	namespace := s.Namespace
+	if mapping.Scope.Name() == meta.RESTScopeNameRoot {
+		namespace = metav1.NamespaceAll
+	}
+
+	tweak := func(options *metav1.ListOptions) {
+		options.LabelSelector = s.LabelSelector
+		options.FieldSelector = s.FieldSelector
+	}
+	informer := dynamicinformer.NewFilteredDynamicInformer(client, mapping.Resource, namespace, resync, cache.Indexers{}, tweak).Informer()
+
+	_, err = informer.AddEventHandler(cache.ResourceEventHandlerDetailedFuncs{
+		AddFunc: func(obj interface{}, isInInitialList bool) {
+			if isInInitialList && s.IgnoreExisting {
+				return
+			}
+			w.handle(EventAdded, obj, nil)
+		},
+		UpdateFunc: func(oldObj, obj interface{}) {
+			w.handle(EventUpdated, obj, oldObj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			// the last known state of an object whose deletion was missed while disconnected
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			w.handle(EventDeleted, obj, nil)
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return informer, nil
+}
+
+// handle invokes the action for the event of an object, the events of an informer are handled one at a time
+// in their order, and the event of a failed action is not handled again
+func (w *watcher) handle(event string, obj, oldObj interface{}) {
+
+	if w.events != nil && !w.events[event] {
+		return
+	}
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return
+	}
+
+	// the objects of the informer are shared with its cache
+	out := &Output{
+		Event:           event,
+		APIVersion:      u.GetAPIVersion(),
+		Kind:            u.GetKind(),
+		Namespace:       u.GetNamespace(),
+		Name:            u.GetName(),
+		UID:             string(u.GetUID()),
+		ResourceVersion: u.GetResourceVersion(),
+		Labels:          u.GetLabels(),
+		Object:          u.DeepCopy().Object,
+	}
+	if out.Labels == nil {
+		out.Labels = make(map[string]string)
+	}
+	if old, ok := oldObj.(*unstructured.Unstructured); ok {
+		out.OldObject = old.DeepCopy().Object
+	}
+
+	_, err := w.handler.Handle(context.Background(), out)
+	if err != nil {
+		w.logger.Errorf("Error handling %s event of %s '%s': %v", event, out.Kind, objectKey(u), err)
+	}
+}
+
+func objectKey(u *unstructured.Unstructured) string {
+	if u.GetNamespace() == "" {
+		return u.GetName()
+	}
+	return u.GetNamespace() + "/" + u.GetName()
+}
@@ -0,0 +1,7 @@
+module github.com/qingcloudhx/contrib/trigger/lorawan
+
+require (
+	flogo/core v0.9.0
+	github.com/eclipse/paho.mqtt.golang v1.4.3
+	github.com/stretchr/testify v1.3.0
+)
@@ -0,0 +1,161 @@
+package lorawan
+
+import (
+	"flogo/core/data/coerce"
+)
+
+const (
+	NetworkChirpStack = "chirpstack"
+	NetworkTTN        = "ttn"
+)
+
+type Settings struct {
+	Network string `md:"network,required,allowed(chirpstack,ttn)"` // The network server sending the uplinks: chirpstack (v4) or ttn (The Things Stack v3)
+
+	Broker             string `md:"broker"`             // The MQTT broker of the network server (ex. tcp://localhost:1883), the uplinks are received with MQTT if specified
+	Topic              string `md:"topic"`              // The MQTT topic of the uplinks, defaults to application/+/device/+/event/up for chirpstack and v3/+/devices/+/up for ttn
+	QoS                int    `md:"qos"`                // The quality of service of the subscription: 0 (default), 1 or 2
+	ClientID           string `md:"clientId"`           // The client id of the connection, generated if not specified
+	Username           string `md:"username"`           // The user name used to authenticate, the application id for ttn
+	Password           string `md:"password"`           // The password used to authenticate, an API key for ttn
+	CAFile             string `md:"caFile"`             // The PEM file of the CA certificates used to verify the broker
+	CertFile           string `md:"certFile"`           // The PEM file of the client certificate, for brokers requiring client authentication
+	KeyFile            string `md:"keyFile"`            // The PEM file of the client private key
+	InsecureSkipVerify bool   `md:"insecureSkipVerify"` // Don't verify the broker certificate, for testing only
+
+	Port      int    `md:"port"`      // The port of the webhook receiving the uplinks, the uplinks are received with webhook requests if specified
+	Path      string `md:"path"`      // The path of the webhook, defaults to /uplink
+	AuthToken string `md:"authToken"` // The token expected in the Authorization header of the webhook requests, with or without the Bearer scheme
+}
+
+type HandlerSettings struct {
+	Applications string `md:"applications"` // The comma separated ids or names of the applications of the uplinks to handle, defaults to all the applications
+	Devices      string `md:"devices"`      // The comma separated EUIs or names of the devices of the uplinks to handle, defaults to all the devices
+	FPorts       string `md:"fPorts"`       // The comma separated application ports of the uplinks to handle, defaults to all the ports
+}
+
+type Output struct {
+	Network         string                 `md:"network"`         // The network server of the uplink: chirpstack or ttn
+	ApplicationID   string                 `md:"applicationId"`   // The id of the application of the device
+	ApplicationName string                 `md:"applicationName"` // The name of the application, for chirpstack
+	DeviceName      string                 `md:"deviceName"`      // The name of the device, its device id for ttn
+	DevEUI          string                 `md:"devEui"`          // The EUI of the device, in lowercase hexadecimal
+	DevAddr         string                 `md:"devAddr"`         // The address of the device in the network, in lowercase hexadecimal
+	FCnt            int64                  `md:"fCnt"`            // The frame counter of the uplink
+	FPort           int                    `md:"fPort"`           // The application port of the uplink
+	Confirmed       bool                   `md:"confirmed"`       // Whether the uplink is confirmed
+	Payload         string                 `md:"payload"`         // The raw payload of the uplink, in lowercase hexadecimal
+	Decoded         interface{}            `md:"decoded"`         // The payload decoded by the codec of the device on the network server
+	RSSI            int                    `md:"rssi"`            // The best signal strength of the gateways which received the uplink, in dBm
+	SNR             float64                `md:"snr"`             // The signal to noise ratio of the gateway with the best signal strength, in dB
+	Gateways        []interface{}          `md:"gateways"`        // The gateways which received the uplink, with their gatewayId, rssi and snr
+	Frequency       int64                  `md:"frequency"`       // The frequency of the uplink, in Hz
+	SpreadingFactor int                    `md:"spreadingFactor"` // The LoRa spreading factor of the uplink
+	Time            int64                  `md:"time"`            // The time the uplink was received, in milliseconds since epoch
+	Tags            map[string]string      `md:"tags"`            // The tags of the device, for chirpstack
+	Uplink          map[string]interface{} `md:"uplink"`          // The message of the uplink sent by the network server
+}
+
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"network":         o.Network,
+		"applicationId":   o.ApplicationID,
+		"applicationName": o.ApplicationName,
+		"deviceName":      o.DeviceName,
+		"devEui":          o.DevEUI,
+		"devAddr":         o.DevAddr,
+		"fCnt":            o.FCnt,
+		"fPort":           o.FPort,
+		"confirmed":       o.Confirmed,
+		"payload":         o.Payload,
+		"decoded":         o.Decoded,
+		"rssi":            o.RSSI,
+		"snr":             o.SNR,
+		"gateways":        o.Gateways,
+		"frequency":       o.Frequency,
+		"spreadingFactor": o.SpreadingFactor,
+		"time":            o.Time,
+		"tags":            o.Tags,
+		"uplink":          o.Uplink,
+	}
+}
+
+func (o *Output) FromMap(values map[string]interface{}) error {
+
+	var err error
+	o.Network, err = coerce.ToString(values["network"])
+	if err != nil {
+		return err
+	}
+	o.ApplicationID, err = coerce.ToString(values["applicationId"])
+	if err != nil {
+		return err
+	}
+	o.ApplicationName, err = coerce.ToString(values["applicationName"])
+	if err != nil {
+		return err
+	}
+	o.DeviceName, err = coerce.ToString(values["deviceName"])
+	if err != nil {
+		return err
+	}
+	o.DevEUI, err = coerce.ToString(values["devEui"])
+	if err != nil {
+		return err
+	}
+	o.DevAddr, err = coerce.ToString(values["devAddr"])
+	if err != nil {
+		return err
+	}
+	o.FCnt, err = coerce.ToInt64(values["fCnt"])
+	if err != nil {
+		return err
+	}
+	o.FPort, err = coerce.ToInt(values["fPort"])
+	if err != nil {
+		return err
+	}
+	o.Confirmed, err = coerce.ToBool(values["confirmed"])
+	if err != nil {
+		return err
+	}
+	o.Payload, err = coerce.ToString(values["payload"])
+	if err != nil {
+		return err
+	}
+	o.Decoded = values["decoded"]
+	o.RSSI, err = coerce.ToInt(values["rssi"])
+	if err != nil {
+		return err
+	}
+	o.SNR, err = coerce.ToFloat64(values["snr"])
+	if err != nil {
+		return err
+	}
+	o.Gateways, err = coerce.ToArray(values["gateways"])
+	if err != nil {
+		return err
+	}
+	o.Frequency, err = coerce.ToInt64(values["frequency"])
+	if err != nil {
+		return err
+	}
+	o.SpreadingFactor, err = coerce.ToInt(values["spreadingFactor"])
+	if err != nil {
+		return err
+	}
+	o.Time, err = coerce.ToInt64(values["time"])
+	if err != nil {
+		return err
+	}
+	o.Tags, err = coerce.ToParams(values["tags"])
+	if err != nil {
+		return err
+	}
+	o.Uplink, err = coerce.ToObject(values["uplink"])
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
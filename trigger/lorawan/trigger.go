@@ -0,0 +1,382 @@
+package lorawan
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"flogo/core/data/metadata"
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+const (
+	defaultPath    = "/uplink"
+	maxBodySize    = 1024 * 1024
+	connectTimeout = 30 * time.Second
+)
+
+// the MQTT topics of the uplinks of all the devices
+var defaultTopics = map[string]string{
+	NetworkChirpStack: "application/+/device/+/event/up",
+	NetworkTTN:        "v3/+/devices/+/up",
+}
+
+var triggerMd = trigger.NewMetadata(&Settings{}, &HandlerSettings{}, &Output{})
+
+func init() {
+	_ = trigger.Register(&Trigger{}, &Factory{})
+}
+
+type Factory struct {
+}
+
+// Metadata implements trigger.Factory.Metadata
+func (*Factory) Metadata() *trigger.Metadata {
+	return triggerMd
+}
+
+// New implements trigger.Factory.New
+func (*Factory) New(config *trigger.Config) (trigger.Trigger, error) {
+
+	s := &Settings{}
+	err := metadata.MapToStruct(config.Settings, s, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Broker == "" && s.Port == 0 {
+		return nil, errors.New("a broker or a port is required to receive the uplinks")
+	}
+	if s.QoS < 0 || s.QoS > 2 {
+		return nil, fmt.Errorf("invalid QoS %d, the QoS is 0, 1 or 2", s.QoS)
+	}
+	if s.Topic == "" {
+		s.Topic = defaultTopics[s.Network]
+	}
+	if s.Path == "" {
+		s.Path = defaultPath
+	}
+
+	return &Trigger{settings: s}, nil
+}
+
+// Trigger receives the uplinks of the LoRaWAN devices from the MQTT integration or the webhooks of
+// ChirpStack or The Things Stack
+type Trigger struct {
+	settings *Settings
+	logger   log.Logger
+	handlers []*uplinkHandler
+
+	client   paho.Client
+	mux      *http.ServeMux
+	server   *http.Server
+	listener net.Listener
+}
+
+// uplinkHandler is a handler with the applications, devices and ports of the uplinks it handles
+type uplinkHandler struct {
+	handler      trigger.Handler
+	applications map[string]bool
+	devices      map[string]bool
+	fPorts       map[int]bool
+}
+
+// Initialize implements trigger.Init.Initialize
+func (t *Trigger) Initialize(ctx trigger.InitContext) error {
+
+	t.logger = ctx.Logger()
+
+	for _, handler := range ctx.GetHandlers() {
+
+		s := &HandlerSettings{}
+		err := metadata.MapToStruct(handler.Settings(), s, true)
+		if err != nil {
+			return err
+		}
+
+		h, err := newUplinkHandler(handler, s)
+		if err != nil {
+			return err
+		}
+		t.handlers = append(t.handlers, h)
+	}
+
+	if t.settings.Broker != "" {
+		options, err := t.clientOptions()
+		if err != nil {
+			return err
+		}
+		t.client = paho.NewClient(options)
+	}
+
+	if t.settings.Port != 0 {
+		t.mux = http.NewServeMux()
+		t.mux.HandleFunc(t.settings.Path, t.serveHTTP)
+	}
+
+	return nil
+}
+
+func newUplinkHandler(handler trigger.Handler, s *HandlerSettings) (*uplinkHandler, error) {
+
+	h := &uplinkHandler{handler: handler}
+
+	if applications := splitList(s.Applications); len(applications) > 0 {
+		h.applications = make(map[string]bool)
+		for _, application := range applications {
+			h.applications[application] = true
+		}
+	}
+	if devices := splitList(s.Devices); len(devices) > 0 {
+		h.devices = make(map[string]bool)
+		for _, device := range devices {
+			// the EUIs are matched in lowercase, like in the outputs
+			if _, err := hex.DecodeString(device); err == nil && len(device) == 16 {
+				device = strings.ToLower(device)
+			}
+			h.devices[device] = true
+		}
+	}
+	if fPorts := splitList(s.FPorts); len(fPorts) > 0 {
+		h.fPorts = make(map[int]bool)
+		for _, fPort := range fPorts {
+			port, err := strconv.Atoi(fPort)
+			if err != nil || port < 0 || port > 255 {
+				return nil, fmt.Errorf("invalid port '%s'", fPort)
+			}
+			h.fPorts[port] = true
+		}
+	}
+
+	return h, nil
+}
+
+// clientOptions returns the options of the MQTT client, which subscribes to the uplinks each time it connects
+func (t *Trigger) clientOptions() (*paho.ClientOptions, error) {
+
+	s := t.settings
+
+	options := paho.NewClientOptions()
+	for _, broker := range splitList(s.Broker) {
+		options.AddBroker(broker)
+	}
+	options.SetClientID(s.ClientID)
+	options.SetUsername(s.Username)
+	options.SetPassword(s.Password)
+	options.SetConnectTimeout(connectTimeout)
+	options.SetAutoReconnect(true)
+	options.SetConnectRetry(true)
+
+	options.SetOnConnectHandler(func(client paho.Client) {
+		token := client.Subscribe(s.Topic, byte(s.QoS), t.handleMessage)
+		if token.WaitTimeout(connectTimeout) && token.Error() == nil {
+			t.logger.Infof("Subscribed to uplinks of topic '%s'", s.Topic)
+			return
+		}
+		t.logger.Errorf("Unable to subscribe to topic '%s': %v", s.Topic, token.Error())
+	})
+	options.SetConnectionLostHandler(func(client paho.Client, err error) {
+		t.logger.Warnf("Connection to MQTT broker lost: %v", err)
+	})
+
+	if s.CAFile == "" && s.CertFile == "" && !s.InsecureSkipVerify {
+		return options, nil
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: s.InsecureSkipVerify}
+	if s.CAFile != "" {
+		pem, err := ioutil.ReadFile(s.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read CA file '%s': %v", s.CAFile, err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file '%s'", s.CAFile)
+		}
+	}
+	if s.CertFile != "" || s.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(s.CertFile, s.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	options.SetTLSConfig(tlsConfig)
+
+	return options, nil
+}
+
+// Start implements util.Managed.Start
+func (t *Trigger) Start() error {
+
+	if t.mux != nil {
+		ln, err := net.Listen("tcp", ":"+strconv.Itoa(t.settings.Port))
+		if err != nil {
+			return err
+		}
+		t.listener = ln
+
+		// a shut down server can't serve again, each start uses a new one
+		server := &http.Server{Handler: t.mux, ReadHeaderTimeout: 30 * time.Second}
+		t.server = server
+
+		t.logger.Infof("Listening on port %d", t.settings.Port)
+
+		go func() {
+			err := server.Serve(ln)
+			if err != nil && err != http.ErrServerClosed {
+				t.logger.Errorf("Webhook server stopped: %v", err)
+			}
+		}()
+	}
+
+	// the client keeps trying to connect when the broker is not available
+	if t.client != nil {
+		t.client.Connect()
+	}
+
+	return nil
+}
+
+// Stop implements util.Managed.Stop
+func (t *Trigger) Stop() error {
+
+	if t.client != nil {
+		t.client.Disconnect(250)
+	}
+
+	if t.listener == nil {
+		return nil
+	}
+	t.listener = nil
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return t.server.Shutdown(ctx)
+}
+
+// handleMessage handles the uplink of a MQTT message, the uplink of a failed action is not received again
+func (t *Trigger) handleMessage(client paho.Client, msg paho.Message) {
+
+	// the other events of ChirpStack are published under the same topics
+	if t.settings.Network == NetworkChirpStack && strings.Contains(msg.Topic(), "/event/") && !strings.HasSuffix(msg.Topic(), "/event/up") {
+		return
+	}
+
+	out, err := parseUplink(t.settings.Network, msg.Payload())
+	if err != nil {
+		t.logger.Errorf("Invalid uplink of topic '%s': %v", msg.Topic(), err)
+		return
+	}
+	if out == nil {
+		return
+	}
+
+	err = t.handle(context.Background(), out)
+	if err != nil {
+		t.logger.Errorf("Error handling uplink of device '%s': %v", out.DevEUI, err)
+	}
+}
+
+// serveHTTP handles the uplink of a webhook request, the request fails when an action fails
+func (t *Trigger) serveHTTP(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	if token := t.settings.AuthToken; token != "" {
+		auth := r.Header.Get("Authorization")
+		if subtle.ConstantTimeCompare([]byte(auth), []byte(token)) != 1 &&
+			subtle.ConstantTimeCompare([]byte(auth), []byte("Bearer "+token)) != 1 {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+	}
+
+	// the other events of ChirpStack are sent to the same url with their event
+	if event := r.URL.Query().Get("event"); t.settings.Network == NetworkChirpStack && event != "" && event != "up" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	out, err := parseUplink(t.settings.Network, body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid uplink: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	if out != nil {
+		err = t.handle(r.Context(), out)
+		if err != nil {
+			t.logger.Errorf("Error handling uplink of device '%s': %v", out.DevEUI, err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handle invokes the handlers of the uplink, it fails when one of them fails
+func (t *Trigger) handle(ctx context.Context, out *Output) error {
+
+	var failed error
+	for _, h := range t.handlers {
+		if !h.matches(out) {
+			continue
+		}
+		_, err := h.handler.Handle(ctx, out)
+		if err != nil {
+			failed = err
+		}
+	}
+
+	return failed
+}
+
+func (h *uplinkHandler) matches(out *Output) bool {
+
+	if h.applications != nil && !h.applications[out.ApplicationID] && !h.applications[out.ApplicationName] {
+		return false
+	}
+	if h.devices != nil && !h.devices[out.DevEUI] && !h.devices[out.DeviceName] {
+		return false
+	}
+	if h.fPorts != nil && !h.fPorts[out.FPort] {
+		return false
+	}
+
+	return true
+}
+
+func splitList(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,245 @@
+package lorawan
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"flogo/core/action"
+	"flogo/core/api"
+	"flogo/core/support/test"
+	"flogo/core/trigger"
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig string = `{
+	"id": "trigger-lorawan",
+	"ref": "github.com/qingcloudhx/contrib/trigger/lorawan",
+	"settings": {
+	  "network": "ttn",
+	  "port": 8080,
+	  "authToken": "secret"
+	},
+	"handlers": [
+	  {
+		"settings": {
+		  "devices": "0004A30B001C0530"
+		},
+		"action": {
+		  "id": "test"
+		}
+	  }
+	]
+}`
+
+// testMessage is a MQTT message received by the trigger
+type testMessage struct {
+	paho.Message
+	topic   string
+	payload string
+}
+
+func (m *testMessage) Topic() string {
+	return m.topic
+}
+
+func (m *testMessage) Payload() []byte {
+	return []byte(m.payload)
+}
+
+func TestFactory_New(t *testing.T) {
+
+	f := &Factory{}
+
+	_, err := f.New(&trigger.Config{Settings: map[string]interface{}{"network": "chirpstack"}})
+	assert.NotNil(t, err)
+
+	_, err = f.New(&trigger.Config{Settings: map[string]interface{}{"network": "loriot", "port": 8080}})
+	assert.NotNil(t, err)
+
+	_, err = f.New(&trigger.Config{Settings: map[string]interface{}{"network": "ttn", "broker": "tcp://localhost:1883", "qos": 3}})
+	assert.NotNil(t, err)
+
+	trg, err := f.New(&trigger.Config{Settings: map[string]interface{}{"network": "ttn", "broker": "tcp://localhost:1883"}})
+	assert.Nil(t, err)
+	assert.Equal(t, "v3/+/devices/+/up", trg.(*Trigger).settings.Topic)
+	assert.Equal(t, "/uplink", trg.(*Trigger).settings.Path)
+}
+
+func TestTrigger_Initialize(t *testing.T) {
+
+	config := &trigger.Config{}
+	err := json.Unmarshal([]byte(testConfig), config)
+	assert.Nil(t, err)
+	config.Handlers[0].Settings = map[string]interface{}{"fPorts": "1,256"}
+
+	_, err = test.InitTrigger(&Factory{}, config, map[string]action.Action{"test": test.NewDummyAction(func() {
+		//do nothing
+	})})
+	assert.NotNil(t, err)
+}
+
+func TestTrigger_HandleMessage(t *testing.T) {
+
+	topic := "application/17c82e96-be03-4f38-aef3-f83d48582d97/device/0101010101010101/event/"
+
+	tests := []struct {
+		settings map[string]interface{}
+		devices  []string
+	}{
+		{map[string]interface{}{"applications": "sensors"}, []string{"greenhouse-1", "greenhouse-3"}},
+		{map[string]interface{}{"devices": "0101010101010101, greenhouse-3"}, []string{"greenhouse-1", "greenhouse-3"}},
+		{map[string]interface{}{"fPorts": "1"}, nil},
+	}
+
+	for _, tt := range tests {
+		var devices []string
+		actions := map[string]action.Action{"test": api.NewProxyAction(func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+			out := &Output{}
+			if err := out.FromMap(inputs); err != nil {
+				return nil, err
+			}
+			devices = append(devices, out.DeviceName)
+			return nil, nil
+		})}
+
+		config := &trigger.Config{}
+		err := json.Unmarshal([]byte(testConfig), config)
+		assert.Nil(t, err)
+		config.Settings = map[string]interface{}{"network": "chirpstack", "broker": "tcp://localhost:1883"}
+		config.Handlers[0].Settings = tt.settings
+		trg, err := test.InitTrigger(&Factory{}, config, actions)
+		assert.Nil(t, err)
+		tgr := trg.(*Trigger)
+
+		tgr.handleMessage(nil, &testMessage{topic: topic + "up", payload: chirpStackUp})
+		tgr.handleMessage(nil, &testMessage{topic: topic + "up", payload: strings.Replace(chirpStackUp, "greenhouse-1", "greenhouse-3", 1)})
+		tgr.handleMessage(nil, &testMessage{topic: topic + "join", payload: chirpStackUp})
+		tgr.handleMessage(nil, &testMessage{topic: topic + "up", payload: "{"})
+
+		assert.Equal(t, tt.devices, devices, tt.settings)
+	}
+}
+
+func TestTrigger_ServeHTTP(t *testing.T) {
+
+	var devices []string
+	var actionErr error
+	actions := map[string]action.Action{"test": api.NewProxyAction(func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+		out := &Output{}
+		if err := out.FromMap(inputs); err != nil {
+			return nil, err
+		}
+		devices = append(devices, out.DeviceName)
+		return nil, actionErr
+	})}
+
+	config := &trigger.Config{}
+	err := json.Unmarshal([]byte(testConfig), config)
+	assert.Nil(t, err)
+	trg, err := test.InitTrigger(&Factory{}, config, actions)
+	assert.Nil(t, err)
+	tgr := trg.(*Trigger)
+
+	send := func(method, token, body string) int {
+		r := httptest.NewRequest(method, "/uplink", strings.NewReader(body))
+		if token != "" {
+			r.Header.Set("Authorization", token)
+		}
+		w := httptest.NewRecorder()
+		tgr.mux.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusMethodNotAllowed, send(http.MethodGet, "Bearer secret", ""))
+	assert.Equal(t, http.StatusUnauthorized, send(http.MethodPost, "Bearer other", ttnUp))
+	assert.Equal(t, http.StatusBadRequest, send(http.MethodPost, "secret", "{"))
+	assert.Equal(t, http.StatusNoContent, send(http.MethodPost, "Bearer secret", ttnUp))
+	assert.Equal(t, http.StatusNoContent, send(http.MethodPost, "Bearer secret", `{"end_device_ids": {}, "join_accept": {}}`))
+	assert.Equal(t, []string{"greenhouse-2"}, devices)
+
+	actionErr = errors.New("failed")
+	assert.Equal(t, http.StatusInternalServerError, send(http.MethodPost, "Bearer secret", ttnUp))
+}
+
+func TestTrigger_ServeHTTP_ChirpStackEvents(t *testing.T) {
+
+	var devices []string
+	actions := map[string]action.Action{"test": api.NewProxyAction(func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+		out := &Output{}
+		if err := out.FromMap(inputs); err != nil {
+			return nil, err
+		}
+		devices = append(devices, out.DeviceName)
+		return nil, nil
+	})}
+
+	config := &trigger.Config{}
+	err := json.Unmarshal([]byte(testConfig), config)
+	assert.Nil(t, err)
+	config.Settings = map[string]interface{}{"network": "chirpstack", "port": 8080}
+	config.Handlers[0].Settings = map[string]interface{}{}
+	trg, err := test.InitTrigger(&Factory{}, config, actions)
+	assert.Nil(t, err)
+	tgr := trg.(*Trigger)
+
+	for _, event := range []string{"up", "join", "status"} {
+		r := httptest.NewRequest(http.MethodPost, "/uplink?event="+event, strings.NewReader(chirpStackUp))
+		w := httptest.NewRecorder()
+		tgr.mux.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	}
+
+	assert.Equal(t, []string{"greenhouse-1"}, devices)
+}
+
+func TestTrigger_Restart(t *testing.T) {
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	handled := 0
+	actions := map[string]action.Action{"test": api.NewProxyAction(func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+		handled++
+		return nil, nil
+	})}
+
+	config := &trigger.Config{}
+	err = json.Unmarshal([]byte(testConfig), config)
+	assert.Nil(t, err)
+	config.Settings["port"] = port
+	trg, err := test.InitTrigger(&Factory{}, config, actions)
+	assert.Nil(t, err)
+
+	post := func() int {
+		r, err := http.NewRequest(http.MethodPost, fmt.Sprintf("http://127.0.0.1:%d/uplink", port), strings.NewReader(ttnUp))
+		assert.Nil(t, err)
+		r.Header.Set("Authorization", "Bearer secret")
+		// the connections of a stopped trigger are closed
+		r.Close = true
+		resp, err := http.DefaultClient.Do(r)
+		if err != nil {
+			return 0
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// the trigger serves the requests again once restarted
+	for i := 1; i <= 2; i++ {
+		assert.Nil(t, trg.Start())
+		assert.Equal(t, http.StatusNoContent, post())
+		assert.Equal(t, i, handled)
+		assert.Nil(t, trg.Stop())
+	}
+	assert.Equal(t, 0, post())
+}
@@ -0,0 +1,192 @@
+package lorawan
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// chirpStackUplink is the up event of ChirpStack v4, as encoded in JSON by its MQTT and HTTP integrations
+type chirpStackUplink struct {
+	Time       string `json:"time"`
+	DeviceInfo *struct {
+		ApplicationID   string            `json:"applicationId"`
+		ApplicationName string            `json:"applicationName"`
+		DeviceName      string            `json:"deviceName"`
+		DevEUI          string            `json:"devEui"`
+		Tags            map[string]string `json:"tags"`
+	} `json:"deviceInfo"`
+	DevAddr   string      `json:"devAddr"`
+	FCnt      int64       `json:"fCnt"`
+	FPort     int         `json:"fPort"`
+	Confirmed bool        `json:"confirmed"`
+	Data      []byte      `json:"data"`
+	Object    interface{} `json:"object"`
+	RxInfo    []struct {
+		GatewayID string  `json:"gatewayId"`
+		RSSI      int     `json:"rssi"`
+		SNR       float64 `json:"snr"`
+	} `json:"rxInfo"`
+	TxInfo struct {
+		Frequency  int64 `json:"frequency"`
+		Modulation struct {
+			LoRa struct {
+				SpreadingFactor int `json:"spreadingFactor"`
+			} `json:"lora"`
+		} `json:"modulation"`
+	} `json:"txInfo"`
+}
+
+// ttnUplink is the uplink message of The Things Stack v3, as sent by its MQTT server and webhooks
+type ttnUplink struct {
+	EndDeviceIDs struct {
+		DeviceID       string `json:"device_id"`
+		ApplicationIDs struct {
+			ApplicationID string `json:"application_id"`
+		} `json:"application_ids"`
+		DevEUI  string `json:"dev_eui"`
+		DevAddr string `json:"dev_addr"`
+	} `json:"end_device_ids"`
+	ReceivedAt    string `json:"received_at"`
+	UplinkMessage *struct {
+		FPort          int         `json:"f_port"`
+		FCnt           int64       `json:"f_cnt"`
+		Confirmed      bool        `json:"confirmed"`
+		FrmPayload     []byte      `json:"frm_payload"`
+		DecodedPayload interface{} `json:"decoded_payload"`
+		RxMetadata     []struct {
+			GatewayIDs struct {
+				GatewayID string `json:"gateway_id"`
+			} `json:"gateway_ids"`
+			RSSI int     `json:"rssi"`
+			SNR  float64 `json:"snr"`
+		} `json:"rx_metadata"`
+		Settings struct {
+			DataRate struct {
+				LoRa struct {
+					SpreadingFactor int `json:"spreading_factor"`
+				} `json:"lora"`
+			} `json:"data_rate"`
+			Frequency string `json:"frequency"`
+		} `json:"settings"`
+	} `json:"uplink_message"`
+}
+
+// parseUplink parses the uplink message of the network server, the other messages have no output
+func parseUplink(network string, body []byte) (*Output, error) {
+
+	var out *Output
+	var err error
+	if network == NetworkTTN {
+		out, err = parseTTNUplink(body)
+	} else {
+		out, err = parseChirpStackUplink(body)
+	}
+	if out == nil || err != nil {
+		return nil, err
+	}
+
+	out.Network = network
+	if out.Gateways == nil {
+		out.Gateways = make([]interface{}, 0)
+	}
+	err = json.Unmarshal(body, &out.Uplink)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func parseChirpStackUplink(body []byte) (*Output, error) {
+
+	up := &chirpStackUplink{}
+	err := json.Unmarshal(body, up)
+	if err != nil {
+		return nil, err
+	}
+	if up.DeviceInfo == nil {
+		return nil, nil
+	}
+
+	out := &Output{
+		ApplicationID:   up.DeviceInfo.ApplicationID,
+		ApplicationName: up.DeviceInfo.ApplicationName,
+		DeviceName:      up.DeviceInfo.DeviceName,
+		DevEUI:          strings.ToLower(up.DeviceInfo.DevEUI),
+		DevAddr:         strings.ToLower(up.DevAddr),
+		FCnt:            up.FCnt,
+		FPort:           up.FPort,
+		Confirmed:       up.Confirmed,
+		Payload:         hex.EncodeToString(up.Data),
+		Decoded:         up.Object,
+		Frequency:       up.TxInfo.Frequency,
+		SpreadingFactor: up.TxInfo.Modulation.LoRa.SpreadingFactor,
+		Time:            toTime(up.Time),
+		Tags:            up.DeviceInfo.Tags,
+	}
+	for _, rx := range up.RxInfo {
+		out.addGateway(rx.GatewayID, rx.RSSI, rx.SNR)
+	}
+
+	return out, nil
+}
+
+func parseTTNUplink(body []byte) (*Output, error) {
+
+	up := &ttnUplink{}
+	err := json.Unmarshal(body, up)
+	if err != nil {
+		return nil, err
+	}
+	msg := up.UplinkMessage
+	if msg == nil {
+		return nil, nil
+	}
+
+	ids := up.EndDeviceIDs
+	out := &Output{
+		ApplicationID:   ids.ApplicationIDs.ApplicationID,
+		DeviceName:      ids.DeviceID,
+		DevEUI:          strings.ToLower(ids.DevEUI),
+		DevAddr:         strings.ToLower(ids.DevAddr),
+		FCnt:            msg.FCnt,
+		FPort:           msg.FPort,
+		Confirmed:       msg.Confirmed,
+		Payload:         hex.EncodeToString(msg.FrmPayload),
+		Decoded:         msg.DecodedPayload,
+		SpreadingFactor: msg.Settings.DataRate.LoRa.SpreadingFactor,
+		Time:            toTime(up.ReceivedAt),
+	}
+	// the 64 bits integers are encoded as strings by The Things Stack
+	out.Frequency, _ = strconv.ParseInt(msg.Settings.Frequency, 10, 64)
+	for _, rx := range msg.RxMetadata {
+		out.addGateway(rx.GatewayIDs.GatewayID, rx.RSSI, rx.SNR)
+	}
+
+	return out, nil
+}
+
+// addGateway adds a gateway which received the uplink, the signal of the best one being the one of the uplink
+func (o *Output) addGateway(id string, rssi int, snr float64) {
+
+	if len(o.Gateways) == 0 || rssi > o.RSSI {
+		o.RSSI = rssi
+		o.SNR = snr
+	}
+	o.Gateways = append(o.Gateways, map[string]interface{}{
+		"gatewayId": id,
+		"rssi":      rssi,
+		"snr":       snr,
+	})
+}
+
+// toTime returns the time in milliseconds since epoch, or 0 when not specified
+func toTime(s string) int64 {
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t.UnixNano() / int64(time.Millisecond)
+	}
+	return 0
+}
@@ -0,0 +1,122 @@
+package lorawan
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const chirpStackUp = `{
+	"deduplicationId": "3ac7e3c4-4401-4b8d-9386-a5c902f9202d",
+	"time": "2023-05-22T07:47:29.467Z",
+	"deviceInfo": {
+		"tenantId": "52f14cd4-c6f1-4fbd-8f87-4025e1d49242",
+		"tenantName": "ChirpStack",
+		"applicationId": "17c82e96-be03-4f38-aef3-f83d48582d97",
+		"applicationName": "sensors",
+		"deviceProfileId": "14855bf7-d10d-4aee-b618-ebfcb64dc7ad",
+		"deviceProfileName": "Sensor",
+		"deviceName": "greenhouse-1",
+		"devEui": "0101010101010101",
+		"tags": {"site": "lyon"}
+	},
+	"devAddr": "00189440",
+	"adr": true,
+	"dr": 5,
+	"fCnt": 7,
+	"fPort": 2,
+	"confirmed": true,
+	"data": "AQIDBA==",
+	"object": {"temperature": 21.5},
+	"rxInfo": [
+		{"gatewayId": "0016c001ff10a235", "uplinkId": 4217106255, "rssi": -104, "snr": -2.5},
+		{"gatewayId": "0016c001ff10d3f6", "uplinkId": 2426045281, "rssi": -57, "snr": 10.5}
+	],
+	"txInfo": {
+		"frequency": 868100000,
+		"modulation": {"lora": {"bandwidth": 125000, "spreadingFactor": 7, "codeRate": "CR_4_5"}}
+	}
+}`
+
+const ttnUp = `{
+	"end_device_ids": {
+		"device_id": "greenhouse-2",
+		"application_ids": {"application_id": "sensors"},
+		"dev_eui": "0004A30B001C0530",
+		"join_eui": "800000000000000C",
+		"dev_addr": "00BCB929"
+	},
+	"received_at": "2023-05-22T07:47:29.467Z",
+	"uplink_message": {
+		"f_port": 1,
+		"f_cnt": 45,
+		"frm_payload": "vu8=",
+		"decoded_payload": {"humidity": 60},
+		"rx_metadata": [
+			{"gateway_ids": {"gateway_id": "gw-1", "eui": "B827EBFFFE7FE28A"}, "rssi": -42, "channel_rssi": -42, "snr": 4.2}
+		],
+		"settings": {
+			"data_rate": {"lora": {"bandwidth": 125000, "spreading_factor": 9}},
+			"frequency": "868300000"
+		}
+	}
+}`
+
+func TestParseUplink_ChirpStack(t *testing.T) {
+
+	out, err := parseUplink(NetworkChirpStack, []byte(chirpStackUp))
+	assert.Nil(t, err)
+
+	assert.Equal(t, "chirpstack", out.Network)
+	assert.Equal(t, "17c82e96-be03-4f38-aef3-f83d48582d97", out.ApplicationID)
+	assert.Equal(t, "sensors", out.ApplicationName)
+	assert.Equal(t, "greenhouse-1", out.DeviceName)
+	assert.Equal(t, "0101010101010101", out.DevEUI)
+	assert.Equal(t, "00189440", out.DevAddr)
+	assert.Equal(t, int64(7), out.FCnt)
+	assert.Equal(t, 2, out.FPort)
+	assert.True(t, out.Confirmed)
+	assert.Equal(t, "01020304", out.Payload)
+	assert.Equal(t, map[string]interface{}{"temperature": 21.5}, out.Decoded)
+	assert.Equal(t, -57, out.RSSI)
+	assert.Equal(t, 10.5, out.SNR)
+	assert.Len(t, out.Gateways, 2)
+	assert.Equal(t, int64(868100000), out.Frequency)
+	assert.Equal(t, 7, out.SpreadingFactor)
+	assert.Equal(t, int64(1684741649467), out.Time)
+	assert.Equal(t, map[string]string{"site": "lyon"}, out.Tags)
+	assert.Equal(t, "3ac7e3c4-4401-4b8d-9386-a5c902f9202d", out.Uplink["deduplicationId"])
+}
+
+func TestParseUplink_TTN(t *testing.T) {
+
+	out, err := parseUplink(NetworkTTN, []byte(ttnUp))
+	assert.Nil(t, err)
+
+	assert.Equal(t, "ttn", out.Network)
+	assert.Equal(t, "sensors", out.ApplicationID)
+	assert.Equal(t, "greenhouse-2", out.DeviceName)
+	assert.Equal(t, "0004a30b001c0530", out.DevEUI)
+	assert.Equal(t, "00bcb929", out.DevAddr)
+	assert.Equal(t, int64(45), out.FCnt)
+	assert.Equal(t, 1, out.FPort)
+	assert.Equal(t, "beef", out.Payload)
+	assert.Equal(t, map[string]interface{}{"humidity": float64(60)}, out.Decoded)
+	assert.Equal(t, -42, out.RSSI)
+	assert.Equal(t, 4.2, out.SNR)
+	assert.Equal(t, []interface{}{map[string]interface{}{"gatewayId": "gw-1", "rssi": -42, "snr": 4.2}}, out.Gateways)
+	assert.Equal(t, int64(868300000), out.Frequency)
+	assert.Equal(t, 9, out.SpreadingFactor)
+	assert.Equal(t, int64(1684741649467), out.Time)
+}
+
+func TestParseUplink_Other(t *testing.T) {
+
+	// a join accept of The Things Stack
+	out, err := parseUplink(NetworkTTN, []byte(`{"end_device_ids": {"device_id": "greenhouse-2"}, "join_accept": {"session_key_id": "AXBSH1Pk6Z0G166jI0ZLSg=="}}`))
+	assert.Nil(t, err)
+	assert.Nil(t, out)
+
+	_, err = parseUplink(NetworkChirpStack, []byte(`not json`))
+	assert.NotNil(t, err)
+}
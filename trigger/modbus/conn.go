@@ -0,0 +1,123 @@
+package modbus
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/goburrow/modbus"
+)
+
+// reader reads the tables of the devices
+type reader interface {
+	read(slaveID byte, table string, address, quantity uint16) ([]byte, error)
+}
+
+// transport is the TCP or RTU handler of the client
+type transport interface {
+	modbus.ClientHandler
+	Close() error
+}
+
+// conn shares the connection to the devices between the handlers, the requests being sent one at
+// a time, with the unit id of the handler sending it
+type conn struct {
+	mu        sync.Mutex
+	transport transport
+	setSlave  func(id byte)
+	client    modbus.Client
+}
+
+func newConn(s *Settings) (*conn, error) {
+
+	var timeout time.Duration
+	if s.Timeout != "" {
+		var err error
+		timeout, err = time.ParseDuration(s.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout '%s': %v", s.Timeout, err)
+		}
+	}
+
+	c := &conn{}
+
+	switch s.Mode {
+	case "", ModeTCP:
+		handler := modbus.NewTCPClientHandler(s.Address)
+		if timeout > 0 {
+			handler.Timeout = timeout
+		}
+		c.transport = handler
+		c.setSlave = func(id byte) { handler.SlaveId = id }
+	case ModeRTU:
+		handler := modbus.NewRTUClientHandler(s.Address)
+		handler.BaudRate = s.BaudRate
+		if handler.BaudRate == 0 {
+			handler.BaudRate = 19200
+		}
+		handler.DataBits = s.DataBits
+		if handler.DataBits == 0 {
+			handler.DataBits = 8
+		}
+		handler.Parity = s.Parity
+		if handler.Parity == "" {
+			handler.Parity = "E"
+		}
+		handler.StopBits = s.StopBits
+		if handler.StopBits == 0 {
+			handler.StopBits = 1
+		}
+		if timeout > 0 {
+			handler.Timeout = timeout
+		}
+		c.transport = handler
+		c.setSlave = func(id byte) { handler.SlaveId = id }
+	default:
+		return nil, fmt.Errorf("unsupported mode '%s'", s.Mode)
+	}
+
+	c.client = modbus.NewClient(c.transport)
+	return c, nil
+}
+
+// read reads the coils or registers of a table, the connection is reopened by the next request
+// after a failure, except for the exceptions replied by the device
+func (c *conn) read(slaveID byte, table string, address, quantity uint16) ([]byte, error) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.setSlave(slaveID)
+
+	var results []byte
+	var err error
+	switch table {
+	case TableCoils:
+		results, err = c.client.ReadCoils(address, quantity)
+	case TableDiscreteInputs:
+		results, err = c.client.ReadDiscreteInputs(address, quantity)
+	case TableHoldingRegisters:
+		results, err = c.client.ReadHoldingRegisters(address, quantity)
+	case TableInputRegisters:
+		results, err = c.client.ReadInputRegisters(address, quantity)
+	default:
+		return nil, fmt.Errorf("unsupported table '%s'", table)
+	}
+
+	if err != nil {
+		if _, ok := err.(*modbus.ModbusError); !ok {
+			_ = c.transport.Close()
+		}
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (c *conn) close() error {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.transport.Close()
+}
@@ -0,0 +1,174 @@
+package modbus
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// registerCounts are the numbers of registers of the values of each data type
+var registerCounts = map[string]int{
+	"int16":   1,
+	"uint16":  1,
+	"int32":   2,
+	"uint32":  2,
+	"float32": 2,
+	"int64":   4,
+	"uint64":  4,
+	"float64": 4,
+}
+
+// decoder converts the coils or registers read to the values of a handler
+type decoder struct {
+	coils        bool
+	dataType     string
+	registers    int
+	littleBytes  bool
+	littleWords  bool
+	scale        float64
+	offset       float64
+	scaled       bool
+	quantity     int
+	readQuantity int
+}
+
+func newDecoder(s *HandlerSettings) (*decoder, error) {
+
+	d := &decoder{
+		dataType:    s.DataType,
+		quantity:    s.Quantity,
+		littleBytes: s.ByteOrder == "little",
+		littleWords: s.WordOrder == "little",
+		scale:       s.Scale,
+		offset:      s.Offset,
+		scaled:      s.Scale != 0 || s.Offset != 0,
+	}
+	if d.quantity == 0 {
+		d.quantity = 1
+	}
+	if d.quantity < 0 {
+		return nil, fmt.Errorf("invalid quantity %d", s.Quantity)
+	}
+	if d.scale == 0 {
+		d.scale = 1
+	}
+
+	switch s.Table {
+	case TableCoils, TableDiscreteInputs:
+		if d.dataType != "" && d.dataType != "bool" {
+			return nil, fmt.Errorf("the values of %s are bool, not %s", s.Table, d.dataType)
+		}
+		d.coils = true
+		d.dataType = "bool"
+		d.readQuantity = d.quantity
+		if d.readQuantity > 2000 {
+			return nil, fmt.Errorf("at most 2000 %s can be read at once", s.Table)
+		}
+	case TableHoldingRegisters, TableInputRegisters:
+		if d.dataType == "" {
+			d.dataType = "uint16"
+		}
+		d.registers = registerCounts[d.dataType]
+		if d.registers == 0 {
+			return nil, fmt.Errorf("unsupported data type '%s' for %s", d.dataType, s.Table)
+		}
+		d.readQuantity = d.quantity * d.registers
+		if d.readQuantity > 125 {
+			return nil, fmt.Errorf("at most 125 registers can be read at once, %d %s values require %d", d.quantity, d.dataType, d.readQuantity)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported table '%s'", s.Table)
+	}
+
+	return d, nil
+}
+
+// decode returns the values of the bits of coils, or of the bytes of registers
+func (d *decoder) decode(data []byte) ([]interface{}, error) {
+
+	values := make([]interface{}, d.quantity)
+
+	if d.coils {
+		if len(data)*8 < d.quantity {
+			return nil, fmt.Errorf("expected %d coils, got %d bytes", d.quantity, len(data))
+		}
+		for i := range values {
+			values[i] = data[i/8]&(1<<uint(i%8)) != 0
+		}
+		return values, nil
+	}
+
+	size := d.registers * 2
+	if len(data) < d.quantity*size {
+		return nil, fmt.Errorf("expected %d registers, got %d bytes", d.readQuantity, len(data))
+	}
+
+	for i := range values {
+		b := d.order(data[i*size : (i+1)*size])
+
+		var value interface{}
+		switch d.dataType {
+		case "int16":
+			value = int64(int16(binary.BigEndian.Uint16(b)))
+		case "uint16":
+			value = int64(binary.BigEndian.Uint16(b))
+		case "int32":
+			value = int64(int32(binary.BigEndian.Uint32(b)))
+		case "uint32":
+			value = int64(binary.BigEndian.Uint32(b))
+		case "float32":
+			value = float64(math.Float32frombits(binary.BigEndian.Uint32(b)))
+		case "int64":
+			value = int64(binary.BigEndian.Uint64(b))
+		case "uint64":
+			value = binary.BigEndian.Uint64(b)
+		case "float64":
+			value = math.Float64frombits(binary.BigEndian.Uint64(b))
+		}
+
+		if d.scaled {
+			value = toFloat(value)*d.scale + d.offset
+		}
+		values[i] = value
+	}
+
+	return values, nil
+}
+
+// order returns the big endian bytes of the registers of a value
+func (d *decoder) order(b []byte) []byte {
+
+	if !d.littleBytes && !d.littleWords {
+		return b
+	}
+
+	ordered := make([]byte, len(b))
+	copy(ordered, b)
+
+	if d.littleBytes {
+		for i := 0; i < len(ordered); i += 2 {
+			ordered[i], ordered[i+1] = ordered[i+1], ordered[i]
+		}
+	}
+	if d.littleWords {
+		for i, j := 0, len(ordered)-2; i < j; i, j = i+2, j-2 {
+			ordered[i], ordered[i+1], ordered[j], ordered[j+1] = ordered[j], ordered[j+1], ordered[i], ordered[i+1]
+		}
+	}
+
+	return ordered
+}
+
+func toFloat(value interface{}) float64 {
+
+	switch v := value.(type) {
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float64:
+		return v
+	}
+
+	return 0
+}
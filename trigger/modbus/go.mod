@@ -0,0 +1,8 @@
+module github.com/qingcloudhx/contrib/trigger/modbus
+
+require (
+	flogo/core v0.9.0
+	github.com/goburrow/modbus v0.1.0
+	github.com/goburrow/serial v0.1.0 // indirect
+	github.com/stretchr/testify v1.3.0
+)
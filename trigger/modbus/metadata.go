@@ -0,0 +1,87 @@
+package modbus
+
+import (
+	"flogo/core/data/coerce"
+)
+
+const (
+	ModeTCP = "tcp"
+	ModeRTU = "rtu"
+
+	TableCoils            = "coils"
+	TableDiscreteInputs   = "discreteInputs"
+	TableHoldingRegisters = "holdingRegisters"
+	TableInputRegisters   = "inputRegisters"
+)
+
+type Settings struct {
+	Mode     string `md:"mode,allowed(tcp,rtu)"` // How the devices are reached: 'tcp' (default) or 'rtu' over a serial line
+	Address  string `md:"address,required"`      // The host and port of the device with tcp (ex. 192.168.1.10:502), the serial device with rtu (ex. /dev/ttyUSB0)
+	BaudRate int    `md:"baudRate"`              // The baud rate of the serial line, defaults to 19200
+	DataBits int    `md:"dataBits"`              // The data bits of the serial line, defaults to 8
+	Parity   string `md:"parity,allowed(N,E,O)"` // The parity of the serial line: N (none), E (even, default) or O (odd)
+	StopBits int    `md:"stopBits"`              // The stop bits of the serial line, defaults to 1
+	Timeout  string `md:"timeout"`               // The timeout of the requests (ex. 2s), defaults to 10s with tcp and 5s with rtu
+}
+
+type HandlerSettings struct {
+	SlaveID      int     `md:"slaveId"`                                                                       // The unit id of the device polled, defaults to 1
+	Table        string  `md:"table,required,allowed(coils,discreteInputs,holdingRegisters,inputRegisters)"`  // The table read: coils, discreteInputs, holdingRegisters or inputRegisters
+	StartAddress int     `md:"startAddress"`                                                                  // The zero-based address of the first coil or register read
+	Quantity     int     `md:"quantity"`                                                                      // The number of values read, defaults to 1
+	DataType     string  `md:"dataType,allowed(bool,int16,uint16,int32,uint32,float32,int64,uint64,float64)"` // The type of the values of registers, spanning 1, 2 or 4 registers, defaults to uint16, the values of coils are bool
+	ByteOrder    string  `md:"byteOrder,allowed(big,little)"`                                                 // The order of the bytes in a register: 'big' (default) or 'little' endian
+	WordOrder    string  `md:"wordOrder,allowed(big,little)"`                                                 // The order of the registers of a value: 'big' (default), the high register first, or 'little'
+	Scale        float64 `md:"scale"`                                                                         // The factor the values of registers are multiplied by, not scaled if not specified
+	Offset       float64 `md:"offset"`                                                                        // The offset added to the values of registers once scaled
+	PollInterval string  `md:"pollInterval"`                                                                  // The interval between two polls (ex. 500ms), defaults to 1s
+	OnChange     bool    `md:"onChange"`                                                                      // Only trigger when the values changed since the last poll
+}
+
+type Output struct {
+	SlaveID      int           `md:"slaveId"`      // The unit id of the device
+	Table        string        `md:"table"`        // The table read
+	StartAddress int           `md:"startAddress"` // The address of the first coil or register read
+	Values       []interface{} `md:"values"`       // The values read, converted to their data type and scaled
+	Value        interface{}   `md:"value"`        // The first value read
+	Timestamp    int64         `md:"timestamp"`    // The time the values were read, in milliseconds since epoch
+}
+
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"slaveId":      o.SlaveID,
+		"table":        o.Table,
+		"startAddress": o.StartAddress,
+		"values":       o.Values,
+		"value":        o.Value,
+		"timestamp":    o.Timestamp,
+	}
+}
+
+func (o *Output) FromMap(values map[string]interface{}) error {
+
+	var err error
+	o.SlaveID, err = coerce.ToInt(values["slaveId"])
+	if err != nil {
+		return err
+	}
+	o.Table, err = coerce.ToString(values["table"])
+	if err != nil {
+		return err
+	}
+	o.StartAddress, err = coerce.ToInt(values["startAddress"])
+	if err != nil {
+		return err
+	}
+	o.Values, err = coerce.ToArray(values["values"])
+	if err != nil {
+		return err
+	}
+	o.Value = values["value"]
+	o.Timestamp, err = coerce.ToInt64(values["timestamp"])
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
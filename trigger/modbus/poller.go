@@ -0,0 +1,134 @@
+package modbus
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+)
+
+const defaultPollInterval = time.Second
+
+// poller reads the coils or registers of a handler at each interval and invokes its action with
+// the values decoded, only when they changed if the handler is triggered on change
+type poller struct {
+	reader   reader
+	handler  trigger.Handler
+	settings *HandlerSettings
+	decoder  *decoder
+	logger   log.Logger
+
+	interval time.Duration
+	last     []interface{}
+	done     chan struct{}
+}
+
+func newPoller(reader reader, handler trigger.Handler, s *HandlerSettings, logger log.Logger) (*poller, error) {
+
+	if s.SlaveID == 0 {
+		s.SlaveID = 1
+	}
+	if s.SlaveID < 0 || s.SlaveID > 255 {
+		return nil, fmt.Errorf("slave id must be between 0 and 255")
+	}
+	if s.StartAddress < 0 || s.StartAddress > 65535 {
+		return nil, fmt.Errorf("start address must be between 0 and 65535")
+	}
+
+	d, err := newDecoder(s)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &poller{reader: reader, handler: handler, settings: s, decoder: d, logger: logger, interval: defaultPollInterval}
+
+	if s.PollInterval != "" {
+		interval, err := time.ParseDuration(s.PollInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid poll interval '%s': %v", s.PollInterval, err)
+		}
+		if interval <= 0 {
+			return nil, fmt.Errorf("poll interval must be positive")
+		}
+		p.interval = interval
+	}
+
+	return p, nil
+}
+
+// run polls the device until the context is done
+func (p *poller) run(ctx context.Context) {
+
+	defer close(p.done)
+
+	s := p.settings
+	p.logger.Infof("Polling %d %s of slave %d from address %d every %v", p.decoder.readQuantity, s.Table, s.SlaveID, s.StartAddress, p.interval)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.poll()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// wait waits for the poller to stop, at most for the timeout
+func (p *poller) wait(timeout time.Duration) {
+
+	if p.done == nil {
+		return
+	}
+
+	select {
+	case <-p.done:
+	case <-time.After(timeout):
+		p.logger.Warnf("Values of slave %d still being handled", p.settings.SlaveID)
+	}
+}
+
+// poll reads the values and invokes the action
+func (p *poller) poll() {
+
+	s := p.settings
+
+	data, err := p.reader.read(byte(s.SlaveID), s.Table, uint16(s.StartAddress), uint16(p.decoder.readQuantity))
+	if err != nil {
+		p.logger.Errorf("Error reading %s of slave %d: %v", s.Table, s.SlaveID, err)
+		return
+	}
+
+	values, err := p.decoder.decode(data)
+	if err != nil {
+		p.logger.Errorf("Error decoding %s of slave %d: %v", s.Table, s.SlaveID, err)
+		return
+	}
+
+	if s.OnChange {
+		if p.last != nil && reflect.DeepEqual(values, p.last) {
+			return
+		}
+		p.last = values
+	}
+
+	out := &Output{
+		SlaveID:      s.SlaveID,
+		Table:        s.Table,
+		StartAddress: s.StartAddress,
+		Values:       values,
+		Value:        values[0],
+		Timestamp:    time.Now().UnixNano() / int64(time.Millisecond),
+	}
+
+	if _, err := p.handler.Handle(context.Background(), out); err != nil {
+		p.logger.Errorf("Error handling %s of slave %d: %v", s.Table, s.SlaveID, err)
+	}
+}
@@ -0,0 +1,104 @@
+package modbus
+
+import (
+	"context"
+	"time"
+
+	"flogo/core/data/metadata"
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+)
+
+var triggerMd = trigger.NewMetadata(&Settings{}, &HandlerSettings{}, &Output{})
+
+func init() {
+	_ = trigger.Register(&Trigger{}, &Factory{})
+}
+
+type Factory struct {
+}
+
+// Metadata implements trigger.Factory.Metadata
+func (*Factory) Metadata() *trigger.Metadata {
+	return triggerMd
+}
+
+// New implements trigger.Factory.New
+func (*Factory) New(config *trigger.Config) (trigger.Trigger, error) {
+
+	s := &Settings{}
+	err := metadata.MapToStruct(config.Settings, s, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Trigger{settings: s}, nil
+}
+
+// Trigger polls the coils and registers of Modbus devices
+type Trigger struct {
+	settings *Settings
+	logger   log.Logger
+	conn     *conn
+	pollers  []*poller
+
+	cancel context.CancelFunc
+}
+
+// Initialize implements trigger.Init.Initialize
+func (t *Trigger) Initialize(ctx trigger.InitContext) error {
+
+	t.logger = ctx.Logger()
+
+	c, err := newConn(t.settings)
+	if err != nil {
+		return err
+	}
+	t.conn = c
+
+	for _, handler := range ctx.GetHandlers() {
+
+		s := &HandlerSettings{}
+		err := metadata.MapToStruct(handler.Settings(), s, true)
+		if err != nil {
+			return err
+		}
+
+		p, err := newPoller(t.conn, handler, s, t.logger)
+		if err != nil {
+			return err
+		}
+		t.pollers = append(t.pollers, p)
+	}
+
+	return nil
+}
+
+// Start implements util.Managed.Start
+func (t *Trigger) Start() error {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+
+	for _, p := range t.pollers {
+		p.done = make(chan struct{})
+		go p.run(ctx)
+	}
+
+	return nil
+}
+
+// Stop implements util.Managed.Stop
+func (t *Trigger) Stop() error {
+
+	if t.cancel != nil {
+		t.cancel()
+		t.cancel = nil
+	}
+
+	for _, p := range t.pollers {
+		p.wait(30 * time.Second)
+	}
+
+	return t.conn.close()
+}
@@ -0,0 +1,285 @@
+package modbus
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"flogo/core/action"
+	"flogo/core/api"
+	"flogo/core/support/log"
+	"flogo/core/support/test"
+	"flogo/core/trigger"
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig string = `{
+	"id": "trigger-modbus",
+	"ref": "github.com/qingcloudhx/contrib/trigger/modbus",
+	"settings": {
+	  "address": "127.0.0.1:502"
+	},
+	"handlers": [
+	  {
+		"settings": {
+		  "table": "holdingRegisters"
+		},
+		"action": {
+		  "id": "test"
+		}
+	  }
+	]
+}`
+
+// send returns a handler function sending the outputs it handles
+func send(outputs chan *Output) api.HandlerFunc {
+	return func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+		out := &Output{}
+		if err := out.FromMap(inputs); err != nil {
+			return nil, err
+		}
+		outputs <- out
+		return nil, nil
+	}
+}
+
+func next(t *testing.T, outputs chan *Output) *Output {
+	select {
+	case out := <-outputs:
+		return out
+	case <-time.After(5 * time.Second):
+		t.Fatal("no values handled")
+		return nil
+	}
+}
+
+// testReader returns its results one after the other, then the last one
+type testReader struct {
+	mu      sync.Mutex
+	results [][]byte
+	err     error
+}
+
+func (r *testReader) read(slaveID byte, table string, address, quantity uint16) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return nil, r.err
+	}
+	result := r.results[0]
+	if len(r.results) > 1 {
+		r.results = r.results[1:]
+	}
+	return result, nil
+}
+
+// serve replies to the Modbus TCP read requests of slave 1 with the coils and registers, an
+// illegal address exception is replied to the other slaves
+func serve(t *testing.T, coils []byte, registers []uint16) net.Listener {
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer c.Close()
+				for {
+					request := make([]byte, 12)
+					if _, err := io.ReadFull(c, request); err != nil {
+						return
+					}
+					function := request[7]
+					address := binary.BigEndian.Uint16(request[8:])
+					quantity := binary.BigEndian.Uint16(request[10:])
+
+					var pdu []byte
+					switch {
+					case request[6] != 1:
+						pdu = []byte{function | 0x80, 2}
+					case function == 1 || function == 2:
+						pdu = []byte{function, byte(len(coils))}
+						pdu = append(pdu, coils...)
+					default:
+						pdu = []byte{function, byte(quantity * 2)}
+						for _, value := range registers[address : address+quantity] {
+							pdu = append(pdu, byte(value>>8), byte(value))
+						}
+					}
+
+					response := make([]byte, 7, 7+len(pdu))
+					copy(response, request[:4])
+					binary.BigEndian.PutUint16(response[4:], uint16(len(pdu)+1))
+					response[6] = request[6]
+					if _, err := c.Write(append(response, pdu...)); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+
+	return l
+}
+
+func TestTrigger(t *testing.T) {
+
+	// 23.5 as a float32 and -2 as an int16
+	l := serve(t, []byte{0x05}, []uint16{0, 0x41bc, 0x0000, 0xfffe})
+	defer l.Close()
+
+	registers, coils, missing := make(chan *Output, 10), make(chan *Output, 10), make(chan *Output, 10)
+
+	// the handlers share the connection
+	app := api.NewApp()
+	trg := app.NewTrigger(&Trigger{}, map[string]interface{}{"address": l.Addr().String(), "timeout": "1s"})
+	for _, h := range []struct {
+		settings map[string]interface{}
+		outputs  chan *Output
+	}{
+		{map[string]interface{}{"table": "holdingRegisters", "startAddress": 1, "dataType": "float32", "pollInterval": "20ms", "onChange": true}, registers},
+		{map[string]interface{}{"table": "coils", "quantity": 3, "pollInterval": "20ms"}, coils},
+		{map[string]interface{}{"table": "inputRegisters", "slaveId": 2, "pollInterval": "20ms"}, missing},
+	} {
+		handler, err := trg.NewHandler(h.settings)
+		assert.Nil(t, err)
+		_, err = handler.NewAction(send(h.outputs))
+		assert.Nil(t, err)
+	}
+
+	e, err := api.NewEngine(app)
+	assert.Nil(t, err)
+	assert.Nil(t, e.Start())
+
+	out := next(t, registers)
+	assert.Equal(t, 1, out.SlaveID)
+	assert.Equal(t, "holdingRegisters", out.Table)
+	assert.Equal(t, 1, out.StartAddress)
+	assert.Equal(t, []interface{}{23.5}, out.Values)
+	assert.Equal(t, 23.5, out.Value)
+	assert.True(t, out.Timestamp > 0)
+
+	out = next(t, coils)
+	assert.Equal(t, []interface{}{true, false, true}, out.Values)
+	next(t, coils)
+
+	assert.Nil(t, e.Stop())
+
+	// the values didn't change and the exception isn't handled
+	assert.Equal(t, 0, len(registers))
+	assert.Equal(t, 0, len(missing))
+}
+
+func TestPoller_OnChange(t *testing.T) {
+
+	reader := &testReader{results: [][]byte{{0, 1}, {0, 1}, {0, 2}, {0, 2}}}
+	outputs := make(chan *Output, 10)
+	config := &trigger.Config{}
+	err := json.Unmarshal([]byte(testConfig), config)
+	assert.Nil(t, err)
+	config.Handlers[0].Settings = map[string]interface{}{"table": TableInputRegisters, "onChange": true}
+	trg, err := test.InitTrigger(&Factory{}, config, map[string]action.Action{"test": api.NewProxyAction(send(outputs))})
+	assert.Nil(t, err)
+	p := trg.(*Trigger).pollers[0]
+	p.reader = reader
+
+	for i := 0; i < 4; i++ {
+		p.poll()
+	}
+	assert.Equal(t, int64(1), next(t, outputs).Value)
+	assert.Equal(t, int64(2), next(t, outputs).Value)
+	assert.Equal(t, 0, len(outputs))
+
+	// the values are handled at each poll unless on change
+	p.settings.OnChange = false
+	p.poll()
+	p.poll()
+	assert.Equal(t, 2, len(outputs))
+
+	// failed reads aren't handled
+	reader.err = errors.New("timeout")
+	p.poll()
+	assert.Equal(t, 2, len(outputs))
+}
+
+func TestNewPoller(t *testing.T) {
+
+	// the handler is only invoked when polling
+	var handler trigger.Handler
+
+	s := &HandlerSettings{Table: TableHoldingRegisters}
+	p, err := newPoller(&testReader{}, handler, s, log.RootLogger())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, s.SlaveID)
+	assert.Equal(t, time.Second, p.interval)
+	assert.Equal(t, "uint16", p.decoder.dataType)
+	assert.Equal(t, 1, p.decoder.readQuantity)
+
+	p, err = newPoller(&testReader{}, handler, &HandlerSettings{Table: TableInputRegisters, DataType: "float64", Quantity: 3}, log.RootLogger())
+	assert.Nil(t, err)
+	assert.Equal(t, 12, p.decoder.readQuantity)
+
+	for _, s := range []*HandlerSettings{
+		{Table: "registers"},
+		{Table: TableCoils, DataType: "uint16"},
+		{Table: TableHoldingRegisters, DataType: "bool"},
+		{Table: TableHoldingRegisters, DataType: "int32", Quantity: 63},
+		{Table: TableCoils, Quantity: 2001},
+		{Table: TableCoils, SlaveID: 256},
+		{Table: TableCoils, StartAddress: 65536},
+		{Table: TableCoils, PollInterval: "often"},
+	} {
+		_, err := newPoller(&testReader{}, handler, s, log.RootLogger())
+		assert.NotNil(t, err, "%+v", s)
+	}
+}
+
+func TestDecode(t *testing.T) {
+
+	tests := []struct {
+		settings *HandlerSettings
+		data     []byte
+		values   []interface{}
+	}{
+		{&HandlerSettings{Table: TableDiscreteInputs, Quantity: 10}, []byte{0x81, 0x02}, []interface{}{true, false, false, false, false, false, false, true, false, true}},
+		{&HandlerSettings{Table: TableHoldingRegisters, Quantity: 2}, []byte{0x01, 0x02, 0xff, 0xff}, []interface{}{int64(258), int64(65535)}},
+		{&HandlerSettings{Table: TableHoldingRegisters, DataType: "int16"}, []byte{0xff, 0xfe}, []interface{}{int64(-2)}},
+		{&HandlerSettings{Table: TableHoldingRegisters, DataType: "int16", ByteOrder: "little"}, []byte{0xfe, 0xff}, []interface{}{int64(-2)}},
+		{&HandlerSettings{Table: TableHoldingRegisters, DataType: "uint32"}, []byte{0x00, 0x01, 0x00, 0x02}, []interface{}{int64(65538)}},
+		{&HandlerSettings{Table: TableHoldingRegisters, DataType: "uint32", WordOrder: "little"}, []byte{0x00, 0x02, 0x00, 0x01}, []interface{}{int64(65538)}},
+		{&HandlerSettings{Table: TableHoldingRegisters, DataType: "int32", ByteOrder: "little", WordOrder: "little"}, []byte{0xfe, 0xff, 0xff, 0xff}, []interface{}{int64(-2)}},
+		{&HandlerSettings{Table: TableInputRegisters, DataType: "float32"}, []byte{0x41, 0xbc, 0x00, 0x00}, []interface{}{23.5}},
+		{&HandlerSettings{Table: TableInputRegisters, DataType: "int64"}, []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xfe}, []interface{}{int64(-2)}},
+		{&HandlerSettings{Table: TableInputRegisters, DataType: "uint64", WordOrder: "little"}, []byte{0x00, 0x04, 0x00, 0x03, 0x00, 0x02, 0x00, 0x01}, []interface{}{uint64(0x0001000200030004)}},
+		{&HandlerSettings{Table: TableInputRegisters, DataType: "float64"}, []byte{0x40, 0x37, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00}, []interface{}{23.5}},
+		{&HandlerSettings{Table: TableInputRegisters, Quantity: 2, Scale: 0.1, Offset: -40}, []byte{0x01, 0x90, 0x00, 0x00}, []interface{}{0.0, -40.0}},
+		{&HandlerSettings{Table: TableInputRegisters, Offset: 1.5}, []byte{0x00, 0x02}, []interface{}{3.5}},
+	}
+
+	for _, test := range tests {
+		d, err := newDecoder(test.settings)
+		assert.Nil(t, err)
+
+		values, err := d.decode(test.data)
+		assert.Nil(t, err)
+		assert.Equal(t, test.values, values, "%+v", test.settings)
+	}
+
+	d, err := newDecoder(&HandlerSettings{Table: TableHoldingRegisters, DataType: "int32", Quantity: 2})
+	assert.Nil(t, err)
+	_, err = d.decode([]byte{0, 1, 0, 2})
+	assert.NotNil(t, err)
+}
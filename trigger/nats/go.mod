@@ -0,0 +1,8 @@
+module github.com/qingcloudhx/contrib/trigger/nats
+
+require (
+	flogo/core v0.9.0
+	github.com/nats-io/nats-server/v2 v2.10.22
+	github.com/nats-io/nats.go v1.37.0
+	github.com/stretchr/testify v1.3.0
+)
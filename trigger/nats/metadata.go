@@ -0,0 +1,131 @@
+package nats
+
+import (
+	"flogo/core/data/coerce"
+)
+
+const (
+	AckExplicit = "explicit"
+	AckAll      = "all"
+	AckNone     = "none"
+
+	DeliverAll  = "all"
+	DeliverNew  = "new"
+	DeliverLast = "last"
+)
+
+type Settings struct {
+	URL       string `md:"url,required"` // The comma separated urls of the servers to connect to (ex. nats://localhost:4222)
+	Name      string `md:"name"`         // The name of the connection, reported to the servers
+	Username  string `md:"username"`     // The user name used to authenticate
+	Password  string `md:"password"`     // The password used to authenticate
+	Token     string `md:"token"`        // The token used to authenticate
+	CredsFile string `md:"credsFile"`    // The credentials file (JWT and NKey seed) used to authenticate
+
+	CAFile             string `md:"caFile"`             // The PEM file of the CA certificates used to verify the servers, enables TLS
+	CertFile           string `md:"certFile"`           // The PEM file of the client certificate, for servers requiring client authentication
+	KeyFile            string `md:"keyFile"`            // The PEM file of the client private key
+	InsecureSkipVerify bool   `md:"insecureSkipVerify"` // Don't verify the server certificates, for testing only
+}
+
+type HandlerSettings struct {
+	Subject string `md:"subject,required"` // The subject to subscribe to, with * and > wildcards (ex. orders.*.created)
+	Queue   string `md:"queue"`            // The queue group to join, the messages of the subject are balanced between its members
+
+	Stream        string `md:"stream"`                               // The JetStream stream to consume from, the subject filters the messages of the stream
+	Durable       string `md:"durable"`                              // The name of the durable JetStream consumer, which resumes from its last acknowledged message
+	AckPolicy     string `md:"ackPolicy,allowed(explicit,all,none)"` // How the JetStream messages are acknowledged: explicit (default) each message once the action succeeded, all the previous messages too, or none
+	DeliverPolicy string `md:"deliverPolicy,allowed(all,new,last)"`  // Where a new JetStream consumer starts: all (default) the messages of the stream, new messages only or the last message
+	AckWait       string `md:"ackWait"`                              // How long the server waits for the acknowledgement of a JetStream message before redelivering it (ex. 1m), defaults to 30s
+	MaxDeliver    int    `md:"maxDeliver"`                           // The max number of deliveries of a JetStream message, unlimited if not specified
+	MaxAckPending int    `md:"maxAckPending"`                        // The max number of unacknowledged JetStream messages delivered to the handler
+}
+
+type Output struct {
+	Subject   string            `md:"subject"`   // The subject of the message
+	Wildcards []string          `md:"wildcards"` // The tokens of the subject matched by the wildcards of the handler subject
+	Message   string            `md:"message"`   // The data of the message
+	Content   interface{}       `md:"content"`   // The data of the message parsed, when it is JSON
+	Headers   map[string]string `md:"headers"`   // The headers of the message
+	ReplyTo   string            `md:"replyTo"`   // The subject the reply is published to, for a request
+	Sequence  int64             `md:"sequence"`  // The sequence of the message in the JetStream stream
+	Delivered int               `md:"delivered"` // The number of times the JetStream message was delivered
+	Timestamp int64             `md:"timestamp"` // The time the JetStream message was stored, in milliseconds since the epoch
+}
+
+type Reply struct {
+	Data interface{} `md:"data"` // The reply of a request, objects are published as JSON
+}
+
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"subject":   o.Subject,
+		"wildcards": o.Wildcards,
+		"message":   o.Message,
+		"content":   o.Content,
+		"headers":   o.Headers,
+		"replyTo":   o.ReplyTo,
+		"sequence":  o.Sequence,
+		"delivered": o.Delivered,
+		"timestamp": o.Timestamp,
+	}
+}
+
+func (o *Output) FromMap(values map[string]interface{}) error {
+
+	var err error
+	o.Subject, err = coerce.ToString(values["subject"])
+	if err != nil {
+		return err
+	}
+	wildcards, err := coerce.ToArray(values["wildcards"])
+	if err != nil {
+		return err
+	}
+	o.Wildcards = nil
+	for _, wildcard := range wildcards {
+		token, err := coerce.ToString(wildcard)
+		if err != nil {
+			return err
+		}
+		o.Wildcards = append(o.Wildcards, token)
+	}
+	o.Message, err = coerce.ToString(values["message"])
+	if err != nil {
+		return err
+	}
+	o.Content = values["content"]
+	o.Headers, err = coerce.ToParams(values["headers"])
+	if err != nil {
+		return err
+	}
+	o.ReplyTo, err = coerce.ToString(values["replyTo"])
+	if err != nil {
+		return err
+	}
+	o.Sequence, err = coerce.ToInt64(values["sequence"])
+	if err != nil {
+		return err
+	}
+	o.Delivered, err = coerce.ToInt(values["delivered"])
+	if err != nil {
+		return err
+	}
+	o.Timestamp, err = coerce.ToInt64(values["timestamp"])
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *Reply) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"data": r.Data,
+	}
+}
+
+func (r *Reply) FromMap(values map[string]interface{}) error {
+	r.Data = values["data"]
+	return nil
+}
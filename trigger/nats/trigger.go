@@ -0,0 +1,379 @@
+package nats
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"flogo/core/data/metadata"
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+var triggerMd = trigger.NewMetadata(&Settings{}, &HandlerSettings{}, &Output{}, &Reply{})
+
+func init() {
+	_ = trigger.Register(&Trigger{}, &Factory{})
+}
+
+type Factory struct {
+}
+
+// Metadata implements trigger.Factory.Metadata
+func (*Factory) Metadata() *trigger.Metadata {
+	return triggerMd
+}
+
+// New implements trigger.Factory.New
+func (*Factory) New(config *trigger.Config) (trigger.Trigger, error) {
+
+	s := &Settings{}
+	err := metadata.MapToStruct(config.Settings, s, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Trigger{settings: s}, nil
+}
+
+// Trigger subscribes to NATS subjects and consumes JetStream streams
+type Trigger struct {
+	settings    *Settings
+	logger      log.Logger
+	subscribers []*subscriber
+
+	conn *nats.Conn
+}
+
+// subscriber delivers the messages of the subject of a handler
+type subscriber struct {
+	handler  trigger.Handler
+	settings *HandlerSettings
+	logger   log.Logger
+	config   *jetstream.ConsumerConfig
+
+	subscription *nats.Subscription
+	consumer     jetstream.ConsumeContext
+}
+
+// Initialize implements trigger.Init.Initialize
+func (t *Trigger) Initialize(ctx trigger.InitContext) error {
+
+	t.logger = ctx.Logger()
+
+	for _, handler := range ctx.GetHandlers() {
+
+		s := &HandlerSettings{}
+		err := metadata.MapToStruct(handler.Settings(), s, true)
+		if err != nil {
+			return err
+		}
+
+		sub := &subscriber{handler: handler, settings: s, logger: t.logger}
+		if s.Stream != "" {
+			sub.config, err = consumerConfig(s)
+			if err != nil {
+				return err
+			}
+		}
+
+		t.subscribers = append(t.subscribers, sub)
+	}
+
+	return nil
+}
+
+// Start implements util.Managed.Start
+func (t *Trigger) Start() error {
+
+	options, err := connectOptions(t.settings)
+	if err != nil {
+		return err
+	}
+
+	t.conn, err = nats.Connect(t.settings.URL, options...)
+	if err != nil {
+		return fmt.Errorf("unable to connect to '%s': %v", t.settings.URL, err)
+	}
+
+	var js jetstream.JetStream
+
+	for _, sub := range t.subscribers {
+		s := sub.settings
+
+		if sub.config == nil {
+			sub.subscription, err = t.conn.QueueSubscribe(s.Subject, s.Queue, sub.onMessage)
+			if err != nil {
+				_ = t.Stop()
+				return fmt.Errorf("unable to subscribe to '%s': %v", s.Subject, err)
+			}
+			t.logger.Infof("Subscribed to '%s'", s.Subject)
+			continue
+		}
+
+		if js == nil {
+			js, err = jetstream.New(t.conn)
+			if err != nil {
+				_ = t.Stop()
+				return err
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		consumer, err := js.CreateOrUpdateConsumer(ctx, s.Stream, *sub.config)
+		cancel()
+		if err != nil {
+			_ = t.Stop()
+			return fmt.Errorf("unable to create consumer of stream '%s': %v", s.Stream, err)
+		}
+
+		sub.consumer, err = consumer.Consume(sub.onStreamMessage)
+		if err != nil {
+			_ = t.Stop()
+			return fmt.Errorf("unable to consume stream '%s': %v", s.Stream, err)
+		}
+		t.logger.Infof("Consuming '%s' from stream '%s'", s.Subject, s.Stream)
+	}
+
+	return nil
+}
+
+// Stop implements util.Managed.Stop
+func (t *Trigger) Stop() error {
+
+	for _, sub := range t.subscribers {
+		if sub.consumer != nil {
+			sub.consumer.Stop()
+			sub.consumer = nil
+		}
+		if sub.subscription != nil {
+			_ = sub.subscription.Unsubscribe()
+			sub.subscription = nil
+		}
+	}
+
+	if t.conn != nil {
+		// the messages being handled are completed before the connection closes
+		err := t.conn.Drain()
+		t.conn = nil
+		return err
+	}
+
+	return nil
+}
+
+// onMessage invokes the action for a core NATS message, and publishes its reply for a request
+func (s *subscriber) onMessage(msg *nats.Msg) {
+
+	out := s.toOutput(msg.Subject, msg.Data, msg.Header)
+	out.ReplyTo = msg.Reply
+
+	results, err := s.handler.Handle(context.Background(), out)
+	if err != nil {
+		s.logger.Errorf("Error handling message of '%s': %v", msg.Subject, err)
+		return
+	}
+
+	if msg.Reply == "" {
+		return
+	}
+
+	reply := &Reply{}
+	if err := reply.FromMap(results); err != nil || reply.Data == nil {
+		return
+	}
+
+	data, err := toData(reply.Data)
+	if err == nil {
+		err = msg.Respond(data)
+	}
+	if err != nil {
+		s.logger.Errorf("Error replying to '%s': %v", msg.Reply, err)
+	}
+}
+
+// onStreamMessage invokes the action for a JetStream message, and acknowledges it once
+// the action succeeded, the message is redelivered when it fails
+func (s *subscriber) onStreamMessage(msg jetstream.Msg) {
+
+	out := s.toOutput(msg.Subject(), msg.Data(), msg.Headers())
+	if md, err := msg.Metadata(); err == nil {
+		out.Sequence = int64(md.Sequence.Stream)
+		out.Delivered = int(md.NumDelivered)
+		out.Timestamp = md.Timestamp.UnixNano() / int64(time.Millisecond)
+	}
+
+	_, err := s.handler.Handle(context.Background(), out)
+
+	if s.config.AckPolicy == jetstream.AckNonePolicy {
+		if err != nil {
+			s.logger.Errorf("Error handling message %d of stream '%s': %v", out.Sequence, s.settings.Stream, err)
+		}
+		return
+	}
+
+	if err != nil {
+		s.logger.Errorf("Error handling message %d of stream '%s', it will be redelivered: %v", out.Sequence, s.settings.Stream, err)
+		err = msg.Nak()
+	} else {
+		err = msg.Ack()
+	}
+	if err != nil {
+		s.logger.Errorf("Error acknowledging message %d of stream '%s': %v", out.Sequence, s.settings.Stream, err)
+	}
+}
+
+func (s *subscriber) toOutput(subject string, data []byte, header nats.Header) *Output {
+
+	out := &Output{
+		Subject:   subject,
+		Wildcards: wildcards(s.settings.Subject, subject),
+		Message:   string(data),
+		Headers:   make(map[string]string, len(header)),
+	}
+
+	for name, values := range header {
+		out.Headers[name] = strings.Join(values, ",")
+	}
+
+	var content interface{}
+	if err := json.Unmarshal(data, &content); err == nil {
+		out.Content = content
+	}
+
+	return out
+}
+
+// wildcards returns the tokens of the subject matched by the * and > wildcards of the pattern,
+// the tokens matched by > are joined
+func wildcards(pattern, subject string) []string {
+
+	patternTokens := strings.Split(pattern, ".")
+	subjectTokens := strings.Split(subject, ".")
+
+	var tokens []string
+	for i, token := range patternTokens {
+		if i >= len(subjectTokens) {
+			break
+		}
+		switch token {
+		case "*":
+			tokens = append(tokens, subjectTokens[i])
+		case ">":
+			return append(tokens, strings.Join(subjectTokens[i:], "."))
+		}
+	}
+
+	return tokens
+}
+
+func toData(data interface{}) ([]byte, error) {
+
+	switch t := data.(type) {
+	case string:
+		return []byte(t), nil
+	case []byte:
+		return t, nil
+	}
+
+	return json.Marshal(data)
+}
+
+// consumerConfig returns the JetStream consumer of the settings of a handler
+func consumerConfig(s *HandlerSettings) (*jetstream.ConsumerConfig, error) {
+
+	config := &jetstream.ConsumerConfig{
+		Durable:       s.Durable,
+		FilterSubject: s.Subject,
+		MaxDeliver:    s.MaxDeliver,
+		MaxAckPending: s.MaxAckPending,
+	}
+
+	switch s.AckPolicy {
+	case "", AckExplicit:
+		config.AckPolicy = jetstream.AckExplicitPolicy
+	case AckAll:
+		config.AckPolicy = jetstream.AckAllPolicy
+	case AckNone:
+		config.AckPolicy = jetstream.AckNonePolicy
+	default:
+		return nil, fmt.Errorf("unsupported ack policy '%s'", s.AckPolicy)
+	}
+
+	switch s.DeliverPolicy {
+	case "", DeliverAll:
+		config.DeliverPolicy = jetstream.DeliverAllPolicy
+	case DeliverNew:
+		config.DeliverPolicy = jetstream.DeliverNewPolicy
+	case DeliverLast:
+		config.DeliverPolicy = jetstream.DeliverLastPolicy
+	default:
+		return nil, fmt.Errorf("unsupported deliver policy '%s'", s.DeliverPolicy)
+	}
+
+	if s.AckWait != "" {
+		wait, err := time.ParseDuration(s.AckWait)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ack wait '%s': %v", s.AckWait, err)
+		}
+		config.AckWait = wait
+	}
+
+	return config, nil
+}
+
+// connectOptions returns the options of the connection of the settings
+func connectOptions(settings *Settings) ([]nats.Option, error) {
+
+	options := []nats.Option{nats.MaxReconnects(-1)}
+
+	if settings.Name != "" {
+		options = append(options, nats.Name(settings.Name))
+	}
+	if settings.Username != "" {
+		options = append(options, nats.UserInfo(settings.Username, settings.Password))
+	}
+	if settings.Token != "" {
+		options = append(options, nats.Token(settings.Token))
+	}
+	if settings.CredsFile != "" {
+		options = append(options, nats.UserCredentials(settings.CredsFile))
+	}
+
+	if settings.CAFile != "" || settings.CertFile != "" || settings.InsecureSkipVerify {
+		tlsConfig := &tls.Config{InsecureSkipVerify: settings.InsecureSkipVerify}
+
+		if settings.CAFile != "" {
+			pem, err := ioutil.ReadFile(settings.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read CA file [%s]: %v", settings.CAFile, err)
+			}
+			tlsConfig.RootCAs = x509.NewCertPool()
+			if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in CA file [%s]", settings.CAFile)
+			}
+		}
+
+		if settings.CertFile != "" || settings.KeyFile != "" {
+			if settings.CertFile == "" || settings.KeyFile == "" {
+				return nil, fmt.Errorf("both cert file and key file must be specified for client certificate authentication")
+			}
+			cert, err := tls.LoadX509KeyPair(settings.CertFile, settings.KeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("unable to load client certificate: %v", err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+
+		options = append(options, nats.Secure(tlsConfig))
+	}
+
+	return options, nil
+}
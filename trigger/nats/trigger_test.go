@@ -0,0 +1,229 @@
+package nats
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"flogo/core/action"
+	"flogo/core/api"
+	"flogo/core/support/test"
+	"flogo/core/trigger"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig string = `{
+	"id": "trigger-nats",
+	"ref": "github.com/qingcloudhx/contrib/trigger/nats",
+	"settings": {
+	  "url": "nats://localhost:4222"
+	},
+	"handlers": [
+	  {
+		"settings": {
+		  "subject": "orders.>"
+		},
+		"action": {
+		  "id": "test"
+		}
+	  }
+	]
+}`
+
+// send returns a handler function sending the outputs it handles and replying with the result of the function
+func send(outputs chan *Output, reply func(out *Output) (map[string]interface{}, error)) api.HandlerFunc {
+	return func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+		out := &Output{}
+		if err := out.FromMap(inputs); err != nil {
+			return nil, err
+		}
+		outputs <- out
+		if reply == nil {
+			return nil, nil
+		}
+		return reply(out)
+	}
+}
+
+func next(t *testing.T, outputs chan *Output) *Output {
+	select {
+	case out := <-outputs:
+		return out
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message handled")
+		return nil
+	}
+}
+
+func startServer(t *testing.T) (*server.Server, func()) {
+
+	dir, err := ioutil.TempDir("", "nats")
+	assert.Nil(t, err)
+
+	s, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: -1, JetStream: true, StoreDir: dir, NoLog: true, NoSigs: true})
+	assert.Nil(t, err)
+	s.Start()
+	if !s.ReadyForConnections(5 * time.Second) {
+		t.Fatal("server not ready")
+	}
+
+	return s, func() {
+		s.Shutdown()
+		os.RemoveAll(dir)
+	}
+}
+
+// startTrigger starts a trigger connected to the url with a handler of the settings running the function
+func startTrigger(t *testing.T, url string, settings map[string]interface{}, f api.HandlerFunc) trigger.Trigger {
+
+	config := &trigger.Config{}
+	err := json.Unmarshal([]byte(testConfig), config)
+	assert.Nil(t, err)
+	config.Settings["url"] = url
+	config.Handlers[0].Settings = settings
+
+	trg, err := test.InitTrigger(&Factory{}, config, map[string]action.Action{"test": api.NewProxyAction(f)})
+	assert.Nil(t, err)
+	err = trg.Start()
+	assert.Nil(t, err)
+
+	return trg
+}
+
+func TestTrigger_Subject(t *testing.T) {
+
+	s, shutdown := startServer(t)
+	defer shutdown()
+
+	outputs := make(chan *Output, 10)
+	tgr := startTrigger(t, s.ClientURL(), map[string]interface{}{"subject": "orders.*.created", "queue": "workers"},
+		send(outputs, func(out *Output) (map[string]interface{}, error) {
+			return map[string]interface{}{"data": map[string]interface{}{"region": out.Wildcards[0]}}, nil
+		}))
+	defer tgr.Stop()
+
+	nc, err := nats.Connect(s.ClientURL())
+	assert.Nil(t, err)
+	defer nc.Close()
+
+	msg := nats.NewMsg("orders.eu.created")
+	msg.Data = []byte(`{"id":1}`)
+	msg.Header.Set("Trace-Id", "abc")
+	err = nc.PublishMsg(msg)
+	assert.Nil(t, err)
+
+	out := next(t, outputs)
+	assert.Equal(t, "orders.eu.created", out.Subject)
+	assert.Equal(t, []string{"eu"}, out.Wildcards)
+	assert.Equal(t, `{"id":1}`, out.Message)
+	assert.Equal(t, map[string]interface{}{"id": 1.0}, out.Content)
+	assert.Equal(t, "abc", out.Headers["Trace-Id"])
+
+	// request reply
+	reply, err := nc.Request("orders.us.created", []byte("new order"), 5*time.Second)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"region":"us"}`, string(reply.Data))
+	out = next(t, outputs)
+	assert.Nil(t, out.Content)
+	assert.NotEmpty(t, out.ReplyTo)
+}
+
+func TestTrigger_JetStream(t *testing.T) {
+
+	s, shutdown := startServer(t)
+	defer shutdown()
+
+	nc, err := nats.Connect(s.ClientURL())
+	assert.Nil(t, err)
+	defer nc.Close()
+
+	js, err := jetstream.New(nc)
+	assert.Nil(t, err)
+	ctx := context.Background()
+	_, err = js.CreateStream(ctx, jetstream.StreamConfig{Name: "ORDERS", Subjects: []string{"orders.>"}})
+	assert.Nil(t, err)
+
+	var mu sync.Mutex
+	failures := 1
+	outputs := make(chan *Output, 10)
+	f := send(outputs, func(out *Output) (map[string]interface{}, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if failures > 0 {
+			failures--
+			return nil, errors.New("failed")
+		}
+		return nil, nil
+	})
+
+	_, err = js.Publish(ctx, "orders.eu.created", []byte("first"))
+	assert.Nil(t, err)
+
+	tgr := startTrigger(t, s.ClientURL(), map[string]interface{}{"subject": "orders.>", "stream": "ORDERS", "durable": "processor", "ackWait": "10s"}, f)
+	defer tgr.Stop()
+
+	// the failed message is redelivered
+	out := next(t, outputs)
+	assert.Equal(t, "first", out.Message)
+	assert.Equal(t, int64(1), out.Sequence)
+	assert.Equal(t, 1, out.Delivered)
+	assert.Equal(t, []string{"eu.created"}, out.Wildcards)
+	assert.True(t, out.Timestamp > 0)
+
+	out = next(t, outputs)
+	assert.Equal(t, "first", out.Message)
+	assert.Equal(t, 2, out.Delivered)
+
+	_, err = js.Publish(ctx, "orders.us.created", []byte("second"))
+	assert.Nil(t, err)
+	out = next(t, outputs)
+	assert.Equal(t, "second", out.Message)
+	assert.Equal(t, int64(2), out.Sequence)
+
+	consumer, err := js.Consumer(ctx, "ORDERS", "processor")
+	assert.Nil(t, err)
+	for i := 0; i < 100; i++ {
+		info, err := consumer.Info(ctx)
+		assert.Nil(t, err)
+		if info.AckFloor.Stream == 2 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	info, err := consumer.Info(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), info.AckFloor.Stream)
+}
+
+func TestWildcards(t *testing.T) {
+	assert.Equal(t, []string{"eu"}, wildcards("orders.*.created", "orders.eu.created"))
+	assert.Equal(t, []string{"eu", "created.today"}, wildcards("orders.*.>", "orders.eu.created.today"))
+	assert.Nil(t, wildcards("orders.created", "orders.created"))
+}
+
+func TestConsumerConfig(t *testing.T) {
+
+	config, err := consumerConfig(&HandlerSettings{Subject: "orders.>", Durable: "processor", AckWait: "1m", MaxDeliver: 5})
+	assert.Nil(t, err)
+	assert.Equal(t, jetstream.AckExplicitPolicy, config.AckPolicy)
+	assert.Equal(t, jetstream.DeliverAllPolicy, config.DeliverPolicy)
+	assert.Equal(t, time.Minute, config.AckWait)
+	assert.Equal(t, 5, config.MaxDeliver)
+	assert.Equal(t, "orders.>", config.FilterSubject)
+
+	config, err = consumerConfig(&HandlerSettings{AckPolicy: AckNone, DeliverPolicy: DeliverNew})
+	assert.Nil(t, err)
+	assert.Equal(t, jetstream.AckNonePolicy, config.AckPolicy)
+	assert.Equal(t, jetstream.DeliverNewPolicy, config.DeliverPolicy)
+
+	_, err = consumerConfig(&HandlerSettings{AckWait: "soon"})
+	assert.NotNil(t, err)
+}
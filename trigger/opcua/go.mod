@@ -0,0 +1,7 @@
+module github.com/qingcloudhx/contrib/trigger/opcua
+
+require (
+	flogo/core v0.9.0
+	github.com/gopcua/opcua v0.8.0
+	github.com/stretchr/testify v1.3.0
+)
@@ -0,0 +1,84 @@
+package opcua
+
+import (
+	"flogo/core/data/coerce"
+)
+
+const (
+	NotificationDataChange = "dataChange"
+	NotificationEvent      = "event"
+)
+
+type Settings struct {
+	Endpoint       string `md:"endpoint,required"`                                                                                             // The url of the OPC UA server (ex. opc.tcp://plc.local:4840)
+	SecurityPolicy string `md:"securityPolicy,allowed(None,Basic128Rsa15,Basic256,Basic256Sha256,Aes128_Sha256_RsaOaep,Aes256_Sha256_RsaPss)"` // The security policy of the secure channel, defaults to None
+	SecurityMode   string `md:"securityMode,allowed(None,Sign,SignAndEncrypt)"`                                                                // The security mode of the secure channel, defaults to SignAndEncrypt with a security policy, None otherwise
+	CertFile       string `md:"certFile"`                                                                                                      // The file of the client certificate, PEM with a .pem extension and DER otherwise, required by the security policies other than None
+	KeyFile        string `md:"keyFile"`                                                                                                       // The PEM file of the client private key
+	Username       string `md:"username"`                                                                                                      // The user name of the session, anonymous if not specified
+	Password       string `md:"password"`                                                                                                      // The password of the user
+}
+
+type HandlerSettings struct {
+	NodeIDs            string  `md:"nodeIds,required"`   // The comma separated ids of the nodes monitored (ex. ns=2;s=Line1.Temperature, ns=3;i=1001)
+	Events             bool    `md:"events"`             // Monitor the events emitted by the nodes, usually the Server object (i=2253), instead of their values
+	EventFields        string  `md:"eventFields"`        // The comma separated browse names of the fields of the events, defaults to EventId, EventType, SourceName, Time, Message and Severity
+	SamplingInterval   string  `md:"samplingInterval"`   // How often the server samples the values (ex. 100ms), defaults to the publishing interval
+	PublishingInterval string  `md:"publishingInterval"` // How often the server publishes the notifications queued (ex. 500ms), defaults to 1s
+	QueueSize          int     `md:"queueSize"`          // The number of notifications the server queues per node between two publishes, defaults to 1 for values and 100 for events
+	Deadband           float64 `md:"deadband"`           // The absolute change of a value under which no notification is sent, every change is notified if not specified
+}
+
+type Output struct {
+	Notification    string                 `md:"notification"`    // The type of the notification: dataChange or event
+	NodeID          string                 `md:"nodeId"`          // The id of the node monitored
+	Value           interface{}            `md:"value"`           // The value of the node
+	Status          string                 `md:"status"`          // The status of the value (ex. Good, BadCommunicationError)
+	SourceTimestamp int64                  `md:"sourceTimestamp"` // The time the value was produced by its source, in milliseconds since epoch
+	ServerTimestamp int64                  `md:"serverTimestamp"` // The time the value was received by the server, in milliseconds since epoch
+	Fields          map[string]interface{} `md:"fields"`          // The fields of the event, by browse name
+}
+
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"notification":    o.Notification,
+		"nodeId":          o.NodeID,
+		"value":           o.Value,
+		"status":          o.Status,
+		"sourceTimestamp": o.SourceTimestamp,
+		"serverTimestamp": o.ServerTimestamp,
+		"fields":          o.Fields,
+	}
+}
+
+func (o *Output) FromMap(values map[string]interface{}) error {
+
+	var err error
+	o.Notification, err = coerce.ToString(values["notification"])
+	if err != nil {
+		return err
+	}
+	o.NodeID, err = coerce.ToString(values["nodeId"])
+	if err != nil {
+		return err
+	}
+	o.Value = values["value"]
+	o.Status, err = coerce.ToString(values["status"])
+	if err != nil {
+		return err
+	}
+	o.SourceTimestamp, err = coerce.ToInt64(values["sourceTimestamp"])
+	if err != nil {
+		return err
+	}
+	o.ServerTimestamp, err = coerce.ToInt64(values["serverTimestamp"])
+	if err != nil {
+		return err
+	}
+	o.Fields, err = coerce.ToObject(values["fields"])
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,321 @@
+package opcua
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+	"github.com/gopcua/opcua"
+	"github.com/gopcua/opcua/id"
+	"github.com/gopcua/opcua/ua"
+)
+
+const defaultPublishingInterval = time.Second
+
+var defaultEventFields = []string{"EventId", "EventType", "SourceName", "Time", "Message", "Severity"}
+
+// subscription monitors the nodes of a handler and invokes its action for each notification, the
+// client handle of a monitored item being the index of its node
+type subscription struct {
+	handler  trigger.Handler
+	settings *HandlerSettings
+	logger   log.Logger
+
+	nodeIDs            []*ua.NodeID
+	eventFields        []string
+	samplingInterval   float64
+	publishingInterval time.Duration
+
+	sub      *opcua.Subscription
+	notifyCh chan *opcua.PublishNotificationData
+	done     chan struct{}
+}
+
+func newSubscription(handler trigger.Handler, s *HandlerSettings, logger log.Logger) (*subscription, error) {
+
+	sub := &subscription{handler: handler, settings: s, logger: logger, samplingInterval: -1, publishingInterval: defaultPublishingInterval}
+
+	for _, nodeID := range splitList(s.NodeIDs) {
+		id, err := ua.ParseNodeID(nodeID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid node id '%s': %v", nodeID, err)
+		}
+		sub.nodeIDs = append(sub.nodeIDs, id)
+	}
+	if len(sub.nodeIDs) == 0 {
+		return nil, fmt.Errorf("at least one node id is required")
+	}
+
+	if s.Events {
+		sub.eventFields = splitList(s.EventFields)
+		if len(sub.eventFields) == 0 {
+			sub.eventFields = defaultEventFields
+		}
+		if s.Deadband != 0 {
+			return nil, fmt.Errorf("a deadband can't be applied to events")
+		}
+	}
+
+	if s.SamplingInterval != "" {
+		interval, err := time.ParseDuration(s.SamplingInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sampling interval '%s': %v", s.SamplingInterval, err)
+		}
+		sub.samplingInterval = float64(interval) / float64(time.Millisecond)
+	}
+	if s.PublishingInterval != "" {
+		interval, err := time.ParseDuration(s.PublishingInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid publishing interval '%s': %v", s.PublishingInterval, err)
+		}
+		sub.publishingInterval = interval
+	}
+
+	if s.QueueSize == 0 {
+		s.QueueSize = 1
+		if s.Events {
+			s.QueueSize = 100
+		}
+	}
+	if s.QueueSize < 0 {
+		return nil, fmt.Errorf("invalid queue size %d", s.QueueSize)
+	}
+
+	return sub, nil
+}
+
+// subscribe creates the subscription and its monitored items, which are restored by the client
+// when it reconnects
+func (sub *subscription) subscribe(ctx context.Context, client *opcua.Client) error {
+
+	sub.notifyCh = make(chan *opcua.PublishNotificationData, 100)
+
+	var err error
+	sub.sub, err = client.Subscribe(ctx, &opcua.SubscriptionParameters{Interval: sub.publishingInterval}, sub.notifyCh)
+	if err != nil {
+		return fmt.Errorf("unable to create subscription: %v", err)
+	}
+
+	requests := make([]*ua.MonitoredItemCreateRequest, len(sub.nodeIDs))
+	for i := range sub.nodeIDs {
+		requests[i] = sub.monitoredItem(i)
+	}
+
+	res, err := sub.sub.Monitor(ctx, ua.TimestampsToReturnBoth, requests...)
+	if err != nil {
+		_ = sub.sub.Cancel(ctx)
+		return fmt.Errorf("unable to monitor nodes: %v", err)
+	}
+	for i, result := range res.Results {
+		if result.StatusCode != ua.StatusOK {
+			_ = sub.sub.Cancel(ctx)
+			return fmt.Errorf("unable to monitor node '%s': %v", sub.nodeIDs[i], result.StatusCode)
+		}
+	}
+
+	return nil
+}
+
+// monitoredItem returns the request monitoring the value or the events of a node
+func (sub *subscription) monitoredItem(handle int) *ua.MonitoredItemCreateRequest {
+
+	s := sub.settings
+
+	req := &ua.MonitoredItemCreateRequest{
+		ItemToMonitor: &ua.ReadValueID{
+			NodeID:       sub.nodeIDs[handle],
+			AttributeID:  ua.AttributeIDValue,
+			DataEncoding: &ua.QualifiedName{},
+		},
+		MonitoringMode: ua.MonitoringModeReporting,
+		RequestedParameters: &ua.MonitoringParameters{
+			ClientHandle:     uint32(handle),
+			SamplingInterval: sub.samplingInterval,
+			QueueSize:        uint32(s.QueueSize),
+			DiscardOldest:    true,
+			Filter:           ua.NewExtensionObject(nil),
+		},
+	}
+
+	if s.Events {
+		selects := make([]*ua.SimpleAttributeOperand, len(sub.eventFields))
+		for i, field := range sub.eventFields {
+			selects[i] = &ua.SimpleAttributeOperand{
+				TypeDefinitionID: ua.NewNumericNodeID(0, id.BaseEventType),
+				BrowsePath:       []*ua.QualifiedName{{NamespaceIndex: 0, Name: field}},
+				AttributeID:      ua.AttributeIDValue,
+			}
+		}
+		req.ItemToMonitor.AttributeID = ua.AttributeIDEventNotifier
+		req.RequestedParameters.Filter = ua.NewExtensionObject(&ua.EventFilter{SelectClauses: selects, WhereClause: &ua.ContentFilter{}})
+	} else if s.Deadband != 0 {
+		req.RequestedParameters.Filter = ua.NewExtensionObject(&ua.DataChangeFilter{
+			Trigger:       ua.DataChangeTriggerStatusValue,
+			DeadbandType:  uint32(ua.DeadbandTypeAbsolute),
+			DeadbandValue: s.Deadband,
+		})
+	}
+
+	return req
+}
+
+// run invokes the action for the notifications until the context is done, then deletes the
+// subscription
+func (sub *subscription) run(ctx context.Context) {
+
+	defer close(sub.done)
+
+	for {
+		select {
+		case <-ctx.Done():
+			cancelCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := sub.sub.Cancel(cancelCtx); err != nil {
+				sub.logger.Debugf("Error deleting subscription %d: %v", sub.sub.SubscriptionID, err)
+			}
+			cancel()
+			return
+		case data := <-sub.notifyCh:
+			if data.Error != nil {
+				sub.logger.Errorf("Error of subscription %d: %v", data.SubscriptionID, data.Error)
+				continue
+			}
+			for _, out := range sub.outputs(data.Value) {
+				if _, err := sub.handler.Handle(context.Background(), out); err != nil {
+					sub.logger.Errorf("Error handling %s notification of node '%s': %v", out.Notification, out.NodeID, err)
+				}
+			}
+		}
+	}
+}
+
+// wait waits for the subscription to stop, at most for the timeout
+func (sub *subscription) wait(timeout time.Duration) {
+
+	if sub.done == nil {
+		return
+	}
+
+	select {
+	case <-sub.done:
+	case <-time.After(timeout):
+		sub.logger.Warnf("Notifications of nodes %s still being handled", sub.settings.NodeIDs)
+	}
+}
+
+// outputs returns the outputs of the data changes or events of a notification
+func (sub *subscription) outputs(notification interface{}) []*Output {
+
+	var outputs []*Output
+
+	switch n := notification.(type) {
+	case *ua.DataChangeNotification:
+		for _, item := range n.MonitoredItems {
+			if int(item.ClientHandle) >= len(sub.nodeIDs) || item.Value == nil {
+				continue
+			}
+			out := &Output{
+				Notification:    NotificationDataChange,
+				NodeID:          sub.nodeIDs[item.ClientHandle].String(),
+				Status:          statusName(item.Value.Status),
+				SourceTimestamp: toMillis(item.Value.SourceTimestamp),
+				ServerTimestamp: toMillis(item.Value.ServerTimestamp),
+			}
+			if item.Value.Value != nil {
+				out.Value = toValue(item.Value.Value.Value())
+			}
+			outputs = append(outputs, out)
+		}
+	case *ua.EventNotificationList:
+		for _, event := range n.Events {
+			if int(event.ClientHandle) >= len(sub.nodeIDs) {
+				continue
+			}
+			out := &Output{
+				Notification: NotificationEvent,
+				NodeID:       sub.nodeIDs[event.ClientHandle].String(),
+				Status:       statusName(ua.StatusOK),
+				Fields:       make(map[string]interface{}, len(sub.eventFields)),
+			}
+			for i, field := range event.EventFields {
+				if i < len(sub.eventFields) && field != nil {
+					out.Fields[sub.eventFields[i]] = toValue(field.Value())
+				}
+			}
+			outputs = append(outputs, out)
+		}
+	}
+
+	return outputs
+}
+
+// toValue converts the OPC UA types to the types of the flows
+func toValue(value interface{}) interface{} {
+
+	switch v := value.(type) {
+	case time.Time:
+		return toMillis(v)
+	case *ua.LocalizedText:
+		if v == nil {
+			return nil
+		}
+		return v.Text
+	case *ua.QualifiedName:
+		if v == nil {
+			return nil
+		}
+		return v.Name
+	case *ua.NodeID:
+		if v == nil {
+			return nil
+		}
+		return v.String()
+	case *ua.ExpandedNodeID:
+		if v == nil {
+			return nil
+		}
+		return v.String()
+	case ua.StatusCode:
+		return statusName(v)
+	case *ua.GUID:
+		if v == nil {
+			return nil
+		}
+		return v.String()
+	}
+
+	return value
+}
+
+func statusName(status ua.StatusCode) string {
+
+	if desc, ok := ua.StatusCodes[status]; ok {
+		return strings.TrimPrefix(desc.Name, "Status")
+	}
+
+	return fmt.Sprintf("0x%X", uint32(status))
+}
+
+// toMillis returns the milliseconds since epoch of a time, 0 if it isn't set
+func toMillis(t time.Time) int64 {
+
+	if t.IsZero() {
+		return 0
+	}
+
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+func splitList(list string) []string {
+
+	var values []string
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
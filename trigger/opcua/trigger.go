@@ -0,0 +1,180 @@
+package opcua
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"flogo/core/data/metadata"
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+	"github.com/gopcua/opcua"
+	"github.com/gopcua/opcua/ua"
+)
+
+const connectTimeout = 30 * time.Second
+
+var triggerMd = trigger.NewMetadata(&Settings{}, &HandlerSettings{}, &Output{})
+
+func init() {
+	_ = trigger.Register(&Trigger{}, &Factory{})
+}
+
+type Factory struct {
+}
+
+// Metadata implements trigger.Factory.Metadata
+func (*Factory) Metadata() *trigger.Metadata {
+	return triggerMd
+}
+
+// New implements trigger.Factory.New
+func (*Factory) New(config *trigger.Config) (trigger.Trigger, error) {
+
+	s := &Settings{}
+	err := metadata.MapToStruct(config.Settings, s, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Trigger{settings: s}, nil
+}
+
+// Trigger subscribes to the data changes and events of the nodes of an OPC UA server
+type Trigger struct {
+	settings      *Settings
+	logger        log.Logger
+	subscriptions []*subscription
+
+	client *opcua.Client
+	cancel context.CancelFunc
+}
+
+// Initialize implements trigger.Init.Initialize
+func (t *Trigger) Initialize(ctx trigger.InitContext) error {
+
+	t.logger = ctx.Logger()
+
+	s := t.settings
+	if s.SecurityPolicy != "" && s.SecurityPolicy != "None" && (s.CertFile == "" || s.KeyFile == "") {
+		return fmt.Errorf("security policy '%s' requires a cert file and a key file", s.SecurityPolicy)
+	}
+
+	for _, handler := range ctx.GetHandlers() {
+
+		hs := &HandlerSettings{}
+		err := metadata.MapToStruct(handler.Settings(), hs, true)
+		if err != nil {
+			return err
+		}
+
+		sub, err := newSubscription(handler, hs, t.logger)
+		if err != nil {
+			return fmt.Errorf("invalid settings of handler '%s': %v", handler.Name(), err)
+		}
+		t.subscriptions = append(t.subscriptions, sub)
+	}
+
+	return nil
+}
+
+// Start implements util.Managed.Start
+func (t *Trigger) Start() error {
+
+	connectCtx, cancelConnect := context.WithTimeout(context.Background(), connectTimeout)
+	defer cancelConnect()
+
+	client, err := newClient(connectCtx, t.settings)
+	if err != nil {
+		return err
+	}
+	if err := client.Connect(connectCtx); err != nil {
+		return fmt.Errorf("unable to connect to '%s': %v", t.settings.Endpoint, err)
+	}
+	t.client = client
+	t.logger.Infof("Connected to '%s'", t.settings.Endpoint)
+
+	for _, sub := range t.subscriptions {
+		if err := sub.subscribe(connectCtx, client); err != nil {
+			_ = t.Stop()
+			return err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+
+	for _, sub := range t.subscriptions {
+		sub.done = make(chan struct{})
+		go sub.run(ctx)
+	}
+
+	return nil
+}
+
+// Stop implements util.Managed.Stop
+func (t *Trigger) Stop() error {
+
+	if t.cancel != nil {
+		t.cancel()
+		t.cancel = nil
+	}
+
+	for _, sub := range t.subscriptions {
+		sub.wait(30 * time.Second)
+		sub.done = nil
+	}
+
+	if t.client == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := t.client.Close(ctx)
+	t.client = nil
+
+	return err
+}
+
+// newClient returns a client of the endpoint of the server matching the security settings
+func newClient(ctx context.Context, s *Settings) (*opcua.Client, error) {
+
+	policy := s.SecurityPolicy
+	if policy == "" {
+		policy = "None"
+	}
+	mode := s.SecurityMode
+	if mode == "" {
+		mode = "None"
+		if policy != "None" {
+			mode = "SignAndEncrypt"
+		}
+	}
+
+	endpoints, err := opcua.GetEndpoints(ctx, s.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the endpoints of '%s': %v", s.Endpoint, err)
+	}
+	ep, err := opcua.SelectEndpoint(endpoints, policy, ua.MessageSecurityModeFromString(mode))
+	if err != nil {
+		return nil, fmt.Errorf("unable to select the endpoint of '%s': %v", s.Endpoint, err)
+	}
+
+	options := []opcua.Option{opcua.SecurityPolicy(policy), opcua.SecurityModeString(mode)}
+	if s.CertFile != "" {
+		options = append(options, opcua.CertificateFile(s.CertFile), opcua.PrivateKeyFile(s.KeyFile))
+	}
+
+	authType := ua.UserTokenTypeAnonymous
+	if s.Username != "" {
+		authType = ua.UserTokenTypeUserName
+		options = append(options, opcua.AuthUsername(s.Username, s.Password))
+	} else {
+		options = append(options, opcua.AuthAnonymous())
+	}
+	options = append(options, opcua.SecurityFromEndpoint(ep, authType))
+
+	// the server may advertise an endpoint url not reachable from the client
+	return opcua.NewClient(s.Endpoint, options...)
+}
@@ -0,0 +1,213 @@
+package opcua
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"flogo/core/action"
+	"flogo/core/api"
+	"flogo/core/support/log"
+	"flogo/core/support/test"
+	"flogo/core/trigger"
+	"github.com/gopcua/opcua/server"
+	"github.com/gopcua/opcua/ua"
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig string = `{
+	"id": "trigger-opcua",
+	"ref": "github.com/qingcloudhx/contrib/trigger/opcua",
+	"settings": {
+	  "endpoint": "opc.tcp://127.0.0.1:4840"
+	},
+	"handlers": [
+	  {
+		"settings": {
+		  "nodeIds": "i=2253"
+		},
+		"action": {
+		  "id": "test"
+		}
+	  }
+	]
+}`
+
+// send returns a handler function sending the outputs it handles
+func send(outputs chan *Output) api.HandlerFunc {
+	return func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+		out := &Output{}
+		if err := out.FromMap(inputs); err != nil {
+			return nil, err
+		}
+		outputs <- out
+		return nil, nil
+	}
+}
+
+func next(t *testing.T, outputs chan *Output) *Output {
+	select {
+	case out := <-outputs:
+		return out
+	case <-time.After(5 * time.Second):
+		t.Fatal("no notification handled")
+		return nil
+	}
+}
+
+// startServer starts a server without security, whose namespace holds the values of the map
+func startServer(t *testing.T) (*server.Server, *server.MapNamespace, string) {
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	_ = l.Close()
+
+	srv := server.New(
+		server.EndPoint("127.0.0.1", port),
+		server.EnableSecurity("None", ua.MessageSecurityModeNone),
+		server.EnableAuthMode(ua.UserTokenTypeAnonymous),
+	)
+	ns := server.NewMapNamespace(srv, "Line1")
+	ns.Data["Temperature"] = 21.5
+	ns.Data["Running"] = true
+
+	if err := srv.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	return srv, ns, fmt.Sprintf("opc.tcp://127.0.0.1:%d", port)
+}
+
+func TestTrigger(t *testing.T) {
+
+	srv, ns, endpoint := startServer(t)
+	defer srv.Close()
+
+	temperature := fmt.Sprintf("ns=%d;s=Temperature", ns.ID())
+	running := fmt.Sprintf("ns=%d;s=Running", ns.ID())
+
+	outputs := make(chan *Output, 10)
+	config := &trigger.Config{}
+	err := json.Unmarshal([]byte(testConfig), config)
+	assert.Nil(t, err)
+	config.Settings["endpoint"] = endpoint
+	config.Handlers[0].Settings = map[string]interface{}{"nodeIds": temperature + ", " + running, "publishingInterval": "50ms"}
+	tgr, err := test.InitTrigger(&Factory{}, config, map[string]action.Action{"test": api.NewProxyAction(send(outputs))})
+	assert.Nil(t, err)
+	assert.Nil(t, tgr.Start())
+
+	// the initial values are notified
+	values := make(map[string]interface{})
+	for i := 0; i < 2; i++ {
+		out := next(t, outputs)
+		assert.Equal(t, NotificationDataChange, out.Notification)
+		assert.Equal(t, "Good", out.Status)
+		values[out.NodeID] = out.Value
+	}
+	assert.Equal(t, map[string]interface{}{temperature: 21.5, running: true}, values)
+
+	ns.SetValue("Temperature", 22.0)
+	out := next(t, outputs)
+	assert.Equal(t, temperature, out.NodeID)
+	assert.Equal(t, 22.0, out.Value)
+
+	assert.Nil(t, tgr.Stop())
+}
+
+func TestInitialize_SecurityPolicy(t *testing.T) {
+
+	config := &trigger.Config{}
+	err := json.Unmarshal([]byte(testConfig), config)
+	assert.Nil(t, err)
+	config.Settings["securityPolicy"] = "Basic256Sha256"
+	config.Handlers = nil
+
+	// the security policy requires a client certificate
+	_, err = test.InitTrigger(&Factory{}, config, nil)
+	assert.NotNil(t, err)
+}
+
+func TestNewSubscription(t *testing.T) {
+
+	s := &HandlerSettings{NodeIDs: "ns=2;s=Temperature, i=2253"}
+	sub, err := newSubscription(nil, s, log.RootLogger())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(sub.nodeIDs))
+	assert.Equal(t, float64(-1), sub.samplingInterval)
+	assert.Equal(t, time.Second, sub.publishingInterval)
+	assert.Equal(t, 1, s.QueueSize)
+
+	s = &HandlerSettings{NodeIDs: "i=2253", Events: true, SamplingInterval: "250ms"}
+	sub, err = newSubscription(nil, s, log.RootLogger())
+	assert.Nil(t, err)
+	assert.Equal(t, defaultEventFields, sub.eventFields)
+	assert.Equal(t, float64(250), sub.samplingInterval)
+	assert.Equal(t, 100, s.QueueSize)
+
+	for _, s := range []*HandlerSettings{
+		{NodeIDs: " , "},
+		{NodeIDs: "ns=x;s=Temperature"},
+		{NodeIDs: "i=2253", Events: true, Deadband: 0.5},
+		{NodeIDs: "i=2253", PublishingInterval: "often"},
+		{NodeIDs: "i=2253", SamplingInterval: "often"},
+	} {
+		_, err := newSubscription(nil, s, log.RootLogger())
+		assert.NotNil(t, err, "%+v", s)
+	}
+}
+
+func TestMonitoredItem(t *testing.T) {
+
+	sub, err := newSubscription(nil, &HandlerSettings{NodeIDs: "ns=2;s=Temperature", Deadband: 0.5}, log.RootLogger())
+	assert.Nil(t, err)
+
+	req := sub.monitoredItem(0)
+	assert.Equal(t, ua.AttributeIDValue, req.ItemToMonitor.AttributeID)
+	filter := req.RequestedParameters.Filter.Value.(*ua.DataChangeFilter)
+	assert.Equal(t, uint32(ua.DeadbandTypeAbsolute), filter.DeadbandType)
+	assert.Equal(t, 0.5, filter.DeadbandValue)
+
+	sub, err = newSubscription(nil, &HandlerSettings{NodeIDs: "i=2253", Events: true, EventFields: "Message, Severity"}, log.RootLogger())
+	assert.Nil(t, err)
+
+	req = sub.monitoredItem(0)
+	assert.Equal(t, ua.AttributeIDEventNotifier, req.ItemToMonitor.AttributeID)
+	eventFilter := req.RequestedParameters.Filter.Value.(*ua.EventFilter)
+	assert.Equal(t, 2, len(eventFilter.SelectClauses))
+	assert.Equal(t, "Severity", eventFilter.SelectClauses[1].BrowsePath[0].Name)
+}
+
+func TestOutputs(t *testing.T) {
+
+	sub, err := newSubscription(nil, &HandlerSettings{NodeIDs: "i=2253", Events: true, EventFields: "Message, Severity, Time"}, log.RootLogger())
+	assert.Nil(t, err)
+
+	now := time.Now()
+	outputs := sub.outputs(&ua.EventNotificationList{Events: []*ua.EventFieldList{{
+		ClientHandle: 0,
+		EventFields: []*ua.Variant{
+			ua.MustVariant(&ua.LocalizedText{Text: "Pressure high"}),
+			ua.MustVariant(uint16(700)),
+			ua.MustVariant(now),
+		},
+	}}})
+	assert.Equal(t, 1, len(outputs))
+	assert.Equal(t, NotificationEvent, outputs[0].Notification)
+	assert.Equal(t, "i=2253", outputs[0].NodeID)
+	assert.Equal(t, map[string]interface{}{"Message": "Pressure high", "Severity": uint16(700), "Time": toMillis(now)}, outputs[0].Fields)
+
+	outputs = sub.outputs(&ua.DataChangeNotification{MonitoredItems: []*ua.MonitoredItemNotification{
+		{ClientHandle: 0, Value: &ua.DataValue{Status: ua.StatusBadCommunicationError}},
+		{ClientHandle: 3, Value: &ua.DataValue{}},
+	}})
+	assert.Equal(t, 1, len(outputs))
+	assert.Equal(t, "BadCommunicationError", outputs[0].Status)
+	assert.Nil(t, outputs[0].Value)
+	assert.Equal(t, int64(0), outputs[0].SourceTimestamp)
+}
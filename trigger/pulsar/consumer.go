@@ -0,0 +1,212 @@
+package pulsar
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+	"github.com/apache/pulsar-client-go/pulsar"
+)
+
+const retryInterval = 5 * time.Second
+
+var subscriptionTypes = map[string]pulsar.SubscriptionType{
+	SubscriptionExclusive: pulsar.Exclusive,
+	SubscriptionShared:    pulsar.Shared,
+	SubscriptionFailover:  pulsar.Failover,
+	SubscriptionKeyShared: pulsar.KeyShared,
+}
+
+// receiver is the part of the Pulsar consumer used by a consumer
+type receiver interface {
+	Receive(ctx context.Context) (pulsar.Message, error)
+	Ack(msg pulsar.Message) error
+	Nack(msg pulsar.Message)
+}
+
+// consumer receives the messages of the subscription of a handler, a message is acknowledged once
+// its action succeeded, otherwise it is negatively acknowledged and redelivered after the nack
+// redelivery delay, until it is sent to the dead letter topic
+type consumer struct {
+	handler  trigger.Handler
+	settings *HandlerSettings
+	options  pulsar.ConsumerOptions
+	logger   log.Logger
+
+	receiver receiver
+	done     chan struct{}
+}
+
+func newConsumer(handler trigger.Handler, s *HandlerSettings, logger log.Logger) (*consumer, error) {
+
+	options, err := consumerOptions(s)
+	if err != nil {
+		return nil, fmt.Errorf("handler '%s': %v", handler.Name(), err)
+	}
+
+	return &consumer{handler: handler, settings: s, options: options, logger: logger}, nil
+}
+
+// consumerOptions returns the options of the consumer of the settings
+func consumerOptions(s *HandlerSettings) (pulsar.ConsumerOptions, error) {
+
+	options := pulsar.ConsumerOptions{
+		Topics:            splitList(s.Topics),
+		TopicsPattern:     s.TopicsPattern,
+		SubscriptionName:  s.Subscription,
+		ReceiverQueueSize: s.ReceiverQueueSize,
+	}
+
+	if len(options.Topics) == 0 && options.TopicsPattern == "" {
+		return options, fmt.Errorf("topics or a topics pattern must be specified")
+	}
+	if len(options.Topics) > 0 && options.TopicsPattern != "" {
+		return options, fmt.Errorf("topics and a topics pattern can't both be specified")
+	}
+	if options.TopicsPattern != "" {
+		if _, err := regexp.Compile(options.TopicsPattern); err != nil {
+			return options, fmt.Errorf("invalid topics pattern '%s': %v", options.TopicsPattern, err)
+		}
+	}
+	if s.Subscription == "" {
+		return options, fmt.Errorf("a subscription must be specified")
+	}
+
+	if s.SubscriptionType == "" {
+		s.SubscriptionType = SubscriptionExclusive
+	}
+	subscriptionType, ok := subscriptionTypes[s.SubscriptionType]
+	if !ok {
+		return options, fmt.Errorf("unsupported subscription type '%s'", s.SubscriptionType)
+	}
+	options.Type = subscriptionType
+
+	switch s.InitialPosition {
+	case "", PositionLatest:
+		options.SubscriptionInitialPosition = pulsar.SubscriptionPositionLatest
+	case PositionEarliest:
+		options.SubscriptionInitialPosition = pulsar.SubscriptionPositionEarliest
+	default:
+		return options, fmt.Errorf("unsupported initial position '%s'", s.InitialPosition)
+	}
+
+	if s.NackRedeliveryDelay != "" {
+		delay, err := time.ParseDuration(s.NackRedeliveryDelay)
+		if err != nil {
+			return options, fmt.Errorf("invalid nack redelivery delay '%s': %v", s.NackRedeliveryDelay, err)
+		}
+		options.NackRedeliveryDelay = delay
+	}
+
+	if s.MaxDeliveries < 0 {
+		return options, fmt.Errorf("max deliveries must not be negative")
+	}
+	if s.MaxDeliveries > 0 {
+		options.DLQ = &pulsar.DLQPolicy{MaxDeliveries: uint32(s.MaxDeliveries), DeadLetterTopic: s.DeadLetterTopic}
+	} else if s.DeadLetterTopic != "" {
+		return options, fmt.Errorf("a dead letter topic requires max deliveries")
+	}
+
+	return options, nil
+}
+
+// run receives the messages until the context is done, the message being handled is completed
+func (c *consumer) run(ctx context.Context) {
+
+	defer close(c.done)
+
+	c.logger.Infof("Consuming subscription '%s'", c.settings.Subscription)
+
+	for ctx.Err() == nil {
+		msg, err := c.receiver.Receive(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			c.logger.Errorf("Error receiving messages of subscription '%s': %v", c.settings.Subscription, err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(retryInterval):
+			}
+			continue
+		}
+
+		c.handle(msg)
+	}
+}
+
+// wait waits for the consumer to stop, at most for the timeout
+func (c *consumer) wait(timeout time.Duration) {
+
+	if c.done == nil {
+		return
+	}
+
+	select {
+	case <-c.done:
+	case <-time.After(timeout):
+		c.logger.Warnf("Message of subscription '%s' still being handled", c.settings.Subscription)
+	}
+}
+
+// handle invokes the action for a message, and acknowledges the message once the action succeeded
+func (c *consumer) handle(msg pulsar.Message) {
+
+	_, err := c.handler.Handle(context.Background(), toOutput(msg))
+	if err != nil {
+		c.logger.Errorf("Error handling message '%s' of topic '%s': %v", msg.ID(), msg.Topic(), err)
+		c.receiver.Nack(msg)
+		return
+	}
+
+	if err := c.receiver.Ack(msg); err != nil {
+		c.logger.Errorf("Error acknowledging message '%s' of topic '%s': %v", msg.ID(), msg.Topic(), err)
+	}
+}
+
+func toOutput(msg pulsar.Message) *Output {
+
+	out := &Output{
+		Topic:           msg.Topic(),
+		MessageID:       msg.ID().String(),
+		Key:             msg.Key(),
+		Payload:         string(msg.Payload()),
+		Properties:      msg.Properties(),
+		PublishTime:     toMillis(msg.PublishTime()),
+		EventTime:       toMillis(msg.EventTime()),
+		RedeliveryCount: int(msg.RedeliveryCount()),
+	}
+
+	var content interface{}
+	if err := json.Unmarshal(msg.Payload(), &content); err == nil {
+		out.Content = content
+	}
+
+	return out
+}
+
+func toMillis(t time.Time) int64 {
+
+	if t.IsZero() {
+		return 0
+	}
+
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+func splitList(list string) []string {
+
+	var values []string
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
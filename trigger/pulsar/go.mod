@@ -0,0 +1,7 @@
+module github.com/qingcloudhx/contrib/trigger/pulsar
+
+require (
+	flogo/core v0.9.0
+	github.com/apache/pulsar-client-go v0.12.0
+	github.com/stretchr/testify v1.3.0
+)
@@ -0,0 +1,105 @@
+package pulsar
+
+import (
+	"flogo/core/data/coerce"
+)
+
+const (
+	SubscriptionExclusive = "exclusive"
+	SubscriptionShared    = "shared"
+	SubscriptionFailover  = "failover"
+	SubscriptionKeyShared = "keyShared"
+
+	PositionLatest   = "latest"
+	PositionEarliest = "earliest"
+)
+
+type Settings struct {
+	URL       string `md:"url,required"` // The service url of the cluster (ex. pulsar://localhost:6650), pulsar+ssl urls connect with TLS
+	Token     string `md:"token"`        // The JWT token used to authenticate
+	TokenFile string `md:"tokenFile"`    // The file containing the JWT token, read again when the token is refreshed
+
+	CAFile             string `md:"caFile"`             // The PEM file of the CA certificates used to verify the brokers
+	CertFile           string `md:"certFile"`           // The PEM file of the client certificate, used to authenticate unless a token is set
+	KeyFile            string `md:"keyFile"`            // The PEM file of the client private key
+	InsecureSkipVerify bool   `md:"insecureSkipVerify"` // Don't verify the broker certificates and host names, for testing only
+}
+
+type HandlerSettings struct {
+	Topics            string `md:"topics"`                                                        // The comma separated topics to consume (ex. persistent://public/default/orders)
+	TopicsPattern     string `md:"topicsPattern"`                                                 // A regular expression, the handler consumes the topics of the namespace it matches (ex. persistent://public/default/orders-.*)
+	Subscription      string `md:"subscription,required"`                                         // The name of the subscription
+	SubscriptionType  string `md:"subscriptionType,allowed(exclusive,shared,failover,keyShared)"` // How the messages are dispatched to the consumers of the subscription: exclusive (default), shared, failover or keyShared
+	InitialPosition   string `md:"initialPosition,allowed(latest,earliest)"`                      // Where a new subscription starts: latest (default) or earliest
+	ReceiverQueueSize int    `md:"receiverQueueSize"`                                             // The number of messages prefetched by the consumer, defaults to 1000
+
+	NackRedeliveryDelay string `md:"nackRedeliveryDelay"` // How long the broker waits before redelivering a message whose action failed (ex. 30s), defaults to 1m
+	MaxDeliveries       int    `md:"maxDeliveries"`       // The number of deliveries after which a failing message is sent to the dead letter topic, never sent if not specified
+	DeadLetterTopic     string `md:"deadLetterTopic"`     // The dead letter topic, defaults to <topic>-<subscription>-DLQ
+}
+
+type Output struct {
+	Topic           string            `md:"topic"`           // The topic of the message
+	MessageID       string            `md:"messageId"`       // The id of the message
+	Key             string            `md:"key"`             // The key of the message
+	Payload         string            `md:"payload"`         // The payload of the message
+	Content         interface{}       `md:"content"`         // The payload of the message parsed, when it is JSON
+	Properties      map[string]string `md:"properties"`      // The properties of the message
+	PublishTime     int64             `md:"publishTime"`     // The time the message was published, in milliseconds since epoch
+	EventTime       int64             `md:"eventTime"`       // The event time set by the producer, in milliseconds since epoch, 0 if not set
+	RedeliveryCount int               `md:"redeliveryCount"` // The number of times the message was redelivered
+}
+
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"topic":           o.Topic,
+		"messageId":       o.MessageID,
+		"key":             o.Key,
+		"payload":         o.Payload,
+		"content":         o.Content,
+		"properties":      o.Properties,
+		"publishTime":     o.PublishTime,
+		"eventTime":       o.EventTime,
+		"redeliveryCount": o.RedeliveryCount,
+	}
+}
+
+func (o *Output) FromMap(values map[string]interface{}) error {
+
+	var err error
+	o.Topic, err = coerce.ToString(values["topic"])
+	if err != nil {
+		return err
+	}
+	o.MessageID, err = coerce.ToString(values["messageId"])
+	if err != nil {
+		return err
+	}
+	o.Key, err = coerce.ToString(values["key"])
+	if err != nil {
+		return err
+	}
+	o.Payload, err = coerce.ToString(values["payload"])
+	if err != nil {
+		return err
+	}
+	o.Content = values["content"]
+	o.Properties, err = coerce.ToParams(values["properties"])
+	if err != nil {
+		return err
+	}
+	o.PublishTime, err = coerce.ToInt64(values["publishTime"])
+	if err != nil {
+		return err
+	}
+	o.EventTime, err = coerce.ToInt64(values["eventTime"])
+	if err != nil {
+		return err
+	}
+	o.RedeliveryCount, err = coerce.ToInt(values["redeliveryCount"])
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
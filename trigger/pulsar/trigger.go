@@ -0,0 +1,165 @@
+package pulsar
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"flogo/core/data/metadata"
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+	"github.com/apache/pulsar-client-go/pulsar"
+)
+
+var triggerMd = trigger.NewMetadata(&Settings{}, &HandlerSettings{}, &Output{})
+
+func init() {
+	_ = trigger.Register(&Trigger{}, &Factory{})
+}
+
+type Factory struct {
+}
+
+// Metadata implements trigger.Factory.Metadata
+func (*Factory) Metadata() *trigger.Metadata {
+	return triggerMd
+}
+
+// New implements trigger.Factory.New
+func (*Factory) New(config *trigger.Config) (trigger.Trigger, error) {
+
+	s := &Settings{}
+	err := metadata.MapToStruct(config.Settings, s, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Trigger{settings: s}, nil
+}
+
+// Trigger consumes Pulsar subscriptions
+type Trigger struct {
+	settings  *Settings
+	logger    log.Logger
+	consumers []*consumer
+
+	client pulsar.Client
+	cancel context.CancelFunc
+}
+
+// Initialize implements trigger.Init.Initialize
+func (t *Trigger) Initialize(ctx trigger.InitContext) error {
+
+	t.logger = ctx.Logger()
+
+	for _, handler := range ctx.GetHandlers() {
+
+		s := &HandlerSettings{}
+		err := metadata.MapToStruct(handler.Settings(), s, true)
+		if err != nil {
+			return err
+		}
+
+		c, err := newConsumer(handler, s, t.logger)
+		if err != nil {
+			return err
+		}
+		t.consumers = append(t.consumers, c)
+	}
+
+	return nil
+}
+
+// Start implements util.Managed.Start
+func (t *Trigger) Start() error {
+
+	options, err := clientOptions(t.settings)
+	if err != nil {
+		return err
+	}
+
+	t.client, err = pulsar.NewClient(options)
+	if err != nil {
+		return fmt.Errorf("unable to create client for '%s': %v", t.settings.URL, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+
+	for _, c := range t.consumers {
+		pc, err := t.client.Subscribe(c.options)
+		if err != nil {
+			_ = t.Stop()
+			return fmt.Errorf("unable to subscribe to '%s': %v", c.settings.Subscription, err)
+		}
+		c.receiver = pc
+		c.done = make(chan struct{})
+		go c.run(ctx)
+	}
+
+	return nil
+}
+
+// Stop implements util.Managed.Stop
+func (t *Trigger) Stop() error {
+
+	if t.cancel != nil {
+		t.cancel()
+		t.cancel = nil
+	}
+
+	for _, c := range t.consumers {
+		c.wait(30 * time.Second)
+		if pc, ok := c.receiver.(pulsar.Consumer); ok {
+			pc.Close()
+		}
+		c.receiver = nil
+		c.done = nil
+	}
+
+	if t.client != nil {
+		t.client.Close()
+		t.client = nil
+	}
+
+	return nil
+}
+
+// clientOptions returns the options of the client of the settings, the client certificate
+// authenticates the client unless a token is set, in which case it is only used for mutual TLS
+func clientOptions(settings *Settings) (pulsar.ClientOptions, error) {
+
+	options := pulsar.ClientOptions{
+		URL:                        settings.URL,
+		TLSTrustCertsFilePath:      settings.CAFile,
+		TLSAllowInsecureConnection: settings.InsecureSkipVerify,
+		TLSValidateHostname:        !settings.InsecureSkipVerify,
+	}
+
+	if settings.URL == "" {
+		return options, fmt.Errorf("a url must be specified")
+	}
+	if settings.Token != "" && settings.TokenFile != "" {
+		return options, fmt.Errorf("a token and a token file can't both be specified")
+	}
+	if settings.CertFile != "" || settings.KeyFile != "" {
+		if settings.CertFile == "" || settings.KeyFile == "" {
+			return options, fmt.Errorf("both cert file and key file must be specified for client certificate authentication")
+		}
+	}
+
+	switch {
+	case settings.Token != "":
+		options.Authentication = pulsar.NewAuthenticationToken(settings.Token)
+	case settings.TokenFile != "":
+		options.Authentication = pulsar.NewAuthenticationTokenFromFile(settings.TokenFile)
+	case settings.CertFile != "":
+		options.Authentication = pulsar.NewAuthenticationTLS(settings.CertFile, settings.KeyFile)
+		return options, nil
+	}
+
+	options.TLSCertificateFile = settings.CertFile
+	options.TLSKeyFilePath = settings.KeyFile
+
+	return options, nil
+}
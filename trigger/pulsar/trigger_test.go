@@ -0,0 +1,234 @@
+package pulsar
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"flogo/core/action"
+	"flogo/core/api"
+	"flogo/core/support/test"
+	"flogo/core/trigger"
+	"github.com/apache/pulsar-client-go/pulsar"
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig string = `{
+	"id": "trigger-pulsar",
+	"ref": "github.com/qingcloudhx/contrib/trigger/pulsar",
+	"settings": {
+	  "url": "pulsar://localhost:6650"
+	},
+	"handlers": [
+	  {
+		"settings": {
+		  "topics": "orders",
+		  "subscription": "flogo"
+		},
+		"action": {
+		  "id": "test"
+		}
+	  }
+	]
+}`
+
+type testID struct {
+	pulsar.MessageID
+	id string
+}
+
+func (id testID) String() string {
+	return id.id
+}
+
+type testMessage struct {
+	pulsar.Message
+	id          string
+	payload     string
+	key         string
+	properties  map[string]string
+	publishTime time.Time
+	redelivery  uint32
+}
+
+func (m *testMessage) Topic() string                 { return "persistent://public/default/orders" }
+func (m *testMessage) ID() pulsar.MessageID          { return testID{id: m.id} }
+func (m *testMessage) Key() string                   { return m.key }
+func (m *testMessage) Payload() []byte               { return []byte(m.payload) }
+func (m *testMessage) Properties() map[string]string { return m.properties }
+func (m *testMessage) PublishTime() time.Time        { return m.publishTime }
+func (m *testMessage) EventTime() time.Time          { return time.Time{} }
+func (m *testMessage) RedeliveryCount() uint32       { return m.redelivery }
+
+// testReceiver returns its messages and records the acknowledgements
+type testReceiver struct {
+	mu       sync.Mutex
+	messages []pulsar.Message
+	acked    []string
+	nacked   []string
+}
+
+func (r *testReceiver) Receive(ctx context.Context) (pulsar.Message, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.messages) == 0 {
+		r.mu.Unlock()
+		<-ctx.Done()
+		r.mu.Lock()
+		return nil, ctx.Err()
+	}
+
+	msg := r.messages[0]
+	r.messages = r.messages[1:]
+	return msg, nil
+}
+
+func (r *testReceiver) Ack(msg pulsar.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.acked = append(r.acked, msg.ID().String())
+	return nil
+}
+
+func (r *testReceiver) Nack(msg pulsar.Message) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nacked = append(r.nacked, msg.ID().String())
+}
+
+func TestConsumer(t *testing.T) {
+
+	receiver := &testReceiver{messages: []pulsar.Message{
+		&testMessage{id: "1:0:0", payload: "ok"},
+		&testMessage{id: "1:1:0", payload: "fail"},
+		&testMessage{id: "1:2:0", payload: "ok"},
+	}}
+
+	config := &trigger.Config{}
+	err := json.Unmarshal([]byte(testConfig), config)
+	assert.Nil(t, err)
+
+	// the action fails for the failing payload
+	f := func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+		out := &Output{}
+		if err := out.FromMap(inputs); err != nil {
+			return nil, err
+		}
+		if out.Payload == "fail" {
+			return nil, errors.New("failed")
+		}
+		return nil, nil
+	}
+	trg, err := test.InitTrigger(&Factory{}, config, map[string]action.Action{"test": api.NewProxyAction(f)})
+	assert.Nil(t, err)
+	c := trg.(*Trigger).consumers[0]
+	c.receiver = receiver
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c.done = make(chan struct{})
+	go c.run(ctx)
+
+	for i := 0; i < 100; i++ {
+		receiver.mu.Lock()
+		handled := len(receiver.acked) + len(receiver.nacked)
+		receiver.mu.Unlock()
+		if handled == 3 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	c.wait(time.Second)
+
+	// the failed message is negatively acknowledged, so that it is redelivered
+	assert.Equal(t, []string{"1:0:0", "1:2:0"}, receiver.acked)
+	assert.Equal(t, []string{"1:1:0"}, receiver.nacked)
+}
+
+func TestConsumerOptions(t *testing.T) {
+
+	s := &HandlerSettings{Topics: "orders, invoices", Subscription: "flogo"}
+	options, err := consumerOptions(s)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"orders", "invoices"}, options.Topics)
+	assert.Equal(t, pulsar.Exclusive, options.Type)
+	assert.Equal(t, pulsar.SubscriptionPositionLatest, options.SubscriptionInitialPosition)
+	assert.Nil(t, options.DLQ)
+
+	s = &HandlerSettings{TopicsPattern: "persistent://public/default/orders-.*", Subscription: "flogo", SubscriptionType: SubscriptionKeyShared,
+		InitialPosition: PositionEarliest, NackRedeliveryDelay: "30s", MaxDeliveries: 3, DeadLetterTopic: "orders-dlq"}
+	options, err = consumerOptions(s)
+	assert.Nil(t, err)
+	assert.Equal(t, pulsar.KeyShared, options.Type)
+	assert.Equal(t, pulsar.SubscriptionPositionEarliest, options.SubscriptionInitialPosition)
+	assert.Equal(t, 30*time.Second, options.NackRedeliveryDelay)
+	assert.Equal(t, &pulsar.DLQPolicy{MaxDeliveries: 3, DeadLetterTopic: "orders-dlq"}, options.DLQ)
+
+	invalid := []*HandlerSettings{
+		{Subscription: "flogo"},
+		{Topics: "orders", TopicsPattern: "orders-.*", Subscription: "flogo"},
+		{TopicsPattern: "orders-(", Subscription: "flogo"},
+		{Topics: "orders"},
+		{Topics: "orders", Subscription: "flogo", SubscriptionType: "broadcast"},
+		{Topics: "orders", Subscription: "flogo", InitialPosition: "first"},
+		{Topics: "orders", Subscription: "flogo", NackRedeliveryDelay: "soon"},
+		{Topics: "orders", Subscription: "flogo", DeadLetterTopic: "orders-dlq"},
+	}
+	for _, s := range invalid {
+		_, err = consumerOptions(s)
+		assert.NotNil(t, err)
+	}
+}
+
+func TestClientOptions(t *testing.T) {
+
+	options, err := clientOptions(&Settings{URL: "pulsar+ssl://localhost:6651", CAFile: "ca.pem"})
+	assert.Nil(t, err)
+	assert.Equal(t, "ca.pem", options.TLSTrustCertsFilePath)
+	assert.True(t, options.TLSValidateHostname)
+	assert.Nil(t, options.Authentication)
+
+	// the client certificate authenticates the client
+	options, err = clientOptions(&Settings{URL: "pulsar+ssl://localhost:6651", CertFile: "client.pem", KeyFile: "client-key.pem"})
+	assert.Nil(t, err)
+	assert.NotNil(t, options.Authentication)
+	assert.Equal(t, "", options.TLSCertificateFile)
+
+	// the token authenticates the client, the client certificate is only used for TLS
+	options, err = clientOptions(&Settings{URL: "pulsar+ssl://localhost:6651", Token: "token", CertFile: "client.pem", KeyFile: "client-key.pem", InsecureSkipVerify: true})
+	assert.Nil(t, err)
+	assert.NotNil(t, options.Authentication)
+	assert.Equal(t, "client.pem", options.TLSCertificateFile)
+	assert.True(t, options.TLSAllowInsecureConnection)
+	assert.False(t, options.TLSValidateHostname)
+
+	_, err = clientOptions(&Settings{})
+	assert.NotNil(t, err)
+
+	_, err = clientOptions(&Settings{URL: "pulsar://localhost:6650", Token: "token", TokenFile: "token.jwt"})
+	assert.NotNil(t, err)
+
+	_, err = clientOptions(&Settings{URL: "pulsar://localhost:6650", CertFile: "client.pem"})
+	assert.NotNil(t, err)
+}
+
+func TestToOutput(t *testing.T) {
+
+	msg := &testMessage{id: "1:0:0", payload: `{"id":1}`, key: "acme", properties: map[string]string{"tenant": "acme"},
+		publishTime: time.Unix(1551434400, 0), redelivery: 2}
+
+	out := toOutput(msg)
+	assert.Equal(t, "persistent://public/default/orders", out.Topic)
+	assert.Equal(t, "1:0:0", out.MessageID)
+	assert.Equal(t, "acme", out.Key)
+	assert.Equal(t, map[string]interface{}{"id": 1.0}, out.Content)
+	assert.Equal(t, map[string]string{"tenant": "acme"}, out.Properties)
+	assert.Equal(t, int64(1551434400000), out.PublishTime)
+	assert.Equal(t, int64(0), out.EventTime)
+	assert.Equal(t, 2, out.RedeliveryCount)
+}
@@ -0,0 +1,8 @@
+module github.com/qingcloudhx/contrib/trigger/redis
+
+require (
+	flogo/core v0.9.0
+	github.com/alicebob/miniredis/v2 v2.39.0
+	github.com/go-redis/redis/v8 v8.11.4
+	github.com/stretchr/testify v1.3.0
+)
@@ -0,0 +1,87 @@
+package redis
+
+import (
+	"flogo/core/data/coerce"
+)
+
+const (
+	StartNew    = "new"
+	StartOldest = "oldest"
+)
+
+type Settings struct {
+	URL      string `md:"url,required"` // The url of the server (ex. redis://localhost:6379/0), rediss urls connect with TLS
+	Username string `md:"username"`     // The user name used to authenticate, overrides the user of the url
+	Password string `md:"password"`     // The password used to authenticate, overrides the password of the url
+
+	CAFile             string `md:"caFile"`             // The PEM file of the CA certificates used to verify the server, enables TLS
+	CertFile           string `md:"certFile"`           // The PEM file of the client certificate, for servers requiring client authentication
+	KeyFile            string `md:"keyFile"`            // The PEM file of the client private key
+	ServerName         string `md:"serverName"`         // The server name used to verify the server certificate, overrides the server host
+	InsecureSkipVerify bool   `md:"insecureSkipVerify"` // Don't verify the server certificate, for testing only
+}
+
+type HandlerSettings struct {
+	Channels string `md:"channels"` // The comma separated channels to subscribe to
+	Patterns string `md:"patterns"` // The comma separated glob patterns of the channels to subscribe to (ex. news.*)
+
+	Stream    string `md:"stream"`                        // The stream to consume with a consumer group, instead of channels
+	Group     string `md:"group"`                         // The consumer group of the stream, created if it doesn't exist
+	Consumer  string `md:"consumer"`                      // The name of the consumer in the group, defaults to the host name
+	StartFrom string `md:"startFrom,allowed(new,oldest)"` // Where a created group starts: new (default) entries only or the oldest entry of the stream
+	BatchSize int    `md:"batchSize"`                     // The max number of entries read at once, defaults to 10
+}
+
+type Output struct {
+	Channel string            `md:"channel"` // The channel the message was published to
+	Pattern string            `md:"pattern"` // The pattern the channel matched
+	Message string            `md:"message"` // The message published to the channel
+	Content interface{}       `md:"content"` // The message parsed, when it is JSON
+	Stream  string            `md:"stream"`  // The stream of the entry
+	ID      string            `md:"id"`      // The id of the entry in the stream
+	Values  map[string]string `md:"values"`  // The fields of the entry
+}
+
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"channel": o.Channel,
+		"pattern": o.Pattern,
+		"message": o.Message,
+		"content": o.Content,
+		"stream":  o.Stream,
+		"id":      o.ID,
+		"values":  o.Values,
+	}
+}
+
+func (o *Output) FromMap(values map[string]interface{}) error {
+
+	var err error
+	o.Channel, err = coerce.ToString(values["channel"])
+	if err != nil {
+		return err
+	}
+	o.Pattern, err = coerce.ToString(values["pattern"])
+	if err != nil {
+		return err
+	}
+	o.Message, err = coerce.ToString(values["message"])
+	if err != nil {
+		return err
+	}
+	o.Content = values["content"]
+	o.Stream, err = coerce.ToString(values["stream"])
+	if err != nil {
+		return err
+	}
+	o.ID, err = coerce.ToString(values["id"])
+	if err != nil {
+		return err
+	}
+	o.Values, err = coerce.ToParams(values["values"])
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
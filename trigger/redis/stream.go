@@ -0,0 +1,127 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	defaultBatchSize = 10
+	readBlock        = 5 * time.Second
+	retryDelay       = 5 * time.Second
+)
+
+// streamConsumer consumes the entries of a stream as a member of a consumer group, the
+// entries are acknowledged once the action succeeded
+type streamConsumer struct {
+	client   *redis.Client
+	handler  *handler
+	consumer string
+	count    int64
+}
+
+func newStreamConsumer(ctx context.Context, client *redis.Client, h *handler) (*streamConsumer, error) {
+
+	s := h.settings
+
+	start := "$"
+	if s.StartFrom == StartOldest {
+		start = "0"
+	}
+
+	err := client.XGroupCreateMkStream(ctx, s.Stream, s.Group, start).Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil, fmt.Errorf("unable to create group '%s' of stream '%s': %v", s.Group, s.Stream, err)
+	}
+
+	c := &streamConsumer{client: client, handler: h, consumer: s.Consumer, count: int64(s.BatchSize)}
+	if c.consumer == "" {
+		c.consumer, _ = os.Hostname()
+	}
+	if c.count <= 0 {
+		c.count = defaultBatchSize
+	}
+
+	return c, nil
+}
+
+// run handles the entries still pending for the consumer, delivered before a restart but not
+// acknowledged, and then the new entries of the group, until the context is done
+func (c *streamConsumer) run(ctx context.Context) {
+
+	s := c.handler.settings
+	logger := c.handler.logger
+
+	logger.Infof("Consuming stream '%s' as '%s' of group '%s'", s.Stream, c.consumer, s.Group)
+
+	// the pending entries are read from the start of the history of the consumer
+	id := "0"
+
+	for ctx.Err() == nil {
+		streams, err := c.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    s.Group,
+			Consumer: c.consumer,
+			Streams:  []string{s.Stream, id},
+			Count:    c.count,
+			Block:    readBlock,
+		}).Result()
+
+		if err != nil {
+			if err == redis.Nil || ctx.Err() != nil {
+				continue
+			}
+			logger.Errorf("Error reading stream '%s': %v", s.Stream, err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(retryDelay):
+			}
+			continue
+		}
+
+		var messages []redis.XMessage
+		if len(streams) > 0 {
+			messages = streams[0].Messages
+		}
+
+		if id != ">" {
+			if len(messages) == 0 {
+				// no more pending entries
+				id = ">"
+				continue
+			}
+			// the failed entries stay pending, they are handled again after a restart
+			id = messages[len(messages)-1].ID
+		}
+
+		for _, msg := range messages {
+			c.handle(ctx, msg)
+		}
+	}
+}
+
+func (c *streamConsumer) handle(ctx context.Context, msg redis.XMessage) {
+
+	s := c.handler.settings
+
+	// the entries deleted while pending have no values
+	if msg.Values != nil {
+		out := &Output{Stream: s.Stream, ID: msg.ID, Values: make(map[string]string, len(msg.Values))}
+		for name, value := range msg.Values {
+			out.Values[name] = fmt.Sprint(value)
+		}
+
+		if _, err := c.handler.handler.Handle(context.Background(), out); err != nil {
+			c.handler.logger.Errorf("Error handling entry '%s' of stream '%s': %v", msg.ID, s.Stream, err)
+			return
+		}
+	}
+
+	if err := c.client.XAck(ctx, s.Stream, s.Group, msg.ID).Err(); err != nil {
+		c.handler.logger.Errorf("Error acknowledging entry '%s' of stream '%s': %v", msg.ID, s.Stream, err)
+	}
+}
@@ -0,0 +1,259 @@
+package redis
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"flogo/core/data/metadata"
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+	"github.com/go-redis/redis/v8"
+)
+
+var triggerMd = trigger.NewMetadata(&Settings{}, &HandlerSettings{}, &Output{})
+
+func init() {
+	_ = trigger.Register(&Trigger{}, &Factory{})
+}
+
+type Factory struct {
+}
+
+// Metadata implements trigger.Factory.Metadata
+func (*Factory) Metadata() *trigger.Metadata {
+	return triggerMd
+}
+
+// New implements trigger.Factory.New
+func (*Factory) New(config *trigger.Config) (trigger.Trigger, error) {
+
+	s := &Settings{}
+	err := metadata.MapToStruct(config.Settings, s, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Trigger{settings: s}, nil
+}
+
+// Trigger subscribes to Redis channels and consumes Redis streams
+type Trigger struct {
+	settings *Settings
+	logger   log.Logger
+	handlers []*handler
+
+	client  *redis.Client
+	cancel  context.CancelFunc
+	running sync.WaitGroup
+}
+
+// handler receives the messages of the channels or the entries of the stream of a trigger handler
+type handler struct {
+	handler  trigger.Handler
+	settings *HandlerSettings
+	logger   log.Logger
+}
+
+// Initialize implements trigger.Init.Initialize
+func (t *Trigger) Initialize(ctx trigger.InitContext) error {
+
+	t.logger = ctx.Logger()
+
+	for _, h := range ctx.GetHandlers() {
+
+		s := &HandlerSettings{}
+		err := metadata.MapToStruct(h.Settings(), s, true)
+		if err != nil {
+			return err
+		}
+
+		if s.Stream != "" {
+			if s.Channels != "" || s.Patterns != "" {
+				return fmt.Errorf("handler '%s' can't consume a stream and subscribe to channels", h.Name())
+			}
+			if s.Group == "" {
+				return fmt.Errorf("handler '%s' requires a consumer group to consume stream '%s'", h.Name(), s.Stream)
+			}
+		} else if s.Channels == "" && s.Patterns == "" {
+			return fmt.Errorf("handler '%s' requires channels, patterns or a stream", h.Name())
+		}
+
+		t.handlers = append(t.handlers, &handler{handler: h, settings: s, logger: t.logger})
+	}
+
+	return nil
+}
+
+// Start implements util.Managed.Start
+func (t *Trigger) Start() error {
+
+	options, err := clientOptions(t.settings)
+	if err != nil {
+		return err
+	}
+	t.client = redis.NewClient(options)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+
+	for _, h := range t.handlers {
+		var run func(ctx context.Context)
+
+		if h.settings.Stream != "" {
+			consumer, err := newStreamConsumer(ctx, t.client, h)
+			if err != nil {
+				_ = t.Stop()
+				return err
+			}
+			run = consumer.run
+		} else {
+			pubsub, err := subscribe(ctx, t.client, h.settings)
+			if err != nil {
+				_ = t.Stop()
+				return err
+			}
+			run = func(ctx context.Context) {
+				go func() {
+					<-ctx.Done()
+					_ = pubsub.Close()
+				}()
+				h.receive(pubsub)
+			}
+		}
+
+		t.running.Add(1)
+		go func() {
+			defer t.running.Done()
+			run(ctx)
+		}()
+	}
+
+	return nil
+}
+
+// Stop implements util.Managed.Stop
+func (t *Trigger) Stop() error {
+
+	if t.cancel == nil {
+		return nil
+	}
+
+	t.cancel()
+	t.running.Wait()
+	t.cancel = nil
+
+	return t.client.Close()
+}
+
+// subscribe subscribes to the channels and the patterns of the settings
+func subscribe(ctx context.Context, client *redis.Client, s *HandlerSettings) (*redis.PubSub, error) {
+
+	pubsub := client.Subscribe(ctx)
+
+	if channels := splitList(s.Channels); len(channels) > 0 {
+		if err := pubsub.Subscribe(ctx, channels...); err != nil {
+			_ = pubsub.Close()
+			return nil, fmt.Errorf("unable to subscribe to %v: %v", channels, err)
+		}
+	}
+	if patterns := splitList(s.Patterns); len(patterns) > 0 {
+		if err := pubsub.PSubscribe(ctx, patterns...); err != nil {
+			_ = pubsub.Close()
+			return nil, fmt.Errorf("unable to subscribe to %v: %v", patterns, err)
+		}
+	}
+
+	// wait for the confirmation, so that no message published after the start is missed
+	if _, err := pubsub.Receive(ctx); err != nil {
+		_ = pubsub.Close()
+		return nil, fmt.Errorf("unable to subscribe: %v", err)
+	}
+
+	return pubsub, nil
+}
+
+// receive invokes the action for each message of the channels, until the subscription is closed
+func (h *handler) receive(pubsub *redis.PubSub) {
+
+	for msg := range pubsub.Channel() {
+		out := &Output{Channel: msg.Channel, Pattern: msg.Pattern, Message: msg.Payload}
+
+		var content interface{}
+		if err := json.Unmarshal([]byte(msg.Payload), &content); err == nil {
+			out.Content = content
+		}
+
+		if _, err := h.handler.Handle(context.Background(), out); err != nil {
+			h.logger.Errorf("Error handling message of channel '%s': %v", msg.Channel, err)
+		}
+	}
+}
+
+// clientOptions returns the options of the client of the settings
+func clientOptions(settings *Settings) (*redis.Options, error) {
+
+	options, err := redis.ParseURL(settings.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url '%s': %v", settings.URL, err)
+	}
+
+	if settings.Username != "" {
+		options.Username = settings.Username
+	}
+	if settings.Password != "" {
+		options.Password = settings.Password
+	}
+
+	if settings.CAFile != "" || settings.CertFile != "" || settings.ServerName != "" || settings.InsecureSkipVerify {
+		if options.TLSConfig == nil {
+			options.TLSConfig = &tls.Config{}
+		}
+		tlsConfig := options.TLSConfig
+		if settings.ServerName != "" {
+			tlsConfig.ServerName = settings.ServerName
+		}
+		tlsConfig.InsecureSkipVerify = settings.InsecureSkipVerify
+
+		if settings.CAFile != "" {
+			pem, err := ioutil.ReadFile(settings.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("unable to read CA file [%s]: %v", settings.CAFile, err)
+			}
+			tlsConfig.RootCAs = x509.NewCertPool()
+			if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in CA file [%s]", settings.CAFile)
+			}
+		}
+
+		if settings.CertFile != "" || settings.KeyFile != "" {
+			if settings.CertFile == "" || settings.KeyFile == "" {
+				return nil, fmt.Errorf("both cert file and key file must be specified for client certificate authentication")
+			}
+			cert, err := tls.LoadX509KeyPair(settings.CertFile, settings.KeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("unable to load client certificate: %v", err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+	}
+
+	return options, nil
+}
+
+func splitList(list string) []string {
+
+	var values []string
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,178 @@
+package rest
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"flogo/core/support/log"
+)
+
+const (
+	AccessLogText = "text"
+	AccessLogJSON = "json"
+
+	headerRequestID = "X-Request-Id"
+)
+
+// accessLogger logs a line for every request served
+type accessLogger struct {
+	logger log.Logger
+	format string
+	debug  bool
+}
+
+func newAccessLogger(logger log.Logger, format, level string) (*accessLogger, error) {
+
+	switch format {
+	case "":
+		format = AccessLogText
+	case AccessLogText, AccessLogJSON:
+	default:
+		return nil, fmt.Errorf("unsupported access log format '%s'", format)
+	}
+
+	switch level {
+	case "", "info", "debug":
+	default:
+		return nil, fmt.Errorf("unsupported access log level '%s'", level)
+	}
+
+	return &accessLogger{logger: logger, format: format, debug: level == "debug"}, nil
+}
+
+// wrap returns a handler that logs the requests served by the specified handler
+func (l *accessLogger) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		if l.debug && !l.logger.DebugEnabled() {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		requestID := r.Header.Get(headerRequestID)
+		if requestID == "" {
+			requestID = newRequestID()
+			r.Header.Set(headerRequestID, requestID)
+		}
+		w.Header().Set(headerRequestID, requestID)
+
+		start := time.Now()
+		rw := &statusWriter{ResponseWriter: w}
+
+		next.ServeHTTP(rw, r)
+
+		if rw.status == 0 {
+			rw.status = http.StatusOK
+		}
+
+		entry := accessLogEntry{
+			Method:    r.Method,
+			Path:      r.URL.RequestURI(),
+			Status:    rw.status,
+			Latency:   time.Since(start),
+			Bytes:     rw.bytes,
+			ClientIP:  clientIP(r),
+			RequestID: requestID,
+		}
+
+		l.log(entry)
+	})
+}
+
+type accessLogEntry struct {
+	Method    string        `json:"method"`
+	Path      string        `json:"path"`
+	Status    int           `json:"status"`
+	Latency   time.Duration `json:"-"`
+	Bytes     int64         `json:"bytes"`
+	ClientIP  string        `json:"clientIp"`
+	RequestID string        `json:"requestId"`
+}
+
+func (l *accessLogger) log(e accessLogEntry) {
+
+	var line string
+	if l.format == AccessLogJSON {
+		b, _ := json.Marshal(struct {
+			accessLogEntry
+			LatencyMs float64 `json:"latencyMs"`
+		}{e, float64(e.Latency) / float64(time.Millisecond)})
+		line = string(b)
+	} else {
+		line = fmt.Sprintf("%s %s %d %s %d %s %s", e.Method, e.Path, e.Status, e.Latency, e.Bytes, e.ClientIP, e.RequestID)
+	}
+
+	if l.debug {
+		l.logger.Debug(line)
+	} else {
+		l.logger.Info(line)
+	}
+}
+
+// statusWriter records the status and the number of bytes written to the response
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Flush keeps streamed responses working through the writer
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack allows the connection to be upgraded to a WebSocket
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("the response writer doesn't support hijacking")
+	}
+	if w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return hijacker.Hijack()
+}
+
+// Unwrap allows the http.ResponseController to access the underlying writer
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// clientIP returns the address of the client connected to the server
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,37 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"flogo/core/support/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccessLogger_Wrap(t *testing.T) {
+
+	l, err := newAccessLogger(log.RootLogger(), AccessLogJSON, "")
+	assert.Nil(t, err)
+
+	h := l.wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NotEmpty(t, r.Header.Get(headerRequestID))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("created"))
+	}))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/test", nil))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.NotEmpty(t, w.Header().Get(headerRequestID))
+
+	r := httptest.NewRequest("GET", "/test", nil)
+	r.Header.Set(headerRequestID, "abc")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	assert.Equal(t, "abc", w.Header().Get(headerRequestID))
+
+	_, err = newAccessLogger(log.RootLogger(), "xml", "")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,454 @@
+package rest
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	AuthNone   = "none"
+	AuthBasic  = "basic"
+	AuthAPIKey = "apiKey"
+	AuthJWT    = "jwt"
+
+	defaultAPIKeyHeader = "X-API-Key"
+
+	jwksRefreshInterval = time.Minute
+)
+
+var (
+	errUnauthorized = errors.New("unauthorized")
+	errForbidden    = errors.New("forbidden")
+)
+
+// authenticator validates the credentials of a request and returns the claims of the caller
+type authenticator struct {
+	authType     string
+	realm        string
+	users        map[string]string
+	apiKeys      []string
+	apiKeyHeader string
+
+	secret         []byte
+	jwks           *jwks
+	requiredClaims map[string]string
+	requiredScopes []string
+}
+
+func newAuthenticator(s *HandlerSettings) (*authenticator, error) {
+
+	a := &authenticator{authType: s.AuthType, realm: "flogo", requiredClaims: s.RequiredClaims}
+
+	switch s.AuthType {
+	case "", AuthNone:
+		return nil, nil
+	case AuthBasic:
+		a.users = make(map[string]string)
+		for _, cred := range splitList(s.Credentials) {
+			idx := strings.Index(cred, ":")
+			if idx < 0 {
+				return nil, fmt.Errorf("invalid basic auth credentials, expected 'user:password'")
+			}
+			a.users[cred[:idx]] = cred[idx+1:]
+		}
+		if len(a.users) == 0 {
+			return nil, fmt.Errorf("basic auth requires credentials")
+		}
+	case AuthAPIKey:
+		a.apiKeys = splitList(s.Credentials)
+		a.apiKeyHeader = s.APIKeyHeader
+		if a.apiKeyHeader == "" {
+			a.apiKeyHeader = defaultAPIKeyHeader
+		}
+		if len(a.apiKeys) == 0 {
+			return nil, fmt.Errorf("api key auth requires credentials")
+		}
+	case AuthJWT:
+		if s.Credentials != "" {
+			a.secret = []byte(s.Credentials)
+		}
+		if s.JWKSURL != "" {
+			a.jwks = &jwks{url: s.JWKSURL, keys: make(map[string]crypto.PublicKey)}
+		}
+		if a.secret == nil && a.jwks == nil {
+			return nil, fmt.Errorf("jwt auth requires a secret or a JWKS url")
+		}
+		a.requiredScopes = splitList(s.RequiredScopes)
+	default:
+		return nil, fmt.Errorf("unsupported auth type '%s'", s.AuthType)
+	}
+
+	return a, nil
+}
+
+// authenticate returns the claims of the authenticated caller, errUnauthorized if the credentials are
+// missing or invalid and errForbidden if the caller doesn't have the required claims or scopes
+func (a *authenticator) authenticate(r *http.Request) (map[string]interface{}, error) {
+
+	switch a.authType {
+	case AuthBasic:
+		user, password, ok := r.BasicAuth()
+		if !ok {
+			return nil, errUnauthorized
+		}
+		expected, exists := a.users[user]
+		if !exists || subtle.ConstantTimeCompare([]byte(password), []byte(expected)) != 1 {
+			return nil, errUnauthorized
+		}
+		return map[string]interface{}{"sub": user}, nil
+	case AuthAPIKey:
+		key := r.Header.Get(a.apiKeyHeader)
+		if key == "" {
+			return nil, errUnauthorized
+		}
+		for _, apiKey := range a.apiKeys {
+			if subtle.ConstantTimeCompare([]byte(key), []byte(apiKey)) == 1 {
+				return map[string]interface{}{}, nil
+			}
+		}
+		return nil, errUnauthorized
+	case AuthJWT:
+		header := r.Header.Get("Authorization")
+		if len(header) < 7 || !strings.EqualFold(header[:7], "Bearer ") {
+			return nil, errUnauthorized
+		}
+		claims, err := a.verifyJWT(strings.TrimSpace(header[7:]))
+		if err != nil {
+			return nil, errUnauthorized
+		}
+		if !a.authorized(claims) {
+			return nil, errForbidden
+		}
+		return claims, nil
+	}
+
+	return nil, errUnauthorized
+}
+
+// challenge writes the error response for a failed authentication
+func (a *authenticator) challenge(w http.ResponseWriter, err error) {
+	if err == errForbidden {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	switch a.authType {
+	case AuthBasic:
+		w.Header().Set("WWW-Authenticate", `Basic realm="`+a.realm+`"`)
+	case AuthJWT:
+		w.Header().Set("WWW-Authenticate", `Bearer realm="`+a.realm+`"`)
+	}
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
+
+func (a *authenticator) authorized(claims map[string]interface{}) bool {
+
+	for name, expected := range a.requiredClaims {
+		if fmt.Sprint(claims[name]) != expected {
+			// aud can be an array
+			if values, ok := claims[name].([]interface{}); !ok || !containsValue(values, expected) {
+				return false
+			}
+		}
+	}
+
+	if len(a.requiredScopes) > 0 {
+		var granted []string
+		switch t := claims["scope"].(type) {
+		case string:
+			granted = strings.Fields(t)
+		case []interface{}:
+			for _, v := range t {
+				granted = append(granted, fmt.Sprint(v))
+			}
+		}
+		for _, scope := range a.requiredScopes {
+			if !containsString(granted, scope) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func (a *authenticator) verifyJWT(token string) (map[string]interface{}, error) {
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, err
+	}
+
+	signed := []byte(parts[0] + "." + parts[1])
+
+	hash, err := jwtHash(header.Alg)
+	if err != nil {
+		return nil, err
+	}
+
+	switch header.Alg[:2] {
+	case "HS":
+		if a.secret == nil {
+			return nil, errors.New("no secret configured for HMAC token")
+		}
+		mac := hmac.New(hash.New, a.secret)
+		mac.Write(signed)
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return nil, errors.New("invalid signature")
+		}
+	case "RS", "ES":
+		if a.jwks == nil {
+			return nil, errors.New("no JWKS configured for asymmetric token")
+		}
+		key, err := a.jwks.key(header.Kid)
+		if err != nil {
+			return nil, err
+		}
+		h := hash.New()
+		h.Write(signed)
+		digest := h.Sum(nil)
+
+		switch k := key.(type) {
+		case *rsa.PublicKey:
+			if header.Alg[:2] != "RS" {
+				return nil, errors.New("key type does not match algorithm")
+			}
+			if err := rsa.VerifyPKCS1v15(k, hash, digest, signature); err != nil {
+				return nil, err
+			}
+		case *ecdsa.PublicKey:
+			if header.Alg[:2] != "ES" || len(signature)%2 != 0 {
+				return nil, errors.New("key type does not match algorithm")
+			}
+			r := new(big.Int).SetBytes(signature[:len(signature)/2])
+			s := new(big.Int).SetBytes(signature[len(signature)/2:])
+			if !ecdsa.Verify(k, digest, r, s) {
+				return nil, errors.New("invalid signature")
+			}
+		default:
+			return nil, errors.New("unsupported key type")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported algorithm '%s'", header.Alg)
+	}
+
+	claims := make(map[string]interface{})
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+
+	now := float64(time.Now().Unix())
+	if exp, ok := claims["exp"].(float64); ok && now > exp {
+		return nil, errors.New("token expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now < nbf {
+		return nil, errors.New("token not yet valid")
+	}
+
+	return claims, nil
+}
+
+func jwtHash(alg string) (crypto.Hash, error) {
+	if len(alg) != 5 {
+		return 0, fmt.Errorf("unsupported algorithm '%s'", alg)
+	}
+
+	switch alg[2:] {
+	case "256":
+		return crypto.SHA256, nil
+	case "384":
+		return crypto.SHA384, nil
+	case "512":
+		return crypto.SHA512, nil
+	}
+
+	return 0, fmt.Errorf("unsupported algorithm '%s'", alg)
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+// jwks is a lazily loaded JSON Web Key Set, it is refreshed when an unknown key id is encountered.
+// The set is fetched without holding the lock, so the requests with known keys aren't blocked by a refresh,
+// and the requests with unknown keys wait for the fetch in progress instead of starting another one.
+type jwks struct {
+	mutex     sync.Mutex
+	url       string
+	keys      map[string]crypto.PublicKey
+	refreshed time.Time
+	fetching  chan struct{}
+	fetchErr  error
+}
+
+func (j *jwks) key(kid string) (crypto.PublicKey, error) {
+	j.mutex.Lock()
+
+	if key, ok := j.keys[kid]; ok {
+		j.mutex.Unlock()
+		return key, nil
+	}
+
+	fetching := j.fetching
+	if fetching == nil {
+		if time.Since(j.refreshed) <= jwksRefreshInterval {
+			j.mutex.Unlock()
+			return nil, fmt.Errorf("unknown key id '%s'", kid)
+		}
+		fetching = make(chan struct{})
+		j.fetching = fetching
+		j.refreshed = time.Now()
+		go j.refresh(fetching)
+	}
+	j.mutex.Unlock()
+
+	<-fetching
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if key, ok := j.keys[kid]; ok {
+		return key, nil
+	}
+	if j.fetchErr != nil {
+		return nil, j.fetchErr
+	}
+
+	return nil, fmt.Errorf("unknown key id '%s'", kid)
+}
+
+// refresh fetches the key set and replaces the keys, done is closed once they are replaced
+func (j *jwks) refresh(done chan struct{}) {
+	keys, err := j.fetch()
+
+	j.mutex.Lock()
+	if err == nil {
+		j.keys = keys
+	}
+	j.fetchErr = err
+	j.fetching = nil
+	j.mutex.Unlock()
+
+	close(done)
+}
+
+func (j *jwks) fetch() (map[string]crypto.PublicKey, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(j.url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to fetch JWKS, status: %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+			Crv string `json:"crv"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		switch k.Kty {
+		case "RSA":
+			n, err1 := base64.RawURLEncoding.DecodeString(k.N)
+			e, err2 := base64.RawURLEncoding.DecodeString(k.E)
+			if err1 != nil || err2 != nil {
+				continue
+			}
+			keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		case "EC":
+			var curve elliptic.Curve
+			switch k.Crv {
+			case "P-256":
+				curve = elliptic.P256()
+			case "P-384":
+				curve = elliptic.P384()
+			case "P-521":
+				curve = elliptic.P521()
+			default:
+				continue
+			}
+			x, err1 := base64.RawURLEncoding.DecodeString(k.X)
+			y, err2 := base64.RawURLEncoding.DecodeString(k.Y)
+			if err1 != nil || err2 != nil {
+				continue
+			}
+			keys[k.Kid] = &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		}
+	}
+
+	return keys, nil
+}
+
+func splitList(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsValue(values []interface{}, value string) bool {
+	for _, v := range values {
+		if fmt.Sprint(v) == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package rest
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthenticator_Basic(t *testing.T) {
+
+	a, err := newAuthenticator(&HandlerSettings{AuthType: AuthBasic, Credentials: "alice:secret"})
+	assert.Nil(t, err)
+
+	r := httptest.NewRequest("GET", "/test", nil)
+	_, err = a.authenticate(r)
+	assert.Equal(t, errUnauthorized, err)
+
+	r.SetBasicAuth("alice", "secret")
+	claims, err := a.authenticate(r)
+	assert.Nil(t, err)
+	assert.Equal(t, "alice", claims["sub"])
+}
+
+func TestAuthenticator_JWT(t *testing.T) {
+
+	a, err := newAuthenticator(&HandlerSettings{AuthType: AuthJWT, Credentials: "secret", RequiredScopes: "read",
+		RequiredClaims: map[string]string{"aud": "api"}})
+	assert.Nil(t, err)
+
+	sign := func(payload string) string {
+		enc := base64.RawURLEncoding
+		unsigned := enc.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." + enc.EncodeToString([]byte(payload))
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write([]byte(unsigned))
+		return unsigned + "." + enc.EncodeToString(mac.Sum(nil))
+	}
+
+	r := httptest.NewRequest("GET", "/test", nil)
+	r.Header.Set("Authorization", "Bearer "+sign(`{"sub":"bob","aud":["api"],"scope":"read write"}`))
+	claims, err := a.authenticate(r)
+	assert.Nil(t, err)
+	assert.Equal(t, "bob", claims["sub"])
+
+	r.Header.Set("Authorization", "Bearer "+sign(`{"sub":"bob","aud":"api","scope":"write"}`))
+	_, err = a.authenticate(r)
+	assert.Equal(t, errForbidden, err)
+
+	r.Header.Set("Authorization", "Bearer "+sign(`{"sub":"bob","exp":1}`))
+	_, err = a.authenticate(r)
+	assert.Equal(t, errUnauthorized, err)
+}
+
+func TestJWKS_RefreshDoesNotBlock(t *testing.T) {
+
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.Nil(t, err)
+
+	enc := base64.RawURLEncoding
+	set := fmt.Sprintf(`{"keys":[{"kid":"k1","kty":"RSA","n":"%s","e":"%s"}]}`,
+		enc.EncodeToString(key.N.Bytes()), enc.EncodeToString(big.NewInt(int64(key.E)).Bytes()))
+
+	fetched := make(chan struct{}, 2)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetched <- struct{}{}
+		if len(fetched) > 1 {
+			<-release
+		}
+		_, _ = w.Write([]byte(set))
+	}))
+	defer server.Close()
+
+	j := &jwks{url: server.URL, keys: make(map[string]crypto.PublicKey)}
+
+	k, err := j.key("k1")
+	assert.Nil(t, err)
+	assert.Equal(t, key.N, k.(*rsa.PublicKey).N)
+
+	// an unknown key id triggers a refresh, which must not block the known keys
+	j.mutex.Lock()
+	j.refreshed = time.Time{}
+	j.mutex.Unlock()
+
+	unknown := make(chan error, 1)
+	go func() {
+		_, err := j.key("k2")
+		unknown <- err
+	}()
+
+	for len(fetched) < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	_, err = j.key("k1")
+	assert.Nil(t, err)
+
+	select {
+	case <-unknown:
+		t.Fatal("unknown key returned before the refresh completed")
+	default:
+	}
+
+	close(release)
+	assert.NotNil(t, <-unknown)
+}
@@ -0,0 +1,268 @@
+package rest
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCacheMaxEntries = 1000
+
+	// responses larger than this are not cached
+	cacheMaxBodySize = 1 << 20
+
+	headerCache = "X-Cache"
+)
+
+// responseCache is a LRU cache of the responses of a handler, keyed by method, path, query and caller
+type responseCache struct {
+	mutex      sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	entries    map[string]*list.Element
+	lru        *list.List
+}
+
+type cachedResponse struct {
+	key     string
+	status  int
+	header  http.Header
+	body    []byte
+	expires time.Time
+}
+
+func newResponseCache(s *HandlerSettings) (*responseCache, error) {
+
+	if s.CacheTTL == "" {
+		return nil, nil
+	}
+
+	ttl, err := time.ParseDuration(s.CacheTTL)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse cache ttl: %s", err.Error())
+	}
+	if ttl <= 0 {
+		return nil, nil
+	}
+
+	maxEntries := s.CacheMaxEntries
+	if maxEntries <= 0 {
+		maxEntries = defaultCacheMaxEntries
+	}
+
+	return &responseCache{ttl: ttl, maxEntries: maxEntries, entries: make(map[string]*list.Element), lru: list.New()}, nil
+}
+
+// cacheKey returns the key of the response of a request by the caller, in the format negotiated with the request
+func cacheKey(r *http.Request, caller, format string) string {
+	method := r.Method
+	if method == http.MethodHead {
+		// a HEAD can be served from a cached GET
+		method = http.MethodGet
+	}
+	return method + " " + r.URL.RequestURI() + " " + caller + " " + format
+}
+
+// callerKey identifies the caller of a request by a hash of its credentials, so that the response cached
+// for a caller is never served to another one
+func callerKey(r *http.Request, auth *authenticator) string {
+
+	credentials := r.Header.Get("Authorization")
+	if auth != nil && auth.authType == AuthAPIKey {
+		credentials = r.Header.Get(auth.apiKeyHeader)
+	}
+	if credentials == "" {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(credentials))
+	return hex.EncodeToString(sum[:])
+}
+
+// cacheable checks if the request can be served from or stored in the cache
+func cacheable(r *http.Request) bool {
+	return r.Method == http.MethodGet || r.Method == http.MethodHead
+}
+
+// serve writes the cached response of the request if any, the cache is bypassed
+// when the request has a Cache-Control no-cache or no-store directive
+func (c *responseCache) serve(w http.ResponseWriter, r *http.Request, key string) bool {
+
+	if hasCacheDirective(r.Header, "no-cache", "no-store") {
+		return false
+	}
+
+	c.mutex.Lock()
+	e, ok := c.entries[key]
+	var resp *cachedResponse
+	if ok {
+		resp = e.Value.(*cachedResponse)
+		if time.Now().After(resp.expires) {
+			c.remove(e)
+			resp = nil
+		} else {
+			c.lru.MoveToFront(e)
+		}
+	}
+	c.mutex.Unlock()
+
+	if resp == nil {
+		return false
+	}
+
+	for name, values := range resp.header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(headerCache, "HIT")
+	w.WriteHeader(resp.status)
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(resp.body)
+	}
+
+	return true
+}
+
+// record returns a writer that stores the response in the cache once it is complete
+func (c *responseCache) record(w http.ResponseWriter, r *http.Request, key string) *cacheRecorder {
+
+	w.Header().Set(headerCache, "MISS")
+
+	// only the headers set by the handler are cached
+	existing := make(map[string]bool, len(w.Header()))
+	for name := range w.Header() {
+		existing[name] = true
+	}
+
+	return &cacheRecorder{ResponseWriter: w, cache: c, key: key, existing: existing, store: !hasCacheDirective(r.Header, "no-store") && r.Method == http.MethodGet}
+}
+
+func (c *responseCache) add(resp *cachedResponse) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if e, ok := c.entries[resp.key]; ok {
+		c.remove(e)
+	}
+
+	c.entries[resp.key] = c.lru.PushFront(resp)
+
+	for c.lru.Len() > c.maxEntries {
+		c.remove(c.lru.Back())
+	}
+}
+
+func (c *responseCache) remove(e *list.Element) {
+	c.lru.Remove(e)
+	delete(c.entries, e.Value.(*cachedResponse).key)
+}
+
+// cacheRecorder buffers the response written by the handler so it can be cached
+type cacheRecorder struct {
+	http.ResponseWriter
+	cache    *responseCache
+	key      string
+	existing map[string]bool
+	store    bool
+	status   int
+	body     bytes.Buffer
+}
+
+func (cr *cacheRecorder) WriteHeader(code int) {
+	if cr.status == 0 {
+		cr.status = code
+	}
+	cr.ResponseWriter.WriteHeader(code)
+}
+
+func (cr *cacheRecorder) Write(b []byte) (int, error) {
+	if cr.status == 0 {
+		cr.status = http.StatusOK
+	}
+	if cr.store {
+		if cr.body.Len()+len(b) > cacheMaxBodySize {
+			cr.store = false
+			cr.body.Reset()
+		} else {
+			cr.body.Write(b)
+		}
+	}
+	return cr.ResponseWriter.Write(b)
+}
+
+// Flush keeps streamed responses working through the recorder
+func (cr *cacheRecorder) Flush() {
+	if f, ok := cr.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap allows the http.ResponseController to access the underlying writer
+func (cr *cacheRecorder) Unwrap() http.ResponseWriter {
+	return cr.ResponseWriter
+}
+
+// done caches the response if it is a complete 200 which isn't private or marked as no-store, the responses
+// setting cookies are never cached as the cookies are specific to the caller
+func (cr *cacheRecorder) done() {
+
+	if !cr.store || cr.status != http.StatusOK || hasCacheDirective(cr.Header(), "no-store", "private") {
+		return
+	}
+	if _, ok := cr.Header()["Set-Cookie"]; ok {
+		return
+	}
+
+	header := make(http.Header)
+	for name, values := range cr.Header() {
+		switch name {
+		case "Content-Encoding", "Content-Length", headerCache:
+			// set when the cached response is written
+			continue
+		case "Vary":
+			// the compression adds its own when the cached response is written
+			values = withoutValue(values, "Accept-Encoding")
+		}
+		if !cr.existing[name] && len(values) > 0 {
+			header[name] = append([]string(nil), values...)
+		}
+	}
+
+	cr.cache.add(&cachedResponse{
+		key:     cr.key,
+		status:  cr.status,
+		header:  header,
+		body:    append([]byte(nil), cr.body.Bytes()...),
+		expires: time.Now().Add(cr.cache.ttl),
+	})
+}
+
+func withoutValue(values []string, value string) []string {
+	var kept []string
+	for _, v := range values {
+		if !strings.EqualFold(strings.TrimSpace(v), value) {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
+
+func hasCacheDirective(header http.Header, directives ...string) bool {
+	for _, value := range header["Cache-Control"] {
+		for _, directive := range strings.Split(value, ",") {
+			directive = strings.ToLower(strings.TrimSpace(directive))
+			for _, d := range directives {
+				if directive == d {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,106 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseCache(t *testing.T) {
+
+	c, err := newResponseCache(&HandlerSettings{CacheTTL: "1m", CacheMaxEntries: 1})
+	assert.Nil(t, err)
+
+	calls := 0
+	serve := func(r *http.Request) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		key := cacheKey(r, "", FormatJSON)
+		if c.serve(w, r, key) {
+			return w
+		}
+		cr := c.record(w, r, key)
+		defer cr.done()
+		calls++
+		cr.Header().Set("Content-Type", "text/plain")
+		_, _ = cr.Write([]byte(r.URL.RawQuery))
+		return w
+	}
+
+	w := serve(httptest.NewRequest("GET", "/test?id=1", nil))
+	assert.Equal(t, "MISS", w.Header().Get(headerCache))
+
+	w = serve(httptest.NewRequest("GET", "/test?id=1", nil))
+	assert.Equal(t, "HIT", w.Header().Get(headerCache))
+	assert.Equal(t, "text/plain", w.Header().Get("Content-Type"))
+	assert.Equal(t, "id=1", w.Body.String())
+	assert.Equal(t, 1, calls)
+
+	r := httptest.NewRequest("GET", "/test?id=1", nil)
+	r.Header.Set("Cache-Control", "no-cache")
+	serve(r)
+	assert.Equal(t, 2, calls)
+
+	// the least recently used entry is evicted
+	serve(httptest.NewRequest("GET", "/test?id=2", nil))
+	serve(httptest.NewRequest("GET", "/test?id=1", nil))
+	assert.Equal(t, 4, calls)
+}
+
+func TestActionHandler_CachePerCaller(t *testing.T) {
+
+	h := newTestHandle(t, &Settings{}, &HandlerSettings{Method: "GET", Path: "/me", CacheTTL: "1m",
+		AuthType: AuthBasic, Credentials: "alice:a,bob:b"},
+		func(ctx context.Context, out *Output) (map[string]interface{}, error) {
+			return map[string]interface{}{"data": map[string]interface{}{"user": out.Claims["sub"]}}, nil
+		})
+
+	get := func(user, password string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/me", nil)
+		r.SetBasicAuth(user, password)
+		return serveTest(h, r)
+	}
+
+	w := get("alice", "a")
+	assert.Equal(t, "MISS", w.Header().Get(headerCache))
+	assert.JSONEq(t, `{"user":"alice"}`, w.Body.String())
+
+	w = get("alice", "a")
+	assert.Equal(t, "HIT", w.Header().Get(headerCache))
+
+	w = get("bob", "b")
+	assert.Equal(t, "MISS", w.Header().Get(headerCache))
+	assert.JSONEq(t, `{"user":"bob"}`, w.Body.String())
+
+	// the caller of an API key is identified by the key header
+	a, err := newAuthenticator(&HandlerSettings{AuthType: AuthAPIKey, Credentials: "k1,k2", APIKeyHeader: "X-Key"})
+	assert.Nil(t, err)
+	r1 := httptest.NewRequest("GET", "/me", nil)
+	r1.Header.Set("X-Key", "k1")
+	r2 := httptest.NewRequest("GET", "/me", nil)
+	r2.Header.Set("X-Key", "k2")
+	assert.NotEqual(t, callerKey(r1, a), callerKey(r2, a))
+	assert.Equal(t, "", callerKey(httptest.NewRequest("GET", "/me", nil), nil))
+}
+
+func TestActionHandler_CacheSetCookie(t *testing.T) {
+
+	calls := 0
+	h := newTestHandle(t, &Settings{}, &HandlerSettings{Method: "GET", Path: "/login", CacheTTL: "1m"},
+		func(ctx context.Context, out *Output) (map[string]interface{}, error) {
+			calls++
+			return map[string]interface{}{"data": "welcome",
+				"cookies": []interface{}{map[string]interface{}{"name": "session", "value": "s" + string(rune('0'+calls))}}}, nil
+		})
+
+	w := serveTest(h, httptest.NewRequest("GET", "/login", nil))
+	assert.Equal(t, "session=s1", w.Header().Get("Set-Cookie"))
+
+	// a reply setting cookies is not cached, so the cookie of a caller is never replayed to another
+	w = serveTest(h, httptest.NewRequest("GET", "/login", nil))
+	assert.Equal(t, "MISS", w.Header().Get(headerCache))
+	assert.Equal(t, "session=s2", w.Header().Get("Set-Cookie"))
+	assert.Equal(t, 2, calls)
+}
@@ -0,0 +1,162 @@
+package rest
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// decodeBody replaces the body of the request with a decompressing reader
+// according to its Content-Encoding
+func decodeBody(r *http.Request) error {
+
+	encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+
+	var body io.ReadCloser
+	switch encoding {
+	case "", "identity":
+		return nil
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(r.Body)
+		if err == io.EOF {
+			// empty body
+			return nil
+		} else if err != nil {
+			return err
+		}
+		body = zr
+	case "deflate":
+		// the http deflate coding is the zlib format
+		zr, err := zlib.NewReader(r.Body)
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		body = zr
+	default:
+		return fmt.Errorf("unsupported content encoding '%s'", encoding)
+	}
+
+	r.Body = &decodedBody{ReadCloser: body, raw: r.Body}
+	r.Header.Del("Content-Encoding")
+	r.Header.Del("Content-Length")
+	r.ContentLength = -1
+
+	return nil
+}
+
+type decodedBody struct {
+	io.ReadCloser
+	raw io.ReadCloser
+}
+
+func (b *decodedBody) Close() error {
+	_ = b.ReadCloser.Close()
+	return b.raw.Close()
+}
+
+// compressWriter compresses the response if the client accepts it
+type compressWriter struct {
+	http.ResponseWriter
+	w           io.WriteCloser
+	encoding    string
+	wroteHeader bool
+}
+
+func newCompressWriter(w http.ResponseWriter, r *http.Request) *compressWriter {
+
+	encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+	if encoding == "" {
+		return nil
+	}
+
+	return &compressWriter{ResponseWriter: w, encoding: encoding}
+}
+
+// negotiateEncoding picks gzip or deflate from the Accept-Encoding header, preferring gzip
+func negotiateEncoding(acceptEncoding string) string {
+
+	var deflate bool
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		fields := strings.Split(part, ";")
+		coding := strings.ToLower(strings.TrimSpace(fields[0]))
+		if len(fields) > 1 && strings.Replace(strings.TrimSpace(fields[1]), " ", "", -1) == "q=0" {
+			continue
+		}
+		switch coding {
+		case "gzip", "*":
+			return "gzip"
+		case "deflate":
+			deflate = true
+		}
+	}
+
+	if deflate {
+		return "deflate"
+	}
+	return ""
+}
+
+func (cw *compressWriter) WriteHeader(code int) {
+	if cw.wroteHeader {
+		return
+	}
+	cw.wroteHeader = true
+
+	h := cw.Header()
+	h.Add("Vary", "Accept-Encoding")
+
+	// don't compress empty or already encoded responses
+	if code == http.StatusNoContent || code == http.StatusNotModified || h.Get("Content-Encoding") != "" {
+		cw.ResponseWriter.WriteHeader(code)
+		return
+	}
+
+	h.Set("Content-Encoding", cw.encoding)
+	h.Del("Content-Length")
+
+	if cw.encoding == "gzip" {
+		cw.w = gzip.NewWriter(cw.ResponseWriter)
+	} else {
+		cw.w = zlib.NewWriter(cw.ResponseWriter)
+	}
+
+	cw.ResponseWriter.WriteHeader(code)
+}
+
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if cw.w == nil {
+		return cw.ResponseWriter.Write(b)
+	}
+	return cw.w.Write(b)
+}
+
+// Flush flushes the compressed data written so far, so streamed responses are still delivered incrementally
+func (cw *compressWriter) Flush() {
+	if f, ok := cw.w.(interface{ Flush() error }); ok {
+		_ = f.Flush()
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap allows the http.ResponseController to access the underlying writer
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// Close terminates the compressed stream
+func (cw *compressWriter) Close() error {
+	if cw.w == nil {
+		return nil
+	}
+	return cw.w.Close()
+}
@@ -0,0 +1,51 @@
+package rest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeBody(t *testing.T) {
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, _ = zw.Write([]byte(`{"a":1}`))
+	_ = zw.Close()
+
+	r := httptest.NewRequest("POST", "/test", &buf)
+	r.Header.Set("Content-Encoding", "gzip")
+
+	err := decodeBody(r)
+	assert.Nil(t, err)
+
+	b, err := ioutil.ReadAll(r.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, `{"a":1}`, string(b))
+}
+
+func TestCompressWriter(t *testing.T) {
+
+	assert.Equal(t, "gzip", negotiateEncoding("deflate, gzip;q=0.8"))
+	assert.Equal(t, "deflate", negotiateEncoding("deflate, gzip;q=0"))
+	assert.Equal(t, "", negotiateEncoding("br"))
+
+	r := httptest.NewRequest("GET", "/test", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+
+	cw := newCompressWriter(w, r)
+	_, _ = cw.Write([]byte("hello"))
+	_ = cw.Close()
+
+	assert.Equal(t, "gzip", w.Header().Get("Content-Encoding"))
+
+	zr, err := gzip.NewReader(w.Body)
+	assert.Nil(t, err)
+	b, _ := ioutil.ReadAll(zr)
+	assert.Equal(t, "hello", string(b))
+}
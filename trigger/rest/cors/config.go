@@ -0,0 +1,153 @@
+package cors
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"flogo/core/support/log"
+)
+
+// Config is the declarative CORS configuration
+type Config struct {
+	// AllowOrigins are the origins allowed to make cross-origin requests, '*' allows any origin
+	AllowOrigins []string
+	// AllowMethods are the methods allowed for cross-origin requests
+	AllowMethods []string
+	// AllowHeaders are the request headers allowed for cross-origin requests
+	AllowHeaders []string
+	// ExposeHeaders are the response headers the browser can expose to the client
+	ExposeHeaders []string
+	// AllowCredentials indicates if cookies and credentials are allowed
+	AllowCredentials bool
+	// MaxAge is how long in seconds the result of a preflight request can be cached, not sent if 0
+	MaxAge int
+}
+
+// DefaultConfig returns the default CORS configuration, which allows any origin
+func DefaultConfig() Config {
+	return Config{
+		AllowOrigins: SplitList(CORS_ALLOW_ORIGIN_DEFAULT),
+		AllowMethods: SplitList(CORS_ALLOW_METHODS_DEFAULT),
+		AllowHeaders: SplitList(CORS_ALLOW_HEADERS_DEFAULT),
+	}
+}
+
+type configCors struct {
+	config Config
+	logger log.Logger
+
+	allowMethods string
+	allowHeaders string
+}
+
+// NewWithConfig creates a Cors using the specified configuration
+func NewWithConfig(config Config, logger log.Logger) Cors {
+	return &configCors{
+		config:       config,
+		logger:       logger,
+		allowMethods: strings.Join(config.AllowMethods, ", "),
+		allowHeaders: strings.Join(config.AllowHeaders, ", "),
+	}
+}
+
+// HandlePreflight Handles the cors preflight request setting the right headers and responding to the request
+func (c *configCors) HandlePreflight(w http.ResponseWriter, r *http.Request) {
+
+	origin := r.Header.Get(HeaderOrigin)
+	if origin == "" {
+		c.logger.Info("Invalid CORS preflight request, no Origin header found")
+		writeInvalidPreflightResponse(w)
+		return
+	}
+
+	if !c.originAllowed(origin) {
+		c.logger.Infof("Invalid CORS preflight request, origin '%s' not allowed", origin)
+		writeInvalidPreflightResponse(w)
+		return
+	}
+
+	method := strings.TrimSpace(r.Header.Get(HeaderAccessControlRequestMethod))
+	if method == "" || !containsFold(c.config.AllowMethods, method) {
+		c.logger.Infof("Invalid Access Control Method for preflight request: '%s'", method)
+		writeInvalidPreflightResponse(w)
+		return
+	}
+
+	if requestHeaders := r.Header.Get(HeaderAccessControlRequestHeaders); requestHeaders != "" {
+		for _, header := range strings.Split(requestHeaders, ",") {
+			if !containsFold(c.config.AllowHeaders, strings.TrimSpace(header)) {
+				c.logger.Infof("Invalid Access Control Header for pre-flight request: '%s'", strings.TrimSpace(header))
+				writeInvalidPreflightResponse(w)
+				return
+			}
+		}
+	}
+
+	c.WriteCorsHeaders(w, r)
+	w.Header().Set(HeaderAccessControlAllowMethods, c.allowMethods)
+	w.Header().Set(HeaderAccessControlAllowHeaders, c.allowHeaders)
+	if c.config.MaxAge > 0 {
+		w.Header().Set(HeaderAccessControlMaxAge, strconv.Itoa(c.config.MaxAge))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+}
+
+// WriteCorsActualRequestHeaders writes the CORS headers that don't depend on the request origin
+func (c *configCors) WriteCorsActualRequestHeaders(w http.ResponseWriter) {
+	if len(c.config.ExposeHeaders) > 0 {
+		w.Header().Set(HeaderAccessControlExposeHeaders, strings.Join(c.config.ExposeHeaders, ", "))
+	}
+	if c.config.AllowCredentials {
+		w.Header().Set(HeaderAccessControlAllowCredentials, "true")
+	}
+}
+
+// WriteCorsHeaders writes the CORS headers of a request, if the origin of the request is allowed
+func (c *configCors) WriteCorsHeaders(w http.ResponseWriter, r *http.Request) {
+
+	origin := r.Header.Get(HeaderOrigin)
+	if origin == "" || !c.originAllowed(origin) {
+		return
+	}
+
+	if containsFold(c.config.AllowOrigins, "*") && !c.config.AllowCredentials {
+		w.Header().Set(HeaderAccessControlAllowOrigin, "*")
+	} else {
+		// a wildcard can't be used with credentials, so the origin is echoed
+		w.Header().Set(HeaderAccessControlAllowOrigin, origin)
+		w.Header().Add("Vary", HeaderOrigin)
+	}
+
+	c.WriteCorsActualRequestHeaders(w)
+}
+
+func (c *configCors) originAllowed(origin string) bool {
+	for _, allowed := range c.config.AllowOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// SplitList splits a comma separated list, trimming the values and ignoring empty ones
+func SplitList(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
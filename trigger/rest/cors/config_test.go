@@ -0,0 +1,48 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"flogo/core/support/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigHandlePreflightOk(t *testing.T) {
+	config := DefaultConfig()
+	config.AllowOrigins = []string{"http://foo.com"}
+	config.AllowCredentials = true
+	config.MaxAge = 20
+
+	r, _ := http.NewRequest("OPTIONS", "http://bar.com", nil)
+	r.Header.Set(HeaderOrigin, "http://foo.com")
+	r.Header.Set(HeaderAccessControlRequestMethod, "get")
+	r.Header.Set(HeaderAccessControlRequestHeaders, "content-type")
+
+	w := httptest.NewRecorder()
+
+	c := NewWithConfig(config, log.RootLogger())
+	c.HandlePreflight(w, r)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "http://foo.com", w.Header().Get(HeaderAccessControlAllowOrigin))
+	assert.Equal(t, CORS_ALLOW_METHODS_DEFAULT, w.Header().Get(HeaderAccessControlAllowMethods))
+	assert.Equal(t, "true", w.Header().Get(HeaderAccessControlAllowCredentials))
+	assert.Equal(t, "20", w.Header().Get(HeaderAccessControlMaxAge))
+}
+
+func TestConfigWriteCorsHeadersOriginNotAllowed(t *testing.T) {
+	config := DefaultConfig()
+	config.AllowOrigins = []string{"http://foo.com"}
+
+	r, _ := http.NewRequest("GET", "http://bar.com", nil)
+	r.Header.Set(HeaderOrigin, "http://evil.com")
+
+	w := httptest.NewRecorder()
+
+	c := NewWithConfig(config, log.RootLogger())
+	c.WriteCorsHeaders(w, r)
+
+	assert.Equal(t, "", w.Header().Get(HeaderAccessControlAllowOrigin))
+}
@@ -26,6 +26,8 @@ type Cors interface {
 	HandlePreflight(w http.ResponseWriter, r *http.Request)
 	// WriteCorsActualRequestHeaders writes the needed request headers for the CORS support
 	WriteCorsActualRequestHeaders(w http.ResponseWriter)
+	// WriteCorsHeaders writes the needed headers for the CORS support of the request
+	WriteCorsHeaders(w http.ResponseWriter, r *http.Request)
 }
 
 type cors struct {
@@ -38,7 +40,8 @@ type cors struct {
 // make sure that the cors implements the Cors interface
 var _ Cors = (*cors)(nil)
 
-//Cors constructor
+//Cors constructor, the CORS configuration is read from environment variables with the specified prefix,
+//use NewWithConfig to configure CORS declaratively
 func New(prefix string, logger log.Logger) Cors {
 	return cors{Prefix: prefix, logger: logger}
 }
@@ -160,3 +163,8 @@ func (c cors) WriteCorsActualRequestHeaders(w http.ResponseWriter) {
 		w.Header().Set(HeaderAccessControlAllowCredentials, strings.TrimSpace(allowCredentials))
 	}
 }
+
+// WriteCorsHeaders writes the CORS actual request headers
+func (c cors) WriteCorsHeaders(w http.ResponseWriter, r *http.Request) {
+	c.WriteCorsActualRequestHeaders(w)
+}
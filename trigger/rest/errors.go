@@ -0,0 +1,137 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const (
+	ErrorFormatText = "text"
+	ErrorFormatJSON = "json"
+)
+
+// Error is an error an action can return to choose the status and content of the error reply
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	Details interface{}
+}
+
+// NewError creates an error replied with the specified status
+func NewError(status int, code, message string, details interface{}) *Error {
+	return &Error{Status: status, Code: code, Message: message, Details: details}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// errorBody is the json content of the error replies
+type errorBody struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// errorReplier replies to the requests whose handling failed
+type errorReplier struct {
+	format  string
+	status  int
+	mapping map[string]int
+}
+
+func newErrorReplier(format string, s *HandlerSettings) (*errorReplier, error) {
+
+	switch format {
+	case "":
+		format = ErrorFormatText
+	case ErrorFormatText, ErrorFormatJSON:
+	default:
+		return nil, fmt.Errorf("unsupported error format '%s'", format)
+	}
+
+	status := s.ErrorStatus
+	if status == 0 {
+		status = http.StatusBadRequest
+	} else if status < 400 || status > 599 {
+		return nil, fmt.Errorf("invalid error status %d", status)
+	}
+
+	mapping := make(map[string]int, len(s.ErrorMapping))
+	for code, value := range s.ErrorMapping {
+		mapped, err := strconv.Atoi(value)
+		if err != nil || mapped < 400 || mapped > 599 {
+			return nil, fmt.Errorf("invalid status '%s' for error code '%s'", value, code)
+		}
+		mapping[code] = mapped
+	}
+
+	return &errorReplier{format: format, status: status, mapping: mapping}, nil
+}
+
+// classify returns the status, code and details of an error returned by the action. The status is the one of
+// an Error, the one mapped to the error code or the code itself if it's a 4xx or 5xx status, otherwise the
+// error status of the handler
+func (e *errorReplier) classify(err error) (int, string, interface{}) {
+
+	if restErr, ok := err.(*Error); ok {
+		status := restErr.Status
+		if status == 0 {
+			status = e.status
+		}
+		return status, restErr.Code, restErr.Details
+	}
+
+	var code string
+	var details interface{}
+
+	// activity errors have a code and data
+	if coded, ok := err.(interface{ Code() string }); ok {
+		code = coded.Code()
+	}
+	if withData, ok := err.(interface{ Data() interface{} }); ok {
+		details = withData.Data()
+	}
+
+	if status, ok := e.mapping[code]; ok {
+		return status, code, details
+	}
+	if status, convErr := strconv.Atoi(code); convErr == nil && status >= 400 && status <= 599 {
+		return status, code, details
+	}
+
+	return e.status, code, details
+}
+
+// reply writes the error reply of an error returned by the action
+func (e *errorReplier) reply(w http.ResponseWriter, err error) {
+	status, code, details := e.classify(err)
+	e.write(w, status, code, err.Error(), details)
+}
+
+// write writes an error reply, either as plain text or as a json object with a code, message and details
+func (e *errorReplier) write(w http.ResponseWriter, status int, code, message string, details interface{}) {
+
+	if e.format != ErrorFormatJSON {
+		http.Error(w, message, status)
+		return
+	}
+
+	if code == "" {
+		code = strings.ToUpper(strings.Replace(http.StatusText(status), " ", "_", -1))
+	}
+
+	b, err := json.Marshal(&errorBody{Code: code, Message: message, Details: details})
+	if err != nil {
+		b, _ = json.Marshal(&errorBody{Code: code, Message: message})
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	_, _ = w.Write(append(b, '\n'))
+}
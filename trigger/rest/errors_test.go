@@ -0,0 +1,36 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"flogo/core/activity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorReplier(t *testing.T) {
+
+	e, err := newErrorReplier(ErrorFormatJSON, &HandlerSettings{ErrorStatus: 500, ErrorMapping: map[string]string{"NOT_FOUND": "404"}})
+	assert.Nil(t, err)
+
+	w := httptest.NewRecorder()
+	e.reply(w, activity.NewError("pet 12 not found", "NOT_FOUND", map[string]interface{}{"id": 12}))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.JSONEq(t, `{"code":"NOT_FOUND","message":"pet 12 not found","details":{"id":12}}`, w.Body.String())
+
+	status, _, _ := e.classify(activity.NewError("conflict", "409", nil))
+	assert.Equal(t, http.StatusConflict, status)
+
+	status, _, _ = e.classify(NewError(http.StatusUnprocessableEntity, "INVALID", "invalid pet", nil))
+	assert.Equal(t, http.StatusUnprocessableEntity, status)
+
+	w = httptest.NewRecorder()
+	e.reply(w, errors.New("failed"))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.JSONEq(t, `{"code":"INTERNAL_SERVER_ERROR","message":"failed"}`, w.Body.String())
+
+	_, err = newErrorReplier(ErrorFormatJSON, &HandlerSettings{ErrorMapping: map[string]string{"NOT_FOUND": "200"}})
+	assert.NotNil(t, err)
+}
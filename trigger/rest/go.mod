@@ -4,4 +4,7 @@ require (
 	github.com/julienschmidt/httprouter v1.2.0
 	flogo/core v0.9.0
 	github.com/stretchr/testify v1.3.0
+	github.com/clbanning/mxj v1.8.4
+	github.com/gorilla/websocket v1.5.3
+	gopkg.in/yaml.v2 v2.4.0
 )
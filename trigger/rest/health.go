@@ -0,0 +1,94 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+const readinessCheckTimeout = 5 * time.Second
+
+// ReadinessCheck checks if a dependency of the application (database, broker, etc.) is available
+type ReadinessCheck func(ctx context.Context) error
+
+var (
+	readinessChecksMu sync.RWMutex
+	readinessChecks   = make(map[string]ReadinessCheck)
+)
+
+// RegisterReadinessCheck registers a check that must pass for the readiness endpoint to report the application as ready
+func RegisterReadinessCheck(name string, check ReadinessCheck) {
+	readinessChecksMu.Lock()
+	defer readinessChecksMu.Unlock()
+
+	readinessChecks[name] = check
+}
+
+// healthHandler reports if the server is alive
+func (t *Trigger) healthHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	writeStatus(w, http.StatusOK, map[string]interface{}{"status": "UP"})
+}
+
+// readyHandler reports if the trigger is ready to handle requests, which is the case if it isn't
+// shutting down and all the registered readiness checks pass
+func (t *Trigger) readyHandler(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+
+	select {
+	case <-t.shutdown:
+		writeStatus(w, http.StatusServiceUnavailable, map[string]interface{}{"status": "DOWN", "reason": "shutting down"})
+		return
+	default:
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), readinessCheckTimeout)
+	defer cancel()
+
+	readinessChecksMu.RLock()
+	checks := make(map[string]ReadinessCheck, len(readinessChecks))
+	for name, check := range readinessChecks {
+		checks[name] = check
+	}
+	readinessChecksMu.RUnlock()
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	results := make(map[string]interface{}, len(checks))
+	ready := true
+
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check ReadinessCheck) {
+			defer wg.Done()
+
+			err := check(ctx)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err != nil {
+				ready = false
+				results[name] = map[string]interface{}{"status": "DOWN", "error": err.Error()}
+			} else {
+				results[name] = map[string]interface{}{"status": "UP"}
+			}
+		}(name, check)
+	}
+	wg.Wait()
+
+	if !ready {
+		writeStatus(w, http.StatusServiceUnavailable, map[string]interface{}{"status": "DOWN", "checks": results})
+		return
+	}
+
+	writeStatus(w, http.StatusOK, map[string]interface{}{"status": "UP", "checks": results})
+}
+
+func writeStatus(w http.ResponseWriter, code int, status map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(status)
+}
@@ -0,0 +1,95 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+	"github.com/stretchr/testify/assert"
+)
+
+type emptyInitContext struct {
+}
+
+func (emptyInitContext) GetHandlers() []trigger.Handler {
+	return nil
+}
+
+func (emptyInitContext) Logger() log.Logger {
+	return log.RootLogger()
+}
+
+func TestHealthHandlers(t *testing.T) {
+
+	rt := &Trigger{settings: &Settings{}, logger: log.RootLogger(), shutdown: make(chan struct{})}
+
+	status := func(handle func(w http.ResponseWriter, r *http.Request)) (int, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		handle(w, httptest.NewRequest("GET", "/", nil))
+		var body map[string]interface{}
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+		return w.Code, body
+	}
+	health := func(w http.ResponseWriter, r *http.Request) { rt.healthHandler(w, r, nil) }
+	ready := func(w http.ResponseWriter, r *http.Request) { rt.readyHandler(w, r, nil) }
+
+	code, body := status(health)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "UP", body["status"])
+
+	RegisterReadinessCheck("db", func(ctx context.Context) error { return nil })
+	defer func() {
+		readinessChecksMu.Lock()
+		delete(readinessChecks, "db")
+		delete(readinessChecks, "broker")
+		readinessChecksMu.Unlock()
+	}()
+
+	code, body = status(ready)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, map[string]interface{}{"db": map[string]interface{}{"status": "UP"}}, body["checks"])
+
+	RegisterReadinessCheck("broker", func(ctx context.Context) error { return errors.New("unreachable") })
+	code, body = status(ready)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "DOWN", body["status"])
+	assert.Equal(t, map[string]interface{}{"status": "DOWN", "error": "unreachable"}, body["checks"].(map[string]interface{})["broker"])
+
+	// the trigger is not ready while shutting down, but still alive
+	close(rt.shutdown)
+	code, body = status(ready)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "shutting down", body["reason"])
+
+	code, _ = status(health)
+	assert.Equal(t, http.StatusOK, code)
+}
+
+func TestHealthHandlers_BasePath(t *testing.T) {
+
+	f := &Factory{}
+	tgr, err := f.New(&trigger.Config{Settings: map[string]interface{}{"port": 8080, "basePath": "/api/v1",
+		"healthPath": "/healthz", "readyPath": "/readyz", "metrics": true}})
+	assert.Nil(t, err)
+
+	rt := tgr.(*Trigger)
+	assert.Nil(t, rt.Initialize(emptyInitContext{}))
+
+	for path, code := range map[string]int{
+		"/api/v1/healthz": http.StatusOK,
+		"/api/v1/readyz":  http.StatusOK,
+		"/api/v1/metrics": http.StatusOK,
+		"/healthz":        http.StatusNotFound,
+		"/metrics":        http.StatusNotFound,
+	} {
+		w := httptest.NewRecorder()
+		rt.server.srv.Handler.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, code, w.Code, path)
+	}
+}
@@ -5,29 +5,112 @@ import (
 )
 
 type Settings struct {
-	Port      int    `md:"port,required"` // The port to listen on
-	EnableTLS bool   `md:"enableTLS"`     // Enable TLS on the server
-	CertFile  string `md:"certFile"`      // The path to PEM encoded server certificate
-	KeyFile   string `md:"keyFile"`       // The path to PEM encoded server key
+	Port                 int    `md:"port"`                 // The port to listen on, required unless listeners are specified
+	EnableTLS            bool   `md:"enableTLS"`            // Enable TLS on the server
+	CertFile             string `md:"certFile"`             // The path to PEM encoded server certificate
+	KeyFile              string `md:"keyFile"`              // The path to PEM encoded server key
+	DrainTimeout         string `md:"drainTimeout"`         // The time to wait for in-flight requests to complete when stopping (ex. 30s), defaults to 5s
+	MaxBodySize          int64  `md:"maxBodySize"`          // The maximum size in bytes of a request body, unlimited if not specified
+	MultipartMemory      int64  `md:"multipartMemory"`      // The maximum bytes of a multipart form kept in memory, the remainder is stored in temporary files
+	Compression          bool   `md:"compression"`          // Compress the responses with gzip or deflate when the client accepts it
+	CorsAllowOrigins     string `md:"corsAllowOrigins"`     // The origins allowed to make cross-origin requests, comma separated, defaults to *
+	CorsAllowMethods     string `md:"corsAllowMethods"`     // The methods allowed for cross-origin requests, comma separated
+	CorsAllowHeaders     string `md:"corsAllowHeaders"`     // The request headers allowed for cross-origin requests, comma separated
+	CorsExposeHeaders    string `md:"corsExposeHeaders"`    // The response headers exposed to cross-origin requests, comma separated
+	CorsAllowCredentials bool   `md:"corsAllowCredentials"` // Allow cookies and credentials on cross-origin requests
+	CorsMaxAge           int    `md:"corsMaxAge"`           // How long in seconds the result of a preflight request can be cached
+	ClientCAFile         string `md:"clientCAFile"`         // The path to the PEM encoded CAs used to verify client certificates
+	ClientAuth           string `md:"clientAuth"`           // The TLS client certificate policy (none, request, require, verifyIfGiven or requireAndVerify)
+	HandlerTimeout       string `md:"handlerTimeout"`       // The maximum time a handler can take to reply (ex. 30s), a 504 is returned when exceeded
+	BasePath             string `md:"basePath"`             // The path prefixed to the path of all handlers (ex. /api/v1)
+	OpenAPIPath          string `md:"openAPIPath"`          // The path serving the OpenAPI document of the handlers (ex. /openapi.json), not served if not specified
+	HealthPath           string `md:"healthPath"`           // The path of the liveness endpoint (ex. /healthz), not served if not specified
+	ReadyPath            string `md:"readyPath"`            // The path of the readiness endpoint (ex. /readyz), not served if not specified
+	AccessLog            bool   `md:"accessLog"`            // Log every request with its method, path, status, latency, bytes, client IP and request id
+	AccessLogFormat      string `md:"accessLogFormat"`      // The format of the access log, text (default) or json
+	AccessLogLevel       string `md:"accessLogLevel"`       // The level of the access log, info (default) or debug
+	TrustedProxies       string `md:"trustedProxies"`       // The comma separated addresses or CIDRs of the proxies whose X-Forwarded-* headers are trusted
+	Listeners            string `md:"listeners"`            // The comma separated additional addresses to listen on (ex. https://:8443, unix:///var/run/app.sock)
+	ReplyFormat          string `md:"replyFormat"`          // The format of the replies when the Accept header doesn't ask for one, json (default), xml or yaml
+	ErrorFormat          string `md:"errorFormat"`          // The format of the error replies, text (default) or json with a code, message and details
+	Metrics              bool   `md:"metrics"`              // Expose the request count, latency and in-flight requests of the handlers in the Prometheus format
+	MetricsPath          string `md:"metricsPath"`          // The path of the metrics endpoint, defaults to /metrics
+	MetricsPort          int    `md:"metricsPort"`          // The port of the metrics endpoint, served on the trigger port if not specified
 }
 
 type HandlerSettings struct {
-	Method string `md:"method,required,allowed(GET,POST,PUT,PATCH,DELETE)"` // The HTTP method (ie. GET,POST,PUT,PATCH or DELETE)
-	Path   string `md:"path,required"`                                      // The resource path
+	Method  string `md:"method,required,allowed(GET,POST,PUT,PATCH,DELETE)"` // The HTTP method (ie. GET,POST,PUT,PATCH or DELETE)
+	Path    string `md:"path,required"`                                      // The resource path
+	SSE     bool   `md:"sse"`                                                // Keep the connection open and push the replies as Server-Sent Events
+	Channel string `md:"channel"`                                            // The engine channel whose messages are pushed as events to the connected SSE clients
+	Retry   int    `md:"retry"`                                              // The reconnection time in milliseconds sent to SSE clients
+
+	AuthType       string            `md:"authType"`       // The authentication required to invoke the handler (none, basic, apiKey or jwt)
+	Credentials    string            `md:"credentials"`    // The 'user:password' pairs for basic, the keys for apiKey or the HMAC secret for jwt, comma separated
+	APIKeyHeader   string            `md:"apiKeyHeader"`   // The header containing the API key, defaults to X-API-Key
+	JWKSURL        string            `md:"jwksUrl"`        // The URL of the JSON Web Key Set used to verify RSA and ECDSA signed tokens
+	RequiredClaims map[string]string `md:"requiredClaims"` // The claims the token must have (e.g., iss, aud)
+	RequiredScopes string            `md:"requiredScopes"` // The scopes the token must grant, comma separated
+
+	RateLimit    float64 `md:"rateLimit"`    // The number of requests per second allowed per client, unlimited if not specified
+	RateBurst    int     `md:"rateBurst"`    // The number of requests a client can burst above the rate limit
+	RateLimitKey string  `md:"rateLimitKey"` // How clients are identified for rate limiting, 'ip' (default) or 'header:<name>', by IP when the header is missing
+
+	MaxBodySize     int64 `md:"maxBodySize"`     // The maximum size in bytes of a request body, overrides the trigger setting
+	MultipartMemory int64 `md:"multipartMemory"` // The maximum bytes of a multipart form kept in memory, overrides the trigger setting
+
+	CorsAllowOrigins  string `md:"corsAllowOrigins"`  // The origins allowed to make cross-origin requests, overrides the trigger setting
+	CorsExposeHeaders string `md:"corsExposeHeaders"` // The response headers exposed to cross-origin requests, overrides the trigger setting
+
+	StaticDir   string `md:"staticDir"`   // The directory to serve files from instead of invoking the action
+	IndexFile   string `md:"indexFile"`   // The file served for a directory, defaults to index.html
+	CacheMaxAge int    `md:"cacheMaxAge"` // The max-age in seconds of the Cache-Control header of served files, -1 disables caching
+
+	Timeout string `md:"timeout"` // The maximum time the handler can take to reply (ex. 30s), overrides the trigger setting
+
+	StreamUploads bool   `md:"streamUploads"` // Stream the files of multipart requests to temporary files instead of keeping them in memory
+	UploadDir     string `md:"uploadDir"`     // The directory of the temporary upload files, defaults to the system temp dir
+
+	RequestSchema string `md:"requestSchema"` // The JSON schema of the request content, used in the OpenAPI document
+	ReplySchema   string `md:"replySchema"`   // The JSON schema of the reply data, used in the OpenAPI document
+
+	WebSocket        bool  `md:"webSocket"`        // Upgrade the connection to a WebSocket, the action is invoked for each message and its reply is sent back as a frame
+	WSMaxMessageSize int64 `md:"wsMaxMessageSize"` // The maximum size in bytes of an inbound WebSocket message
+
+	CacheTTL        string `md:"cacheTTL"`        // How long the replies of GET requests are cached (ex. 30s), not cached if not specified
+	CacheMaxEntries int    `md:"cacheMaxEntries"` // The maximum number of cached replies, defaults to 1000
+
+	ReplyFormat string `md:"replyFormat"` // The default format of the replies, json, xml or yaml, overrides the trigger setting
+
+	ErrorStatus  int               `md:"errorStatus"`  // The status of the reply when the action fails with an unmapped error, defaults to 400
+	ErrorMapping map[string]string `md:"errorMapping"` // The status of the reply for each error code returned by the action (e.g., {"NOT_FOUND": "404"})
 }
 
 type Output struct {
-	PathParams  map[string]string `md:"pathParams"`  // The path parameters (e.g., 'id' in http://.../pet/:id/name )
-	QueryParams map[string]string `md:"queryParams"` // The query parameters (e.g., 'id' in http://.../pet?id=someValue )
-	Headers     map[string]string `md:"headers"`     // The HTTP header parameters
-	Content     interface{}       `md:"content"`     // The content of the request
-	Method      string            `md:"method"`      // The HTTP method used for the request
+	PathParams  map[string]string      `md:"pathParams"`  // The path parameters (e.g., 'id' in http://.../pet/:id/name )
+	QueryParams map[string]string      `md:"queryParams"` // The query parameters (e.g., 'id' in http://.../pet?id=someValue )
+	Headers     map[string]string      `md:"headers"`     // The HTTP header parameters
+	Content     interface{}            `md:"content"`     // The content of the request
+	Method      string                 `md:"method"`      // The HTTP method used for the request
+	Claims      map[string]interface{} `md:"claims"`      // The claims of the authenticated caller
+	ClientCert  map[string]interface{} `md:"clientCert"`  // The TLS client certificate of the caller (subject, commonName, issuer, dnsNames, etc.)
 
+	Event            string                 `md:"event"`            // The WebSocket event (connect, message or disconnect)
+	ConnectionID     string                 `md:"connectionId"`     // The id of the WebSocket connection
+	ClientIP         string                 `md:"clientIp"`         // The address of the client, as forwarded by the trusted proxies
+	Scheme           string                 `md:"scheme"`           // The scheme used by the client (http or https)
+	Host             string                 `md:"host"`             // The host requested by the client
+	QueryParamsMulti map[string]interface{} `md:"queryParamsMulti"` // The query parameters with all their values, each parameter is an array (e.g., 'id' in http://.../pet?id=1&id=2 )
 }
 
 type Reply struct {
-	Code int         `md:"code"` // The http code to reply with
-	Data interface{} `md:"data"` // The data to reply with
+	Code    int               `md:"code"`    // The http code to reply with
+	Data    interface{}       `md:"data"`    // The data to reply with
+	Headers map[string]string `md:"headers"` // The HTTP headers to reply with (e.g., Location, Cache-Control)
+	Cookies []interface{}     `md:"cookies"` // The cookies to set, each cookie is an object with name, value and optionally path, domain, maxAge, expires, secure, httpOnly and sameSite
+
+	ContentType string `md:"contentType"` // The Content-Type of the data, overrides the detected type
+	Encoding    string `md:"encoding"`    // The encoding of string data, 'base64' data is decoded and replied as binary
 }
 
 func (o *Output) ToMap() map[string]interface{} {
@@ -37,6 +120,17 @@ func (o *Output) ToMap() map[string]interface{} {
 		"headers":     o.Headers,
 		"method":      o.Method,
 		"content":     o.Content,
+		"claims":      o.Claims,
+		"clientCert":  o.ClientCert,
+
+		"event":        o.Event,
+		"connectionId": o.ConnectionID,
+
+		"clientIp": o.ClientIP,
+		"scheme":   o.Scheme,
+		"host":     o.Host,
+
+		"queryParamsMulti": o.QueryParamsMulti,
 	}
 }
 
@@ -60,14 +154,51 @@ func (o *Output) FromMap(values map[string]interface{}) error {
 		return err
 	}
 	o.Content = values["content"]
+	o.Claims, err = coerce.ToObject(values["claims"])
+	if err != nil {
+		return err
+	}
+	o.ClientCert, err = coerce.ToObject(values["clientCert"])
+	if err != nil {
+		return err
+	}
+	o.Event, err = coerce.ToString(values["event"])
+	if err != nil {
+		return err
+	}
+	o.ConnectionID, err = coerce.ToString(values["connectionId"])
+	if err != nil {
+		return err
+	}
+	o.ClientIP, err = coerce.ToString(values["clientIp"])
+	if err != nil {
+		return err
+	}
+	o.Scheme, err = coerce.ToString(values["scheme"])
+	if err != nil {
+		return err
+	}
+	o.Host, err = coerce.ToString(values["host"])
+	if err != nil {
+		return err
+	}
+	o.QueryParamsMulti, err = coerce.ToObject(values["queryParamsMulti"])
+	if err != nil {
+		return err
+	}
 
 	return nil
 }
 
 func (r *Reply) ToMap() map[string]interface{} {
 	return map[string]interface{}{
-		"code": r.Code,
-		"data": r.Data,
+		"code":    r.Code,
+		"data":    r.Data,
+		"headers": r.Headers,
+		"cookies": r.Cookies,
+
+		"contentType": r.ContentType,
+		"encoding":    r.Encoding,
 	}
 }
 
@@ -79,6 +210,22 @@ func (r *Reply) FromMap(values map[string]interface{}) error {
 		return err
 	}
 	r.Data, _ = values["data"]
+	r.Headers, err = coerce.ToParams(values["headers"])
+	if err != nil {
+		return err
+	}
+	r.Cookies, err = coerce.ToArray(values["cookies"])
+	if err != nil {
+		return err
+	}
+	r.ContentType, err = coerce.ToString(values["contentType"])
+	if err != nil {
+		return err
+	}
+	r.Encoding, err = coerce.ToString(values["encoding"])
+	if err != nil {
+		return err
+	}
 
 	return nil
 }
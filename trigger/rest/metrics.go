@@ -0,0 +1,175 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+const defaultMetricsPath = "/metrics"
+
+// latencyBuckets are the upper bounds in seconds of the request duration histogram buckets
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// metrics collects the request count, latency and in-flight requests of each handler
+// and exposes them in the Prometheus text format
+type metrics struct {
+	mutex     sync.Mutex
+	requests  map[requestKey]uint64
+	latencies map[handlerKey]*histogram
+	inFlight  map[handlerKey]int64
+}
+
+type handlerKey struct {
+	method string
+	path   string
+}
+
+type requestKey struct {
+	handlerKey
+	status int
+}
+
+type histogram struct {
+	buckets []uint64
+	sum     float64
+	count   uint64
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		requests:  make(map[requestKey]uint64),
+		latencies: make(map[handlerKey]*histogram),
+		inFlight:  make(map[handlerKey]int64),
+	}
+}
+
+// instrument returns a handle that records the metrics of the requests served by the specified handle
+func (m *metrics) instrument(method, path string, handle httprouter.Handle) httprouter.Handle {
+
+	key := handlerKey{method: method, path: path}
+
+	m.mutex.Lock()
+	m.latencies[key] = &histogram{buckets: make([]uint64, len(latencyBuckets))}
+	m.inFlight[key] = 0
+	m.mutex.Unlock()
+
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+
+		m.mutex.Lock()
+		m.inFlight[key]++
+		m.mutex.Unlock()
+
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w}
+
+		defer func() {
+			status := sw.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			m.observe(key, status, time.Since(start))
+		}()
+
+		handle(sw, r, ps)
+	}
+}
+
+func (m *metrics) observe(key handlerKey, status int, latency time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.inFlight[key]--
+	m.requests[requestKey{handlerKey: key, status: status}]++
+
+	h := m.latencies[key]
+	seconds := latency.Seconds()
+	for i, bound := range latencyBuckets {
+		if seconds <= bound {
+			h.buckets[i]++
+		}
+	}
+	h.sum += seconds
+	h.count++
+}
+
+// handler serves the metrics in the Prometheus text exposition format
+func (m *metrics) handler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = w.Write([]byte(m.text()))
+}
+
+func (m *metrics) text() string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var sb strings.Builder
+
+	requests := make([]requestKey, 0, len(m.requests))
+	for key := range m.requests {
+		requests = append(requests, key)
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		if requests[i].handlerKey != requests[j].handlerKey {
+			return requests[i].handlerKey.less(requests[j].handlerKey)
+		}
+		return requests[i].status < requests[j].status
+	})
+
+	sb.WriteString("# HELP flogo_rest_requests_total The number of requests handled.\n")
+	sb.WriteString("# TYPE flogo_rest_requests_total counter\n")
+	for _, key := range requests {
+		fmt.Fprintf(&sb, "flogo_rest_requests_total{%s,status=\"%d\"} %d\n", key.labels(), key.status, m.requests[key])
+	}
+
+	handlers := make([]handlerKey, 0, len(m.latencies))
+	for key := range m.latencies {
+		handlers = append(handlers, key)
+	}
+	sort.Slice(handlers, func(i, j int) bool {
+		return handlers[i].less(handlers[j])
+	})
+
+	sb.WriteString("# HELP flogo_rest_request_duration_seconds The time taken to handle the requests.\n")
+	sb.WriteString("# TYPE flogo_rest_request_duration_seconds histogram\n")
+	for _, key := range handlers {
+		h := m.latencies[key]
+		for i, bound := range latencyBuckets {
+			fmt.Fprintf(&sb, "flogo_rest_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n", key.labels(), strconv.FormatFloat(bound, 'g', -1, 64), h.buckets[i])
+		}
+		fmt.Fprintf(&sb, "flogo_rest_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", key.labels(), h.count)
+		fmt.Fprintf(&sb, "flogo_rest_request_duration_seconds_sum{%s} %s\n", key.labels(), strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(&sb, "flogo_rest_request_duration_seconds_count{%s} %d\n", key.labels(), h.count)
+	}
+
+	sb.WriteString("# HELP flogo_rest_requests_in_flight The number of requests being handled.\n")
+	sb.WriteString("# TYPE flogo_rest_requests_in_flight gauge\n")
+	for _, key := range handlers {
+		fmt.Fprintf(&sb, "flogo_rest_requests_in_flight{%s} %d\n", key.labels(), m.inFlight[key])
+	}
+
+	return sb.String()
+}
+
+func (k handlerKey) labels() string {
+	return "method=\"" + escapeLabel(k.method) + "\",path=\"" + escapeLabel(k.path) + "\""
+}
+
+func (k handlerKey) less(other handlerKey) bool {
+	if k.path != other.path {
+		return k.path < other.path
+	}
+	return k.method < other.method
+}
+
+func escapeLabel(value string) string {
+	value = strings.Replace(value, `\`, `\\`, -1)
+	value = strings.Replace(value, `"`, `\"`, -1)
+	return strings.Replace(value, "\n", `\n`, -1)
+}
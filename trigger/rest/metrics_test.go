@@ -0,0 +1,31 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+
+	m := newMetrics()
+
+	handle := m.instrument("GET", "/pets/:id", func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+		if ps.ByName("id") == "0" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	handle(httptest.NewRecorder(), httptest.NewRequest("GET", "/pets/1", nil), httprouter.Params{{Key: "id", Value: "1"}})
+	handle(httptest.NewRecorder(), httptest.NewRequest("GET", "/pets/0", nil), httprouter.Params{{Key: "id", Value: "0"}})
+
+	text := m.text()
+	assert.True(t, strings.Contains(text, `flogo_rest_requests_total{method="GET",path="/pets/:id",status="200"} 1`))
+	assert.True(t, strings.Contains(text, `flogo_rest_requests_total{method="GET",path="/pets/:id",status="404"} 1`))
+	assert.True(t, strings.Contains(text, `flogo_rest_request_duration_seconds_count{method="GET",path="/pets/:id"} 2`))
+	assert.True(t, strings.Contains(text, `flogo_rest_requests_in_flight{method="GET",path="/pets/:id"} 0`))
+}
@@ -0,0 +1,35 @@
+package rest
+
+import (
+	"net/http"
+	"sync"
+)
+
+// Middleware wraps the handler of the server, to run code before and after every request
+type Middleware func(next http.Handler) http.Handler
+
+var (
+	middlewaresMu sync.RWMutex
+	middlewares   []Middleware
+)
+
+// RegisterMiddleware registers a middleware applied around all the handlers of the REST triggers
+// initialized afterwards, middlewares are run in the order they are registered
+func RegisterMiddleware(middleware Middleware) {
+	middlewaresMu.Lock()
+	defer middlewaresMu.Unlock()
+
+	middlewares = append(middlewares, middleware)
+}
+
+// applyMiddlewares wraps the handler with the registered middlewares, the first registered being the outermost
+func applyMiddlewares(handler http.Handler) http.Handler {
+	middlewaresMu.RLock()
+	defer middlewaresMu.RUnlock()
+
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		handler = middlewares[i](handler)
+	}
+
+	return handler
+}
@@ -0,0 +1,108 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	FormatJSON = "json"
+	FormatXML  = "xml"
+	FormatYAML = "yaml"
+)
+
+// formatContentTypes are the Content-Types of the replies in each format
+var formatContentTypes = map[string]string{
+	FormatJSON: "application/json; charset=UTF-8",
+	FormatXML:  "application/xml; charset=UTF-8",
+	FormatYAML: "application/yaml; charset=UTF-8",
+}
+
+func validateFormat(format string) error {
+	if _, ok := formatContentTypes[format]; !ok && format != "" {
+		return fmt.Errorf("unsupported reply format '%s'", format)
+	}
+	return nil
+}
+
+// mediaTypeFormat returns the format of a media type, or an empty string if it isn't supported
+func mediaTypeFormat(mediaType string) string {
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return FormatJSON
+	case mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml"):
+		return FormatXML
+	case mediaType == "application/yaml" || mediaType == "application/x-yaml" || mediaType == "text/yaml" ||
+		mediaType == "text/x-yaml" || strings.HasSuffix(mediaType, "+yaml"):
+		return FormatYAML
+	}
+	return ""
+}
+
+// negotiateFormat picks the reply format preferred by the Accept header of the request,
+// the default format is used when the header is absent, accepts anything or only unsupported types
+func negotiateFormat(accept, defaultFormat string) string {
+
+	if defaultFormat == "" {
+		defaultFormat = FormatJSON
+	}
+
+	type acceptedType struct {
+		mediaType string
+		q         float64
+	}
+
+	var accepted []acceptedType
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		if q > 0 {
+			accepted = append(accepted, acceptedType{mediaType: mediaType, q: q})
+		}
+	}
+
+	sort.SliceStable(accepted, func(i, j int) bool {
+		return accepted[i].q > accepted[j].q
+	})
+
+	for _, a := range accepted {
+		if a.mediaType == "*/*" || a.mediaType == "application/*" {
+			return defaultFormat
+		}
+		if format := mediaTypeFormat(a.mediaType); format != "" {
+			return format
+		}
+	}
+
+	return defaultFormat
+}
+
+// encodeReply serializes the reply data in the specified format
+func encodeReply(format string, data interface{}) ([]byte, error) {
+	switch format {
+	case FormatXML:
+		return encodeXML(data)
+	case FormatYAML:
+		return yaml.Marshal(data)
+	}
+
+	b, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
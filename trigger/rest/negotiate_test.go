@@ -0,0 +1,61 @@
+package rest
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+
+	assert.Equal(t, FormatJSON, negotiateFormat("", ""))
+	assert.Equal(t, FormatXML, negotiateFormat("", FormatXML))
+	assert.Equal(t, FormatXML, negotiateFormat("text/xml", ""))
+	assert.Equal(t, FormatYAML, negotiateFormat("application/json;q=0.5, application/yaml;q=0.9", ""))
+	assert.Equal(t, FormatYAML, negotiateFormat("text/html, */*;q=0.8", FormatYAML))
+	assert.Equal(t, FormatJSON, negotiateFormat("application/xml;q=0, application/vnd.api+json", FormatXML))
+}
+
+func TestEncodeReply(t *testing.T) {
+
+	b, err := encodeReply(FormatYAML, map[string]interface{}{"a": 1})
+	assert.Nil(t, err)
+	assert.Equal(t, "a: 1\n", string(b))
+
+	b, err = encodeReply(FormatJSON, map[string]interface{}{"a": 1})
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"a\":1}\n", string(b))
+}
+
+func TestActionHandler_NegotiateCached(t *testing.T) {
+
+	h := newTestHandle(t, &Settings{}, &HandlerSettings{Method: "GET", Path: "/pets", CacheTTL: "1m"},
+		func(ctx context.Context, out *Output) (map[string]interface{}, error) {
+			return map[string]interface{}{"data": map[string]interface{}{"pet": "rex"}}, nil
+		})
+
+	get := func(accept string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/pets", nil)
+		r.Header.Set("Accept", accept)
+		return serveTest(h, r)
+	}
+
+	w := get("application/json")
+	assert.Equal(t, "MISS", w.Header().Get(headerCache))
+	assert.Equal(t, "Accept", w.Header().Get("Vary"))
+	assert.JSONEq(t, `{"pet":"rex"}`, w.Body.String())
+
+	// the cached JSON reply isn't served to a request accepting XML
+	w = get("application/xml")
+	assert.Equal(t, "MISS", w.Header().Get(headerCache))
+	assert.Equal(t, "application/xml; charset=UTF-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "<pet>rex</pet>", w.Body.String())
+
+	w = get("application/xml")
+	assert.Equal(t, "HIT", w.Header().Get(headerCache))
+	assert.Equal(t, "application/xml; charset=UTF-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "Accept", w.Header().Get("Vary"))
+	assert.Equal(t, "<pet>rex</pet>", w.Body.String())
+}
@@ -0,0 +1,133 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+const openAPIVersion = "3.0.3"
+
+// openAPI builds an OpenAPI 3 document describing the registered handlers
+type openAPI struct {
+	title string
+	paths map[string]map[string]interface{}
+}
+
+func newOpenAPI(title string) *openAPI {
+	return &openAPI{title: title, paths: make(map[string]map[string]interface{})}
+}
+
+// addOperation adds the operation of a handler to the document
+func (o *openAPI) addOperation(method, path string, s *HandlerSettings) error {
+
+	apiPath, params := toOpenAPIPath(path)
+
+	var parameters []interface{}
+	for _, param := range params {
+		parameters = append(parameters, map[string]interface{}{
+			"name":     param,
+			"in":       "path",
+			"required": true,
+			"schema":   map[string]interface{}{"type": "string"},
+		})
+	}
+
+	operation := map[string]interface{}{
+		"operationId": strings.ToLower(method) + operationName(apiPath),
+	}
+	if len(parameters) > 0 {
+		operation["parameters"] = parameters
+	}
+
+	if s.RequestSchema != "" {
+		var schema interface{}
+		if err := json.Unmarshal([]byte(s.RequestSchema), &schema); err != nil {
+			return err
+		}
+		operation["requestBody"] = map[string]interface{}{
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": schema},
+			},
+		}
+	}
+
+	response := map[string]interface{}{"description": "Successful response"}
+	if s.ReplySchema != "" {
+		var schema interface{}
+		if err := json.Unmarshal([]byte(s.ReplySchema), &schema); err != nil {
+			return err
+		}
+		response["content"] = map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": schema},
+		}
+	}
+	operation["responses"] = map[string]interface{}{"200": response}
+
+	if s.SSE {
+		operation["responses"] = map[string]interface{}{
+			"200": map[string]interface{}{
+				"description": "Server-Sent Events stream",
+				"content":     map[string]interface{}{"text/event-stream": map[string]interface{}{}},
+			},
+		}
+	}
+
+	if _, ok := o.paths[apiPath]; !ok {
+		o.paths[apiPath] = make(map[string]interface{})
+	}
+	o.paths[apiPath][strings.ToLower(method)] = operation
+
+	return nil
+}
+
+// document returns the OpenAPI document
+func (o *openAPI) document() map[string]interface{} {
+	return map[string]interface{}{
+		"openapi": openAPIVersion,
+		"info": map[string]interface{}{
+			"title":   o.title,
+			"version": "1.0.0",
+		},
+		"paths": o.paths,
+	}
+}
+
+// handler serves the OpenAPI document as json
+func (o *openAPI) handler() httprouter.Handle {
+	return func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(o.document())
+	}
+}
+
+// toOpenAPIPath converts a router path (/pet/:id, /files/*path) to an OpenAPI path (/pet/{id}, /files/{path})
+// and returns the names of its parameters
+func toOpenAPIPath(path string) (string, []string) {
+	var params []string
+
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		if strings.HasPrefix(part, ":") || strings.HasPrefix(part, "*") {
+			params = append(params, part[1:])
+			parts[i] = "{" + part[1:] + "}"
+		}
+	}
+
+	return strings.Join(parts, "/"), params
+}
+
+func operationName(apiPath string) string {
+	var sb strings.Builder
+	for _, part := range strings.Split(apiPath, "/") {
+		part = strings.Trim(part, "{}")
+		if part == "" {
+			continue
+		}
+		sb.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return sb.String()
+}
@@ -0,0 +1,112 @@
+package rest
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// trustedProxies resolves the client address, scheme and host of requests forwarded by trusted proxies
+type trustedProxies struct {
+	networks []*net.IPNet
+}
+
+func newTrustedProxies(list string) (*trustedProxies, error) {
+
+	p := &trustedProxies{}
+	for _, entry := range splitList(list) {
+		if !strings.Contains(entry, "/") {
+			// a single address
+			if strings.Contains(entry, ":") {
+				entry += "/128"
+			} else {
+				entry += "/32"
+			}
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy '%s'", entry)
+		}
+		p.networks = append(p.networks, network)
+	}
+
+	if len(p.networks) == 0 {
+		return nil, nil
+	}
+
+	return p, nil
+}
+
+func (p *trustedProxies) trusted(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range p.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// wrap returns a handler that replaces the remote address, scheme and host of requests received from a
+// trusted proxy with the ones of the X-Forwarded-For, X-Forwarded-Proto and X-Forwarded-Host headers
+func (p *trustedProxies) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+		if !p.trusted(clientIP(r)) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		forwarded := *r
+		forwarded.URL = new(url.URL)
+		*forwarded.URL = *r.URL
+
+		// the client is the first address, from the right, that isn't a trusted proxy
+		var forwardedFor []string
+		for _, value := range r.Header["X-Forwarded-For"] {
+			forwardedFor = append(forwardedFor, splitList(value)...)
+		}
+		for i := len(forwardedFor) - 1; i >= 0; i-- {
+			ip := forwardedFor[i]
+			if net.ParseIP(ip) == nil {
+				break
+			}
+			forwarded.RemoteAddr = net.JoinHostPort(ip, "0")
+			if !p.trusted(ip) {
+				break
+			}
+		}
+
+		if proto := firstValue(r.Header.Get("X-Forwarded-Proto")); proto == "http" || proto == "https" {
+			forwarded.URL.Scheme = proto
+		}
+		if host := firstValue(r.Header.Get("X-Forwarded-Host")); host != "" {
+			forwarded.Host = host
+		}
+
+		next.ServeHTTP(w, &forwarded)
+	})
+}
+
+func firstValue(list string) string {
+	if idx := strings.Index(list, ","); idx >= 0 {
+		list = list[:idx]
+	}
+	return strings.ToLower(strings.TrimSpace(list))
+}
+
+// requestScheme returns the scheme used by the client, as forwarded by a trusted proxy if any
+func requestScheme(r *http.Request) string {
+	if r.URL.Scheme != "" {
+		return r.URL.Scheme
+	}
+	if r.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
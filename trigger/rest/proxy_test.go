@@ -0,0 +1,42 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrustedProxies_Wrap(t *testing.T) {
+
+	p, err := newTrustedProxies("10.0.0.0/8, 192.168.1.10")
+	assert.Nil(t, err)
+
+	var ip, scheme, host string
+	h := p.wrap(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip, scheme, host = clientIP(r), requestScheme(r), r.Host
+	}))
+
+	r := httptest.NewRequest("GET", "/test", nil)
+	r.RemoteAddr = "192.168.1.10:4000"
+	r.Header.Set("X-Forwarded-For", "1.2.3.4, 5.6.7.8, 10.1.1.1")
+	r.Header.Set("X-Forwarded-Proto", "https")
+	r.Header.Set("X-Forwarded-Host", "api.example.com")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	assert.Equal(t, "5.6.7.8", ip)
+	assert.Equal(t, "https", scheme)
+	assert.Equal(t, "api.example.com", host)
+
+	// headers of untrusted clients are ignored
+	r.RemoteAddr = "5.6.7.8:4000"
+	h.ServeHTTP(httptest.NewRecorder(), r)
+
+	assert.Equal(t, "5.6.7.8", ip)
+	assert.Equal(t, "http", scheme)
+	assert.Equal(t, "example.com", host)
+
+	_, err = newTrustedProxies("10.0.0.0/33")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,112 @@
+package rest
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	rateLimitKeyIP     = "ip"
+	rateLimitKeyHeader = "header:"
+
+	// how often the buckets that are full again are discarded
+	rateLimitSweepInterval = 10 * time.Minute
+)
+
+// rateLimiter is a token bucket rate limiter keyed by client
+type rateLimiter struct {
+	mutex   sync.Mutex
+	rate    float64
+	burst   float64
+	key     string
+	buckets map[string]*bucket
+	swept   time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(s *HandlerSettings) *rateLimiter {
+
+	if s.RateLimit <= 0 {
+		return nil
+	}
+
+	burst := float64(s.RateBurst)
+	if burst < 1 {
+		burst = math.Max(1, math.Ceil(s.RateLimit))
+	}
+
+	key := s.RateLimitKey
+	if key == "" {
+		key = rateLimitKeyIP
+	}
+
+	return &rateLimiter{rate: s.RateLimit, burst: burst, key: key, buckets: make(map[string]*bucket), swept: time.Now()}
+}
+
+// allow takes a token from the bucket of the client, if the bucket is empty it returns
+// false and how long the client has to wait for a token to be available
+func (l *rateLimiter) allow(r *http.Request) (bool, time.Duration) {
+
+	key := l.clientKey(r)
+	now := time.Now()
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if now.Sub(l.swept) > rateLimitSweepInterval {
+		for k, b := range l.buckets {
+			if l.refilled(b, now) {
+				delete(l.buckets, k)
+			}
+		}
+		l.swept = now
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	return false, wait
+}
+
+// refilled returns true if the bucket is full again, it can then be discarded as a new bucket is the same
+func (l *rateLimiter) refilled(b *bucket, now time.Time) bool {
+	return now.Sub(b.last).Seconds()*l.rate >= l.burst-b.tokens
+}
+
+// clientKey returns the key of the bucket of the client, the clients without the key header are limited by IP
+func (l *rateLimiter) clientKey(r *http.Request) string {
+
+	if strings.HasPrefix(l.key, rateLimitKeyHeader) {
+		if key := r.Header.Get(strings.TrimPrefix(l.key, rateLimitKeyHeader)); key != "" {
+			return key
+		}
+	}
+
+	return clientIP(r)
+}
+
+// reject writes the 429 response, telling the client when to retry
+func (l *rateLimiter) reject(w http.ResponseWriter, wait time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+}
@@ -0,0 +1,69 @@
+package rest
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter_Allow(t *testing.T) {
+
+	l := newRateLimiter(&HandlerSettings{RateLimit: 1, RateBurst: 2, RateLimitKey: "header:X-Client"})
+
+	r := httptest.NewRequest("GET", "/test", nil)
+	r.Header.Set("X-Client", "a")
+
+	ok, _ := l.allow(r)
+	assert.True(t, ok)
+	ok, _ = l.allow(r)
+	assert.True(t, ok)
+	ok, wait := l.allow(r)
+	assert.False(t, ok)
+	assert.True(t, wait > 0)
+
+	// other clients have their own bucket
+	r.Header.Set("X-Client", "b")
+	ok, _ = l.allow(r)
+	assert.True(t, ok)
+
+	assert.Nil(t, newRateLimiter(&HandlerSettings{}))
+}
+
+func TestRateLimiter_Sweep(t *testing.T) {
+
+	// one token every 20 minutes, a bucket idle for the sweep interval is not full again
+	l := newRateLimiter(&HandlerSettings{RateLimit: 1.0 / 1200, RateBurst: 1})
+
+	b := &bucket{last: time.Now()}
+	assert.False(t, l.refilled(b, b.last.Add(19*time.Minute)))
+	assert.True(t, l.refilled(b, b.last.Add(20*time.Minute)))
+
+	r := httptest.NewRequest("GET", "/test", nil)
+	ok, _ := l.allow(r)
+	assert.True(t, ok)
+
+	// the sweep keeps the empty bucket, so the client is still limited
+	l.buckets[l.clientKey(r)].last = time.Now().Add(-11 * time.Minute)
+	l.swept = time.Now().Add(-11 * time.Minute)
+
+	ok, _ = l.allow(r)
+	assert.False(t, ok)
+}
+
+func TestRateLimiter_MissingHeader(t *testing.T) {
+
+	l := newRateLimiter(&HandlerSettings{RateLimit: 1, RateBurst: 1, RateLimitKey: "header:X-Client"})
+
+	// the clients without the header are limited by IP instead of sharing a bucket
+	r := httptest.NewRequest("GET", "/test", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	assert.Equal(t, "10.0.0.1", l.clientKey(r))
+	ok, _ := l.allow(r)
+	assert.True(t, ok)
+
+	r.RemoteAddr = "10.0.0.2:1234"
+	ok, _ = l.allow(r)
+	assert.True(t, ok)
+}
@@ -0,0 +1,99 @@
+package rest
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"flogo/core/data/coerce"
+)
+
+// writeReplyHeaders sets the headers and cookies of the reply on the response
+func writeReplyHeaders(w http.ResponseWriter, reply *Reply) error {
+
+	for name, value := range reply.Headers {
+		w.Header().Set(name, value)
+	}
+
+	for _, spec := range reply.Cookies {
+		cookie, err := toCookie(spec)
+		if err != nil {
+			return err
+		}
+		http.SetCookie(w, cookie)
+	}
+
+	return nil
+}
+
+// binaryData returns the bytes of a binary reply, either []byte data or base64 encoded string data,
+// nil is returned for any other reply
+func binaryData(reply *Reply) ([]byte, error) {
+
+	switch t := reply.Data.(type) {
+	case []byte:
+		return t, nil
+	case string:
+		if strings.EqualFold(reply.Encoding, "base64") {
+			return base64.StdEncoding.DecodeString(t)
+		}
+	}
+
+	return nil, nil
+}
+
+// toCookie converts a cookie spec to a http.Cookie
+func toCookie(spec interface{}) (*http.Cookie, error) {
+
+	values, err := coerce.ToObject(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	cookie := &http.Cookie{}
+
+	cookie.Name, _ = coerce.ToString(values["name"])
+	if cookie.Name == "" {
+		return nil, fmt.Errorf("cookie name must be specified")
+	}
+	cookie.Value, _ = coerce.ToString(values["value"])
+	cookie.Path, _ = coerce.ToString(values["path"])
+	cookie.Domain, _ = coerce.ToString(values["domain"])
+
+	cookie.MaxAge, err = coerce.ToInt(values["maxAge"])
+	if err != nil {
+		return nil, err
+	}
+	cookie.Secure, err = coerce.ToBool(values["secure"])
+	if err != nil {
+		return nil, err
+	}
+	cookie.HttpOnly, err = coerce.ToBool(values["httpOnly"])
+	if err != nil {
+		return nil, err
+	}
+
+	if expires, _ := coerce.ToString(values["expires"]); expires != "" {
+		cookie.Expires, err = time.Parse(time.RFC3339, expires)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cookie expires '%s', expected RFC3339", expires)
+		}
+	}
+
+	sameSite, _ := coerce.ToString(values["sameSite"])
+	switch strings.ToLower(sameSite) {
+	case "":
+	case "lax":
+		cookie.SameSite = http.SameSiteLaxMode
+	case "strict":
+		cookie.SameSite = http.SameSiteStrictMode
+	case "none":
+		cookie.SameSite = http.SameSiteNoneMode
+	default:
+		return nil, fmt.Errorf("invalid cookie sameSite '%s'", sameSite)
+	}
+
+	return cookie, nil
+}
@@ -0,0 +1,92 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActionHandler_ReplyHeaders(t *testing.T) {
+
+	h := newTestHandle(t, &Settings{}, &HandlerSettings{Method: "POST", Path: "/pets"},
+		func(ctx context.Context, out *Output) (map[string]interface{}, error) {
+			return map[string]interface{}{
+				"code":    201,
+				"data":    map[string]interface{}{"id": 12},
+				"headers": map[string]string{"Location": "/pets/12", "Cache-Control": "no-store"},
+				"cookies": []interface{}{
+					map[string]interface{}{"name": "session", "value": "abc", "path": "/", "maxAge": 3600,
+						"secure": true, "httpOnly": true, "sameSite": "strict"},
+					map[string]interface{}{"name": "theme", "value": "dark", "expires": "2030-01-02T15:04:05Z"},
+				},
+			}, nil
+		})
+
+	w := serveTest(h, httptest.NewRequest("POST", "/pets", nil))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "/pets/12", w.Header().Get("Location"))
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	assert.Equal(t, []string{
+		"session=abc; Path=/; Max-Age=3600; HttpOnly; Secure; SameSite=Strict",
+		"theme=dark; Expires=Wed, 02 Jan 2030 15:04:05 GMT",
+	}, w.Header()["Set-Cookie"])
+	assert.JSONEq(t, `{"id":12}`, w.Body.String())
+}
+
+func TestActionHandler_InvalidCookie(t *testing.T) {
+
+	h := newTestHandle(t, &Settings{}, &HandlerSettings{Method: "GET", Path: "/test"},
+		func(ctx context.Context, out *Output) (map[string]interface{}, error) {
+			return map[string]interface{}{"cookies": []interface{}{map[string]interface{}{"value": "abc"}}}, nil
+		})
+
+	w := serveTest(h, httptest.NewRequest("GET", "/test", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Empty(t, w.Header()["Set-Cookie"])
+
+	_, err := toCookie(map[string]interface{}{"name": "a", "sameSite": "always"})
+	assert.NotNil(t, err)
+	_, err = toCookie(map[string]interface{}{"name": "a", "expires": "tomorrow"})
+	assert.NotNil(t, err)
+}
+
+func TestActionHandler_BinaryReply(t *testing.T) {
+
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\x0dIHDR")
+
+	tests := []struct {
+		reply       map[string]interface{}
+		contentType string
+	}{
+		// the content type of raw bytes is detected
+		{map[string]interface{}{"data": png}, "image/png"},
+		// the content type of the reply takes precedence
+		{map[string]interface{}{"data": png, "contentType": "application/octet-stream"}, "application/octet-stream"},
+		// base64 string data is decoded
+		{map[string]interface{}{"data": "iVBORw0KGgoAAAANSUhEUg==", "encoding": "base64", "contentType": "image/png"}, "image/png"},
+	}
+
+	for _, test := range tests {
+		reply := test.reply
+		h := newTestHandle(t, &Settings{}, &HandlerSettings{Method: "GET", Path: "/image"},
+			func(ctx context.Context, out *Output) (map[string]interface{}, error) {
+				return reply, nil
+			})
+
+		w := serveTest(h, httptest.NewRequest("GET", "/image", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, test.contentType, w.Header().Get("Content-Type"))
+		assert.Equal(t, "16", w.Header().Get("Content-Length"))
+		assert.Equal(t, png, w.Body.Bytes())
+	}
+
+	h := newTestHandle(t, &Settings{}, &HandlerSettings{Method: "GET", Path: "/image"},
+		func(ctx context.Context, out *Output) (map[string]interface{}, error) {
+			return map[string]interface{}{"data": "not base64!", "encoding": "base64"}, nil
+		})
+	w := serveTest(h, httptest.NewRequest("GET", "/image", nil))
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
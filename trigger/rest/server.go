@@ -3,9 +3,14 @@ package rest
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"net/http"
+	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"flogo/core/support/log"
@@ -17,24 +22,37 @@ const (
 
 	httpDefaultReadTimeout = 15 * time.Second
 	httpDefaultWriteTimeout = 15 * time.Second
+	httpDefaultDrainTimeout = 5 * time.Second
 )
 
 type Server struct {
+	mu      sync.Mutex
 	running bool
 	srv *http.Server
 
 	tlsEnabled bool
 	certFile string
 	keyFile string
+
+	drainTimeout time.Duration
+
+	clientCAFile string
+	clientAuth   tls.ClientAuthType
+	tlsConfig    *tls.Config
+
+	listeners []*listener
 }
 
-func NewServer(addr string, handler http.Handler, opts ...func(*Server)) (*Server, error) {
+// listener is an additional address the server listens on
+type listener struct {
+	network string
+	addr    string
+	tls     bool
+}
 
-	if addr == "" {
-		addr = httpDefaultAddr
-	}
+func NewServer(addr string, handler http.Handler, opts ...func(*Server)) (*Server, error) {
 
-	srv := &Server{}
+	srv := &Server{drainTimeout: httpDefaultDrainTimeout}
 	srv.srv = &http.Server{
 		Addr: addr,
 		Handler: handler,
@@ -46,6 +64,14 @@ func NewServer(addr string, handler http.Handler, opts ...func(*Server)) (*Serve
 		opt(srv)
 	}
 
+	if srv.srv.Addr == "" && len(srv.listeners) == 0 {
+		if srv.tlsEnabled {
+			srv.srv.Addr = httpDefaultTlsAddr
+		} else {
+			srv.srv.Addr = httpDefaultAddr
+		}
+	}
+
 	if err := srv.validateInit(); err != nil {
 		return nil, err
 	}
@@ -63,10 +89,21 @@ func TLS(certFile, keyFile string) func(*Server) {
 		s.tlsEnabled = true
 		s.certFile = certFile
 		s.keyFile = keyFile
+	}
+}
 
-		if s.srv.Addr == "" {
-			s.srv.Addr = httpDefaultTlsAddr
-		}
+// Certificate option sets the certificate of the additional TLS listeners, without enabling TLS on the main address
+func Certificate(certFile, keyFile string) func(*Server) {
+	return func(s *Server) {
+		s.certFile = certFile
+		s.keyFile = keyFile
+	}
+}
+
+// Listener option adds an address the server listens on, network is either tcp or unix
+func Listener(network, addr string, tlsEnabled bool) func(*Server) {
+	return func(s *Server) {
+		s.listeners = append(s.listeners, &listener{network: network, addr: addr, tls: tlsEnabled})
 	}
 }
 
@@ -78,88 +115,183 @@ func Timeouts(readTimeout, writeTimeout time.Duration) func(*Server) {
 	}
 }
 
+// ClientAuth option enables TLS client certificate authentication, verifying the certificates with the CAs of the specified file
+func ClientAuth(caFile string, clientAuth tls.ClientAuthType) func(*Server) {
+	return func(s *Server) {
+		s.clientCAFile = caFile
+		s.clientAuth = clientAuth
+	}
+}
+
+// DrainTimeout option lets you set how long the server waits for in-flight requests to complete when stopped
+func DrainTimeout(drainTimeout time.Duration) func(*Server) {
+	return func(s *Server) {
+		s.drainTimeout = drainTimeout
+	}
+}
+
 ///////////////////////
 // Lifecycle
 
 // Start starts the server
 func (s *Server) Start() error {
 
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	if s.running {
 		return nil
 	}
 
-	if err := s.validateStart(); err != nil {
-		return err
+	listeners := s.listeners
+	if s.srv.Addr != "" {
+		listeners = append([]*listener{{network: "tcp", addr: s.srv.Addr, tls: s.tlsEnabled}}, listeners...)
 	}
 
-	fullAddr := s.srv.Addr
-	if fullAddr[0] == ':' {
-		fullAddr = "0.0.0.0" + s.srv.Addr
+	// all the listeners are opened before serving, so an unavailable address fails the start
+	var lns []net.Listener
+	for _, l := range listeners {
+		ln, err := l.listen()
+		if err != nil {
+			for _, opened := range lns {
+				opened.Close()
+			}
+			return err
+		}
+		lns = append(lns, ln)
 	}
 
 	s.running = true
 
-	if s.tlsEnabled {
+	for i, l := range listeners {
+		go s.serve(s.srv, l, lns[i])
+	}
 
-		go func() {
+	return nil
+}
 
-			log.RootLogger().Infof("Listening on https://%s", fullAddr)
+func (s *Server) serve(srv *http.Server, l *listener, ln net.Listener) {
 
-			if err := s.srv.ListenAndServeTLS(s.certFile, s.keyFile); err != nil {
-				s.running = false
-				if err != http.ErrServerClosed {
-					log.RootLogger().Error(err)
-				}
-			}
-		}()
-	} else {
-		go func() {
+	log.RootLogger().Infof("Listening on %s", l)
 
-			log.RootLogger().Infof("Listening on http://%s", fullAddr)
+	var err error
+	if l.tls {
+		err = srv.ServeTLS(ln, s.certFile, s.keyFile)
+	} else {
+		err = srv.Serve(ln)
+	}
 
-			if err := s.srv.ListenAndServe(); err != nil {
-				s.running = false
-				if err != http.ErrServerClosed {
-					log.RootLogger().Error(err)
-				}
-			}
-		}()
+	if err != nil && err != http.ErrServerClosed {
+		log.RootLogger().Error(err)
 	}
 
-	return nil
+	// a server replaced by a restart is no longer the one running
+	s.mu.Lock()
+	if s.srv == srv {
+		s.running = false
+	}
+	s.mu.Unlock()
 }
 
-// Stop stops the server
+// Stop stops accepting new connections and waits for the in-flight requests to complete,
+// connections still active once the drain timeout expires are forcibly closed
 func (s *Server) Stop() error {
 
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	if !s.running {
 		return nil
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	srv := s.srv
+
+	// a shut down http.Server can't serve again, the next start uses a new one with the same configuration
+	s.srv = &http.Server{
+		Addr:         srv.Addr,
+		Handler:      srv.Handler,
+		ReadTimeout:  srv.ReadTimeout,
+		WriteTimeout: srv.WriteTimeout,
+		TLSConfig:    s.tlsConfig,
+	}
+	s.running = false
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.drainTimeout)
 	defer cancel()
-	err := s.srv.Shutdown(ctx)
+
+	err := srv.Shutdown(ctx)
+	if err == context.DeadlineExceeded {
+		log.RootLogger().Warnf("Server did not drain within %s, closing remaining connections", s.drainTimeout)
+		return srv.Close()
+	}
+
 	return err
 }
 
 ///////////////////////
 // Validation Helpers
 
-func (s *Server) validateStart()  error {
+// parseListener parses a listener address, either a tcp address optionally prefixed with
+// http:// or https:// (ex. https://:8443) or the path of a unix socket prefixed with unix://
+func parseListener(addr string) (*listener, error) {
+
+	addr = strings.TrimSpace(addr)
+
+	switch {
+	case strings.HasPrefix(addr, "unix://"):
+		return &listener{network: "unix", addr: strings.TrimPrefix(addr, "unix://")}, nil
+	case strings.HasPrefix(addr, "https://"):
+		return &listener{network: "tcp", addr: strings.TrimPrefix(addr, "https://"), tls: true}, nil
+	case strings.HasPrefix(addr, "http://"):
+		addr = strings.TrimPrefix(addr, "http://")
+	case strings.Contains(addr, "://"):
+		return nil, fmt.Errorf("unsupported listener '%s'", addr)
+	}
 
-	//check if port is available
-	ln, err := net.Listen("tcp", s.srv.Addr)
-	if err != nil {
-		return err
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return nil, fmt.Errorf("invalid listener '%s': %s", addr, err.Error())
 	}
-	ln.Close()
 
-	return nil
+	return &listener{network: "tcp", addr: addr}, nil
+}
+
+func (l *listener) listen() (net.Listener, error) {
+
+	if l.network == "unix" {
+		// remove the socket file left over by a previous run
+		if err := os.Remove(l.addr); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	return net.Listen(l.network, l.addr)
+}
+
+func (l *listener) String() string {
+
+	if l.network == "unix" {
+		return "unix://" + l.addr
+	}
+
+	addr := l.addr
+	if addr[0] == ':' {
+		addr = "0.0.0.0" + addr
+	}
+
+	if l.tls {
+		return "https://" + addr
+	}
+	return "http://" + addr
 }
 
 func (s *Server) validateInit()  error {
 
-	if s.tlsEnabled {
+	tlsEnabled := s.tlsEnabled
+	for _, l := range s.listeners {
+		tlsEnabled = tlsEnabled || l.tls
+	}
+
+	if tlsEnabled {
 		// using tls, so validate cert & key
 
 		if s.certFile == "" || s.keyFile == "" {
@@ -170,6 +302,28 @@ func (s *Server) validateInit()  error {
 		if err != nil {
 			return err
 		}
+
+		if s.clientAuth != tls.NoClientCert {
+			tlsConfig := &tls.Config{ClientAuth: s.clientAuth}
+
+			if s.clientCAFile != "" {
+				pem, err := ioutil.ReadFile(s.clientCAFile)
+				if err != nil {
+					return err
+				}
+				tlsConfig.ClientCAs = x509.NewCertPool()
+				if !tlsConfig.ClientCAs.AppendCertsFromPEM(pem) {
+					return fmt.Errorf("no certificates found in client CA file '%s'", s.clientCAFile)
+				}
+			} else if s.clientAuth >= tls.VerifyClientCertIfGiven {
+				return fmt.Errorf("when verifying client certificates, the client CA file must be specified")
+			}
+
+			s.tlsConfig = tlsConfig
+			s.srv.TLSConfig = tlsConfig
+		}
+	} else if s.clientAuth != tls.NoClientCert {
+		return fmt.Errorf("client certificate authentication requires TLS to be enabled")
 	}
 
 	return nil
@@ -0,0 +1,110 @@
+package rest
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseListener(t *testing.T) {
+
+	l, err := parseListener("https://:8443")
+	assert.Nil(t, err)
+	assert.Equal(t, &listener{network: "tcp", addr: ":8443", tls: true}, l)
+
+	l, err = parseListener("localhost:8080")
+	assert.Nil(t, err)
+	assert.Equal(t, &listener{network: "tcp", addr: "localhost:8080"}, l)
+
+	l, err = parseListener("unix:///var/run/app.sock")
+	assert.Nil(t, err)
+	assert.Equal(t, &listener{network: "unix", addr: "/var/run/app.sock"}, l)
+
+	_, err = parseListener("udp://:53")
+	assert.NotNil(t, err)
+}
+
+func TestServer_UnixListener(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "rest")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "app.sock")
+
+	srv, err := NewServer("", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}), Listener("unix", socket, false))
+	assert.Nil(t, err)
+	assert.Nil(t, srv.Start())
+	defer srv.Stop()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		},
+	}}
+
+	resp, err := client.Get("http://unix/test")
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+
+	b, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, "ok", string(b))
+}
+
+func TestServer_DrainAndRestart(t *testing.T) {
+
+	// find a free port, the server listens on the same address when restarted
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	addr := ln.Addr().String()
+	ln.Close()
+
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	srv, err := NewServer(addr, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			started <- struct{}{}
+			<-release
+		}
+		_, _ = w.Write([]byte("ok"))
+	}), DrainTimeout(100*time.Millisecond))
+	assert.Nil(t, err)
+	assert.Nil(t, srv.Start())
+
+	failed := make(chan error, 1)
+	go func() {
+		resp, err := http.Get("http://" + addr + "/slow")
+		if err == nil {
+			resp.Body.Close()
+		}
+		failed <- err
+	}()
+	<-started
+
+	// the request still in flight once the drain timeout expires is cut off
+	begin := time.Now()
+	assert.Nil(t, srv.Stop())
+	assert.True(t, time.Since(begin) >= 100*time.Millisecond)
+	assert.NotNil(t, <-failed)
+	close(release)
+
+	assert.Nil(t, srv.Start())
+	defer srv.Stop()
+
+	resp, err := http.Get("http://" + addr + "/test")
+	assert.Nil(t, err)
+	if err == nil {
+		defer resp.Body.Close()
+		b, _ := ioutil.ReadAll(resp.Body)
+		assert.Equal(t, "ok", string(b))
+	}
+}
@@ -0,0 +1,289 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"flogo/core/data/coerce"
+	"flogo/core/engine/channels"
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+)
+
+// Event is a Server-Sent Event pushed to the client
+type Event struct {
+	ID    string      `md:"id"`    // The event id
+	Event string      `md:"event"` // The event type
+	Data  interface{} `md:"data"`  // The event data
+	Retry int         `md:"retry"` // The reconnection time in milliseconds
+}
+
+func (e *Event) FromMap(values map[string]interface{}) error {
+
+	var err error
+	e.ID, err = coerce.ToString(values["id"])
+	if err != nil {
+		return err
+	}
+	e.Event, err = coerce.ToString(values["event"])
+	if err != nil {
+		return err
+	}
+	e.Retry, err = coerce.ToInt(values["retry"])
+	if err != nil {
+		return err
+	}
+	e.Data = values["data"]
+
+	return nil
+}
+
+// toEvent converts a value pushed by an action or channel to an Event, a map is
+// considered an event if it has a 'data' key, otherwise the whole value is the data
+func toEvent(value interface{}) (*Event, error) {
+
+	switch t := value.(type) {
+	case *Event:
+		return t, nil
+	case Event:
+		return &t, nil
+	case map[string]interface{}:
+		if _, ok := t["data"]; ok {
+			e := &Event{}
+			err := e.FromMap(t)
+			return e, err
+		}
+	}
+
+	return &Event{Data: value}, nil
+}
+
+// write writes the event as a text/event-stream frame
+func (e *Event) write(w http.ResponseWriter) error {
+
+	var sb strings.Builder
+
+	if e.ID != "" {
+		sb.WriteString("id: " + e.ID + "\n")
+	}
+	if e.Event != "" {
+		sb.WriteString("event: " + e.Event + "\n")
+	}
+	if e.Retry > 0 {
+		sb.WriteString("retry: " + strconv.Itoa(e.Retry) + "\n")
+	}
+
+	var data string
+	switch t := e.Data.(type) {
+	case nil:
+	case string:
+		data = t
+	case []byte:
+		data = string(t)
+	default:
+		b, err := json.Marshal(t)
+		if err != nil {
+			return err
+		}
+		data = string(b)
+	}
+
+	for _, line := range strings.Split(data, "\n") {
+		sb.WriteString("data: " + line + "\n")
+	}
+	sb.WriteString("\n")
+
+	_, err := w.Write([]byte(sb.String()))
+	if err != nil {
+		return err
+	}
+
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+
+	return nil
+}
+
+// sseBroker fans out the events published on an engine channel to all
+// clients connected to a SSE handler
+type sseBroker struct {
+	mutex   sync.RWMutex
+	clients map[chan interface{}]struct{}
+	logger  log.Logger
+}
+
+func newSSEBroker(channel string, logger log.Logger) (*sseBroker, error) {
+
+	ch := channels.Get(channel)
+	if ch == nil {
+		return nil, fmt.Errorf("unknown engine channel '%s'", channel)
+	}
+
+	b := &sseBroker{clients: make(map[chan interface{}]struct{}), logger: logger}
+	err := ch.RegisterCallback(b.publish)
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+func (b *sseBroker) subscribe() chan interface{} {
+	client := make(chan interface{}, 16)
+
+	b.mutex.Lock()
+	b.clients[client] = struct{}{}
+	b.mutex.Unlock()
+
+	return client
+}
+
+func (b *sseBroker) unsubscribe(client chan interface{}) {
+	b.mutex.Lock()
+	delete(b.clients, client)
+	b.mutex.Unlock()
+}
+
+func (b *sseBroker) publish(msg interface{}) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	for client := range b.clients {
+		select {
+		case client <- msg:
+		default:
+			b.logger.Warnf("SSE client is too slow, dropping event")
+		}
+	}
+}
+
+// sseHandler keeps the connection open and pushes the events produced by the
+// action and/or published on the configured engine channel
+type sseHandler struct {
+	rt      *Trigger
+	handler trigger.Handler
+	broker  *sseBroker
+	retry   int
+}
+
+func newSSEHandler(rt *Trigger, handler trigger.Handler, s *HandlerSettings) (*sseHandler, error) {
+
+	h := &sseHandler{rt: rt, handler: handler, retry: s.Retry}
+
+	if s.Channel != "" {
+		var err error
+		h.broker, err = newSSEBroker(s.Channel, rt.logger)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return h, nil
+}
+
+func (h *sseHandler) serve(w http.ResponseWriter, r *http.Request, out *Output) {
+
+	rt := h.rt
+	broker := h.broker
+
+	if _, ok := w.(http.Flusher); !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	// subscribe before invoking the action so no channel events are missed
+	var events chan interface{}
+	if broker != nil {
+		events = broker.subscribe()
+		defer broker.unsubscribe(events)
+	}
+
+	clearWriteDeadline(w)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	nextId := 0
+	send := func(value interface{}) error {
+		e, err := toEvent(value)
+		if err != nil {
+			return err
+		}
+		if e.Retry == 0 && nextId == 0 {
+			// send the retry hint with the first event
+			e.Retry = h.retry
+		}
+		nextId++
+		if e.ID == "" {
+			e.ID = strconv.Itoa(nextId)
+		}
+		return e.write(w)
+	}
+
+	results, err := h.handler.Handle(r.Context(), out)
+	if err != nil {
+		rt.logger.Debugf("Error handling request: %s", err.Error())
+		_ = send(&Event{Event: "error", Data: err.Error()})
+		return
+	}
+
+	reply := &Reply{}
+	err = reply.FromMap(results)
+	if err != nil {
+		rt.logger.Debugf("Error mapping results: %s", err.Error())
+		_ = send(&Event{Event: "error", Data: err.Error()})
+		return
+	}
+
+	if reply.Data != nil {
+		if ch := reflect.ValueOf(reply.Data); ch.Kind() == reflect.Chan {
+			// the action pushes events over the channel until it is closed
+			cases := []reflect.SelectCase{
+				{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(r.Context().Done())},
+				{Dir: reflect.SelectRecv, Chan: ch},
+			}
+			for {
+				chosen, value, ok := reflect.Select(cases)
+				if chosen == 0 {
+					return
+				}
+				if !ok {
+					break
+				}
+				if err := send(value.Interface()); err != nil {
+					rt.logger.Debugf("Error writing event: %s", err.Error())
+					return
+				}
+			}
+		} else if err := send(reply.Data); err != nil {
+			rt.logger.Debugf("Error writing event: %s", err.Error())
+			return
+		}
+	}
+
+	if events == nil {
+		return
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-rt.shutdown:
+			return
+		case value := <-events:
+			if err := send(value); err != nil {
+				rt.logger.Debugf("Error writing event: %s", err.Error())
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package rest
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvent_Write(t *testing.T) {
+
+	e, err := toEvent(map[string]interface{}{"id": "7", "event": "update", "data": map[string]interface{}{"a": 1}})
+	assert.Nil(t, err)
+
+	w := httptest.NewRecorder()
+	err = e.write(w)
+	assert.Nil(t, err)
+	assert.Equal(t, "id: 7\nevent: update\ndata: {\"a\":1}\n\n", w.Body.String())
+
+	e, err = toEvent("line1\nline2")
+	assert.Nil(t, err)
+	e.Retry = 3000
+
+	w = httptest.NewRecorder()
+	err = e.write(w)
+	assert.Nil(t, err)
+	assert.Equal(t, "retry: 3000\ndata: line1\ndata: line2\n\n", w.Body.String())
+}
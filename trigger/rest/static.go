@@ -0,0 +1,95 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/julienschmidt/httprouter"
+)
+
+const defaultIndexFile = "index.html"
+
+// newStaticHandler creates a handler that serves the files of a directory without invoking an action,
+// the handler path should end with a catch-all parameter (ex. /ui/*filepath)
+func newStaticHandler(s *HandlerSettings) (httprouter.Handle, error) {
+
+	info, err := os.Stat(s.StaticDir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("static dir '%s' is not a directory", s.StaticDir)
+	}
+
+	// the name of the catch-all parameter, if any
+	var param string
+	if idx := strings.LastIndex(s.Path, "/*"); idx >= 0 {
+		param = s.Path[idx+2:]
+	}
+
+	indexFile := s.IndexFile
+	if indexFile == "" {
+		indexFile = defaultIndexFile
+	}
+
+	dir := http.Dir(s.StaticDir)
+
+	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
+
+		name := "/"
+		if param != "" {
+			name = ps.ByName(param)
+		}
+		name = path.Clean("/" + name)
+
+		// don't serve hidden files such as .git or .env
+		for _, part := range strings.Split(name, "/") {
+			if strings.HasPrefix(part, ".") {
+				http.NotFound(w, r)
+				return
+			}
+		}
+
+		f, err := dir.Open(name)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		defer f.Close()
+
+		fi, err := f.Stat()
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		if fi.IsDir() {
+			// directories are never listed, only their index file is served
+			index, err := dir.Open(path.Join(name, indexFile))
+			if err != nil {
+				http.NotFound(w, r)
+				return
+			}
+			defer index.Close()
+
+			fi, err = index.Stat()
+			if err != nil || fi.IsDir() {
+				http.NotFound(w, r)
+				return
+			}
+			f = index
+		}
+
+		if s.CacheMaxAge > 0 {
+			w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(s.CacheMaxAge))
+		} else if s.CacheMaxAge < 0 {
+			w.Header().Set("Cache-Control", "no-cache")
+		}
+
+		http.ServeContent(w, r, fi.Name(), fi.ModTime(), f)
+	}, nil
+}
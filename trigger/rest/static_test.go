@@ -0,0 +1,39 @@
+package rest
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStaticHandler(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "static")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	_ = ioutil.WriteFile(filepath.Join(dir, "index.html"), []byte("index"), 0644)
+	_ = ioutil.WriteFile(filepath.Join(dir, ".env"), []byte("secret"), 0644)
+
+	h, err := newStaticHandler(&HandlerSettings{Path: "/ui/*filepath", StaticDir: dir, CacheMaxAge: 60})
+	assert.Nil(t, err)
+
+	serve := func(file string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h(w, httptest.NewRequest("GET", "/ui"+file, nil), httprouter.Params{{Key: "filepath", Value: file}})
+		return w
+	}
+
+	w := serve("/")
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "index", w.Body.String())
+	assert.Equal(t, "public, max-age=60", w.Header().Get("Cache-Control"))
+
+	assert.Equal(t, 404, serve("/.env").Code)
+	assert.Equal(t, 404, serve("/../../etc/passwd").Code)
+}
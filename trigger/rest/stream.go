@@ -0,0 +1,128 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"time"
+)
+
+const streamBufferSize = 32 * 1024
+
+// isStream checks if the reply data should be streamed to the client instead
+// of being buffered, which is the case for an io.Reader or a channel of chunks
+func isStream(data interface{}) bool {
+	if _, ok := data.(io.Reader); ok {
+		return true
+	}
+
+	v := reflect.ValueOf(data)
+	return v.Kind() == reflect.Chan && v.Type().ChanDir()&reflect.RecvDir != 0
+}
+
+// writeStream writes the streamed reply data using chunked transfer encoding,
+// flushing after every chunk so the client receives data as soon as it is produced
+func writeStream(w http.ResponseWriter, r *http.Request, code int, data interface{}) error {
+
+	flusher, _ := w.(http.Flusher)
+	clearWriteDeadline(w)
+
+	if reader, ok := data.(io.Reader); ok {
+		if closer, ok := reader.(io.Closer); ok {
+			defer closer.Close()
+		}
+
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", "application/octet-stream")
+		}
+		w.WriteHeader(code)
+
+		buf := make([]byte, streamBufferSize)
+		for {
+			n, err := reader.Read(buf)
+			if n > 0 {
+				if _, werr := w.Write(buf[:n]); werr != nil {
+					return werr
+				}
+				if flusher != nil {
+					flusher.Flush()
+				}
+			}
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	ch := reflect.ValueOf(data)
+	if ch.Kind() != reflect.Chan {
+		return fmt.Errorf("unsupported stream type: %T", data)
+	}
+
+	if w.Header().Get("Content-Type") == "" {
+		switch ch.Type().Elem().Kind() {
+		case reflect.String:
+			w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+		case reflect.Slice:
+			w.Header().Set("Content-Type", "application/octet-stream")
+		default:
+			// chunks that are neither bytes nor strings are written as newline delimited json
+			w.Header().Set("Content-Type", "application/x-ndjson")
+		}
+	}
+	w.WriteHeader(code)
+
+	cases := []reflect.SelectCase{
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(r.Context().Done())},
+		{Dir: reflect.SelectRecv, Chan: ch},
+	}
+
+	for {
+		chosen, chunk, ok := reflect.Select(cases)
+		if chosen == 0 {
+			// client went away
+			return r.Context().Err()
+		}
+		if !ok {
+			// channel closed, stream complete
+			return nil
+		}
+
+		b, err := chunkBytes(chunk.Interface())
+		if err != nil {
+			return err
+		}
+
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+// clearWriteDeadline lifts the server write timeout for long lived responses
+func clearWriteDeadline(w http.ResponseWriter) {
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+}
+
+func chunkBytes(chunk interface{}) ([]byte, error) {
+	switch t := chunk.(type) {
+	case []byte:
+		return t, nil
+	case string:
+		return []byte(t), nil
+	default:
+		b, err := json.Marshal(t)
+		if err != nil {
+			return nil, err
+		}
+		return append(b, '\n'), nil
+	}
+}
@@ -0,0 +1,42 @@
+package rest
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteStream_Reader(t *testing.T) {
+
+	data := strings.NewReader("hello world")
+	assert.True(t, isStream(data))
+
+	r := httptest.NewRequest("GET", "/test", nil)
+	w := httptest.NewRecorder()
+
+	err := writeStream(w, r, 200, data)
+	assert.Nil(t, err)
+	assert.Equal(t, "hello world", w.Body.String())
+	assert.Equal(t, "application/octet-stream", w.Header().Get("Content-Type"))
+}
+
+func TestWriteStream_Channel(t *testing.T) {
+
+	ch := make(chan interface{}, 2)
+	ch <- map[string]interface{}{"id": 1}
+	ch <- map[string]interface{}{"id": 2}
+	close(ch)
+
+	assert.True(t, isStream(ch))
+	assert.False(t, isStream("not a stream"))
+
+	r := httptest.NewRequest("GET", "/test", nil)
+	w := httptest.NewRecorder()
+
+	err := writeStream(w, r, 200, ch)
+	assert.Nil(t, err)
+	assert.Equal(t, "{\"id\":1}\n{\"id\":2}\n", w.Body.String())
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+}
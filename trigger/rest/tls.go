@@ -0,0 +1,77 @@
+package rest
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+)
+
+const (
+	ClientAuthNone             = "none"
+	ClientAuthRequest          = "request"
+	ClientAuthRequire          = "require"
+	ClientAuthVerifyIfGiven    = "verifyIfGiven"
+	ClientAuthRequireAndVerify = "requireAndVerify"
+)
+
+// toClientAuthType converts the client auth setting to the tls client auth type
+func toClientAuthType(clientAuth string) (tls.ClientAuthType, error) {
+	switch clientAuth {
+	case "", ClientAuthNone:
+		return tls.NoClientCert, nil
+	case ClientAuthRequest:
+		return tls.RequestClientCert, nil
+	case ClientAuthRequire:
+		return tls.RequireAnyClientCert, nil
+	case ClientAuthVerifyIfGiven:
+		return tls.VerifyClientCertIfGiven, nil
+	case ClientAuthRequireAndVerify:
+		return tls.RequireAndVerifyClientCert, nil
+	}
+
+	return tls.NoClientCert, fmt.Errorf("unsupported client auth '%s'", clientAuth)
+}
+
+// clientCertificate returns the details of the client certificate of the request, nil if none was presented
+func clientCertificate(r *http.Request) map[string]interface{} {
+
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return nil
+	}
+
+	cert := r.TLS.PeerCertificates[0]
+
+	var uris []string
+	for _, uri := range cert.URIs {
+		uris = append(uris, uri.String())
+	}
+
+	var ips []string
+	for _, ip := range cert.IPAddresses {
+		ips = append(ips, ip.String())
+	}
+
+	return map[string]interface{}{
+		"subject":        cert.Subject.String(),
+		"commonName":     cert.Subject.CommonName,
+		"issuer":         cert.Issuer.String(),
+		"serialNumber":   cert.SerialNumber.String(),
+		"dnsNames":       cert.DNSNames,
+		"emailAddresses": cert.EmailAddresses,
+		"ipAddresses":    ips,
+		"uris":           uris,
+		"notBefore":      cert.NotBefore,
+		"notAfter":       cert.NotAfter,
+		"verified":       len(r.TLS.VerifiedChains) > 0 && isLeaf(r.TLS.VerifiedChains, cert),
+	}
+}
+
+func isLeaf(chains [][]*x509.Certificate, cert *x509.Certificate) bool {
+	for _, chain := range chains {
+		if len(chain) > 0 && chain[0].Equal(cert) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,140 @@
+package rest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newCertificate creates a certificate signed by the parent, or self-signed if the parent is nil
+func newCertificate(t *testing.T, cn string, isCA bool, parent *tls.Certificate) tls.Certificate {
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: cn},
+		DNSNames:              []string{cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+
+	signer, signerKey := template, interface{}(key)
+	if parent != nil {
+		signer, signerKey = parent.Leaf, parent.PrivateKey
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	assert.Nil(t, err)
+	leaf, err := x509.ParseCertificate(der)
+	assert.Nil(t, err)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func TestToClientAuthType(t *testing.T) {
+
+	tests := map[string]tls.ClientAuthType{
+		"":                         tls.NoClientCert,
+		ClientAuthNone:             tls.NoClientCert,
+		ClientAuthRequest:          tls.RequestClientCert,
+		ClientAuthRequire:          tls.RequireAnyClientCert,
+		ClientAuthVerifyIfGiven:    tls.VerifyClientCertIfGiven,
+		ClientAuthRequireAndVerify: tls.RequireAndVerifyClientCert,
+	}
+	for setting, expected := range tests {
+		clientAuth, err := toClientAuthType(setting)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, clientAuth, setting)
+	}
+
+	_, err := toClientAuthType("always")
+	assert.NotNil(t, err)
+}
+
+func TestClientCertificate(t *testing.T) {
+
+	ca := newCertificate(t, "test-ca", true, nil)
+	trusted := newCertificate(t, "client.example.com", false, &ca)
+	untrusted := newCertificate(t, "intruder.example.com", false, nil)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Leaf)
+
+	// get calls the server with the client certificate, it returns the certificate seen by the server
+	get := func(clientAuth string, cert *tls.Certificate) (map[string]interface{}, error) {
+
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_ = json.NewEncoder(w).Encode(clientCertificate(r))
+		}))
+		authType, err := toClientAuthType(clientAuth)
+		assert.Nil(t, err)
+		server.TLS = &tls.Config{ClientAuth: authType, ClientCAs: roots}
+		server.StartTLS()
+		defer server.Close()
+
+		// the certificate is sent even if not issued by the client CA, so that the server verifies it
+		client := server.Client()
+		if cert != nil {
+			client.Transport.(*http.Transport).TLSClientConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+				return cert, nil
+			}
+		}
+
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+
+		var details map[string]interface{}
+		assert.Nil(t, json.NewDecoder(resp.Body).Decode(&details))
+		return details, nil
+	}
+
+	// the certificate is not requested
+	details, err := get(ClientAuthNone, &trusted)
+	assert.Nil(t, err)
+	assert.Nil(t, details)
+
+	// any certificate is required, but not verified
+	_, err = get(ClientAuthRequire, nil)
+	assert.NotNil(t, err)
+
+	details, err = get(ClientAuthRequire, &untrusted)
+	assert.Nil(t, err)
+	assert.Equal(t, "intruder.example.com", details["commonName"])
+	assert.Equal(t, false, details["verified"])
+
+	// a certificate signed by the client CA is required
+	_, err = get(ClientAuthRequireAndVerify, &untrusted)
+	assert.NotNil(t, err)
+
+	details, err = get(ClientAuthRequireAndVerify, &trusted)
+	assert.Nil(t, err)
+	assert.Equal(t, "client.example.com", details["commonName"])
+	assert.Equal(t, "CN=test-ca", details["issuer"])
+	assert.Equal(t, []interface{}{"client.example.com"}, details["dnsNames"])
+	assert.Equal(t, true, details["verified"])
+
+	// the certificate is verified only if presented
+	details, err = get(ClientAuthVerifyIfGiven, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, details)
+}
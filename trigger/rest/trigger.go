@@ -3,7 +3,10 @@ package rest
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"mime/multipart"
@@ -11,6 +14,7 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/qingcloudhx/contrib/trigger/rest/cors"
@@ -19,9 +23,7 @@ import (
 	"flogo/core/trigger"
 )
 
-const (
-	CorsPrefix = "REST_TRIGGER"
-)
+const defaultMultipartMemory = 32
 
 var triggerMd = trigger.NewMetadata(&Settings{}, &HandlerSettings{}, &Output{}, &Reply{})
 
@@ -45,6 +47,10 @@ func (*Factory) New(config *trigger.Config) (trigger.Trigger, error) {
 		return nil, err
 	}
 
+	if s.Port == 0 && s.Listeners == "" {
+		return nil, fmt.Errorf("either port or listeners must be specified")
+	}
+
 	return &Trigger{id: config.Id, settings: s}, nil
 }
 
@@ -54,19 +60,37 @@ type Trigger struct {
 	settings *Settings
 	id       string
 	logger   log.Logger
+	shutdown chan struct{}
+
+	metrics       *metrics
+	metricsServer *Server
 }
 
 func (t *Trigger) Initialize(ctx trigger.InitContext) error {
 
 	t.logger = ctx.Logger()
+	t.shutdown = make(chan struct{})
 
 	router := httprouter.New()
 
-	addr := ":" + strconv.Itoa(t.settings.Port)
+	// the port is optional when listeners are specified
+	var addr string
+	if t.settings.Port != 0 || t.settings.Listeners == "" {
+		addr = ":" + strconv.Itoa(t.settings.Port)
+	}
 
 	pathMap := make(map[string]string)
 
-	preflightHandler := &PreflightHandler{logger: t.logger, c: cors.New(CorsPrefix, t.logger)}
+	corsConfig := t.corsConfig()
+
+	var api *openAPI
+	if t.settings.OpenAPIPath != "" {
+		api = newOpenAPI(t.id)
+	}
+
+	if t.settings.Metrics {
+		t.metrics = newMetrics()
+	}
 
 	// Init handlers
 	for _, handler := range ctx.GetHandlers() {
@@ -78,17 +102,76 @@ func (t *Trigger) Initialize(ctx trigger.InitContext) error {
 		}
 
 		method := s.Method
-		path := s.Path
+		path := withBasePath(t.settings.BasePath, s.Path)
 
 		t.logger.Debugf("Registering handler [%s: %s]", method, path)
 
+		if s.StaticDir != "" {
+			staticHandler, err := newStaticHandler(s)
+			if err != nil {
+				return err
+			}
+			router.Handle(method, path, staticHandler)
+			if method == http.MethodGet {
+				router.Handle(http.MethodHead, path, staticHandler)
+			}
+			continue
+		}
+
+		if api != nil {
+			if err := api.addOperation(method, path, s); err != nil {
+				return fmt.Errorf("invalid schema for handler [%s: %s]: %s", method, path, err.Error())
+			}
+		}
+
+		c := cors.NewWithConfig(handlerCorsConfig(corsConfig, s), t.logger)
+
 		if _, ok := pathMap[path]; !ok {
 			pathMap[path] = path
+			preflightHandler := &PreflightHandler{logger: t.logger, c: c}
 			router.OPTIONS(path, preflightHandler.handleCorsPreflight) // for CORS
 		}
 
+		actionHandler, err := newActionHandler(t, strings.ToUpper(method), handler, s, c)
+		if err != nil {
+			return err
+		}
+
+		if t.metrics != nil {
+			actionHandler = t.metrics.instrument(strings.ToUpper(method), path, actionHandler)
+		}
+
 		//router.OPTIONS(path, handleCorsPreflight) // for CORS
-		router.Handle(method, path, newActionHandler(t, strings.ToUpper(method), handler))
+		router.Handle(method, path, actionHandler)
+	}
+
+	if api != nil {
+		router.GET(withBasePath(t.settings.BasePath, t.settings.OpenAPIPath), api.handler())
+	}
+
+	// like the handlers, the endpoints of the trigger are served under the base path
+	if t.settings.HealthPath != "" {
+		router.GET(withBasePath(t.settings.BasePath, t.settings.HealthPath), t.healthHandler)
+	}
+	if t.metrics != nil {
+		metricsPath := t.settings.MetricsPath
+		if metricsPath == "" {
+			metricsPath = defaultMetricsPath
+		}
+		if t.settings.MetricsPort > 0 {
+			mux := http.NewServeMux()
+			mux.HandleFunc(metricsPath, t.metrics.handler)
+			metricsServer, err := NewServer(":"+strconv.Itoa(t.settings.MetricsPort), mux)
+			if err != nil {
+				return err
+			}
+			t.metricsServer = metricsServer
+		} else {
+			router.Handler(http.MethodGet, withBasePath(t.settings.BasePath, metricsPath), http.HandlerFunc(t.metrics.handler))
+		}
+	}
+	if t.settings.ReadyPath != "" {
+		router.GET(withBasePath(t.settings.BasePath, t.settings.ReadyPath), t.readyHandler)
 	}
 
 	t.logger.Debugf("Configured on port %d", t.settings.Port)
@@ -97,9 +180,53 @@ func (t *Trigger) Initialize(ctx trigger.InitContext) error {
 
 	if t.settings.EnableTLS {
 		options = append(options, TLS(t.settings.CertFile, t.settings.KeyFile))
+	} else if t.settings.CertFile != "" {
+		options = append(options, Certificate(t.settings.CertFile, t.settings.KeyFile))
 	}
 
-	server, err := NewServer(addr, router, options...)
+	for _, listenerAddr := range splitList(t.settings.Listeners) {
+		l, err := parseListener(listenerAddr)
+		if err != nil {
+			return err
+		}
+		options = append(options, Listener(l.network, l.addr, l.tls))
+	}
+
+	clientAuth, err := toClientAuthType(t.settings.ClientAuth)
+	if err != nil {
+		return err
+	}
+	if clientAuth != tls.NoClientCert {
+		options = append(options, ClientAuth(t.settings.ClientCAFile, clientAuth))
+	}
+
+	if t.settings.DrainTimeout != "" {
+		d, err := time.ParseDuration(t.settings.DrainTimeout)
+		if err != nil {
+			return fmt.Errorf("unable to parse drain timeout: %s", err.Error())
+		}
+		options = append(options, DrainTimeout(d))
+	}
+
+	serverHandler := applyMiddlewares(router)
+
+	if t.settings.AccessLog {
+		accessLogger, err := newAccessLogger(t.logger, t.settings.AccessLogFormat, t.settings.AccessLogLevel)
+		if err != nil {
+			return err
+		}
+		serverHandler = accessLogger.wrap(serverHandler)
+	}
+
+	proxies, err := newTrustedProxies(t.settings.TrustedProxies)
+	if err != nil {
+		return err
+	}
+	if proxies != nil {
+		serverHandler = proxies.wrap(serverHandler)
+	}
+
+	server, err := NewServer(addr, serverHandler, options...)
 	if err != nil {
 		return err
 	}
@@ -110,14 +237,81 @@ func (t *Trigger) Initialize(ctx trigger.InitContext) error {
 }
 
 func (t *Trigger) Start() error {
+	// a trigger restarted after being stopped is no longer shutting down
+	select {
+	case <-t.shutdown:
+		t.shutdown = make(chan struct{})
+	default:
+	}
+
+	if t.metricsServer != nil {
+		if err := t.metricsServer.Start(); err != nil {
+			return err
+		}
+	}
+
 	return t.server.Start()
 }
 
 // Stop implements util.Managed.Stop
 func (t *Trigger) Stop() error {
+	// release the long lived connections so they don't hold up the drain
+	select {
+	case <-t.shutdown:
+	default:
+		close(t.shutdown)
+	}
+
+	if t.metricsServer != nil {
+		if err := t.metricsServer.Stop(); err != nil {
+			t.logger.Warnf("Error stopping metrics server: %s", err.Error())
+		}
+	}
+
 	return t.server.Stop()
 }
 
+// withBasePath prepends the base path to the handler path
+func withBasePath(basePath, path string) string {
+	basePath = strings.Trim(basePath, "/")
+	if basePath == "" {
+		return path
+	}
+
+	return "/" + basePath + "/" + strings.TrimPrefix(path, "/")
+}
+
+// corsConfig returns the CORS configuration of the trigger, unspecified settings use the defaults
+func (t *Trigger) corsConfig() cors.Config {
+	config := cors.DefaultConfig()
+
+	if t.settings.CorsAllowOrigins != "" {
+		config.AllowOrigins = cors.SplitList(t.settings.CorsAllowOrigins)
+	}
+	if t.settings.CorsAllowMethods != "" {
+		config.AllowMethods = cors.SplitList(t.settings.CorsAllowMethods)
+	}
+	if t.settings.CorsAllowHeaders != "" {
+		config.AllowHeaders = cors.SplitList(t.settings.CorsAllowHeaders)
+	}
+	config.ExposeHeaders = cors.SplitList(t.settings.CorsExposeHeaders)
+	config.AllowCredentials = t.settings.CorsAllowCredentials
+	config.MaxAge = t.settings.CorsMaxAge
+
+	return config
+}
+
+// handlerCorsConfig applies the CORS overrides of a handler to the trigger configuration
+func handlerCorsConfig(config cors.Config, s *HandlerSettings) cors.Config {
+	if s.CorsAllowOrigins != "" {
+		config.AllowOrigins = cors.SplitList(s.CorsAllowOrigins)
+	}
+	if s.CorsExposeHeaders != "" {
+		config.ExposeHeaders = cors.SplitList(s.CorsExposeHeaders)
+	}
+	return config
+}
+
 type PreflightHandler struct {
 	logger log.Logger
 	c      cors.Cors
@@ -135,18 +329,143 @@ type IDResponse struct {
 	ID string `json:"id"`
 }
 
-func newActionHandler(rt *Trigger, method string, handler trigger.Handler) httprouter.Handle {
+func newActionHandler(rt *Trigger, method string, handler trigger.Handler, s *HandlerSettings, c cors.Cors) (httprouter.Handle, error) {
+
+	var sse *sseHandler
+	if s.SSE {
+		var err error
+		sse, err = newSSEHandler(rt, handler, s)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var ws *wsHandler
+	if s.WebSocket {
+		ws = newWSHandler(rt, handler, s)
+	}
+
+	auth, err := newAuthenticator(s)
+	if err != nil {
+		return nil, err
+	}
+
+	replyFormat := s.ReplyFormat
+	if replyFormat == "" {
+		replyFormat = rt.settings.ReplyFormat
+	}
+	if err := validateFormat(replyFormat); err != nil {
+		return nil, err
+	}
+
+	errReplier, err := newErrorReplier(rt.settings.ErrorFormat, s)
+	if err != nil {
+		return nil, err
+	}
+
+	limiter := newRateLimiter(s)
+
+	var cache *responseCache
+	if sse == nil && ws == nil {
+		cache, err = newResponseCache(s)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	maxBodySize := rt.settings.MaxBodySize
+	if s.MaxBodySize != 0 {
+		maxBodySize = s.MaxBodySize
+	}
+
+	multipartMemory := int64(defaultMultipartMemory)
+	if s.MultipartMemory > 0 {
+		multipartMemory = s.MultipartMemory
+	} else if rt.settings.MultipartMemory > 0 {
+		multipartMemory = rt.settings.MultipartMemory
+	}
+
+	timeout := s.Timeout
+	if timeout == "" {
+		timeout = rt.settings.HandlerTimeout
+	}
+	var handlerTimeout time.Duration
+	if timeout != "" {
+		handlerTimeout, err = time.ParseDuration(timeout)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse handler timeout: %s", err.Error())
+		}
+	}
 
 	return func(w http.ResponseWriter, r *http.Request, ps httprouter.Params) {
 
 		rt.logger.Debugf("Received request for id '%s'", rt.id)
 
-		c := cors.New(CorsPrefix, rt.logger)
-		c.WriteCorsActualRequestHeaders(w)
+		c.WriteCorsHeaders(w, r)
+
+		if limiter != nil {
+			if ok, wait := limiter.allow(r); !ok {
+				rt.logger.Debugf("Rate limit exceeded for '%s'", limiter.clientKey(r))
+				limiter.reject(w, wait)
+				return
+			}
+		}
+
+		if err := decodeBody(r); err != nil {
+			rt.logger.Debugf("Error decoding body: %s", err.Error())
+			http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+			return
+		}
+
+		if rt.settings.Compression && sse == nil && ws == nil {
+			if cw := newCompressWriter(w, r); cw != nil {
+				defer cw.Close()
+				w = cw
+			}
+		}
+
+		if maxBodySize > 0 {
+			if r.ContentLength > maxBodySize {
+				http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, maxBodySize)
+		}
+
+		// the uploaded files are removed once the action completes, which can be after the request timed out
+		var uploads []string
+		defer func() {
+			removeUploads(uploads)
+		}()
 
 		out := &Output{}
 		out.Method = method
 
+		out.ClientCert = clientCertificate(r)
+		out.ClientIP = clientIP(r)
+		out.Scheme = requestScheme(r)
+		out.Host = r.Host
+
+		if auth != nil {
+			claims, err := auth.authenticate(r)
+			if err != nil {
+				rt.logger.Debugf("Authentication failed: %s", err.Error())
+				auth.challenge(w, err)
+				return
+			}
+			out.Claims = claims
+		}
+
+		if cache != nil && cacheable(r) {
+			key := cacheKey(r, callerKey(r, auth), negotiateFormat(r.Header.Get("Accept"), replyFormat))
+			if cache.serve(w, r, key) {
+				return
+			}
+			cr := cache.record(w, r, key)
+			defer cr.done()
+			w = cr
+		}
+
 		out.PathParams = make(map[string]string)
 		for _, param := range ps {
 			out.PathParams[param.Key] = param.Value
@@ -163,6 +482,12 @@ func newActionHandler(rt *Trigger, method string, handler trigger.Handler) httpr
 		for key, value := range queryValues {
 			out.QueryParams[key] = strings.Join(value, ",")
 		}
+		out.QueryParamsMulti = multiValues(queryValues)
+
+		if ws != nil {
+			ws.serve(w, r, out)
+			return
+		}
 
 		// Check the HTTP Header Content-Type
 		contentType := r.Header.Get("Content-Type")
@@ -172,7 +497,7 @@ func newActionHandler(rt *Trigger, method string, handler trigger.Handler) httpr
 			_,err :=buf.ReadFrom(r.Body)
 			if err != nil {
 				rt.logger.Debugf("Error reading body: %s", err.Error())
-				http.Error(w, err.Error(), http.StatusBadRequest)
+				writeBodyError(w, err)
 				return
 			}
 
@@ -180,20 +505,11 @@ func newActionHandler(rt *Trigger, method string, handler trigger.Handler) httpr
 			m, err := url.ParseQuery(s)
 			if err != nil {
 				rt.logger.Debugf("Error parsing query string: %s", err.Error())
-				http.Error(w, err.Error(), http.StatusBadRequest)
+				writeBodyError(w, err)
 				return
 			}
 
-			content := make(map[string]interface{}, 0)
-			for key, val := range m {
-				if len(val) == 1 {
-					content[key] = val[0]
-				} else {
-					content[key] = val[0]
-				}
-			}
-
-			out.Content = content
+			out.Content = formValues(m)
 		case "application/json":
 			var content interface{}
 			err := json.NewDecoder(r.Body).Decode(&content)
@@ -204,18 +520,40 @@ func newActionHandler(rt *Trigger, method string, handler trigger.Handler) httpr
 					//todo what should handler say if content is expected?
 				default:
 					rt.logger.Debugf("Error parsing json body: %s", err.Error())
-					http.Error(w, err.Error(), http.StatusBadRequest)
+					writeBodyError(w, err)
 					return
 				}
 			}
 			out.Content = content
 		default:
-			if strings.Contains(contentType, "multipart/form-data") {
+			if isXML(contentType) {
+				content, err := decodeXML(r.Body)
+				if err != nil {
+					rt.logger.Debugf("Error parsing xml body: %s", err.Error())
+					writeBodyError(w, err)
+					return
+				}
+				out.Content = content
+			} else if strings.Contains(contentType, "multipart/form-data") && s.StreamUploads {
+				fields, files, paths, err := streamMultipart(r, s.UploadDir)
+				uploads = paths
+				if err != nil {
+					rt.logger.Debugf("Error streaming multipart form: %s", err.Error())
+					writeBodyError(w, err)
+					return
+				}
+
+				out.Content = map[string]interface{}{
+					"body":   nil,
+					"fields": fields,
+					"files":  files,
+				}
+			} else if strings.Contains(contentType, "multipart/form-data") {
 				// need to still extract the body, only handling the multipart data for now...
 
-				if err := r.ParseMultipartForm(32); err != nil {
+				if err := r.ParseMultipartForm(multipartMemory); err != nil {
 					rt.logger.Debugf("Error parsing multipart form: %s", err.Error())
-					http.Error(w, err.Error(), http.StatusBadRequest)
+					writeBodyError(w, err)
 					return
 				}
 
@@ -237,15 +575,16 @@ func newActionHandler(rt *Trigger, method string, handler trigger.Handler) httpr
 
 				// The content output from the trigger
 				content := map[string]interface{}{
-					"body":  nil,
-					"files": files,
+					"body":   nil,
+					"fields": formValues(r.MultipartForm.Value),
+					"files":  files,
 				}
 				out.Content = content
 			} else {
 				b, err := ioutil.ReadAll(r.Body)
 				if err != nil {
 					rt.logger.Debugf("Error reading body: %s", err.Error())
-					http.Error(w, err.Error(), http.StatusBadRequest)
+					writeBodyError(w, err)
 					return
 				}
 
@@ -253,10 +592,35 @@ func newActionHandler(rt *Trigger, method string, handler trigger.Handler) httpr
 			}
 		}
 
-		results, err := handler.Handle(context.Background(), out)
+		if sse != nil {
+			sse.serve(w, r, out)
+			return
+		}
+
+		ctx := r.Context()
+		if handlerTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, handlerTimeout)
+			defer cancel()
+		}
+
+		paths := uploads
+		uploads = nil
+		results, err := handle(ctx, handler, out, func() {
+			removeUploads(paths)
+		})
 		if err != nil {
+			if err == context.DeadlineExceeded {
+				rt.logger.Debugf("Handler timed out after %s", handlerTimeout)
+				errReplier.write(w, http.StatusGatewayTimeout, "", http.StatusText(http.StatusGatewayTimeout), nil)
+				return
+			}
+			if err == context.Canceled {
+				rt.logger.Debugf("Request cancelled by client")
+				return
+			}
 			rt.logger.Debugf("Error handling request: %s", err.Error())
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			errReplier.reply(w, err)
 			return
 		}
 
@@ -264,7 +628,14 @@ func newActionHandler(rt *Trigger, method string, handler trigger.Handler) httpr
 		err = reply.FromMap(results)
 		if err != nil {
 			rt.logger.Debugf("Error mapping results: %s", err.Error())
-			http.Error(w, err.Error(), http.StatusBadRequest)
+			errReplier.write(w, http.StatusInternalServerError, "", err.Error(), nil)
+			return
+		}
+
+		err = writeReplyHeaders(w, reply)
+		if err != nil {
+			rt.logger.Debugf("Error writing reply headers: %s", err.Error())
+			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
@@ -274,16 +645,47 @@ func newActionHandler(rt *Trigger, method string, handler trigger.Handler) httpr
 				reply.Code = 200
 			}
 
+			if reply.ContentType != "" {
+				w.Header().Set("Content-Type", reply.ContentType)
+			}
+
+			if isStream(reply.Data) {
+				if err := writeStream(w, r, reply.Code, reply.Data); err != nil {
+					rt.logger.Debugf("Error streaming reply: %s", err.Error())
+				}
+				return
+			}
+
+			b, err := binaryData(reply)
+			if err != nil {
+				rt.logger.Debugf("Error decoding binary reply: %s", err.Error())
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			if b != nil {
+				if w.Header().Get("Content-Type") == "" {
+					w.Header().Set("Content-Type", http.DetectContentType(b))
+				}
+				w.Header().Set("Content-Length", strconv.Itoa(len(b)))
+				w.WriteHeader(reply.Code)
+				if _, err := w.Write(b); err != nil {
+					rt.logger.Debugf("Error writing body: %s", err.Error())
+				}
+				return
+			}
+
 			switch t := reply.Data.(type) {
 			case string:
-				var v interface{}
-				err := json.Unmarshal([]byte(t), &v)
-				if err != nil {
-					//Not a json
-					w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
-				} else {
-					//Json
-					w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+				if reply.ContentType == "" {
+					var v interface{}
+					err := json.Unmarshal([]byte(t), &v)
+					if err != nil {
+						//Not a json
+						w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+					} else {
+						//Json
+						w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+					}
 				}
 
 				w.WriteHeader(reply.Code)
@@ -293,10 +695,25 @@ func newActionHandler(rt *Trigger, method string, handler trigger.Handler) httpr
 				}
 				return
 			default:
-				w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+				// the Content-Type of the reply takes precedence over the Accept header of the request
+				format := mediaTypeFormat(strings.SplitN(reply.ContentType, ";", 2)[0])
+				if format == "" {
+					format = negotiateFormat(r.Header.Get("Accept"), replyFormat)
+					w.Header().Add("Vary", "Accept")
+				}
+
+				b, err := encodeReply(format, reply.Data)
+				if err != nil {
+					rt.logger.Debugf("Error encoding %s reply: %s", format, err.Error())
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				if reply.ContentType == "" {
+					w.Header().Set("Content-Type", formatContentTypes[format])
+				}
 				w.WriteHeader(reply.Code)
-				if err := json.NewEncoder(w).Encode(reply.Data); err != nil {
-					rt.logger.Debugf("Error encoding json reply: %s", err.Error())
+				if _, err := w.Write(b); err != nil {
+					rt.logger.Debugf("Error writing body: %s", err.Error())
 				}
 				return
 			}
@@ -307,9 +724,45 @@ func newActionHandler(rt *Trigger, method string, handler trigger.Handler) httpr
 		} else {
 			w.WriteHeader(http.StatusOK)
 		}
+	}, nil
+}
+
+
+// handle invokes the handler with the request context, returning the context error as soon as the
+// request is cancelled or times out, even if the action doesn't observe the context. The completed
+// function is called once the action is done, whether the request timed out or not.
+func handle(ctx context.Context, handler trigger.Handler, out *Output, completed func()) (map[string]interface{}, error) {
+
+	type result struct {
+		results map[string]interface{}
+		err     error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		results, err := handler.Handle(ctx, out)
+		completed()
+		done <- result{results: results, err: err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.results, res.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
 	}
 }
 
+// writeBodyError replies with a 413 if the body exceeded the maximum size or a 400 otherwise
+func writeBodyError(w http.ResponseWriter, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	http.Error(w, err.Error(), http.StatusBadRequest)
+}
 
 func getFileDetails(key string, header *multipart.FileHeader) (map[string]interface{}, error){
 	file, err := header.Open()
@@ -333,4 +786,34 @@ func getFileDetails(key string, header *multipart.FileHeader) (map[string]interf
 	}
 
 	return fileDetails, nil
-}
\ No newline at end of file
+}
+
+// formValues converts form values to a map, parameters with several values are arrays
+func formValues(values url.Values) map[string]interface{} {
+	content := make(map[string]interface{}, len(values))
+	for key, val := range values {
+		if len(val) == 1 {
+			content[key] = val[0]
+		} else {
+			content[key] = multiValue(val)
+		}
+	}
+	return content
+}
+
+// multiValues converts values to a map where every parameter is an array
+func multiValues(values url.Values) map[string]interface{} {
+	params := make(map[string]interface{}, len(values))
+	for key, val := range values {
+		params[key] = multiValue(val)
+	}
+	return params
+}
+
+func multiValue(values []string) []interface{} {
+	arr := make([]interface{}, len(values))
+	for i, v := range values {
+		arr[i] = v
+	}
+	return arr
+}
@@ -1,11 +1,19 @@
 package rest
 
 import (
+	"bytes"
 	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"io/ioutil"
+	"mime/multipart"
+	"net/url"
+	"os"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -14,11 +22,48 @@ import (
 	"flogo/core/api"
 	"flogo/core/engine"
 	"flogo/core/support"
+	"flogo/core/support/log"
 	"flogo/core/support/test"
 	"flogo/core/trigger"
+	"github.com/julienschmidt/httprouter"
+	"github.com/qingcloudhx/contrib/trigger/rest/cors"
 	"github.com/stretchr/testify/assert"
 )
 
+// handlerFunc is a trigger handler invoking a function with the output of the request
+type handlerFunc func(ctx context.Context, out *Output) (map[string]interface{}, error)
+
+func (f handlerFunc) Name() string {
+	return "test"
+}
+
+func (f handlerFunc) Settings() map[string]interface{} {
+	return nil
+}
+
+func (f handlerFunc) Handle(ctx context.Context, triggerData interface{}) (map[string]interface{}, error) {
+	return f(ctx, triggerData.(*Output))
+}
+
+// newTestHandle returns the action handler of the handler settings, invoking the function
+func newTestHandle(t *testing.T, settings *Settings, s *HandlerSettings, f handlerFunc) httprouter.Handle {
+
+	rt := &Trigger{settings: settings, logger: log.RootLogger(), shutdown: make(chan struct{})}
+	c := cors.NewWithConfig(cors.DefaultConfig(), rt.logger)
+
+	h, err := newActionHandler(rt, strings.ToUpper(s.Method), f, s, c)
+	assert.Nil(t, err)
+
+	return h
+}
+
+// serveTest serves a request with the action handler and returns the recorded response
+func serveTest(h httprouter.Handle, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h(w, r, nil)
+	return w
+}
+
 func TestTrigger_Register(t *testing.T) {
 
 	ref := support.GetRef(&Trigger{})
@@ -125,3 +170,117 @@ func RunActivities(ctx context.Context, inputs map[string]interface{}) (map[stri
 	result := &Reply{Code: 200, Data: "hello"}
 	return result.ToMap(), nil
 }
+
+func TestWithBasePath(t *testing.T) {
+	assert.Equal(t, "/test", withBasePath("", "/test"))
+	assert.Equal(t, "/api/v1/test", withBasePath("/api/v1", "/test"))
+	assert.Equal(t, "/api/v1/test/:id", withBasePath("api/v1/", "test/:id"))
+}
+
+func TestOpenAPI(t *testing.T) {
+	path, params := toOpenAPIPath("/pet/:id/files/*path")
+	assert.Equal(t, "/pet/{id}/files/{path}", path)
+	assert.Equal(t, []string{"id", "path"}, params)
+
+	api := newOpenAPI("test")
+	err := api.addOperation("GET", "/pet/:id", &HandlerSettings{ReplySchema: `{"type":"object"}`})
+	assert.Nil(t, err)
+	err = api.addOperation("POST", "/pet", &HandlerSettings{RequestSchema: `{invalid`})
+	assert.NotNil(t, err)
+
+	op := api.document()["paths"].(map[string]map[string]interface{})["/pet/{id}"]["get"].(map[string]interface{})
+	assert.Equal(t, "getPetId", op["operationId"])
+}
+
+func TestFormValues(t *testing.T) {
+
+	values, err := url.ParseQuery("id=1&id=2&name=a")
+	assert.Nil(t, err)
+
+	assert.Equal(t, map[string]interface{}{"id": []interface{}{"1", "2"}, "name": "a"}, formValues(values))
+	assert.Equal(t, map[string]interface{}{"id": []interface{}{"1", "2"}, "name": []interface{}{"a"}}, multiValues(values))
+}
+
+func TestActionHandler_MaxBodySize(t *testing.T) {
+
+	var received string
+	h := newTestHandle(t, &Settings{MaxBodySize: 10}, &HandlerSettings{Method: "POST", Path: "/test"},
+		func(ctx context.Context, out *Output) (map[string]interface{}, error) {
+			received = out.Content.(string)
+			return nil, nil
+		})
+
+	w := serveTest(h, httptest.NewRequest("POST", "/test", strings.NewReader("0123456789")))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0123456789", received)
+
+	w = serveTest(h, httptest.NewRequest("POST", "/test", strings.NewReader("0123456789a")))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	// without a content length, the limit is enforced while reading the body
+	r := httptest.NewRequest("POST", "/test", strings.NewReader("0123456789a"))
+	r.ContentLength = -1
+	w = serveTest(h, r)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	// the limit of the handler overrides the one of the trigger
+	h = newTestHandle(t, &Settings{MaxBodySize: 10}, &HandlerSettings{Method: "POST", Path: "/test", MaxBodySize: 20},
+		func(ctx context.Context, out *Output) (map[string]interface{}, error) {
+			return nil, nil
+		})
+	w = serveTest(h, httptest.NewRequest("POST", "/test", strings.NewReader("0123456789a")))
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+// newMultipartRequest returns a multipart request with a field and a file
+func newMultipartRequest(t *testing.T, path, content string) *http.Request {
+
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	assert.Nil(t, mw.WriteField("name", "report"))
+	fw, err := mw.CreateFormFile("file", "report.txt")
+	assert.Nil(t, err)
+	_, _ = fw.Write([]byte(content))
+	assert.Nil(t, mw.Close())
+
+	r := httptest.NewRequest("POST", path, body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	return r
+}
+
+func TestActionHandler_Timeout(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "rest")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	read := make(chan error, 1)
+	release := make(chan struct{})
+	h := newTestHandle(t, &Settings{}, &HandlerSettings{Method: "POST", Path: "/upload", Timeout: "50ms", StreamUploads: true, UploadDir: dir},
+		func(ctx context.Context, out *Output) (map[string]interface{}, error) {
+			<-release
+			// the action still reads the upload after the request timed out
+			files := out.Content.(map[string]interface{})["files"].([]map[string]interface{})
+			_, err := ioutil.ReadFile(files[0]["path"].(string))
+			read <- err
+			return nil, nil
+		})
+
+	w := serveTest(h, newMultipartRequest(t, "/upload", "data"))
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+
+	uploads, _ := ioutil.ReadDir(dir)
+	assert.Len(t, uploads, 1)
+
+	close(release)
+	assert.Nil(t, <-read)
+
+	// the upload is removed once the action completes
+	for i := 0; i < 100; i++ {
+		if uploads, _ = ioutil.ReadDir(dir); len(uploads) == 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Len(t, uploads, 0)
+}
@@ -0,0 +1,81 @@
+package rest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// streamMultipart streams the file parts of a multipart request to temporary files in the upload dir,
+// so large uploads aren't buffered in memory. It returns the form fields, the details of the stored files
+// and the paths of the files, which should be removed once the request is handled
+func streamMultipart(r *http.Request, uploadDir string) (map[string]interface{}, []map[string]interface{}, []string, error) {
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	fields := make(url.Values)
+	var files []map[string]interface{}
+	var paths []string
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, paths, err
+		}
+
+		if part.FileName() == "" {
+			b, err := ioutil.ReadAll(part)
+			_ = part.Close()
+			if err != nil {
+				return nil, nil, paths, err
+			}
+			fields.Add(part.FormName(), string(b))
+			continue
+		}
+
+		f, err := ioutil.TempFile(uploadDir, "upload-")
+		if err != nil {
+			_ = part.Close()
+			return nil, nil, paths, err
+		}
+		paths = append(paths, f.Name())
+
+		hash := sha256.New()
+		size, err := io.Copy(io.MultiWriter(f, hash), part)
+		_ = part.Close()
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return nil, nil, paths, err
+		}
+
+		files = append(files, map[string]interface{}{
+			"key":      part.FormName(),
+			"fileName": part.FileName(),
+			"fileType": part.Header.Get("Content-Type"),
+			"size":     size,
+			"path":     f.Name(),
+			"sha256":   hex.EncodeToString(hash.Sum(nil)),
+		})
+	}
+
+	return formValues(fields), files, paths, nil
+}
+
+// removeUploads removes the temporary files of a streamed multipart request
+func removeUploads(paths []string) {
+	for _, path := range paths {
+		_ = os.Remove(path)
+	}
+}
@@ -0,0 +1,97 @@
+package rest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreamMultipart(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "rest")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	fields, files, paths, err := streamMultipart(newMultipartRequest(t, "/upload", "hello"), dir)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "report"}, fields)
+	assert.Len(t, files, 1)
+	assert.Len(t, paths, 1)
+
+	sum := sha256.Sum256([]byte("hello"))
+	file := files[0]
+	assert.Equal(t, "file", file["key"])
+	assert.Equal(t, "report.txt", file["fileName"])
+	assert.Equal(t, int64(5), file["size"])
+	assert.Equal(t, hex.EncodeToString(sum[:]), file["sha256"])
+	assert.Equal(t, paths[0], file["path"])
+	assert.True(t, strings.HasPrefix(paths[0], dir))
+
+	b, err := ioutil.ReadFile(paths[0])
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", string(b))
+
+	removeUploads(paths)
+	_, err = os.Stat(paths[0])
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestStreamMultipart_Truncated(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "rest")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// the paths of the files written before the error are returned so they can be removed
+	r := newMultipartRequest(t, "/upload", "hello")
+	b, _ := ioutil.ReadAll(r.Body)
+	r.Body = ioutil.NopCloser(strings.NewReader(string(b[:len(b)-10])))
+
+	_, _, paths, err := streamMultipart(r, dir)
+	assert.NotNil(t, err)
+	assert.Len(t, paths, 1)
+
+	removeUploads(paths)
+	uploads, _ := ioutil.ReadDir(dir)
+	assert.Len(t, uploads, 0)
+}
+
+func TestActionHandler_StreamUploads(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "rest")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	var content string
+	h := newTestHandle(t, &Settings{MaxBodySize: 1024}, &HandlerSettings{Method: "POST", Path: "/upload", StreamUploads: true, UploadDir: dir},
+		func(ctx context.Context, out *Output) (map[string]interface{}, error) {
+			files := out.Content.(map[string]interface{})["files"].([]map[string]interface{})
+			b, err := ioutil.ReadFile(files[0]["path"].(string))
+			content = string(b)
+			return nil, err
+		})
+
+	w := serveTest(h, newMultipartRequest(t, "/upload", "hello"))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "hello", content)
+
+	// the temporary files are removed once the request is handled
+	uploads, _ := ioutil.ReadDir(dir)
+	assert.Len(t, uploads, 0)
+
+	// an upload exceeding the max body size is rejected and its partial file removed
+	r := newMultipartRequest(t, "/upload", strings.Repeat("a", 2048))
+	r.ContentLength = -1
+	w = serveTest(h, r)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+
+	uploads, _ = ioutil.ReadDir(dir)
+	assert.Len(t, uploads, 0)
+}
@@ -0,0 +1,158 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"flogo/core/trigger"
+	"github.com/gorilla/websocket"
+	"github.com/qingcloudhx/contrib/trigger/rest/cors"
+)
+
+const (
+	WSEventConnect    = "connect"
+	WSEventMessage    = "message"
+	WSEventDisconnect = "disconnect"
+)
+
+// wsHandler upgrades the connection to a WebSocket and invokes the action for each
+// inbound message, the data of the reply being sent back as a frame
+type wsHandler struct {
+	rt             *Trigger
+	handler        trigger.Handler
+	upgrader       *websocket.Upgrader
+	maxMessageSize int64
+}
+
+func newWSHandler(rt *Trigger, handler trigger.Handler, s *HandlerSettings) *wsHandler {
+
+	upgrader := &websocket.Upgrader{}
+
+	origins := s.CorsAllowOrigins
+	if origins == "" {
+		origins = rt.settings.CorsAllowOrigins
+	}
+	if allowed := cors.SplitList(origins); len(allowed) > 0 {
+		upgrader.CheckOrigin = func(r *http.Request) bool {
+			origin := r.Header.Get(cors.HeaderOrigin)
+			if origin == "" {
+				return true
+			}
+			for _, o := range allowed {
+				if o == "*" || strings.EqualFold(o, origin) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	// otherwise only same origin connections are accepted
+
+	return &wsHandler{rt: rt, handler: handler, upgrader: upgrader, maxMessageSize: s.WSMaxMessageSize}
+}
+
+func (h *wsHandler) serve(w http.ResponseWriter, r *http.Request, out *Output) {
+
+	rt := h.rt
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader already replied with an error
+		rt.logger.Debugf("Error upgrading to WebSocket: %s", err.Error())
+		return
+	}
+	defer conn.Close()
+
+	// the connection is long-lived, so the deadlines of the server don't apply
+	_ = conn.UnderlyingConn().SetDeadline(time.Time{})
+
+	if h.maxMessageSize > 0 {
+		conn.SetReadLimit(h.maxMessageSize)
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-rt.shutdown:
+			msg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+			_ = conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+			_ = conn.Close()
+		}
+	}()
+
+	out.ConnectionID = newRequestID()
+
+	invoke := func(event string, content interface{}) error {
+		eventOut := *out
+		eventOut.Event = event
+		eventOut.Content = content
+
+		results, err := h.handler.Handle(ctx, &eventOut)
+		if err != nil {
+			rt.logger.Debugf("Error handling WebSocket %s: %s", event, err.Error())
+			return nil
+		}
+
+		reply := &Reply{}
+		err = reply.FromMap(results)
+		if err != nil {
+			rt.logger.Debugf("Error mapping results: %s", err.Error())
+			return nil
+		}
+
+		if reply.Data == nil || event == WSEventDisconnect {
+			return nil
+		}
+
+		return writeFrame(conn, reply.Data)
+	}
+
+	if err := invoke(WSEventConnect, nil); err != nil {
+		rt.logger.Debugf("Error writing WebSocket frame: %s", err.Error())
+		return
+	}
+
+	for {
+		msgType, msg, err := conn.ReadMessage()
+		if err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				rt.logger.Debugf("WebSocket connection closed: %s", err.Error())
+			}
+			break
+		}
+
+		var content interface{}
+		if msgType == websocket.BinaryMessage {
+			content = msg
+		} else if err := json.Unmarshal(msg, &content); err != nil {
+			// not json, the text is passed as is
+			content = string(msg)
+		}
+
+		if err := invoke(WSEventMessage, content); err != nil {
+			rt.logger.Debugf("Error writing WebSocket frame: %s", err.Error())
+			break
+		}
+	}
+
+	_ = invoke(WSEventDisconnect, nil)
+}
+
+// writeFrame writes strings as text frames, bytes as binary frames and other values as json text frames
+func writeFrame(conn *websocket.Conn, data interface{}) error {
+
+	switch t := data.(type) {
+	case string:
+		return conn.WriteMessage(websocket.TextMessage, []byte(t))
+	case []byte:
+		return conn.WriteMessage(websocket.BinaryMessage, t)
+	}
+
+	return conn.WriteJSON(data)
+}
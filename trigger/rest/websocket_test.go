@@ -0,0 +1,64 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"flogo/core/support/log"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+type echoHandler struct {
+	events chan string
+}
+
+func (h *echoHandler) Name() string {
+	return "echo"
+}
+
+func (h *echoHandler) Settings() map[string]interface{} {
+	return nil
+}
+
+func (h *echoHandler) Handle(ctx context.Context, triggerData interface{}) (map[string]interface{}, error) {
+	out := triggerData.(*Output)
+	h.events <- out.Event
+	if out.Event != WSEventMessage {
+		return nil, nil
+	}
+	return map[string]interface{}{"data": out.Content}, nil
+}
+
+func TestWSHandler_Serve(t *testing.T) {
+
+	rt := &Trigger{settings: &Settings{}, logger: log.RootLogger(), shutdown: make(chan struct{})}
+	handler := &echoHandler{events: make(chan string, 3)}
+	ws := newWSHandler(rt, handler, &HandlerSettings{})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws.serve(w, r, &Output{})
+	}))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	assert.Nil(t, err)
+
+	err = conn.WriteMessage(websocket.TextMessage, []byte(`{"a":1}`))
+	assert.Nil(t, err)
+
+	msgType, msg, err := conn.ReadMessage()
+	assert.Nil(t, err)
+	assert.Equal(t, websocket.TextMessage, msgType)
+	assert.JSONEq(t, `{"a":1}`, string(msg))
+
+	_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	conn.Close()
+
+	assert.Equal(t, WSEventConnect, <-handler.events)
+	assert.Equal(t, WSEventMessage, <-handler.events)
+	assert.Equal(t, WSEventDisconnect, <-handler.events)
+}
@@ -0,0 +1,43 @@
+package rest
+
+import (
+	"io"
+	"mime"
+	"strings"
+
+	"github.com/clbanning/mxj"
+)
+
+const xmlRootTag = "response"
+
+// isXML checks if the media type is an XML media type (e.g., application/xml, text/xml, application/soap+xml)
+func isXML(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/xml" || mediaType == "text/xml" || strings.HasSuffix(mediaType, "+xml")
+}
+
+// decodeXML parses the XML body into a map keyed by the element names, attributes are prefixed with '-'
+// and the text of elements with attributes is stored under '#text'
+func decodeXML(r io.Reader) (map[string]interface{}, error) {
+	m, err := mxj.NewMapXmlReader(r)
+	if err != nil {
+		if err == io.EOF {
+			// empty body
+			return nil, nil
+		}
+		return nil, err
+	}
+	return m, nil
+}
+
+// encodeXML serializes the reply data to XML, a map with a single key uses it as root element
+// otherwise the data is wrapped in a 'response' element
+func encodeXML(data interface{}) ([]byte, error) {
+	if m, ok := data.(map[string]interface{}); ok && len(m) == 1 {
+		return mxj.Map(m).Xml()
+	}
+	return mxj.AnyXml(data, xmlRootTag)
+}
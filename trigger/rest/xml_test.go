@@ -0,0 +1,69 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsXML(t *testing.T) {
+	assert.True(t, isXML("application/xml"))
+	assert.True(t, isXML("text/xml; charset=UTF-8"))
+	assert.True(t, isXML("application/soap+xml"))
+	assert.False(t, isXML("application/json"))
+	assert.False(t, isXML(""))
+}
+
+func TestXML_RoundTrip(t *testing.T) {
+
+	m, err := decodeXML(strings.NewReader(`<pet id="12"><name>rex</name><tag>a</tag><tag>b</tag></pet>`))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"pet": map[string]interface{}{
+		"-id": "12", "name": "rex", "tag": []interface{}{"a", "b"},
+	}}, m)
+
+	b, err := encodeXML(m)
+	assert.Nil(t, err)
+
+	decoded, err := decodeXML(strings.NewReader(string(b)))
+	assert.Nil(t, err)
+	assert.Equal(t, m, decoded)
+
+	// data without a single root is wrapped in a response element
+	b, err = encodeXML(map[string]interface{}{"a": "1", "b": "2"})
+	assert.Nil(t, err)
+	decoded, err = decodeXML(strings.NewReader(string(b)))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"response": map[string]interface{}{"a": "1", "b": "2"}}, decoded)
+
+	m, err = decodeXML(strings.NewReader(""))
+	assert.Nil(t, err)
+	assert.Nil(t, m)
+}
+
+func TestActionHandler_XML(t *testing.T) {
+
+	h := newTestHandle(t, &Settings{ReplyFormat: FormatXML}, &HandlerSettings{Method: "POST", Path: "/pets"},
+		func(ctx context.Context, out *Output) (map[string]interface{}, error) {
+			return map[string]interface{}{"code": 201, "data": out.Content}, nil
+		})
+
+	r := httptest.NewRequest("POST", "/pets", strings.NewReader(`<pet><name>rex</name></pet>`))
+	r.Header.Set("Content-Type", "application/xml")
+	w := serveTest(h, r)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "application/xml; charset=UTF-8", w.Header().Get("Content-Type"))
+
+	reply, err := decodeXML(w.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"pet": map[string]interface{}{"name": "rex"}}, reply)
+
+	r = httptest.NewRequest("POST", "/pets", strings.NewReader(`<pet><name>rex</pet>`))
+	r.Header.Set("Content-Type", "text/xml")
+	w = serveTest(h, r)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
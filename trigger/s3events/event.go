@@ -0,0 +1,273 @@
+package s3events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+	"github.com/minio/minio-go/v7/pkg/notification"
+)
+
+const (
+	defaultMaxContentSize = 10 * 1024 * 1024
+	retryInterval         = 5 * time.Second
+)
+
+// the events of the listen source when not specified, MinIO requires events
+var defaultListenEvents = []string{"s3:ObjectCreated:*", "s3:ObjectRemoved:*"}
+
+// eventHandler handles the notifications of a source, filtered by its bucket, prefix, suffix and events
+type eventHandler struct {
+	handler  trigger.Handler
+	settings *HandlerSettings
+	logger   log.Logger
+	store    store
+	queue    queueClient
+
+	events []string
+	done   chan struct{}
+}
+
+func newEventHandler(handler trigger.Handler, s *HandlerSettings, logger log.Logger) (*eventHandler, error) {
+
+	switch s.Source {
+	case SourceSQS:
+		if s.QueueURL == "" {
+			return nil, errors.New("a queue url is required by the sqs source")
+		}
+	case SourceListen:
+		if s.Bucket == "" {
+			return nil, errors.New("a bucket is required by the listen source")
+		}
+	}
+
+	h := &eventHandler{handler: handler, settings: s, logger: logger}
+
+	for _, event := range splitList(s.Events) {
+		if _, err := path.Match(event, ""); err != nil {
+			return nil, fmt.Errorf("invalid event pattern '%s': %v", event, err)
+		}
+		h.events = append(h.events, event)
+	}
+	if s.MaxContentSize <= 0 {
+		s.MaxContentSize = defaultMaxContentSize
+	}
+
+	return h, nil
+}
+
+// run receives the notifications of the sqs and listen sources until the context is done
+func (h *eventHandler) run(ctx context.Context) {
+
+	defer close(h.done)
+
+	if h.settings.Source == SourceSQS {
+		h.poll(ctx)
+	} else {
+		h.listen(ctx)
+	}
+}
+
+// wait waits for the handler to stop, at most for the timeout
+func (h *eventHandler) wait(timeout time.Duration) {
+
+	if h.done == nil {
+		return
+	}
+
+	select {
+	case <-h.done:
+	case <-time.After(timeout):
+		h.logger.Warnf("Notifications of %s source still being handled", h.settings.Source)
+	}
+}
+
+// listen listens on the notifications of the bucket with the MinIO API, the notifications of a
+// failed action aren't received again
+func (h *eventHandler) listen(ctx context.Context) {
+
+	s := h.settings
+	events := h.events
+	if len(events) == 0 {
+		events = defaultListenEvents
+	}
+
+	h.logger.Infof("Listening on notifications of bucket '%s'", s.Bucket)
+
+	for ctx.Err() == nil {
+		for info := range h.store.listen(ctx, s.Bucket, s.Prefix, s.Suffix, events) {
+			if info.Err != nil {
+				if ctx.Err() == nil {
+					h.logger.Errorf("Error listening on notifications of bucket '%s': %v", s.Bucket, info.Err)
+				}
+				continue
+			}
+			for _, event := range info.Records {
+				raw, _ := json.Marshal(event)
+				out, err := toOutput(SourceListen, raw)
+				if err != nil {
+					h.logger.Errorf("Invalid notification of bucket '%s': %v", s.Bucket, err)
+					continue
+				}
+				err = h.handle(context.Background(), out)
+				if err != nil {
+					h.logger.Errorf("Error handling %s event of object '%s': %v", out.EventName, out.Key, err)
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+// handle invokes the action for a notification which matches the filters, with the content of
+// the object when it is fetched
+func (h *eventHandler) handle(ctx context.Context, out *Output) error {
+
+	if !h.matches(out) {
+		return nil
+	}
+
+	s := h.settings
+	o := *out
+
+	if s.FetchContent && strings.HasPrefix(o.EventName, "s3:ObjectCreated:") {
+		if o.Size > s.MaxContentSize {
+			h.logger.Warnf("Object '%s' too large to be fetched: %d bytes", o.Key, o.Size)
+		} else {
+			content, contentType, err := h.store.fetch(ctx, o.Bucket, o.Key, o.VersionID, s.MaxContentSize)
+			if err != nil {
+				return fmt.Errorf("unable to fetch object '%s' of bucket '%s': %v", o.Key, o.Bucket, err)
+			}
+			o.Content = string(content)
+			o.ContentType = contentType
+		}
+	}
+
+	_, err := h.handler.Handle(ctx, &o)
+	return err
+}
+
+func (h *eventHandler) matches(out *Output) bool {
+
+	s := h.settings
+
+	if s.Bucket != "" && out.Bucket != s.Bucket {
+		return false
+	}
+	if !strings.HasPrefix(out.Key, s.Prefix) || !strings.HasSuffix(out.Key, s.Suffix) {
+		return false
+	}
+	if len(h.events) == 0 {
+		return true
+	}
+	for _, event := range h.events {
+		if ok, _ := path.Match(event, out.EventName); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// notificationBody is the body of a notification: the records of S3 or MinIO, the S3 test event, or
+// an SNS message whose message is the notification
+type notificationBody struct {
+	Records []json.RawMessage `json:"Records"`
+	Event   string            `json:"Event"`
+	Type    string            `json:"Type"`
+	Message string            `json:"Message"`
+}
+
+// parseEvents parses the events of a notification, the S3 test event has none
+func parseEvents(source string, body []byte) ([]*Output, error) {
+
+	n := &notificationBody{}
+	err := json.Unmarshal(body, n)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.Records == nil {
+		switch {
+		case n.Type == "Notification" && n.Message != "":
+			return parseEvents(source, []byte(n.Message))
+		case n.Event == "s3:TestEvent":
+			return nil, nil
+		}
+		return nil, errors.New("not an S3 event notification")
+	}
+
+	outs := make([]*Output, 0, len(n.Records))
+	for _, raw := range n.Records {
+		out, err := toOutput(source, raw)
+		if err != nil {
+			return nil, err
+		}
+		outs = append(outs, out)
+	}
+
+	return outs, nil
+}
+
+// toOutput normalizes the record of an event, the names of the events of S3 are given the s3:
+// prefix of MinIO and the keys are decoded
+func toOutput(source string, raw []byte) (*Output, error) {
+
+	e := &notification.Event{}
+	err := json.Unmarshal(raw, e)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &Output{
+		Source:      source,
+		EventName:   e.EventName,
+		Region:      e.AwsRegion,
+		Bucket:      e.S3.Bucket.Name,
+		Key:         e.S3.Object.Key,
+		Size:        e.S3.Object.Size,
+		ETag:        e.S3.Object.ETag,
+		VersionID:   e.S3.Object.VersionID,
+		ContentType: e.S3.Object.ContentType,
+		PrincipalID: e.UserIdentity.PrincipalID,
+		SourceIP:    e.RequestParameters["sourceIPAddress"],
+	}
+
+	if !strings.HasPrefix(out.EventName, "s3:") {
+		out.EventName = "s3:" + out.EventName
+	}
+	if key, err := url.QueryUnescape(out.Key); err == nil {
+		out.Key = key
+	}
+	if t, err := time.Parse(time.RFC3339Nano, e.EventTime); err == nil {
+		out.EventTime = t.UnixNano() / int64(time.Millisecond)
+	}
+
+	err = json.Unmarshal(raw, &out.Record)
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+func splitList(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
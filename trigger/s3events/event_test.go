@@ -0,0 +1,123 @@
+package s3events
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"flogo/core/support/log"
+	"github.com/minio/minio-go/v7/pkg/notification"
+	"github.com/stretchr/testify/assert"
+)
+
+// an event of S3 sent to SQS
+const s3Record = `{"eventVersion": "2.1", "eventSource": "aws:s3", "awsRegion": "eu-west-1", "eventTime": "2019-05-15T15:20:41.123Z",
+  "eventName": "ObjectCreated:Put", "userIdentity": {"principalId": "AWS:AIDAJDPLRKLG7UEXAMPLE"},
+  "requestParameters": {"sourceIPAddress": "127.0.0.1"}, "responseElements": {"x-amz-request-id": "C3D13FE58DE4C810"},
+  "s3": {"s3SchemaVersion": "1.0", "configurationId": "uploads", "bucket": {"name": "uploads", "arn": "arn:aws:s3:::uploads"},
+    "object": {"key": "images/my+photo%281%29.jpg", "size": 1024, "eTag": "d41d8cd98f00b204e9800998ecf8427e", "versionId": "096fKKXTRTtl3on89fVO.nfljtsv6qko", "sequencer": "0055AED6DCD90281E5"}}}`
+
+func TestParseEvents(t *testing.T) {
+
+	outs, err := parseEvents(SourceSQS, []byte(`{"Records": [`+s3Record+`]}`))
+	assert.Nil(t, err)
+	assert.Len(t, outs, 1)
+
+	out := outs[0]
+	assert.Equal(t, SourceSQS, out.Source)
+	assert.Equal(t, "s3:ObjectCreated:Put", out.EventName)
+	assert.Equal(t, time.Date(2019, 5, 15, 15, 20, 41, 123000000, time.UTC).UnixNano()/int64(time.Millisecond), out.EventTime)
+	assert.Equal(t, "eu-west-1", out.Region)
+	assert.Equal(t, "uploads", out.Bucket)
+	assert.Equal(t, "images/my photo(1).jpg", out.Key)
+	assert.Equal(t, int64(1024), out.Size)
+	assert.Equal(t, "d41d8cd98f00b204e9800998ecf8427e", out.ETag)
+	assert.Equal(t, "096fKKXTRTtl3on89fVO.nfljtsv6qko", out.VersionID)
+	assert.Equal(t, "AWS:AIDAJDPLRKLG7UEXAMPLE", out.PrincipalID)
+	assert.Equal(t, "127.0.0.1", out.SourceIP)
+	assert.Equal(t, "aws:s3", out.Record["eventSource"])
+
+	// the notification published to an SNS topic the queue subscribes to
+	message, _ := json.Marshal(`{"Records": [` + s3Record + `]}`)
+	outs, err = parseEvents(SourceSQS, []byte(`{"Type": "Notification", "MessageId": "22b80b92", "Message": `+string(message)+`}`))
+	assert.Nil(t, err)
+	assert.Len(t, outs, 1)
+	assert.Equal(t, "images/my photo(1).jpg", outs[0].Key)
+
+	outs, err = parseEvents(SourceSQS, []byte(`{"Service": "Amazon S3", "Event": "s3:TestEvent", "Bucket": "uploads"}`))
+	assert.Nil(t, err)
+	assert.Len(t, outs, 0)
+
+	_, err = parseEvents(SourceSQS, []byte(`{"order": 42}`))
+	assert.NotNil(t, err)
+	_, err = parseEvents(SourceSQS, []byte(`order 42`))
+	assert.NotNil(t, err)
+}
+
+func TestEventHandler_Handle(t *testing.T) {
+
+	store := &testStore{objects: map[string]string{"uploads/notes/todo.txt": "buy milk"}}
+	var outputs []*Output
+	h := initHandler(t, map[string]interface{}{"source": "webhook", "prefix": "notes/", "fetchContent": true, "maxContentSize": 10}, collect(&outputs, nil))
+	h.store = store
+
+	err := h.handle(context.Background(), &Output{EventName: "s3:ObjectCreated:Put", Bucket: "uploads", Key: "notes/todo.txt", Size: 8})
+	assert.Nil(t, err)
+	err = h.handle(context.Background(), &Output{EventName: "s3:ObjectCreated:Put", Bucket: "uploads", Key: "images/a.jpg", Size: 8})
+	assert.Nil(t, err)
+	// too large to be fetched
+	err = h.handle(context.Background(), &Output{EventName: "s3:ObjectCreated:Put", Bucket: "uploads", Key: "notes/large.txt", Size: 11})
+	assert.Nil(t, err)
+	err = h.handle(context.Background(), &Output{EventName: "s3:ObjectRemoved:Delete", Bucket: "uploads", Key: "notes/old.txt"})
+	assert.Nil(t, err)
+	err = h.handle(context.Background(), &Output{EventName: "s3:ObjectCreated:Put", Bucket: "uploads", Key: "notes/gone.txt", Size: 8})
+	assert.NotNil(t, err)
+
+	assert.Equal(t, []string{"notes/todo.txt", "notes/large.txt", "notes/old.txt"}, keys(outputs))
+	assert.Equal(t, "buy milk", outputs[0].Content)
+	assert.Equal(t, "", outputs[1].Content)
+}
+
+func TestEventHandler_Matches(t *testing.T) {
+
+	h, err := newEventHandler(nil, &HandlerSettings{Source: SourceWebhook, Bucket: "uploads", Suffix: ".jpg", Events: "s3:ObjectCreated:*, s3:ObjectRemoved:Delete"}, log.RootLogger())
+	assert.Nil(t, err)
+
+	assert.True(t, h.matches(&Output{EventName: "s3:ObjectCreated:Put", Bucket: "uploads", Key: "a.jpg"}))
+	assert.True(t, h.matches(&Output{EventName: "s3:ObjectCreated:CompleteMultipartUpload", Bucket: "uploads", Key: "b/c.jpg"}))
+	assert.True(t, h.matches(&Output{EventName: "s3:ObjectRemoved:Delete", Bucket: "uploads", Key: "a.jpg"}))
+	assert.False(t, h.matches(&Output{EventName: "s3:ObjectRemoved:DeleteMarkerCreated", Bucket: "uploads", Key: "a.jpg"}))
+	assert.False(t, h.matches(&Output{EventName: "s3:ObjectCreated:Put", Bucket: "backups", Key: "a.jpg"}))
+	assert.False(t, h.matches(&Output{EventName: "s3:ObjectCreated:Put", Bucket: "uploads", Key: "a.png"}))
+}
+
+func TestEventHandler_Listen(t *testing.T) {
+
+	store := &testStore{notifications: make(chan notification.Info)}
+	var outputs []*Output
+	h := initHandler(t, map[string]interface{}{"source": "listen", "bucket": "uploads"}, collect(&outputs, map[string]bool{"b.txt": true}))
+	h.store = store
+
+	ctx, cancel := context.WithCancel(context.Background())
+	h.done = make(chan struct{})
+	go h.run(ctx)
+
+	event := func(key string) notification.Event {
+		e := notification.Event{EventName: "s3:ObjectCreated:Put", EventTime: "2019-05-15T15:20:41Z"}
+		e.S3.Bucket.Name = "uploads"
+		e.S3.Object.Key = key
+		return e
+	}
+
+	store.notifications <- notification.Info{Records: []notification.Event{event("a.txt"), event("b.txt")}}
+	store.notifications <- notification.Info{Err: context.DeadlineExceeded}
+	store.notifications <- notification.Info{Records: []notification.Event{event("c.txt")}}
+
+	cancel()
+	h.wait(time.Second)
+
+	assert.Equal(t, []string{"a.txt", "c.txt"}, keys(outputs))
+	assert.Equal(t, SourceListen, outputs[0].Source)
+	assert.Equal(t, "uploads", outputs[0].Record["s3"].(map[string]interface{})["bucket"].(map[string]interface{})["name"])
+}
@@ -0,0 +1,11 @@
+module github.com/qingcloudhx/contrib/trigger/s3events
+
+require (
+	flogo/core v0.9.0
+	github.com/aws/aws-sdk-go-v2 v1.32.2
+	github.com/aws/aws-sdk-go-v2/config v1.27.43
+	github.com/aws/aws-sdk-go-v2/credentials v1.17.41
+	github.com/aws/aws-sdk-go-v2/service/sqs v1.34.8
+	github.com/minio/minio-go/v7 v7.0.66
+	github.com/stretchr/testify v1.3.0
+)
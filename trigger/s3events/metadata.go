@@ -0,0 +1,132 @@
+package s3events
+
+import (
+	"flogo/core/data/coerce"
+)
+
+const (
+	SourceSQS     = "sqs"
+	SourceWebhook = "webhook"
+	SourceListen  = "listen"
+)
+
+type Settings struct {
+	Endpoint     string `md:"endpoint"`     // The url of the S3 compatible service the objects are fetched from and the MinIO notifications listened on (ex. http://localhost:9000), defaults to AWS S3
+	Region       string `md:"region"`       // The region of the buckets and queues, defaults to the region of the environment for the queues
+	AccessKey    string `md:"accessKey"`    // The access key, the credentials of the environment, shared files or instance role are used if not specified
+	SecretKey    string `md:"secretKey"`    // The secret key
+	SessionToken string `md:"sessionToken"` // The session token of temporary credentials
+	Port         int    `md:"port"`         // The port the webhook notifications are received on, required by the webhook handlers
+	Path         string `md:"path"`         // The path the webhook notifications are received on, defaults to /events
+	AuthToken    string `md:"authToken"`    // The token the webhook notifications must have in their Authorization header, as is or as a bearer token
+}
+
+type HandlerSettings struct {
+	Source         string `md:"source,required,allowed(sqs,webhook,listen)"` // The source of the notifications: an SQS queue, webhook requests or the MinIO listen API
+	QueueURL       string `md:"queueUrl"`                                    // The url of the SQS queue the notifications are sent to, required by the sqs source
+	Bucket         string `md:"bucket"`                                      // The bucket of the notifications, required by the listen source, defaults to all the buckets otherwise
+	Prefix         string `md:"prefix"`                                      // The prefix of the keys of the objects
+	Suffix         string `md:"suffix"`                                      // The suffix of the keys of the objects (ex. .csv)
+	Events         string `md:"events"`                                      // The comma separated events to handle, patterns are supported (ex. s3:ObjectCreated:*), defaults to all the events, or the created and removed objects for the listen source
+	FetchContent   bool   `md:"fetchContent"`                                // Fetch the content of the created objects
+	MaxContentSize int64  `md:"maxContentSize"`                              // The maximum size in bytes of the content fetched, the larger objects aren't fetched, defaults to 10MB
+}
+
+type Output struct {
+	Source      string                 `md:"source"`      // The source of the notification: sqs, webhook or listen
+	EventName   string                 `md:"eventName"`   // The name of the event, with the s3: prefix (ex. s3:ObjectCreated:Put)
+	EventTime   int64                  `md:"eventTime"`   // The time of the event, in milliseconds since epoch
+	Region      string                 `md:"region"`      // The region of the bucket
+	Bucket      string                 `md:"bucket"`      // The name of the bucket
+	Key         string                 `md:"key"`         // The key of the object, decoded
+	Size        int64                  `md:"size"`        // The size of the object in bytes
+	ETag        string                 `md:"eTag"`        // The entity tag of the object
+	VersionID   string                 `md:"versionId"`   // The version of the object, when the bucket is versioned
+	ContentType string                 `md:"contentType"` // The content type of the object, when given by the notification or fetched
+	PrincipalID string                 `md:"principalId"` // The id of the user who caused the event
+	SourceIP    string                 `md:"sourceIp"`    // The IP address the request of the event came from
+	Content     string                 `md:"content"`     // The content of the object, when fetched
+	Record      map[string]interface{} `md:"record"`      // The record of the event, as sent by the service
+}
+
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"source":      o.Source,
+		"eventName":   o.EventName,
+		"eventTime":   o.EventTime,
+		"region":      o.Region,
+		"bucket":      o.Bucket,
+		"key":         o.Key,
+		"size":        o.Size,
+		"eTag":        o.ETag,
+		"versionId":   o.VersionID,
+		"contentType": o.ContentType,
+		"principalId": o.PrincipalID,
+		"sourceIp":    o.SourceIP,
+		"content":     o.Content,
+		"record":      o.Record,
+	}
+}
+
+func (o *Output) FromMap(values map[string]interface{}) error {
+
+	var err error
+	o.Source, err = coerce.ToString(values["source"])
+	if err != nil {
+		return err
+	}
+	o.EventName, err = coerce.ToString(values["eventName"])
+	if err != nil {
+		return err
+	}
+	o.EventTime, err = coerce.ToInt64(values["eventTime"])
+	if err != nil {
+		return err
+	}
+	o.Region, err = coerce.ToString(values["region"])
+	if err != nil {
+		return err
+	}
+	o.Bucket, err = coerce.ToString(values["bucket"])
+	if err != nil {
+		return err
+	}
+	o.Key, err = coerce.ToString(values["key"])
+	if err != nil {
+		return err
+	}
+	o.Size, err = coerce.ToInt64(values["size"])
+	if err != nil {
+		return err
+	}
+	o.ETag, err = coerce.ToString(values["eTag"])
+	if err != nil {
+		return err
+	}
+	o.VersionID, err = coerce.ToString(values["versionId"])
+	if err != nil {
+		return err
+	}
+	o.ContentType, err = coerce.ToString(values["contentType"])
+	if err != nil {
+		return err
+	}
+	o.PrincipalID, err = coerce.ToString(values["principalId"])
+	if err != nil {
+		return err
+	}
+	o.SourceIP, err = coerce.ToString(values["sourceIp"])
+	if err != nil {
+		return err
+	}
+	o.Content, err = coerce.ToString(values["content"])
+	if err != nil {
+		return err
+	}
+	o.Record, err = coerce.ToObject(values["record"])
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
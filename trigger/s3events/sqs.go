@@ -0,0 +1,76 @@
+package s3events
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// queueClient is the part of the SQS client used to receive the notifications
+type queueClient interface {
+	ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error)
+	DeleteMessage(ctx context.Context, params *sqs.DeleteMessageInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error)
+}
+
+// poll receives the notifications of the queue, a message is deleted once the actions of its events
+// succeeded, otherwise it is received again once its visibility timeout expires
+func (h *eventHandler) poll(ctx context.Context) {
+
+	s := h.settings
+	h.logger.Infof("Polling queue '%s'", s.QueueURL)
+
+	input := &sqs.ReceiveMessageInput{
+		QueueUrl:            aws.String(s.QueueURL),
+		MaxNumberOfMessages: 10,
+		WaitTimeSeconds:     20,
+	}
+
+	for ctx.Err() == nil {
+		output, err := h.queue.ReceiveMessage(ctx, input)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			h.logger.Errorf("Error receiving messages from queue '%s': %v", s.QueueURL, err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(retryInterval):
+			}
+			continue
+		}
+
+		for _, msg := range output.Messages {
+			if !h.handleMessage(msg) {
+				continue
+			}
+			_, err = h.queue.DeleteMessage(context.Background(), &sqs.DeleteMessageInput{QueueUrl: aws.String(s.QueueURL), ReceiptHandle: msg.ReceiptHandle})
+			if err != nil {
+				h.logger.Errorf("Error deleting message '%s' of queue '%s': %v", aws.ToString(msg.MessageId), s.QueueURL, err)
+			}
+		}
+	}
+}
+
+// handleMessage handles the events of a message, and returns true when the message can be deleted.
+// The invalid messages are kept, to be moved to the dead letter queue of the queue
+func (h *eventHandler) handleMessage(msg types.Message) bool {
+
+	outs, err := parseEvents(SourceSQS, []byte(aws.ToString(msg.Body)))
+	if err != nil {
+		h.logger.Errorf("Invalid notification '%s' of queue '%s': %v", aws.ToString(msg.MessageId), h.settings.QueueURL, err)
+		return false
+	}
+
+	for _, out := range outs {
+		err = h.handle(context.Background(), out)
+		if err != nil {
+			h.logger.Errorf("Error handling %s event of object '%s': %v", out.EventName, out.Key, err)
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,84 @@
+package s3events
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// testQueue returns its batches of messages and records the deleted messages
+type testQueue struct {
+	mu      sync.Mutex
+	batches [][]types.Message
+	deleted []string
+}
+
+func (q *testQueue) ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.batches) == 0 {
+		q.mu.Unlock()
+		<-ctx.Done()
+		q.mu.Lock()
+		return nil, ctx.Err()
+	}
+
+	batch := q.batches[0]
+	q.batches = q.batches[1:]
+	return &sqs.ReceiveMessageOutput{Messages: batch}, nil
+}
+
+func (q *testQueue) DeleteMessage(ctx context.Context, params *sqs.DeleteMessageInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.deleted = append(q.deleted, aws.ToString(params.ReceiptHandle))
+	return &sqs.DeleteMessageOutput{}, nil
+}
+
+func message(id, body string) types.Message {
+	return types.Message{MessageId: aws.String(id), ReceiptHandle: aws.String("rh-" + id), Body: aws.String(body)}
+}
+
+func TestEventHandler_Poll(t *testing.T) {
+
+	queue := &testQueue{batches: [][]types.Message{
+		{
+			message("1", `{"Records": [`+record("ObjectCreated:Put", "uploads", "a.txt", 1)+`,`+record("ObjectCreated:Put", "uploads", "b.txt", 1)+`]}`),
+			message("2", `{"Service": "Amazon S3", "Event": "s3:TestEvent"}`),
+		},
+		{
+			message("3", `not a notification`),
+			message("4", `{"Records": [`+record("ObjectCreated:Put", "uploads", "c.txt", 1)+`]}`),
+			message("5", `{"Records": [`+record("ObjectCreated:Put", "uploads", "d.txt", 1)+`]}`),
+		},
+	}}
+
+	var outputs []*Output
+	h := initHandler(t, map[string]interface{}{"source": "sqs", "queueUrl": "https://sqs.eu-west-1.amazonaws.com/123456789012/uploads"}, collect(&outputs, map[string]bool{"c.txt": true}))
+	h.queue = queue
+
+	ctx, cancel := context.WithCancel(context.Background())
+	h.done = make(chan struct{})
+	go h.run(ctx)
+
+	assert.Eventually(t, func() bool {
+		queue.mu.Lock()
+		defer queue.mu.Unlock()
+		return len(queue.batches) == 0 && len(queue.deleted) == 3
+	}, time.Second, 10*time.Millisecond)
+
+	cancel()
+	h.wait(time.Second)
+
+	// the invalid message and the message whose action failed are kept
+	assert.Equal(t, []string{"rh-1", "rh-2", "rh-5"}, queue.deleted)
+	assert.Equal(t, []string{"a.txt", "b.txt", "d.txt"}, keys(outputs))
+	assert.Equal(t, "s3:ObjectCreated:Put", outputs[0].EventName)
+}
@@ -0,0 +1,85 @@
+package s3events
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/minio/minio-go/v7/pkg/notification"
+)
+
+const defaultEndpoint = "https://s3.amazonaws.com"
+
+// store is the S3 compatible service the notifications are listened on and the objects fetched from
+type store interface {
+	listen(ctx context.Context, bucket, prefix, suffix string, events []string) <-chan notification.Info
+	fetch(ctx context.Context, bucket, key, versionID string, maxSize int64) ([]byte, string, error)
+}
+
+type minioStore struct {
+	client *minio.Client
+}
+
+func newStore(s *Settings) (*minioStore, error) {
+
+	endpoint := s.Endpoint
+	if endpoint == "" {
+		endpoint = defaultEndpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("invalid endpoint '%s'", endpoint)
+	}
+
+	var creds *credentials.Credentials
+	if s.AccessKey != "" {
+		creds = credentials.NewStaticV4(s.AccessKey, s.SecretKey, s.SessionToken)
+	} else {
+		creds = credentials.NewChainCredentials([]credentials.Provider{
+			&credentials.EnvAWS{},
+			&credentials.EnvMinio{},
+			&credentials.FileAWSCredentials{},
+			&credentials.IAM{},
+		})
+	}
+
+	client, err := minio.New(u.Host, &minio.Options{Creds: creds, Secure: u.Scheme == "https", Region: s.Region})
+	if err != nil {
+		return nil, err
+	}
+
+	return &minioStore{client: client}, nil
+}
+
+func (m *minioStore) listen(ctx context.Context, bucket, prefix, suffix string, events []string) <-chan notification.Info {
+	return m.client.ListenBucketNotification(ctx, bucket, prefix, suffix, events)
+}
+
+// fetch returns the content and content type of an object, at most of the max size
+func (m *minioStore) fetch(ctx context.Context, bucket, key, versionID string, maxSize int64) ([]byte, string, error) {
+
+	obj, err := m.client.GetObject(ctx, bucket, key, minio.GetObjectOptions{VersionID: versionID})
+	if err != nil {
+		return nil, "", err
+	}
+	defer obj.Close()
+
+	info, err := obj.Stat()
+	if err != nil {
+		return nil, "", err
+	}
+	if info.Size > maxSize {
+		return nil, "", fmt.Errorf("object too large: %d bytes", info.Size)
+	}
+
+	content, err := ioutil.ReadAll(io.LimitReader(obj, maxSize))
+	if err != nil {
+		return nil, "", err
+	}
+
+	return content, info.ContentType, nil
+}
@@ -0,0 +1,252 @@
+package s3events
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"flogo/core/data/metadata"
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+)
+
+const (
+	defaultPath = "/events"
+	maxBodySize = 10 * 1024 * 1024
+)
+
+var triggerMd = trigger.NewMetadata(&Settings{}, &HandlerSettings{}, &Output{})
+
+func init() {
+	_ = trigger.Register(&Trigger{}, &Factory{})
+}
+
+type Factory struct {
+}
+
+// Metadata implements trigger.Factory.Metadata
+func (*Factory) Metadata() *trigger.Metadata {
+	return triggerMd
+}
+
+// New implements trigger.Factory.New
+func (*Factory) New(config *trigger.Config) (trigger.Trigger, error) {
+
+	s := &Settings{}
+	err := metadata.MapToStruct(config.Settings, s, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Trigger{settings: s}, nil
+}
+
+// Trigger handles the bucket notifications of S3 and MinIO, received from SQS queues, webhook
+// requests or the MinIO listen API
+type Trigger struct {
+	settings *Settings
+	logger   log.Logger
+	handlers []*eventHandler
+	webhooks []*eventHandler
+
+	cancel   context.CancelFunc
+	mux      *http.ServeMux
+	server   *http.Server
+	listener net.Listener
+}
+
+// Initialize implements trigger.Init.Initialize
+func (t *Trigger) Initialize(ctx trigger.InitContext) error {
+
+	t.logger = ctx.Logger()
+
+	objects, err := newStore(t.settings)
+	if err != nil {
+		return err
+	}
+
+	var queue queueClient
+	for _, handler := range ctx.GetHandlers() {
+
+		s := &HandlerSettings{}
+		err := metadata.MapToStruct(handler.Settings(), s, true)
+		if err != nil {
+			return err
+		}
+
+		h, err := newEventHandler(handler, s, t.logger)
+		if err != nil {
+			return err
+		}
+		h.store = objects
+
+		switch s.Source {
+		case SourceWebhook:
+			t.webhooks = append(t.webhooks, h)
+			continue
+		case SourceSQS:
+			if queue == nil {
+				queue, err = t.newQueueClient()
+				if err != nil {
+					return err
+				}
+			}
+			h.queue = queue
+		}
+		t.handlers = append(t.handlers, h)
+	}
+
+	if len(t.webhooks) > 0 {
+		if t.settings.Port == 0 {
+			return errors.New("a port is required by the webhook handlers")
+		}
+
+		webhookPath := t.settings.Path
+		if webhookPath == "" {
+			webhookPath = defaultPath
+		}
+
+		t.mux = http.NewServeMux()
+		t.mux.HandleFunc(webhookPath, t.serveHTTP)
+	}
+
+	return nil
+}
+
+// newQueueClient returns the SQS client, with the credentials of the settings or, when not
+// specified, the ones of the default chain: environment, shared files, web identity and instance roles
+func (t *Trigger) newQueueClient() (queueClient, error) {
+
+	var options []func(*config.LoadOptions) error
+	if t.settings.Region != "" {
+		options = append(options, config.WithRegion(t.settings.Region))
+	}
+	if t.settings.AccessKey != "" {
+		provider := credentials.NewStaticCredentialsProvider(t.settings.AccessKey, t.settings.SecretKey, t.settings.SessionToken)
+		options = append(options, config.WithCredentialsProvider(provider))
+	}
+
+	awsConfig, err := config.LoadDefaultConfig(context.Background(), options...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load AWS configuration: %v", err)
+	}
+
+	return sqs.NewFromConfig(awsConfig), nil
+}
+
+// Start implements util.Managed.Start
+func (t *Trigger) Start() error {
+
+	if t.mux != nil {
+		ln, err := net.Listen("tcp", ":"+strconv.Itoa(t.settings.Port))
+		if err != nil {
+			return err
+		}
+		t.listener = ln
+
+		// a shut down server can't serve again, each start uses a new one
+		server := &http.Server{Handler: t.mux, ReadHeaderTimeout: 30 * time.Second}
+		t.server = server
+
+		t.logger.Infof("Listening on port %d", t.settings.Port)
+
+		go func() {
+			err := server.Serve(ln)
+			if err != nil && err != http.ErrServerClosed {
+				t.logger.Errorf("Webhook server stopped: %v", err)
+			}
+		}()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+
+	for _, h := range t.handlers {
+		h.done = make(chan struct{})
+		go h.run(ctx)
+	}
+
+	return nil
+}
+
+// Stop implements util.Managed.Stop
+func (t *Trigger) Stop() error {
+
+	if t.cancel != nil {
+		t.cancel()
+		t.cancel = nil
+	}
+
+	for _, h := range t.handlers {
+		h.wait(30 * time.Second)
+	}
+
+	if t.listener == nil {
+		return nil
+	}
+	t.listener = nil
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return t.server.Shutdown(ctx)
+}
+
+// serveHTTP handles the notifications of a webhook request, the request fails when an action
+// fails so that it is sent again
+func (t *Trigger) serveHTTP(w http.ResponseWriter, r *http.Request) {
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	if token := t.settings.AuthToken; token != "" {
+		auth := r.Header.Get("Authorization")
+		if subtle.ConstantTimeCompare([]byte(auth), []byte(token)) != 1 &&
+			subtle.ConstantTimeCompare([]byte(auth), []byte("Bearer "+token)) != 1 {
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	outs, err := parseEvents(SourceWebhook, body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid notification: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	failed := false
+	for _, out := range outs {
+		for _, h := range t.webhooks {
+			err := h.handle(r.Context(), out)
+			if err != nil {
+				t.logger.Errorf("Error handling %s event of object '%s': %v", out.EventName, out.Key, err)
+				failed = true
+			}
+		}
+	}
+
+	if failed {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,286 @@
+package s3events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"flogo/core/action"
+	"flogo/core/api"
+	"flogo/core/support/test"
+	"flogo/core/trigger"
+	"github.com/minio/minio-go/v7/pkg/notification"
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig string = `{
+	"id": "trigger-s3events",
+	"ref": "github.com/qingcloudhx/contrib/trigger/s3events",
+	"settings": {
+	  "endpoint": "http://localhost:9000",
+	  "port": 9090,
+	  "authToken": "t0ken"
+	},
+	"handlers": [
+	  {
+		"settings": {
+		  "source": "webhook",
+		  "bucket": "reports",
+		  "suffix": ".csv",
+		  "events": "s3:ObjectCreated:*",
+		  "fetchContent": true
+		},
+		"action": {
+		  "id": "test"
+		}
+	  }
+	]
+}`
+
+// collect returns a handler function appending the outputs it handles to the slice, it fails for the keys of the failures
+func collect(outputs *[]*Output, failures map[string]bool) api.HandlerFunc {
+	return func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+		out := &Output{}
+		if err := out.FromMap(inputs); err != nil {
+			return nil, err
+		}
+		if failures[out.Key] {
+			return nil, errors.New("failed")
+		}
+		*outputs = append(*outputs, out)
+		return nil, nil
+	}
+}
+
+func keys(outputs []*Output) []string {
+	var keys []string
+	for _, out := range outputs {
+		keys = append(keys, out.Key)
+	}
+	return keys
+}
+
+// initHandler returns the event handler of a trigger initialized with a handler of the settings running the function
+func initHandler(t *testing.T, settings map[string]interface{}, f api.HandlerFunc) *eventHandler {
+
+	config := &trigger.Config{}
+	err := json.Unmarshal([]byte(testConfig), config)
+	assert.Nil(t, err)
+	config.Handlers[0].Settings = settings
+
+	trg, err := test.InitTrigger(&Factory{}, config, map[string]action.Action{"test": api.NewProxyAction(f)})
+	assert.Nil(t, err)
+
+	tgr := trg.(*Trigger)
+	if len(tgr.webhooks) > 0 {
+		return tgr.webhooks[0]
+	}
+	return tgr.handlers[0]
+}
+
+// testStore returns the content of its objects and sends its notifications to the listeners
+type testStore struct {
+	objects       map[string]string
+	notifications chan notification.Info
+}
+
+func (s *testStore) listen(ctx context.Context, bucket, prefix, suffix string, events []string) <-chan notification.Info {
+	ch := make(chan notification.Info)
+	go func() {
+		defer close(ch)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case info := <-s.notifications:
+				ch <- info
+			}
+		}
+	}()
+	return ch
+}
+
+func (s *testStore) fetch(ctx context.Context, bucket, key, versionID string, maxSize int64) ([]byte, string, error) {
+	content, ok := s.objects[bucket+"/"+key]
+	if !ok {
+		return nil, "", errors.New("not found")
+	}
+	return []byte(content), "text/plain", nil
+}
+
+// record returns the record of an event of MinIO
+func record(event, bucket, key string, size int) string {
+	return `{"eventVersion": "2.0", "eventSource": "minio:s3", "awsRegion": "", "eventTime": "2019-05-15T15:20:41.123Z",
+  "eventName": "` + event + `", "userIdentity": {"principalId": "minioadmin"}, "requestParameters": {"sourceIPAddress": "10.0.0.1"},
+  "s3": {"bucket": {"name": "` + bucket + `"}, "object": {"key": "` + key + `", "size": ` + strconv.Itoa(size) + `, "eTag": "d41d8cd9"}}}`
+}
+
+func TestTrigger_Webhook(t *testing.T) {
+
+	var outputs []*Output
+	failures := map[string]bool{}
+	config := &trigger.Config{}
+	err := json.Unmarshal([]byte(testConfig), config)
+	assert.Nil(t, err)
+	trg, err := test.InitTrigger(&Factory{}, config, map[string]action.Action{"test": api.NewProxyAction(collect(&outputs, failures))})
+	assert.Nil(t, err)
+	tgr := trg.(*Trigger)
+	assert.Len(t, tgr.webhooks, 1)
+	assert.Len(t, tgr.handlers, 0)
+	tgr.webhooks[0].store = &testStore{objects: map[string]string{"reports/2019/may report.csv": "id,total\n1,42\n"}}
+
+	post := func(auth, body string) int {
+		r := httptest.NewRequest(http.MethodPost, "/events", strings.NewReader(body))
+		if auth != "" {
+			r.Header.Set("Authorization", auth)
+		}
+		w := httptest.NewRecorder()
+		tgr.mux.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	body := `{"EventName": "s3:ObjectCreated:Put", "Key": "reports/2019/may report.csv", "Records": [` +
+		record("s3:ObjectCreated:Put", "reports", "2019%2Fmay+report.csv", 5) + `,` +
+		record("s3:ObjectRemoved:Delete", "reports", "2019%2Fapril.csv", 0) + `]}`
+
+	assert.Equal(t, http.StatusNoContent, post("Bearer t0ken", body))
+	assert.Equal(t, []string{"2019/may report.csv"}, keys(outputs))
+	assert.Equal(t, "id,total\n1,42\n", outputs[0].Content)
+	assert.Equal(t, "text/plain", outputs[0].ContentType)
+
+	assert.Equal(t, http.StatusNoContent, post("t0ken", body))
+	assert.Equal(t, http.StatusUnauthorized, post("", body))
+	assert.Equal(t, http.StatusUnauthorized, post("Bearer token", body))
+	assert.Equal(t, http.StatusBadRequest, post("t0ken", `{"Key": "reports/a.csv"}`))
+
+	// the content of a missing object can't be fetched
+	missing := `{"Records": [` + record("s3:ObjectCreated:Put", "reports", "missing.csv", 5) + `]}`
+	assert.Equal(t, http.StatusInternalServerError, post("t0ken", missing))
+
+	failures["2019/may report.csv"] = true
+	assert.Equal(t, http.StatusInternalServerError, post("t0ken", body))
+}
+
+func TestTrigger_WebhookHandlers(t *testing.T) {
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	// each notification is handled by the handlers matching it
+	var csv, removed []*Output
+	app := api.NewApp()
+	trg := app.NewTrigger(&Trigger{}, map[string]interface{}{"endpoint": "http://localhost:9000", "port": port})
+	for _, h := range []struct {
+		settings map[string]interface{}
+		outputs  *[]*Output
+	}{
+		{map[string]interface{}{"source": "webhook", "bucket": "reports", "suffix": ".csv", "events": "s3:ObjectCreated:*"}, &csv},
+		{map[string]interface{}{"source": "webhook", "events": "s3:ObjectRemoved:*"}, &removed},
+	} {
+		handler, err := trg.NewHandler(h.settings)
+		assert.Nil(t, err)
+		_, err = handler.NewAction(collect(h.outputs, nil))
+		assert.Nil(t, err)
+	}
+
+	e, err := api.NewEngine(app)
+	assert.Nil(t, err)
+	assert.Nil(t, e.Start())
+	defer e.Stop()
+
+	body := `{"Records": [` +
+		record("s3:ObjectCreated:Put", "reports", "2019%2Fmay+report.csv", 5) + `,` +
+		record("s3:ObjectRemoved:Delete", "reports", "2019%2Fapril.csv", 0) + `,` +
+		record("s3:ObjectCreated:Put", "images", "a.jpg", 5) + `]}`
+	resp, err := http.Post(fmt.Sprintf("http://127.0.0.1:%d/events", port), "application/json", strings.NewReader(body))
+	assert.Nil(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, []string{"2019/may report.csv"}, keys(csv))
+	assert.Equal(t, []string{"2019/april.csv"}, keys(removed))
+	assert.Equal(t, "", removed[0].Content)
+}
+
+func TestTrigger_Restart(t *testing.T) {
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	var outputs []*Output
+	config := &trigger.Config{}
+	err = json.Unmarshal([]byte(testConfig), config)
+	assert.Nil(t, err)
+	config.Settings["port"] = port
+	config.Handlers[0].Settings["fetchContent"] = false
+	trg, err := test.InitTrigger(&Factory{}, config, map[string]action.Action{"test": api.NewProxyAction(collect(&outputs, nil))})
+	assert.Nil(t, err)
+
+	post := func() int {
+		body := `{"Records": [` + record("s3:ObjectCreated:Put", "reports", "a.csv", 5) + `]}`
+		r, err := http.NewRequest(http.MethodPost, fmt.Sprintf("http://127.0.0.1:%d/events", port), strings.NewReader(body))
+		assert.Nil(t, err)
+		r.Header.Set("Authorization", "Bearer t0ken")
+		// the connections of a stopped trigger are closed
+		r.Close = true
+		resp, err := http.DefaultClient.Do(r)
+		if err != nil {
+			return 0
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	// the trigger serves the requests again once restarted
+	for i := 1; i <= 2; i++ {
+		assert.Nil(t, trg.Start())
+		assert.Equal(t, http.StatusNoContent, post())
+		assert.Len(t, outputs, i)
+		assert.Nil(t, trg.Stop())
+	}
+	assert.Equal(t, 0, post())
+}
+
+func TestTrigger_Initialize(t *testing.T) {
+
+	tests := []struct {
+		settings map[string]interface{}
+		handler  map[string]interface{}
+		err      string
+	}{
+		{map[string]interface{}{}, map[string]interface{}{"source": "webhook"}, "a port is required by the webhook handlers"},
+		{map[string]interface{}{}, map[string]interface{}{"source": "sqs"}, "a queue url is required by the sqs source"},
+		{map[string]interface{}{}, map[string]interface{}{"source": "listen"}, "a bucket is required by the listen source"},
+		{map[string]interface{}{}, map[string]interface{}{"source": "listen", "bucket": "b", "events": "s3:[Object"}, "invalid event pattern"},
+		{map[string]interface{}{"endpoint": "localhost:9000"}, map[string]interface{}{"source": "listen", "bucket": "b"}, "invalid endpoint"},
+		{map[string]interface{}{"endpoint": "http://localhost:9000"}, map[string]interface{}{"source": "listen", "bucket": "b"}, ""},
+	}
+
+	for _, tt := range tests {
+		config := &trigger.Config{}
+		err := json.Unmarshal([]byte(testConfig), config)
+		assert.Nil(t, err)
+		config.Settings = tt.settings
+		config.Handlers[0].Settings = tt.handler
+
+		_, err = test.InitTrigger(&Factory{}, config, map[string]action.Action{"test": test.NewDummyAction(func() {
+			//do nothing
+		})})
+		if tt.err == "" {
+			assert.Nil(t, err)
+		} else if assert.NotNil(t, err) {
+			assert.Contains(t, err.Error(), tt.err)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+module github.com/qingcloudhx/contrib/trigger/sftp
+
+require (
+	flogo/core v0.9.0
+	github.com/jlaffaye/ftp v0.2.0
+	github.com/pkg/sftp v1.13.6
+	github.com/stretchr/testify v1.3.0
+	golang.org/x/crypto v0.17.0
+)
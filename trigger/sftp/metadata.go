@@ -0,0 +1,99 @@
+package sftp
+
+import (
+	"flogo/core/data/coerce"
+)
+
+const (
+	DownloadContent = "content"
+	DownloadFile    = "file"
+
+	OnSuccessKeep   = "keep"
+	OnSuccessDelete = "delete"
+	OnSuccessMove   = "move"
+	OnSuccessRename = "rename"
+)
+
+type Settings struct {
+	URL      string `md:"url,required"` // The url of the server (ex. sftp://files.example.com), sftp, ftp and ftps urls are supported, ftps urls connect with implicit TLS
+	Username string `md:"username"`     // The user name used to log in, defaults to anonymous for FTP
+	Password string `md:"password"`     // The password used to log in
+
+	PrivateKeyFile        string `md:"privateKeyFile"`        // The PEM file of the private key used to authenticate with SFTP
+	PrivateKeyPassphrase  string `md:"privateKeyPassphrase"`  // The passphrase of the private key, if it is encrypted
+	KnownHostsFile        string `md:"knownHostsFile"`        // The known_hosts file used to verify the key of the SFTP server, defaults to ~/.ssh/known_hosts
+	InsecureIgnoreHostKey bool   `md:"insecureIgnoreHostKey"` // Don't verify the key of the SFTP server, for testing only
+
+	ExplicitTLS        bool   `md:"explicitTLS"`        // Switch ftp connections to TLS with AUTH TLS
+	CAFile             string `md:"caFile"`             // The PEM file of the CA certificates used to verify the FTPS server
+	InsecureSkipVerify bool   `md:"insecureSkipVerify"` // Don't verify the certificate of the FTPS server, for testing only
+}
+
+type HandlerSettings struct {
+	Path           string `md:"path,required"`                              // The remote directory to poll
+	Patterns       string `md:"patterns"`                                   // The comma separated glob patterns of the names of the files to handle (ex. *.csv,report-??.json), defaults to all the files
+	PollInterval   string `md:"pollInterval"`                               // The interval between two listings of the directory (ex. 30s), defaults to 1m
+	Download       string `md:"download,allowed(content,file)"`             // Download the files: content delivers their content, file saves them to local files removed once the action completed; only their reference is delivered if not specified
+	DownloadDir    string `md:"downloadDir"`                                // The local directory of the downloaded files, defaults to the temporary directory
+	MaxContentSize int64  `md:"maxContentSize"`                             // The max size in bytes of a content delivered, larger files are delivered without content, defaults to 10MB
+	OnSuccess      string `md:"onSuccess,allowed(keep,delete,move,rename)"` // What is done with a file once its action succeeded: keep (default), delete, move or rename
+	MoveTo         string `md:"moveTo"`                                     // The remote directory the files are moved to
+	RenameSuffix   string `md:"renameSuffix"`                               // The suffix appended to the names of the renamed files, which are ignored afterwards, defaults to .processed
+}
+
+type Output struct {
+	Path      string `md:"path"`      // The remote path of the file
+	Name      string `md:"name"`      // The name of the file
+	URL       string `md:"url"`       // The url of the file, without the credentials
+	Size      int64  `md:"size"`      // The size of the file in bytes
+	ModTime   int64  `md:"modTime"`   // The last modification time of the file in milliseconds since epoch
+	Content   string `md:"content"`   // The content of the file, when downloaded as content
+	LocalPath string `md:"localPath"` // The path of the local copy of the file, when downloaded as file
+}
+
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"path":      o.Path,
+		"name":      o.Name,
+		"url":       o.URL,
+		"size":      o.Size,
+		"modTime":   o.ModTime,
+		"content":   o.Content,
+		"localPath": o.LocalPath,
+	}
+}
+
+func (o *Output) FromMap(values map[string]interface{}) error {
+
+	var err error
+	o.Path, err = coerce.ToString(values["path"])
+	if err != nil {
+		return err
+	}
+	o.Name, err = coerce.ToString(values["name"])
+	if err != nil {
+		return err
+	}
+	o.URL, err = coerce.ToString(values["url"])
+	if err != nil {
+		return err
+	}
+	o.Size, err = coerce.ToInt64(values["size"])
+	if err != nil {
+		return err
+	}
+	o.ModTime, err = coerce.ToInt64(values["modTime"])
+	if err != nil {
+		return err
+	}
+	o.Content, err = coerce.ToString(values["content"])
+	if err != nil {
+		return err
+	}
+	o.LocalPath, err = coerce.ToString(values["localPath"])
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
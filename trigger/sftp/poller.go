@@ -0,0 +1,298 @@
+package sftp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+)
+
+const (
+	defaultPollInterval   = time.Minute
+	defaultMaxContentSize = 10 * 1024 * 1024
+	defaultRenameSuffix   = ".processed"
+)
+
+// poller lists the directory of a handler, a file is handled once it is unchanged between two
+// listings, so that the files being uploaded are ignored, and post-processed once its action
+// succeeded; a kept file is handled again when it changes
+type poller struct {
+	handler  trigger.Handler
+	settings *HandlerSettings
+	logger   log.Logger
+	dial     func() (remote, error)
+	baseURL  string
+
+	patterns     []string
+	pollInterval time.Duration
+	listed       map[string]fileInfo
+	handled      map[string]fileInfo
+	done         chan struct{}
+}
+
+func newPoller(handler trigger.Handler, dial func() (remote, error), baseURL string, s *HandlerSettings, logger log.Logger) (*poller, error) {
+
+	p := &poller{handler: handler, settings: s, logger: logger, dial: dial, baseURL: baseURL, pollInterval: defaultPollInterval,
+		listed: make(map[string]fileInfo), handled: make(map[string]fileInfo)}
+
+	if s.Path == "" {
+		return nil, fmt.Errorf("a path must be specified")
+	}
+
+	p.patterns = splitList(s.Patterns)
+	for _, pattern := range p.patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern '%s': %v", pattern, err)
+		}
+	}
+
+	if s.PollInterval != "" {
+		interval, err := time.ParseDuration(s.PollInterval)
+		if err != nil {
+			return nil, fmt.Errorf("invalid poll interval '%s': %v", s.PollInterval, err)
+		}
+		if interval <= 0 {
+			return nil, fmt.Errorf("poll interval must be positive")
+		}
+		p.pollInterval = interval
+	}
+
+	if s.MaxContentSize <= 0 {
+		s.MaxContentSize = defaultMaxContentSize
+	}
+
+	if s.OnSuccess == "" {
+		s.OnSuccess = OnSuccessKeep
+	}
+	switch s.OnSuccess {
+	case OnSuccessKeep, OnSuccessDelete:
+	case OnSuccessMove:
+		if s.MoveTo == "" {
+			return nil, fmt.Errorf("moving the files requires a directory to move them to")
+		}
+	case OnSuccessRename:
+		if s.RenameSuffix == "" {
+			s.RenameSuffix = defaultRenameSuffix
+		}
+	default:
+		return nil, fmt.Errorf("unsupported action on success '%s'", s.OnSuccess)
+	}
+
+	return p, nil
+}
+
+// run polls the directory until the context is done, the file being handled is completed
+func (p *poller) run(ctx context.Context) {
+
+	defer close(p.done)
+
+	p.logger.Infof("Polling directory '%s'", p.settings.Path)
+
+	for {
+		if err := p.poll(ctx); err != nil {
+			p.logger.Errorf("Error polling directory '%s': %v", p.settings.Path, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(p.pollInterval):
+		}
+	}
+}
+
+// wait waits for the poller to stop, at most for the timeout
+func (p *poller) wait(timeout time.Duration) {
+
+	if p.done == nil {
+		return
+	}
+
+	select {
+	case <-p.done:
+	case <-time.After(timeout):
+		p.logger.Warnf("File of directory '%s' still being handled", p.settings.Path)
+	}
+}
+
+// poll lists the directory and handles the files unchanged since the previous listing
+func (p *poller) poll(ctx context.Context) error {
+
+	r, err := p.dial()
+	if err != nil {
+		return err
+	}
+	defer r.close()
+
+	files, err := r.list(p.settings.Path)
+	if err != nil {
+		return fmt.Errorf("unable to list directory: %v", err)
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].name < files[j].name })
+
+	listed := make(map[string]fileInfo, len(files))
+	for _, file := range files {
+		if !p.matches(file.name) {
+			continue
+		}
+		listed[file.name] = file
+
+		if ctx.Err() != nil || p.listed[file.name] != file || p.handled[file.name] == file {
+			continue
+		}
+		if p.handle(r, file) {
+			p.handled[file.name] = file
+		}
+	}
+
+	p.listed = listed
+	for name := range p.handled {
+		if _, ok := listed[name]; !ok {
+			delete(p.handled, name)
+		}
+	}
+
+	return nil
+}
+
+// handle invokes the action for a file and post-processes the file once the action succeeded, it
+// returns whether the file is left in the directory after its action succeeded
+func (p *poller) handle(r remote, file fileInfo) bool {
+
+	s := p.settings
+	remotePath := path.Join(s.Path, file.name)
+
+	out := &Output{
+		Path:    remotePath,
+		Name:    file.name,
+		URL:     p.baseURL + "/" + strings.TrimPrefix(remotePath, "/"),
+		Size:    file.size,
+		ModTime: file.modTime,
+	}
+
+	switch s.Download {
+	case DownloadContent:
+		if file.size > s.MaxContentSize {
+			p.logger.Warnf("File '%s' too large to be downloaded: %d bytes", remotePath, file.size)
+			break
+		}
+		content, err := readContent(r, remotePath)
+		if err != nil {
+			p.logger.Errorf("Error downloading file '%s': %v", remotePath, err)
+			return false
+		}
+		out.Content = content
+	case DownloadFile:
+		localPath, err := downloadFile(r, remotePath, s.DownloadDir)
+		if err != nil {
+			p.logger.Errorf("Error downloading file '%s': %v", remotePath, err)
+			return false
+		}
+		defer os.Remove(localPath)
+		out.LocalPath = localPath
+	}
+
+	_, err := p.handler.Handle(context.Background(), out)
+	if err != nil {
+		p.logger.Errorf("Error handling file '%s': %v", remotePath, err)
+		return false
+	}
+
+	switch s.OnSuccess {
+	case OnSuccessDelete:
+		err = r.remove(remotePath)
+	case OnSuccessMove:
+		err = r.rename(remotePath, path.Join(s.MoveTo, file.name))
+	case OnSuccessRename:
+		err = r.rename(remotePath, remotePath+s.RenameSuffix)
+	default:
+		return true
+	}
+	if err != nil {
+		// the file isn't handled again, until it changes
+		p.logger.Errorf("Error post-processing file '%s' (%s): %v", remotePath, s.OnSuccess, err)
+		return true
+	}
+
+	return false
+}
+
+func readContent(r remote, remotePath string) (string, error) {
+
+	reader, err := r.open(remotePath)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	content, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+
+	return string(content), nil
+}
+
+// downloadFile copies the file to a new local file of the directory, and returns its path
+func downloadFile(r remote, remotePath, dir string) (string, error) {
+
+	reader, err := r.open(remotePath)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	local, err := ioutil.TempFile(dir, "sftp-*-"+path.Base(remotePath))
+	if err != nil {
+		return "", err
+	}
+
+	_, err = io.Copy(local, reader)
+	if cerr := local.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(local.Name())
+		return "", err
+	}
+
+	return local.Name(), nil
+}
+
+func (p *poller) matches(name string) bool {
+
+	if p.settings.OnSuccess == OnSuccessRename && strings.HasSuffix(name, p.settings.RenameSuffix) {
+		return false
+	}
+	if len(p.patterns) == 0 {
+		return true
+	}
+
+	for _, pattern := range p.patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func splitList(list string) []string {
+
+	var values []string
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
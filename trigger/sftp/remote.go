@@ -0,0 +1,283 @@
+package sftp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jlaffaye/ftp"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const dialTimeout = 30 * time.Second
+
+// remote is a connection to an SFTP or FTP server
+type remote interface {
+	list(dir string) ([]fileInfo, error)
+	open(path string) (io.ReadCloser, error)
+	remove(path string) error
+	rename(from, to string) error
+	close() error
+}
+
+// fileInfo is the state of a remote file, a file is unchanged while its state is equal
+type fileInfo struct {
+	name    string
+	size    int64
+	modTime int64
+}
+
+func newFileInfo(name string, size int64, modTime time.Time) fileInfo {
+	return fileInfo{name: name, size: size, modTime: modTime.UnixNano() / int64(time.Millisecond)}
+}
+
+// parseURL returns the url of the server of the settings
+func parseURL(settings *Settings) (*url.URL, error) {
+
+	serverURL, err := url.Parse(settings.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url '%s': %v", settings.URL, err)
+	}
+
+	switch serverURL.Scheme {
+	case "sftp", "ftp", "ftps":
+	default:
+		return nil, fmt.Errorf("unsupported url scheme '%s', sftp, ftp or ftps expected", serverURL.Scheme)
+	}
+
+	return serverURL, nil
+}
+
+// dial connects to the server of the settings and logs in
+func dial(settings *Settings) (remote, error) {
+
+	serverURL, err := parseURL(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	if serverURL.Scheme == "sftp" {
+		return dialSFTP(serverURL, settings)
+	}
+
+	return dialFTP(serverURL, settings)
+}
+
+func hostPort(serverURL *url.URL, defaultPort string) string {
+
+	if serverURL.Port() != "" {
+		return serverURL.Host
+	}
+
+	return net.JoinHostPort(serverURL.Hostname(), defaultPort)
+}
+
+type sftpRemote struct {
+	conn   *ssh.Client
+	client *sftp.Client
+}
+
+func dialSFTP(serverURL *url.URL, settings *Settings) (remote, error) {
+
+	config, err := getSSHConfig(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := ssh.Dial("tcp", hostPort(serverURL, "22"), config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to '%s': %v", serverURL.Host, err)
+	}
+
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("unable to start SFTP session with '%s': %v", serverURL.Host, err)
+	}
+
+	return &sftpRemote{conn: conn, client: client}, nil
+}
+
+// getSSHConfig returns the SSH configuration of the settings
+func getSSHConfig(settings *Settings) (*ssh.ClientConfig, error) {
+
+	config := &ssh.ClientConfig{User: settings.Username, Timeout: dialTimeout}
+
+	if settings.PrivateKeyFile != "" {
+		pem, err := ioutil.ReadFile(settings.PrivateKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read private key file [%s]: %v", settings.PrivateKeyFile, err)
+		}
+		var signer ssh.Signer
+		if settings.PrivateKeyPassphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase(pem, []byte(settings.PrivateKeyPassphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey(pem)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse private key file [%s]: %v", settings.PrivateKeyFile, err)
+		}
+		config.Auth = append(config.Auth, ssh.PublicKeys(signer))
+	}
+	if settings.Password != "" {
+		config.Auth = append(config.Auth, ssh.Password(settings.Password))
+	}
+
+	if settings.InsecureIgnoreHostKey {
+		config.HostKeyCallback = ssh.InsecureIgnoreHostKey()
+		return config, nil
+	}
+
+	file := settings.KnownHostsFile
+	if file == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("unable to locate the known_hosts file: %v", err)
+		}
+		file = filepath.Join(home, ".ssh", "known_hosts")
+	}
+
+	callback, err := knownhosts.New(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read known hosts file [%s]: %v", file, err)
+	}
+	config.HostKeyCallback = callback
+
+	return config, nil
+}
+
+func (r *sftpRemote) list(dir string) ([]fileInfo, error) {
+
+	entries, err := r.client.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []fileInfo
+	for _, entry := range entries {
+		if entry.Mode().IsRegular() {
+			files = append(files, newFileInfo(entry.Name(), entry.Size(), entry.ModTime()))
+		}
+	}
+
+	return files, nil
+}
+
+func (r *sftpRemote) open(path string) (io.ReadCloser, error) {
+	return r.client.Open(path)
+}
+
+func (r *sftpRemote) remove(path string) error {
+	return r.client.Remove(path)
+}
+
+func (r *sftpRemote) rename(from, to string) error {
+	return r.client.Rename(from, to)
+}
+
+func (r *sftpRemote) close() error {
+	_ = r.client.Close()
+	return r.conn.Close()
+}
+
+type ftpRemote struct {
+	conn *ftp.ServerConn
+}
+
+func dialFTP(serverURL *url.URL, settings *Settings) (remote, error) {
+
+	options := []ftp.DialOption{ftp.DialWithTimeout(dialTimeout)}
+	port := "21"
+
+	if serverURL.Scheme == "ftps" || settings.ExplicitTLS {
+		tlsConfig, err := getTLSConfig(settings)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.ServerName = serverURL.Hostname()
+
+		if serverURL.Scheme == "ftps" {
+			options = append(options, ftp.DialWithTLS(tlsConfig))
+			port = "990"
+		} else {
+			options = append(options, ftp.DialWithExplicitTLS(tlsConfig))
+		}
+	}
+
+	conn, err := ftp.Dial(hostPort(serverURL, port), options...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to '%s': %v", serverURL.Host, err)
+	}
+
+	username := settings.Username
+	if username == "" {
+		username = "anonymous"
+	}
+	if err := conn.Login(username, settings.Password); err != nil {
+		_ = conn.Quit()
+		return nil, fmt.Errorf("unable to log in to '%s' as '%s': %v", serverURL.Host, username, err)
+	}
+
+	return &ftpRemote{conn: conn}, nil
+}
+
+// getTLSConfig returns the TLS configuration of the FTPS connection
+func getTLSConfig(settings *Settings) (*tls.Config, error) {
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: settings.InsecureSkipVerify}
+
+	if settings.CAFile != "" {
+		pem, err := ioutil.ReadFile(settings.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read CA file [%s]: %v", settings.CAFile, err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file [%s]", settings.CAFile)
+		}
+	}
+
+	return tlsConfig, nil
+}
+
+func (r *ftpRemote) list(dir string) ([]fileInfo, error) {
+
+	entries, err := r.conn.List(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []fileInfo
+	for _, entry := range entries {
+		if entry.Type == ftp.EntryTypeFile {
+			files = append(files, newFileInfo(entry.Name, int64(entry.Size), entry.Time))
+		}
+	}
+
+	return files, nil
+}
+
+func (r *ftpRemote) open(path string) (io.ReadCloser, error) {
+	return r.conn.Retr(path)
+}
+
+func (r *ftpRemote) remove(path string) error {
+	return r.conn.Delete(path)
+}
+
+func (r *ftpRemote) rename(from, to string) error {
+	return r.conn.Rename(from, to)
+}
+
+func (r *ftpRemote) close() error {
+	return r.conn.Quit()
+}
@@ -0,0 +1,356 @@
+package sftp
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"flogo/core/action"
+	"flogo/core/api"
+	"flogo/core/support/test"
+	"flogo/core/trigger"
+	"github.com/pkg/sftp"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+)
+
+const testConfig string = `{
+	"id": "trigger-sftp",
+	"ref": "github.com/qingcloudhx/contrib/trigger/sftp",
+	"settings": {
+	  "url": "sftp://files",
+	  "insecureIgnoreHostKey": true
+	},
+	"handlers": [
+	  {
+		"settings": {
+		  "path": "/inbox"
+		},
+		"action": {
+		  "id": "test"
+		}
+	  }
+	]
+}`
+
+// collect returns a handler function recording its outputs, it fails for the names of the failures, and a
+// function returning the outputs recorded since its last call
+func collect(failures map[string]bool) (api.HandlerFunc, func() []*Output) {
+	var mu sync.Mutex
+	var outputs []*Output
+
+	f := func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+		out := &Output{}
+		if err := out.FromMap(inputs); err != nil {
+			return nil, err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if out.LocalPath != "" {
+			content, _ := ioutil.ReadFile(out.LocalPath)
+			out.Content = string(content)
+		}
+		outputs = append(outputs, out)
+		if failures[out.Name] {
+			return nil, errors.New("failed")
+		}
+		return nil, nil
+	}
+	handled := func() []*Output {
+		mu.Lock()
+		defer mu.Unlock()
+
+		handled := outputs
+		outputs = nil
+		return handled
+	}
+
+	return f, handled
+}
+
+func names(outputs []*Output) []string {
+	var names []string
+	for _, out := range outputs {
+		names = append(names, out.Name)
+	}
+	return names
+}
+
+// initTrigger returns a trigger initialized with the settings and a handler of the settings running the function
+func initTrigger(settings, handlerSettings map[string]interface{}, f api.HandlerFunc) (*Trigger, error) {
+
+	config := &trigger.Config{}
+	if err := json.Unmarshal([]byte(testConfig), config); err != nil {
+		return nil, err
+	}
+	for name, value := range settings {
+		config.Settings[name] = value
+	}
+	config.Handlers[0].Settings = handlerSettings
+
+	trg, err := test.InitTrigger(&Factory{}, config, map[string]action.Action{"test": api.NewProxyAction(f)})
+	if err != nil {
+		return nil, err
+	}
+	return trg.(*Trigger), nil
+}
+
+// testRemote is a directory of files in memory
+type testRemote struct {
+	files map[string]string
+	times map[string]time.Time
+}
+
+func newTestRemote() *testRemote {
+	return &testRemote{files: make(map[string]string), times: make(map[string]time.Time)}
+}
+
+func (r *testRemote) write(name, content string) {
+	r.files[name] = content
+	r.times[name] = time.Now()
+}
+
+func (r *testRemote) list(dir string) ([]fileInfo, error) {
+	var files []fileInfo
+	for name, content := range r.files {
+		if path.Dir(name) == dir {
+			files = append(files, newFileInfo(path.Base(name), int64(len(content)), r.times[name]))
+		}
+	}
+	return files, nil
+}
+
+func (r *testRemote) open(path string) (io.ReadCloser, error) {
+	content, ok := r.files[path]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return ioutil.NopCloser(strings.NewReader(content)), nil
+}
+
+func (r *testRemote) remove(path string) error {
+	delete(r.files, path)
+	return nil
+}
+
+func (r *testRemote) rename(from, to string) error {
+	r.files[to], r.times[to] = r.files[from], r.times[from]
+	delete(r.files, from)
+	return nil
+}
+
+func (r *testRemote) close() error {
+	return nil
+}
+
+func newTestPoller(t *testing.T, r *testRemote, f api.HandlerFunc, settings map[string]interface{}) *poller {
+	trg, err := initTrigger(nil, settings, f)
+	assert.Nil(t, err)
+	p := trg.pollers[0]
+	p.dial = func() (remote, error) { return r, nil }
+	return p
+}
+
+func TestPoller(t *testing.T) {
+
+	r := newTestRemote()
+	r.write("/inbox/a.csv", "a")
+	r.write("/inbox/b.csv", "b")
+	r.write("/inbox/c.txt", "c")
+
+	f, handled := collect(map[string]bool{"b.csv": true})
+	p := newTestPoller(t, r, f, map[string]interface{}{"path": "/inbox", "patterns": "*.csv", "download": DownloadContent})
+
+	// the files are handled once they are unchanged between two listings
+	assert.Nil(t, p.poll(context.Background()))
+	assert.Empty(t, names(handled()))
+
+	r.write("/inbox/d.csv", "d")
+	assert.Nil(t, p.poll(context.Background()))
+	assert.Equal(t, []string{"a.csv", "b.csv"}, names(handled()))
+
+	// the failed file is handled again, the kept file only once it changes
+	r.write("/inbox/a.csv", "aa")
+	assert.Nil(t, p.poll(context.Background()))
+	assert.Equal(t, []string{"b.csv", "d.csv"}, names(handled()))
+
+	assert.Nil(t, p.poll(context.Background()))
+	assert.Equal(t, []string{"a.csv", "b.csv"}, names(handled()))
+}
+
+func TestPoller_OnSuccess(t *testing.T) {
+
+	r := newTestRemote()
+	r.write("/inbox/a.csv", "a")
+	r.write("/inbox/b.csv", "b")
+
+	f, handled := collect(map[string]bool{"b.csv": true})
+	p := newTestPoller(t, r, f, map[string]interface{}{"path": "/inbox", "onSuccess": OnSuccessRename})
+
+	assert.Nil(t, p.poll(context.Background()))
+	assert.Nil(t, p.poll(context.Background()))
+	assert.Equal(t, []string{"a.csv", "b.csv"}, names(handled()))
+	assert.Contains(t, r.files, "/inbox/a.csv.processed")
+	assert.Contains(t, r.files, "/inbox/b.csv")
+
+	// the renamed files are ignored
+	assert.Nil(t, p.poll(context.Background()))
+	assert.Equal(t, []string{"b.csv"}, names(handled()))
+
+	p = newTestPoller(t, r, f, map[string]interface{}{"path": "/inbox", "patterns": "*.processed", "onSuccess": OnSuccessMove, "moveTo": "/done"})
+	assert.Nil(t, p.poll(context.Background()))
+	assert.Nil(t, p.poll(context.Background()))
+	assert.Equal(t, []string{"a.csv.processed"}, names(handled()))
+	assert.Contains(t, r.files, "/done/a.csv.processed")
+
+	f, handled = collect(nil)
+	p = newTestPoller(t, r, f, map[string]interface{}{"path": "/inbox", "onSuccess": OnSuccessDelete})
+	assert.Nil(t, p.poll(context.Background()))
+	assert.Nil(t, p.poll(context.Background()))
+	assert.Equal(t, []string{"b.csv"}, names(handled()))
+	assert.NotContains(t, r.files, "/inbox/b.csv")
+}
+
+func TestNewPoller(t *testing.T) {
+
+	f, _ := collect(nil)
+	trg, err := initTrigger(nil, map[string]interface{}{"path": "/inbox"}, f)
+	assert.Nil(t, err)
+	p := trg.pollers[0]
+	assert.Equal(t, time.Minute, p.pollInterval)
+	assert.Equal(t, OnSuccessKeep, p.settings.OnSuccess)
+	assert.Equal(t, int64(defaultMaxContentSize), p.settings.MaxContentSize)
+
+	invalid := []map[string]interface{}{
+		{},
+		{"path": "/inbox", "patterns": "[a-"},
+		{"path": "/inbox", "pollInterval": "soon"},
+		{"path": "/inbox", "onSuccess": OnSuccessMove},
+		{"path": "/inbox", "onSuccess": "archive"},
+	}
+	for _, s := range invalid {
+		_, err = initTrigger(nil, s, f)
+		assert.NotNil(t, err)
+	}
+}
+
+// startSFTPServer serves the file system with SFTP, for the user test with the password secret
+func startSFTPServer(t *testing.T) net.Listener {
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+	signer, err := ssh.NewSignerFromKey(key)
+	assert.Nil(t, err)
+
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if conn.User() == "test" && string(password) == "secret" {
+				return nil, nil
+			}
+			return nil, errors.New("denied")
+		},
+	}
+	config.AddHostKey(signer)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go serveSFTP(conn, config)
+		}
+	}()
+
+	return l
+}
+
+func serveSFTP(conn net.Conn, config *ssh.ServerConfig) {
+
+	_, channels, requests, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(requests)
+
+	for newChannel := range channels {
+		if newChannel.ChannelType() != "session" {
+			_ = newChannel.Reject(ssh.UnknownChannelType, "unknown channel type")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			for req := range requests {
+				_ = req.Reply(req.Type == "subsystem" && string(req.Payload[4:]) == "sftp", nil)
+			}
+		}()
+		server, err := sftp.NewServer(channel)
+		if err != nil {
+			return
+		}
+		go func() {
+			_ = server.Serve()
+			_ = server.Close()
+		}()
+	}
+}
+
+func TestSFTP(t *testing.T) {
+
+	l := startSFTPServer(t)
+	defer l.Close()
+
+	dir, err := ioutil.TempDir("", "sftp")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	assert.Nil(t, os.Mkdir(filepath.Join(dir, "inbox"), 0755))
+	assert.Nil(t, os.Mkdir(filepath.Join(dir, "done"), 0755))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, "inbox", "orders.csv"), []byte("id,qty\n1,2\n"), 0644))
+
+	url := "sftp://" + l.Addr().String()
+	f, handled := collect(nil)
+	trg, err := initTrigger(map[string]interface{}{"url": url, "username": "test", "password": "secret"}, map[string]interface{}{"path": filepath.Join(dir, "inbox"),
+		"download": DownloadFile, "onSuccess": OnSuccessMove, "moveTo": filepath.Join(dir, "done")}, f)
+	assert.Nil(t, err)
+	p := trg.pollers[0]
+
+	assert.Nil(t, p.poll(context.Background()))
+	assert.Nil(t, p.poll(context.Background()))
+
+	outputs := handled()
+	assert.Len(t, outputs, 1)
+	out := outputs[0]
+	assert.Equal(t, "orders.csv", out.Name)
+	assert.Equal(t, int64(11), out.Size)
+	assert.Equal(t, "id,qty\n1,2\n", out.Content)
+	assert.Equal(t, url+filepath.Join(dir, "inbox", "orders.csv"), out.URL)
+
+	// the local copy is removed once the action completed
+	_, err = os.Stat(out.LocalPath)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(dir, "done", "orders.csv"))
+	assert.Nil(t, err)
+
+	trg.settings.Password = "wrong"
+	assert.NotNil(t, p.poll(context.Background()))
+}
@@ -0,0 +1,7 @@
+module github.com/qingcloudhx/contrib/trigger/snmptrap
+
+require (
+	flogo/core v0.9.0
+	github.com/gosnmp/gosnmp v1.38.0
+	github.com/stretchr/testify v1.3.0
+)
@@ -0,0 +1,100 @@
+package snmptrap
+
+import (
+	"flogo/core/data/coerce"
+)
+
+type Settings struct {
+	Host        string `md:"host"`        // The host name or IP to listen on, defaults to all the interfaces
+	Port        int    `md:"port"`        // The UDP port to listen on, defaults to 162
+	Communities string `md:"communities"` // The comma separated communities of the v1 and v2c traps accepted, defaults to all the communities
+
+	Username       string `md:"username"`                                                    // The user of the v3 traps, the v3 traps aren't accepted if not specified
+	AuthProtocol   string `md:"authProtocol,allowed(MD5,SHA,SHA224,SHA256,SHA384,SHA512)"`   // The authentication protocol of the user, the traps aren't authenticated if not specified
+	AuthPassphrase string `md:"authPassphrase"`                                              // The authentication passphrase of the user
+	PrivProtocol   string `md:"privProtocol,allowed(DES,AES,AES192,AES256,AES192C,AES256C)"` // The privacy protocol of the user, the traps aren't encrypted if not specified
+	PrivPassphrase string `md:"privPassphrase"`                                              // The privacy passphrase of the user
+	EngineID       string `md:"engineId"`                                                    // The engine id of the trigger in hexadecimal (ex. 8000000001020304), required by the v3 informs
+
+	ResolveNames bool   `md:"resolveNames"` // Resolve the names of the OIDs with the standard MIBs and the MIB file
+	MIBFile      string `md:"mibFile"`      // The file of the names of the OIDs, with a name and an OID per line, as written by snmptranslate -Tz -On
+}
+
+type HandlerSettings struct {
+	TrapOIDs string `md:"trapOids"` // The comma separated OIDs or names of the traps to handle, with the traps under them (ex. linkDown,1.3.6.1.4.1.9), defaults to all the traps
+}
+
+type Output struct {
+	Version   string                 `md:"version"`   // The version of the trap: 1, 2c or 3
+	Type      string                 `md:"type"`      // The type of the notification: trap or inform
+	Source    string                 `md:"source"`    // The address of the sender (ex. 10.0.0.5:49152)
+	Community string                 `md:"community"` // The community of a v1 or v2c trap
+	User      string                 `md:"user"`      // The user of a v3 trap
+	TrapOID   string                 `md:"trapOid"`   // The OID of the trap, converted from its enterprise and generic and specific traps for v1
+	TrapName  string                 `md:"trapName"`  // The name of the trap, when resolved
+	Uptime    int64                  `md:"uptime"`    // The time since the sender started, in hundredths of seconds
+	Varbinds  []interface{}          `md:"varbinds"`  // The variables of the trap, with their oid, name, type and value
+	Values    map[string]interface{} `md:"values"`    // The values of the variables by name when resolved, or by OID, except the uptime and trap OID
+}
+
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"version":   o.Version,
+		"type":      o.Type,
+		"source":    o.Source,
+		"community": o.Community,
+		"user":      o.User,
+		"trapOid":   o.TrapOID,
+		"trapName":  o.TrapName,
+		"uptime":    o.Uptime,
+		"varbinds":  o.Varbinds,
+		"values":    o.Values,
+	}
+}
+
+func (o *Output) FromMap(values map[string]interface{}) error {
+
+	var err error
+	o.Version, err = coerce.ToString(values["version"])
+	if err != nil {
+		return err
+	}
+	o.Type, err = coerce.ToString(values["type"])
+	if err != nil {
+		return err
+	}
+	o.Source, err = coerce.ToString(values["source"])
+	if err != nil {
+		return err
+	}
+	o.Community, err = coerce.ToString(values["community"])
+	if err != nil {
+		return err
+	}
+	o.User, err = coerce.ToString(values["user"])
+	if err != nil {
+		return err
+	}
+	o.TrapOID, err = coerce.ToString(values["trapOid"])
+	if err != nil {
+		return err
+	}
+	o.TrapName, err = coerce.ToString(values["trapName"])
+	if err != nil {
+		return err
+	}
+	o.Uptime, err = coerce.ToInt64(values["uptime"])
+	if err != nil {
+		return err
+	}
+	o.Varbinds, err = coerce.ToArray(values["varbinds"])
+	if err != nil {
+		return err
+	}
+	o.Values, err = coerce.ToObject(values["values"])
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
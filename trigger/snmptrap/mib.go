@@ -0,0 +1,163 @@
+package snmptrap
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// standardNames are the names of the OIDs of the standard traps and of the variables they usually send,
+// from SNMPv2-MIB and IF-MIB
+var standardNames = map[string]string{
+	"1.3.6.1.2.1.1.1":         "sysDescr",
+	"1.3.6.1.2.1.1.2":         "sysObjectID",
+	"1.3.6.1.2.1.1.3":         "sysUpTime",
+	"1.3.6.1.2.1.1.4":         "sysContact",
+	"1.3.6.1.2.1.1.5":         "sysName",
+	"1.3.6.1.2.1.1.6":         "sysLocation",
+	"1.3.6.1.2.1.2.2.1.1":     "ifIndex",
+	"1.3.6.1.2.1.2.2.1.2":     "ifDescr",
+	"1.3.6.1.2.1.2.2.1.3":     "ifType",
+	"1.3.6.1.2.1.2.2.1.4":     "ifMtu",
+	"1.3.6.1.2.1.2.2.1.5":     "ifSpeed",
+	"1.3.6.1.2.1.2.2.1.6":     "ifPhysAddress",
+	"1.3.6.1.2.1.2.2.1.7":     "ifAdminStatus",
+	"1.3.6.1.2.1.2.2.1.8":     "ifOperStatus",
+	"1.3.6.1.2.1.2.2.1.9":     "ifLastChange",
+	"1.3.6.1.2.1.31.1.1.1.1":  "ifName",
+	"1.3.6.1.2.1.31.1.1.1.18": "ifAlias",
+	"1.3.6.1.4.1":             "enterprises",
+	"1.3.6.1.6.3.1.1.4.1":     "snmpTrapOID",
+	"1.3.6.1.6.3.1.1.4.3":     "snmpTrapEnterprise",
+	"1.3.6.1.6.3.1.1.5.1":     "coldStart",
+	"1.3.6.1.6.3.1.1.5.2":     "warmStart",
+	"1.3.6.1.6.3.1.1.5.3":     "linkDown",
+	"1.3.6.1.6.3.1.1.5.4":     "linkUp",
+	"1.3.6.1.6.3.1.1.5.5":     "authenticationFailure",
+	"1.3.6.1.6.3.1.1.5.6":     "egpNeighborLoss",
+	"1.3.6.1.6.3.18.1.3":      "snmpTrapAddress",
+	"1.3.6.1.6.3.18.1.4":      "snmpTrapCommunity",
+}
+
+// mib resolves the names of the OIDs, and the OIDs of the names
+type mib struct {
+	names map[string]string
+	oids  map[string]string
+}
+
+// newMIB returns the standard names, with the ones of the file when specified
+func newMIB(file string) (*mib, error) {
+
+	m := &mib{names: make(map[string]string), oids: make(map[string]string)}
+	for oid, name := range standardNames {
+		m.add(oid, name)
+	}
+
+	if file == "" {
+		return m, nil
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid line %d of MIB file '%s'", line, file)
+		}
+
+		name, oid := strings.Trim(fields[0], `"`), strings.Trim(fields[1], `"`)
+		if isOID(name) {
+			name, oid = oid, name
+		}
+		if !isOID(oid) {
+			return nil, fmt.Errorf("invalid OID '%s' at line %d of MIB file '%s'", oid, line, file)
+		}
+		m.add(oid, name)
+	}
+
+	return m, scanner.Err()
+}
+
+func (m *mib) add(oid, name string) {
+	oid = normalizeOID(oid)
+	m.names[oid] = name
+	m.oids[name] = oid
+}
+
+// name returns the name of the OID, with the name of its longest known prefix followed by the rest
+// of the OID (ex. ifOperStatus.3), or an empty name when not known
+func (m *mib) name(oid string) string {
+
+	oid = normalizeOID(oid)
+	for prefix := oid; prefix != ""; {
+		if name, ok := m.names[prefix]; ok {
+			return name + strings.TrimPrefix(oid, prefix)
+		}
+		i := strings.LastIndex(prefix, ".")
+		if i < 0 {
+			break
+		}
+		prefix = prefix[:i]
+	}
+
+	return ""
+}
+
+// oid returns the OID of an OID or a name, the name being optionally followed by an index (ex. ifDescr.3)
+func (m *mib) oid(s string) (string, error) {
+
+	if isOID(s) {
+		return normalizeOID(s), nil
+	}
+
+	name, index := s, ""
+	if i := strings.Index(s, "."); i >= 0 {
+		name, index = s[:i], s[i:]
+	}
+	oid, ok := m.oids[name]
+	if !ok || (index != "" && !isOID(index)) {
+		return "", fmt.Errorf("unknown OID name '%s'", s)
+	}
+
+	return oid + index, nil
+}
+
+// normalizeOID removes the leading dot of an OID
+func normalizeOID(oid string) string {
+	return strings.TrimPrefix(oid, ".")
+}
+
+// isOID returns true when the string is an OID in numeric form, with or without a leading dot
+func isOID(s string) bool {
+
+	s = normalizeOID(s)
+	if s == "" {
+		return false
+	}
+	for _, part := range strings.Split(s, ".") {
+		if part == "" {
+			return false
+		}
+		for _, c := range part {
+			if c < '0' || c > '9' {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// hasOIDPrefix returns true when the OID is the prefix or is under it
+func hasOIDPrefix(oid, prefix string) bool {
+	return oid == prefix || strings.HasPrefix(oid, prefix+".")
+}
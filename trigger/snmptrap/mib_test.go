@@ -0,0 +1,60 @@
+package snmptrap
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMIB(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "snmptrap")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "mib.txt")
+	err = ioutil.WriteFile(file, []byte(`# snmptranslate -Tz -On -m CISCO-SYSLOG-MIB
+"ciscoSyslogMIB"	".1.3.6.1.4.1.9.9.41"
+"clogMessageGenerated"	".1.3.6.1.4.1.9.9.41.2.0.1"
+"clogHistSeverity"	".1.3.6.1.4.1.9.9.41.1.2.3.1.3"
+.1.3.6.1.4.1.9.9.41.1.2.3.1.5 clogHistMsgText
+`), 0644)
+	assert.Nil(t, err)
+
+	m, err := newMIB(file)
+	assert.Nil(t, err)
+
+	assert.Equal(t, "clogMessageGenerated", m.name("1.3.6.1.4.1.9.9.41.2.0.1"))
+	assert.Equal(t, "clogHistSeverity.12", m.name(".1.3.6.1.4.1.9.9.41.1.2.3.1.3.12"))
+	assert.Equal(t, "clogHistMsgText.12", m.name("1.3.6.1.4.1.9.9.41.1.2.3.1.5.12"))
+	assert.Equal(t, "ciscoSyslogMIB.3", m.name("1.3.6.1.4.1.9.9.41.3"))
+	assert.Equal(t, "enterprises.2636", m.name("1.3.6.1.4.1.2636"))
+	assert.Equal(t, "linkUp", m.name("1.3.6.1.6.3.1.1.5.4"))
+	assert.Equal(t, "", m.name("1.0.8802"))
+
+	oid, err := m.oid("clogMessageGenerated")
+	assert.Nil(t, err)
+	assert.Equal(t, "1.3.6.1.4.1.9.9.41.2.0.1", oid)
+	oid, err = m.oid("ifDescr.3")
+	assert.Nil(t, err)
+	assert.Equal(t, "1.3.6.1.2.1.2.2.1.2.3", oid)
+	oid, err = m.oid(".1.3.6.1.4.1.9")
+	assert.Nil(t, err)
+	assert.Equal(t, "1.3.6.1.4.1.9", oid)
+	_, err = m.oid("ifDescr.x")
+	assert.NotNil(t, err)
+
+	err = ioutil.WriteFile(file, []byte("\"ciscoSyslogMIB\" \"cisco.9.41\"\n"), 0644)
+	assert.Nil(t, err)
+	_, err = newMIB(file)
+	assert.NotNil(t, err)
+}
+
+func TestHasOIDPrefix(t *testing.T) {
+	assert.True(t, hasOIDPrefix("1.3.6.1.4.1.9", "1.3.6.1.4.1.9"))
+	assert.True(t, hasOIDPrefix("1.3.6.1.4.1.9.9.41", "1.3.6.1.4.1.9"))
+	assert.False(t, hasOIDPrefix("1.3.6.1.4.1.99", "1.3.6.1.4.1.9"))
+}
@@ -0,0 +1,160 @@
+package snmptrap
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+const (
+	sysUpTimeOID   = "1.3.6.1.2.1.1.3.0"
+	snmpTrapOID    = "1.3.6.1.6.3.1.1.4.1.0"
+	genericTrapOID = "1.3.6.1.6.3.1.1.5"
+
+	// the generic trap of the v1 traps specific to their enterprise
+	enterpriseSpecific = 6
+)
+
+// toOutput returns the data of a trap, the names of the OIDs being resolved when the mib is specified
+func toOutput(packet *gosnmp.SnmpPacket, source string, names *mib) *Output {
+
+	out := &Output{
+		Source:   source,
+		Type:     "trap",
+		Varbinds: make([]interface{}, 0, len(packet.Variables)),
+		Values:   make(map[string]interface{}, len(packet.Variables)),
+	}
+	if packet.PDUType == gosnmp.InformRequest {
+		out.Type = "inform"
+	}
+
+	switch packet.Version {
+	case gosnmp.Version1:
+		out.Version = "1"
+		out.Community = packet.Community
+		out.TrapOID = v1TrapOID(packet.Enterprise, packet.GenericTrap, packet.SpecificTrap)
+		out.Uptime = int64(packet.Timestamp)
+	case gosnmp.Version2c:
+		out.Version = "2c"
+		out.Community = packet.Community
+	case gosnmp.Version3:
+		out.Version = "3"
+		if usm, ok := packet.SecurityParameters.(*gosnmp.UsmSecurityParameters); ok {
+			out.User = usm.UserName
+		}
+	}
+
+	for _, v := range packet.Variables {
+		oid := normalizeOID(v.Name)
+		value := toValue(v)
+
+		var name string
+		if names != nil {
+			name = names.name(oid)
+		}
+
+		out.Varbinds = append(out.Varbinds, map[string]interface{}{
+			"oid":   oid,
+			"name":  name,
+			"type":  v.Type.String(),
+			"value": value,
+		})
+
+		// the uptime and trap OID of the v2c and v3 traps are the first variables
+		switch oid {
+		case sysUpTimeOID:
+			if uptime, ok := value.(int64); ok {
+				out.Uptime = uptime
+				continue
+			}
+		case snmpTrapOID:
+			if trapOID, ok := value.(string); ok {
+				out.TrapOID = trapOID
+				continue
+			}
+		}
+
+		if name != "" {
+			out.Values[name] = value
+		} else {
+			out.Values[oid] = value
+		}
+	}
+
+	if names != nil {
+		out.TrapName = names.name(out.TrapOID)
+	}
+
+	return out
+}
+
+// v1TrapOID returns the OID of a v1 trap, as converted to v2c by RFC 3584
+func v1TrapOID(enterprise string, generic, specific int) string {
+
+	if generic == enterpriseSpecific {
+		return normalizeOID(enterprise) + ".0." + strconv.Itoa(specific)
+	}
+	return genericTrapOID + "." + strconv.Itoa(generic+1)
+}
+
+// toValue returns the value of a variable: the integers as int64, except the 64 bits counters which
+// are uint64, the OIDs without leading dot, and the octet strings as text when printable or else
+// as hexadecimal bytes separated by colons (ex. 00:1a:2b:3c:4d:5e)
+func toValue(v gosnmp.SnmpPDU) interface{} {
+
+	switch v.Type {
+	case gosnmp.OctetString, gosnmp.BitString, gosnmp.Opaque:
+		b, ok := v.Value.([]byte)
+		if !ok {
+			return v.Value
+		}
+		if isPrintable(b) {
+			return string(b)
+		}
+		hex := make([]string, len(b))
+		for i, c := range b {
+			hex[i] = fmt.Sprintf("%02x", c)
+		}
+		return strings.Join(hex, ":")
+	case gosnmp.ObjectIdentifier:
+		if oid, ok := v.Value.(string); ok {
+			return normalizeOID(oid)
+		}
+	case gosnmp.Counter64:
+		return gosnmp.ToBigInt(v.Value).Uint64()
+	case gosnmp.Integer, gosnmp.Counter32, gosnmp.Gauge32, gosnmp.TimeTicks, gosnmp.Uinteger32:
+		return gosnmp.ToBigInt(v.Value).Int64()
+	case gosnmp.Null, gosnmp.NoSuchObject, gosnmp.NoSuchInstance, gosnmp.EndOfMibView:
+		return nil
+	}
+
+	return v.Value
+}
+
+func isPrintable(b []byte) bool {
+
+	if !utf8.Valid(b) {
+		return false
+	}
+	for _, r := range string(b) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func splitList(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
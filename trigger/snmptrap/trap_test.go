@@ -0,0 +1,60 @@
+package snmptrap
+
+import (
+	"testing"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToOutput_V1(t *testing.T) {
+
+	names, err := newMIB("")
+	assert.Nil(t, err)
+
+	packet := &gosnmp.SnmpPacket{
+		Version:   gosnmp.Version1,
+		Community: "public",
+		PDUType:   gosnmp.Trap,
+		Variables: []gosnmp.SnmpPDU{
+			{Name: ".1.3.6.1.2.1.2.2.1.1.2", Type: gosnmp.Integer, Value: 2},
+		},
+		SnmpTrap: gosnmp.SnmpTrap{
+			Enterprise:   ".1.3.6.1.4.1.8072.3.2.10",
+			GenericTrap:  2,
+			SpecificTrap: 0,
+			Timestamp:    300,
+		},
+	}
+
+	out := toOutput(packet, "10.0.0.5:1620", names)
+	assert.Equal(t, "1", out.Version)
+	assert.Equal(t, "trap", out.Type)
+	assert.Equal(t, "10.0.0.5:1620", out.Source)
+	assert.Equal(t, "public", out.Community)
+	assert.Equal(t, "1.3.6.1.6.3.1.1.5.3", out.TrapOID)
+	assert.Equal(t, "linkDown", out.TrapName)
+	assert.Equal(t, int64(300), out.Uptime)
+	assert.Equal(t, map[string]interface{}{"ifIndex.2": int64(2)}, out.Values)
+
+	packet.GenericTrap = enterpriseSpecific
+	packet.SpecificTrap = 17
+	out = toOutput(packet, "10.0.0.5:1620", nil)
+	assert.Equal(t, "1.3.6.1.4.1.8072.3.2.10.0.17", out.TrapOID)
+	assert.Equal(t, "", out.TrapName)
+	assert.Equal(t, map[string]interface{}{"1.3.6.1.2.1.2.2.1.1.2": int64(2)}, out.Values)
+	assert.Equal(t, map[string]interface{}{"oid": "1.3.6.1.2.1.2.2.1.1.2", "name": "", "type": "Integer", "value": int64(2)}, out.Varbinds[0])
+}
+
+func TestToValue(t *testing.T) {
+
+	assert.Equal(t, "eth0", toValue(gosnmp.SnmpPDU{Type: gosnmp.OctetString, Value: []byte("eth0")}))
+	assert.Equal(t, "00:1a:2b:3c:4d:5e", toValue(gosnmp.SnmpPDU{Type: gosnmp.OctetString, Value: []byte{0x00, 0x1a, 0x2b, 0x3c, 0x4d, 0x5e}}))
+	assert.Equal(t, "1.3.6.1.4.1.9", toValue(gosnmp.SnmpPDU{Type: gosnmp.ObjectIdentifier, Value: ".1.3.6.1.4.1.9"}))
+	assert.Equal(t, "192.168.1.1", toValue(gosnmp.SnmpPDU{Type: gosnmp.IPAddress, Value: "192.168.1.1"}))
+	assert.Equal(t, int64(-5), toValue(gosnmp.SnmpPDU{Type: gosnmp.Integer, Value: -5}))
+	assert.Equal(t, int64(42), toValue(gosnmp.SnmpPDU{Type: gosnmp.Counter32, Value: uint(42)}))
+	assert.Equal(t, int64(4200), toValue(gosnmp.SnmpPDU{Type: gosnmp.TimeTicks, Value: uint32(4200)}))
+	assert.Equal(t, uint64(18446744073709551615), toValue(gosnmp.SnmpPDU{Type: gosnmp.Counter64, Value: uint64(18446744073709551615)}))
+	assert.Nil(t, toValue(gosnmp.SnmpPDU{Type: gosnmp.NoSuchObject}))
+}
@@ -0,0 +1,264 @@
+package snmptrap
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+
+	"flogo/core/data/metadata"
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+	"github.com/gosnmp/gosnmp"
+)
+
+const defaultPort = 162
+
+var triggerMd = trigger.NewMetadata(&Settings{}, &HandlerSettings{}, &Output{})
+
+var authProtocols = map[string]gosnmp.SnmpV3AuthProtocol{
+	"MD5":    gosnmp.MD5,
+	"SHA":    gosnmp.SHA,
+	"SHA224": gosnmp.SHA224,
+	"SHA256": gosnmp.SHA256,
+	"SHA384": gosnmp.SHA384,
+	"SHA512": gosnmp.SHA512,
+}
+
+var privProtocols = map[string]gosnmp.SnmpV3PrivProtocol{
+	"DES":     gosnmp.DES,
+	"AES":     gosnmp.AES,
+	"AES192":  gosnmp.AES192,
+	"AES256":  gosnmp.AES256,
+	"AES192C": gosnmp.AES192C,
+	"AES256C": gosnmp.AES256C,
+}
+
+func init() {
+	_ = trigger.Register(&Trigger{}, &Factory{})
+}
+
+type Factory struct {
+}
+
+// Metadata implements trigger.Factory.Metadata
+func (*Factory) Metadata() *trigger.Metadata {
+	return triggerMd
+}
+
+// New implements trigger.Factory.New
+func (*Factory) New(config *trigger.Config) (trigger.Trigger, error) {
+
+	s := &Settings{}
+	err := metadata.MapToStruct(config.Settings, s, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Port == 0 {
+		s.Port = defaultPort
+	}
+
+	params, err := newParams(s)
+	if err != nil {
+		return nil, err
+	}
+
+	names, err := newMIB(s.MIBFile)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load MIB file: %v", err)
+	}
+
+	t := &Trigger{settings: s, params: params, names: names, communities: make(map[string]bool)}
+	for _, community := range splitList(s.Communities) {
+		t.communities[community] = true
+	}
+
+	return t, nil
+}
+
+// newParams returns the parameters of the listener, with the user of the v3 traps when specified
+func newParams(s *Settings) (*gosnmp.GoSNMP, error) {
+
+	params := &gosnmp.GoSNMP{Version: gosnmp.Version2c}
+	if s.Username == "" {
+		return params, nil
+	}
+
+	usm := &gosnmp.UsmSecurityParameters{UserName: s.Username}
+	params.MsgFlags = gosnmp.NoAuthNoPriv
+
+	if s.EngineID != "" {
+		engineID, err := hex.DecodeString(s.EngineID)
+		if err != nil || len(engineID) < 5 || len(engineID) > 32 {
+			return nil, fmt.Errorf("invalid engine id '%s'", s.EngineID)
+		}
+		usm.AuthoritativeEngineID = string(engineID)
+	}
+
+	if s.AuthProtocol != "" {
+		if s.AuthPassphrase == "" {
+			return nil, errors.New("an authentication passphrase is required by the authentication protocol")
+		}
+		usm.AuthenticationProtocol = authProtocols[s.AuthProtocol]
+		usm.AuthenticationPassphrase = s.AuthPassphrase
+		params.MsgFlags = gosnmp.AuthNoPriv
+	}
+
+	if s.PrivProtocol != "" {
+		if s.AuthProtocol == "" {
+			return nil, errors.New("an authentication protocol is required by the privacy protocol")
+		}
+		if s.PrivPassphrase == "" {
+			return nil, errors.New("a privacy passphrase is required by the privacy protocol")
+		}
+		usm.PrivacyProtocol = privProtocols[s.PrivProtocol]
+		usm.PrivacyPassphrase = s.PrivPassphrase
+		params.MsgFlags = gosnmp.AuthPriv
+	}
+
+	params.Version = gosnmp.Version3
+	params.SecurityModel = gosnmp.UserSecurityModel
+	params.SecurityParameters = usm
+
+	return params, nil
+}
+
+// Trigger receives the SNMP traps and informs, and invokes the handlers of their trap OIDs
+type Trigger struct {
+	settings    *Settings
+	params      *gosnmp.GoSNMP
+	names       *mib
+	communities map[string]bool
+	logger      log.Logger
+	handlers    []*trapHandler
+
+	listener *gosnmp.TrapListener
+}
+
+// trapHandler is a handler with the OIDs of the traps it handles
+type trapHandler struct {
+	handler trigger.Handler
+	oids    []string
+}
+
+// Initialize implements trigger.Init.Initialize
+func (t *Trigger) Initialize(ctx trigger.InitContext) error {
+
+	t.logger = ctx.Logger()
+
+	for _, handler := range ctx.GetHandlers() {
+
+		s := &HandlerSettings{}
+		err := metadata.MapToStruct(handler.Settings(), s, true)
+		if err != nil {
+			return err
+		}
+
+		h := &trapHandler{handler: handler}
+		for _, trapOID := range splitList(s.TrapOIDs) {
+			oid, err := t.names.oid(trapOID)
+			if err != nil {
+				return err
+			}
+			h.oids = append(h.oids, oid)
+		}
+		t.handlers = append(t.handlers, h)
+	}
+
+	return nil
+}
+
+// Start implements util.Managed.Start
+func (t *Trigger) Start() error {
+
+	listener := gosnmp.NewTrapListener()
+	listener.Params = t.params
+	listener.OnNewTrap = t.handleTrap
+
+	addr := net.JoinHostPort(t.settings.Host, strconv.Itoa(t.settings.Port))
+
+	failed := make(chan error, 1)
+	go func() {
+		failed <- listener.Listen(addr)
+	}()
+
+	select {
+	case <-listener.Listening():
+	case err := <-failed:
+		return err
+	}
+	t.listener = listener
+
+	t.logger.Infof("Listening on traps on %s", addr)
+
+	return nil
+}
+
+// Stop implements util.Managed.Stop
+func (t *Trigger) Stop() error {
+
+	if t.listener != nil {
+		t.listener.Close()
+		t.listener = nil
+	}
+
+	return nil
+}
+
+// handleTrap invokes the handlers of a trap, an inform is acknowledged once they are completed
+func (t *Trigger) handleTrap(packet *gosnmp.SnmpPacket, addr *net.UDPAddr) {
+
+	if !t.accepts(packet) {
+		t.logger.Warnf("Trap from %s rejected", addr)
+		return
+	}
+
+	var names *mib
+	if t.settings.ResolveNames {
+		names = t.names
+	}
+	out := toOutput(packet, addr.String(), names)
+
+	for _, h := range t.handlers {
+		if !h.matches(out.TrapOID) {
+			continue
+		}
+		_, err := h.handler.Handle(context.Background(), out)
+		if err != nil {
+			t.logger.Errorf("Error handling trap '%s' from %s: %v", out.TrapOID, addr, err)
+		}
+	}
+}
+
+// accepts returns true when the community of a v1 or v2c trap is accepted, or when a v3 trap is
+// sent by the user, its authentication and privacy being checked by the listener
+func (t *Trigger) accepts(packet *gosnmp.SnmpPacket) bool {
+
+	if packet.Version != gosnmp.Version3 {
+		return len(t.communities) == 0 || t.communities[packet.Community]
+	}
+
+	if t.settings.Username == "" {
+		return false
+	}
+	usm, ok := packet.SecurityParameters.(*gosnmp.UsmSecurityParameters)
+
+	return ok && usm.UserName == t.settings.Username
+}
+
+func (h *trapHandler) matches(trapOID string) bool {
+
+	if len(h.oids) == 0 {
+		return true
+	}
+	for _, oid := range h.oids {
+		if hasOIDPrefix(trapOID, oid) {
+			return true
+		}
+	}
+
+	return false
+}
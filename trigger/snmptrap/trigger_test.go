@@ -0,0 +1,261 @@
+package snmptrap
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"flogo/core/action"
+	"flogo/core/api"
+	"flogo/core/support/test"
+	"flogo/core/trigger"
+	"github.com/gosnmp/gosnmp"
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig string = `{
+	"id": "trigger-snmptrap",
+	"ref": "github.com/qingcloudhx/contrib/trigger/snmptrap",
+	"settings": {
+	  "host": "127.0.0.1"
+	},
+	"handlers": [
+	  {
+		"settings": {
+		},
+		"action": {
+		  "id": "test"
+		}
+	  }
+	]
+}`
+
+// collect returns a handler function recording the outputs it handles, and a function returning the outputs
+func collect() (api.HandlerFunc, func() []*Output) {
+	var mu sync.Mutex
+	var outputs []*Output
+
+	f := func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+		out := &Output{}
+		if err := out.FromMap(inputs); err != nil {
+			return nil, err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		outputs = append(outputs, out)
+		return nil, nil
+	}
+	handled := func() []*Output {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return append([]*Output(nil), outputs...)
+	}
+
+	return f, handled
+}
+
+func trapOIDs(outputs []*Output) []string {
+	var oids []string
+	for _, out := range outputs {
+		oids = append(oids, out.TrapOID)
+	}
+	return oids
+}
+
+// waitFor waits until the number of traps are handled
+func waitFor(handled func() []*Output, count int) {
+	for i := 0; i < 100 && len(handled()) < count; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func freePort(t *testing.T) int {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).Port
+}
+
+func sendTrap(t *testing.T, client *gosnmp.GoSNMP, trapOID string, inform bool) error {
+
+	err := client.Connect()
+	assert.Nil(t, err)
+	defer client.Conn.Close()
+
+	_, err = client.SendTrap(gosnmp.SnmpTrap{
+		IsInform: inform,
+		Variables: []gosnmp.SnmpPDU{
+			{Name: ".1.3.6.1.2.1.1.3.0", Type: gosnmp.TimeTicks, Value: uint32(4200)},
+			{Name: ".1.3.6.1.6.3.1.1.4.1.0", Type: gosnmp.ObjectIdentifier, Value: trapOID},
+			{Name: ".1.3.6.1.2.1.2.2.1.1.3", Type: gosnmp.Integer, Value: 3},
+			{Name: ".1.3.6.1.2.1.2.2.1.8.3", Type: gosnmp.Integer, Value: 2},
+			{Name: ".1.3.6.1.2.1.2.2.1.2.3", Type: gosnmp.OctetString, Value: "eth0"},
+		},
+	})
+	return err
+}
+
+func TestFactory_New(t *testing.T) {
+
+	f := &Factory{}
+
+	_, err := f.New(&trigger.Config{Settings: map[string]interface{}{"username": "flogo", "privProtocol": "AES", "privPassphrase": "secret"}})
+	assert.NotNil(t, err)
+
+	_, err = f.New(&trigger.Config{Settings: map[string]interface{}{"username": "flogo", "authProtocol": "SHA"}})
+	assert.NotNil(t, err)
+
+	_, err = f.New(&trigger.Config{Settings: map[string]interface{}{"username": "flogo", "engineId": "80zz"}})
+	assert.NotNil(t, err)
+
+	_, err = f.New(&trigger.Config{Settings: map[string]interface{}{"mibFile": "missing.txt"}})
+	assert.NotNil(t, err)
+
+	trg, err := f.New(&trigger.Config{Settings: map[string]interface{}{}})
+	assert.Nil(t, err)
+	assert.Equal(t, defaultPort, trg.(*Trigger).settings.Port)
+}
+
+func TestTrigger_Initialize(t *testing.T) {
+
+	config := &trigger.Config{}
+	err := json.Unmarshal([]byte(testConfig), config)
+	assert.Nil(t, err)
+	config.Handlers[0].Settings = map[string]interface{}{"trapOids": "unknownTrap"}
+
+	f, _ := collect()
+	_, err = test.InitTrigger(&Factory{}, config, map[string]action.Action{"test": api.NewProxyAction(f)})
+	assert.NotNil(t, err)
+}
+
+func TestTrigger_V2c(t *testing.T) {
+
+	port := freePort(t)
+
+	// each trap is handled by the handlers matching it
+	app := api.NewApp()
+	trg := app.NewTrigger(&Trigger{}, map[string]interface{}{
+		"host":         "127.0.0.1",
+		"port":         port,
+		"communities":  "public",
+		"resolveNames": true,
+	})
+	linksFunc, links := collect()
+	vendorFunc, vendor := collect()
+	allFunc, all := collect()
+	for _, h := range []struct {
+		settings map[string]interface{}
+		f        api.HandlerFunc
+	}{
+		{map[string]interface{}{"trapOids": "linkDown, linkUp"}, linksFunc},
+		{map[string]interface{}{"trapOids": "1.3.6.1.4.1.9"}, vendorFunc},
+		{map[string]interface{}{}, allFunc},
+	} {
+		handler, err := trg.NewHandler(h.settings)
+		assert.Nil(t, err)
+		_, err = handler.NewAction(h.f)
+		assert.Nil(t, err)
+	}
+
+	e, err := api.NewEngine(app)
+	assert.Nil(t, err)
+	assert.Nil(t, e.Start())
+	defer e.Stop()
+
+	client := &gosnmp.GoSNMP{Target: "127.0.0.1", Port: uint16(port), Community: "public", Version: gosnmp.Version2c, Timeout: time.Second}
+
+	err = sendTrap(t, client, ".1.3.6.1.6.3.1.1.5.3", false)
+	assert.Nil(t, err)
+	err = sendTrap(t, client, ".1.3.6.1.4.1.9.9.41.2.0.1", false)
+	assert.Nil(t, err)
+
+	// the community isn't accepted
+	client.Community = "private"
+	err = sendTrap(t, client, ".1.3.6.1.6.3.1.1.5.4", false)
+	assert.Nil(t, err)
+
+	// the inform is acknowledged once handled
+	client.Community = "public"
+	err = sendTrap(t, client, ".1.3.6.1.6.3.1.1.5.4", true)
+	assert.Nil(t, err)
+
+	waitFor(all, 3)
+	assert.Equal(t, []string{"1.3.6.1.6.3.1.1.5.3", "1.3.6.1.4.1.9.9.41.2.0.1", "1.3.6.1.6.3.1.1.5.4"}, trapOIDs(all()))
+	assert.Equal(t, []string{"1.3.6.1.6.3.1.1.5.3", "1.3.6.1.6.3.1.1.5.4"}, trapOIDs(links()))
+	assert.Equal(t, []string{"1.3.6.1.4.1.9.9.41.2.0.1"}, trapOIDs(vendor()))
+
+	out := links()[0]
+	assert.Equal(t, "2c", out.Version)
+	assert.Equal(t, "trap", out.Type)
+	assert.Equal(t, "public", out.Community)
+	assert.Equal(t, "linkDown", out.TrapName)
+	assert.Equal(t, int64(4200), out.Uptime)
+	assert.Len(t, out.Varbinds, 5)
+	assert.Equal(t, map[string]interface{}{"ifIndex.3": int64(3), "ifOperStatus.3": int64(2), "ifDescr.3": "eth0"}, out.Values)
+	assert.Equal(t, "inform", links()[1].Type)
+}
+
+func TestTrigger_V3(t *testing.T) {
+
+	port := freePort(t)
+
+	config := &trigger.Config{}
+	err := json.Unmarshal([]byte(testConfig), config)
+	assert.Nil(t, err)
+	config.Settings = map[string]interface{}{
+		"host":           "127.0.0.1",
+		"port":           port,
+		"username":       "flogo",
+		"authProtocol":   "SHA",
+		"authPassphrase": "authpassphrase",
+		"privProtocol":   "AES",
+		"privPassphrase": "privpassphrase",
+	}
+
+	f, handled := collect()
+	trg, err := test.InitTrigger(&Factory{}, config, map[string]action.Action{"test": api.NewProxyAction(f)})
+	assert.Nil(t, err)
+	assert.Nil(t, trg.Start())
+	defer trg.Stop()
+
+	newClient := func(user, privPassphrase string) *gosnmp.GoSNMP {
+		return &gosnmp.GoSNMP{
+			Target:        "127.0.0.1",
+			Port:          uint16(port),
+			Version:       gosnmp.Version3,
+			Timeout:       time.Second,
+			SecurityModel: gosnmp.UserSecurityModel,
+			MsgFlags:      gosnmp.AuthPriv,
+			SecurityParameters: &gosnmp.UsmSecurityParameters{
+				UserName:                 user,
+				AuthoritativeEngineID:    "\x80\x00\x00\x00\x01\x02\x03\x04",
+				AuthenticationProtocol:   gosnmp.SHA,
+				AuthenticationPassphrase: "authpassphrase",
+				PrivacyProtocol:          gosnmp.AES,
+				PrivacyPassphrase:        privPassphrase,
+			},
+		}
+	}
+
+	err = sendTrap(t, newClient("flogo", "privpassphrase"), ".1.3.6.1.6.3.1.1.5.3", false)
+	assert.Nil(t, err)
+	err = sendTrap(t, newClient("other", "privpassphrase"), ".1.3.6.1.6.3.1.1.5.4", false)
+	assert.Nil(t, err)
+	err = sendTrap(t, newClient("flogo", "wrongpassphrase"), ".1.3.6.1.6.3.1.1.5.5", false)
+	assert.Nil(t, err)
+	err = sendTrap(t, newClient("flogo", "privpassphrase"), ".1.3.6.1.6.3.1.1.5.1", false)
+	assert.Nil(t, err)
+
+	waitFor(handled, 2)
+	outputs := handled()
+	assert.Equal(t, []string{"1.3.6.1.6.3.1.1.5.3", "1.3.6.1.6.3.1.1.5.1"}, trapOIDs(outputs))
+	assert.Equal(t, "3", outputs[0].Version)
+	assert.Equal(t, "flogo", outputs[0].User)
+	assert.Equal(t, map[string]interface{}{"1.3.6.1.2.1.2.2.1.1.3": int64(3), "1.3.6.1.2.1.2.2.1.8.3": int64(2), "1.3.6.1.2.1.2.2.1.2.3": "eth0"}, outputs[0].Values)
+}
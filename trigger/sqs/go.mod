@@ -0,0 +1,9 @@
+module github.com/qingcloudhx/contrib/trigger/sqs
+
+require (
+	flogo/core v0.9.0
+	github.com/aws/aws-sdk-go-v2 v1.32.2
+	github.com/aws/aws-sdk-go-v2/config v1.27.43
+	github.com/aws/aws-sdk-go-v2/service/sqs v1.34.8
+	github.com/stretchr/testify v1.3.0
+)
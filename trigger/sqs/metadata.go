@@ -0,0 +1,69 @@
+package sqs
+
+import (
+	"flogo/core/data/coerce"
+)
+
+type Settings struct {
+	Region   string `md:"region"`   // The AWS region of the queues, defaults to the region of the environment or the shared configuration
+	Profile  string `md:"profile"`  // The profile of the shared configuration and credentials files to use
+	Endpoint string `md:"endpoint"` // The url of the SQS endpoint, to use a compatible service (ex. http://localhost:4566)
+}
+
+type HandlerSettings struct {
+	QueueURL          string `md:"queueUrl,required"` // The url of the queue to poll
+	MaxMessages       int    `md:"maxMessages"`       // The max number of messages received at once, from 1 to 10 (default)
+	WaitTime          int    `md:"waitTime"`          // How long a receive waits for messages in seconds (long polling), from 1 to 20 (default)
+	VisibilityTimeout int    `md:"visibilityTimeout"` // How long the received messages are hidden from the other consumers in seconds, defaults to the visibility timeout of the queue
+	ExtendVisibility  bool   `md:"extendVisibility"`  // Extend the visibility timeout of the messages while their action runs, requires a visibility timeout
+	Concurrency       int    `md:"concurrency"`       // The number of messages of a batch handled at the same time, defaults to 1
+	RetryDelay        string `md:"retryDelay"`        // How long a failed message stays hidden before it is received again (ex. 30s), defaults to the rest of its visibility timeout
+}
+
+type Output struct {
+	MessageID        string            `md:"messageId"`        // The id of the message
+	Body             string            `md:"body"`             // The body of the message
+	Content          interface{}       `md:"content"`          // The body of the message parsed, when it is JSON
+	Attributes       map[string]string `md:"attributes"`       // The string and number attributes of the message
+	SystemAttributes map[string]string `md:"systemAttributes"` // The system attributes of the message (ex. SentTimestamp, MessageGroupId)
+	ReceiveCount     int               `md:"receiveCount"`     // The number of times the message was received
+}
+
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"messageId":        o.MessageID,
+		"body":             o.Body,
+		"content":          o.Content,
+		"attributes":       o.Attributes,
+		"systemAttributes": o.SystemAttributes,
+		"receiveCount":     o.ReceiveCount,
+	}
+}
+
+func (o *Output) FromMap(values map[string]interface{}) error {
+
+	var err error
+	o.MessageID, err = coerce.ToString(values["messageId"])
+	if err != nil {
+		return err
+	}
+	o.Body, err = coerce.ToString(values["body"])
+	if err != nil {
+		return err
+	}
+	o.Content = values["content"]
+	o.Attributes, err = coerce.ToParams(values["attributes"])
+	if err != nil {
+		return err
+	}
+	o.SystemAttributes, err = coerce.ToParams(values["systemAttributes"])
+	if err != nil {
+		return err
+	}
+	o.ReceiveCount, err = coerce.ToInt(values["receiveCount"])
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
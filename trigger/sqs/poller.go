@@ -0,0 +1,223 @@
+package sqs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+const (
+	defaultMaxMessages = 10
+	defaultWaitTime    = 20
+	retryInterval      = 5 * time.Second
+)
+
+// client is the part of the SQS client used by a poller
+type client interface {
+	ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error)
+	DeleteMessage(ctx context.Context, params *sqs.DeleteMessageInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error)
+	ChangeMessageVisibility(ctx context.Context, params *sqs.ChangeMessageVisibilityInput, optFns ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error)
+}
+
+// poller receives the messages of the queue of a handler, a message is deleted once its action
+// succeeded, otherwise it is received again once its visibility timeout expires and moved to the
+// dead letter queue of the queue after its max receive count
+type poller struct {
+	client   client
+	handler  trigger.Handler
+	settings *HandlerSettings
+	logger   log.Logger
+
+	retryDelay time.Duration
+	done       chan struct{}
+}
+
+func newPoller(client client, handler trigger.Handler, s *HandlerSettings, logger log.Logger) (*poller, error) {
+
+	if s.MaxMessages == 0 {
+		s.MaxMessages = defaultMaxMessages
+	}
+	if s.MaxMessages < 1 || s.MaxMessages > 10 {
+		return nil, fmt.Errorf("max messages must be between 1 and 10")
+	}
+	if s.WaitTime == 0 {
+		s.WaitTime = defaultWaitTime
+	}
+	if s.WaitTime < 1 || s.WaitTime > 20 {
+		return nil, fmt.Errorf("wait time must be between 1 and 20 seconds")
+	}
+	if s.ExtendVisibility && s.VisibilityTimeout <= 0 {
+		return nil, fmt.Errorf("extending the visibility requires a visibility timeout")
+	}
+	if s.Concurrency <= 0 {
+		s.Concurrency = 1
+	}
+
+	p := &poller{client: client, handler: handler, settings: s, logger: logger, retryDelay: -1}
+
+	if s.RetryDelay != "" {
+		delay, err := time.ParseDuration(s.RetryDelay)
+		if err != nil {
+			return nil, fmt.Errorf("invalid retry delay '%s': %v", s.RetryDelay, err)
+		}
+		p.retryDelay = delay
+	}
+
+	return p, nil
+}
+
+// run polls the queue until the context is done, the messages being handled are completed
+func (p *poller) run(ctx context.Context) {
+
+	defer close(p.done)
+
+	s := p.settings
+	p.logger.Infof("Polling queue '%s'", s.QueueURL)
+
+	input := &sqs.ReceiveMessageInput{
+		QueueUrl:                    aws.String(s.QueueURL),
+		MaxNumberOfMessages:         int32(s.MaxMessages),
+		WaitTimeSeconds:             int32(s.WaitTime),
+		VisibilityTimeout:           int32(s.VisibilityTimeout),
+		MessageAttributeNames:       []string{"All"},
+		MessageSystemAttributeNames: []types.MessageSystemAttributeName{types.MessageSystemAttributeNameAll},
+	}
+
+	for ctx.Err() == nil {
+		output, err := p.client.ReceiveMessage(ctx, input)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			p.logger.Errorf("Error receiving messages from queue '%s': %v", s.QueueURL, err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(retryInterval):
+			}
+			continue
+		}
+
+		// the messages of a batch are handled before the next receive
+		var wg sync.WaitGroup
+		slots := make(chan struct{}, s.Concurrency)
+		for _, msg := range output.Messages {
+			slots <- struct{}{}
+			wg.Add(1)
+			go func(msg types.Message) {
+				defer func() {
+					<-slots
+					wg.Done()
+				}()
+				p.handle(msg)
+			}(msg)
+		}
+		wg.Wait()
+	}
+}
+
+// wait waits for the poller to stop, at most for the timeout
+func (p *poller) wait(timeout time.Duration) {
+
+	if p.done == nil {
+		return
+	}
+
+	select {
+	case <-p.done:
+	case <-time.After(timeout):
+		p.logger.Warnf("Messages of queue '%s' still being handled", p.settings.QueueURL)
+	}
+}
+
+// handle invokes the action for a message, whose visibility timeout is extended while it runs,
+// and deletes the message once the action succeeded
+func (p *poller) handle(msg types.Message) {
+
+	s := p.settings
+	ctx := context.Background()
+
+	if s.ExtendVisibility {
+		stop := make(chan struct{})
+		defer close(stop)
+		go p.extend(msg, stop)
+	}
+
+	_, err := p.handler.Handle(ctx, toOutput(msg))
+	if err != nil {
+		p.logger.Errorf("Error handling message '%s' of queue '%s': %v", aws.ToString(msg.MessageId), s.QueueURL, err)
+		if p.retryDelay >= 0 {
+			p.changeVisibility(msg, int32(p.retryDelay/time.Second))
+		}
+		return
+	}
+
+	_, err = p.client.DeleteMessage(ctx, &sqs.DeleteMessageInput{QueueUrl: aws.String(s.QueueURL), ReceiptHandle: msg.ReceiptHandle})
+	if err != nil {
+		p.logger.Errorf("Error deleting message '%s' of queue '%s': %v", aws.ToString(msg.MessageId), s.QueueURL, err)
+	}
+}
+
+// extend resets the visibility timeout of the message every half timeout, until stopped
+func (p *poller) extend(msg types.Message, stop chan struct{}) {
+
+	timeout := p.settings.VisibilityTimeout
+	ticker := time.NewTicker(time.Duration(timeout) * time.Second / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			p.changeVisibility(msg, int32(timeout))
+		}
+	}
+}
+
+func (p *poller) changeVisibility(msg types.Message, timeout int32) {
+
+	_, err := p.client.ChangeMessageVisibility(context.Background(), &sqs.ChangeMessageVisibilityInput{
+		QueueUrl:          aws.String(p.settings.QueueURL),
+		ReceiptHandle:     msg.ReceiptHandle,
+		VisibilityTimeout: timeout,
+	})
+	if err != nil {
+		p.logger.Errorf("Error changing the visibility of message '%s': %v", aws.ToString(msg.MessageId), err)
+	}
+}
+
+func toOutput(msg types.Message) *Output {
+
+	out := &Output{
+		MessageID:        aws.ToString(msg.MessageId),
+		Body:             aws.ToString(msg.Body),
+		Attributes:       make(map[string]string, len(msg.MessageAttributes)),
+		SystemAttributes: msg.Attributes,
+	}
+
+	for name, value := range msg.MessageAttributes {
+		if value.StringValue != nil {
+			out.Attributes[name] = *value.StringValue
+		}
+	}
+
+	if count, ok := msg.Attributes[string(types.MessageSystemAttributeNameApproximateReceiveCount)]; ok {
+		out.ReceiveCount, _ = strconv.Atoi(count)
+	}
+
+	var content interface{}
+	if err := json.Unmarshal([]byte(out.Body), &content); err == nil {
+		out.Content = content
+	}
+
+	return out
+}
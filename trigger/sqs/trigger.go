@@ -0,0 +1,121 @@
+package sqs
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"flogo/core/data/metadata"
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+)
+
+var triggerMd = trigger.NewMetadata(&Settings{}, &HandlerSettings{}, &Output{})
+
+func init() {
+	_ = trigger.Register(&Trigger{}, &Factory{})
+}
+
+type Factory struct {
+}
+
+// Metadata implements trigger.Factory.Metadata
+func (*Factory) Metadata() *trigger.Metadata {
+	return triggerMd
+}
+
+// New implements trigger.Factory.New
+func (*Factory) New(config *trigger.Config) (trigger.Trigger, error) {
+
+	s := &Settings{}
+	err := metadata.MapToStruct(config.Settings, s, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Trigger{settings: s}, nil
+}
+
+// Trigger polls SQS queues
+type Trigger struct {
+	settings *Settings
+	logger   log.Logger
+	pollers  []*poller
+
+	cancel context.CancelFunc
+}
+
+// Initialize implements trigger.Init.Initialize
+func (t *Trigger) Initialize(ctx trigger.InitContext) error {
+
+	t.logger = ctx.Logger()
+
+	var options []func(*config.LoadOptions) error
+	if t.settings.Region != "" {
+		options = append(options, config.WithRegion(t.settings.Region))
+	}
+	if t.settings.Profile != "" {
+		options = append(options, config.WithSharedConfigProfile(t.settings.Profile))
+	}
+
+	// the credentials are resolved with the default chain: environment, shared files, web identity and instance roles
+	awsConfig, err := config.LoadDefaultConfig(context.Background(), options...)
+	if err != nil {
+		return fmt.Errorf("unable to load AWS configuration: %v", err)
+	}
+
+	client := sqs.NewFromConfig(awsConfig, func(o *sqs.Options) {
+		if t.settings.Endpoint != "" {
+			o.BaseEndpoint = aws.String(t.settings.Endpoint)
+		}
+	})
+
+	for _, handler := range ctx.GetHandlers() {
+
+		s := &HandlerSettings{}
+		err := metadata.MapToStruct(handler.Settings(), s, true)
+		if err != nil {
+			return err
+		}
+
+		p, err := newPoller(client, handler, s, t.logger)
+		if err != nil {
+			return err
+		}
+		t.pollers = append(t.pollers, p)
+	}
+
+	return nil
+}
+
+// Start implements util.Managed.Start
+func (t *Trigger) Start() error {
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+
+	for _, p := range t.pollers {
+		p.done = make(chan struct{})
+		go p.run(ctx)
+	}
+
+	return nil
+}
+
+// Stop implements util.Managed.Stop
+func (t *Trigger) Stop() error {
+
+	if t.cancel != nil {
+		t.cancel()
+		t.cancel = nil
+	}
+
+	for _, p := range t.pollers {
+		p.wait(30 * time.Second)
+	}
+
+	return nil
+}
@@ -0,0 +1,196 @@
+package sqs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"flogo/core/action"
+	"flogo/core/api"
+	"flogo/core/support/test"
+	"flogo/core/trigger"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig string = `{
+	"id": "trigger-sqs",
+	"ref": "github.com/qingcloudhx/contrib/trigger/sqs",
+	"settings": {
+	  "region": "eu-west-1"
+	},
+	"handlers": [
+	  {
+		"settings": {
+		  "queueUrl": "https://sqs/queue"
+		},
+		"action": {
+		  "id": "test"
+		}
+	  }
+	]
+}`
+
+// handle returns a handler function waiting for the delay, it fails for the bodies of the failures
+func handle(delay time.Duration, failures map[string]bool) api.HandlerFunc {
+	return func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+		out := &Output{}
+		if err := out.FromMap(inputs); err != nil {
+			return nil, err
+		}
+		time.Sleep(delay)
+		if failures[out.Body] {
+			return nil, errors.New("failed")
+		}
+		return nil, nil
+	}
+}
+
+// initPoller returns the poller of a trigger initialized with a handler of the settings running the function
+func initPoller(settings map[string]interface{}, f api.HandlerFunc) (*poller, error) {
+
+	config := &trigger.Config{}
+	if err := json.Unmarshal([]byte(testConfig), config); err != nil {
+		return nil, err
+	}
+	for name, value := range settings {
+		config.Handlers[0].Settings[name] = value
+	}
+
+	trg, err := test.InitTrigger(&Factory{}, config, map[string]action.Action{"test": api.NewProxyAction(f)})
+	if err != nil {
+		return nil, err
+	}
+	return trg.(*Trigger).pollers[0], nil
+}
+
+// testClient returns its batches of messages and records the calls
+type testClient struct {
+	mu         sync.Mutex
+	batches    [][]types.Message
+	deleted    []string
+	visibility map[string][]int32
+}
+
+func (c *testClient) ReceiveMessage(ctx context.Context, params *sqs.ReceiveMessageInput, optFns ...func(*sqs.Options)) (*sqs.ReceiveMessageOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if len(c.batches) == 0 {
+		c.mu.Unlock()
+		<-ctx.Done()
+		c.mu.Lock()
+		return nil, ctx.Err()
+	}
+
+	batch := c.batches[0]
+	c.batches = c.batches[1:]
+	return &sqs.ReceiveMessageOutput{Messages: batch}, nil
+}
+
+func (c *testClient) DeleteMessage(ctx context.Context, params *sqs.DeleteMessageInput, optFns ...func(*sqs.Options)) (*sqs.DeleteMessageOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.deleted = append(c.deleted, aws.ToString(params.ReceiptHandle))
+	return &sqs.DeleteMessageOutput{}, nil
+}
+
+func (c *testClient) ChangeMessageVisibility(ctx context.Context, params *sqs.ChangeMessageVisibilityInput, optFns ...func(*sqs.Options)) (*sqs.ChangeMessageVisibilityOutput, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	handle := aws.ToString(params.ReceiptHandle)
+	c.visibility[handle] = append(c.visibility[handle], params.VisibilityTimeout)
+	return &sqs.ChangeMessageVisibilityOutput{}, nil
+}
+
+func message(id, body string) types.Message {
+	return types.Message{MessageId: aws.String(id), ReceiptHandle: aws.String("rh-" + id), Body: aws.String(body)}
+}
+
+func TestPoller(t *testing.T) {
+
+	client := &testClient{
+		batches: [][]types.Message{
+			{message("1", "ok"), message("2", "fail")},
+			{message("3", "ok")},
+		},
+		visibility: make(map[string][]int32),
+	}
+
+	p, err := initPoller(map[string]interface{}{"concurrency": 2, "retryDelay": "10s"}, handle(0, map[string]bool{"fail": true}))
+	assert.Nil(t, err)
+	p.client = client
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.done = make(chan struct{})
+	go p.run(ctx)
+
+	for i := 0; i < 100; i++ {
+		client.mu.Lock()
+		deleted := len(client.deleted)
+		client.mu.Unlock()
+		if deleted == 2 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	p.wait(time.Second)
+
+	// the failed message isn't deleted, it is visible again after the retry delay
+	assert.ElementsMatch(t, []string{"rh-1", "rh-3"}, client.deleted)
+	assert.Equal(t, map[string][]int32{"rh-2": {10}}, client.visibility)
+}
+
+func TestPoller_ExtendVisibility(t *testing.T) {
+
+	client := &testClient{batches: [][]types.Message{{message("1", "ok")}}, visibility: make(map[string][]int32)}
+
+	p, err := initPoller(map[string]interface{}{"visibilityTimeout": 2, "extendVisibility": true}, handle(1500*time.Millisecond, nil))
+	assert.Nil(t, err)
+	p.client = client
+
+	p.handle(client.batches[0][0])
+
+	assert.Equal(t, []string{"rh-1"}, client.deleted)
+	assert.Equal(t, map[string][]int32{"rh-1": {2}}, client.visibility)
+}
+
+func TestNewPoller(t *testing.T) {
+
+	p, err := initPoller(nil, handle(0, nil))
+	assert.Nil(t, err)
+	assert.Equal(t, 10, p.settings.MaxMessages)
+	assert.Equal(t, 20, p.settings.WaitTime)
+	assert.Equal(t, 1, p.settings.Concurrency)
+	assert.Equal(t, time.Duration(-1), p.retryDelay)
+
+	_, err = initPoller(map[string]interface{}{"maxMessages": 11}, handle(0, nil))
+	assert.NotNil(t, err)
+
+	_, err = initPoller(map[string]interface{}{"extendVisibility": true}, handle(0, nil))
+	assert.NotNil(t, err)
+
+	_, err = initPoller(map[string]interface{}{"retryDelay": "soon"}, handle(0, nil))
+	assert.NotNil(t, err)
+}
+
+func TestToOutput(t *testing.T) {
+
+	msg := message("1", `{"id":1}`)
+	msg.MessageAttributes = map[string]types.MessageAttributeValue{"tenant": {DataType: aws.String("String"), StringValue: aws.String("acme")}}
+	msg.Attributes = map[string]string{"ApproximateReceiveCount": "3", "SentTimestamp": "1551434400000"}
+
+	out := toOutput(msg)
+	assert.Equal(t, "1", out.MessageID)
+	assert.Equal(t, map[string]interface{}{"id": 1.0}, out.Content)
+	assert.Equal(t, map[string]string{"tenant": "acme"}, out.Attributes)
+	assert.Equal(t, "1551434400000", out.SystemAttributes["SentTimestamp"])
+	assert.Equal(t, 3, out.ReceiveCount)
+}
@@ -0,0 +1,62 @@
+package sseclient
+
+import (
+	"flogo/core/data/coerce"
+)
+
+type Settings struct {
+	CAFile             string `md:"caFile"`             // The PEM file of the CA certificates used to verify the servers
+	CertFile           string `md:"certFile"`           // The PEM file of the client certificate, for servers requiring client authentication
+	KeyFile            string `md:"keyFile"`            // The PEM file of the client private key
+	InsecureSkipVerify bool   `md:"insecureSkipVerify"` // Don't verify the server certificates, for testing only
+}
+
+type HandlerSettings struct {
+	URL         string            `md:"url,required"` // The url of the event stream (ex. https://api.example.com/events)
+	Headers     map[string]string `md:"headers"`      // The HTTP headers of the requests (ex. Authorization)
+	Events      string            `md:"events"`       // The comma separated types of the events handled, defaults to all the events
+	LastEventID string            `md:"lastEventId"`  // The id of the last event already received, sent when first connecting
+	RetryDelay  string            `md:"retryDelay"`   // How long to wait before reconnecting (ex. 5s), defaults to 3s unless set by the server
+}
+
+type Output struct {
+	URL     string      `md:"url"`     // The url of the event stream
+	Event   string      `md:"event"`   // The type of the event, 'message' unless set by the server
+	ID      string      `md:"id"`      // The last event id set by the server
+	Data    string      `md:"data"`    // The data of the event
+	Content interface{} `md:"content"` // The data of the event parsed, when it is JSON
+}
+
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"url":     o.URL,
+		"event":   o.Event,
+		"id":      o.ID,
+		"data":    o.Data,
+		"content": o.Content,
+	}
+}
+
+func (o *Output) FromMap(values map[string]interface{}) error {
+
+	var err error
+	o.URL, err = coerce.ToString(values["url"])
+	if err != nil {
+		return err
+	}
+	o.Event, err = coerce.ToString(values["event"])
+	if err != nil {
+		return err
+	}
+	o.ID, err = coerce.ToString(values["id"])
+	if err != nil {
+		return err
+	}
+	o.Data, err = coerce.ToString(values["data"])
+	if err != nil {
+		return err
+	}
+	o.Content = values["content"]
+
+	return nil
+}
@@ -0,0 +1,289 @@
+package sseclient
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+)
+
+const (
+	defaultRetryDelay = 3 * time.Second
+	maxRetryDelay     = time.Minute
+	maxLineSize       = 1024 * 1024
+)
+
+// errNoContent is returned when the server replies 204, telling the client not to reconnect
+var errNoContent = errors.New("server replied no content")
+
+// stream consumes the event stream of a handler, it reconnects when the connection is lost and
+// resumes after the last event id received
+type stream struct {
+	client   *http.Client
+	handler  trigger.Handler
+	settings *HandlerSettings
+	logger   log.Logger
+
+	events      map[string]bool
+	retryDelay  time.Duration
+	lastEventID string
+	done        chan struct{}
+}
+
+// event is an event being parsed
+type event struct {
+	typ  string
+	data bytes.Buffer
+}
+
+func newStream(client *http.Client, handler trigger.Handler, s *HandlerSettings, logger log.Logger) (*stream, error) {
+
+	st := &stream{client: client, handler: handler, settings: s, logger: logger, retryDelay: defaultRetryDelay, lastEventID: s.LastEventID}
+
+	if s.RetryDelay != "" {
+		delay, err := time.ParseDuration(s.RetryDelay)
+		if err != nil {
+			return nil, fmt.Errorf("invalid retry delay '%s': %v", s.RetryDelay, err)
+		}
+		st.retryDelay = delay
+	}
+
+	if events := splitList(s.Events); len(events) > 0 {
+		st.events = make(map[string]bool, len(events))
+		for _, e := range events {
+			st.events[e] = true
+		}
+	}
+
+	return st, nil
+}
+
+// run consumes the stream until the context is done, the delay between failed connections doubles up to a minute
+func (st *stream) run(ctx context.Context) {
+
+	defer close(st.done)
+
+	url := st.settings.URL
+	failures := 0
+
+	for ctx.Err() == nil {
+		received, err := st.consume(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if err == errNoContent {
+			st.logger.Infof("Event stream '%s' ended by the server", url)
+			return
+		}
+
+		if received {
+			failures = 0
+		} else {
+			failures++
+		}
+
+		delay := st.retryDelay
+		for i := 1; i < failures && delay < maxRetryDelay; i++ {
+			delay *= 2
+		}
+		if delay > maxRetryDelay && st.retryDelay < maxRetryDelay {
+			delay = maxRetryDelay
+		}
+
+		if err != nil {
+			st.logger.Errorf("Error reading event stream '%s', reconnecting in %v: %v", url, delay, err)
+		} else {
+			st.logger.Debugf("Event stream '%s' closed, reconnecting in %v", url, delay)
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(delay):
+		}
+	}
+}
+
+// wait waits for the stream to stop, at most for the timeout
+func (st *stream) wait(timeout time.Duration) {
+
+	if st.done == nil {
+		return
+	}
+
+	select {
+	case <-st.done:
+	case <-time.After(timeout):
+		st.logger.Warnf("Events of stream '%s' still being handled", st.settings.URL)
+	}
+}
+
+// consume connects to the stream and dispatches its events until the connection is closed, it returns
+// whether the connection succeeded
+func (st *stream) consume(ctx context.Context) (bool, error) {
+
+	s := st.settings
+
+	req, err := http.NewRequest(http.MethodGet, s.URL, nil)
+	if err != nil {
+		return false, err
+	}
+	req = req.WithContext(ctx)
+
+	for name, value := range s.Headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	if st.lastEventID != "" {
+		req.Header.Set("Last-Event-ID", st.lastEventID)
+	}
+
+	resp, err := st.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return false, errNoContent
+	}
+	if resp.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "text/event-stream" {
+		return false, fmt.Errorf("unexpected content type '%s'", resp.Header.Get("Content-Type"))
+	}
+
+	st.logger.Debugf("Connected to event stream '%s'", s.URL)
+
+	return true, st.read(resp.Body)
+}
+
+// read parses the events of the stream, as specified by the HTML Server-Sent Events standard
+func (st *stream) read(r io.Reader) error {
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 4096), maxLineSize)
+	scanner.Split(scanLines)
+
+	e := &event{}
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if line == "" {
+			st.dispatch(e)
+			e = &event{}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			// comment, used as keep-alive
+			continue
+		}
+
+		field, value := line, ""
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+		}
+
+		switch field {
+		case "event":
+			e.typ = value
+		case "data":
+			e.data.WriteString(value)
+			e.data.WriteByte('\n')
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				st.lastEventID = value
+			}
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+				st.retryDelay = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+
+	// an incomplete event at the end of the stream is discarded
+	return scanner.Err()
+}
+
+func (st *stream) dispatch(e *event) {
+
+	if e.data.Len() == 0 {
+		return
+	}
+
+	out := &Output{URL: st.settings.URL, Event: e.typ, ID: st.lastEventID}
+	if out.Event == "" {
+		out.Event = "message"
+	}
+	if st.events != nil && !st.events[out.Event] {
+		return
+	}
+
+	data := e.data.Bytes()
+	out.Data = string(data[:len(data)-1])
+
+	var content interface{}
+	if json.Unmarshal(data, &content) == nil {
+		out.Content = content
+	}
+
+	_, err := st.handler.Handle(context.Background(), out)
+	if err != nil {
+		st.logger.Errorf("Error handling event '%s' of stream '%s': %v", out.ID, st.settings.URL, err)
+	}
+}
+
+// scanLines splits the lines ended by \r\n, \n or \r
+func scanLines(data []byte, atEOF bool) (int, []byte, error) {
+
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\n' {
+			return i + 1, data[:i], nil
+		}
+		// \r, the next byte is needed to know whether it is followed by \n
+		if i+1 < len(data) {
+			if data[i+1] == '\n' {
+				return i + 2, data[:i], nil
+			}
+			return i + 1, data[:i], nil
+		}
+		if atEOF {
+			return i + 1, data[:i], nil
+		}
+		return 0, nil, nil
+	}
+
+	if atEOF {
+		return len(data), data, nil
+	}
+
+	return 0, nil, nil
+}
+
+func splitList(list string) []string {
+
+	var values []string
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
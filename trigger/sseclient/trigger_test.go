@@ -0,0 +1,199 @@
+package sseclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"flogo/core/action"
+	"flogo/core/api"
+	"flogo/core/support/log"
+	"flogo/core/support/test"
+	"flogo/core/trigger"
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig string = `{
+	"id": "trigger-sseclient",
+	"ref": "github.com/qingcloudhx/contrib/trigger/sseclient",
+	"settings": {
+	},
+	"handlers": [
+	  {
+		"settings": {
+		  "url": "http://localhost/events"
+		},
+		"action": {
+		  "id": "test"
+		}
+	  }
+	]
+}`
+
+// send returns a handler function sending the outputs it handles
+func send(outputs chan *Output) api.HandlerFunc {
+	return func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+		out := &Output{}
+		if err := out.FromMap(inputs); err != nil {
+			return nil, err
+		}
+		outputs <- out
+		return nil, nil
+	}
+}
+
+func next(t *testing.T, outputs chan *Output) *Output {
+	select {
+	case out := <-outputs:
+		return out
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event handled")
+		return nil
+	}
+}
+
+// initTrigger returns a trigger initialized with a handler of the settings running the function
+func initTrigger(settings map[string]interface{}, f api.HandlerFunc) (*Trigger, error) {
+
+	config := &trigger.Config{}
+	if err := json.Unmarshal([]byte(testConfig), config); err != nil {
+		return nil, err
+	}
+	config.Handlers[0].Settings = settings
+
+	trg, err := test.InitTrigger(&Factory{}, config, map[string]action.Action{"test": api.NewProxyAction(f)})
+	if err != nil {
+		return nil, err
+	}
+	return trg.(*Trigger), nil
+}
+
+func TestRead(t *testing.T) {
+	outputs := make(chan *Output, 10)
+	trg, err := initTrigger(map[string]interface{}{"url": "http://localhost/events", "events": "message, update"}, send(outputs))
+	assert.Nil(t, err)
+	st := trg.streams[0]
+
+	input := ": keep-alive\n\n" +
+		"data: first\r\ndata:second\r\n\r\n" +
+		"event: update\rid: 7\rdata: {\"id\":1}\r\r" +
+		"event: ignored\ndata: filtered\n\n" +
+		"id: 8\nretry: 250\n\n" +
+		"data\n\n" +
+		"data: incomplete"
+	assert.Nil(t, st.read(strings.NewReader(input)))
+
+	out := next(t, outputs)
+	assert.Equal(t, "message", out.Event)
+	assert.Equal(t, "first\nsecond", out.Data)
+	assert.Equal(t, "", out.ID)
+	assert.Nil(t, out.Content)
+	assert.Equal(t, "http://localhost/events", out.URL)
+
+	out = next(t, outputs)
+	assert.Equal(t, "update", out.Event)
+	assert.Equal(t, "7", out.ID)
+	assert.Equal(t, map[string]interface{}{"id": 1.0}, out.Content)
+
+	// an event with an empty data field
+	out = next(t, outputs)
+	assert.Equal(t, "", out.Data)
+	assert.Equal(t, "8", out.ID)
+
+	assert.Equal(t, 0, len(outputs))
+	assert.Equal(t, "8", st.lastEventID)
+	assert.Equal(t, 250*time.Millisecond, st.retryDelay)
+}
+
+func TestTrigger(t *testing.T) {
+	var mu sync.Mutex
+	var lastEventIDs []string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		lastEventIDs = append(lastEventIDs, r.Header.Get("Last-Event-ID"))
+		connection := len(lastEventIDs)
+		mu.Unlock()
+
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		assert.Equal(t, "text/event-stream", r.Header.Get("Accept"))
+
+		w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+		flusher := w.(http.Flusher)
+
+		switch connection {
+		case 1:
+			// the connection is lost after two events
+			fmt.Fprint(w, "retry: 10\n\nid: 1\ndata: a\n\nid: 2\ndata: b\n\n")
+			flusher.Flush()
+		case 2:
+			fmt.Fprint(w, "id: 3\ndata: c\n\n")
+			flusher.Flush()
+			<-r.Context().Done()
+		}
+	}))
+	defer srv.Close()
+
+	outputs := make(chan *Output, 10)
+	tgr, err := initTrigger(map[string]interface{}{"url": srv.URL, "headers": map[string]string{"Authorization": "Bearer token"}, "lastEventId": "0"}, send(outputs))
+	assert.Nil(t, err)
+	assert.Nil(t, tgr.Start())
+
+	for _, data := range []string{"a", "b", "c"} {
+		assert.Equal(t, data, next(t, outputs).Data)
+	}
+	assert.Nil(t, tgr.Stop())
+
+	// the stream is resumed after the last event received
+	mu.Lock()
+	assert.Equal(t, []string{"0", "2"}, lastEventIDs)
+	mu.Unlock()
+}
+
+func TestTriggerNoContent(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	st, err := newStream(http.DefaultClient, nil, &HandlerSettings{URL: srv.URL}, log.RootLogger())
+	assert.Nil(t, err)
+
+	// the stream isn't reconnected
+	st.done = make(chan struct{})
+	go st.run(context.Background())
+	select {
+	case <-st.done:
+	case <-time.After(time.Second):
+		t.Fatal("stream still running")
+	}
+}
+
+func TestConsumeErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/json" {
+			w.Header().Set("Content-Type", "application/json")
+			return
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	for _, path := range []string{"/json", "/unauthorized"} {
+		st, err := newStream(http.DefaultClient, nil, &HandlerSettings{URL: srv.URL + path}, log.RootLogger())
+		assert.Nil(t, err)
+
+		received, err := st.consume(context.Background())
+		assert.False(t, received)
+		assert.NotNil(t, err)
+	}
+
+	_, err := newStream(http.DefaultClient, nil, &HandlerSettings{URL: srv.URL, RetryDelay: "later"}, log.RootLogger())
+	assert.NotNil(t, err)
+}
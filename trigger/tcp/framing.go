@@ -0,0 +1,186 @@
+package tcp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+const defaultMaxFrameSize = 1024 * 1024
+
+// framer reads and writes the frames of a connection
+type framer struct {
+	framing      string
+	delimiter    []byte
+	lengthSize   int
+	order        binary.ByteOrder
+	frameSize    int
+	maxFrameSize int
+	encoding     string
+}
+
+func newFramer(s *Settings) (*framer, error) {
+
+	f := &framer{framing: s.Framing, lengthSize: s.LengthSize, frameSize: s.FrameSize, maxFrameSize: s.MaxFrameSize, encoding: s.Encoding, order: binary.BigEndian}
+
+	if f.framing == "" {
+		f.framing = FramingLine
+	}
+	if f.maxFrameSize <= 0 {
+		f.maxFrameSize = defaultMaxFrameSize
+	}
+	if f.encoding == "" {
+		f.encoding = EncodingText
+	}
+	if f.encoding != EncodingText && f.encoding != EncodingHex && f.encoding != EncodingBase64 {
+		return nil, fmt.Errorf("unsupported encoding '%s'", f.encoding)
+	}
+	if s.LittleEndian {
+		f.order = binary.LittleEndian
+	}
+
+	switch f.framing {
+	case FramingLine:
+		f.delimiter = []byte("\n")
+	case FramingDelimiter:
+		if s.Delimiter == "" {
+			return nil, fmt.Errorf("the delimiter framing requires a delimiter")
+		}
+		f.delimiter = []byte(s.Delimiter)
+	case FramingLength:
+		if f.lengthSize == 0 {
+			f.lengthSize = 4
+		}
+		if f.lengthSize != 1 && f.lengthSize != 2 && f.lengthSize != 4 {
+			return nil, fmt.Errorf("length size must be 1, 2 or 4")
+		}
+	case FramingFixed:
+		if f.frameSize <= 0 {
+			return nil, fmt.Errorf("the fixed framing requires a frame size")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported framing '%s'", f.framing)
+	}
+
+	return f, nil
+}
+
+// read reads the next frame, without its delimiter or length prefix
+func (f *framer) read(r *bufio.Reader) ([]byte, error) {
+
+	switch f.framing {
+	case FramingLength:
+		prefix := make([]byte, f.lengthSize)
+		_, err := io.ReadFull(r, prefix)
+		if err != nil {
+			return nil, err
+		}
+
+		var size int
+		switch f.lengthSize {
+		case 1:
+			size = int(prefix[0])
+		case 2:
+			size = int(f.order.Uint16(prefix))
+		default:
+			size = int(f.order.Uint32(prefix))
+		}
+		if size > f.maxFrameSize {
+			return nil, fmt.Errorf("frame of %d bytes exceeds the max frame size", size)
+		}
+
+		frame := make([]byte, size)
+		_, err = io.ReadFull(r, frame)
+		return frame, err
+	case FramingFixed:
+		frame := make([]byte, f.frameSize)
+		_, err := io.ReadFull(r, frame)
+		return frame, err
+	}
+
+	// the frame is read until the last byte of the delimiter, until it ends with the whole delimiter
+	last := f.delimiter[len(f.delimiter)-1]
+	var frame []byte
+	for {
+		chunk, err := r.ReadSlice(last)
+		if len(frame)+len(chunk) > f.maxFrameSize+len(f.delimiter) {
+			return nil, fmt.Errorf("frame exceeds the max frame size")
+		}
+		frame = append(frame, chunk...)
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if bytes.HasSuffix(frame, f.delimiter) {
+			frame = frame[:len(frame)-len(f.delimiter)]
+			break
+		}
+	}
+
+	if f.framing == FramingLine {
+		frame = bytes.TrimSuffix(frame, []byte("\r"))
+	}
+
+	return frame, nil
+}
+
+// frame returns the frame of the data, with its delimiter or length prefix
+func (f *framer) frame(data []byte) ([]byte, error) {
+
+	switch f.framing {
+	case FramingLength:
+		size := len(data)
+		if f.lengthSize < 4 && size >= 1<<(8*uint(f.lengthSize)) {
+			return nil, fmt.Errorf("frame of %d bytes exceeds the length prefix", size)
+		}
+
+		frame := make([]byte, f.lengthSize, f.lengthSize+size)
+		switch f.lengthSize {
+		case 1:
+			frame[0] = byte(size)
+		case 2:
+			f.order.PutUint16(frame, uint16(size))
+		default:
+			f.order.PutUint32(frame, uint32(size))
+		}
+		return append(frame, data...), nil
+	case FramingFixed:
+		if len(data) != f.frameSize {
+			return nil, fmt.Errorf("frame of %d bytes instead of %d", len(data), f.frameSize)
+		}
+		return data, nil
+	}
+
+	frame := make([]byte, 0, len(data)+len(f.delimiter))
+	return append(append(frame, data...), f.delimiter...), nil
+}
+
+func (f *framer) encode(frame []byte) string {
+
+	switch f.encoding {
+	case EncodingHex:
+		return hex.EncodeToString(frame)
+	case EncodingBase64:
+		return base64.StdEncoding.EncodeToString(frame)
+	}
+
+	return string(frame)
+}
+
+func (f *framer) decode(data string) ([]byte, error) {
+
+	switch f.encoding {
+	case EncodingHex:
+		return hex.DecodeString(data)
+	case EncodingBase64:
+		return base64.StdEncoding.DecodeString(data)
+	}
+
+	return []byte(data), nil
+}
@@ -0,0 +1,103 @@
+package tcp
+
+import (
+	"flogo/core/data/coerce"
+)
+
+const (
+	EventConnect    = "connect"
+	EventData       = "data"
+	EventDisconnect = "disconnect"
+
+	FramingLine      = "line"
+	FramingDelimiter = "delimiter"
+	FramingLength    = "length"
+	FramingFixed     = "fixed"
+
+	EncodingText   = "text"
+	EncodingHex    = "hex"
+	EncodingBase64 = "base64"
+)
+
+type Settings struct {
+	Host         string `md:"host"`                                         // The host name or IP to listen on, defaults to all the interfaces
+	Port         int    `md:"port,required"`                                // The port to listen on
+	Framing      string `md:"framing,allowed(line,delimiter,length,fixed)"` // How the frames are delimited: line (default), delimiter, length or fixed
+	Delimiter    string `md:"delimiter"`                                    // The delimiter ending the frames with the delimiter framing (ex. \r\n)
+	LengthSize   int    `md:"lengthSize"`                                   // The size in bytes of the length prefixing the frames with the length framing: 1, 2 or 4 (default)
+	LittleEndian bool   `md:"littleEndian"`                                 // The length prefix is little-endian, instead of big-endian
+	FrameSize    int    `md:"frameSize"`                                    // The size in bytes of the frames with the fixed framing
+	MaxFrameSize int    `md:"maxFrameSize"`                                 // The max size in bytes of a frame, the connection is closed when exceeded, defaults to 1MB
+	Encoding     string `md:"encoding,allowed(text,hex,base64)"`            // How the binary frames are encoded in the data: text (default), hex or base64
+	IdleTimeout  string `md:"idleTimeout"`                                  // How long a connection stays open without receiving data (ex. 5m), never closed if not specified
+}
+
+type HandlerSettings struct {
+	Events string `md:"events"` // The comma separated events of the connections handled: connect, data and disconnect, defaults to data
+}
+
+type Output struct {
+	Event         string `md:"event"`         // The event of the connection: connect, data or disconnect
+	ConnectionID  string `md:"connectionId"`  // The id of the connection
+	RemoteAddress string `md:"remoteAddress"` // The address of the client (ex. 10.0.0.5:49152)
+	Data          string `md:"data"`          // The frame received, without its delimiter or length prefix, encoded with the encoding
+}
+
+type Reply struct {
+	Data  string `md:"data"`  // The frame to write back on the connection, encoded with the encoding, it is framed like the received frames
+	Close bool   `md:"close"` // Close the connection, once the data is written
+}
+
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"event":         o.Event,
+		"connectionId":  o.ConnectionID,
+		"remoteAddress": o.RemoteAddress,
+		"data":          o.Data,
+	}
+}
+
+func (o *Output) FromMap(values map[string]interface{}) error {
+
+	var err error
+	o.Event, err = coerce.ToString(values["event"])
+	if err != nil {
+		return err
+	}
+	o.ConnectionID, err = coerce.ToString(values["connectionId"])
+	if err != nil {
+		return err
+	}
+	o.RemoteAddress, err = coerce.ToString(values["remoteAddress"])
+	if err != nil {
+		return err
+	}
+	o.Data, err = coerce.ToString(values["data"])
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *Reply) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"data":  r.Data,
+		"close": r.Close,
+	}
+}
+
+func (r *Reply) FromMap(values map[string]interface{}) error {
+
+	var err error
+	r.Data, err = coerce.ToString(values["data"])
+	if err != nil {
+		return err
+	}
+	r.Close, err = coerce.ToBool(values["close"])
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,294 @@
+package tcp
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"flogo/core/data/metadata"
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+)
+
+var triggerMd = trigger.NewMetadata(&Settings{}, &HandlerSettings{}, &Output{}, &Reply{})
+
+func init() {
+	_ = trigger.Register(&Trigger{}, &Factory{})
+}
+
+type Factory struct {
+}
+
+// Metadata implements trigger.Factory.Metadata
+func (*Factory) Metadata() *trigger.Metadata {
+	return triggerMd
+}
+
+// New implements trigger.Factory.New
+func (*Factory) New(config *trigger.Config) (trigger.Trigger, error) {
+
+	s := &Settings{}
+	err := metadata.MapToStruct(config.Settings, s, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Trigger{settings: s}, nil
+}
+
+// Trigger is a TCP server invoking the actions for the frames received on its connections
+type Trigger struct {
+	settings    *Settings
+	logger      log.Logger
+	framer      *framer
+	idleTimeout time.Duration
+	handlers    []*tcpHandler
+
+	listener    net.Listener
+	mu          sync.Mutex
+	connections map[net.Conn]bool
+	wg          sync.WaitGroup
+}
+
+type tcpHandler struct {
+	handler trigger.Handler
+	events  map[string]bool
+}
+
+// Initialize implements trigger.Init.Initialize
+func (t *Trigger) Initialize(ctx trigger.InitContext) error {
+
+	t.logger = ctx.Logger()
+
+	var err error
+	t.framer, err = newFramer(t.settings)
+	if err != nil {
+		return err
+	}
+
+	if t.settings.IdleTimeout != "" {
+		t.idleTimeout, err = time.ParseDuration(t.settings.IdleTimeout)
+		if err != nil {
+			return fmt.Errorf("invalid idle timeout '%s': %v", t.settings.IdleTimeout, err)
+		}
+	}
+
+	for _, handler := range ctx.GetHandlers() {
+
+		s := &HandlerSettings{}
+		err := metadata.MapToStruct(handler.Settings(), s, true)
+		if err != nil {
+			return err
+		}
+
+		h := &tcpHandler{handler: handler, events: make(map[string]bool)}
+		events := splitList(s.Events)
+		if len(events) == 0 {
+			events = []string{EventData}
+		}
+		for _, event := range events {
+			if event != EventConnect && event != EventData && event != EventDisconnect {
+				return fmt.Errorf("unsupported event '%s'", event)
+			}
+			h.events[event] = true
+		}
+		t.handlers = append(t.handlers, h)
+	}
+
+	return nil
+}
+
+// Start implements util.Managed.Start
+func (t *Trigger) Start() error {
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(t.settings.Host, strconv.Itoa(t.settings.Port)))
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	t.listener = listener
+	t.connections = make(map[net.Conn]bool)
+	t.mu.Unlock()
+
+	t.logger.Infof("Listening on %s", listener.Addr())
+
+	t.wg.Add(1)
+	go t.accept(listener)
+
+	return nil
+}
+
+// Stop implements util.Managed.Stop
+func (t *Trigger) Stop() error {
+
+	t.mu.Lock()
+	if t.listener != nil {
+		_ = t.listener.Close()
+		t.listener = nil
+	}
+	for conn := range t.connections {
+		_ = conn.Close()
+	}
+	t.mu.Unlock()
+
+	t.wg.Wait()
+
+	return nil
+}
+
+func (t *Trigger) accept(listener net.Listener) {
+
+	defer t.wg.Done()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			t.mu.Lock()
+			stopped := t.listener == nil
+			t.mu.Unlock()
+			if !stopped {
+				t.logger.Errorf("Error accepting connection: %v", err)
+			}
+			return
+		}
+
+		t.mu.Lock()
+		if t.listener == nil {
+			t.mu.Unlock()
+			_ = conn.Close()
+			return
+		}
+		t.connections[conn] = true
+		t.wg.Add(1)
+		t.mu.Unlock()
+
+		go t.serve(conn)
+	}
+}
+
+// serve reads the frames of a connection one after the other, so that the replies are written in order
+func (t *Trigger) serve(conn net.Conn) {
+
+	defer t.wg.Done()
+
+	out := &Output{ConnectionID: newConnectionID(), RemoteAddress: conn.RemoteAddr().String()}
+	t.logger.Debugf("Connection [%s] opened from %s", out.ConnectionID, out.RemoteAddress)
+
+	open := t.invoke(conn, out, EventConnect, nil)
+
+	r := bufio.NewReader(conn)
+	for open {
+		if t.idleTimeout > 0 {
+			_ = conn.SetReadDeadline(time.Now().Add(t.idleTimeout))
+		}
+
+		frame, err := t.framer.read(r)
+		if err != nil {
+			if err != io.EOF && !isClosed(err) {
+				t.logger.Debugf("Connection [%s] closed: %v", out.ConnectionID, err)
+			}
+			break
+		}
+
+		open = t.invoke(conn, out, EventData, frame)
+	}
+
+	t.mu.Lock()
+	delete(t.connections, conn)
+	t.mu.Unlock()
+	_ = conn.Close()
+
+	t.invoke(conn, out, EventDisconnect, nil)
+}
+
+// invoke invokes the actions of the handlers of an event of the connection, writes their replies and returns
+// false when the connection has to be closed
+func (t *Trigger) invoke(conn net.Conn, out *Output, event string, frame []byte) bool {
+
+	open := true
+
+	for _, h := range t.handlers {
+		if !h.events[event] {
+			continue
+		}
+
+		eventOut := *out
+		eventOut.Event = event
+		if frame != nil {
+			eventOut.Data = t.framer.encode(frame)
+		}
+
+		results, err := h.handler.Handle(context.Background(), &eventOut)
+		if err != nil {
+			t.logger.Errorf("Error handling %s of connection [%s]: %v", event, out.ConnectionID, err)
+			continue
+		}
+		if event == EventDisconnect {
+			continue
+		}
+
+		reply := &Reply{}
+		err = reply.FromMap(results)
+		if err != nil {
+			t.logger.Errorf("Invalid reply to %s of connection [%s]: %v", event, out.ConnectionID, err)
+			continue
+		}
+
+		if reply.Data != "" {
+			err = t.write(conn, reply.Data)
+			if err != nil {
+				t.logger.Errorf("Error writing reply to connection [%s]: %v", out.ConnectionID, err)
+			}
+		}
+		if reply.Close {
+			open = false
+		}
+	}
+
+	return open
+}
+
+func (t *Trigger) write(conn net.Conn, data string) error {
+
+	decoded, err := t.framer.decode(data)
+	if err != nil {
+		return fmt.Errorf("invalid %s data: %v", t.framer.encoding, err)
+	}
+
+	frame, err := t.framer.frame(decoded)
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.Write(frame)
+	return err
+}
+
+func isClosed(err error) bool {
+	return strings.Contains(err.Error(), "use of closed network connection")
+}
+
+func newConnectionID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func splitList(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
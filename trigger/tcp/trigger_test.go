@@ -0,0 +1,251 @@
+package tcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"flogo/core/action"
+	"flogo/core/api"
+	"flogo/core/support/test"
+	"flogo/core/trigger"
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig string = `{
+	"id": "trigger-tcp",
+	"ref": "github.com/qingcloudhx/contrib/trigger/tcp",
+	"settings": {
+	  "port": 0
+	},
+	"handlers": [
+	  {
+		"settings": {
+		},
+		"action": {
+		  "id": "test"
+		}
+	  }
+	]
+}`
+
+// send returns a handler function sending the outputs it handles and replying with the result of the function
+func send(outputs chan *Output, reply func(out *Output) map[string]interface{}) api.HandlerFunc {
+	return func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+		out := &Output{}
+		if err := out.FromMap(inputs); err != nil {
+			return nil, err
+		}
+		outputs <- out
+		if reply == nil {
+			return nil, nil
+		}
+		return reply(out), nil
+	}
+}
+
+func next(t *testing.T, outputs chan *Output) *Output {
+	select {
+	case out := <-outputs:
+		return out
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event handled")
+		return nil
+	}
+}
+
+// initTrigger returns a trigger initialized with the settings and a handler of the settings running the function
+func initTrigger(settings, handlerSettings map[string]interface{}, f api.HandlerFunc) (*Trigger, error) {
+
+	config := &trigger.Config{}
+	if err := json.Unmarshal([]byte(testConfig), config); err != nil {
+		return nil, err
+	}
+	for name, value := range settings {
+		config.Settings[name] = value
+	}
+	config.Handlers[0].Settings = handlerSettings
+
+	trg, err := test.InitTrigger(&Factory{}, config, map[string]action.Action{"test": api.NewProxyAction(f)})
+	if err != nil {
+		return nil, err
+	}
+	return trg.(*Trigger), nil
+}
+
+// startTrigger starts a trigger listening on a free port and returns a connection to it
+func startTrigger(t *testing.T, settings, handlerSettings map[string]interface{}, f api.HandlerFunc) (*Trigger, net.Conn) {
+
+	tgr, err := initTrigger(settings, handlerSettings, f)
+	assert.Nil(t, err)
+	assert.Nil(t, tgr.Start())
+
+	conn, err := net.Dial("tcp", tgr.listener.Addr().String())
+	assert.Nil(t, err)
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	return tgr, conn
+}
+
+func TestFramer(t *testing.T) {
+	tests := []struct {
+		settings *Settings
+		input    string
+		frames   []string
+	}{
+		{&Settings{}, "a\nbc\r\n\n", []string{"a", "bc", ""}},
+		{&Settings{Framing: FramingDelimiter, Delimiter: "||"}, "a|b||c||", []string{"a|b", "c"}},
+		{&Settings{Framing: FramingLength}, "\x00\x00\x00\x02ab\x00\x00\x00\x00", []string{"ab", ""}},
+		{&Settings{Framing: FramingLength, LengthSize: 2, LittleEndian: true}, "\x03\x00abc", []string{"abc"}},
+		{&Settings{Framing: FramingLength, LengthSize: 1}, "\x01a", []string{"a"}},
+		{&Settings{Framing: FramingFixed, FrameSize: 3}, "abcdef", []string{"abc", "def"}},
+	}
+
+	for _, test := range tests {
+		f, err := newFramer(test.settings)
+		assert.Nil(t, err)
+
+		var frames []string
+		var written bytes.Buffer
+		r := bufio.NewReader(strings.NewReader(test.input))
+		for {
+			frame, err := f.read(r)
+			if err != nil {
+				break
+			}
+			frames = append(frames, string(frame))
+
+			data, err := f.frame(frame)
+			assert.Nil(t, err)
+			written.Write(data)
+		}
+		assert.Equal(t, test.frames, frames)
+
+		// the frames are written back as read, except the line endings
+		if test.settings.Framing != "" {
+			assert.Equal(t, test.input, written.String())
+		}
+	}
+}
+
+func TestFramerErrors(t *testing.T) {
+	_, err := newFramer(&Settings{Framing: FramingDelimiter})
+	assert.NotNil(t, err)
+	_, err = newFramer(&Settings{Framing: FramingLength, LengthSize: 3})
+	assert.NotNil(t, err)
+	_, err = newFramer(&Settings{Framing: FramingFixed})
+	assert.NotNil(t, err)
+	_, err = newFramer(&Settings{Encoding: "ascii"})
+	assert.NotNil(t, err)
+
+	f, err := newFramer(&Settings{MaxFrameSize: 4})
+	assert.Nil(t, err)
+	_, err = f.read(bufio.NewReader(strings.NewReader("abcdefgh\n")))
+	assert.NotNil(t, err)
+
+	f, err = newFramer(&Settings{Framing: FramingLength, MaxFrameSize: 4})
+	assert.Nil(t, err)
+	_, err = f.read(bufio.NewReader(strings.NewReader("\x00\x00\x00\x05abcde")))
+	assert.NotNil(t, err)
+
+	f, err = newFramer(&Settings{Framing: FramingLength, LengthSize: 1})
+	assert.Nil(t, err)
+	_, err = f.frame(make([]byte, 256))
+	assert.NotNil(t, err)
+
+	f, err = newFramer(&Settings{Framing: FramingFixed, FrameSize: 2})
+	assert.Nil(t, err)
+	_, err = f.frame([]byte("abc"))
+	assert.NotNil(t, err)
+}
+
+func TestTriggerLines(t *testing.T) {
+	outputs := make(chan *Output, 10)
+	tgr, conn := startTrigger(t, nil, map[string]interface{}{"events": "connect,data,disconnect"}, send(outputs, func(out *Output) map[string]interface{} {
+		switch {
+		case out.Event == EventConnect:
+			return map[string]interface{}{"data": "welcome"}
+		case out.Data == "quit":
+			return map[string]interface{}{"data": "bye", "close": true}
+		}
+		return map[string]interface{}{"data": strings.ToUpper(out.Data)}
+	}))
+	defer tgr.Stop()
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	line, err := r.ReadString('\n')
+	assert.Nil(t, err)
+	assert.Equal(t, "welcome\n", line)
+
+	connect := next(t, outputs)
+	assert.Equal(t, EventConnect, connect.Event)
+	assert.Equal(t, conn.LocalAddr().String(), connect.RemoteAddress)
+
+	_, err = conn.Write([]byte("hello\r\nworld\nquit\nignored\n"))
+	assert.Nil(t, err)
+
+	for _, expected := range []string{"HELLO\n", "WORLD\n", "bye\n"} {
+		line, err = r.ReadString('\n')
+		assert.Nil(t, err)
+		assert.Equal(t, expected, line)
+	}
+
+	out := next(t, outputs)
+	assert.Equal(t, EventData, out.Event)
+	assert.Equal(t, "hello", out.Data)
+	assert.Equal(t, connect.ConnectionID, out.ConnectionID)
+	assert.Equal(t, "world", next(t, outputs).Data)
+	assert.Equal(t, "quit", next(t, outputs).Data)
+
+	// the connection is closed after the reply
+	assert.Equal(t, EventDisconnect, next(t, outputs).Event)
+	_, err = r.ReadString('\n')
+	assert.NotNil(t, err)
+}
+
+func TestTriggerLengthHex(t *testing.T) {
+	outputs := make(chan *Output, 10)
+	tgr, conn := startTrigger(t, map[string]interface{}{"framing": "length", "lengthSize": 2, "encoding": "hex"}, nil, send(outputs, func(out *Output) map[string]interface{} {
+		return map[string]interface{}{"data": "06" + out.Data}
+	}))
+	defer tgr.Stop()
+	defer conn.Close()
+
+	_, err := conn.Write([]byte{0x00, 0x02, 0x01, 0xff})
+	assert.Nil(t, err)
+
+	reply := make([]byte, 5)
+	_, err = io.ReadFull(conn, reply)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x00, 0x03, 0x06, 0x01, 0xff}, reply)
+
+	out := next(t, outputs)
+	assert.Equal(t, EventData, out.Event)
+	assert.Equal(t, "01ff", out.Data)
+}
+
+func TestTriggerStop(t *testing.T) {
+	outputs := make(chan *Output, 10)
+	tgr, conn := startTrigger(t, map[string]interface{}{"idleTimeout": "1m"}, map[string]interface{}{"events": "disconnect"}, send(outputs, nil))
+	defer conn.Close()
+
+	// the open connections are closed
+	time.Sleep(50 * time.Millisecond)
+	assert.Nil(t, tgr.Stop())
+	assert.Equal(t, EventDisconnect, next(t, outputs).Event)
+
+	_, err := bufio.NewReader(conn).ReadByte()
+	assert.NotNil(t, err)
+}
+
+func TestTriggerInvalidEvents(t *testing.T) {
+	_, err := initTrigger(nil, map[string]interface{}{"events": "message"}, send(nil, nil))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,141 @@
+package timer
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultAdminHost = "127.0.0.1"
+
+// timerStatus is the status of a timer returned by the admin API
+type timerStatus struct {
+	ID      string `json:"id"`
+	Handler string `json:"handler"`
+	Paused  bool   `json:"paused"`
+}
+
+// startAdmin starts the HTTP server of the admin API, it only listens on the loopback interface if no host is specified
+func (t *Trigger) startAdmin(host string, port int) error {
+	if host == "" {
+		host = defaultAdminHost
+	}
+
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("unable to start the admin API: %s", err.Error())
+	}
+
+	if t.settings.AdminToken == "" {
+		t.logger.Warnf("Admin API has no authentication, anyone reaching %s can pause and resume the timers", addr)
+	}
+
+	t.admin = &http.Server{Handler: t.authorize(t.settings.AdminToken, t.adminHandler())}
+	go func(server *http.Server) {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			t.logger.Errorf("Admin API stopped: %s", err.Error())
+		}
+	}(t.admin)
+
+	t.logger.Infof("Admin API listening on %s", addr)
+
+	return nil
+}
+
+func (t *Trigger) stopAdmin() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := t.admin.Shutdown(ctx)
+	t.admin = nil
+
+	return err
+}
+
+// authorize rejects the requests without the bearer token, if a token is specified
+func (t *Trigger) authorize(token string, next http.Handler) http.Handler {
+	if token == "" {
+		return next
+	}
+
+	expected := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="timer"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// adminHandler serves the admin API, GET /timers lists the timers, POST /timers/{id}/pause and
+// POST /timers/{id}/resume pause and resume a timer
+func (t *Trigger) adminHandler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/timers", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(t.statuses())
+	})
+
+	mux.HandleFunc("/timers/", func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/timers/")
+		i := strings.LastIndex(path, "/")
+		if i < 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, action := path[:i], path[i+1:]
+
+		var err error
+		switch action {
+		case "pause":
+			err = t.Pause(id)
+		case "resume":
+			err = t.Resume(id)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	return mux
+}
+
+func (t *Trigger) statuses() []timerStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	statuses := make([]timerStatus, 0, len(t.timers))
+	for id, timer := range t.timers {
+		statuses = append(statuses, timerStatus{ID: id, Handler: timer.handler.Name(), Paused: timer.isPaused()})
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].ID < statuses[j].ID })
+
+	return statuses
+}
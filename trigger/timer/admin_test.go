@@ -0,0 +1,106 @@
+package timer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"flogo/core/support/log"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrigger_Admin(t *testing.T) {
+
+	tgr := &Trigger{logger: log.RootLogger(), timers: map[string]*timer{
+		"counting": {handler: &countingHandler{}},
+	}}
+	server := httptest.NewServer(tgr.adminHandler())
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/timers/counting/pause", "", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, err = http.Get(server.URL + "/timers")
+	assert.Nil(t, err)
+	var statuses []timerStatus
+	err = json.NewDecoder(resp.Body).Decode(&statuses)
+	resp.Body.Close()
+	assert.Nil(t, err)
+	assert.Equal(t, []timerStatus{{ID: "counting", Handler: "counting", Paused: true}}, statuses)
+
+	resp, err = http.Post(server.URL+"/timers/counting/resume", "", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	paused, err := tgr.Paused("counting")
+	assert.Nil(t, err)
+	assert.False(t, paused)
+
+	resp, err = http.Post(server.URL+"/timers/unknown/pause", "", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, err = http.Get(server.URL + "/timers/counting/pause")
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+func TestTrigger_AdminToken(t *testing.T) {
+
+	tgr := &Trigger{logger: log.RootLogger(), timers: map[string]*timer{
+		"counting": {handler: &countingHandler{}},
+	}}
+	server := httptest.NewServer(tgr.authorize("secret", tgr.adminHandler()))
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/timers/counting/pause", "", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/timers/counting/pause", nil)
+	req.Header.Set("Authorization", "Bearer wrong")
+	resp, err = http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	paused, _ := tgr.Paused("counting")
+	assert.False(t, paused)
+
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err = http.DefaultClient.Do(req)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	paused, _ = tgr.Paused("counting")
+	assert.True(t, paused)
+}
+
+func TestTrigger_AdminHost(t *testing.T) {
+
+	tgr := &Trigger{settings: &Settings{AdminToken: "secret"}, logger: log.RootLogger(), timers: map[string]*timer{}}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	err = tgr.startAdmin("", port)
+	assert.Nil(t, err)
+	defer tgr.stopAdmin()
+
+	// only listening on the loopback interface
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	assert.Nil(t, err)
+	conn.Close()
+	if addrs, err := net.InterfaceAddrs(); err == nil {
+		for _, addr := range addrs {
+			if ip, ok := addr.(*net.IPNet); ok && !ip.IP.IsLoopback() && ip.IP.To4() != nil {
+				_, err = net.DialTimeout("tcp", net.JoinHostPort(ip.IP.String(), strconv.Itoa(port)), time.Second)
+				assert.NotNil(t, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package timer
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+)
+
+const consulKeyPrefix = "flogo/timer/lock/"
+
+// consulLock acquires the runs by acquiring a key with a session, the key is deleted when the session expires
+type consulLock struct {
+	client *api.Client
+	owner  string
+}
+
+func newConsulLock(u *url.URL) (*consulLock, error) {
+	config := api.DefaultConfig()
+	config.Address = u.Host
+	config.WaitTime = lockTimeout
+	if u.User != nil {
+		// the ACL token
+		config.Token = u.User.Username()
+	}
+
+	client, err := api.NewClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &consulLock{client: client, owner: lockOwner()}, nil
+}
+
+func (l *consulLock) acquire(key string, ttl time.Duration) (bool, error) {
+	session := l.client.Session()
+
+	id, _, err := session.Create(&api.SessionEntry{
+		Name:     l.owner,
+		TTL:      ttl.String(),
+		Behavior: api.SessionBehaviorDelete,
+	}, nil)
+	if err != nil {
+		return false, err
+	}
+
+	acquired, _, err := l.client.KV().Acquire(&api.KVPair{Key: consulKeyPrefix + key, Value: []byte(l.owner), Session: id}, nil)
+	if err != nil || !acquired {
+		// the session is not used
+		_, _ = session.Destroy(id, nil)
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (l *consulLock) close() error {
+	return nil
+}
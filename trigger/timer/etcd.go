@@ -0,0 +1,59 @@
+package timer
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+const etcdKeyPrefix = "/flogo/timer/lock/"
+
+// etcdLock acquires the runs by creating a key only if it doesn't exist, the key expires with its lease
+type etcdLock struct {
+	client *clientv3.Client
+	owner  string
+}
+
+func newEtcdLock(u *url.URL) (*etcdLock, error) {
+	config := clientv3.Config{Endpoints: endpoints(u), DialTimeout: lockTimeout}
+	if u.User != nil {
+		config.Username = u.User.Username()
+		config.Password, _ = u.User.Password()
+	}
+
+	client, err := clientv3.New(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &etcdLock{client: client, owner: lockOwner()}, nil
+}
+
+func (l *etcdLock) acquire(key string, ttl time.Duration) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), lockTimeout)
+	defer cancel()
+
+	lease, err := l.client.Grant(ctx, int64(ttl/time.Second))
+	if err != nil {
+		return false, err
+	}
+
+	key = etcdKeyPrefix + key
+	resp, err := l.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(key), "=", 0)).
+		Then(clientv3.OpPut(key, l.owner, clientv3.WithLease(lease.ID))).
+		Commit()
+	if err != nil || !resp.Succeeded {
+		// the lease is not used
+		_, _ = l.client.Revoke(ctx, lease.ID)
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (l *etcdLock) close() error {
+	return l.client.Close()
+}
@@ -1,7 +1,11 @@
 module github.com/qingcloudhx/contrib/trigger/timer
 
 require (
-	github.com/carlescere/scheduler v0.0.0-20170109141437-ee74d2f83d82
+	github.com/go-redis/redis/v8 v8.11.4
+	github.com/hashicorp/consul/api v1.9.1
+	github.com/robfig/cron/v3 v3.0.1
 	flogo/core v0.9.0
 	github.com/stretchr/testify v1.3.0
+	go.etcd.io/etcd/client/v3 v3.5.17
+	github.com/alicebob/miniredis/v2 v2.39.0
 )
@@ -0,0 +1,66 @@
+package timer
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// jitter delays the runs of a timer by a random amount, so that the instances of an application sharing a schedule
+// don't run at the same time
+type jitter struct {
+	max     time.Duration
+	percent float64
+	rand    *rand.Rand
+}
+
+// newJitter parses a jitter expressed as a duration (ex. 30s) or as a percentage of the interval between runs (ex. 10%)
+func newJitter(value string) (*jitter, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	j := &jitter{rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+
+	if strings.HasSuffix(value, "%") {
+		p, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(value, "%")), 64)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse jitter percentage: %s", err.Error())
+		}
+		if p < 0 || p > 100 {
+			return nil, fmt.Errorf("the jitter percentage must be between 0 and 100")
+		}
+		j.percent = p
+		return j, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse jitter: %s", err.Error())
+	}
+	if d < 0 {
+		return nil, fmt.Errorf("the jitter can't be negative")
+	}
+	j.max = d
+
+	return j, nil
+}
+
+// offset returns the random delay of a run scheduled after the given interval
+func (j *jitter) offset(interval time.Duration) time.Duration {
+	if j == nil {
+		return 0
+	}
+
+	max := j.max
+	if j.percent > 0 {
+		max = time.Duration(float64(interval) * j.percent / 100)
+	}
+	if max <= 0 {
+		return 0
+	}
+
+	return time.Duration(j.rand.Int63n(int64(max)))
+}
@@ -0,0 +1,39 @@
+package timer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewJitter(t *testing.T) {
+
+	j, err := newJitter("")
+	assert.Nil(t, err)
+	assert.Equal(t, time.Duration(0), j.offset(time.Minute))
+
+	j, err = newJitter("30s")
+	assert.Nil(t, err)
+	for i := 0; i < 100; i++ {
+		offset := j.offset(time.Second)
+		assert.True(t, offset >= 0 && offset < 30*time.Second)
+	}
+
+	j, err = newJitter("10%")
+	assert.Nil(t, err)
+	for i := 0; i < 100; i++ {
+		offset := j.offset(time.Minute)
+		assert.True(t, offset >= 0 && offset < 6*time.Second)
+	}
+	assert.Equal(t, time.Duration(0), j.offset(0))
+
+	_, err = newJitter("150%")
+	assert.NotNil(t, err)
+
+	_, err = newJitter("-1s")
+	assert.NotNil(t, err)
+
+	_, err = newJitter("soon")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,60 @@
+package timer
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	defaultLockTTL = 10 * time.Minute
+	lockTimeout    = 5 * time.Second
+)
+
+// lock elects the instance of an application running a scheduled run, so that a run happens once across the instances
+type lock interface {
+	// acquire returns whether this instance acquired the run identified by the key, the other instances can't acquire
+	// it before the time to live expired
+	acquire(key string, ttl time.Duration) (bool, error)
+	close() error
+}
+
+// newLock connects to the lock backend of a URI, "redis://[:password@]host:port[/db]", "etcd://host:port[,host:port]"
+// or "consul://host:port"
+func newLock(uri string) (lock, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid lock '%s': %s", uri, err.Error())
+	}
+
+	switch u.Scheme {
+	case "redis", "rediss":
+		return newRedisLock(uri)
+	case "etcd":
+		return newEtcdLock(u)
+	case "consul":
+		return newConsulLock(u)
+	default:
+		return nil, fmt.Errorf("unsupported lock '%s', the supported locks are redis, etcd and consul", uri)
+	}
+}
+
+// lockKey returns the key of the run of a timer scheduled at the specified time
+func lockKey(key string, scheduled time.Time) string {
+	return fmt.Sprintf("%s/%d", key, scheduled.UnixNano())
+}
+
+// lockOwner identifies this instance as the owner of the runs it acquired
+func lockOwner() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
+
+func endpoints(u *url.URL) []string {
+	return strings.Split(u.Host, ",")
+}
@@ -0,0 +1,74 @@
+package timer
+
+import (
+	"testing"
+	"time"
+
+	"flogo/core/support/log"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisLock(t *testing.T) {
+
+	server, err := miniredis.Run()
+	assert.Nil(t, err)
+	defer server.Close()
+
+	l1, err := newLock("redis://" + server.Addr())
+	assert.Nil(t, err)
+	defer l1.close()
+	l2, err := newLock("redis://" + server.Addr())
+	assert.Nil(t, err)
+	defer l2.close()
+
+	scheduled := time.Date(2019, 5, 3, 10, 0, 0, 0, time.UTC)
+
+	acquired, err := l1.acquire(lockKey("timer/handler", scheduled), time.Minute)
+	assert.Nil(t, err)
+	assert.True(t, acquired)
+	acquired, err = l2.acquire(lockKey("timer/handler", scheduled), time.Minute)
+	assert.Nil(t, err)
+	assert.False(t, acquired)
+
+	// the next run
+	acquired, err = l2.acquire(lockKey("timer/handler", scheduled.Add(time.Hour)), time.Minute)
+	assert.Nil(t, err)
+	assert.True(t, acquired)
+
+	// the lock expired
+	server.FastForward(time.Minute)
+	acquired, err = l2.acquire(lockKey("timer/handler", scheduled), time.Minute)
+	assert.Nil(t, err)
+	assert.True(t, acquired)
+
+	_, err = newLock("zookeeper://localhost:2181")
+	assert.NotNil(t, err)
+}
+
+func TestTimer_Lock(t *testing.T) {
+
+	server, err := miniredis.Run()
+	assert.Nil(t, err)
+	defer server.Close()
+
+	scheduled := time.Now()
+
+	// two instances of the same timer
+	var handlers []*countingHandler
+	for i := 0; i < 2; i++ {
+		l, err := newLock("redis://" + server.Addr())
+		assert.Nil(t, err)
+		defer l.close()
+
+		handler := &countingHandler{}
+		handlers = append(handlers, handler)
+		tm := &timer{handler: handler, key: "timer/counting", lock: l, lockTTL: time.Minute, logger: log.RootLogger()}
+		tm.trigger(scheduled)
+		assert.Equal(t, 1, tm.executions)
+	}
+
+	assert.Eventually(t, func() bool { return handlers[0].runs()+handlers[1].runs() == 1 }, time.Second, 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 1, handlers[0].runs()+handlers[1].runs())
+}
@@ -0,0 +1,77 @@
+package timer
+
+import (
+	"flogo/core/data/coerce"
+)
+
+const (
+	CatchUpSkip = "skip"
+	CatchUpOnce = "once"
+	CatchUpAll  = "all"
+
+	OverlapAllow = "allow"
+	OverlapSkip  = "skip"
+	OverlapQueue = "queue"
+)
+
+type Settings struct {
+	Store   string `md:"store"`   // The store of the last runs of the handlers (ex. file:///var/lib/flogo/timer.json, redis://localhost:6379), the schedules restart with the engine if not specified
+	Lock    string `md:"lock"`    // The lock electing the instance of the application running each run (ex. redis://localhost:6379, etcd://localhost:2379, consul://localhost:8500), every instance runs them if not specified
+	LockTTL string `md:"lockTTL"` // The time a run stays locked (ex. 10m), it must be longer than the clock difference between the instances, 10m if not specified
+
+	AdminPort  int    `md:"adminPort"`  // The port of the HTTP API pausing and resuming the timers, disabled if not specified
+	AdminHost  string `md:"adminHost"`  // The address the HTTP API listens on, 127.0.0.1 if not specified
+	AdminToken string `md:"adminToken"` // The bearer token required by the HTTP API, no authentication if not specified
+}
+
+type HandlerSettings struct {
+	StartInterval  string `md:"startDelay"`                        // The start delay (ex. 1m, 1h, etc.), immediate if not specified
+	RepeatInterval string `md:"repeatInterval"`                    // The repeat interval (ex. 1m, 1h, etc.), doesn't repeat if not specified
+	CronExpression string `md:"cronExpression"`                    // The cron expression of the schedule (ex. "30 2 * * 1-5"), with an optional leading seconds field, instead of a repeat interval
+	Timezone       string `md:"timezone"`                          // The IANA timezone the schedule is evaluated in (ex. Europe/Paris), the local time of the host if not specified
+	StartTime      string `md:"startTime"`                         // The time of the first run (ex. 2019-05-06T09:00:00), in the timezone of the schedule if it has no offset, the start delay and schedule apply from this time
+	EndTime        string `md:"endTime"`                           // The time after which the handler doesn't run anymore, in the timezone of the schedule if it has no offset
+	MaxExecutions  int    `md:"maxExecutions"`                     // The maximum number of runs, unlimited if not specified
+	Jitter         string `md:"jitter"`                            // The maximum random delay of each run, as a duration (ex. 30s) or a percentage of the interval between runs (ex. 10%)
+	CatchUp        string `md:"catchUp,allowed(skip,once,all)"`    // The policy for the runs missed while the engine was stopped (skip, once or all), 'skip' is the default, requires a store
+	Overlap        string `md:"overlap,allowed(allow,skip,queue)"` // The policy for the runs due while the previous run is still running (allow, skip or queue), 'allow' is the default
+}
+
+type Output struct {
+	ScheduledTime  int64  `md:"scheduledTime"`  // The time the run was scheduled at, in milliseconds since the epoch
+	ActualFireTime int64  `md:"actualFireTime"` // The time the run started at, in milliseconds since the epoch
+	Iteration      int    `md:"iteration"`      // The number of the run, starting at 1
+	HandlerName    string `md:"handlerName"`    // The name of the handler
+}
+
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"scheduledTime":  o.ScheduledTime,
+		"actualFireTime": o.ActualFireTime,
+		"iteration":      o.Iteration,
+		"handlerName":    o.HandlerName,
+	}
+}
+
+func (o *Output) FromMap(values map[string]interface{}) error {
+
+	var err error
+	o.ScheduledTime, err = coerce.ToInt64(values["scheduledTime"])
+	if err != nil {
+		return err
+	}
+	o.ActualFireTime, err = coerce.ToInt64(values["actualFireTime"])
+	if err != nil {
+		return err
+	}
+	o.Iteration, err = coerce.ToInt(values["iteration"])
+	if err != nil {
+		return err
+	}
+	o.HandlerName, err = coerce.ToString(values["handlerName"])
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
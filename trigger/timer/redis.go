@@ -0,0 +1,80 @@
+package timer
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const redisKeyPrefix = "flogo:timer:"
+
+// redisStore persists the states as JSON values in Redis, so that they are shared by the instances of an application
+type redisStore struct {
+	client *redis.Client
+}
+
+func newRedisStore(uri string) (*redisStore, error) {
+	options, err := redis.ParseURL(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	return &redisStore{client: redis.NewClient(options)}, nil
+}
+
+func (s *redisStore) load(key string) (runState, error) {
+
+	var state runState
+
+	data, err := s.client.Get(context.Background(), redisKeyPrefix+key).Bytes()
+	if err != nil {
+		if err == redis.Nil {
+			return state, nil
+		}
+		return state, err
+	}
+
+	err = json.Unmarshal(data, &state)
+	return state, err
+}
+
+func (s *redisStore) save(key string, state runState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	return s.client.Set(context.Background(), redisKeyPrefix+key, data, 0).Err()
+}
+
+func (s *redisStore) close() error {
+	return s.client.Close()
+}
+
+// redisLock acquires the runs by setting a key only if it doesn't exist
+type redisLock struct {
+	client *redis.Client
+	owner  string
+}
+
+func newRedisLock(uri string) (*redisLock, error) {
+	options, err := redis.ParseURL(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	return &redisLock{client: redis.NewClient(options), owner: lockOwner()}, nil
+}
+
+func (l *redisLock) acquire(key string, ttl time.Duration) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), lockTimeout)
+	defer cancel()
+
+	return l.client.SetNX(ctx, redisKeyPrefix+"lock:"+key, l.owner, ttl).Result()
+}
+
+func (l *redisLock) close() error {
+	return l.client.Close()
+}
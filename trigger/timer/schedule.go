@@ -0,0 +1,202 @@
+package timer
+
+import (
+	"fmt"
+	"time"
+	// the timezones are available even when the host has no timezone database
+	_ "time/tzdata"
+
+	"github.com/robfig/cron/v3"
+)
+
+// cronParser parses the standard 5 fields cron expressions, with an optional leading seconds
+// field, and the descriptors such as @daily or @every 1h
+var cronParser = cron.NewParser(cron.SecondOptional | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// schedule computes the fire times of a timer
+type schedule interface {
+	// first returns the first fire time after the timer started at the specified time
+	first(start time.Time) time.Time
+	// next returns the fire time following the previous fire time, zero when the timer doesn't fire anymore
+	next(prev time.Time) time.Time
+}
+
+// onceSchedule fires once after the start delay
+type onceSchedule struct {
+	delay time.Duration
+}
+
+func (s *onceSchedule) first(start time.Time) time.Time {
+	return start.Add(s.delay)
+}
+
+func (s *onceSchedule) next(prev time.Time) time.Time {
+	return time.Time{}
+}
+
+// intervalSchedule fires after the start delay and then at a fixed interval, aligned on multiples of the interval if
+// required, so that the timers started at different times fire at the same times
+type intervalSchedule struct {
+	delay    time.Duration
+	interval time.Duration
+	aligned  bool
+}
+
+func (s *intervalSchedule) first(start time.Time) time.Time {
+	first := start.Add(s.delay)
+	if s.aligned {
+		if aligned := first.Truncate(s.interval); aligned.Before(first) {
+			return aligned.Add(s.interval)
+		}
+	}
+	return first
+}
+
+func (s *intervalSchedule) next(prev time.Time) time.Time {
+	return prev.Add(s.interval)
+}
+
+// cronSchedule fires at the times matching a cron expression, evaluated in its location if set, starting after the start delay
+type cronSchedule struct {
+	delay    time.Duration
+	cron     cron.Schedule
+	location *time.Location
+}
+
+func (s *cronSchedule) first(start time.Time) time.Time {
+	if s.location != nil {
+		start = start.In(s.location)
+	}
+	// a fire time matching the end of the delay is included
+	return s.cron.Next(start.Add(s.delay).Add(-time.Nanosecond))
+}
+
+func (s *cronSchedule) next(prev time.Time) time.Time {
+	next := s.cron.Next(prev)
+	if _, ok := s.cron.(*cron.SpecSchedule); ok && sameWallClock(prev, next) {
+		// the clock was set back by a daylight saving time change, the time already fired
+		next = s.cron.Next(next)
+	}
+	return next
+}
+
+func sameWallClock(t1, t2 time.Time) bool {
+	y1, mo1, d1 := t1.Date()
+	y2, mo2, d2 := t2.Date()
+	h1, mi1, s1 := t1.Clock()
+	h2, mi2, s2 := t2.Clock()
+	return y1 == y2 && mo1 == mo2 && d1 == d2 && h1 == h2 && mi1 == mi2 && s1 == s2
+}
+
+// boundedSchedule limits a schedule to a time window
+type boundedSchedule struct {
+	schedule
+	startTime time.Time
+	endTime   time.Time
+}
+
+func (s *boundedSchedule) first(start time.Time) time.Time {
+	if start.Before(s.startTime) {
+		start = s.startTime
+	}
+	return s.bound(s.schedule.first(start))
+}
+
+func (s *boundedSchedule) next(prev time.Time) time.Time {
+	return s.bound(s.schedule.next(prev))
+}
+
+func (s *boundedSchedule) bound(t time.Time) time.Time {
+	if !s.endTime.IsZero() && t.After(s.endTime) {
+		return time.Time{}
+	}
+	return t
+}
+
+// newSchedule creates the schedule of a handler according to its settings, with the repeat intervals aligned if required
+func newSchedule(settings *HandlerSettings, aligned bool) (schedule, error) {
+
+	var location *time.Location
+	if settings.Timezone != "" {
+		loc, err := time.LoadLocation(settings.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("unknown timezone '%s'", settings.Timezone)
+		}
+		location = loc
+	}
+
+	s, err := newBaseSchedule(settings, location, aligned)
+	if err != nil {
+		return nil, err
+	}
+
+	if settings.StartTime == "" && settings.EndTime == "" {
+		return s, nil
+	}
+
+	bounded := &boundedSchedule{schedule: s}
+	if settings.StartTime != "" {
+		bounded.startTime, err = parseTime(settings.StartTime, location)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse start time: %s", err.Error())
+		}
+	}
+	if settings.EndTime != "" {
+		bounded.endTime, err = parseTime(settings.EndTime, location)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse end time: %s", err.Error())
+		}
+		if bounded.endTime.Before(bounded.startTime) {
+			return nil, fmt.Errorf("the end time is before the start time")
+		}
+	}
+
+	return bounded, nil
+}
+
+// parseTime parses a RFC 3339 time, the time is in the location of the schedule when it has no offset
+func parseTime(value string, location *time.Location) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if location == nil {
+		location = time.Local
+	}
+	return time.ParseInLocation("2006-01-02T15:04:05", value, location)
+}
+
+func newBaseSchedule(settings *HandlerSettings, location *time.Location, aligned bool) (schedule, error) {
+	var delay time.Duration
+	if settings.StartInterval != "" {
+		d, err := time.ParseDuration(settings.StartInterval)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse start delay: %s", err.Error())
+		}
+		delay = d
+	}
+
+	if settings.CronExpression != "" {
+		if settings.RepeatInterval != "" {
+			return nil, fmt.Errorf("a repeat interval and a cron expression can't be both specified")
+		}
+		c, err := cronParser.Parse(settings.CronExpression)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse cron expression: %s", err.Error())
+		}
+		return &cronSchedule{delay: delay, cron: c, location: location}, nil
+	}
+
+	if settings.RepeatInterval == "" {
+		return &onceSchedule{delay: delay}, nil
+	}
+
+	interval, err := time.ParseDuration(settings.RepeatInterval)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse repeat interval: %s", err.Error())
+	}
+	if interval <= 0 {
+		return nil, fmt.Errorf("the repeat interval must be positive")
+	}
+
+	return &intervalSchedule{delay: delay, interval: interval, aligned: aligned}, nil
+}
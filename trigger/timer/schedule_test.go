@@ -0,0 +1,109 @@
+package timer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSchedule(t *testing.T) {
+
+	start := time.Date(2019, 5, 3, 10, 0, 0, 0, time.UTC) // a friday
+
+	s, err := newSchedule(&HandlerSettings{}, false)
+	assert.Nil(t, err)
+	assert.Equal(t, start, s.first(start))
+	assert.True(t, s.next(start).IsZero())
+
+	s, err = newSchedule(&HandlerSettings{StartInterval: "1m", RepeatInterval: "10s"}, false)
+	assert.Nil(t, err)
+	first := s.first(start)
+	assert.Equal(t, start.Add(time.Minute), first)
+	assert.Equal(t, first.Add(10*time.Second), s.next(first))
+
+	// aligned on the interval
+	s, err = newSchedule(&HandlerSettings{RepeatInterval: "1h"}, true)
+	assert.Nil(t, err)
+	assert.Equal(t, start, s.first(start))
+	first = s.first(start.Add(time.Second))
+	assert.Equal(t, start.Add(time.Hour), first)
+	assert.Equal(t, start.Add(2*time.Hour), s.next(first))
+
+	// at 02:30 every weekday
+	s, err = newSchedule(&HandlerSettings{CronExpression: "30 2 * * 1-5"}, false)
+	assert.Nil(t, err)
+	first = s.first(start)
+	assert.Equal(t, time.Date(2019, 5, 6, 2, 30, 0, 0, time.UTC), first)
+	assert.Equal(t, time.Date(2019, 5, 7, 2, 30, 0, 0, time.UTC), s.next(first))
+
+	// with seconds
+	s, err = newSchedule(&HandlerSettings{CronExpression: "*/15 * * * * *"}, false)
+	assert.Nil(t, err)
+	assert.Equal(t, start, s.first(start))
+	assert.Equal(t, start.Add(15*time.Second), s.next(start))
+
+	_, err = newSchedule(&HandlerSettings{CronExpression: "* * *"}, false)
+	assert.NotNil(t, err)
+
+	_, err = newSchedule(&HandlerSettings{CronExpression: "@hourly", RepeatInterval: "1h"}, false)
+	assert.NotNil(t, err)
+}
+
+func TestNewSchedule_Timezone(t *testing.T) {
+
+	paris, err := time.LoadLocation("Europe/Paris")
+	assert.Nil(t, err)
+
+	s, err := newSchedule(&HandlerSettings{CronExpression: "30 2 * * *", Timezone: "Europe/Paris"}, false)
+	assert.Nil(t, err)
+
+	// the daylight saving time starts on March 31 2019 at 02:00 in Paris, there is no 02:30 that day
+	start := time.Date(2019, 3, 30, 12, 0, 0, 0, time.UTC)
+	first := s.first(start)
+	assert.Equal(t, time.Date(2019, 4, 1, 2, 30, 0, 0, paris), first)
+	assert.True(t, first.Equal(time.Date(2019, 4, 1, 0, 30, 0, 0, time.UTC)))
+
+	// the daylight saving time ends on October 27 2019 at 03:00 in Paris, 02:30 only fires once
+	first = s.first(time.Date(2019, 10, 26, 12, 0, 0, 0, time.UTC))
+	assert.True(t, first.Equal(time.Date(2019, 10, 27, 0, 30, 0, 0, time.UTC)))
+	assert.Equal(t, time.Date(2019, 10, 28, 2, 30, 0, 0, paris), s.next(first))
+
+	_, err = newSchedule(&HandlerSettings{CronExpression: "30 2 * * *", Timezone: "Mars/Olympus"}, false)
+	assert.NotNil(t, err)
+}
+
+func TestNewSchedule_Bounds(t *testing.T) {
+
+	start := time.Date(2019, 5, 3, 10, 0, 0, 0, time.UTC)
+
+	s, err := newSchedule(&HandlerSettings{StartTime: "2019-05-06T09:00:00Z", RepeatInterval: "1h"}, false)
+	assert.Nil(t, err)
+	first := s.first(start)
+	assert.Equal(t, time.Date(2019, 5, 6, 9, 0, 0, 0, time.UTC), first)
+	assert.Equal(t, first.Add(time.Hour), s.next(first))
+
+	// a start time in the past doesn't delay the first run
+	s, err = newSchedule(&HandlerSettings{StartTime: "2019-05-01T09:00:00Z"}, false)
+	assert.Nil(t, err)
+	assert.Equal(t, start, s.first(start))
+
+	s, err = newSchedule(&HandlerSettings{CronExpression: "0 9 * * *", EndTime: "2019-05-05T09:00:00", Timezone: "UTC"}, false)
+	assert.Nil(t, err)
+	first = s.first(start)
+	assert.Equal(t, time.Date(2019, 5, 4, 9, 0, 0, 0, time.UTC), first)
+	second := s.next(first)
+	assert.True(t, second.Equal(time.Date(2019, 5, 5, 9, 0, 0, 0, time.UTC)))
+	assert.True(t, s.next(second).IsZero())
+
+	// the end time already passed
+	s, err = newSchedule(&HandlerSettings{EndTime: "2019-05-01T09:00:00Z"}, false)
+	assert.Nil(t, err)
+	assert.True(t, s.first(start).IsZero())
+
+	_, err = newSchedule(&HandlerSettings{StartTime: "2019-05-06T09:00:00Z", EndTime: "2019-05-05T09:00:00Z"}, false)
+	assert.NotNil(t, err)
+
+	_, err = newSchedule(&HandlerSettings{StartTime: "tomorrow"}, false)
+	assert.NotNil(t, err)
+}
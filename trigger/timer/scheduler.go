@@ -0,0 +1,157 @@
+package timer
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"flogo/core/data/metadata"
+	"flogo/core/trigger"
+)
+
+// the started timer triggers, by id
+var (
+	triggersMu sync.RWMutex
+	triggers   = make(map[string]*Trigger)
+)
+
+func register(t *Trigger) {
+	if t.id == "" {
+		return
+	}
+
+	triggersMu.Lock()
+	defer triggersMu.Unlock()
+
+	triggers[t.id] = t
+}
+
+func unregister(t *Trigger) {
+	triggersMu.Lock()
+	defer triggersMu.Unlock()
+
+	if triggers[t.id] == t {
+		delete(triggers, t.id)
+	}
+}
+
+// GetTrigger returns the started timer trigger with the specified id, so that its timers can be changed at runtime
+func GetTrigger(id string) (*Trigger, bool) {
+	triggersMu.RLock()
+	defer triggersMu.RUnlock()
+
+	t, ok := triggers[id]
+	return t, ok
+}
+
+// Schedule starts a timer running the action of the named handler according to the specified handler settings (ex.
+// {"startDelay": "2h"} to run it once in two hours). A timer with the same id is replaced, the id of a handler's own timer
+// is the name of the handler, so that scheduling it changes the schedule of the handler.
+func (t *Trigger) Schedule(id, handlerName string, settings map[string]interface{}) error {
+
+	handler := t.handler(handlerName)
+	if handler == nil {
+		return fmt.Errorf("unknown handler '%s'", handlerName)
+	}
+
+	s := &HandlerSettings{}
+	err := metadata.MapToStruct(settings, s, true)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.timers == nil {
+		return fmt.Errorf("trigger '%s' is stopped", t.id)
+	}
+
+	return t.schedule(id, handler, s)
+}
+
+// Reschedule changes the schedule of a handler
+func (t *Trigger) Reschedule(handlerName string, settings map[string]interface{}) error {
+	return t.Schedule(handlerName, handlerName, settings)
+}
+
+// Unschedule stops a timer, it returns false if there is no such timer
+func (t *Trigger) Unschedule(id string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	timer, exists := t.timers[id]
+	if !exists {
+		return false
+	}
+
+	close(timer.quit)
+	delete(t.timers, id)
+
+	return true
+}
+
+// Timers returns the ids of the scheduled timers
+func (t *Trigger) Timers() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ids := make([]string, 0, len(t.timers))
+	for id := range t.timers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	return ids
+}
+
+// Pause pauses a timer, the runs due until it is resumed are skipped
+func (t *Trigger) Pause(id string) error {
+	return t.setPaused(id, true)
+}
+
+// Resume resumes a paused timer
+func (t *Trigger) Resume(id string) error {
+	return t.setPaused(id, false)
+}
+
+// Paused returns whether a timer is paused
+func (t *Trigger) Paused(id string) (bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	timer, exists := t.timers[id]
+	if !exists {
+		return false, fmt.Errorf("unknown timer '%s'", id)
+	}
+
+	return timer.isPaused(), nil
+}
+
+func (t *Trigger) setPaused(id string, paused bool) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	timer, exists := t.timers[id]
+	if !exists {
+		return fmt.Errorf("unknown timer '%s'", id)
+	}
+
+	timer.setPaused(paused)
+	if paused {
+		t.logger.Infof("Paused timer [%s]", id)
+	} else {
+		t.logger.Infof("Resumed timer [%s]", id)
+	}
+
+	return nil
+}
+
+func (t *Trigger) handler(name string) trigger.Handler {
+	for _, handler := range t.handlers {
+		if handler.Name() == name {
+			return handler
+		}
+	}
+	return nil
+}
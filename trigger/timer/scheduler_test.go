@@ -0,0 +1,81 @@
+package timer
+
+import (
+	"testing"
+	"time"
+
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrigger_Schedule(t *testing.T) {
+
+	handler := &countingHandler{}
+	tgr := &Trigger{id: "timers", settings: &Settings{}, handlers: []trigger.Handler{handler}, logger: log.RootLogger()}
+
+	_, found := GetTrigger("timers")
+	assert.False(t, found)
+
+	// the handler only runs in an hour
+	handler.settings = map[string]interface{}{"startDelay": "1h"}
+	err := tgr.Start()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"counting"}, tgr.Timers())
+
+	started, found := GetTrigger("timers")
+	assert.True(t, found)
+	assert.Equal(t, tgr, started)
+
+	// a follow-up run, the timer is removed once it ran
+	err = started.Schedule("follow-up", "counting", map[string]interface{}{"startDelay": "10ms"})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"counting", "follow-up"}, tgr.Timers())
+	assert.Eventually(t, func() bool { return handler.runs() == 1 }, time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool { return len(tgr.Timers()) == 1 }, time.Second, 10*time.Millisecond)
+
+	err = started.Reschedule("counting", map[string]interface{}{"repeatInterval": "10ms"})
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool { return handler.runs() >= 3 }, time.Second, 10*time.Millisecond)
+
+	// no run while paused
+	err = started.Pause("counting")
+	assert.Nil(t, err)
+	paused, err := started.Paused("counting")
+	assert.Nil(t, err)
+	assert.True(t, paused)
+
+	// a rescheduled timer stays paused
+	err = started.Reschedule("counting", map[string]interface{}{"repeatInterval": "10ms"})
+	assert.Nil(t, err)
+	paused, err = started.Paused("counting")
+	assert.Nil(t, err)
+	assert.True(t, paused)
+	time.Sleep(30 * time.Millisecond)
+	runs := handler.runs()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, runs, handler.runs())
+
+	err = started.Resume("counting")
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool { return handler.runs() > runs }, time.Second, 10*time.Millisecond)
+
+	assert.NotNil(t, started.Pause("unknown"))
+
+	assert.True(t, started.Unschedule("counting"))
+	assert.False(t, started.Unschedule("counting"))
+	assert.Empty(t, tgr.Timers())
+
+	err = started.Schedule("unknown", "unknown", map[string]interface{}{"startDelay": "10ms"})
+	assert.NotNil(t, err)
+	err = started.Schedule("invalid", "counting", map[string]interface{}{"cronExpression": "* * *"})
+	assert.NotNil(t, err)
+
+	err = tgr.Stop()
+	assert.Nil(t, err)
+	_, found = GetTrigger("timers")
+	assert.False(t, found)
+
+	err = started.Schedule("follow-up", "counting", map[string]interface{}{"startDelay": "10ms"})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,114 @@
+package timer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// runState is the persisted state of a timer
+type runState struct {
+	LastRun    time.Time `json:"lastRun"`
+	Executions int       `json:"executions"`
+}
+
+// store persists the state of the timers, so that a restarted engine resumes their schedules
+type store interface {
+	// load returns the state of a timer, the zero state if it never ran
+	load(key string) (runState, error)
+	save(key string, state runState) error
+	close() error
+}
+
+// newStore opens the store of a URI, "file:///path/to/file.json" or "redis://[:password@]host:port[/db]"
+func newStore(uri string) (store, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid store '%s': %s", uri, err.Error())
+	}
+
+	switch u.Scheme {
+	case "file":
+		path := u.Path
+		if u.Host != "" {
+			// relative path, ex. file://timer.json
+			path = u.Host + u.Path
+		}
+		return newFileStore(path)
+	case "redis", "rediss":
+		return newRedisStore(uri)
+	default:
+		return nil, fmt.Errorf("unsupported store '%s', the supported stores are file and redis", uri)
+	}
+}
+
+// fileStore persists the states as a JSON object in a local file
+type fileStore struct {
+	path   string
+	mu     sync.Mutex
+	states map[string]runState
+}
+
+func newFileStore(path string) (*fileStore, error) {
+	s := &fileStore{path: path, states: make(map[string]runState)}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &s.states); err != nil {
+			return nil, fmt.Errorf("invalid store file '%s': %s", path, err.Error())
+		}
+	}
+
+	return s, nil
+}
+
+func (s *fileStore) load(key string) (runState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.states[key], nil
+}
+
+func (s *fileStore) save(key string, state runState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.states[key] = state
+
+	data, err := json.MarshalIndent(s.states, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// the file is replaced so that it is never partially written
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path)
+}
+
+func (s *fileStore) close() error {
+	return nil
+}
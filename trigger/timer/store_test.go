@@ -0,0 +1,114 @@
+package timer
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"flogo/core/support/log"
+	"github.com/stretchr/testify/assert"
+)
+
+type countingHandler struct {
+	settings map[string]interface{}
+	mu       sync.Mutex
+	count    int
+	outputs  []*Output
+}
+
+func (h *countingHandler) Name() string {
+	return "counting"
+}
+
+func (h *countingHandler) Settings() map[string]interface{} {
+	return h.settings
+}
+
+func (h *countingHandler) Handle(ctx context.Context, triggerData interface{}) (map[string]interface{}, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.count++
+	if output, ok := triggerData.(*Output); ok {
+		h.outputs = append(h.outputs, output)
+	}
+	return nil, nil
+}
+
+func (h *countingHandler) runs() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count
+}
+
+func TestFileStore(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "timer")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := newStore("file://" + filepath.Join(dir, "timer.json"))
+	assert.Nil(t, err)
+
+	state, err := s.load("timer/handler")
+	assert.Nil(t, err)
+	assert.True(t, state.LastRun.IsZero())
+
+	lastRun := time.Date(2019, 5, 3, 10, 0, 0, 0, time.UTC)
+	err = s.save("timer/handler", runState{LastRun: lastRun, Executions: 3})
+	assert.Nil(t, err)
+
+	// reopened
+	s, err = newStore("file://" + filepath.Join(dir, "timer.json"))
+	assert.Nil(t, err)
+	state, err = s.load("timer/handler")
+	assert.Nil(t, err)
+	assert.True(t, lastRun.Equal(state.LastRun))
+	assert.Equal(t, 3, state.Executions)
+
+	_, err = newStore("etcd://localhost:2379")
+	assert.NotNil(t, err)
+}
+
+func TestTimer_Resume(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "timer")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	s, err := newFileStore(filepath.Join(dir, "timer.json"))
+	assert.Nil(t, err)
+
+	start := time.Date(2019, 5, 3, 10, 0, 30, 0, time.UTC)
+	sched, err := newSchedule(&HandlerSettings{RepeatInterval: "1m"}, false)
+	assert.Nil(t, err)
+
+	for policy, runs := range map[string]int{CatchUpSkip: 0, CatchUpOnce: 1, CatchUpAll: 5} {
+		// the last run was 5 runs before the start
+		err = s.save(policy, runState{LastRun: start.Add(-330 * time.Second), Executions: 2})
+		assert.Nil(t, err)
+
+		handler := &countingHandler{}
+		tm := &timer{handler: handler, schedule: sched, store: s, key: policy, catchUp: policy, logger: log.RootLogger()}
+
+		next := tm.resume(start, sched.first(start))
+		assert.Equal(t, start.Add(30*time.Second), next, policy)
+		assert.Equal(t, 2+runs, tm.executions, policy)
+		assert.Eventually(t, func() bool { return handler.runs() == runs }, time.Second, 10*time.Millisecond, policy)
+	}
+
+	// at most 3 runs
+	err = s.save("bounded", runState{LastRun: start.Add(-330 * time.Second), Executions: 2})
+	assert.Nil(t, err)
+	tm := &timer{handler: &countingHandler{}, schedule: sched, store: s, key: "bounded", catchUp: CatchUpAll, maxExecutions: 3, logger: log.RootLogger()}
+	tm.resume(start, sched.first(start))
+	assert.Equal(t, 3, tm.executions)
+	assert.True(t, tm.ended())
+
+	// never ran
+	tm = &timer{handler: &countingHandler{}, schedule: sched, store: s, key: "new", catchUp: CatchUpAll, logger: log.RootLogger()}
+	assert.Equal(t, start, tm.resume(start, sched.first(start)))
+}
@@ -3,20 +3,19 @@ package timer
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"sync"
 	"time"
 
-	"github.com/carlescere/scheduler"
 	"flogo/core/data/metadata"
 	"flogo/core/support/log"
 	"flogo/core/trigger"
 )
 
-type HandlerSettings struct {
-	StartInterval  string `md:"startDelay"`      // The start delay (ex. 1m, 1h, etc.), immediate if not specified
-	RepeatInterval string `md:"repeatInterval"`  // The repeat interval (ex. 1m, 1h, etc.), doesn't repeat if not specified
-}
+var triggerMd = trigger.NewMetadata(&Settings{}, &HandlerSettings{}, &Output{})
 
-var triggerMd = trigger.NewMetadata(&HandlerSettings{})
+// maxQueuedRuns is the maximum number of runs waiting for the previous run with the queue overlap policy
+const maxQueuedRuns = 100
 
 func init() {
 	_ = trigger.Register(&Trigger{}, &Factory{})
@@ -32,11 +31,30 @@ func (*Factory) Metadata() *trigger.Metadata {
 
 // New implements trigger.Factory.New
 func (*Factory) New(config *trigger.Config) (trigger.Trigger, error) {
-	return &Trigger{}, nil
+
+	if config == nil {
+		return &Trigger{settings: &Settings{}}, nil
+	}
+
+	s := &Settings{}
+	err := metadata.MapToStruct(config.Settings, s, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Trigger{id: config.Id, settings: s}, nil
 }
 
 type Trigger struct {
-	timers   []*scheduler.Job
+	id       string
+	settings *Settings
+	store    store
+	lock     lock
+	lockTTL  time.Duration
+	admin    *http.Server
+	mu       sync.Mutex
+	timers   map[string]*timer
+	running  sync.WaitGroup
 	handlers []trigger.Handler
 	logger   log.Logger
 }
@@ -51,173 +69,438 @@ func (t *Trigger) Initialize(ctx trigger.InitContext) error {
 }
 
 // Start implements ext.Trigger.Start
-func (t *Trigger) Start() error {
-
-	handlers := t.handlers
+func (t *Trigger) Start() (err error) {
 
-	for _, handler := range handlers {
+	defer func() {
+		if err != nil {
+			// the timers, the admin API, the store and the lock started so far are stopped
+			if stopErr := t.Stop(); stopErr != nil {
+				t.logger.Warnf("Unable to stop the trigger after it failed to start: %s", stopErr.Error())
+			}
+		}
+	}()
 
-		s := &HandlerSettings{}
-		err := metadata.MapToStruct(handler.Settings(), s, true)
+	if t.settings.Store != "" {
+		st, err := newStore(t.settings.Store)
 		if err != nil {
 			return err
 		}
+		t.store = st
+	}
 
-		if s.RepeatInterval == "" {
-			err = t.scheduleOnce(handler, s)
+	if t.settings.Lock != "" {
+		t.lockTTL = defaultLockTTL
+		if t.settings.LockTTL != "" {
+			ttl, err := time.ParseDuration(t.settings.LockTTL)
 			if err != nil {
-				return err
+				return fmt.Errorf("unable to parse lock TTL: %s", err.Error())
 			}
-		} else {
-			err = t.scheduleRepeating(handler, s)
-			if err != nil {
-				return err
+			if ttl < 10*time.Second {
+				return fmt.Errorf("the lock TTL must be at least 10s")
 			}
+			t.lockTTL = ttl
+		}
+
+		l, err := newLock(t.settings.Lock)
+		if err != nil {
+			return err
+		}
+		t.lock = l
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.timers = make(map[string]*timer)
+
+	for i, handler := range t.handlers {
+
+		s := &HandlerSettings{}
+		err := metadata.MapToStruct(handler.Settings(), s, true)
+		if err != nil {
+			return err
+		}
+
+		// the timer of a handler is identified by its name
+		id := handler.Name()
+		if id == "" {
+			id = fmt.Sprintf("handler-%d", i)
+		}
+
+		err = t.schedule(id, handler, s)
+		if err != nil {
+			return err
 		}
 	}
 
+	register(t)
+
+	if t.settings.AdminPort > 0 {
+		return t.startAdmin(t.settings.AdminHost, t.settings.AdminPort)
+	}
+
 	return nil
 }
 
 // Stop implements ext.Trigger.Stop
 func (t *Trigger) Stop() error {
 
-	for _, timer := range t.timers {
+	unregister(t)
 
-		if timer.IsRunning() {
-			timer.Quit <- true
+	if t.admin != nil {
+		if err := t.stopAdmin(); err != nil {
+			t.logger.Warnf("Unable to stop the admin API: %s", err.Error())
 		}
 	}
 
+	t.mu.Lock()
+	for _, timer := range t.timers {
+		close(timer.quit)
+	}
 	t.timers = nil
+	t.mu.Unlock()
 
-	return nil
-}
+	// the timers use the store and the lock until they return
+	t.running.Wait()
 
-func (t *Trigger) scheduleOnce(handler trigger.Handler, settings *HandlerSettings) error {
+	var err error
+	if t.store != nil {
+		err = t.store.close()
+		t.store = nil
+	}
+	if t.lock != nil {
+		if lerr := t.lock.close(); lerr != nil && err == nil {
+			err = lerr
+		}
+		t.lock = nil
+	}
 
-	seconds := 0
+	return err
+}
 
-	if settings.StartInterval != "" {
-		d, err := time.ParseDuration(settings.StartInterval)
-		if err != nil {
-			return fmt.Errorf("unable to parse start delay: %s", err.Error())
-		}
+// schedule starts a timer running the action of a handler, replacing the timer with the same id, the lock must be held
+func (t *Trigger) schedule(id string, handler trigger.Handler, s *HandlerSettings) error {
 
-		seconds = int(d.Seconds())
-		t.logger.Debugf("Scheduling action to run once in %d seconds", seconds)
+	// the instances sharing a lock must schedule the same runs
+	sched, err := newSchedule(s, t.lock != nil)
+	if err != nil {
+		return err
 	}
 
-	var timerJob *scheduler.Job
+	j, err := newJitter(s.Jitter)
+	if err != nil {
+		return err
+	}
 
-	fn := func() {
-		t.logger.Debug("Executing \"Once\" timer trigger")
+	if s.MaxExecutions < 0 {
+		return fmt.Errorf("the maximum number of executions can't be negative")
+	}
 
-		_, err := handler.Handle(context.Background(), nil)
-		if err != nil {
-			t.logger.Error("Error running handler: ", err.Error())
-		}
+	switch s.CatchUp {
+	case "", CatchUpSkip, CatchUpOnce, CatchUpAll:
+	default:
+		return fmt.Errorf("unsupported catch-up policy '%s'", s.CatchUp)
+	}
 
-		if timerJob != nil {
-			timerJob.Quit <- true
-		}
+	switch s.Overlap {
+	case "", OverlapAllow, OverlapSkip, OverlapQueue:
+	default:
+		return fmt.Errorf("unsupported overlap policy '%s'", s.Overlap)
 	}
 
-	if seconds == 0 {
-		t.logger.Debug("Start delay not specified, executing action immediately")
-		fn()
-	} else {
-		timerJob := scheduler.Every(seconds).Seconds()
-		timerJob, err := timerJob.NotImmediately().Run(fn)
-		if err != nil {
-			t.logger.Error("Error scheduling execute \"once\" timer: ", err.Error())
-		}
+	timer := &timer{handler: handler, schedule: sched, jitter: j, maxExecutions: s.MaxExecutions, overlap: s.Overlap, logger: t.logger, quit: make(chan struct{})}
+	timer.key = t.storeKey(id)
+	if t.store != nil {
+		timer.store = t.store
+		timer.catchUp = s.CatchUp
+	}
+	if t.lock != nil {
+		timer.lock = t.lock
+		timer.lockTTL = t.lockTTL
+	}
 
-		t.timers = append(t.timers, timerJob)
+	if previous, exists := t.timers[id]; exists {
+		// a rescheduled timer stays paused
+		timer.paused = previous.isPaused()
+		close(previous.quit)
 	}
+	t.timers[id] = timer
+
+	t.running.Add(1)
+	go func() {
+		defer t.running.Done()
+
+		timer.run(time.Now())
+		t.remove(id, timer)
+	}()
 
 	return nil
 }
 
-func (t *Trigger) scheduleRepeating(handler trigger.Handler, settings *HandlerSettings) error {
-	t.logger.Info("Scheduling a repeating timer")
+// remove forgets a timer which schedule ended
+func (t *Trigger) remove(id string, timer *timer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 
-	startSeconds := 0
+	if t.timers[id] == timer {
+		delete(t.timers, id)
+	}
+}
 
-	if settings.StartInterval != "" {
-		d, err := time.ParseDuration(settings.StartInterval)
-		if err != nil {
-			return fmt.Errorf("unable to parse start delay: %s", err.Error())
+// storeKey returns the key of the state and runs of a timer, unique among the triggers of the stores and locks shared by
+// applications
+func (t *Trigger) storeKey(id string) string {
+	if t.id == "" {
+		return id
+	}
+	return t.id + "/" + id
+}
+
+// timer fires the action of a handler according to its schedule
+type timer struct {
+	handler       trigger.Handler
+	schedule      schedule
+	jitter        *jitter
+	maxExecutions int
+	executions    int
+	store         store
+	key           string
+	lock          lock
+	lockTTL       time.Duration
+	catchUp       string
+	overlap       string
+	mu            sync.Mutex
+	running       bool
+	paused        bool
+	pending       []*run
+	logger        log.Logger
+	quit          chan struct{}
+}
+
+func (t *timer) run(start time.Time) {
+
+	next := t.schedule.first(start)
+	if t.store != nil {
+		next = t.resume(start, next)
+	}
+	if next.IsZero() || t.ended() {
+		t.logger.Infof("Handler [%s] has no run scheduled", t.handler.Name())
+		return
+	}
+	t.logger.Debugf("Scheduling action of handler [%s] to run at %s", t.handler.Name(), next)
+
+	prev := start
+	for !next.IsZero() && !t.ended() {
+		// the jitter only delays the run, the following runs are still scheduled from the scheduled time
+		wait := time.NewTimer(time.Until(next.Add(t.jitter.offset(next.Sub(prev)))))
+		select {
+		case <-t.quit:
+			wait.Stop()
+			return
+		case <-wait.C:
 		}
 
-		startSeconds = int(d.Seconds())
-		t.logger.Debugf("Scheduling action to start in %d seconds", startSeconds)
+		t.trigger(next)
+
+		prev, next = next, t.schedule.next(next)
 	}
 
-	d, err := time.ParseDuration(settings.RepeatInterval)
+	t.logger.Debugf("Schedule of handler [%s] ended", t.handler.Name())
+}
+
+// resume restores the persisted state of the timer and catches up the runs missed since its last run, it returns the
+// time of the next run
+func (t *timer) resume(start, first time.Time) time.Time {
+
+	state, err := t.store.load(t.key)
 	if err != nil {
-		return fmt.Errorf("unable to parse repeat interval: %s", err.Error())
+		t.logger.Errorf("Unable to load the last run of handler [%s], its schedule restarts: %s", t.handler.Name(), err.Error())
+		return first
+	}
+	if state.LastRun.IsZero() {
+		return first
+	}
+	t.executions = state.Executions
+
+	var missed []time.Time
+	next := t.schedule.next(state.LastRun)
+	for !next.IsZero() && next.Before(start) {
+		missed = append(missed, next)
+		next = t.schedule.next(next)
 	}
 
-	repeatInterval := int(d.Seconds())
-	t.logger.Debugf("Scheduling action to repeat every %d seconds", repeatInterval)
+	if len(missed) > 0 {
+		switch t.catchUp {
+		case CatchUpOnce:
+			t.logger.Infof("Handler [%s] missed %d run(s) since %s, running once", t.handler.Name(), len(missed), state.LastRun)
+			t.trigger(missed[len(missed)-1])
+		case CatchUpAll:
+			t.logger.Infof("Handler [%s] missed %d run(s) since %s, running all of them", t.handler.Name(), len(missed), state.LastRun)
+			for _, scheduled := range missed {
+				if t.ended() || t.stopped() {
+					break
+				}
+				t.trigger(scheduled)
+			}
+		default:
+			t.logger.Infof("Handler [%s] missed %d run(s) since %s, skipping them", t.handler.Name(), len(missed), state.LastRun)
+		}
+	}
 
-	fn := func() {
-		t.logger.Debug("Executing \"Repeating\" timer")
+	return next
+}
 
-		_, err := handler.Handle(context.Background(), nil)
+// run is a run of a timer
+type run struct {
+	scheduled time.Time
+	iteration int
+}
+
+// trigger starts the run scheduled at the specified time and records it
+func (t *timer) trigger(scheduled time.Time) {
+
+	if t.isPaused() {
+		t.logger.Infof("Skipping run of handler [%s] scheduled at %s, the timer is paused", t.handler.Name(), scheduled)
+		return
+	}
+
+	if t.lock != nil {
+		acquired, err := t.lock.acquire(lockKey(t.key, scheduled), t.lockTTL)
 		if err != nil {
-			t.logger.Error("Error running handler: ", err.Error())
+			t.logger.Errorf("Unable to lock run of handler [%s] scheduled at %s, skipping it: %s", t.handler.Name(), scheduled, err.Error())
+			return
+		}
+		if !acquired {
+			// another instance runs it
+			t.logger.Debugf("Run of handler [%s] scheduled at %s is locked by another instance", t.handler.Name(), scheduled)
+			t.record(scheduled)
+			return
 		}
 	}
 
-	if startSeconds == 0 {
-		timerJob, err := scheduler.Every(repeatInterval).Seconds().Run(fn)
+	switch t.overlap {
+	case OverlapSkip:
+		t.mu.Lock()
+		if t.running {
+			t.mu.Unlock()
+			t.logger.Infof("Skipping run of handler [%s] scheduled at %s, the previous run is still running", t.handler.Name(), scheduled)
+			return
+		}
+		t.running = true
+		t.mu.Unlock()
+
+		r := t.record(scheduled)
+		go func() {
+			t.fire(r)
+
+			t.mu.Lock()
+			t.running = false
+			t.mu.Unlock()
+		}()
+	case OverlapQueue:
+		r := t.record(scheduled)
+
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if len(t.pending) >= maxQueuedRuns {
+			// the flow is always slower than the schedule, the oldest run is dropped
+			dropped := t.pending[0]
+			t.pending = t.pending[1:]
+			t.logger.Warnf("Dropping run of handler [%s] scheduled at %s, %d runs are already queued", t.handler.Name(), dropped.scheduled, maxQueuedRuns)
+		}
+		t.pending = append(t.pending, r)
+		if !t.running {
+			t.running = true
+			go t.drain()
+		}
+	default:
+		go t.fire(t.record(scheduled))
+	}
+}
+
+// drain runs the queued runs one after the other
+func (t *timer) drain() {
+	for {
+		t.mu.Lock()
+		stopped := false
+		select {
+		case <-t.quit:
+			stopped = true
+		default:
+		}
+		if len(t.pending) == 0 || stopped {
+			t.pending = nil
+			t.running = false
+			t.mu.Unlock()
+			return
+		}
+		r := t.pending[0]
+		t.pending = t.pending[1:]
+		t.mu.Unlock()
+
+		t.fire(r)
+	}
+}
+
+// record counts a run and persists it
+func (t *timer) record(scheduled time.Time) *run {
+
+	t.executions++
+	if t.store != nil {
+		err := t.store.save(t.key, runState{LastRun: scheduled, Executions: t.executions})
 		if err != nil {
-			t.logger.Error("Error scheduling repeating timer: ", err.Error())
+			t.logger.Errorf("Unable to save the last run of handler [%s]: %s", t.handler.Name(), err.Error())
 		}
+	}
 
-		t.timers = append(t.timers, timerJob)
-	} else {
+	return &run{scheduled: scheduled, iteration: t.executions}
+}
 
-		timerJob := scheduler.Every(startSeconds).Seconds()
+func (t *timer) setPaused(paused bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 
-		fn2 := func() {
-			t.logger.Debug("Executing first run of repeating timer")
+	t.paused = paused
+}
 
-			_, err := handler.Handle(context.Background(), nil)
-			if err != nil {
-				t.logger.Error("Error running handler: ", err.Error())
-			}
+func (t *timer) isPaused() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
 
-			if timerJob != nil {
-				timerJob.Quit <- true
-			}
+	return t.paused
+}
 
-			timerJob, err := scheduler.Every(repeatInterval).Seconds().NotImmediately().Run(fn)
-			if err != nil {
-				t.logger.Error("Error scheduling repeating timer: ", err.Error())
-			}
+// stopped returns whether the timer was stopped or replaced
+func (t *timer) stopped() bool {
+	select {
+	case <-t.quit:
+		return true
+	default:
+		return false
+	}
+}
 
-			t.timers = append(t.timers, timerJob)
-		}
+// ended returns whether the timer reached its maximum number of runs
+func (t *timer) ended() bool {
+	return t.maxExecutions > 0 && t.executions >= t.maxExecutions
+}
 
-		timerJob, err := timerJob.NotImmediately().Run(fn2)
-		if err != nil {
-			t.logger.Error("Error scheduling delayed start repeating timer: ", err.Error())
-		}
+func (t *timer) fire(r *run) {
+	t.logger.Debugf("Executing timer of handler [%s]", t.handler.Name())
 
-		t.timers = append(t.timers, timerJob)
+	output := &Output{
+		ScheduledTime:  toMillis(r.scheduled),
+		ActualFireTime: toMillis(time.Now()),
+		Iteration:      r.iteration,
+		HandlerName:    t.handler.Name(),
 	}
 
-	return nil
+	_, err := t.handler.Handle(context.Background(), output)
+	if err != nil {
+		t.logger.Error("Error running handler: ", err.Error())
+	}
 }
 
-//type PrintJob struct {
-//	Msg string
-//}
-//
-//func (j *PrintJob) Run() error {
-//	t.logger.Debug(j.Msg)
-//	return nil
-//}
+func toMillis(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
@@ -1,10 +1,16 @@
 package timer
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"net"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"flogo/core/action"
+	"flogo/core/support/log"
 	"flogo/core/support/test"
 	"flogo/core/trigger"
 	"github.com/stretchr/testify/assert"
@@ -54,3 +60,176 @@ func TestTimerTrigger_Initialize(t *testing.T) {
 	assert.Nil(t, err)
 
 }
+
+// blockingHandler runs until released
+type blockingHandler struct {
+	started int32
+	release chan struct{}
+}
+
+func (h *blockingHandler) Name() string {
+	return "blocking"
+}
+
+func (h *blockingHandler) Settings() map[string]interface{} {
+	return nil
+}
+
+func (h *blockingHandler) Handle(ctx context.Context, triggerData interface{}) (map[string]interface{}, error) {
+	atomic.AddInt32(&h.started, 1)
+	<-h.release
+	return nil, nil
+}
+
+func (h *blockingHandler) runs() int {
+	return int(atomic.LoadInt32(&h.started))
+}
+
+func TestTimer_Overlap(t *testing.T) {
+
+	scheduled := time.Now()
+
+	for policy, runs := range map[string]int{OverlapAllow: 3, OverlapSkip: 1, OverlapQueue: 1} {
+		handler := &blockingHandler{release: make(chan struct{})}
+		tm := &timer{handler: handler, overlap: policy, logger: log.RootLogger()}
+
+		for i := 0; i < 3; i++ {
+			tm.trigger(scheduled)
+		}
+		assert.Eventually(t, func() bool { return handler.runs() == runs }, time.Second, 10*time.Millisecond, policy)
+		time.Sleep(50 * time.Millisecond)
+		assert.Equal(t, runs, handler.runs(), policy)
+
+		close(handler.release)
+		if policy == OverlapQueue {
+			// the queued runs run once the previous one completed
+			assert.Eventually(t, func() bool { return handler.runs() == 3 }, time.Second, 10*time.Millisecond, policy)
+		}
+		if policy == OverlapSkip {
+			assert.Equal(t, 1, tm.executions)
+		}
+	}
+}
+
+func TestTimer_OverlapQueueBound(t *testing.T) {
+
+	scheduled := time.Date(2019, 5, 3, 10, 0, 0, 0, time.UTC)
+	handler := &blockingHandler{release: make(chan struct{})}
+	tm := &timer{handler: handler, overlap: OverlapQueue, logger: log.RootLogger()}
+
+	tm.trigger(scheduled)
+	assert.Eventually(t, func() bool { return handler.runs() == 1 }, time.Second, 10*time.Millisecond)
+
+	// the oldest queued runs are dropped
+	for i := 1; i <= maxQueuedRuns+2; i++ {
+		tm.trigger(scheduled.Add(time.Duration(i) * time.Minute))
+	}
+	tm.mu.Lock()
+	assert.Len(t, tm.pending, maxQueuedRuns)
+	assert.Equal(t, scheduled.Add(3*time.Minute), tm.pending[0].scheduled)
+	tm.mu.Unlock()
+
+	close(handler.release)
+	assert.Eventually(t, func() bool { return handler.runs() == maxQueuedRuns+1 }, time.Second, 10*time.Millisecond)
+}
+
+func TestTimer_Output(t *testing.T) {
+
+	handler := &countingHandler{}
+	tm := &timer{handler: handler, overlap: OverlapQueue, logger: log.RootLogger()}
+
+	scheduled := time.Date(2019, 5, 3, 10, 0, 0, 0, time.UTC)
+	tm.trigger(scheduled)
+	tm.trigger(scheduled.Add(time.Minute))
+	assert.Eventually(t, func() bool { return handler.runs() == 2 }, time.Second, 10*time.Millisecond)
+
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+
+	first := handler.outputs[0]
+	assert.Equal(t, int64(1556877600000), first.ScheduledTime)
+	assert.True(t, first.ActualFireTime > first.ScheduledTime)
+	assert.Equal(t, 1, first.Iteration)
+	assert.Equal(t, "counting", first.HandlerName)
+
+	second := handler.outputs[1]
+	assert.Equal(t, int64(1556877660000), second.ScheduledTime)
+	assert.Equal(t, 2, second.Iteration)
+
+	out := &Output{}
+	err := out.FromMap(first.ToMap())
+	assert.Nil(t, err)
+	assert.Equal(t, first, out)
+}
+
+// blockingLock acquires the runs once released, and records whether it was closed while acquiring
+type blockingLock struct {
+	acquiring int32
+	closed    int32
+	release   chan struct{}
+}
+
+func (l *blockingLock) acquire(key string, ttl time.Duration) (bool, error) {
+	atomic.AddInt32(&l.acquiring, 1)
+	defer atomic.AddInt32(&l.acquiring, -1)
+	<-l.release
+	return true, nil
+}
+
+func (l *blockingLock) close() error {
+	if atomic.LoadInt32(&l.acquiring) > 0 {
+		return errors.New("closed while acquiring")
+	}
+	atomic.StoreInt32(&l.closed, 1)
+	return nil
+}
+
+func TestTrigger_Stop(t *testing.T) {
+
+	l := &blockingLock{release: make(chan struct{})}
+	handler := &countingHandler{settings: map[string]interface{}{"repeatInterval": "10ms"}}
+	tgr := &Trigger{settings: &Settings{}, lock: l, lockTTL: time.Minute, handlers: []trigger.Handler{handler}, logger: log.RootLogger()}
+	assert.Nil(t, tgr.Start())
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&l.acquiring) == 1 }, time.Second, 10*time.Millisecond)
+
+	// the lock is closed once the timers returned
+	stopped := make(chan error)
+	go func() {
+		stopped <- tgr.Stop()
+	}()
+	select {
+	case <-stopped:
+		t.Fatal("stopped while a timer is running")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(l.release)
+	assert.Nil(t, <-stopped)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&l.closed))
+}
+
+func TestTrigger_StartFailure(t *testing.T) {
+
+	// the timers already scheduled are stopped and the lock is closed when a handler is invalid
+	l := &blockingLock{release: make(chan struct{})}
+	close(l.release)
+	valid := &countingHandler{settings: map[string]interface{}{"repeatInterval": "1h"}}
+	invalid := &countingHandler{settings: map[string]interface{}{"repeatInterval": "often"}}
+	tgr := &Trigger{id: "failing", settings: &Settings{}, lock: l, lockTTL: time.Minute, handlers: []trigger.Handler{valid, invalid}, logger: log.RootLogger()}
+	assert.NotNil(t, tgr.Start())
+	assert.Empty(t, tgr.Timers())
+	assert.Nil(t, tgr.lock)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&l.closed))
+
+	// the trigger is unregistered when the admin API can't listen
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer listener.Close()
+
+	settings := &Settings{AdminPort: listener.Addr().(*net.TCPAddr).Port}
+	tgr = &Trigger{id: "failing", settings: settings, handlers: []trigger.Handler{valid}, logger: log.RootLogger()}
+	assert.NotNil(t, tgr.Start())
+	assert.Empty(t, tgr.Timers())
+	_, registered := GetTrigger("failing")
+	assert.False(t, registered)
+}
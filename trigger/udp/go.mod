@@ -0,0 +1,6 @@
+module github.com/qingcloudhx/contrib/trigger/udp
+
+require (
+	flogo/core v0.9.0
+	github.com/stretchr/testify v1.3.0
+)
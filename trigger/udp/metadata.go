@@ -0,0 +1,68 @@
+package udp
+
+import (
+	"flogo/core/data/coerce"
+)
+
+const (
+	EncodingText   = "text"
+	EncodingHex    = "hex"
+	EncodingBase64 = "base64"
+)
+
+type Settings struct {
+	Host            string `md:"host"`                              // The host name or IP to listen on, defaults to all the interfaces
+	Port            int    `md:"port,required"`                     // The port to listen on
+	MulticastGroup  string `md:"multicastGroup"`                    // The multicast group to join (ex. 239.0.0.1), the host is ignored
+	Interface       string `md:"interface"`                         // The name of the network interface joining the multicast group (ex. eth0), defaults to the system interface
+	MaxDatagramSize int    `md:"maxDatagramSize"`                   // The max size in bytes of the datagrams received, larger datagrams are truncated, defaults to 65535
+	Encoding        string `md:"encoding,allowed(text,hex,base64)"` // How the binary datagrams are encoded in the data: text (default), hex or base64
+}
+
+type Output struct {
+	Data          string `md:"data"`          // The payload of the datagram, encoded with the encoding
+	SourceAddress string `md:"sourceAddress"` // The address of the sender (ex. 10.0.0.5:49152)
+}
+
+type Reply struct {
+	Data string `md:"data"` // The payload of the datagram to send back to the sender, encoded with the encoding
+}
+
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"data":          o.Data,
+		"sourceAddress": o.SourceAddress,
+	}
+}
+
+func (o *Output) FromMap(values map[string]interface{}) error {
+
+	var err error
+	o.Data, err = coerce.ToString(values["data"])
+	if err != nil {
+		return err
+	}
+	o.SourceAddress, err = coerce.ToString(values["sourceAddress"])
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *Reply) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"data": r.Data,
+	}
+}
+
+func (r *Reply) FromMap(values map[string]interface{}) error {
+
+	var err error
+	r.Data, err = coerce.ToString(values["data"])
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,222 @@
+package udp
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+
+	"flogo/core/data/metadata"
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+)
+
+const defaultMaxDatagramSize = 65535
+
+var triggerMd = trigger.NewMetadata(&Settings{}, &Output{}, &Reply{})
+
+func init() {
+	_ = trigger.Register(&Trigger{}, &Factory{})
+}
+
+type Factory struct {
+}
+
+// Metadata implements trigger.Factory.Metadata
+func (*Factory) Metadata() *trigger.Metadata {
+	return triggerMd
+}
+
+// New implements trigger.Factory.New
+func (*Factory) New(config *trigger.Config) (trigger.Trigger, error) {
+
+	s := &Settings{}
+	err := metadata.MapToStruct(config.Settings, s, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Encoding == "" {
+		s.Encoding = EncodingText
+	}
+	if s.Encoding != EncodingText && s.Encoding != EncodingHex && s.Encoding != EncodingBase64 {
+		return nil, fmt.Errorf("unsupported encoding '%s'", s.Encoding)
+	}
+	if s.MaxDatagramSize <= 0 {
+		s.MaxDatagramSize = defaultMaxDatagramSize
+	}
+
+	return &Trigger{settings: s}, nil
+}
+
+// Trigger receives UDP datagrams and invokes the actions for them, a reply being sent back to the sender
+type Trigger struct {
+	settings *Settings
+	logger   log.Logger
+	handlers []trigger.Handler
+
+	mu   sync.Mutex
+	conn *net.UDPConn
+	done chan struct{}
+}
+
+// Initialize implements trigger.Init.Initialize
+func (t *Trigger) Initialize(ctx trigger.InitContext) error {
+
+	t.logger = ctx.Logger()
+	t.handlers = ctx.GetHandlers()
+
+	return nil
+}
+
+// Start implements util.Managed.Start
+func (t *Trigger) Start() error {
+
+	conn, err := t.listen()
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	t.conn = conn
+	t.done = make(chan struct{})
+	t.mu.Unlock()
+
+	t.logger.Infof("Listening on %s", conn.LocalAddr())
+
+	go t.receive(conn, t.done)
+
+	return nil
+}
+
+// listen listens on the port, joining the multicast group when set
+func (t *Trigger) listen() (*net.UDPConn, error) {
+
+	s := t.settings
+
+	if s.MulticastGroup == "" {
+		addr, err := net.ResolveUDPAddr("udp", net.JoinHostPort(s.Host, strconv.Itoa(s.Port)))
+		if err != nil {
+			return nil, err
+		}
+		return net.ListenUDP("udp", addr)
+	}
+
+	group := net.ParseIP(s.MulticastGroup)
+	if group == nil || !group.IsMulticast() {
+		return nil, fmt.Errorf("invalid multicast group '%s'", s.MulticastGroup)
+	}
+
+	var iface *net.Interface
+	if s.Interface != "" {
+		var err error
+		iface, err = net.InterfaceByName(s.Interface)
+		if err != nil {
+			return nil, fmt.Errorf("unknown interface '%s': %v", s.Interface, err)
+		}
+	}
+
+	return net.ListenMulticastUDP("udp", iface, &net.UDPAddr{IP: group, Port: s.Port})
+}
+
+// Stop implements util.Managed.Stop
+func (t *Trigger) Stop() error {
+
+	t.mu.Lock()
+	conn, done := t.conn, t.done
+	t.conn = nil
+	t.mu.Unlock()
+
+	if conn == nil {
+		return nil
+	}
+
+	err := conn.Close()
+	<-done
+
+	return err
+}
+
+// receive handles the datagrams one after the other, until the connection is closed
+func (t *Trigger) receive(conn *net.UDPConn, done chan struct{}) {
+
+	defer close(done)
+
+	buf := make([]byte, t.settings.MaxDatagramSize)
+	for {
+		n, addr, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			t.mu.Lock()
+			stopped := t.conn != conn
+			t.mu.Unlock()
+			if stopped {
+				return
+			}
+			t.logger.Errorf("Error receiving datagram: %v", err)
+			continue
+		}
+
+		t.handle(conn, addr, buf[:n])
+	}
+}
+
+func (t *Trigger) handle(conn *net.UDPConn, addr *net.UDPAddr, datagram []byte) {
+
+	out := &Output{Data: t.encode(datagram), SourceAddress: addr.String()}
+
+	for _, handler := range t.handlers {
+		results, err := handler.Handle(context.Background(), out)
+		if err != nil {
+			t.logger.Errorf("Error handling datagram from %s: %v", addr, err)
+			continue
+		}
+
+		reply := &Reply{}
+		err = reply.FromMap(results)
+		if err != nil {
+			t.logger.Errorf("Invalid reply to datagram from %s: %v", addr, err)
+			continue
+		}
+		if reply.Data == "" {
+			continue
+		}
+
+		data, err := t.decode(reply.Data)
+		if err != nil {
+			t.logger.Errorf("Invalid %s reply to datagram from %s: %v", t.settings.Encoding, addr, err)
+			continue
+		}
+
+		_, err = conn.WriteToUDP(data, addr)
+		if err != nil {
+			t.logger.Errorf("Error sending reply to %s: %v", addr, err)
+		}
+	}
+}
+
+func (t *Trigger) encode(datagram []byte) string {
+
+	switch t.settings.Encoding {
+	case EncodingHex:
+		return hex.EncodeToString(datagram)
+	case EncodingBase64:
+		return base64.StdEncoding.EncodeToString(datagram)
+	}
+
+	return string(datagram)
+}
+
+func (t *Trigger) decode(data string) ([]byte, error) {
+
+	switch t.settings.Encoding {
+	case EncodingHex:
+		return hex.DecodeString(data)
+	case EncodingBase64:
+		return base64.StdEncoding.DecodeString(data)
+	}
+
+	return []byte(data), nil
+}
@@ -0,0 +1,165 @@
+package udp
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"flogo/core/action"
+	"flogo/core/api"
+	"flogo/core/support/test"
+	"flogo/core/trigger"
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig string = `{
+	"id": "trigger-udp",
+	"ref": "github.com/qingcloudhx/contrib/trigger/udp",
+	"settings": {
+	  "host": "127.0.0.1",
+	  "port": 0
+	},
+	"handlers": [
+	  {
+		"action": {
+		  "id": "test"
+		}
+	  }
+	]
+}`
+
+// send returns a handler function sending the outputs it handles and replying with the result of the function
+func send(outputs chan *Output, reply func(out *Output) map[string]interface{}) api.HandlerFunc {
+	return func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+		out := &Output{}
+		if err := out.FromMap(inputs); err != nil {
+			return nil, err
+		}
+		outputs <- out
+		if reply == nil {
+			return nil, nil
+		}
+		return reply(out), nil
+	}
+}
+
+func next(t *testing.T, outputs chan *Output) *Output {
+	select {
+	case out := <-outputs:
+		return out
+	case <-time.After(5 * time.Second):
+		t.Fatal("no datagram handled")
+		return nil
+	}
+}
+
+// initTrigger returns a trigger initialized with the settings and a handler running the function
+func initTrigger(t *testing.T, settings map[string]interface{}, f api.HandlerFunc) *Trigger {
+
+	config := &trigger.Config{}
+	err := json.Unmarshal([]byte(testConfig), config)
+	assert.Nil(t, err)
+	config.Settings = settings
+
+	trg, err := test.InitTrigger(&Factory{}, config, map[string]action.Action{"test": api.NewProxyAction(f)})
+	assert.Nil(t, err)
+
+	return trg.(*Trigger)
+}
+
+func TestTrigger(t *testing.T) {
+	outputs := make(chan *Output, 10)
+	tgr := initTrigger(t, map[string]interface{}{"host": "127.0.0.1", "port": 0}, send(outputs, func(out *Output) map[string]interface{} {
+		if out.Data == "silent" {
+			return nil
+		}
+		return map[string]interface{}{"data": strings.ToUpper(out.Data)}
+	}))
+	assert.Nil(t, tgr.Start())
+	defer tgr.Stop()
+
+	conn, err := net.Dial("udp", tgr.conn.LocalAddr().String())
+	assert.Nil(t, err)
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	_, err = conn.Write([]byte("silent"))
+	assert.Nil(t, err)
+	_, err = conn.Write([]byte("hello"))
+	assert.Nil(t, err)
+
+	buf := make([]byte, 100)
+	n, err := conn.Read(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, "HELLO", string(buf[:n]))
+
+	out := next(t, outputs)
+	assert.Equal(t, "silent", out.Data)
+	assert.Equal(t, conn.LocalAddr().String(), out.SourceAddress)
+	assert.Equal(t, "hello", next(t, outputs).Data)
+}
+
+func TestTriggerHex(t *testing.T) {
+	outputs := make(chan *Output, 10)
+	tgr := initTrigger(t, map[string]interface{}{"host": "127.0.0.1", "port": 0, "encoding": "hex"}, send(outputs, func(out *Output) map[string]interface{} {
+		return map[string]interface{}{"data": "06"}
+	}))
+	assert.Nil(t, tgr.Start())
+	defer tgr.Stop()
+
+	conn, err := net.Dial("udp", tgr.conn.LocalAddr().String())
+	assert.Nil(t, err)
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	_, err = conn.Write([]byte{0x02, 0xff, 0x03})
+	assert.Nil(t, err)
+
+	buf := make([]byte, 100)
+	n, err := conn.Read(buf)
+	assert.Nil(t, err)
+	assert.Equal(t, []byte{0x06}, buf[:n])
+	assert.Equal(t, "02ff03", next(t, outputs).Data)
+}
+
+func TestTriggerMulticast(t *testing.T) {
+	outputs := make(chan *Output, 10)
+	tt := initTrigger(t, map[string]interface{}{"port": 0, "multicastGroup": "239.1.2.3"}, send(outputs, nil))
+
+	err := tt.Start()
+	if err != nil {
+		t.Skipf("multicast unavailable: %v", err)
+	}
+	defer tt.Stop()
+
+	port := tt.conn.LocalAddr().(*net.UDPAddr).Port
+	conn, err := net.DialUDP("udp", nil, &net.UDPAddr{IP: net.ParseIP("239.1.2.3"), Port: port})
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("announce"))
+	assert.Nil(t, err)
+
+	select {
+	case out := <-outputs:
+		assert.Equal(t, "announce", out.Data)
+	case <-time.After(time.Second):
+		t.Skip("multicast datagrams not routed")
+	}
+}
+
+func TestFactory(t *testing.T) {
+	f := &Factory{}
+	tgr, err := f.New(&trigger.Config{Settings: map[string]interface{}{"port": 9999}})
+	assert.Nil(t, err)
+	s := tgr.(*Trigger).settings
+	assert.Equal(t, EncodingText, s.Encoding)
+	assert.Equal(t, defaultMaxDatagramSize, s.MaxDatagramSize)
+
+	tgr, err = f.New(&trigger.Config{Settings: map[string]interface{}{"port": 0, "multicastGroup": "10.0.0.1"}})
+	assert.Nil(t, err)
+	assert.NotNil(t, tgr.Start())
+}
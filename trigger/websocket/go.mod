@@ -0,0 +1,7 @@
+module github.com/qingcloudhx/contrib/trigger/websocket
+
+require (
+	flogo/core v0.9.0
+	github.com/gorilla/websocket v1.5.3
+	github.com/stretchr/testify v1.3.0
+)
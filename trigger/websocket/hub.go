@@ -0,0 +1,220 @@
+package websocket
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const writeTimeout = 10 * time.Second
+
+// connection is an open WebSocket connection, the writes are serialized as the
+// underlying connection supports a single concurrent writer
+type connection struct {
+	id   string
+	conn *websocket.Conn
+
+	writeMu sync.Mutex
+}
+
+func (c *connection) write(data interface{}) error {
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	_ = c.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+
+	switch t := data.(type) {
+	case string:
+		return c.conn.WriteMessage(websocket.TextMessage, []byte(t))
+	case []byte:
+		return c.conn.WriteMessage(websocket.BinaryMessage, t)
+	}
+
+	return c.conn.WriteJSON(data)
+}
+
+func (c *connection) ping() error {
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	return c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout))
+}
+
+func (c *connection) close(code int, text string) {
+
+	c.writeMu.Lock()
+	_ = c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), time.Now().Add(time.Second))
+	c.writeMu.Unlock()
+
+	_ = c.conn.Close()
+}
+
+// hub tracks the open connections of all the WebSocket triggers of the engine and
+// the broadcast groups they joined
+type hub struct {
+	mu          sync.RWMutex
+	connections map[string]*connection
+	groups      map[string]map[string]*connection
+}
+
+var connections = &hub{connections: make(map[string]*connection), groups: make(map[string]map[string]*connection)}
+
+func (h *hub) add(conn *websocket.Conn) *connection {
+
+	c := &connection{id: newConnectionID(), conn: conn}
+
+	h.mu.Lock()
+	h.connections[c.id] = c
+	h.mu.Unlock()
+
+	return c
+}
+
+func (h *hub) remove(c *connection) {
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.connections, c.id)
+	for group, members := range h.groups {
+		delete(members, c.id)
+		if len(members) == 0 {
+			delete(h.groups, group)
+		}
+	}
+}
+
+func (h *hub) get(id string) (*connection, error) {
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	c, ok := h.connections[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown WebSocket connection '%s'", id)
+	}
+
+	return c, nil
+}
+
+func (h *hub) join(id string, groups []string) error {
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	c, ok := h.connections[id]
+	if !ok {
+		return fmt.Errorf("unknown WebSocket connection '%s'", id)
+	}
+
+	for _, group := range groups {
+		members, ok := h.groups[group]
+		if !ok {
+			members = make(map[string]*connection)
+			h.groups[group] = members
+		}
+		members[id] = c
+	}
+
+	return nil
+}
+
+func (h *hub) leave(id string, groups []string) error {
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.connections[id]; !ok {
+		return fmt.Errorf("unknown WebSocket connection '%s'", id)
+	}
+
+	for _, group := range groups {
+		if members, ok := h.groups[group]; ok {
+			delete(members, id)
+			if len(members) == 0 {
+				delete(h.groups, group)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (h *hub) members(group string) []*connection {
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	all := h.connections
+	if group != "" {
+		all = h.groups[group]
+	}
+
+	members := make([]*connection, 0, len(all))
+	for _, c := range all {
+		members = append(members, c)
+	}
+
+	return members
+}
+
+// Send sends a message to the connection with the given id, strings are sent as text
+// messages, bytes as binary messages and other values as JSON
+func Send(connectionID string, data interface{}) error {
+
+	c, err := connections.get(connectionID)
+	if err != nil {
+		return err
+	}
+
+	return c.write(data)
+}
+
+// Broadcast sends a message to all the connections of a group, or to all the open
+// connections if the group is empty, and returns the number of connections it was sent to
+func Broadcast(group string, data interface{}) int {
+
+	sent := 0
+	for _, c := range connections.members(group) {
+		// a failed connection is closed and removed by its read loop
+		if c.write(data) == nil {
+			sent++
+		}
+	}
+
+	return sent
+}
+
+// Join adds the connection with the given id to broadcast groups
+func Join(connectionID string, groups ...string) error {
+	return connections.join(connectionID, groups)
+}
+
+// Leave removes the connection with the given id from broadcast groups
+func Leave(connectionID string, groups ...string) error {
+	return connections.leave(connectionID, groups)
+}
+
+// Close closes the connection with the given id
+func Close(connectionID string) error {
+
+	c, err := connections.get(connectionID)
+	if err != nil {
+		return err
+	}
+
+	c.close(websocket.CloseNormalClosure, "")
+	return nil
+}
+
+func newConnectionID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,109 @@
+package websocket
+
+import (
+	"flogo/core/data/coerce"
+)
+
+const (
+	EventConnect    = "connect"
+	EventMessage    = "message"
+	EventDisconnect = "disconnect"
+)
+
+type Settings struct {
+	Port           int    `md:"port,required"`  // The port to listen on
+	AllowedOrigins string `md:"allowedOrigins"` // The comma separated origins allowed to connect, or *, only same origin connections are accepted if not specified
+	MaxMessageSize int64  `md:"maxMessageSize"` // The max size in bytes of the inbound messages, the connection is closed when exceeded
+	PingInterval   string `md:"pingInterval"`   // How often the connections are pinged to detect dead peers (ex. 30s), not pinged if not specified
+	CertFile       string `md:"certFile"`       // The PEM file of the server certificate, enables TLS
+	KeyFile        string `md:"keyFile"`        // The PEM file of the server private key
+}
+
+type HandlerSettings struct {
+	Path   string `md:"path,required"` // The path the WebSocket connections are accepted on (ex. /chat)
+	Groups string `md:"groups"`        // The comma separated broadcast groups the connections join when they connect
+}
+
+type Output struct {
+	Event        string            `md:"event"`        // The event of the connection: connect, message or disconnect
+	ConnectionID string            `md:"connectionId"` // The id of the connection, used to send messages back to it
+	Path         string            `md:"path"`         // The path of the connection
+	Headers      map[string]string `md:"headers"`      // The headers of the upgrade request
+	QueryParams  map[string]string `md:"queryParams"`  // The query parameters of the upgrade request
+	Content      interface{}       `md:"content"`      // The message received, parsed when it is JSON
+}
+
+type Reply struct {
+	Data  interface{} `md:"data"`  // The message to send back to the connection, objects are sent as JSON
+	Join  string      `md:"join"`  // The comma separated broadcast groups the connection joins
+	Leave string      `md:"leave"` // The comma separated broadcast groups the connection leaves
+	Close bool        `md:"close"` // Close the connection, once the data is sent
+}
+
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"event":        o.Event,
+		"connectionId": o.ConnectionID,
+		"path":         o.Path,
+		"headers":      o.Headers,
+		"queryParams":  o.QueryParams,
+		"content":      o.Content,
+	}
+}
+
+func (o *Output) FromMap(values map[string]interface{}) error {
+
+	var err error
+	o.Event, err = coerce.ToString(values["event"])
+	if err != nil {
+		return err
+	}
+	o.ConnectionID, err = coerce.ToString(values["connectionId"])
+	if err != nil {
+		return err
+	}
+	o.Path, err = coerce.ToString(values["path"])
+	if err != nil {
+		return err
+	}
+	o.Headers, err = coerce.ToParams(values["headers"])
+	if err != nil {
+		return err
+	}
+	o.QueryParams, err = coerce.ToParams(values["queryParams"])
+	if err != nil {
+		return err
+	}
+	o.Content = values["content"]
+
+	return nil
+}
+
+func (r *Reply) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"data":  r.Data,
+		"join":  r.Join,
+		"leave": r.Leave,
+		"close": r.Close,
+	}
+}
+
+func (r *Reply) FromMap(values map[string]interface{}) error {
+
+	var err error
+	r.Data = values["data"]
+	r.Join, err = coerce.ToString(values["join"])
+	if err != nil {
+		return err
+	}
+	r.Leave, err = coerce.ToString(values["leave"])
+	if err != nil {
+		return err
+	}
+	r.Close, err = coerce.ToBool(values["close"])
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
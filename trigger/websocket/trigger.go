@@ -0,0 +1,333 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"flogo/core/data/metadata"
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+	"github.com/gorilla/websocket"
+)
+
+var triggerMd = trigger.NewMetadata(&Settings{}, &HandlerSettings{}, &Output{}, &Reply{})
+
+func init() {
+	_ = trigger.Register(&Trigger{}, &Factory{})
+}
+
+type Factory struct {
+}
+
+// Metadata implements trigger.Factory.Metadata
+func (*Factory) Metadata() *trigger.Metadata {
+	return triggerMd
+}
+
+// New implements trigger.Factory.New
+func (*Factory) New(config *trigger.Config) (trigger.Trigger, error) {
+
+	s := &Settings{}
+	err := metadata.MapToStruct(config.Settings, s, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Trigger{settings: s}, nil
+}
+
+// Trigger is a WebSocket server, each handler accepts the connections of a path
+type Trigger struct {
+	settings     *Settings
+	logger       log.Logger
+	pingInterval time.Duration
+
+	mux      *http.ServeMux
+	server   *http.Server
+	listener net.Listener
+
+	mu       sync.Mutex
+	shutdown chan struct{}
+}
+
+// Initialize implements trigger.Init.Initialize
+func (t *Trigger) Initialize(ctx trigger.InitContext) error {
+
+	t.logger = ctx.Logger()
+
+	if t.settings.PingInterval != "" {
+		interval, err := time.ParseDuration(t.settings.PingInterval)
+		if err != nil {
+			return fmt.Errorf("invalid ping interval '%s': %v", t.settings.PingInterval, err)
+		}
+		t.pingInterval = interval
+	}
+
+	upgrader := &websocket.Upgrader{}
+	if origins := splitList(t.settings.AllowedOrigins); len(origins) > 0 {
+		upgrader.CheckOrigin = func(r *http.Request) bool {
+			origin := r.Header.Get("Origin")
+			if origin == "" {
+				return true
+			}
+			for _, o := range origins {
+				if o == "*" || strings.EqualFold(o, origin) {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	// otherwise only same origin connections are accepted
+
+	t.mux = http.NewServeMux()
+	paths := make(map[string]bool)
+
+	for _, handler := range ctx.GetHandlers() {
+
+		s := &HandlerSettings{}
+		err := metadata.MapToStruct(handler.Settings(), s, true)
+		if err != nil {
+			return err
+		}
+
+		if paths[s.Path] {
+			return fmt.Errorf("more than one handler for path '%s'", s.Path)
+		}
+		paths[s.Path] = true
+
+		h := &wsHandler{trigger: t, handler: handler, upgrader: upgrader, groups: splitList(s.Groups)}
+		t.mux.Handle(s.Path, h)
+	}
+
+	return nil
+}
+
+// Start implements util.Managed.Start
+func (t *Trigger) Start() error {
+
+	ln, err := net.Listen("tcp", ":"+strconv.Itoa(t.settings.Port))
+	if err != nil {
+		return err
+	}
+	t.listener = ln
+
+	// a shut down server can't serve again, each start uses a new one
+	server := &http.Server{Handler: t.mux}
+	t.server = server
+
+	t.mu.Lock()
+	t.shutdown = make(chan struct{})
+	t.mu.Unlock()
+
+	t.logger.Infof("Listening on port %d", t.settings.Port)
+
+	go func() {
+		var err error
+		if t.settings.CertFile != "" {
+			err = server.ServeTLS(ln, t.settings.CertFile, t.settings.KeyFile)
+		} else {
+			err = server.Serve(ln)
+		}
+		if err != nil && err != http.ErrServerClosed {
+			t.logger.Errorf("WebSocket server stopped: %v", err)
+		}
+	}()
+
+	return nil
+}
+
+// Stop implements util.Managed.Stop
+func (t *Trigger) Stop() error {
+
+	if t.listener == nil {
+		return nil
+	}
+	t.listener = nil
+
+	// the hijacked connections aren't closed by the server
+	t.mu.Lock()
+	close(t.shutdown)
+	t.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return t.server.Shutdown(ctx)
+}
+
+// wsHandler accepts the WebSocket connections of a path and invokes the action for
+// their connect, message and disconnect events
+type wsHandler struct {
+	trigger  *Trigger
+	handler  trigger.Handler
+	upgrader *websocket.Upgrader
+	groups   []string
+}
+
+func (h *wsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+
+	t := h.trigger
+
+	conn, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader already replied with an error
+		t.logger.Debugf("Error upgrading to WebSocket: %v", err)
+		return
+	}
+
+	if t.settings.MaxMessageSize > 0 {
+		conn.SetReadLimit(t.settings.MaxMessageSize)
+	}
+
+	c := connections.add(conn)
+	_ = connections.join(c.id, h.groups)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	t.mu.Lock()
+	shutdown := t.shutdown
+	t.mu.Unlock()
+	go h.watch(ctx, c, shutdown)
+
+	out := &Output{
+		ConnectionID: c.id,
+		Path:         r.URL.Path,
+		Headers:      make(map[string]string, len(r.Header)),
+		QueryParams:  make(map[string]string),
+	}
+	for name, values := range r.Header {
+		out.Headers[name] = strings.Join(values, ",")
+	}
+	for name, values := range r.URL.Query() {
+		out.QueryParams[name] = strings.Join(values, ",")
+	}
+
+	open := h.invoke(ctx, c, out, EventConnect, nil)
+
+	for open {
+		msgType, msg, err := conn.ReadMessage()
+		if err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				t.logger.Debugf("WebSocket connection [%s] closed: %v", c.id, err)
+			}
+			break
+		}
+
+		var content interface{}
+		if msgType == websocket.BinaryMessage {
+			content = msg
+		} else if err := json.Unmarshal(msg, &content); err != nil {
+			// not json, the text is passed as is
+			content = string(msg)
+		}
+
+		open = h.invoke(ctx, c, out, EventMessage, content)
+	}
+
+	connections.remove(c)
+	_ = conn.Close()
+
+	h.invoke(ctx, c, out, EventDisconnect, nil)
+}
+
+// watch pings the connection and closes it when the trigger stops
+func (h *wsHandler) watch(ctx context.Context, c *connection, shutdown chan struct{}) {
+
+	var ping <-chan time.Time
+	if interval := h.trigger.pingInterval; interval > 0 {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		ping = ticker.C
+
+		// the connection is considered dead when no pong is received in time
+		_ = c.conn.SetReadDeadline(time.Now().Add(2 * interval))
+		c.conn.SetPongHandler(func(string) error {
+			return c.conn.SetReadDeadline(time.Now().Add(2 * interval))
+		})
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-shutdown:
+			c.close(websocket.CloseGoingAway, "server shutting down")
+			return
+		case <-ping:
+			if err := c.ping(); err != nil {
+				_ = c.conn.Close()
+				return
+			}
+		}
+	}
+}
+
+// invoke invokes the action for an event of the connection, and returns false when
+// the connection has to be closed
+func (h *wsHandler) invoke(ctx context.Context, c *connection, out *Output, event string, content interface{}) bool {
+
+	logger := h.trigger.logger
+
+	eventOut := *out
+	eventOut.Event = event
+	eventOut.Content = content
+
+	results, err := h.handler.Handle(ctx, &eventOut)
+	if err != nil {
+		logger.Errorf("Error handling WebSocket %s of connection [%s]: %v", event, c.id, err)
+		return true
+	}
+
+	if event == EventDisconnect {
+		return false
+	}
+
+	reply := &Reply{}
+	err = reply.FromMap(results)
+	if err != nil {
+		logger.Errorf("Invalid reply of WebSocket %s of connection [%s]: %v", event, c.id, err)
+		return true
+	}
+
+	if groups := splitList(reply.Join); len(groups) > 0 {
+		_ = connections.join(c.id, groups)
+	}
+	if groups := splitList(reply.Leave); len(groups) > 0 {
+		_ = connections.leave(c.id, groups)
+	}
+
+	if reply.Data != nil {
+		if err := c.write(reply.Data); err != nil {
+			logger.Debugf("Error writing to WebSocket connection [%s]: %v", c.id, err)
+			return false
+		}
+	}
+
+	if reply.Close {
+		c.close(websocket.CloseNormalClosure, "")
+		return false
+	}
+
+	return true
+}
+
+func splitList(list string) []string {
+
+	var values []string
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
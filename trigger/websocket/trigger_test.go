@@ -0,0 +1,247 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"flogo/core/action"
+	"flogo/core/api"
+	"flogo/core/support/test"
+	"flogo/core/trigger"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig string = `{
+	"id": "trigger-websocket",
+	"ref": "github.com/qingcloudhx/contrib/trigger/websocket",
+	"settings": {
+	  "port": 0
+	},
+	"handlers": [
+	  {
+		"settings": {
+		  "path": "/chat",
+		  "groups": "lobby"
+		},
+		"action": {
+		  "id": "test"
+		}
+	  }
+	]
+}`
+
+// startTrigger starts a trigger whose handler of the path replies with the result of the function
+func startTrigger(t *testing.T, path string, reply func(out *Output) map[string]interface{}) *Trigger {
+
+	config := &trigger.Config{}
+	err := json.Unmarshal([]byte(testConfig), config)
+	assert.Nil(t, err)
+	config.Handlers[0].Settings["path"] = path
+
+	trg, err := test.InitTrigger(&Factory{}, config, map[string]action.Action{"test": api.NewProxyAction(
+		func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+			out := &Output{}
+			if err := out.FromMap(inputs); err != nil {
+				return nil, err
+			}
+			return reply(out), nil
+		})})
+	assert.Nil(t, err)
+	assert.Nil(t, trg.Start())
+
+	return trg.(*Trigger)
+}
+
+func dial(t *testing.T, tgr *Trigger, path string) *websocket.Conn {
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws://"+tgr.listener.Addr().String()+path, nil)
+	assert.Nil(t, err)
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	return conn
+}
+
+func TestTrigger_Events(t *testing.T) {
+
+	var mu sync.Mutex
+	var events []string
+	recorded := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), events...)
+	}
+
+	tgr := startTrigger(t, "/chat", func(out *Output) map[string]interface{} {
+		mu.Lock()
+		events = append(events, out.Event)
+		mu.Unlock()
+
+		switch out.Event {
+		case EventConnect:
+			return map[string]interface{}{"data": "welcome " + out.QueryParams["user"]}
+		case EventMessage:
+			content := out.Content.(map[string]interface{})
+			return map[string]interface{}{"data": map[string]interface{}{"echo": content["text"]}, "join": content["room"]}
+		}
+		return nil
+	})
+	defer tgr.Stop()
+
+	conn := dial(t, tgr, "/chat?user=bob")
+
+	_, msg, err := conn.ReadMessage()
+	assert.Nil(t, err)
+	assert.Equal(t, "welcome bob", string(msg))
+
+	err = conn.WriteJSON(map[string]interface{}{"text": "hello", "room": "general"})
+	assert.Nil(t, err)
+
+	var reply map[string]interface{}
+	err = conn.ReadJSON(&reply)
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", reply["echo"])
+
+	// the connection joined the group of the handler and the group of the reply
+	assert.Equal(t, 1, Broadcast("lobby", "to lobby"))
+	_, msg, err = conn.ReadMessage()
+	assert.Nil(t, err)
+	assert.Equal(t, "to lobby", string(msg))
+
+	assert.Equal(t, 1, Broadcast("general", "to general"))
+	_, msg, err = conn.ReadMessage()
+	assert.Nil(t, err)
+	assert.Equal(t, "to general", string(msg))
+
+	assert.Equal(t, 0, Broadcast("unknown", "to nobody"))
+
+	err = conn.Close()
+	assert.Nil(t, err)
+
+	for i := 0; i < 100 && len(recorded()) < 3; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, []string{EventConnect, EventMessage, EventDisconnect}, recorded())
+	assert.Equal(t, 0, Broadcast("lobby", "to lobby"))
+}
+
+func TestSend(t *testing.T) {
+
+	ids := make(chan string, 2)
+	tgr := startTrigger(t, "/notify", func(out *Output) map[string]interface{} {
+		if out.Event == EventConnect {
+			ids <- out.ConnectionID
+		}
+		return nil
+	})
+	defer tgr.Stop()
+
+	first := dial(t, tgr, "/notify")
+	defer first.Close()
+	firstID := <-ids
+
+	second := dial(t, tgr, "/notify")
+	defer second.Close()
+	secondID := <-ids
+
+	err := Send(secondID, []byte{1, 2})
+	assert.Nil(t, err)
+	msgType, msg, err := second.ReadMessage()
+	assert.Nil(t, err)
+	assert.Equal(t, websocket.BinaryMessage, msgType)
+	assert.Equal(t, []byte{1, 2}, msg)
+
+	err = Join(firstID, "alerts")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, Broadcast("alerts", "alert"))
+	_, msg, err = first.ReadMessage()
+	assert.Nil(t, err)
+	assert.Equal(t, "alert", string(msg))
+
+	err = Leave(firstID, "alerts")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, Broadcast("alerts", "alert"))
+
+	err = Close(firstID)
+	assert.Nil(t, err)
+	_, _, err = first.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure))
+
+	err = Send("unknown", "hello")
+	assert.NotNil(t, err)
+	err = Join("unknown", "alerts")
+	assert.NotNil(t, err)
+}
+
+func TestTrigger_Stop(t *testing.T) {
+
+	tgr := startTrigger(t, "/chat", func(out *Output) map[string]interface{} {
+		return map[string]interface{}{"close": out.Event == EventMessage}
+	})
+
+	conn := dial(t, tgr, "/chat")
+	defer conn.Close()
+
+	err := tgr.Stop()
+	assert.Nil(t, err)
+
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway))
+}
+
+func TestTrigger_Restart(t *testing.T) {
+
+	tgr := startTrigger(t, "/chat", func(out *Output) map[string]interface{} {
+		if out.Event == EventConnect {
+			return map[string]interface{}{"data": "welcome"}
+		}
+		return nil
+	})
+
+	// the trigger accepts the connections again once restarted, and closes them when stopped again
+	for i := 0; i < 2; i++ {
+		if i > 0 {
+			assert.Nil(t, tgr.Start())
+		}
+		conn := dial(t, tgr, "/chat")
+		_, msg, err := conn.ReadMessage()
+		assert.Nil(t, err)
+		assert.Equal(t, "welcome", string(msg))
+
+		assert.Nil(t, tgr.Stop())
+		_, _, err = conn.ReadMessage()
+		assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway))
+		conn.Close()
+	}
+}
+
+func TestInitialize_Errors(t *testing.T) {
+
+	actions := map[string]action.Action{"test": test.NewDummyAction(func() {
+		//do nothing
+	})}
+
+	config := &trigger.Config{}
+	err := json.Unmarshal([]byte(testConfig), config)
+	assert.Nil(t, err)
+	config.Settings["pingInterval"] = "soon"
+	_, err = test.InitTrigger(&Factory{}, config, actions)
+	assert.NotNil(t, err)
+
+	// the paths have a single handler
+	app := api.NewApp()
+	trg := app.NewTrigger(&Trigger{}, map[string]interface{}{"port": 0})
+	for i := 0; i < 2; i++ {
+		handler, err := trg.NewHandler(map[string]interface{}{"path": "/chat"})
+		assert.Nil(t, err)
+		_, err = handler.NewAction(func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+			return nil, nil
+		})
+		assert.Nil(t, err)
+	}
+	_, err = api.NewEngine(app)
+	assert.NotNil(t, err)
+}
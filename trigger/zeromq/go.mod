@@ -0,0 +1,7 @@
+module github.com/qingcloudhx/contrib/trigger/zeromq
+
+require (
+	flogo/core v0.9.0
+	github.com/pebbe/zmq4 v1.2.11
+	github.com/stretchr/testify v1.3.0
+)
@@ -0,0 +1,62 @@
+package zeromq
+
+import (
+	"flogo/core/data/coerce"
+)
+
+const (
+	SocketSub  = "sub"
+	SocketPull = "pull"
+)
+
+type Settings struct {
+	CurvePublicKey  string `md:"curvePublicKey"`  // The Z85 encoded CURVE public key of the trigger, a temporary key pair is used by the sockets that connect if not specified
+	CurveSecretKey  string `md:"curveSecretKey"`  // The Z85 encoded CURVE secret key of the trigger, enables CURVE security on the sockets that bind
+	CurveClientKeys string `md:"curveClientKeys"` // The comma separated Z85 encoded public keys of the clients allowed to connect to the sockets that bind, defaults to all the clients
+}
+
+type HandlerSettings struct {
+	SocketType     string `md:"socketType,allowed(sub,pull)"` // The type of the socket: sub (default) or pull
+	Endpoints      string `md:"endpoints,required"`           // The comma separated endpoints of the socket (ex. tcp://localhost:5556)
+	Bind           bool   `md:"bind"`                         // Bind the endpoints instead of connecting to them
+	Topics         string `md:"topics"`                       // The comma separated topics a sub socket subscribes to, the prefixes of the messages, defaults to all the messages
+	CurveServerKey string `md:"curveServerKey"`               // The Z85 encoded CURVE public key of the server the socket connects to, enables CURVE security
+	ReceiveHWM     int    `md:"receiveHwm"`                   // The maximum number of messages queued by the socket, the messages received beyond are dropped by sub sockets, defaults to 1000
+	Concurrency    int    `md:"concurrency"`                  // The number of messages handled at the same time, defaults to 1 which preserves their order
+}
+
+type Output struct {
+	Topic   string        `md:"topic"`   // The topic of the subscription the message matches, for sub sockets
+	Frames  []interface{} `md:"frames"`  // The frames of the message
+	Payload string        `md:"payload"` // The last frame of the message, its data when the first frames are its envelope
+	Content interface{}   `md:"content"` // The payload parsed, when it is JSON
+}
+
+func (o *Output) ToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"topic":   o.Topic,
+		"frames":  o.Frames,
+		"payload": o.Payload,
+		"content": o.Content,
+	}
+}
+
+func (o *Output) FromMap(values map[string]interface{}) error {
+
+	var err error
+	o.Topic, err = coerce.ToString(values["topic"])
+	if err != nil {
+		return err
+	}
+	o.Frames, err = coerce.ToArray(values["frames"])
+	if err != nil {
+		return err
+	}
+	o.Payload, err = coerce.ToString(values["payload"])
+	if err != nil {
+		return err
+	}
+	o.Content = values["content"]
+
+	return nil
+}
@@ -0,0 +1,295 @@
+package zeromq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+	zmq "github.com/pebbe/zmq4"
+)
+
+const (
+	authDomain     = "flogo"
+	receiveTimeout = 500 * time.Millisecond
+	retryInterval  = 5 * time.Second
+
+	// the length of the Z85 encoding of a CURVE key
+	keyLength = 40
+)
+
+// socket is the part of a ZeroMQ socket used by a subscriber
+type socket interface {
+	RecvMessageBytes(flags zmq.Flag) ([][]byte, error)
+	Close() error
+}
+
+// subscriber receives the messages of the socket of a handler, a socket is used by a single
+// goroutine and is opened again when it fails
+type subscriber struct {
+	handler  trigger.Handler
+	settings *HandlerSettings
+	keys     *Settings
+	logger   log.Logger
+
+	endpoints []string
+	topics    []string
+	open      func() (socket, error)
+	done      chan struct{}
+}
+
+func newSubscriber(handler trigger.Handler, s *HandlerSettings, keys *Settings, logger log.Logger) (*subscriber, error) {
+
+	if s.SocketType == "" {
+		s.SocketType = SocketSub
+	}
+	if s.Concurrency <= 0 {
+		s.Concurrency = 1
+	}
+
+	sub := &subscriber{handler: handler, settings: s, keys: keys, logger: logger}
+
+	sub.endpoints = splitList(s.Endpoints)
+	if len(sub.endpoints) == 0 {
+		return nil, errors.New("an endpoint is required")
+	}
+	for _, endpoint := range sub.endpoints {
+		if !strings.Contains(endpoint, "://") {
+			return nil, fmt.Errorf("invalid endpoint '%s'", endpoint)
+		}
+	}
+
+	sub.topics = splitList(s.Topics)
+	if len(sub.topics) > 0 && s.SocketType != SocketSub {
+		return nil, errors.New("topics are only supported by sub sockets")
+	}
+
+	if s.CurveServerKey != "" {
+		if s.Bind {
+			return nil, errors.New("a server key is only supported by the sockets that connect")
+		}
+		if len(s.CurveServerKey) != keyLength {
+			return nil, errors.New("invalid CURVE server key")
+		}
+	}
+
+	sub.open = sub.openSocket
+
+	return sub, nil
+}
+
+// openSocket opens the socket, with CURVE security as a server when it binds and the secret key of
+// the trigger is specified, or as a client when it connects and the key of the server is specified
+func (sub *subscriber) openSocket() (socket, error) {
+
+	s := sub.settings
+
+	socketType := zmq.SUB
+	if s.SocketType == SocketPull {
+		socketType = zmq.PULL
+	}
+
+	soc, err := zmq.NewSocket(socketType)
+	if err != nil {
+		return nil, err
+	}
+
+	err = sub.configure(soc)
+	if err != nil {
+		soc.Close()
+		return nil, err
+	}
+
+	for _, endpoint := range sub.endpoints {
+		if s.Bind {
+			err = soc.Bind(endpoint)
+		} else {
+			err = soc.Connect(endpoint)
+		}
+		if err != nil {
+			soc.Close()
+			return nil, fmt.Errorf("unable to use endpoint '%s': %v", endpoint, err)
+		}
+	}
+
+	return soc, nil
+}
+
+func (sub *subscriber) configure(soc *zmq.Socket) error {
+
+	s := sub.settings
+
+	err := soc.SetLinger(0)
+	if err != nil {
+		return err
+	}
+	// the receives time out to check if the trigger is stopped
+	err = soc.SetRcvtimeo(receiveTimeout)
+	if err != nil {
+		return err
+	}
+	if s.ReceiveHWM > 0 {
+		err = soc.SetRcvhwm(s.ReceiveHWM)
+		if err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case s.Bind && sub.keys.CurveSecretKey != "":
+		err = soc.ServerAuthCurve(authDomain, sub.keys.CurveSecretKey)
+	case !s.Bind && s.CurveServerKey != "":
+		publicKey, secretKey := sub.keys.CurvePublicKey, sub.keys.CurveSecretKey
+		if publicKey == "" {
+			publicKey, secretKey, err = zmq.NewCurveKeypair()
+			if err != nil {
+				return err
+			}
+		}
+		err = soc.ClientAuthCurve(s.CurveServerKey, publicKey, secretKey)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to set CURVE security: %v", err)
+	}
+
+	if s.SocketType == SocketSub {
+		topics := sub.topics
+		if len(topics) == 0 {
+			topics = []string{""}
+		}
+		for _, topic := range topics {
+			err = soc.SetSubscribe(topic)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// run receives the messages until the context is done, the messages being handled are completed
+func (sub *subscriber) run(ctx context.Context) {
+
+	defer close(sub.done)
+
+	for ctx.Err() == nil {
+		err := sub.receive(ctx)
+		if err == nil {
+			return
+		}
+		sub.logger.Errorf("Error receiving messages of '%s': %v", sub.settings.Endpoints, err)
+		select {
+		case <-ctx.Done():
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+// receive receives the messages of the socket, and dispatches them to the workers
+func (sub *subscriber) receive(ctx context.Context) error {
+
+	soc, err := sub.open()
+	if err != nil {
+		return err
+	}
+	defer soc.Close()
+
+	sub.logger.Infof("Receiving messages of '%s'", sub.settings.Endpoints)
+
+	messages := make(chan [][]byte)
+	var wg sync.WaitGroup
+	for i := 0; i < sub.settings.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for frames := range messages {
+				sub.handle(frames)
+			}
+		}()
+	}
+	defer func() {
+		close(messages)
+		wg.Wait()
+	}()
+
+	for ctx.Err() == nil {
+		frames, err := soc.RecvMessageBytes(0)
+		if err != nil {
+			switch zmq.AsErrno(err) {
+			case zmq.Errno(syscall.EAGAIN), zmq.Errno(syscall.EINTR):
+				continue
+			}
+			return err
+		}
+		messages <- frames
+	}
+
+	return nil
+}
+
+// wait waits for the subscriber to stop, at most for the timeout
+func (sub *subscriber) wait(timeout time.Duration) {
+
+	if sub.done == nil {
+		return
+	}
+
+	select {
+	case <-sub.done:
+	case <-time.After(timeout):
+		sub.logger.Warnf("Messages of '%s' still being handled", sub.settings.Endpoints)
+	}
+}
+
+// handle invokes the action for a message, a failed message is lost since ZeroMQ doesn't redeliver
+func (sub *subscriber) handle(frames [][]byte) {
+
+	_, err := sub.handler.Handle(context.Background(), sub.toOutput(frames))
+	if err != nil {
+		sub.logger.Errorf("Error handling message of '%s': %v", sub.settings.Endpoints, err)
+	}
+}
+
+func (sub *subscriber) toOutput(frames [][]byte) *Output {
+
+	out := &Output{Frames: make([]interface{}, 0, len(frames))}
+	for _, frame := range frames {
+		out.Frames = append(out.Frames, string(frame))
+	}
+	if len(frames) == 0 {
+		return out
+	}
+
+	// the longest topic, when several match
+	for _, topic := range sub.topics {
+		if strings.HasPrefix(string(frames[0]), topic) && len(topic) > len(out.Topic) {
+			out.Topic = topic
+		}
+	}
+
+	out.Payload = string(frames[len(frames)-1])
+
+	var content interface{}
+	if err := json.Unmarshal(frames[len(frames)-1], &content); err == nil {
+		out.Content = content
+	}
+
+	return out
+}
+
+func splitList(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
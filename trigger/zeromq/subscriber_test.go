@@ -0,0 +1,216 @@
+package zeromq
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"flogo/core/api"
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+	zmq "github.com/pebbe/zmq4"
+	"github.com/stretchr/testify/assert"
+)
+
+// collect returns a handler function recording the outputs it handles, it fails for the payloads of the
+// failures, and a function returning the outputs
+func collect(failures map[string]bool) (api.HandlerFunc, func() []*Output) {
+	var mu sync.Mutex
+	var outputs []*Output
+
+	f := func(ctx context.Context, inputs map[string]interface{}) (map[string]interface{}, error) {
+		out := &Output{}
+		if err := out.FromMap(inputs); err != nil {
+			return nil, err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		outputs = append(outputs, out)
+		if failures[out.Payload] {
+			return nil, errors.New("failed")
+		}
+		return nil, nil
+	}
+	handled := func() []*Output {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return append([]*Output(nil), outputs...)
+	}
+
+	return f, handled
+}
+
+func payloads(outputs []*Output) []string {
+	var payloads []string
+	for _, out := range outputs {
+		payloads = append(payloads, out.Payload)
+	}
+	return payloads
+}
+
+// testSocket returns its messages, then its error, then times out
+type testSocket struct {
+	messages [][][]byte
+	err      error
+	closed   bool
+}
+
+func (s *testSocket) RecvMessageBytes(flags zmq.Flag) ([][]byte, error) {
+	if len(s.messages) > 0 {
+		msg := s.messages[0]
+		s.messages = s.messages[1:]
+		return msg, nil
+	}
+	if s.err != nil {
+		err := s.err
+		s.err = nil
+		return nil, err
+	}
+	time.Sleep(10 * time.Millisecond)
+	return nil, zmq.Errno(syscall.EAGAIN)
+}
+
+func (s *testSocket) Close() error {
+	s.closed = true
+	return nil
+}
+
+func frames(values ...string) [][]byte {
+	var msg [][]byte
+	for _, v := range values {
+		msg = append(msg, []byte(v))
+	}
+	return msg
+}
+
+func TestSubscriber(t *testing.T) {
+
+	f, handled := collect(map[string]bool{"oops": true})
+	tgr, err := initTrigger(nil, map[string]interface{}{"endpoints": "tcp://localhost:5556", "topics": "weather,weather.paris"}, f)
+	assert.Nil(t, err)
+	sub := tgr.subscribers[0]
+
+	// the socket is opened again when it fails
+	sockets := []*testSocket{
+		{messages: [][][]byte{frames("weather.paris", `{"temperature": 21.5}`), frames("weather.lyon 19")}, err: errors.New("closed")},
+		{messages: [][][]byte{frames("weather", "oops"), frames("weather", "rain")}},
+	}
+	opened := 0
+	var mu sync.Mutex
+	sub.open = func() (socket, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if opened == len(sockets) {
+			return nil, errors.New("no socket")
+		}
+		opened++
+		return sockets[opened-1], nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sub.done = make(chan struct{})
+	go sub.run(ctx)
+
+	// the second socket is opened after the retry interval
+	for i := 0; i < 700 && len(handled()) < 4; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	outputs := handled()
+	assert.Equal(t, []string{`{"temperature": 21.5}`, "weather.lyon 19", "oops", "rain"}, payloads(outputs))
+
+	cancel()
+	sub.wait(time.Second)
+
+	assert.Equal(t, 2, opened)
+	assert.True(t, sockets[0].closed)
+	assert.True(t, sockets[1].closed)
+
+	out := outputs[0]
+	assert.Equal(t, "weather.paris", out.Topic)
+	assert.Equal(t, []interface{}{"weather.paris", `{"temperature": 21.5}`}, out.Frames)
+	assert.Equal(t, map[string]interface{}{"temperature": 21.5}, out.Content)
+
+	out = outputs[1]
+	assert.Equal(t, "weather", out.Topic)
+	assert.Equal(t, []interface{}{"weather.lyon 19"}, out.Frames)
+	assert.Nil(t, out.Content)
+}
+
+func TestSubscriber_Concurrency(t *testing.T) {
+
+	f, handled := collect(nil)
+	tgr, err := initTrigger(nil, map[string]interface{}{"socketType": SocketPull, "endpoints": "tcp://*:5557", "bind": true, "concurrency": 4}, f)
+	assert.Nil(t, err)
+	sub := tgr.subscribers[0]
+
+	soc := &testSocket{}
+	for i := 0; i < 100; i++ {
+		soc.messages = append(soc.messages, frames("job"))
+	}
+	sub.open = func() (socket, error) {
+		return soc, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sub.done = make(chan struct{})
+	go sub.run(ctx)
+
+	for i := 0; i < 100 && len(handled()) < 100; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Len(t, handled(), 100)
+
+	cancel()
+	sub.wait(time.Second)
+	assert.Equal(t, "", handled()[0].Topic)
+}
+
+func TestNewSubscriber(t *testing.T) {
+
+	tests := []struct {
+		settings *HandlerSettings
+		err      string
+	}{
+		{&HandlerSettings{Endpoints: " , "}, "an endpoint is required"},
+		{&HandlerSettings{Endpoints: "localhost:5556"}, "invalid endpoint 'localhost:5556'"},
+		{&HandlerSettings{SocketType: SocketPull, Endpoints: "tcp://localhost:5556", Topics: "weather"}, "topics are only supported by sub sockets"},
+		{&HandlerSettings{Endpoints: "tcp://*:5556", Bind: true, CurveServerKey: "rq:rM>}U?@Lns47E1%kR.o@n%FcmmsL/@{H8]yf7"}, "a server key is only supported by the sockets that connect"},
+		{&HandlerSettings{Endpoints: "tcp://localhost:5556", CurveServerKey: "rq:rM>}U?@Lns47E1%kR"}, "invalid CURVE server key"},
+		{&HandlerSettings{Endpoints: "tcp://localhost:5556,ipc:///tmp/feed", CurveServerKey: "rq:rM>}U?@Lns47E1%kR.o@n%FcmmsL/@{H8]yf7"}, ""},
+	}
+
+	for _, test := range tests {
+		sub, err := newSubscriber(nil, test.settings, &Settings{}, log.RootLogger())
+		if test.err == "" {
+			assert.Nil(t, err)
+			assert.Equal(t, SocketSub, sub.settings.SocketType)
+			assert.Equal(t, 1, sub.settings.Concurrency)
+			assert.Equal(t, []string{"tcp://localhost:5556", "ipc:///tmp/feed"}, sub.endpoints)
+		} else if assert.NotNil(t, err) {
+			assert.Equal(t, test.err, err.Error())
+		}
+	}
+}
+
+func TestFactory_New(t *testing.T) {
+
+	f := &Factory{}
+
+	_, err := f.New(&trigger.Config{Settings: map[string]interface{}{"curvePublicKey": "Yne@$w-vo<fVvi]a<NY6T1ed:M$fCG*[IaLV{hID"}})
+	assert.NotNil(t, err)
+	_, err = f.New(&trigger.Config{Settings: map[string]interface{}{"curvePublicKey": "Yne@$w-vo<fVvi]a<NY6T1ed:M$fCG*[IaLV{hID", "curveSecretKey": "D:)Q[IlAW!ahhC2ac:9*A}h:p?([4%wOTJ%JR%cs"}})
+	assert.Nil(t, err)
+	_, err = f.New(&trigger.Config{Settings: map[string]interface{}{"curveClientKeys": "Yne@$w-vo<fVvi]a<NY6T1ed:M$fCG*[IaLV{hID,short"}})
+	assert.NotNil(t, err)
+
+	handle, _ := collect(nil)
+	tgr, err := initTrigger(nil, map[string]interface{}{"endpoints": "tcp://localhost:5556"}, handle)
+	assert.Nil(t, err)
+	assert.Len(t, tgr.subscribers, 1)
+}
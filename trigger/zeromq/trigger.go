@@ -0,0 +1,152 @@
+package zeromq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"flogo/core/data/metadata"
+	"flogo/core/support/log"
+	"flogo/core/trigger"
+	zmq "github.com/pebbe/zmq4"
+)
+
+var triggerMd = trigger.NewMetadata(&Settings{}, &HandlerSettings{}, &Output{})
+
+var (
+	authMu      sync.Mutex
+	authStarted bool
+)
+
+func init() {
+	_ = trigger.Register(&Trigger{}, &Factory{})
+}
+
+type Factory struct {
+}
+
+// Metadata implements trigger.Factory.Metadata
+func (*Factory) Metadata() *trigger.Metadata {
+	return triggerMd
+}
+
+// New implements trigger.Factory.New
+func (*Factory) New(config *trigger.Config) (trigger.Trigger, error) {
+
+	s := &Settings{}
+	err := metadata.MapToStruct(config.Settings, s, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if (s.CurvePublicKey == "") != (s.CurveSecretKey == "") {
+		return nil, errors.New("both CURVE public key and secret key must be specified")
+	}
+	if s.CurvePublicKey != "" && (len(s.CurvePublicKey) != keyLength || len(s.CurveSecretKey) != keyLength) {
+		return nil, errors.New("invalid CURVE key pair")
+	}
+	for _, key := range splitList(s.CurveClientKeys) {
+		if len(key) != keyLength {
+			return nil, fmt.Errorf("invalid CURVE client key '%s'", key)
+		}
+	}
+
+	return &Trigger{settings: s}, nil
+}
+
+// Trigger receives the messages of ZeroMQ sub and pull sockets
+type Trigger struct {
+	settings    *Settings
+	logger      log.Logger
+	subscribers []*subscriber
+
+	cancel context.CancelFunc
+	auth   bool
+}
+
+// Initialize implements trigger.Init.Initialize
+func (t *Trigger) Initialize(ctx trigger.InitContext) error {
+
+	t.logger = ctx.Logger()
+
+	for _, handler := range ctx.GetHandlers() {
+
+		s := &HandlerSettings{}
+		err := metadata.MapToStruct(handler.Settings(), s, true)
+		if err != nil {
+			return err
+		}
+
+		sub, err := newSubscriber(handler, s, t.settings, t.logger)
+		if err != nil {
+			return err
+		}
+		t.subscribers = append(t.subscribers, sub)
+	}
+
+	return nil
+}
+
+// Start implements util.Managed.Start
+func (t *Trigger) Start() error {
+
+	// the clients of the sockets that bind are authenticated when their keys are specified,
+	// otherwise all the clients knowing the public key of the trigger can connect
+	if keys := splitList(t.settings.CurveClientKeys); len(keys) > 0 && t.settings.CurveSecretKey != "" {
+		err := startAuth()
+		if err != nil {
+			return err
+		}
+		zmq.AuthCurveAdd(authDomain, keys...)
+		t.auth = true
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+
+	for _, sub := range t.subscribers {
+		sub.done = make(chan struct{})
+		go sub.run(ctx)
+	}
+
+	return nil
+}
+
+// Stop implements util.Managed.Stop
+func (t *Trigger) Stop() error {
+
+	if t.cancel != nil {
+		t.cancel()
+		t.cancel = nil
+	}
+
+	for _, sub := range t.subscribers {
+		sub.wait(30 * time.Second)
+	}
+
+	if t.auth {
+		zmq.AuthCurveRemove(authDomain, splitList(t.settings.CurveClientKeys)...)
+		t.auth = false
+	}
+
+	return nil
+}
+
+// startAuth starts the authentication once, it is never stopped since the ZeroMQ library doesn't release
+// the socket of its handler, so that a trigger that restarts could not authenticate its clients anymore
+func startAuth() error {
+	authMu.Lock()
+	defer authMu.Unlock()
+
+	if authStarted {
+		return nil
+	}
+	err := zmq.AuthStart()
+	if err != nil {
+		return err
+	}
+	authStarted = true
+	return nil
+}
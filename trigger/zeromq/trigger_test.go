@@ -0,0 +1,203 @@
+package zeromq
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"flogo/core/action"
+	"flogo/core/api"
+	"flogo/core/support/test"
+	"flogo/core/trigger"
+	zmq "github.com/pebbe/zmq4"
+	"github.com/stretchr/testify/assert"
+)
+
+const testConfig string = `{
+	"id": "flogo-zeromq",
+	"ref": "github.com/qingcloudhx/contrib/trigger/zeromq",
+	"settings": {
+		"curveSecretKey": "D:)Q[IlAW!ahhC2ac:9*A}h:p?([4%wOTJ%JR%cs",
+		"curvePublicKey": "Yne@$w-vo<fVvi]a<NY6T1ed:M$fCG*[IaLV{hID"
+	},
+	"handlers": [
+		{
+			"action": {
+				"id": "dummy"
+			},
+			"settings": {
+				"endpoints": "tcp://localhost:5556",
+				"topics": "weather"
+			}
+		}
+	]
+}`
+
+// the key pair of a client allowed to connect, the key pair of the trigger being the one of testConfig
+const (
+	clientPublicKey = "rq:rM>}U?@Lns47E1%kR.o@n%FcmmsL/@{H8]yf7"
+	clientSecretKey = "JTKVSB%%)wK0E.X)V>+}o?pNmC{O&4W4b!Ni{Lh6"
+)
+
+// freeEndpoint returns a TCP endpoint of the loopback interface nothing listens on
+func freeEndpoint(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer l.Close()
+	return fmt.Sprintf("tcp://%s", l.Addr().String())
+}
+
+// waitFor waits until the number of messages are handled
+func waitFor(handled func() []*Output, count int) {
+	for i := 0; i < 500 && len(handled()) < count; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// initTrigger returns a trigger initialized with the settings and a handler of the settings running the function,
+// without handler if its settings are nil
+func initTrigger(settings, handlerSettings map[string]interface{}, f api.HandlerFunc) (*Trigger, error) {
+
+	config := &trigger.Config{}
+	if err := json.Unmarshal([]byte(testConfig), config); err != nil {
+		return nil, err
+	}
+	config.Settings = settings
+	if handlerSettings == nil {
+		config.Handlers = nil
+	} else {
+		config.Handlers[0].Settings = handlerSettings
+	}
+
+	trg, err := test.InitTrigger(&Factory{}, config, map[string]action.Action{"dummy": api.NewProxyAction(f)})
+	if err != nil {
+		return nil, err
+	}
+	return trg.(*Trigger), nil
+}
+
+func TestZeroMQTrigger_Initialize(t *testing.T) {
+	f := &Factory{}
+
+	config := &trigger.Config{}
+	err := json.Unmarshal([]byte(testConfig), config)
+	assert.Nil(t, err)
+
+	actions := map[string]action.Action{"dummy": test.NewDummyAction(func() {
+		//do nothing
+	})}
+
+	trg, err := test.InitTrigger(f, config, actions)
+	assert.Nil(t, err)
+	tgr := trg.(*Trigger)
+	assert.Len(t, tgr.subscribers, 1)
+	assert.Equal(t, SocketSub, tgr.subscribers[0].settings.SocketType)
+	assert.Equal(t, []string{"weather"}, tgr.subscribers[0].topics)
+	assert.Equal(t, "Yne@$w-vo<fVvi]a<NY6T1ed:M$fCG*[IaLV{hID", tgr.subscribers[0].keys.CurvePublicKey)
+
+	// the topics are only supported by sub sockets
+	config.Handlers[0].Settings["socketType"] = SocketPull
+	_, err = test.InitTrigger(f, config, actions)
+	assert.NotNil(t, err)
+}
+
+func TestTrigger_StartStop(t *testing.T) {
+
+	endpoint := freeEndpoint(t)
+	f, handled := collect(nil)
+	tgr, err := initTrigger(nil, map[string]interface{}{"socketType": SocketPull, "endpoints": endpoint, "bind": true}, f)
+	assert.Nil(t, err)
+	assert.Nil(t, tgr.Start())
+
+	push, err := zmq.NewSocket(zmq.PUSH)
+	assert.Nil(t, err)
+	defer push.Close()
+	assert.Nil(t, push.SetLinger(0))
+	assert.Nil(t, push.Connect(endpoint))
+	_, err = push.SendMessage("envelope", `{"id": 1}`)
+	assert.Nil(t, err)
+
+	waitFor(handled, 1)
+	outputs := handled()
+	assert.Equal(t, []string{`{"id": 1}`}, payloads(outputs))
+	assert.Equal(t, []interface{}{"envelope", `{"id": 1}`}, outputs[0].Frames)
+	assert.Equal(t, map[string]interface{}{"id": 1.0}, outputs[0].Content)
+
+	// the subscribers are stopped, and the trigger can be stopped again
+	assert.Nil(t, tgr.Stop())
+	select {
+	case <-tgr.subscribers[0].done:
+	default:
+		t.Fatal("subscriber still running")
+	}
+	assert.Nil(t, tgr.Stop())
+}
+
+func TestTrigger_Auth(t *testing.T) {
+
+	// the clients aren't authenticated when their keys aren't specified
+	tgr, err := initTrigger(nil, nil, nil)
+	assert.Nil(t, err)
+	assert.Nil(t, tgr.Start())
+	assert.False(t, tgr.auth)
+	assert.Nil(t, tgr.Stop())
+
+	// the authentication is started with the trigger, and its clients are authenticated again when it restarts
+	settings := map[string]interface{}{
+		"curvePublicKey":  "Yne@$w-vo<fVvi]a<NY6T1ed:M$fCG*[IaLV{hID",
+		"curveSecretKey":  "D:)Q[IlAW!ahhC2ac:9*A}h:p?([4%wOTJ%JR%cs",
+		"curveClientKeys": clientPublicKey,
+	}
+	for i := 0; i < 2; i++ {
+		tgr, err = initTrigger(settings, nil, nil)
+		assert.Nil(t, err)
+		assert.Nil(t, tgr.Start())
+		assert.True(t, tgr.auth)
+		assert.Nil(t, tgr.Stop())
+		assert.False(t, tgr.auth)
+	}
+}
+
+func TestTrigger_Curve(t *testing.T) {
+
+	if !zmq.HasCurve() {
+		t.Skip("libzmq built without CURVE security")
+	}
+
+	serverPublicKey, serverSecretKey, err := zmq.NewCurveKeypair()
+	assert.Nil(t, err)
+
+	endpoint := freeEndpoint(t)
+	f, handled := collect(nil)
+	tgr, err := initTrigger(map[string]interface{}{
+		"curvePublicKey":  serverPublicKey,
+		"curveSecretKey":  serverSecretKey,
+		"curveClientKeys": clientPublicKey,
+	}, map[string]interface{}{"socketType": SocketPull, "endpoints": endpoint, "bind": true}, f)
+	assert.Nil(t, err)
+	assert.Nil(t, tgr.Start())
+	defer tgr.Stop()
+
+	send := func(publicKey, secretKey, payload string) {
+		push, err := zmq.NewSocket(zmq.PUSH)
+		assert.Nil(t, err)
+		defer push.Close()
+		assert.Nil(t, push.SetLinger(time.Second))
+		assert.Nil(t, push.ClientAuthCurve(serverPublicKey, publicKey, secretKey))
+		assert.Nil(t, push.Connect(endpoint))
+		_, err = push.SendMessage(payload)
+		assert.Nil(t, err)
+	}
+
+	// the messages of an unknown client are never received
+	unknownPublicKey, unknownSecretKey, err := zmq.NewCurveKeypair()
+	assert.Nil(t, err)
+	send(unknownPublicKey, unknownSecretKey, "denied")
+	send(clientPublicKey, clientSecretKey, "allowed")
+
+	waitFor(handled, 1)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, []string{"allowed"}, payloads(handled()))
+}